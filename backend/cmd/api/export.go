@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"mediawork/internal/config"
+	"mediawork/internal/db"
+	"mediawork/internal/repositories"
+	"mediawork/internal/services"
+)
+
+// exportAccounting — выгрузка в бухгалтерию из командной строки (для cron у финансов):
+//
+//	api export-accounting -format csv -incremental -out export.zip
+//	api export-accounting -format xml -from 2024-01-01 -to 2024-01-31
+//	api export-accounting -id 42 -format json   # повтор выгрузки
+func exportAccounting(args []string) error {
+	fs := flag.NewFlagSet("export-accounting", flag.ContinueOnError)
+	format := fs.String("format", services.ExportCSV, "csv | json | xml")
+	incremental := fs.Bool("incremental", false, "everything since the last incremental export")
+	from := fs.String("from", "", "period start, YYYY-MM-DD (inclusive)")
+	to := fs.String("to", "", "period end, YYYY-MM-DD (inclusive)")
+	id := fs.Int64("id", 0, "re-download an existing export")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load("")
+	if err != nil {
+		return err
+	}
+	if cfg.Demo {
+		return errDemoMode
+	}
+	sqlDB, err := db.Open(context.Background(), cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	auditSvc := services.NewAuditService(repositories.NewAuditRepository(sqlDB))
+	svc := services.NewAccountingExportService(repositories.NewInvoiceRepository(sqlDB), auditSvc)
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	ctx := context.Background()
+	if *id != 0 {
+		_, err := svc.Redownload(ctx, *id, *format, w)
+		return err
+	}
+
+	periodFrom, periodTo, err := services.ParseExportPeriod(*from, *to)
+	if err != nil {
+		return err
+	}
+	e, err := svc.Export(ctx, services.ExportRequest{
+		Format:      *format,
+		Incremental: *incremental,
+		PeriodFrom:  periodFrom,
+		PeriodTo:    periodTo,
+	}, w)
+	if e != nil {
+		fmt.Fprintf(os.Stderr, "export %d: %d documents, %d payments\n", e.ID, e.InvoiceCount, e.PaymentCount)
+	}
+	return err
+}
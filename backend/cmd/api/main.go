@@ -1,29 +1,215 @@
 package main
 
 import (
+    "context"
+    "crypto/tls"
+    "errors"
+    "fmt"
     "log"
+    "log/slog"
+    "net"
     "net/http"
+    "os"
+    "os/signal"
+    "syscall"
+    "time"
 
     // подстрой под свой модуль
     "mediawork/internal/api"
+    "mediawork/internal/config"
     "mediawork/internal/db"
+    "mediawork/internal/health"
+    "mediawork/internal/logging"
+    "mediawork/internal/metrics"
+    "mediawork/internal/repositories/memory"
+    "mediawork/internal/services"
+    "mediawork/internal/tracing"
 )
 
 func main() {
-    // init DB (если ты уже делаешь это в api.NewRouter, можешь убрать отсюда)
-    if err := db.Init(); err != nil {
-        log.Fatalf("db init error: %v", err)
+    // подкоманды: api export-accounting ... (см. export.go), api migrate ... (см. migrate.go)
+    if len(os.Args) > 1 {
+        switch os.Args[1] {
+        case "migrate":
+            if err := migrateCmd(os.Args[2:]); err != nil {
+                log.Fatalf("migrate: %v", err)
+            }
+            return
+        case "export-accounting":
+            if err := exportAccounting(os.Args[2:]); err != nil {
+                log.Fatalf("export-accounting: %v", err)
+            }
+            return
+        }
     }
-    defer db.DB.Close()
 
-    r, err := api.NewRouter()
+    // конфиг: CONFIG_FILE (JSON) + переменные окружения; с ошибками в конфиге не стартуем
+    cfg, err := config.Load("")
     if err != nil {
-        log.Fatalf("router init error: %v", err)
+        fatal("config", err)
     }
+    if err := serve(cfg); err != nil {
+        fatal("server error", err)
+    }
+}
 
-    addr := ":8080"
-    log.Printf("MediaWork backend listening on %s", addr)
-    if err := http.ListenAndServe(addr, r); err != nil {
-        log.Fatalf("server error: %v", err)
+// serve работает до SIGTERM / Ctrl+C, затем останавливается по порядку:
+//  1. /readyz отвечает 503, WebSocket-стримы получают close frame;
+//  2. HTTP перестаёт принимать соединения и ждёт текущие запросы (не дольше shutdown_timeout);
+//  3. планировщик дожидается текущих запусков задач, закрываются метрики и база.
+func serve(cfg *config.Config) error {
+    metricsSrv, err := setupObservability(cfg.Observability)
+    if err != nil {
+        return fmt.Errorf("observability: %w", err)
     }
+    if metricsSrv != nil {
+        defer metricsSrv.Close()
+    }
+
+    hc := health.New()
+    repos, closeRepos, err := openRepositories(context.Background(), cfg, hc)
+    if err != nil {
+        return fmt.Errorf("storage init: %w", err)
+    }
+    defer closeRepos()
+
+    r, bg, err := api.NewRouter(cfg, repos, hc)
+    if err != nil {
+        return fmt.Errorf("router init: %w", err)
+    }
+
+    // фоновые задачи: rollups, архивирование и чистка play_history / heartbeat.
+    // Контекст не от сигнала: задачи останавливаются после HTTP, а не вместе с ним.
+    bg.Start(context.Background())
+    defer bg.Stop()
+
+    s := cfg.Server
+    srv := &http.Server{
+        Addr:              s.Addr,
+        Handler:           r,
+        TLSConfig:         &tls.Config{MinVersion: s.TLS.Version()},
+        ReadHeaderTimeout: s.ReadHeaderTimeout.D(),
+        ReadTimeout:       s.ReadTimeout.D(),
+        WriteTimeout:      s.WriteTimeout.D(),
+        IdleTimeout:       s.IdleTimeout.D(),
+        ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+    }
+    // hijacked-соединения (WebSocket) Shutdown не закрывает — закрываем сами
+    srv.RegisterOnShutdown(bg.CloseStreams)
+
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+    defer stop()
+
+    serveErr := make(chan error, 1)
+    go func() {
+        slog.Info("MediaWork backend listening", "addr", srv.Addr, "tls", s.TLS.Enabled())
+        if s.TLS.Enabled() {
+            serveErr <- srv.ListenAndServeTLS(s.TLS.CertFile, s.TLS.KeyFile)
+        } else {
+            serveErr <- srv.ListenAndServe()
+        }
+    }()
+
+    select {
+    case err := <-serveErr:
+        return err // порт занят, нет сертификата и т.п.
+    case <-ctx.Done():
+    }
+    stop() // повторный сигнал — немедленный выход
+
+    slog.Info("shutting down", "timeout", s.ShutdownTimeout.D().String())
+    hc.Drain()
+
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout.D())
+    defer cancel()
+    if err := srv.Shutdown(shutdownCtx); err != nil {
+        slog.Warn("shutdown: requests did not finish in time, closing connections", "err", err)
+        srv.Close()
+    }
+    slog.Info("HTTP server stopped")
+    return nil
+}
+
+// fatal — ошибка старта сервера: в лог в выбранном формате и выход
+func fatal(msg string, err error) {
+    slog.Error(msg, "err", err)
+    os.Exit(1)
+}
+
+// setupObservability — формат и уровень логов, экспортёр спанов и /metrics на отдельном адресе
+// (порт занят — не стартуем, чтобы не остаться без метрик незаметно). Сервер метрик — nil, если выключен.
+func setupObservability(cfg config.Observability) (*http.Server, error) {
+    if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+        return nil, err
+    }
+    if cfg.TraceExporter == "log" {
+        tracing.SetExporter(tracing.LogExporter{})
+    }
+
+    if cfg.MetricsAddr == "" {
+        return nil, nil
+    }
+    ln, err := net.Listen("tcp", cfg.MetricsAddr)
+    if err != nil {
+        return nil, fmt.Errorf("metrics: %w", err)
+    }
+    mux := http.NewServeMux()
+    mux.Handle("GET /metrics", metrics.Handler())
+    srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+    go func() {
+        if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+            slog.Error("metrics server stopped", "err", err)
+        }
+    }()
+    slog.Info("metrics listening", "addr", ln.Addr().String())
+    return srv, nil
+}
+
+// errDemoMode — подкоманды работают с базой, а в демо-режиме её нет
+var errDemoMode = errors.New("not available in demo mode (DEMO_MODE): there is no database")
+
+// openRepositories — PostgreSQL (со сверкой схемы) или, в демо-режиме, хранилища в памяти с демо-данными;
+// и проверками готовности базы в hc
+func openRepositories(ctx context.Context, cfg *config.Config, hc *health.Checker) (services.Repositories, func(), error) {
+    if cfg.Demo {
+        store := memory.NewStore()
+        if err := memory.Seed(ctx, store); err != nil {
+            return services.Repositories{}, nil, fmt.Errorf("demo seed: %w", err)
+        }
+        // демо-учётка известна заранее, поэтому её не прячем (ключи demo_* не маскируются)
+        slog.Warn("DEMO MODE: data is kept in memory and lost on restart",
+            "demo_login", memory.DemoAdminEmail, "demo_password", memory.DemoAdminPassword)
+        return store.Repositories(), func() {}, nil
+    }
+
+    sqlDB, err := db.Open(ctx, cfg.Database)
+    if err != nil {
+        return services.Repositories{}, nil, err
+    }
+    db.RegisterPoolMetrics(sqlDB)
+
+    // схема: либо догоняем миграциями сразу, либо не стартуем с отставшей базой
+    if cfg.Database.AutoMigrate {
+        if _, err := db.MigrateUp(ctx, sqlDB); err != nil {
+            sqlDB.Close()
+            return services.Repositories{}, nil, fmt.Errorf("migrate: %w", err)
+        }
+    } else if err := db.CheckSchema(ctx, sqlDB); err != nil {
+        sqlDB.Close()
+        return services.Repositories{}, nil, fmt.Errorf("schema check: %w", err)
+    }
+
+    hc.Add("database", func(ctx context.Context) (any, error) {
+        return nil, sqlDB.PingContext(ctx)
+    })
+    // отставшая схема — другой инстанс ещё мигрирует или откатили миграцию: трафик не принимаем
+    hc.Add("migrations", func(ctx context.Context) (any, error) {
+        applied, latest, err := db.SchemaVersion(ctx, sqlDB)
+        if err == nil && applied < latest {
+            err = fmt.Errorf("%w: version %d, build expects %d", db.ErrSchemaBehind, applied, latest)
+        }
+        return map[string]int64{"version": applied, "latest": latest}, err
+    })
+
+    return api.PostgresRepositories(sqlDB), func() { sqlDB.Close() }, nil
 }
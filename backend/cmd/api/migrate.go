@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"mediawork/internal/config"
+	"mediawork/internal/db"
+)
+
+// migrateCmd — миграции схемы из командной строки:
+//
+//	api migrate up            # применить все новые
+//	api migrate down -steps 1 # откатить последнюю
+//	api migrate status
+func migrateCmd(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: api migrate up | down [-steps N] | status")
+	}
+	cmd := args[0]
+
+	fs := flag.NewFlagSet("migrate "+cmd, flag.ContinueOnError)
+	steps := fs.Int("steps", 1, "how many migrations to roll back (down)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load("")
+	if err != nil {
+		return err
+	}
+	if cfg.Demo {
+		return errDemoMode
+	}
+	ctx := context.Background()
+	sqlDB, err := db.Open(ctx, cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	switch cmd {
+	case "up":
+		done, err := db.MigrateUp(ctx, sqlDB)
+		if err == nil && len(done) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return err
+
+	case "down":
+		_, err := db.MigrateDown(ctx, sqlDB, *steps)
+		return err
+
+	case "status":
+		status, err := db.MigrationsStatus(ctx, sqlDB)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, st := range status {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", st.Version, st.Name, applied)
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown migrate command %q", cmd)
+}
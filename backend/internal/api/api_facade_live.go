@@ -3,20 +3,34 @@ package api
 import (
     "context"
     "encoding/json"
-    "log"
+    "log/slog"
     "net/http"
 
     "github.com/gorilla/websocket"
+    "mediawork/internal/handlers"
     "mediawork/internal/services"
+    "mediawork/internal/ws"
     "strconv"
 )
 
 type FacadeLiveHandler struct {
     service *services.FacadeService
+    conns   *ws.Conns
+
+    // ctx стримов: отменяется в Close, иначе стрим без новых кадров висел бы после остановки
+    ctx    context.Context
+    cancel context.CancelFunc
 }
 
 func NewFacadeLiveHandler(s *services.FacadeService) *FacadeLiveHandler {
-    return &FacadeLiveHandler{service: s}
+    ctx, cancel := context.WithCancel(context.Background())
+    return &FacadeLiveHandler{service: s, conns: ws.NewConns("facade_live"), ctx: ctx, cancel: cancel}
+}
+
+// Close — остановка сервера: стримы получают close frame 1001 и завершаются
+func (h *FacadeLiveHandler) Close() {
+    h.cancel()
+    h.conns.CloseAll()
 }
 
 var upgrader = websocket.Upgrader{
@@ -29,19 +43,25 @@ func (h *FacadeLiveHandler) Stream(w http.ResponseWriter, r *http.Request) {
     idStr := r.PathValue("id")
     facadeID, err := strconv.ParseInt(idStr, 10, 64)
     if err != nil {
-        http.Error(w, "invalid facade id", 400)
+        handlers.Error(w, r, http.StatusBadRequest, "invalid facade id")
         return
     }
 
     // UPGRADING CONNECTION
     conn, err := upgrader.Upgrade(w, r, nil)
     if err != nil {
-        log.Println("WS upgrade error:", err)
+        slog.WarnContext(r.Context(), "ws: upgrade failed", "facade_id", facadeID, "err", err)
         return
     }
     defer conn.Close()
+    ws.ClearDeadlines(conn)
 
-    ctx, cancel := context.WithCancel(context.Background())
+    if !h.conns.Add(conn) {
+        return
+    }
+    defer h.conns.Remove(conn)
+
+    ctx, cancel := context.WithCancel(h.ctx)
     defer cancel()
 
     frames := h.service.StreamLiveFrames(ctx, facadeID)
@@ -55,7 +75,7 @@ func (h *FacadeLiveHandler) Stream(w http.ResponseWriter, r *http.Request) {
         b, _ := json.Marshal(payload)
 
         if err := conn.WriteMessage(websocket.TextMessage, b); err != nil {
-            log.Println("WS write error:", err)
+            slog.InfoContext(r.Context(), "ws: stream closed", "facade_id", facadeID, "err", err)
             return
         }
     }
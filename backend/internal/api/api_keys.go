@@ -0,0 +1,35 @@
+package api
+
+import (
+	"mediawork/internal/handlers"
+	"mediawork/internal/services"
+)
+
+// apiKeyRoutes — что доступно по API-ключу компании. Всего, чего нет в списке
+// (админка, участники, фасады, биллинг на запись и т.д.), ключ не видит.
+func apiKeyRoutes(campaigns *services.CampaignService, billing *services.BillingService) []handlers.APIKeyRoute {
+	return []handlers.APIKeyRoute{
+		// кампании (списки отфильтрованы по компании ключа в хендлерах)
+		{Pattern: "GET /api/campaigns", Scope: services.ScopeCampaignsRead},
+		{Pattern: "GET /api/campaigns/{$}", Scope: services.ScopeCampaignsRead},
+		{Pattern: "GET /api/campaigns/{id}", Scope: services.ScopeCampaignsRead, Owner: campaigns.CompanyOf},
+		{Pattern: "GET /api/campaigns/{id}/targets", Scope: services.ScopeCampaignsRead, Owner: campaigns.CompanyOf},
+		{Pattern: "GET /api/campaigns/{id}/budget", Scope: services.ScopeCampaignsRead, Owner: campaigns.CompanyOf},
+		{Pattern: "POST /api/campaigns", Scope: services.ScopeCampaignsWrite},
+		{Pattern: "POST /api/campaigns/{$}", Scope: services.ScopeCampaignsWrite},
+		{Pattern: "PUT /api/campaigns/{id}", Scope: services.ScopeCampaignsWrite, Owner: campaigns.CompanyOf},
+
+		// аналитика доставки
+		{Pattern: "GET /api/analytics/campaigns/{id}/delivery", Scope: services.ScopeAnalyticsRead, Owner: campaigns.CompanyOf},
+		{Pattern: "GET /api/analytics/campaigns/{id}/breakdown", Scope: services.ScopeAnalyticsRead, Owner: campaigns.CompanyOf},
+		{Pattern: "GET /api/analytics/campaigns/{id}/impressions", Scope: services.ScopeAnalyticsRead, Owner: campaigns.CompanyOf},
+
+		// счета (только чтение)
+		{Pattern: "GET /api/invoices", Scope: services.ScopeInvoicesRead},
+		{Pattern: "GET /api/invoices/{$}", Scope: services.ScopeInvoicesRead},
+		{Pattern: "GET /api/invoices/{id}", Scope: services.ScopeInvoicesRead, Owner: billing.CompanyOf},
+		{Pattern: "GET /api/invoices/{id}/pdf", Scope: services.ScopeInvoicesRead, Owner: billing.CompanyOf},
+		{Pattern: "GET /api/invoices/{id}/payments", Scope: services.ScopeInvoicesRead, Owner: billing.CompanyOf},
+		{Pattern: "GET /api/invoices/{id}/adjustments", Scope: services.ScopeInvoicesRead, Owner: billing.CompanyOf},
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mediawork/internal/repositories/memory"
+)
+
+// apiKey — новый ключ компании companyID со scopes; возвращает ключ и его id
+func apiKey(t *testing.T, handler http.Handler, token string, companyID int64, scopes string) (string, int64) {
+	t.Helper()
+	var k struct {
+		ID  int64  `json:"id"`
+		Key string `json:"key"`
+	}
+	body := call(t, handler, token, "POST", fmt.Sprintf("/api/companies/%d/api-keys", companyID),
+		`{"name":"automation","scopes":`+scopes+`}`, http.StatusCreated)
+	if err := json.Unmarshal(body, &k); err != nil || k.Key == "" {
+		t.Fatalf("create api key: %v %s", err, body)
+	}
+	return k.Key, k.ID
+}
+
+// TestAPIKeyScoping — ключ видит только маршруты своих scopes, только свою компанию
+// и перестаёт работать после отзыва
+func TestAPIKeyScoping(t *testing.T) {
+	handler := contractRouter(t)
+	admin := login(t, handler, memory.DemoAdminEmail, memory.DemoAdminPassword)
+	outsider, outsiderCompany := outsiderToken(t, handler)
+
+	read, _ := apiKey(t, handler, admin, 1, `["campaigns:read"]`)
+	write, writeID := apiKey(t, handler, admin, 1, `["campaigns:read","campaigns:write"]`)
+	foreign, _ := apiKey(t, handler, outsider, outsiderCompany, `["campaigns:read","campaigns:write","invoices:read"]`)
+
+	tests := []struct {
+		name, key, method, path, body string
+		want                          int
+	}{
+		{"read campaign", read, "GET", "/api/campaigns/1", "", http.StatusOK},
+		{"list campaigns", read, "GET", "/api/campaigns", "", http.StatusOK},
+		{"write without scope", read, "PUT", "/api/campaigns/1", `{"description":"by key"}`, http.StatusForbidden},
+		{"invoices without scope", read, "GET", "/api/invoices", "", http.StatusForbidden},
+		{"route not open to keys", read, "GET", "/api/facades", "", http.StatusForbidden},
+		{"keys cannot mint keys", write, "POST", "/api/companies/1/api-keys", `{"name":"x","scopes":["campaigns:read"]}`, http.StatusForbidden},
+		{"keys cannot manage members", write, "GET", "/api/companies/1/members", "", http.StatusForbidden},
+		{"write campaign", write, "PUT", "/api/campaigns/1", `{"description":"by key"}`, http.StatusOK},
+		{"budget is admin only", write, "PUT", "/api/campaigns/1/budget", `{"airtime_limit_sec":60}`, http.StatusForbidden},
+		{"foreign campaign", foreign, "GET", "/api/campaigns/1", "", http.StatusNotFound},
+		{"foreign campaign write", foreign, "PUT", "/api/campaigns/1", `{"status":"cancelled"}`, http.StatusNotFound},
+		{"foreign invoice", foreign, "GET", "/api/invoices/1", "", http.StatusNotFound},
+		{"unknown key", "mwk_000000000000000000000000000000000000000000000000", "GET", "/api/campaigns/1", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call(t, handler, tt.key, tt.method, tt.path, tt.body, tt.want)
+		})
+	}
+
+	// company_id из тела ключ не переопределяет: кампания создаётся в компании ключа
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	body := call(t, handler, foreign, "POST", "/api/campaigns", `{"campaign":{"company_id":1,"name":"Foreign"}}`, http.StatusCreated)
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Campaign struct {
+			CompanyID int64 `json:"company_id"`
+		} `json:"campaign"`
+	}
+	body = call(t, handler, foreign, "GET", fmt.Sprintf("/api/campaigns/%d", created.ID), "", http.StatusOK)
+	if err := json.Unmarshal(body, &got); err != nil || got.Campaign.CompanyID != outsiderCompany {
+		t.Errorf("campaign created by a key of company %d: %s", outsiderCompany, body)
+	}
+
+	// X-API-Key вместо Authorization
+	req := httptest.NewRequest("GET", "/api/campaigns/1", nil)
+	req.RemoteAddr = "192.0.2.7:1234"
+	req.Header.Set("X-API-Key", read)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("X-API-Key: %d %s", rec.Code, rec.Body)
+	}
+
+	call(t, handler, admin, "DELETE", fmt.Sprintf("/api/companies/1/api-keys/%d", writeID), "", http.StatusOK)
+	call(t, handler, write, "GET", "/api/campaigns/1", "", http.StatusUnauthorized)
+	call(t, handler, read, "GET", "/api/campaigns/1", "", http.StatusOK)
+}
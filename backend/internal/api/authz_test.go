@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Записи чужой компании: пользователь, зарегистрировавшийся сам и владеющий только своей
+// компанией B, не может менять фасады, группы и кампании демо-компании A.
+
+// outsiderToken — JWT такого пользователя (глобальная роль viewer, owner компании B) и id компании B
+func outsiderToken(t *testing.T, handler http.Handler) (string, int64) {
+	t.Helper()
+	call(t, handler, "", "POST", "/api/auth/register",
+		`{"email":"outsider@example.com","password":"outsider-password","name":"Outsider"}`, http.StatusCreated)
+	token := login(t, handler, "outsider@example.com", "outsider-password")
+
+	var company struct {
+		ID int64 `json:"id"`
+	}
+	body := call(t, handler, token, "POST", "/api/companies", `{"name":"Outsider LLC"}`, http.StatusCreated)
+	if err := json.Unmarshal(body, &company); err != nil || company.ID == 0 {
+		t.Fatalf("create company: %v %s", err, body)
+	}
+	return token, company.ID
+}
+
+// login — JWT пользователя
+func login(t *testing.T, handler http.Handler, email, password string) string {
+	t.Helper()
+	var resp struct {
+		Token string `json:"token"`
+	}
+	body := call(t, handler, "", "POST", "/api/auth/login",
+		`{"email":"`+email+`","password":"`+password+`"}`, http.StatusOK)
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Token == "" {
+		t.Fatalf("login: %v %s", err, body)
+	}
+	return resp.Token
+}
+
+// call — запрос с токеном; want > 0 — статус, без которого тест дальше не имеет смысла
+func call(t *testing.T, handler http.Handler, token, method, path, body string, want int) []byte {
+	t.Helper()
+	return callFrom(t, handler, "192.0.2.7", token, method, path, body, want)
+}
+
+// callFrom — то же с адреса клиента ip
+func callFrom(t *testing.T, handler http.Handler, ip, token, method, path, body string, want int) []byte {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.RemoteAddr = ip + ":1234"
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if want > 0 && rec.Code != want {
+		t.Fatalf("%s %s = %d %s, want %d", method, path, rec.Code, strings.TrimSpace(rec.Body.String()), want)
+	}
+	return rec.Body.Bytes()
+}
+
+func TestForeignTenantWrites(t *testing.T) {
+	handler := contractRouter(t)
+	token, _ := outsiderToken(t, handler)
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{"PUT", "/api/facades/1/audience", `{"timezone":"UTC","visibility_factor":1,"dwell_sec":1,"hours":[]}`},
+		{"PUT", "/api/facades/1/tags", `{"district":"center"}`},
+		{"POST", "/api/facade-groups", `{"name":"Outsider","filter":{"tags":{"district":["center"]}}}`},
+		{"PUT", "/api/facade-groups/1", `{"name":"Outsider","filter":{"tags":{"district":["center"]}}}`},
+		{"DELETE", "/api/facade-groups/1", ""},
+		{"POST", "/api/campaigns", `{"campaign":{"company_id":1,"name":"Outsider"}}`},
+		{"PUT", "/api/campaigns/1", `{"status":"cancelled"}`},
+		{"POST", "/api/campaigns/1/targets", `{"group_id":1}`},
+		{"DELETE", "/api/campaigns/1/targets/1", ""},
+		{"PUT", "/api/campaigns/1/budget", `{"airtime_limit_sec":0}`},
+		{"DELETE", "/api/campaigns/1/budget", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			call(t, handler, token, tt.method, tt.path, tt.body, http.StatusForbidden)
+		})
+	}
+}
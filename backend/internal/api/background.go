@@ -0,0 +1,30 @@
+package api
+
+import (
+	"context"
+
+	"mediawork/internal/jobs"
+)
+
+// Background — то, что работает помимо обработки запросов: планировщик задач
+// и открытые WebSocket-стримы кадров
+type Background struct {
+	Scheduler *jobs.Scheduler
+	live      *FacadeLiveHandler
+}
+
+func (b *Background) Start(ctx context.Context) {
+	b.Scheduler.Start(ctx)
+}
+
+// CloseStreams закрывает WebSocket-стримы с close frame. Вызывается в начале остановки:
+// http.Server.Shutdown не ждёт hijacked-соединения и не закрывает их.
+func (b *Background) CloseStreams() {
+	b.live.Close()
+}
+
+// Stop — после остановки HTTP: закрыть оставшиеся стримы и дождаться текущих запусков задач
+func (b *Background) Stop() {
+	b.CloseStreams()
+	b.Scheduler.Stop()
+}
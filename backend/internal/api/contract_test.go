@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mediawork/internal/config"
+	"mediawork/internal/health"
+	"mediawork/internal/logging"
+	"mediawork/internal/openapi"
+	"mediawork/internal/repositories/memory"
+)
+
+// Контракт API против internal/openapi/openapi.json на демо-данных в памяти (база не нужна):
+// каждый маршрут NewRouter описан в спецификации и наоборот; сценарий создаёт счёт, оплату,
+// приглашение, webhook, ключ и т.д., а после него вызываются все GET-операции спецификации.
+// Ответ каждого запроса сверяется со схемой: неописанный статус, лишнее или пропавшее поле,
+// не тот тип — ошибка.
+
+// contractRouter — роутер на свежем демо-хранилище. Часы хранилища — сегодня с 18:00 UTC:
+// показ сценария должен попасть в слот демо-кампании (будни 17–21, выходные 11–20).
+func contractRouter(t *testing.T) http.Handler {
+	t.Helper()
+	return testRouter(t, nil)
+}
+
+// testRouter — то же с поправками конфигурации configure (nil — конфигурация по умолчанию)
+func testRouter(t *testing.T, configure func(cfg *config.Config)) http.Handler {
+	t.Helper()
+	// access-лог каждого запроса не нужен: расхождения печатает t.Errorf
+	if err := logging.Setup(os.Stderr, "warn", "text"); err != nil {
+		t.Fatal(err)
+	}
+
+	store := memory.NewStore()
+	start := time.Now()
+	evening := start.UTC().Truncate(24 * time.Hour).Add(18 * time.Hour)
+	store.Now = func() time.Time { return evening.Add(time.Since(start)) }
+	if err := memory.Seed(context.Background(), store); err != nil {
+		t.Fatalf("demo seed: %v", err)
+	}
+
+	cfg := config.Defaults()
+	cfg.Demo = true
+	cfg.Auth.JWTSecret = strings.Repeat("contract-secret-", 4)
+	if configure != nil {
+		configure(&cfg)
+	}
+	handler, _, err := NewRouter(&cfg, store.Repositories(), health.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handler
+}
+
+func loadSpec(t *testing.T) *openapi.Document {
+	t.Helper()
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// TestContractRoutes — таблица маршрутов chi ↔ пути спецификации
+func TestContractRoutes(t *testing.T) {
+	doc := loadSpec(t)
+	handler := contractRouter(t)
+
+	var routes []string
+	if err := chi.Walk(handler.(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes = append(routes, method+" "+route)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) == 0 {
+		t.Fatal("router has no routes")
+	}
+
+	undocumented, missing := doc.CheckRoutes(routes)
+	for _, r := range undocumented {
+		t.Errorf("route %s is not in openapi.json", r)
+	}
+	for _, r := range missing {
+		t.Errorf("openapi.json describes %s, but the router has no such route", r)
+	}
+}
+
+// TestContractScenario — ответы сценария и всех GET-операций против схем спецификации
+func TestContractScenario(t *testing.T) {
+	c := &contractRun{t: t, doc: loadSpec(t), handler: contractRouter(t)}
+	c.scenario()
+}
+
+type contractRun struct {
+	t       *testing.T
+	doc     *openapi.Document
+	handler http.Handler
+
+	token  string // JWT демо-администратора
+	apiKey string
+	device string          // токен плеера фасада 1 для /api/live/*
+	called map[string]bool // операции, уже вызванные сценарием
+}
+
+// scenario — запросы с телами по порядку (каждый должен пройти успешно),
+// затем все GET-операции спецификации с параметрами пути из example
+func (c *contractRun) scenario() {
+	c.called = map[string]bool{}
+	now := time.Now().UTC().Truncate(time.Second)
+	day := now.Format("2006-01-02")
+	ts := now.Format(time.RFC3339)
+
+	c.must("POST", "/api/auth/register", `{"email":"contract@demo.local","password":"contract-password","name":"Contract Check"}`)
+	var login struct {
+		Token string `json:"token"`
+	}
+	c.decode(c.must("POST", "/api/auth/login",
+		fmt.Sprintf(`{"email":%q,"password":%q}`, memory.DemoAdminEmail, memory.DemoAdminPassword)), &login)
+	c.token = login.Token
+
+	// плеер фасада: токен устройства, затем телеметрия от его имени
+	var device struct {
+		Token string `json:"token"`
+	}
+	c.decode(c.must("POST", "/api/admin/facades/1/device-token", ""), &device)
+	c.device = device.Token
+	c.must("POST", "/api/live/heartbeat", `{"facade_id":1,"latency_ms":42}`)
+	c.must("POST", "/api/live/play-event", fmt.Sprintf(
+		`{"facade_id":1,"campaign_id":1,"slot_id":0,"media_url":"demo.mp4","played_at":%q,"duration_sec":15,"resolution_w":1920,"resolution_h":1080,"bitrate_kbps":8000,"sync_latency_ms":12}`, ts))
+
+	// биллинг
+	c.must("POST", "/api/invoices", fmt.Sprintf(
+		`{"company_id":1,"period_start":%q,"period_end":%q,"amount_total":1200,"status":"pending"}`,
+		now.AddDate(0, -1, 0).Format(time.RFC3339), ts))
+	c.must("POST", "/api/invoices/1/payments", fmt.Sprintf(`{"amount":200,"currency":"RUB","method":"card","paid_at":%q}`, ts))
+	c.must("POST", "/api/admin/exchange-rates", fmt.Sprintf(`{"base":"EUR","quote":"RUB","rate":"100.5","valid_on":%q}`, day))
+	c.must("POST", "/api/admin/companies/1/wallet/topup", `{"amount":5000,"reference":"contract"}`)
+	c.must("PUT", "/api/campaigns/1/budget", `{"airtime_limit_sec":36000}`)
+	c.must("PUT", "/api/admin/companies/1/budget", `{"amount_limit":100000}`)
+
+	// компания: приглашение, webhook, API-ключ
+	c.must("POST", "/api/companies/1/invitations", `{"email":"contract@demo.local","role":"viewer"}`)
+	c.must("POST", "/api/companies/1/webhooks", `{"url":"https://example.com/hooks/mediawork","event_types":["invoice.issued"]}`)
+	c.must("POST", "/api/companies/1/webhooks/1/test", "")
+	var key struct {
+		Key string `json:"key"`
+	}
+	c.decode(c.must("POST", "/api/companies/1/api-keys", `{"name":"contract","scopes":["campaigns:read","invoices:read"]}`), &key)
+	c.apiKey = key.Key
+
+	// фасады и группы
+	c.must("PUT", "/api/facades/1/audience",
+		`{"timezone":"Europe/Moscow","visibility_factor":0.4,"dwell_sec":8,"hours":[{"weekday":1,"hour":9,"footfall":1200}]}`)
+	c.must("POST", "/api/facades/geo/within",
+		`{"type":"Polygon","coordinates":[[[37.5,55.7],[37.7,55.7],[37.7,55.8],[37.5,55.8],[37.5,55.7]]]}`)
+	c.must("POST", "/api/facade-groups", `{"name":"Центр","description":"","filter":{"tags":{"district":["center"]}}}`)
+	c.must("POST", "/api/campaigns/1/targets", `{"group_id":1}`)
+	c.must("POST", "/api/admin/jobs/rollup/run", "")
+
+	// ключ API видит списки своей компании
+	c.mustWith("GET", "/api/invoices", "", c.apiKey)
+
+	for _, op := range c.doc.Operations() {
+		if op.Method != http.MethodGet || c.called[op.Method+" "+op.Path] || strings.HasPrefix(op.Path, "/ws/") {
+			continue
+		}
+		path := op.Path
+		for _, p := range c.doc.PathParams(op) {
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", fmt.Sprint(p.Example))
+		}
+		if op.Path == "/api/admin/companies/{id}/billing/summary" {
+			path += "?start=2020-01-01&end=" + now.AddDate(1, 0, 0).Format("2006-01-02")
+		}
+		c.do("GET", path, "", "")
+	}
+}
+
+// must — запрос сценария: кроме соответствия спецификации нужен успешный статус
+func (c *contractRun) must(method, path, body string) []byte {
+	return c.mustWith(method, path, body, "")
+}
+
+func (c *contractRun) mustWith(method, path, body, apiKey string) []byte {
+	status, resp := c.do(method, path, body, apiKey)
+	if status >= 300 {
+		c.t.Errorf("%s %s: scenario step failed with %d: %s", method, path, status, strings.TrimSpace(string(resp)))
+	}
+	return resp
+}
+
+func (c *contractRun) do(method, path, body, apiKey string) (int, []byte) {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, r)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case strings.HasPrefix(path, "/api/live/"):
+		req.Header.Set("X-Device-Token", c.device)
+	case apiKey != "":
+		req.Header.Set("X-API-Key", apiKey)
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, req)
+	resp := rec.Body.Bytes()
+
+	result := "ok"
+	op := c.doc.Find(method, strings.SplitN(path, "?", 2)[0])
+	switch {
+	case op == nil:
+		result = "not in openapi.json"
+	case rec.Code >= 500:
+		result = strings.TrimSpace(string(resp))
+	default:
+		c.called[op.Method+" "+op.Path] = true
+		if err := c.doc.ValidateResponse(op, rec.Code, rec.Header().Get("Content-Type"), resp); err != nil {
+			result = err.Error()
+		}
+	}
+	if result != "ok" {
+		c.t.Errorf("%s %s: %d %s", method, path, rec.Code, result)
+	}
+	return rec.Code, resp
+}
+
+func (c *contractRun) decode(body []byte, v any) {
+	if err := json.Unmarshal(body, v); err != nil {
+		c.t.Errorf("decode response: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"mediawork/internal/config"
+	"mediawork/internal/health"
+	"mediawork/internal/jobs"
+	"mediawork/internal/services"
+)
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+func retentionConfig(cfg config.Retention) services.RetentionConfig {
+	return services.RetentionConfig{
+		PlayHistoryRetention: days(cfg.PlayHistoryDays),
+		HeartbeatRetention:   days(cfg.HeartbeatDays),
+		ArchiveDir:           cfg.ArchiveDir,
+		ArchiveRetention:     days(cfg.ArchiveDays),
+	}
+}
+
+func dunningConfig(cfg config.Dunning) services.DunningConfig {
+	return services.DunningConfig{
+		ReminderOffsets: append([]int(nil), cfg.ReminderDays...),
+		PauseAfterDays:  cfg.PauseAfterDays,
+	}
+}
+
+func webhookConfig(cfg config.Webhooks) services.WebhookConfig {
+	return services.WebhookConfig{
+		MaxAttempts:   cfg.MaxAttempts,
+		BaseBackoff:   cfg.BaseBackoff.D(),
+		MaxBackoff:    cfg.MaxBackoff.D(),
+		Timeout:       cfg.Timeout.D(),
+		FacadeSilence: cfg.FacadeOfflineAfter.D(),
+	}
+}
+
+// registerJobs — все фоновые задачи приложения
+func registerJobs(s *jobs.Scheduler, retention *services.RetentionService, groups *services.FacadeGroupService, dunning *services.DunningService, webhooks *services.WebhookService) {
+	s.Add(jobs.Job{
+		Name:     "rollup",
+		Interval: 15 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return retention.Rollup(ctx, time.Now())
+		},
+	})
+	s.Add(jobs.Job{
+		Name:     "retention",
+		Interval: 6 * time.Hour,
+		Run: func(ctx context.Context) error {
+			if err := retention.ArchiveAndPrune(ctx, time.Now()); err != nil {
+				return err
+			}
+			return retention.PruneArchives(ctx, time.Now())
+		},
+	})
+	// подхватывает фасады, добавленные в сеть после таргетинга кампании на группу
+	s.Add(jobs.Job{
+		Name:     "facade-group-sync",
+		Interval: 10 * time.Minute,
+		Run:      groups.SyncTargets,
+	})
+	// просрочки, напоминания по счетам и пауза кампаний должников
+	s.Add(jobs.Job{
+		Name:     "dunning",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			return dunning.Run(ctx, time.Now())
+		},
+	})
+	// рассылка webhooks из outbox (повторы по расписанию next_attempt_at)
+	s.Add(jobs.Job{
+		Name:     "webhook-dispatch",
+		Interval: 10 * time.Second,
+		Run:      webhooks.Dispatch,
+	})
+	// статус фасадов по heartbeat, facade.offline подписчикам
+	s.Add(jobs.Job{
+		Name:     "facade-watchdog",
+		Interval: time.Minute,
+		Run:      webhooks.WatchFacades,
+	})
+}
+
+// jobsHealth — состояние фоновых задач для /readyz. Готовность не снимает: упавшая задача
+// не мешает обслуживать запросы, а её ошибка уже в логе и в GET /api/admin/jobs.
+func jobsHealth(s *jobs.Scheduler) health.Check {
+	return func(ctx context.Context) (any, error) {
+		failing := []string{}
+		list := s.Status()
+		for _, st := range list {
+			if st.LastError != "" {
+				failing = append(failing, st.Name)
+			}
+		}
+		return map[string]any{"running": s.Running(), "jobs": len(list), "failing": failing}, nil
+	}
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"strings"
+
+	"mediawork/internal/config"
+	"mediawork/internal/ratelimit"
+)
+
+// rateLimits — политики ограничения запросов по группам эндпоинтов
+type rateLimits struct {
+	auth        *ratelimit.Limiter // вход / регистрация, по IP
+	login       *ratelimit.Limiter // вход, по email и IP (перебор пароля одного аккаунта)
+	telemetry   *ratelimit.Limiter // heartbeat / play-event, по фасаду
+	telemetryIP *ratelimit.Limiter // телеметрия, по IP (много плееров за одним NAT)
+	api         *ratelimit.Limiter // кабинет и API, по пользователю / API-ключу
+	apiIP       *ratelimit.Limiter // кабинет и API до проверки токена, по IP (перебор токенов / ключей)
+}
+
+// newLimiter — nil, если политика выключена ("off")
+func newLimiter(name, policy string) (*ratelimit.Limiter, error) {
+	if policy == "" || strings.EqualFold(policy, "off") {
+		return nil, nil
+	}
+	p, err := ratelimit.ParsePolicy(name, policy)
+	if err != nil {
+		return nil, err
+	}
+	return ratelimit.New(p), nil
+}
+
+func newRateLimits(cfg config.RateLimits) (rateLimits, error) {
+	var l rateLimits
+	var err error
+	policies := cfg.Policies()
+	for name, dst := range map[string]**ratelimit.Limiter{
+		"auth":         &l.auth,
+		"login":        &l.login,
+		"telemetry":    &l.telemetry,
+		"telemetry-ip": &l.telemetryIP,
+		"api":          &l.api,
+		"api-ip":       &l.apiIP,
+	} {
+		if *dst, err = newLimiter(name, policies[name]); err != nil {
+			return l, err
+		}
+	}
+	return l, nil
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"mediawork/internal/config"
+	"mediawork/internal/repositories/memory"
+)
+
+// TestLoginRateLimit — перебор пароля с одного адреса не закрывает вход владельцу
+// аккаунта с другого
+func TestLoginRateLimit(t *testing.T) {
+	handler := testRouter(t, func(cfg *config.Config) {
+		cfg.RateLimits.Login = "3/15m"
+	})
+	wrong := `{"email":"` + memory.DemoAdminEmail + `","password":"guess"}`
+	right := `{"email":"` + memory.DemoAdminEmail + `","password":"` + memory.DemoAdminPassword + `"}`
+
+	for range 3 {
+		callFrom(t, handler, "198.51.100.1", "", "POST", "/api/auth/login", wrong, http.StatusUnauthorized)
+	}
+	callFrom(t, handler, "198.51.100.1", "", "POST", "/api/auth/login", right, http.StatusTooManyRequests)
+	callFrom(t, handler, "203.0.113.9", "", "POST", "/api/auth/login", right, http.StatusOK)
+}
+
+// TestAPIRateLimitBeforeAuth — неверные токены считаются по IP до проверки токена
+func TestAPIRateLimitBeforeAuth(t *testing.T) {
+	handler := testRouter(t, func(cfg *config.Config) {
+		cfg.RateLimits.APIIP = "3/1m"
+	})
+	for range 3 {
+		callFrom(t, handler, "198.51.100.1", "mwk_guess", "GET", "/api/me", "", http.StatusUnauthorized)
+	}
+	callFrom(t, handler, "198.51.100.1", "mwk_guess", "GET", "/api/me", "", http.StatusTooManyRequests)
+	callFrom(t, handler, "203.0.113.9", "mwk_guess", "GET", "/api/me", "", http.StatusUnauthorized)
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"database/sql"
+
+	"mediawork/internal/repositories"
+	"mediawork/internal/services"
+)
+
+// PostgresRepositories — хранилища сервисов поверх пула соединений
+func PostgresRepositories(sqlDB *sql.DB) services.Repositories {
+	return services.Repositories{
+		Users:            repositories.NewUserRepository(sqlDB),
+		Companies:        repositories.NewCompanyRepository(sqlDB),
+		Memberships:      repositories.NewCompanyMembershipRepository(sqlDB),
+		Invitations:      repositories.NewCompanyInvitationRepository(sqlDB),
+		CompanyLifecycle: repositories.NewCompanyLifecycleRepository(sqlDB),
+		APIKeys:          repositories.NewAPIKeyRepository(sqlDB),
+		Audit:            repositories.NewAuditRepository(sqlDB),
+		Facades:          repositories.NewFacadeRepository(sqlDB),
+		FacadeTags:       repositories.NewFacadeTagRepository(sqlDB),
+		FacadeGroups:     repositories.NewFacadeGroupRepository(sqlDB),
+		Audience:         repositories.NewAudienceRepository(sqlDB),
+		Campaigns:        repositories.NewCampaignRepository(sqlDB),
+		Creatives:        repositories.NewCreativeRepository(sqlDB),
+		CampaignSlot:     repositories.NewCampaignSlotRepository(sqlDB),
+		CampaignSlots:    repositories.NewCampaignSlotsRepository(sqlDB),
+		Analytics:        repositories.NewAnalyticsRepository(sqlDB),
+		LiveStream:       repositories.NewLiveStreamRepository(sqlDB),
+		Rollups:          repositories.NewRollupRepository(sqlDB),
+		Invoices:         repositories.NewInvoiceRepository(sqlDB),
+		Payments:         repositories.NewPaymentRepository(sqlDB),
+		Dunning:          repositories.NewDunningRepository(sqlDB),
+		TaxRates:         repositories.NewTaxRateRepository(sqlDB),
+		ExchangeRates:    repositories.NewExchangeRateRepository(sqlDB),
+		Budgets:          repositories.NewBudgetRepository(sqlDB),
+		Webhooks:         repositories.NewWebhookRepository(sqlDB),
+	}
+}
@@ -2,7 +2,6 @@ package api
 
 import (
 	"net/http"
-	"os"
 	"time"
 
 	// подстрой пути под свой модуль
@@ -10,46 +9,72 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
-	"mediawork/internal/db"
+	"mediawork/internal/config"
 	"mediawork/internal/handlers"
-	"mediawork/internal/repositories"
+	"mediawork/internal/health"
+	"mediawork/internal/jobs"
+	"mediawork/internal/notify"
+	"mediawork/internal/openapi"
 	"mediawork/internal/services"
 )
 
-// AppRouter собирает все зависимости и возвращает готовый http.Handler
-func NewRouter() (http.Handler, error) {
-	// ───────────────── DB ─────────────────
-	if err := db.Init(); err != nil {
-		return nil, err
-	}
-	sqlDB := db.DB
-
+// NewRouter собирает все зависимости и возвращает готовый http.Handler
+// и фоновую часть (планировщик, WebSocket-стримы) — запускает и останавливает её вызывающий код.
+// Хранилища (PostgreSQL или в памяти) и их проверки готовности в hc создаёт вызывающий код.
+func NewRouter(cfg *config.Config, repos services.Repositories, hc *health.Checker) (http.Handler, *Background, error) {
 	// ───────────────── Repositories ─────────────────
-	userRepo := repositories.NewUserRepository(sqlDB)
-	companyRepo := repositories.NewCompanyRepository(sqlDB)
-	facadeRepo := repositories.NewFacadeRepository(sqlDB)
-	campaignRepo := repositories.NewCampaignRepository(sqlDB)
-	slotRepo := repositories.NewCampaignSlotRepository(sqlDB)
-	invoiceRepo := repositories.NewInvoiceRepository(sqlDB)
-	playHistoryRepo := repositories.NewPlayHistoryRepository(sqlDB)
-	liveStreamRepo := repositories.NewLiveStreamRepository(sqlDB)
-	membershipRepo := repositories.NewCompanyMembershipRepository(sqlDB)
-	// если есть ещё репозитории — добавляй тут
+	userRepo := repos.Users
+	companyRepo := repos.Companies
+	facadeRepo := repos.Facades
+	campaignRepo := repos.Campaigns
+	creativeRepo := repos.Creatives
+	slotRepo := repos.CampaignSlot
+	invoiceRepo := repos.Invoices
+	liveStreamRepo := repos.LiveStream
+	membershipRepo := repos.Memberships
+	invitationRepo := repos.Invitations
+	lifecycleRepo := repos.CompanyLifecycle
+	paymentRepo := repos.Payments
+	dunningRepo := repos.Dunning
+	rollupRepo := repos.Rollups
+	analyticsRepo := repos.Analytics
+	slotsRepo := repos.CampaignSlots
+	audienceRepo := repos.Audience
+	facadeTagRepo := repos.FacadeTags
+	facadeGroupRepo := repos.FacadeGroups
+	auditRepo := repos.Audit
+	taxRateRepo := repos.TaxRates
+	exchangeRateRepo := repos.ExchangeRates
+	budgetRepo := repos.Budgets
+	webhookRepo := repos.Webhooks
+	apiKeyRepo := repos.APIKeys
 
 	// ───────────────── Services ─────────────────
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "dev-secret-change-me"
-	}
+	auditSvc := services.NewAuditService(auditRepo)
+	webhookSvc := services.NewWebhookService(webhookRepo, membershipRepo, facadeRepo, lifecycleRepo, auditSvc, nil, webhookConfig(cfg.Webhooks))
+	authSvc := services.NewAuthService(userRepo, cfg.Auth.JWTSecret)
+	apiKeySvc := services.NewAPIKeyService(apiKeyRepo, companyRepo, membershipRepo, auditSvc)
+	userSvc := services.NewUserService(userRepo, membershipRepo, auditSvc)
+	companySvc := services.NewCompanyService(companyRepo, membershipRepo, invitationRepo, userRepo, lifecycleRepo, auditSvc, webhookSvc)
+	facadeSvc := services.NewFacadeService(facadeRepo, liveStreamRepo, audienceRepo, auditSvc)
+	campaignSvc := services.NewCampaignService(campaignRepo, slotRepo, companyRepo, membershipRepo, auditSvc, webhookSvc)
+	creativeSvc := services.NewCreativeService(creativeRepo)
+	dunningSvc := services.NewDunningService(dunningRepo, lifecycleRepo, membershipRepo, notify.LogNotifier{}, auditSvc, webhookSvc, dunningConfig(cfg.Dunning))
+	taxSvc := services.NewTaxService(taxRateRepo, auditSvc)
+	fxSvc := services.NewExchangeRateService(exchangeRateRepo, auditSvc)
+	billingSvc := services.NewBillingService(invoiceRepo, companyRepo, paymentRepo, taxSvc, fxSvc, dunningSvc, auditSvc, webhookSvc)
+	budgetSvc := services.NewBudgetService(budgetRepo, campaignRepo, companyRepo, membershipRepo, fxSvc, notify.LogNotifier{}, auditSvc)
+	exportSvc := services.NewAccountingExportService(invoiceRepo, auditSvc)
+	liveSvc := services.NewLiveStreamService(liveStreamRepo, budgetSvc, webhookSvc)
+	adminSvc := services.NewAdminService(userRepo, companyRepo, membershipRepo, authSvc, auditSvc)
+	retentionSvc := services.NewRetentionService(rollupRepo, retentionConfig(cfg.Retention))
+	facadeGroupSvc := services.NewFacadeGroupService(facadeGroupRepo, facadeTagRepo, facadeRepo, campaignRepo, membershipRepo, auditSvc)
+	analyticsSvc := services.NewAnalyticsService(analyticsRepo, campaignRepo, slotsRepo, audienceRepo)
 
-	authSvc := services.NewAuthService(userRepo, jwtSecret)
-	userSvc := services.NewUserService(userRepo)
-	companySvc := services.NewCompanyService(companyRepo, membershipRepo)
-	facadeSvc := services.NewFacadeService(facadeRepo, liveStreamRepo)
-	campaignSvc := services.NewCampaignService(campaignRepo, slotRepo)
-	billingSvc := services.NewBillingService(invoiceRepo, playHistoryRepo)
-	liveSvc := services.NewLiveStreamService(liveStreamRepo)
-	adminSvc := services.NewAdminService(userRepo, companyRepo)
+	// ───────────────── Background jobs ─────────────────
+	scheduler := jobs.NewScheduler()
+	registerJobs(scheduler, retentionSvc, facadeGroupSvc, dunningSvc, webhookSvc)
+	hc.Add("jobs", jobsHealth(scheduler))
 
 
 	// ───────────────── Handlers ─────────────────
@@ -57,56 +82,128 @@ func NewRouter() (http.Handler, error) {
 	userH := handlers.NewUserHandler(userSvc)
 	companyH := handlers.NewCompanyHandler(companySvc)
 	campaignH := handlers.NewCampaignHandler(campaignSvc)
+	creativeH := handlers.NewCreativeHandler(creativeSvc)
 	invoiceH := handlers.NewInvoiceHandler(billingSvc)
 	facadeH := handlers.NewFacadeHandler(facadeSvc)
 	liveH := handlers.NewLiveHandler(liveSvc)
 	adminH := handlers.NewAdminHandler(adminSvc)
 	live := NewFacadeLiveHandler(facadeSvc)
+	jobsH := handlers.NewJobsHandler(scheduler, retentionSvc)
+	analyticsH := handlers.NewAnalyticsHandler(analyticsSvc)
+	facadeGroupH := handlers.NewFacadeGroupHandler(facadeGroupSvc)
+	auditH := handlers.NewAuditHandler(auditSvc)
+	rateH := handlers.NewRateHandler(taxSvc, fxSvc)
+	budgetH := handlers.NewBudgetHandler(budgetSvc)
+	exportH := handlers.NewAccountingExportHandler(exportSvc)
+	webhookH := handlers.NewWebhookHandler(webhookSvc)
+	apiKeyH := handlers.NewAPIKeyHandler(apiKeySvc)
 
 
+	// ───────────────── Rate limits ─────────────────
+	limits, err := newRateLimits(cfg.RateLimits)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// ───────────────── Router ─────────────────
 	r := chi.NewRouter()
 
 	// Базовые middlewares
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Recoverer)
+	r.Use(handlers.RealIP(cfg.Server.TrustedProxyPrefixes()))
+	r.Use(handlers.Observe) // трассировка, метрики задержки и access-лог
+	r.Use(handlers.RequestMeta)
+	r.Use(handlers.Recoverer) // паника — 500 в JSON-конверте
 	r.Use(middleware.Timeout(30 * time.Second))
+	r.Use(handlers.LimitBody(int64(cfg.Server.MaxBodyBytes)))
+	r.NotFound(handlers.NotFound)
+	r.MethodNotAllowed(handlers.MethodNotAllowed)
 
-	// CORS — разрешаем фронту ходить на бэк
+	// CORS — разрешаем фронту ходить на бэк (origins из конфига; "*" с credentials не пропустит Validate)
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000", "http://127.0.0.1:3000", "*"},
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-API-Key"},
+		ExposedHeaders:   []string{"Link", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining"},
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
 	}))
 
+	// проверки для балансировщика / оркестратора (вне /api: без CORS-клиентов и лимитов)
+	r.Get("/healthz", hc.Live)
+	r.Get("/readyz", hc.Ready)
+
 	r.Get("/ws/facade/{id}", live.Stream)
 	// ───────────────── API ─────────────────
 	r.Route("/api", func(api chi.Router) {
+		// спецификация API (OpenAPI 3.1); при изменении маршрутов — поправить internal/openapi/openapi.json
+		api.Get("/openapi.json", openapi.Handler)
+
 		// -------- Public auth --------
-		api.Post("/auth/login", authH.Login)
-		api.Post("/auth/register", authH.Register)
+		// лимит по IP и отдельно по паре email + IP — против перебора паролей; только по email
+		// чужой адрес мог бы заблокировать вход владельцу аккаунта
+		api.With(
+			handlers.RateLimit(limits.auth, handlers.IPRateKey),
+			handlers.RateLimit(limits.login, handlers.LoginRateKey),
+		).Post("/auth/login", authH.Login)
+		api.With(handlers.RateLimit(limits.auth, handlers.IPRateKey)).Post("/auth/register", authH.Register)
 
 		// -------- Live (для плееров/фасадов) --------
-		// тут можно потом сделать отдельный токен или IP-фильтр
-		api.Post("/live/heartbeat", liveH.Heartbeat)
-		api.Post("/live/play-event", liveH.PlayEvent)
+		// токен устройства фасада выпускает администратор: POST /api/admin/facades/{id}/device-token
+		api.Group(func(lr chi.Router) {
+			lr.Use(handlers.RateLimit(limits.telemetryIP, handlers.IPRateKey))
+			lr.Use(handlers.DeviceAuth(facadeSvc))
+			lr.Use(handlers.RateLimit(limits.telemetry, handlers.FacadeRateKey))
+			lr.Post("/live/heartbeat", liveH.Heartbeat)
+			lr.Post("/live/play-event", liveH.PlayEvent)
+		})
 
 		// -------- Authenticated area --------
 		api.Group(func(pr chi.Router) {
-			pr.Use(handlers.AuthMiddleware(authSvc))
+			// по IP — до проверки токена: запросы с неверным токеном / ключом тоже считаются
+			pr.Use(handlers.RateLimit(limits.apiIP, handlers.IPRateKey))
+			pr.Use(handlers.AuthMiddleware(authSvc, apiKeySvc))
+			pr.Use(handlers.APIKeyGuard(apiKeyRoutes(campaignSvc, billingSvc)))
+			pr.Use(handlers.RateLimit(limits.api, handlers.ClientRateKey))
 
 			// Профиль текущего пользователя
 			pr.Get("/me", userH.Profile)
+			pr.Get("/me/invitations", companyH.MyInvitations)
+
+			// Ответ на приглашение (токен из письма / ссылки)
+			pr.Post("/invitations/{token}/accept", companyH.AcceptInvitation)
+			pr.Post("/invitations/{token}/decline", companyH.DeclineInvitation)
 
 			// Компании
 			pr.Route("/companies", func(cr chi.Router) {
 				cr.Get("/", companyH.List)
 				cr.Post("/", companyH.Create)
 				cr.Get("/{id}", companyH.GetDetailed)
+
+				// участники и приглашения (роль owner > admin > editor > viewer)
+				cr.Get("/{id}/members", companyH.Members)
+				cr.Put("/{id}/members/{userID}", companyH.ChangeMemberRole)
+				cr.Delete("/{id}/members/{userID}", companyH.RemoveMember)
+				cr.Post("/{id}/owner", companyH.TransferOwnership)
+				cr.Get("/{id}/invitations", companyH.Invitations)
+				cr.Post("/{id}/invitations", companyH.Invite)
+				cr.Delete("/{id}/invitations/{invitationID}", companyH.RevokeInvitation)
+
+				// исходящие webhooks компании (admin / owner): подписки, журнал доставок, повтор
+				cr.Get("/{id}/webhooks", webhookH.List)
+				cr.Post("/{id}/webhooks", webhookH.Create)
+				cr.Put("/{id}/webhooks/{webhookID}", webhookH.Update)
+				cr.Delete("/{id}/webhooks/{webhookID}", webhookH.Delete)
+				cr.Post("/{id}/webhooks/{webhookID}/rotate-secret", webhookH.RotateSecret)
+				cr.Post("/{id}/webhooks/{webhookID}/test", webhookH.Test)
+				cr.Get("/{id}/webhooks/{webhookID}/deliveries", webhookH.Deliveries)
+				cr.Get("/{id}/webhooks/{webhookID}/deliveries/{deliveryID}/attempts", webhookH.Attempts)
+				cr.Post("/{id}/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", webhookH.Redeliver)
+
+				// API-ключи для автоматизации (admin / owner); ключ показывается один раз
+				cr.Get("/{id}/api-keys", apiKeyH.List)
+				cr.Post("/{id}/api-keys", apiKeyH.Create)
+				cr.Delete("/{id}/api-keys/{keyID}", apiKeyH.Revoke)
 			})
 
 			// Кампании
@@ -114,12 +211,50 @@ func NewRouter() (http.Handler, error) {
 				cr.Post("/", campaignH.Create)
 				cr.Get("/", campaignH.List)
 				cr.Get("/{id}", campaignH.Get)
+				cr.Put("/{id}", campaignH.Update)
+
+				// таргетинг на динамические группы фасадов (editor и выше в компании кампании)
+				cr.Get("/{id}/targets", facadeGroupH.ListTargets)
+				cr.Post("/{id}/targets", facadeGroupH.AddTarget)
+				cr.Delete("/{id}/targets/{groupID}", facadeGroupH.RemoveTarget)
+
+				// фиксированный бюджет (деньги и / или секунды эфира) вместо фиксированных слотов;
+				// задаёт и снимает admin компании кампании (проверка в BudgetService)
+				cr.Get("/{id}/budget", budgetH.Campaign)
+				cr.Put("/{id}/budget", budgetH.SetCampaign)
+				cr.Delete("/{id}/budget", budgetH.DeleteCampaign)
 			})
 
+			// Ролики компаний
+			pr.Get("/creatives", creativeH.List)
+
 			// Фасады
 			pr.Route("/facades", func(fr chi.Router) {
 				fr.Get("/", facadeH.List)
+				fr.Get("/geo", facadeH.GeoSearch)
+				fr.Post("/geo/within", facadeH.GeoWithin)
 				fr.Get("/{id}/status", facadeH.Status)
+				fr.Get("/{id}/audience", facadeH.Audience)
+				fr.With(handlers.RoleGuard("admin")).Put("/{id}/audience", facadeH.SaveAudience)
+				fr.Get("/tags", facadeGroupH.TagValues)
+				fr.Get("/{id}/tags", facadeGroupH.GetTags)
+				fr.With(handlers.RoleGuard("admin")).Put("/{id}/tags", facadeGroupH.SetTags)
+			})
+
+			// Динамические группы фасадов (фильтр по тегам)
+			pr.Route("/facade-groups", func(gr chi.Router) {
+				gr.Get("/", facadeGroupH.List)
+				gr.Get("/{id}", facadeGroupH.Get)
+				gr.With(handlers.RoleGuard("admin")).Post("/", facadeGroupH.Create)
+				gr.With(handlers.RoleGuard("admin")).Put("/{id}", facadeGroupH.Update)
+				gr.With(handlers.RoleGuard("admin")).Delete("/{id}", facadeGroupH.Delete)
+			})
+
+			// Аналитика доставки (?from=&to=&format=csv)
+			pr.Route("/analytics", func(ar chi.Router) {
+				ar.Get("/campaigns/{id}/delivery", analyticsH.Delivery)
+				ar.Get("/campaigns/{id}/breakdown", analyticsH.Breakdown)
+				ar.Get("/campaigns/{id}/impressions", analyticsH.Impressions)
 			})
 
 			// Инвойсы
@@ -128,6 +263,13 @@ func NewRouter() (http.Handler, error) {
 				ir.Post("/", invoiceH.Create)
 				ir.Get("/{id}", invoiceH.GetByID)
 				ir.Get("/{id}/pdf", invoiceH.GetPDF)
+				ir.Get("/{id}/payments", invoiceH.Payments)
+				ir.With(handlers.RoleGuard("admin")).Post("/{id}/payments", invoiceH.RecordPayment)
+
+				// выставленный счёт не меняется — только кредит-нота или корректировка к нему
+				ir.Get("/{id}/adjustments", invoiceH.Adjustments)
+				ir.With(handlers.RoleGuard("admin")).Post("/{id}/credit-notes", invoiceH.CreditNote)
+				ir.With(handlers.RoleGuard("admin")).Post("/{id}/adjustments", invoiceH.Adjustment)
 			})
 
 			// -------- Admin-only --------
@@ -135,10 +277,52 @@ func NewRouter() (http.Handler, error) {
 				ar.Use(handlers.RoleGuard("admin"))
 
 				ar.Get("/users", adminH.Users)
+				ar.Get("/users/{id}", adminH.User)
+				ar.Put("/users/{id}/role", adminH.SetRole)
+				ar.Post("/users/{id}/disable", adminH.Disable)
+				ar.Post("/users/{id}/enable", adminH.Enable)
+				ar.Post("/users/{id}/impersonate", adminH.Impersonate)
 				ar.Get("/companies", adminH.Companies)
+				ar.Post("/companies/{id}/deactivate", companyH.Deactivate)
+				ar.Post("/companies/{id}/reactivate", companyH.Reactivate)
+				ar.Get("/companies/{id}/suspensions", companyH.Suspensions)
+
+				// токен плеера фасада для /api/live/*; новый отзывает прежний
+				ar.Post("/facades/{id}/device-token", facadeH.IssueDeviceToken)
+
+				// биллинг: валюта и налоговая юрисдикция компании, справочники ставок и курсов
+				ar.Get("/companies/{id}/billing", invoiceH.CompanyBilling)
+				ar.Put("/companies/{id}/billing", invoiceH.SetCompanyBilling)
+				ar.Get("/companies/{id}/billing/summary", invoiceH.CompanySummary)
+				ar.Get("/tax-rates", rateH.TaxRates)
+				ar.Post("/tax-rates", rateH.CreateTaxRate)
+				ar.Delete("/tax-rates/{id}", rateH.DeleteTaxRate)
+				ar.Get("/exchange-rates", rateH.ExchangeRates)
+				ar.Post("/exchange-rates", rateH.SetExchangeRate)
+				ar.Delete("/exchange-rates/{id}", rateH.DeleteExchangeRate)
+
+				// бюджет компании и предоплаченный кошелёк
+				ar.Get("/companies/{id}/budget", budgetH.Company)
+				ar.Put("/companies/{id}/budget", budgetH.SetCompany)
+				ar.Delete("/companies/{id}/budget", budgetH.DeleteCompany)
+				ar.Get("/companies/{id}/wallet", budgetH.Wallet)
+				ar.Get("/companies/{id}/wallet/transactions", budgetH.Transactions)
+				ar.Post("/companies/{id}/wallet/topup", budgetH.TopUp)
+
+				// выгрузка в бухгалтерию: инкрементальная или за период; повтор по id в любом формате
+				ar.Get("/accounting-exports", exportH.List)
+				ar.Post("/accounting-exports", exportH.Create)
+				ar.Get("/accounting-exports/{id}/download", exportH.Download)
+
+				// журнал изменений (?entity_type=&entity_id=&actor_id=&action=&from=&to=)
+				ar.Get("/audit", auditH.List)
+
+				ar.Get("/jobs", jobsH.List)
+				ar.Post("/jobs/{name}/run", jobsH.Run)
+				ar.Get("/archives", jobsH.Archives)
 			})
 		})
 	})
 
-	return r, nil
+	return r, &Background{Scheduler: scheduler, live: live}, nil
 }
@@ -0,0 +1,28 @@
+package audit
+
+import "context"
+
+// Actor — кто и откуда выполняет запрос. Кладётся в контекст middleware-ом
+// и читается сервисами при записи в audit_log.
+type Actor struct {
+	UserID    int64
+	Email     string
+	Role      string
+	RequestID string
+	IP        string
+
+	// ImpersonatorID — админ, действующий от имени UserID (0 — обычная сессия)
+	ImpersonatorID int64
+}
+
+type ctxKey struct{}
+
+func WithActor(ctx context.Context, a *Actor) context.Context {
+	return context.WithValue(ctx, ctxKey{}, a)
+}
+
+// ActorFrom возвращает актора запроса или nil (фоновые задачи, CLI)
+func ActorFrom(ctx context.Context) *Actor {
+	a, _ := ctx.Value(ctxKey{}).(*Actor)
+	return a
+}
@@ -0,0 +1,586 @@
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"mediawork/internal/ratelimit"
+)
+
+// Config — настройки приложения. Порядок: значения по умолчанию → JSON-файл (CONFIG_FILE)
+// → переменные окружения → секреты из файлов (*_FILE). Проверяется один раз при старте.
+type Config struct {
+	Env           string        `json:"env"`  // development | production
+	Demo          bool          `json:"demo"` // хранилища в памяти с демо-данными, без базы
+	Server        Server        `json:"server"`
+	Database      Database      `json:"database"`
+	Auth          Auth          `json:"auth"`
+	CORS          CORS          `json:"cors"`
+	Retention     Retention     `json:"retention"`
+	Dunning       Dunning       `json:"dunning"`
+	Webhooks      Webhooks      `json:"webhooks"`
+	RateLimits    RateLimits    `json:"rate_limits"`
+	Observability Observability `json:"observability"`
+}
+
+type Server struct {
+	Addr         string `json:"addr"`
+	TLS          TLS    `json:"tls"`
+	MaxBodyBytes int    `json:"max_body_bytes"` // предел тела запроса, больше — 413
+
+	// TrustedProxies — CIDR балансировщиков / прокси перед сервером (или отдельные IP).
+	// X-Forwarded-For / X-Real-IP учитываются только от них; пусто — адрес клиента
+	// всегда адрес соединения, заголовки игнорируются.
+	TrustedProxies []string `json:"trusted_proxies"`
+
+	// таймауты http.Server; write_timeout — с запасом над 30 с на обработчик (выгрузки, PDF)
+	ReadHeaderTimeout Duration `json:"read_header_timeout"`
+	ReadTimeout       Duration `json:"read_timeout"`
+	WriteTimeout      Duration `json:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout"`
+
+	// ShutdownTimeout — сколько после SIGTERM ждать текущие запросы; меньше grace period оркестратора
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+}
+
+// TrustedProxyPrefixes — TrustedProxies как сети; отдельный IP — /32 (/128).
+// Значения уже проверены Validate, неразборчивые пропускаются.
+func (s Server) TrustedProxyPrefixes() []netip.Prefix {
+	list := make([]netip.Prefix, 0, len(s.TrustedProxies))
+	for _, v := range s.TrustedProxies {
+		if p, err := parsePrefix(v); err == nil {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+func parsePrefix(v string) (netip.Prefix, error) {
+	if strings.Contains(v, "/") {
+		p, err := netip.ParsePrefix(v)
+		return p.Masked(), err
+	}
+	ip, err := netip.ParseAddr(v)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	ip = ip.Unmap()
+	return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
+
+// TLS — без сертификата сервер слушает обычный HTTP (например, за балансировщиком)
+type TLS struct {
+	CertFile   string `json:"cert_file"`
+	KeyFile    string `json:"key_file"`
+	MinVersion string `json:"min_version"` // 1.2 | 1.3
+}
+
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// Version — MinVersion для tls.Config
+func (t TLS) Version() uint16 {
+	if t.MinVersion == "1.3" {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
+
+type Database struct {
+	URL             string   `json:"url"`
+	URLFile         string   `json:"url_file"`
+	MaxOpenConns    int      `json:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration `json:"conn_max_idle_time"`
+
+	// AutoMigrate — применять миграции при старте; иначе старт с отставшей схемой — ошибка
+	AutoMigrate bool `json:"auto_migrate"`
+}
+
+type Auth struct {
+	JWTSecret     string `json:"jwt_secret"`
+	JWTSecretFile string `json:"jwt_secret_file"`
+}
+
+// minJWTSecret — HS256-ключ короче 32 байт подбирается слишком легко
+const minJWTSecret = 32
+
+type CORS struct {
+	AllowedOrigins   []string `json:"allowed_origins"`
+	AllowCredentials bool     `json:"allow_credentials"`
+	MaxAge           int      `json:"max_age"` // секунды кэша preflight
+}
+
+type Retention struct {
+	PlayHistoryDays int    `json:"play_history_days"`
+	HeartbeatDays   int    `json:"heartbeat_days"`
+	ArchiveDir      string `json:"archive_dir"`
+	ArchiveDays     int    `json:"archive_days"` // 0 — архивы не удаляются
+}
+
+type Dunning struct {
+	ReminderDays   []int `json:"reminder_days"`    // смещения от срока оплаты, -3 = за три дня
+	PauseAfterDays int   `json:"pause_after_days"` // 0 — не ставить кампании на паузу
+}
+
+type Webhooks struct {
+	MaxAttempts        int      `json:"max_attempts"`
+	BaseBackoff        Duration `json:"base_backoff"`
+	MaxBackoff         Duration `json:"max_backoff"`
+	Timeout            Duration `json:"timeout"`
+	FacadeOfflineAfter Duration `json:"facade_offline_after"`
+}
+
+// RateLimits — политики в формате "LIMIT/WINDOW[:BURST]" ("120/1m:30"), "off" — выключить
+type RateLimits struct {
+	Auth        string `json:"auth"`
+	Login       string `json:"login"`
+	Telemetry   string `json:"telemetry"`
+	TelemetryIP string `json:"telemetry_ip"`
+	API         string `json:"api"`
+	APIIP       string `json:"api_ip"`
+}
+
+// Observability — логи, метрики и трассировка
+type Observability struct {
+	LogLevel      string `json:"log_level"`      // debug | info | warn | error
+	LogFormat     string `json:"log_format"`     // json | text
+	MetricsAddr   string `json:"metrics_addr"`   // отдельный адрес для /metrics; пусто — не слушать
+	TraceExporter string `json:"trace_exporter"` // none | log
+}
+
+// Duration — time.Duration, в файле строкой ("30s", "6h")
+type Duration time.Duration
+
+func (d Duration) D() time.Duration { return time.Duration(d) }
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Defaults — значения для локальной разработки, кроме секретов: их задают всегда
+func Defaults() Config {
+	return Config{
+		Env: "development",
+		Server: Server{
+			Addr:              ":8080",
+			TLS:               TLS{MinVersion: "1.2"},
+			MaxBodyBytes:      1 << 20,
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			ReadTimeout:       Duration(30 * time.Second),
+			WriteTimeout:      Duration(60 * time.Second),
+			IdleTimeout:       Duration(2 * time.Minute),
+			ShutdownTimeout:   Duration(25 * time.Second),
+		},
+		Database: Database{
+			MaxOpenConns:    20,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+			ConnMaxIdleTime: Duration(5 * time.Minute),
+		},
+		CORS: CORS{
+			AllowedOrigins:   []string{"http://localhost:3000", "http://127.0.0.1:3000"},
+			AllowCredentials: true,
+			MaxAge:           300,
+		},
+		Retention: Retention{PlayHistoryDays: 90, HeartbeatDays: 14, ArchiveDir: "./archive"},
+		Dunning:   Dunning{ReminderDays: []int{-3, 0, 3, 7, 14}},
+		Webhooks: Webhooks{
+			MaxAttempts:        8,
+			BaseBackoff:        Duration(30 * time.Second),
+			MaxBackoff:         Duration(6 * time.Hour),
+			Timeout:            Duration(10 * time.Second),
+			FacadeOfflineAfter: Duration(5 * time.Minute),
+		},
+		RateLimits: RateLimits{
+			Auth:        "20/1m:10",
+			Login:       "10/15m:5",
+			Telemetry:   "120/1m:30",
+			TelemetryIP: "6000/1m:1000",
+			API:         "600/1m:100",
+			APIIP:       "3000/1m:500",
+		},
+		Observability: Observability{
+			LogLevel:      "info",
+			LogFormat:     "text",
+			MetricsAddr:   "127.0.0.1:9090",
+			TraceExporter: "none",
+		},
+	}
+}
+
+// Load собирает и проверяет конфигурацию. path — JSON-файл; пустой — взять из CONFIG_FILE (если задан).
+func Load(path string) (*Config, error) {
+	cfg := Defaults()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadSecrets(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+//
+// ---------- ENVIRONMENT ----------
+//
+// env — переменные окружения поверх файла; ошибки формата собираются все сразу
+type env struct {
+	errs []error
+}
+
+func (e *env) str(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		*dst = v
+	}
+}
+
+func (e *env) int(key string, dst *int) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: not an integer: %q", key, v))
+		return
+	}
+	*dst = n
+}
+
+func (e *env) bool(key string, dst *bool) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: not a boolean: %q", key, v))
+		return
+	}
+	*dst = b
+}
+
+func (e *env) duration(key string, dst *Duration) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(v))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: not a duration: %q", key, v))
+		return
+	}
+	*dst = Duration(d)
+}
+
+func (e *env) list(key string, dst *[]string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	list := []string{}
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	*dst = list
+}
+
+func (e *env) ints(key string, dst *[]int) {
+	var parts []string
+	e.list(key, &parts)
+	if parts == nil {
+		return
+	}
+	list := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: not an integer: %q", key, p))
+			return
+		}
+		list = append(list, n)
+	}
+	*dst = list
+}
+
+func (c *Config) loadEnv() error {
+	e := &env{}
+
+	e.str("APP_ENV", &c.Env)
+	e.bool("DEMO_MODE", &c.Demo)
+	e.str("HTTP_ADDR", &c.Server.Addr)
+	e.str("TLS_CERT_FILE", &c.Server.TLS.CertFile)
+	e.str("TLS_KEY_FILE", &c.Server.TLS.KeyFile)
+	e.str("TLS_MIN_VERSION", &c.Server.TLS.MinVersion)
+	e.int("HTTP_MAX_BODY_BYTES", &c.Server.MaxBodyBytes)
+	e.list("TRUSTED_PROXIES", &c.Server.TrustedProxies)
+	e.duration("HTTP_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout)
+	e.duration("HTTP_READ_TIMEOUT", &c.Server.ReadTimeout)
+	e.duration("HTTP_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	e.duration("HTTP_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	e.duration("HTTP_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+
+	e.str("DATABASE_URL", &c.Database.URL)
+	e.str("DATABASE_URL_FILE", &c.Database.URLFile)
+	e.int("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	e.int("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	e.duration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	e.duration("DB_CONN_MAX_IDLE_TIME", &c.Database.ConnMaxIdleTime)
+	e.bool("DB_AUTO_MIGRATE", &c.Database.AutoMigrate)
+
+	e.str("JWT_SECRET", &c.Auth.JWTSecret)
+	e.str("JWT_SECRET_FILE", &c.Auth.JWTSecretFile)
+
+	e.list("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+	e.bool("CORS_ALLOW_CREDENTIALS", &c.CORS.AllowCredentials)
+	e.int("CORS_MAX_AGE", &c.CORS.MaxAge)
+
+	e.int("PLAY_HISTORY_RETENTION_DAYS", &c.Retention.PlayHistoryDays)
+	e.int("HEARTBEAT_RETENTION_DAYS", &c.Retention.HeartbeatDays)
+	e.str("ARCHIVE_DIR", &c.Retention.ArchiveDir)
+	e.int("ARCHIVE_RETENTION_DAYS", &c.Retention.ArchiveDays)
+
+	e.ints("DUNNING_REMINDER_DAYS", &c.Dunning.ReminderDays)
+	e.int("DUNNING_PAUSE_AFTER_DAYS", &c.Dunning.PauseAfterDays)
+
+	e.int("WEBHOOK_MAX_ATTEMPTS", &c.Webhooks.MaxAttempts)
+	e.duration("WEBHOOK_BASE_BACKOFF", &c.Webhooks.BaseBackoff)
+	e.duration("WEBHOOK_MAX_BACKOFF", &c.Webhooks.MaxBackoff)
+	e.duration("WEBHOOK_TIMEOUT", &c.Webhooks.Timeout)
+	e.duration("FACADE_OFFLINE_AFTER", &c.Webhooks.FacadeOfflineAfter)
+
+	e.str("RATE_LIMIT_AUTH", &c.RateLimits.Auth)
+	e.str("RATE_LIMIT_LOGIN", &c.RateLimits.Login)
+	e.str("RATE_LIMIT_TELEMETRY", &c.RateLimits.Telemetry)
+	e.str("RATE_LIMIT_TELEMETRY_IP", &c.RateLimits.TelemetryIP)
+	e.str("RATE_LIMIT_API", &c.RateLimits.API)
+	e.str("RATE_LIMIT_API_IP", &c.RateLimits.APIIP)
+
+	e.str("LOG_LEVEL", &c.Observability.LogLevel)
+	e.str("LOG_FORMAT", &c.Observability.LogFormat)
+	e.str("METRICS_ADDR", &c.Observability.MetricsAddr)
+	e.str("TRACE_EXPORTER", &c.Observability.TraceExporter)
+
+	return errors.Join(e.errs...)
+}
+
+// loadSecrets — секрет из файла (Docker / Kubernetes secrets) важнее значения в env / конфиге
+func (c *Config) loadSecrets() error {
+	for _, s := range []struct {
+		file string
+		dst  *string
+	}{
+		{c.Database.URLFile, &c.Database.URL},
+		{c.Auth.JWTSecretFile, &c.Auth.JWTSecret},
+	} {
+		if s.file == "" {
+			continue
+		}
+		data, err := os.ReadFile(s.file)
+		if err != nil {
+			return fmt.Errorf("secret file: %w", err)
+		}
+		*s.dst = strings.TrimSpace(string(data))
+	}
+	return nil
+}
+
+//
+// ---------- VALIDATION ----------
+//
+// Validate возвращает все найденные ошибки разом, чтобы не чинить конфиг по одной
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Env != "development" && c.Env != "production" {
+		fail("env must be development or production, got %q", c.Env)
+	}
+	if c.Server.Addr == "" {
+		fail("server.addr (HTTP_ADDR) is required")
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		fail("server.max_body_bytes (HTTP_MAX_BODY_BYTES) must be positive")
+	}
+	for _, v := range c.Server.TrustedProxies {
+		if _, err := parsePrefix(v); err != nil {
+			fail("server.trusted_proxies (TRUSTED_PROXIES): %q is not a CIDR or IP address", v)
+		}
+	}
+	for _, t := range []struct {
+		name string
+		d    Duration
+	}{
+		{"read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"read_timeout", c.Server.ReadTimeout},
+		{"write_timeout", c.Server.WriteTimeout},
+		{"idle_timeout", c.Server.IdleTimeout},
+		{"shutdown_timeout", c.Server.ShutdownTimeout},
+	} {
+		if t.d <= 0 {
+			fail("server.%s must be positive", t.name)
+		}
+	}
+
+	t := c.Server.TLS
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		fail("server.tls: cert_file and key_file must be set together")
+	}
+	if t.MinVersion != "1.2" && t.MinVersion != "1.3" {
+		fail("server.tls.min_version must be 1.2 or 1.3, got %q", t.MinVersion)
+	}
+	for _, f := range []string{t.CertFile, t.KeyFile} {
+		if f == "" {
+			continue
+		}
+		if _, err := os.Stat(f); err != nil {
+			fail("server.tls: %v", err)
+		}
+	}
+
+	if c.Demo && c.Env == "production" {
+		fail("demo mode (DEMO_MODE) is not allowed in production")
+	}
+	if c.Database.URL == "" && !c.Demo {
+		fail("database.url (DATABASE_URL or DATABASE_URL_FILE) is required")
+	}
+	if c.Database.MaxOpenConns <= 0 {
+		fail("database.max_open_conns must be positive")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		fail("database.max_idle_conns must be between 0 and max_open_conns")
+	}
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		fail("database connection lifetimes must not be negative")
+	}
+
+	switch {
+	case c.Auth.JWTSecret == "":
+		fail("auth.jwt_secret (JWT_SECRET or JWT_SECRET_FILE) is required")
+	case len(c.Auth.JWTSecret) < minJWTSecret:
+		fail("auth.jwt_secret must be at least %d bytes", minJWTSecret)
+	}
+
+	for _, o := range c.CORS.AllowedOrigins {
+		if o == "*" && c.CORS.AllowCredentials {
+			fail("cors: origin \"*\" cannot be combined with allow_credentials")
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		fail("cors.max_age must not be negative")
+	}
+
+	if c.Retention.PlayHistoryDays <= 0 || c.Retention.HeartbeatDays <= 0 {
+		fail("retention: play_history_days and heartbeat_days must be positive")
+	}
+	if c.Retention.ArchiveDays < 0 {
+		fail("retention.archive_days must not be negative")
+	}
+	if c.Dunning.PauseAfterDays < 0 {
+		fail("dunning.pause_after_days must not be negative")
+	}
+	if c.Webhooks.MaxAttempts <= 0 {
+		fail("webhooks.max_attempts must be positive")
+	}
+	if c.Webhooks.BaseBackoff <= 0 || c.Webhooks.MaxBackoff < c.Webhooks.BaseBackoff {
+		fail("webhooks: base_backoff must be positive and not above max_backoff")
+	}
+	if c.Webhooks.Timeout <= 0 || c.Webhooks.FacadeOfflineAfter <= 0 {
+		fail("webhooks: timeout and facade_offline_after must be positive")
+	}
+
+	for name, p := range c.RateLimits.Policies() {
+		if p == "" || strings.EqualFold(p, "off") {
+			continue
+		}
+		if _, err := ratelimit.ParsePolicy(name, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	o := c.Observability
+	switch strings.ToLower(o.LogLevel) {
+	case "debug", "info", "warn", "error":
+	default:
+		fail("observability.log_level must be debug, info, warn or error, got %q", o.LogLevel)
+	}
+	if o.LogFormat != "json" && o.LogFormat != "text" {
+		fail("observability.log_format must be json or text, got %q", o.LogFormat)
+	}
+	if o.MetricsAddr != "" && o.MetricsAddr == c.Server.Addr {
+		fail("observability.metrics_addr must differ from server.addr: metrics are not public")
+	}
+	if o.TraceExporter != "none" && o.TraceExporter != "log" {
+		fail("observability.trace_exporter must be none or log, got %q", o.TraceExporter)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Policies — политики по именам (имя попадает в ключ лимитера и в ошибки)
+func (r RateLimits) Policies() map[string]string {
+	return map[string]string{
+		"auth":         r.Auth,
+		"login":        r.Login,
+		"telemetry":    r.Telemetry,
+		"telemetry-ip": r.TelemetryIP,
+		"api":          r.API,
+		"api-ip":       r.APIIP,
+	}
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// valid — конфигурация по умолчанию с обязательными секретами
+func valid() Config {
+	c := Defaults()
+	c.Database.URL = "postgres://app@db/mediawork"
+	c.Auth.JWTSecret = testSecret
+	return c
+}
+
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadPrecedence — умолчания → файл → env → секреты из файлов
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.json", `{
+		"server": {"addr": ":9000", "max_body_bytes": 2048},
+		"database": {"url": "postgres://file@db/mediawork", "max_open_conns": 40},
+		"auth": {"jwt_secret": "file-secret-file-secret-file-secret"},
+		"rate_limits": {"api": "off"}
+	}`)
+	t.Setenv("HTTP_ADDR", ":9100")
+	t.Setenv("DB_MAX_IDLE_CONNS", "7")
+	t.Setenv("JWT_SECRET", "env-secret-env-secret-env-secret-env")
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt", testSecret+"\n"))
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com, https://admin.example.com")
+
+	c, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		{"addr from env over file", c.Server.Addr, ":9100"},
+		{"body limit from file", c.Server.MaxBodyBytes, 2048},
+		{"open conns from file", c.Database.MaxOpenConns, 40},
+		{"idle conns from env", c.Database.MaxIdleConns, 7},
+		{"database url from file", c.Database.URL, "postgres://file@db/mediawork"},
+		{"secret file over env, trimmed", c.Auth.JWTSecret, testSecret},
+		{"cors list from env", strings.Join(c.CORS.AllowedOrigins, " "), "https://app.example.com https://admin.example.com"},
+		{"rate limit off", c.RateLimits.API, "off"},
+		{"default kept", c.Server.ReadHeaderTimeout.D(), 5 * time.Second},
+	}
+	for _, ch := range checks {
+		if ch.got != ch.want {
+			t.Errorf("%s: got %v, want %v", ch.name, ch.got, ch.want)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string            // содержимое JSON-файла; "" — без файла
+		env  map[string]string // поверх валидного окружения
+		want []string          // подстроки ошибки
+	}{
+		{name: "unknown field in file", file: `{"server": {"adress": ":9000"}}`, want: []string{`unknown field "adress"`}},
+		{name: "duration not a string", file: `{"server": {"read_timeout": 30}}`, want: []string{"duration must be a string"}},
+		{name: "env format errors reported together",
+			env:  map[string]string{"HTTP_MAX_BODY_BYTES": "lots", "DB_AUTO_MIGRATE": "maybe", "HTTP_READ_TIMEOUT": "soon"},
+			want: []string{"HTTP_MAX_BODY_BYTES", "DB_AUTO_MIGRATE", "HTTP_READ_TIMEOUT"}},
+		{name: "missing secret file", env: map[string]string{"JWT_SECRET_FILE": "/nonexistent/jwt"}, want: []string{"secret file"}},
+		{name: "validation runs on load", env: map[string]string{"APP_ENV": "staging"}, want: []string{"env must be development or production"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DATABASE_URL", "postgres://app@db/mediawork")
+			t.Setenv("JWT_SECRET", testSecret)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeFile(t, "config.json", tt.file)
+			}
+			_, err := Load(path)
+			if err == nil {
+				t.Fatal("Load succeeded")
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("error %q does not mention %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	base := valid()
+	if err := base.Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string // "" — конфигурация верна
+	}{
+		{"unknown env", func(c *Config) { c.Env = "staging" }, "env must be"},
+		{"no addr", func(c *Config) { c.Server.Addr = "" }, "server.addr"},
+		{"zero body limit", func(c *Config) { c.Server.MaxBodyBytes = 0 }, "max_body_bytes"},
+		{"bad trusted proxy", func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.0/33"} }, "trusted_proxies"},
+		{"zero timeout", func(c *Config) { c.Server.WriteTimeout = 0 }, "write_timeout"},
+		{"tls cert without key", func(c *Config) { c.Server.TLS.CertFile = "/etc/tls/cert.pem" }, "cert_file and key_file"},
+		{"tls files missing", func(c *Config) {
+			c.Server.TLS.CertFile, c.Server.TLS.KeyFile = "/nonexistent/cert.pem", "/nonexistent/key.pem"
+		}, "server.tls"},
+		{"tls 1.1", func(c *Config) { c.Server.TLS.MinVersion = "1.1" }, "min_version"},
+		{"demo in production", func(c *Config) { c.Env, c.Demo = "production", true }, "demo mode"},
+		{"no database", func(c *Config) { c.Database.URL = "" }, "database.url"},
+		{"demo without database", func(c *Config) { c.Database.URL, c.Demo = "", true }, ""},
+		{"no pool", func(c *Config) { c.Database.MaxOpenConns = 0 }, "max_open_conns"},
+		{"idle above open", func(c *Config) { c.Database.MaxIdleConns = c.Database.MaxOpenConns + 1 }, "max_idle_conns"},
+		{"negative lifetime", func(c *Config) { c.Database.ConnMaxLifetime = -1 }, "lifetimes"},
+		{"no jwt secret", func(c *Config) { c.Auth.JWTSecret = "" }, "jwt_secret"},
+		{"short jwt secret", func(c *Config) { c.Auth.JWTSecret = "dev-secret-change-me" }, "at least 32 bytes"},
+		{"cors wildcard with credentials", func(c *Config) { c.CORS.AllowedOrigins = []string{"*"} }, `origin "*"`},
+		{"cors wildcard without credentials", func(c *Config) {
+			c.CORS.AllowedOrigins, c.CORS.AllowCredentials = []string{"*"}, false
+		}, ""},
+		{"zero retention", func(c *Config) { c.Retention.HeartbeatDays = 0 }, "retention"},
+		{"negative pause", func(c *Config) { c.Dunning.PauseAfterDays = -1 }, "pause_after_days"},
+		{"no webhook attempts", func(c *Config) { c.Webhooks.MaxAttempts = 0 }, "max_attempts"},
+		{"backoff above max", func(c *Config) { c.Webhooks.BaseBackoff = c.Webhooks.MaxBackoff + 1 }, "base_backoff"},
+		{"bad rate policy", func(c *Config) { c.RateLimits.Login = "10 per minute" }, "login"},
+		{"rate limit off", func(c *Config) { c.RateLimits.APIIP = "OFF" }, ""},
+		{"unknown log level", func(c *Config) { c.Observability.LogLevel = "verbose" }, "log_level"},
+		{"public metrics", func(c *Config) { c.Observability.MetricsAddr = c.Server.Addr }, "metrics_addr"},
+		{"unknown exporter", func(c *Config) { c.Observability.TraceExporter = "otlp" }, "trace_exporter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.modify(&c)
+			err := c.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate = %v, want error mentioning %q", err, tt.want)
+			}
+		})
+	}
+
+	// все ошибки разом
+	c := valid()
+	c.Env, c.Auth.JWTSecret = "staging", ""
+	err := c.Validate()
+	if err == nil || !strings.Contains(err.Error(), "env must be") || !strings.Contains(err.Error(), "jwt_secret") {
+		t.Errorf("Validate = %v, want both errors", err)
+	}
+}
+
+func TestTrustedProxyPrefixes(t *testing.T) {
+	s := Server{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::1", "garbage"}}
+	var got []string
+	for _, p := range s.TrustedProxyPrefixes() {
+		got = append(got, p.String())
+	}
+	if want := "10.0.0.0/8 192.0.2.1/32 2001:db8::1/128"; strings.Join(got, " ") != want {
+		t.Errorf("TrustedProxyPrefixes = %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"database/sql"
+
+	"mediawork/internal/metrics"
+)
+
+// RegisterPoolMetrics публикует статистику пула соединений (sql.DBStats) в /metrics.
+// Вызывается один раз на процесс для основного пула.
+func RegisterPoolMetrics(db *sql.DB) {
+	gauge := func(name, help string, fn func(s sql.DBStats) float64) {
+		metrics.NewGaugeFunc(name, help, func() float64 { return fn(db.Stats()) })
+	}
+	counter := func(name, help string, fn func(s sql.DBStats) float64) {
+		metrics.NewCounterFunc(name, help, func() float64 { return fn(db.Stats()) })
+	}
+
+	gauge("mediawork_db_max_open_connections", "Maximum number of open connections to the database.",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	gauge("mediawork_db_open_connections", "Established connections, in use and idle.",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	gauge("mediawork_db_in_use_connections", "Connections currently in use.",
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	gauge("mediawork_db_idle_connections", "Idle connections.",
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	counter("mediawork_db_wait_count_total", "Connections waited for because the pool was exhausted.",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	counter("mediawork_db_wait_duration_seconds_total", "Time spent waiting for a free connection.",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	counter("mediawork_db_max_idle_closed_total", "Connections closed due to max_idle_conns / conn_max_idle_time.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed + s.MaxIdleTimeClosed) })
+	counter("mediawork_db_max_lifetime_closed_total", "Connections closed due to conn_max_lifetime.",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+}
@@ -0,0 +1,315 @@
+package db
+
+import (
+    "context"
+    "crypto/sha256"
+    "database/sql"
+    "embed"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io/fs"
+    "log/slog"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Миграции лежат в migrations/NNNN_name.up.sql / NNNN_name.down.sql и вшиты в бинарник.
+// Применённые версии — в schema_migrations; уже применённый файл не редактируют, а добавляют следующий.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationsLock — ключ pg_advisory_lock: два инстанса не мигрируют одновременно
+const migrationsLock = 7_362_514_001
+
+var ErrSchemaBehind = errors.New("database schema is behind the application")
+
+type Migration struct {
+    Version  int64
+    Name     string
+    Up       string
+    Down     string
+    Checksum string // sha256 up-скрипта
+}
+
+// MigrationStatus — миграция и когда она применена (nil — ещё нет)
+type MigrationStatus struct {
+    Migration
+    AppliedAt *time.Time
+}
+
+var migrationName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migrations — вшитые миграции по возрастанию версии
+func Migrations() ([]Migration, error) {
+    entries, err := fs.ReadDir(migrationFiles, "migrations")
+    if err != nil {
+        return nil, err
+    }
+
+    byVersion := map[int64]*Migration{}
+    for _, e := range entries {
+        m := migrationName.FindStringSubmatch(e.Name())
+        if m == nil {
+            return nil, fmt.Errorf("migration %s: want NNNN_name.up.sql or NNNN_name.down.sql", e.Name())
+        }
+        version, _ := strconv.ParseInt(m[1], 10, 64)
+        body, err := migrationFiles.ReadFile("migrations/" + e.Name())
+        if err != nil {
+            return nil, err
+        }
+
+        mig, ok := byVersion[version]
+        if !ok {
+            mig = &Migration{Version: version, Name: m[2]}
+            byVersion[version] = mig
+        }
+        if mig.Name != m[2] {
+            return nil, fmt.Errorf("migration %d: names differ (%s, %s)", version, mig.Name, m[2])
+        }
+        if m[3] == "up" {
+            mig.Up = string(body)
+            sum := sha256.Sum256(body)
+            mig.Checksum = hex.EncodeToString(sum[:])
+        } else {
+            mig.Down = string(body)
+        }
+    }
+
+    list := make([]Migration, 0, len(byVersion))
+    for _, m := range byVersion {
+        if m.Up == "" || m.Down == "" {
+            return nil, fmt.Errorf("migration %d_%s: both up and down scripts are required", m.Version, m.Name)
+        }
+        list = append(list, *m)
+    }
+    sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+    return list, nil
+}
+
+//
+// ---------- APPLY / ROLLBACK ----------
+//
+// MigrateUp применяет все неприменённые миграции по порядку, каждую в своей транзакции.
+// Возвращает применённые.
+func MigrateUp(ctx context.Context, db *sql.DB) ([]Migration, error) {
+    var done []Migration
+    err := withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+        status, err := migrationStatus(ctx, conn)
+        if err != nil {
+            return err
+        }
+        for _, st := range status {
+            if st.AppliedAt != nil {
+                continue
+            }
+            if err := applyMigration(ctx, conn, st.Migration, true); err != nil {
+                return err
+            }
+            slog.InfoContext(ctx, "migrate: applied", "version", st.Version, "name", st.Name)
+            done = append(done, st.Migration)
+        }
+        return nil
+    })
+    return done, err
+}
+
+// MigrateDown откатывает steps последних применённых миграций
+func MigrateDown(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+    if steps <= 0 {
+        return nil, errors.New("steps must be positive")
+    }
+
+    var done []Migration
+    err := withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+        status, err := migrationStatus(ctx, conn)
+        if err != nil {
+            return err
+        }
+        for i := len(status) - 1; i >= 0 && len(done) < steps; i-- {
+            st := status[i]
+            if st.AppliedAt == nil {
+                continue
+            }
+            if err := applyMigration(ctx, conn, st.Migration, false); err != nil {
+                return err
+            }
+            slog.InfoContext(ctx, "migrate: rolled back", "version", st.Version, "name", st.Name)
+            done = append(done, st.Migration)
+        }
+        return nil
+    })
+    return done, err
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, m Migration, up bool) error {
+    tx, err := conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    script, dir := m.Up, "up"
+    if !up {
+        script, dir = m.Down, "down"
+    }
+    // без аргументов lib/pq шлёт скрипт простым запросом — несколько statement'ов за раз
+    if _, err := tx.ExecContext(ctx, script); err != nil {
+        return fmt.Errorf("migration %d_%s (%s): %w", m.Version, m.Name, dir, err)
+    }
+
+    if up {
+        _, err = tx.ExecContext(ctx,
+            `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+            m.Version, m.Name, m.Checksum,
+        )
+    } else {
+        _, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+    }
+    if err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+// withMigrationLock держит advisory lock на одном соединении пула, пока идёт fn
+func withMigrationLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+
+    if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationsLock); err != nil {
+        return err
+    }
+    defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationsLock)
+
+    if err := ensureMigrationsTable(ctx, conn); err != nil {
+        return err
+    }
+    return fn(conn)
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+    _, err := conn.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version     BIGINT PRIMARY KEY,
+            name        TEXT NOT NULL,
+            checksum    TEXT NOT NULL,
+            applied_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )
+    `)
+    return err
+}
+
+//
+// ---------- STATUS / STARTUP CHECK ----------
+//
+// MigrationsStatus — все вшитые миграции с отметкой о применении
+func MigrationsStatus(ctx context.Context, db *sql.DB) ([]MigrationStatus, error) {
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer conn.Close()
+
+    if err := ensureMigrationsTable(ctx, conn); err != nil {
+        return nil, err
+    }
+    return migrationStatus(ctx, conn)
+}
+
+// migrationStatus сверяет вшитые миграции с schema_migrations. Ошибка — если применённый
+// скрипт с тех пор изменили: такую базу уже не воспроизвести из миграций.
+func migrationStatus(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, error) {
+    list, err := Migrations()
+    if err != nil {
+        return nil, err
+    }
+
+    type applied struct {
+        checksum string
+        at       time.Time
+    }
+    rows, err := conn.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    done := map[int64]applied{}
+    for rows.Next() {
+        var v int64
+        var a applied
+        if err := rows.Scan(&v, &a.checksum, &a.at); err != nil {
+            return nil, err
+        }
+        done[v] = a
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    status := make([]MigrationStatus, 0, len(list))
+    for _, m := range list {
+        st := MigrationStatus{Migration: m}
+        if a, ok := done[m.Version]; ok {
+            if a.checksum != m.Checksum {
+                return nil, fmt.Errorf("migration %d_%s was changed after it had been applied", m.Version, m.Name)
+            }
+            st.AppliedAt = &a.at
+            delete(done, m.Version)
+        }
+        status = append(status, st)
+    }
+
+    // версии, которых нет в бинарнике: база мигрирована более новой сборкой
+    if len(done) > 0 {
+        unknown := make([]string, 0, len(done))
+        for v := range done {
+            unknown = append(unknown, strconv.FormatInt(v, 10))
+        }
+        sort.Strings(unknown)
+        slog.WarnContext(ctx, "migrate: database has migrations unknown to this build", "versions", strings.Join(unknown, ", "))
+    }
+    return status, nil
+}
+
+// SchemaVersion — последняя применённая версия и последняя вшитая (для /readyz).
+// Только чтение: таблицу schema_migrations не создаёт и скрипты не сверяет.
+func SchemaVersion(ctx context.Context, db *sql.DB) (applied, latest int64, err error) {
+    list, err := Migrations()
+    if err != nil {
+        return 0, 0, err
+    }
+    if len(list) > 0 {
+        latest = list[len(list)-1].Version
+    }
+    err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&applied)
+    return applied, latest, err
+}
+
+// CheckSchema — проверка при старте: все вшитые миграции должны быть применены
+func CheckSchema(ctx context.Context, db *sql.DB) error {
+    status, err := MigrationsStatus(ctx, db)
+    if err != nil {
+        return err
+    }
+
+    var pending []string
+    for _, st := range status {
+        if st.AppliedAt == nil {
+            pending = append(pending, fmt.Sprintf("%d_%s", st.Version, st.Name))
+        }
+    }
+    if len(pending) > 0 {
+        return fmt.Errorf("%w: pending %s (run `api migrate up` or set DB_AUTO_MIGRATE=true)",
+            ErrSchemaBehind, strings.Join(pending, ", "))
+    }
+    return nil
+}
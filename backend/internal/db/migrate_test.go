@@ -0,0 +1,87 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestMigrations — вшитые миграции: версии подряд с 1, у каждой up и down с заголовком
+func TestMigrations(t *testing.T) {
+	list, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) < 6 {
+		t.Fatalf("migrations = %d, want at least 6", len(list))
+	}
+
+	checksums := map[string]int64{}
+	for i, m := range list {
+		if m.Version != int64(i+1) {
+			t.Fatalf("migration #%d has version %d: versions must go in a row from 1", i, m.Version)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %d_%s: empty script", m.Version, m.Name)
+		}
+
+		title := fmt.Sprintf("MEDIAWORK — %04d %s", m.Version, strings.ToUpper(strings.ReplaceAll(m.Name, "_", " ")))
+		if !strings.Contains(firstLines(m.Up, 2), title) {
+			t.Errorf("migration %d_%s: up header does not name it (%q)", m.Version, m.Name, title)
+		}
+		if !strings.Contains(firstLines(m.Down, 2), title+" (down)") {
+			t.Errorf("migration %d_%s: down header does not name it (%q)", m.Version, m.Name, title+" (down)")
+		}
+
+		if prev, ok := checksums[m.Checksum]; ok {
+			t.Errorf("migrations %d and %d have the same up script", prev, m.Version)
+		}
+		checksums[m.Checksum] = m.Version
+	}
+}
+
+// schemaObject — индексы, колонки и ограничения, которые миграция создаёт или удаляет
+var schemaObject = regexp.MustCompile(`(?i)\b(?:CREATE\s+(?:UNIQUE\s+)?INDEX|ADD\s+COLUMN|ADD\s+CONSTRAINT|DROP\s+COLUMN|DROP\s+CONSTRAINT)\s+(?:IF\s+(?:NOT\s+)?EXISTS\s+)?([a-z0-9_]+)`)
+
+// TestMigrationsDownMirrorsUp — down каждой миграции после 0001 возвращает то, что
+// тронул up: всё созданное удаляется, всё удалённое создаётся заново.
+// 0001 откатывается сбросом всей схемы и здесь не сверяется.
+func TestMigrationsDownMirrorsUp(t *testing.T) {
+	list, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range list[1:] {
+		objects := schemaObject.FindAllStringSubmatch(stripComments(m.Up), -1)
+		if len(objects) == 0 {
+			t.Errorf("migration %d_%s: up changes no index, column or constraint", m.Version, m.Name)
+		}
+		down := stripComments(m.Down)
+		for _, o := range objects {
+			if !regexp.MustCompile(`\b` + o[1] + `\b`).MatchString(down) {
+				t.Errorf("migration %d_%s: down does not revert %s", m.Version, m.Name, o[1])
+			}
+		}
+	}
+}
+
+func firstLines(s string, n int) string {
+	lines := strings.SplitN(s, "\n", n+1)
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func stripComments(script string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		if i := strings.Index(line, "--"); i >= 0 {
+			line = line[:i]
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
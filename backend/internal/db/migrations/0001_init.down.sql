@@ -0,0 +1,56 @@
+-- ============================================================
+--  MEDIAWORK — 0001 INIT (down): удаляет всю базовую схему
+-- ============================================================
+
+DROP TABLE IF EXISTS audit_log CASCADE;
+DROP TABLE IF EXISTS user_preferences CASCADE;
+DROP TABLE IF EXISTS api_keys CASCADE;
+DROP TABLE IF EXISTS campaign_play_counters CASCADE;
+DROP TABLE IF EXISTS webhook_delivery_attempts CASCADE;
+DROP TABLE IF EXISTS webhook_deliveries CASCADE;
+DROP TABLE IF EXISTS webhook_events CASCADE;
+DROP TABLE IF EXISTS webhook_endpoints CASCADE;
+DROP TABLE IF EXISTS wallet_transactions CASCADE;
+DROP TABLE IF EXISTS company_wallets CASCADE;
+DROP TABLE IF EXISTS budgets CASCADE;
+DROP TABLE IF EXISTS accounting_exports CASCADE;
+DROP TABLE IF EXISTS invoice_reminders CASCADE;
+DROP TABLE IF EXISTS payments CASCADE;
+DROP TABLE IF EXISTS exchange_rates CASCADE;
+DROP TABLE IF EXISTS tax_rates CASCADE;
+DROP TABLE IF EXISTS invoice_lines CASCADE;
+DROP TABLE IF EXISTS invoice_play_refs CASCADE;
+DROP TABLE IF EXISTS document_sequences CASCADE;
+DROP TABLE IF EXISTS invoices CASCADE;
+DROP TABLE IF EXISTS rate_cards CASCADE;
+DROP TABLE IF EXISTS play_history_archives CASCADE;
+DROP TABLE IF EXISTS facade_heartbeat_rollups CASCADE;
+DROP TABLE IF EXISTS play_history_rollups CASCADE;
+DROP TABLE IF EXISTS facade_status CASCADE;
+DROP TABLE IF EXISTS facade_heartbeat CASCADE;
+DROP TABLE IF EXISTS play_history CASCADE;
+DROP TABLE IF EXISTS campaign_participation CASCADE;
+DROP TABLE IF EXISTS campaign_group_targets CASCADE;
+DROP TABLE IF EXISTS dynamic_facade_groups CASCADE;
+DROP TABLE IF EXISTS facade_tags CASCADE;
+DROP TABLE IF EXISTS facade_presets CASCADE;
+DROP TABLE IF EXISTS creatives CASCADE;
+DROP TABLE IF EXISTS campaign_slots CASCADE;
+DROP TABLE IF EXISTS company_suspension_campaigns CASCADE;
+DROP TABLE IF EXISTS campaigns CASCADE;
+DROP TABLE IF EXISTS facade_audience_hours CASCADE;
+DROP TABLE IF EXISTS facade_audience CASCADE;
+DROP TABLE IF EXISTS facade_status_log CASCADE;
+DROP TABLE IF EXISTS facades CASCADE;
+DROP TABLE IF EXISTS facade_groups CASCADE;
+DROP TABLE IF EXISTS company_invitations CASCADE;
+DROP TABLE IF EXISTS company_suspensions CASCADE;
+DROP TABLE IF EXISTS company_memberships CASCADE;
+DROP TABLE IF EXISTS companies CASCADE;
+DROP TABLE IF EXISTS user_api_tokens CASCADE;
+DROP TABLE IF EXISTS user_sessions CASCADE;
+DROP TABLE IF EXISTS users CASCADE;
+
+DROP FUNCTION IF EXISTS audit_log_append_only();
+DROP FUNCTION IF EXISTS invoice_lines_immutable();
+DROP FUNCTION IF EXISTS invoices_immutable();
@@ -0,0 +1,786 @@
+-- ============================================================
+--  MEDIAWORK — 0001 INIT (PostgreSQL)
+--  Базовая схема; дальнейшие изменения — только новыми миграциями.
+-- ============================================================
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+-- ============================================================
+-- USERS
+-- ============================================================
+
+CREATE TABLE users (
+    id              BIGSERIAL PRIMARY KEY,
+    email           TEXT NOT NULL UNIQUE,
+    password_hash   TEXT NOT NULL,
+    full_name       TEXT NOT NULL,
+    avatar_url      TEXT,
+    global_role     TEXT NOT NULL DEFAULT 'user',  -- 'user' | 'admin' | 'owner'
+    locale          TEXT DEFAULT 'en',
+    time_zone       TEXT DEFAULT 'Europe/Moscow',
+    is_active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE user_sessions (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token   TEXT NOT NULL,
+    user_agent      TEXT,
+    ip_address      INET,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE user_api_tokens (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name            TEXT NOT NULL,
+    token_hash      TEXT NOT NULL,
+    scopes          TEXT[] NOT NULL DEFAULT ARRAY[]::TEXT[],
+    last_used_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at      TIMESTAMPTZ
+);
+
+-- ============================================================
+-- COMPANIES + USER ROLES INSIDE COMPANIES
+-- ============================================================
+
+CREATE TABLE companies (
+    id              BIGSERIAL PRIMARY KEY,
+    name            TEXT NOT NULL,
+    legal_name      TEXT,
+    vat_number      TEXT,
+    company_type    TEXT NOT NULL DEFAULT 'client',  -- 'client' | 'operator' | 'internal'
+    industry        TEXT,
+    country         TEXT,
+    city            TEXT,
+    website         TEXT,
+
+    -- биллинг: валюта счетов и налоговая юрисдикция (ставка — в tax_rates)
+    billing_currency TEXT NOT NULL DEFAULT 'RUB',
+    tax_jurisdiction TEXT,
+    tax_exempt      BOOLEAN NOT NULL DEFAULT FALSE,
+
+    -- владелец передаётся TransferOwnership; удалить пользователя-владельца нельзя
+    owner_id        BIGINT NOT NULL REFERENCES users(id),
+    is_active       BOOLEAN NOT NULL DEFAULT TRUE,
+
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX companies_owner_idx ON companies (owner_id);
+
+CREATE TABLE company_memberships (
+    company_id      BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role            TEXT NOT NULL, -- viewer | editor | admin | owner
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (company_id, user_id)
+);
+
+CREATE INDEX company_memberships_user_idx ON company_memberships (user_id);
+
+-- История деактиваций: что было поставлено на паузу, чтобы реактивация вернула состояние
+CREATE TABLE company_suspensions (
+    id                  BIGSERIAL PRIMARY KEY,
+    company_id          BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    kind                TEXT NOT NULL DEFAULT 'deactivation',  -- deactivation | dunning
+    reason              TEXT,
+    suspended_by        BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    suspended_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reactivated_by      BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    reactivated_at      TIMESTAMPTZ,
+    paused_campaigns    INTEGER NOT NULL DEFAULT 0,
+    suspended_slots     INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX company_suspensions_company_idx ON company_suspensions (company_id, suspended_at DESC);
+
+CREATE TABLE company_invitations (
+    id              BIGSERIAL PRIMARY KEY,
+    company_id      BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    email           TEXT NOT NULL,
+    role            TEXT NOT NULL,
+    token_hash      TEXT NOT NULL UNIQUE,     -- sha256 токена, сам токен не храним
+    invited_by      BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    status          TEXT NOT NULL DEFAULT 'pending',  -- pending | accepted | declined | revoked
+    expires_at      TIMESTAMPTZ NOT NULL,
+    responded_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX company_invitations_email_idx ON company_invitations (lower(email)) WHERE status = 'pending';
+
+-- ============================================================
+-- FACADE GROUPS + FACADE DEVICES
+-- ============================================================
+
+CREATE TABLE facade_groups (
+    id              BIGSERIAL PRIMARY KEY,
+    name            TEXT NOT NULL,
+    city            TEXT,
+    country         TEXT,
+    timezone        TEXT NOT NULL DEFAULT 'Europe/Moscow',
+    notes           TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE facades (
+    id                  BIGSERIAL PRIMARY KEY,
+    group_id            BIGINT REFERENCES facade_groups(id) ON DELETE SET NULL,
+    code                TEXT NOT NULL UNIQUE,
+    name                TEXT NOT NULL,
+    address             TEXT,
+    latitude            DOUBLE PRECISION,
+    longitude           DOUBLE PRECISION,
+
+    physical_width_m    NUMERIC(10,2),
+    physical_height_m   NUMERIC(10,2),
+
+    resolution_x        INTEGER NOT NULL,
+    resolution_y        INTEGER NOT NULL,
+    virtual_rows        INTEGER NOT NULL DEFAULT 20,
+    virtual_cols        INTEGER NOT NULL DEFAULT 10,
+
+    status              TEXT NOT NULL DEFAULT 'offline',   -- online/offline
+    last_ping_at        TIMESTAMPTZ,
+    last_latency_ms     INTEGER,
+    brightness          INTEGER,
+    color_temperature   INTEGER,
+
+    preview_image_url   TEXT,
+    ws_endpoint         TEXT,
+
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE facade_status_log (
+    id              BIGSERIAL PRIMARY KEY,
+    facade_id       BIGINT NOT NULL REFERENCES facades(id) ON DELETE CASCADE,
+    status          TEXT NOT NULL, -- online/offline/degraded
+    latency_ms      INTEGER,
+    brightness      INTEGER,
+    recorded_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Оценка аудитории: поток людей по часам недели
+CREATE TABLE facade_audience (
+    facade_id           BIGINT PRIMARY KEY REFERENCES facades(id) ON DELETE CASCADE,
+    timezone            TEXT NOT NULL DEFAULT 'UTC',
+    visibility_factor   DOUBLE PRECISION NOT NULL DEFAULT 1,
+    dwell_sec           INTEGER NOT NULL DEFAULT 0,
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE facade_audience_hours (
+    facade_id       BIGINT NOT NULL REFERENCES facades(id) ON DELETE CASCADE,
+    weekday         SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),  -- 0 = Sunday
+    hour            SMALLINT NOT NULL CHECK (hour BETWEEN 0 AND 23),
+    footfall        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (facade_id, weekday, hour)
+);
+
+-- ============================================================
+-- CAMPAIGNS + SLOTS + CREATIVES
+-- ============================================================
+
+CREATE TABLE campaigns (
+    id              BIGSERIAL PRIMARY KEY,
+    company_id      BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    name            TEXT NOT NULL,
+    external_ref    TEXT,
+
+    status          TEXT NOT NULL DEFAULT 'draft', 
+    -- allowed: draft | scheduled | active | live | paused | finished | cancelled
+
+    start_at        TIMESTAMPTZ,
+    end_at          TIMESTAMPTZ,
+
+    total_budget    NUMERIC(14,2),
+    currency        TEXT DEFAULT 'RUB',
+
+    -- ролик, который плеер получает вместе с активным слотом
+    media_url       TEXT,
+
+    -- бюджет (budgets) или предоплата (company_wallets) исчерпаны — кампания снята с расписания
+    budget_exhausted_at TIMESTAMPTZ,
+
+    created_by      BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    updated_by      BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE company_suspension_campaigns (
+    suspension_id   BIGINT NOT NULL REFERENCES company_suspensions(id) ON DELETE CASCADE,
+    campaign_id     BIGINT NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    prev_status     TEXT NOT NULL,
+    PRIMARY KEY (suspension_id, campaign_id)
+);
+
+-- Недельная сетка показов (используется плеером через GetActiveSlotsForFacade).
+-- suspended — слот временно снят с расписания деактивацией компании.
+CREATE TABLE campaign_slots (
+    id              BIGSERIAL PRIMARY KEY,
+    campaign_id     BIGINT NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    facade_id       BIGINT REFERENCES facades(id) ON DELETE CASCADE,
+    day_of_week     INTEGER NOT NULL DEFAULT 0 CHECK (day_of_week BETWEEN 0 AND 6),  -- 0 = Sunday
+    start_time      TIME NOT NULL,
+    end_time        TIME NOT NULL,
+    duration_sec    INTEGER NOT NULL DEFAULT 15,
+    priority        INTEGER NOT NULL DEFAULT 0,
+    suspended       BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX campaign_slots_campaign_idx ON campaign_slots (campaign_id);
+
+-- Загруженные ролики компании; campaign_id — если ролик уже привязан к кампании
+CREATE TABLE creatives (
+    id              BIGSERIAL PRIMARY KEY,
+    company_id      BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    campaign_id     BIGINT REFERENCES campaigns(id) ON DELETE SET NULL,
+    filename        TEXT NOT NULL,
+    file_type       TEXT NOT NULL DEFAULT '',
+    duration        INTEGER NOT NULL DEFAULT 0,   -- секунды
+    resolution      TEXT NOT NULL DEFAULT '',     -- 1920x1080
+    uploaded_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX creatives_campaign_idx ON creatives (campaign_id);
+
+-- Optional: presets for facade
+CREATE TABLE facade_presets (
+    id                  BIGSERIAL PRIMARY KEY,
+    facade_id           BIGINT NOT NULL REFERENCES facades(id) ON DELETE CASCADE,
+    name                TEXT NOT NULL,
+    description         TEXT,
+    creative_id         BIGINT REFERENCES creatives(id) ON DELETE SET NULL,
+    brightness          INTEGER,
+    color_temperature   INTEGER,
+    created_by          BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- ============================================================
+-- FACADE TAGS + DYNAMIC GROUPS + CAMPAIGN TARGETING
+-- ============================================================
+
+CREATE TABLE facade_tags (
+    facade_id       BIGINT NOT NULL REFERENCES facades(id) ON DELETE CASCADE,
+    key             TEXT NOT NULL,   -- district | venue_type | orientation | indoor_outdoor | ...
+    value           TEXT NOT NULL,
+    PRIMARY KEY (facade_id, key)
+);
+
+CREATE INDEX facade_tags_key_value_idx ON facade_tags (key, value);
+
+CREATE TABLE dynamic_facade_groups (
+    id              BIGSERIAL PRIMARY KEY,
+    name            TEXT NOT NULL,
+    description     TEXT NOT NULL DEFAULT '',
+    filter          JSONB NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE campaign_group_targets (
+    campaign_id     BIGINT NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    group_id        BIGINT NOT NULL REFERENCES dynamic_facade_groups(id) ON DELETE CASCADE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (campaign_id, group_id)
+);
+
+-- Привязка фасадов к кампании; source_group_id — если фасад добавлен динамической группой
+CREATE TABLE campaign_participation (
+    id                  BIGSERIAL PRIMARY KEY,
+    campaign_id         BIGINT NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    facade_id           BIGINT NOT NULL REFERENCES facades(id) ON DELETE CASCADE,
+    source_group_id     BIGINT REFERENCES dynamic_facade_groups(id) ON DELETE CASCADE,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (campaign_id, facade_id)
+);
+
+CREATE INDEX campaign_participation_facade_idx ON campaign_participation (facade_id);
+
+-- ============================================================
+-- LIVE TELEMETRY: PLAY HISTORY, HEARTBEATS, FACADE STATUS
+-- ============================================================
+
+-- Сырые показы от плееров. Без FK: старые строки сворачиваются в rollups и уходят в архив (retention).
+-- slot_id = 0 — показ вне сетки слотов.
+CREATE TABLE play_history (
+    id              BIGSERIAL PRIMARY KEY,
+    facade_id       BIGINT NOT NULL,
+    campaign_id     BIGINT NOT NULL,
+    slot_id         BIGINT NOT NULL DEFAULT 0,
+    media_url       TEXT NOT NULL DEFAULT '',
+    played_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    duration_sec    INTEGER NOT NULL DEFAULT 0,
+    resolution_w    INTEGER NOT NULL DEFAULT 0,
+    resolution_h    INTEGER NOT NULL DEFAULT 0,
+    bitrate_kbps    INTEGER NOT NULL DEFAULT 0,
+    sync_latency_ms INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX play_history_played_idx ON play_history (played_at);
+CREATE INDEX play_history_facade_idx ON play_history (facade_id, played_at DESC);
+CREATE INDEX play_history_campaign_idx ON play_history (campaign_id, played_at);
+
+CREATE TABLE facade_heartbeat (
+    id              BIGSERIAL PRIMARY KEY,
+    facade_id       BIGINT NOT NULL,
+    timestamp       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    latency_ms      INTEGER,
+    source_ip       TEXT
+);
+
+CREATE INDEX facade_heartbeat_timestamp_idx ON facade_heartbeat (timestamp);
+CREATE INDEX facade_heartbeat_facade_idx ON facade_heartbeat (facade_id, timestamp DESC);
+
+-- Последнее известное состояние плеера (одна строка на фасад)
+CREATE TABLE facade_status (
+    facade_id       BIGINT PRIMARY KEY REFERENCES facades(id) ON DELETE CASCADE,
+    is_online       BOOLEAN NOT NULL DEFAULT FALSE,
+    last_seen       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    latency_ms      INTEGER
+);
+
+-- ============================================================
+-- PLAY HISTORY ROLLUPS + RAW ARCHIVES
+-- ============================================================
+
+CREATE TABLE play_history_rollups (
+    granularity         TEXT NOT NULL,  -- hour | day
+    bucket_start        TIMESTAMPTZ NOT NULL,
+    facade_id           BIGINT NOT NULL,
+    campaign_id         BIGINT NOT NULL,
+    plays               BIGINT NOT NULL DEFAULT 0,
+    airtime_sec         BIGINT NOT NULL DEFAULT 0,
+    bitrate_sum         BIGINT NOT NULL DEFAULT 0,
+    sync_latency_sum    BIGINT NOT NULL DEFAULT 0,
+    sync_latency_max    INTEGER NOT NULL DEFAULT 0,
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (granularity, bucket_start, facade_id, campaign_id)
+);
+
+CREATE INDEX play_history_rollups_campaign_idx
+    ON play_history_rollups (campaign_id, granularity, bucket_start);
+
+CREATE TABLE facade_heartbeat_rollups (
+    granularity     TEXT NOT NULL,  -- hour | day
+    bucket_start    TIMESTAMPTZ NOT NULL,
+    facade_id       BIGINT NOT NULL,
+    beats           BIGINT NOT NULL DEFAULT 0,
+    latency_sum     BIGINT NOT NULL DEFAULT 0,
+    latency_max     INTEGER NOT NULL DEFAULT 0,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (granularity, bucket_start, facade_id)
+);
+
+CREATE TABLE play_history_archives (
+    id              BIGSERIAL PRIMARY KEY,
+    source          TEXT NOT NULL,  -- play_history | facade_heartbeat
+    period_start    TIMESTAMPTZ NOT NULL,
+    period_end      TIMESTAMPTZ NOT NULL,
+    file_path       TEXT NOT NULL,
+    row_count       BIGINT NOT NULL,
+    size_bytes      BIGINT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- ============================================================
+-- BILLING: RATE CARDS, INVOICES, PAYMENTS
+-- ============================================================
+
+CREATE TABLE rate_cards (
+    id              BIGSERIAL PRIMARY KEY,
+    facade_id       BIGINT NOT NULL REFERENCES facades(id) ON DELETE CASCADE,
+    name            TEXT NOT NULL,
+    cpm             NUMERIC(14,4),
+    cost_per_spot   NUMERIC(14,4),
+    cost_per_second NUMERIC(14,4),
+    currency        TEXT NOT NULL DEFAULT 'RUB',
+    is_active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE invoices (
+    id              BIGSERIAL PRIMARY KEY,
+    company_id      BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    invoice_number  TEXT NOT NULL UNIQUE,          -- INV-12-000042 / CN-12-000003 / ADJ-12-000001
+    document_type   TEXT NOT NULL DEFAULT 'invoice', -- invoice | credit_note | adjustment
+    original_invoice_id BIGINT REFERENCES invoices(id),
+    reason          TEXT,
+    period_start    DATE NOT NULL,
+    period_end      DATE NOT NULL,
+    amount_net      NUMERIC(14,2) NOT NULL DEFAULT 0,
+    amount_tax      NUMERIC(14,2) NOT NULL DEFAULT 0,
+    amount_total    NUMERIC(14,2) NOT NULL,      -- gross = net + tax
+    currency        TEXT NOT NULL DEFAULT 'RUB',
+    tax_jurisdiction TEXT,
+    amount_paid     NUMERIC(14,2) NOT NULL DEFAULT 0,
+    amount_credited NUMERIC(14,2) NOT NULL DEFAULT 0,  -- зачтено кредит-нотами
+    status          TEXT NOT NULL DEFAULT 'pending',  -- pending | partially_paid | paid | overdue | failed | applied
+    due_date        DATE,
+    issued_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    paid_at         TIMESTAMPTZ,
+
+    -- основание корректировки: простой фасада за период (показы — в invoice_play_refs)
+    facade_id       BIGINT REFERENCES facades(id),
+    outage_start    TIMESTAMPTZ,
+    outage_end      TIMESTAMPTZ,
+
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (document_type = 'invoice' OR original_invoice_id IS NOT NULL)
+);
+
+CREATE INDEX invoices_original_idx ON invoices (original_invoice_id) WHERE original_invoice_id IS NOT NULL;
+
+-- Сквозная нумерация документов по компании и виду документа
+CREATE TABLE document_sequences (
+    company_id      BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    document_type   TEXT NOT NULL,
+    last_value      BIGINT NOT NULL,
+    PRIMARY KEY (company_id, document_type)
+);
+
+-- Показы, на которые ссылается корректировка. Без FK: старые показы уходят в архив (retention)
+CREATE TABLE invoice_play_refs (
+    invoice_id      BIGINT NOT NULL REFERENCES invoices(id),
+    play_history_id BIGINT NOT NULL,
+    PRIMARY KEY (invoice_id, play_history_id)
+);
+
+CREATE TABLE invoice_lines (
+    id              BIGSERIAL PRIMARY KEY,
+    invoice_id      BIGINT NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+
+    line_type       TEXT NOT NULL,
+    campaign_id     BIGINT REFERENCES campaigns(id) ON DELETE SET NULL,
+    facade_id       BIGINT REFERENCES facades(id) ON DELETE SET NULL,
+
+    description     TEXT NOT NULL,
+    quantity        NUMERIC(14,4) NOT NULL DEFAULT 1,
+    unit            TEXT,
+    unit_price      NUMERIC(14,4),
+    currency        TEXT NOT NULL DEFAULT 'RUB',   -- валюта unit_price
+    fx_rate         NUMERIC(18,6),                 -- курс в валюту счёта, если отличается
+    tax_rate        NUMERIC(7,4) NOT NULL DEFAULT 0,
+    tax_included    BOOLEAN NOT NULL DEFAULT FALSE,
+    amount          NUMERIC(14,2) NOT NULL,        -- net в валюте счёта
+    tax_amount      NUMERIC(14,2) NOT NULL DEFAULT 0,
+    gross_amount    NUMERIC(14,2) NOT NULL,
+    meta            JSONB,
+
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX invoice_lines_invoice_idx ON invoice_lines (invoice_id);
+
+-- Выставленные документы не меняются и не удаляются: исправления — кредит-нотой / корректировкой.
+-- Меняться могут только статус оплаты и зачёты (status, amount_paid, amount_credited, paid_at).
+CREATE OR REPLACE FUNCTION invoices_immutable() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'issued invoice % cannot be deleted; issue a credit note', OLD.invoice_number;
+    END IF;
+    IF (NEW.company_id, NEW.invoice_number, NEW.document_type, NEW.original_invoice_id, NEW.reason,
+        NEW.period_start, NEW.period_end, NEW.amount_net, NEW.amount_tax, NEW.amount_total,
+        NEW.currency, NEW.tax_jurisdiction, NEW.due_date, NEW.issued_at,
+        NEW.facade_id, NEW.outage_start, NEW.outage_end)
+       IS DISTINCT FROM
+       (OLD.company_id, OLD.invoice_number, OLD.document_type, OLD.original_invoice_id, OLD.reason,
+        OLD.period_start, OLD.period_end, OLD.amount_net, OLD.amount_tax, OLD.amount_total,
+        OLD.currency, OLD.tax_jurisdiction, OLD.due_date, OLD.issued_at,
+        OLD.facade_id, OLD.outage_start, OLD.outage_end) THEN
+        RAISE EXCEPTION 'issued invoice % is immutable; issue a credit note or adjustment', OLD.invoice_number;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER invoices_no_rewrite
+    BEFORE UPDATE OR DELETE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION invoices_immutable();
+
+CREATE OR REPLACE FUNCTION invoice_lines_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'lines of an issued invoice are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER invoice_lines_no_rewrite
+    BEFORE UPDATE OR DELETE ON invoice_lines
+    FOR EACH ROW EXECUTE FUNCTION invoice_lines_immutable();
+
+-- Ставки налога по юрисдикциям; rate — проценты. valid_to не включается.
+CREATE TABLE tax_rates (
+    id              BIGSERIAL PRIMARY KEY,
+    jurisdiction    TEXT NOT NULL,
+    name            TEXT NOT NULL DEFAULT 'VAT',
+    rate            NUMERIC(7,4) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    valid_from      DATE NOT NULL,
+    valid_to        DATE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (valid_to IS NULL OR valid_to > valid_from)
+);
+
+CREATE INDEX tax_rates_jurisdiction_idx ON tax_rates (jurisdiction, valid_from DESC);
+
+-- Курсы валют (ведёт админ): 1 base = rate quote на дату valid_on
+CREATE TABLE exchange_rates (
+    id              BIGSERIAL PRIMARY KEY,
+    base            TEXT NOT NULL,
+    quote           TEXT NOT NULL,
+    rate            NUMERIC(18,6) NOT NULL CHECK (rate > 0),
+    valid_on        DATE NOT NULL,
+    created_by      BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (base, quote, valid_on)
+);
+
+CREATE TABLE payments (
+    id              BIGSERIAL PRIMARY KEY,
+    invoice_id      BIGINT NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    amount          NUMERIC(14,2) NOT NULL CHECK (amount > 0),
+    currency        TEXT NOT NULL DEFAULT 'RUB',
+    method          TEXT,
+    reference       TEXT,
+    note            TEXT,
+    paid_at         TIMESTAMPTZ NOT NULL,
+    recorded_by     BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX payments_invoice_idx ON payments (invoice_id);
+
+-- Отправленные напоминания: offset_days — смещение от due_date (-3 = за три дня до срока)
+CREATE TABLE invoice_reminders (
+    invoice_id      BIGINT NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    offset_days     INTEGER NOT NULL,
+    sent_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (invoice_id, offset_days)
+);
+
+-- Выгрузки в бухгалтерию: состав фиксируется диапазонами id (from — не включая, to — включая),
+-- следующая инкрементальная начинается с invoice_id_to / payment_id_to последней инкрементальной
+CREATE TABLE accounting_exports (
+    id              BIGSERIAL PRIMARY KEY,
+    format          TEXT NOT NULL,                    -- csv | json | xml
+    incremental     BOOLEAN NOT NULL DEFAULT FALSE,
+    period_from     TIMESTAMPTZ,
+    period_to       TIMESTAMPTZ,                      -- не включая
+    invoice_id_from BIGINT NOT NULL DEFAULT 0,
+    invoice_id_to   BIGINT NOT NULL DEFAULT 0,
+    payment_id_from BIGINT NOT NULL DEFAULT 0,
+    payment_id_to   BIGINT NOT NULL DEFAULT 0,
+    invoice_count   INTEGER NOT NULL DEFAULT 0,
+    payment_count   INTEGER NOT NULL DEFAULT 0,
+    created_by      BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- ============================================================
+-- BUDGETS / PREPAID WALLETS
+-- ============================================================
+
+-- Бюджет кампании (campaign_id задан) или всей компании (campaign_id IS NULL):
+-- лимит в валюте компании и / или в секундах эфира. Потраченное копится по мере показов;
+-- суммы показов — в точности курса (18,6), чтобы не терять доли копеек.
+CREATE TABLE budgets (
+    id                  BIGSERIAL PRIMARY KEY,
+    company_id          BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    campaign_id         BIGINT REFERENCES campaigns(id) ON DELETE CASCADE,
+    currency            TEXT NOT NULL DEFAULT 'RUB',
+    amount_limit        NUMERIC(14,2) CHECK (amount_limit >= 0),
+    airtime_limit_sec   BIGINT CHECK (airtime_limit_sec >= 0),
+    amount_spent        NUMERIC(18,6) NOT NULL DEFAULT 0,
+    airtime_spent_sec   BIGINT NOT NULL DEFAULT 0,
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (amount_limit IS NOT NULL OR airtime_limit_sec IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX budgets_campaign_uq ON budgets (campaign_id) WHERE campaign_id IS NOT NULL;
+CREATE UNIQUE INDEX budgets_company_uq ON budgets (company_id) WHERE campaign_id IS NULL;
+
+-- Предоплаченный кошелёк: пополняет админ, показы списывают
+CREATE TABLE company_wallets (
+    company_id      BIGINT PRIMARY KEY REFERENCES companies(id) ON DELETE CASCADE,
+    currency        TEXT NOT NULL DEFAULT 'RUB',
+    balance         NUMERIC(18,6) NOT NULL DEFAULT 0,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Движения по кошельку: topup | charge | adjustment
+CREATE TABLE wallet_transactions (
+    id              BIGSERIAL PRIMARY KEY,
+    company_id      BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    kind            TEXT NOT NULL,
+    amount          NUMERIC(18,6) NOT NULL,
+    balance_after   NUMERIC(18,6) NOT NULL,
+    campaign_id     BIGINT REFERENCES campaigns(id) ON DELETE SET NULL,
+    play_history_id BIGINT,
+    reference       TEXT,
+    note            TEXT,
+    created_by      BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX wallet_transactions_company_idx ON wallet_transactions (company_id, created_at DESC);
+
+-- ============================================================
+-- WEBHOOKS (OUTBOX + DELIVERY LOG)
+-- ============================================================
+
+CREATE TABLE webhook_endpoints (
+    id              BIGSERIAL PRIMARY KEY,
+    company_id      BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    url             TEXT NOT NULL,
+    description     TEXT,
+    event_types     TEXT[] NOT NULL,          -- campaign.status_changed, invoice.paid, ...
+    secret          TEXT NOT NULL,            -- ключ HMAC-подписи
+    is_active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by      BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhook_endpoints_company_idx ON webhook_endpoints (company_id);
+
+-- событие пишется только если на него есть подписчики
+CREATE TABLE webhook_events (
+    id              BIGSERIAL PRIMARY KEY,
+    company_id      BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    event_type      TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE webhook_deliveries (
+    id               BIGSERIAL PRIMARY KEY,
+    event_id         BIGINT NOT NULL REFERENCES webhook_events(id) ON DELETE CASCADE,
+    endpoint_id      BIGINT NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    status           TEXT NOT NULL DEFAULT 'pending',   -- pending | succeeded | failed
+    attempts         INT NOT NULL DEFAULT 0,            -- автоматические попытки
+    next_attempt_at  TIMESTAMPTZ,
+    last_attempt_at  TIMESTAMPTZ,
+    last_status_code INT,
+    last_error       TEXT,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (event_id, endpoint_id)
+);
+
+CREATE INDEX webhook_deliveries_endpoint_idx ON webhook_deliveries (endpoint_id, id DESC);
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE webhook_delivery_attempts (
+    id              BIGSERIAL PRIMARY KEY,
+    delivery_id     BIGINT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempt         INT NOT NULL,
+    manual          BOOLEAN NOT NULL DEFAULT FALSE,     -- повтор из журнала
+    status_code     INT,
+    error           TEXT,
+    response_body   TEXT,                               -- первые 1 KB ответа
+    duration_ms     BIGINT NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhook_delivery_attempts_delivery_idx ON webhook_delivery_attempts (delivery_id);
+
+-- счётчик показов для play.milestone (1, 100, 1 000, ...)
+CREATE TABLE campaign_play_counters (
+    campaign_id     BIGINT PRIMARY KEY REFERENCES campaigns(id) ON DELETE CASCADE,
+    plays           BIGINT NOT NULL DEFAULT 0
+);
+
+-- ============================================================
+-- API KEYS
+-- ============================================================
+
+CREATE TABLE api_keys (
+    id              BIGSERIAL PRIMARY KEY,
+    company_id      BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    name            TEXT NOT NULL,
+    prefix          TEXT NOT NULL,             -- mwk_xxxxxxxx, для списка ключей
+    key_hash        TEXT NOT NULL UNIQUE,      -- sha256 ключа; сам ключ не хранится
+    scopes          TEXT[] NOT NULL,           -- campaigns:read, campaigns:write, analytics:read, invoices:read
+    created_by      BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMPTZ,
+    last_used_at    TIMESTAMPTZ,
+    last_used_ip    TEXT,
+    revoked_at      TIMESTAMPTZ,
+    revoked_by      BIGINT REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX api_keys_company_idx ON api_keys (company_id);
+
+-- ============================================================
+-- USER PREFERENCES
+-- ============================================================
+
+CREATE TABLE user_preferences (
+    id                  BIGSERIAL PRIMARY KEY,
+    user_id             BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    language            TEXT DEFAULT 'en',
+    units               TEXT DEFAULT 'metric',
+    time_format_24h     BOOLEAN NOT NULL DEFAULT TRUE,
+    theme               TEXT DEFAULT 'light',
+
+    quiet_mode          BOOLEAN NOT NULL DEFAULT FALSE,
+    notifications_email BOOLEAN NOT NULL DEFAULT TRUE,
+    notifications_push  BOOLEAN NOT NULL DEFAULT FALSE,
+
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE(user_id)
+);
+
+-- ============================================================
+-- AUDIT LOG
+-- ============================================================
+
+CREATE TABLE audit_log (
+    id              BIGSERIAL PRIMARY KEY,
+    actor_user_id   BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    company_id      BIGINT REFERENCES companies(id) ON DELETE SET NULL,
+    action          TEXT NOT NULL,
+    entity_type     TEXT NOT NULL,
+    entity_id       BIGINT,
+    meta            JSONB,
+
+    actor_email     TEXT,
+    before_data     JSONB,
+    after_data      JSONB,
+    diff            JSONB,             -- {"field": {"from": ..., "to": ...}}
+    request_id      TEXT,
+    ip              TEXT,
+    impersonator_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_log_entity_idx  ON audit_log (entity_type, entity_id);
+CREATE INDEX audit_log_actor_idx   ON audit_log (actor_user_id);
+CREATE INDEX audit_log_created_idx ON audit_log (created_at);
+
+-- журнал только дописывается: UPDATE / DELETE запрещены
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
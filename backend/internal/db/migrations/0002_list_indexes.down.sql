@@ -0,0 +1,13 @@
+-- ============================================================
+--  MEDIAWORK — 0002 LIST INDEXES (down)
+-- ============================================================
+
+DROP INDEX IF EXISTS companies_created_idx;
+DROP INDEX IF EXISTS users_created_idx;
+DROP INDEX IF EXISTS creatives_company_uploaded_idx;
+DROP INDEX IF EXISTS creatives_uploaded_idx;
+DROP INDEX IF EXISTS facades_created_idx;
+DROP INDEX IF EXISTS invoices_company_created_idx;
+DROP INDEX IF EXISTS invoices_created_idx;
+DROP INDEX IF EXISTS campaigns_company_created_idx;
+DROP INDEX IF EXISTS campaigns_created_idx;
@@ -0,0 +1,19 @@
+-- ============================================================
+--  MEDIAWORK — 0002 LIST INDEXES
+--  Постраничные списки читаются по курсору (поле сортировки, id):
+--  индексы под сортировку по умолчанию и фильтр по компании.
+-- ============================================================
+
+CREATE INDEX campaigns_created_idx         ON campaigns (created_at, id);
+CREATE INDEX campaigns_company_created_idx ON campaigns (company_id, created_at, id);
+
+CREATE INDEX invoices_created_idx          ON invoices (created_at, id);
+CREATE INDEX invoices_company_created_idx  ON invoices (company_id, created_at, id);
+
+CREATE INDEX facades_created_idx           ON facades (created_at, id);
+
+CREATE INDEX creatives_uploaded_idx         ON creatives (uploaded_at, id);
+CREATE INDEX creatives_company_uploaded_idx ON creatives (company_id, uploaded_at, id);
+
+CREATE INDEX users_created_idx             ON users (created_at, id);
+CREATE INDEX companies_created_idx         ON companies (created_at, id);
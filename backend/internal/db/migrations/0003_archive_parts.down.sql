@@ -0,0 +1,8 @@
+-- ============================================================
+--  MEDIAWORK — 0003 ARCHIVE PARTS (down)
+-- ============================================================
+
+ALTER TABLE play_history_archives
+    DROP CONSTRAINT IF EXISTS play_history_archives_file_path_key,
+    DROP CONSTRAINT IF EXISTS play_history_archives_period_part_key,
+    DROP COLUMN IF EXISTS part;
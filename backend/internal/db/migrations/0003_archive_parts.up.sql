@@ -0,0 +1,19 @@
+-- ============================================================
+--  MEDIAWORK — 0003 ARCHIVE PARTS
+--  Строки, пришедшие за уже выгруженные сутки, пишутся в
+--  отдельную часть архива: (source, period_start, part) и путь
+--  к файлу уникальны. Раньше повторная выгрузка перезаписывала
+--  файл и добавляла вторую строку каталога на тот же путь.
+-- ============================================================
+
+-- файл на диске — от последней выгрузки, остальные строки каталога указывают на него же
+DELETE FROM play_history_archives a
+USING play_history_archives b
+WHERE a.file_path = b.file_path AND a.id < b.id;
+
+ALTER TABLE play_history_archives
+    ADD COLUMN part INTEGER NOT NULL DEFAULT 1 CHECK (part >= 1);
+
+ALTER TABLE play_history_archives
+    ADD CONSTRAINT play_history_archives_period_part_key UNIQUE (source, period_start, part),
+    ADD CONSTRAINT play_history_archives_file_path_key UNIQUE (file_path);
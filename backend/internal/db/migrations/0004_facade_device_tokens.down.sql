@@ -0,0 +1,8 @@
+-- ============================================================
+--  MEDIAWORK — 0004 FACADE DEVICE TOKENS (down)
+-- ============================================================
+
+ALTER TABLE facades
+    DROP CONSTRAINT IF EXISTS facades_device_token_hash_key,
+    DROP COLUMN IF EXISTS device_token_issued_at,
+    DROP COLUMN IF EXISTS device_token_hash;
@@ -0,0 +1,13 @@
+-- ============================================================
+--  MEDIAWORK — 0004 FACADE DEVICE TOKENS
+--  Плеер фасада шлёт телеметрию и показы с токеном устройства
+--  (mwd_...). Хранится только sha256 токена; выпуск нового
+--  токена заменяет старый.
+-- ============================================================
+
+ALTER TABLE facades
+    ADD COLUMN device_token_hash      TEXT,
+    ADD COLUMN device_token_issued_at TIMESTAMPTZ;
+
+ALTER TABLE facades
+    ADD CONSTRAINT facades_device_token_hash_key UNIQUE (device_token_hash);
@@ -0,0 +1,6 @@
+-- ============================================================
+--  MEDIAWORK — 0005 WEBHOOK ATTEMPT BODY (down)
+-- ============================================================
+
+ALTER TABLE webhook_delivery_attempts
+    ADD COLUMN IF NOT EXISTS response_body TEXT;
@@ -0,0 +1,9 @@
+-- ============================================================
+--  MEDIAWORK — 0005 WEBHOOK ATTEMPT BODY
+--  Тело ответа получателя больше не хранится: журнал попыток
+--  виден компании, а ответ мог прийти не от её сервера.
+--  Сохранённые раньше фрагменты удаляются вместе с колонкой.
+-- ============================================================
+
+ALTER TABLE webhook_delivery_attempts
+    DROP COLUMN IF EXISTS response_body;
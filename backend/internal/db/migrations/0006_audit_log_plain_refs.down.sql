@@ -0,0 +1,13 @@
+-- ============================================================
+--  MEDIAWORK — 0006 AUDIT LOG PLAIN REFS (down)
+--  NOT VALID: записи об удалённых пользователях и компаниях
+--  остаются в журнале, проверяются только новые строки.
+-- ============================================================
+
+ALTER TABLE audit_log
+    ADD CONSTRAINT audit_log_actor_user_id_fkey
+        FOREIGN KEY (actor_user_id) REFERENCES users(id) ON DELETE SET NULL NOT VALID,
+    ADD CONSTRAINT audit_log_company_id_fkey
+        FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE SET NULL NOT VALID,
+    ADD CONSTRAINT audit_log_impersonator_id_fkey
+        FOREIGN KEY (impersonator_id) REFERENCES users(id) ON DELETE SET NULL NOT VALID;
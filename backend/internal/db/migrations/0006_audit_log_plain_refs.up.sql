@@ -0,0 +1,13 @@
+-- ============================================================
+--  MEDIAWORK — 0006 AUDIT LOG PLAIN REFS
+--  Журнал только дописывается (триггер audit_log_no_update),
+--  поэтому ON DELETE SET NULL на его ссылках превращался в
+--  запрещённый UPDATE, и удалить пользователя или компанию
+--  с историей было нельзя. Теперь в журнале просто id:
+--  запись остаётся и после удаления того, на кого ссылается.
+-- ============================================================
+
+ALTER TABLE audit_log
+    DROP CONSTRAINT IF EXISTS audit_log_actor_user_id_fkey,
+    DROP CONSTRAINT IF EXISTS audit_log_company_id_fkey,
+    DROP CONSTRAINT IF EXISTS audit_log_impersonator_id_fkey;
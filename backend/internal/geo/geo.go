@@ -0,0 +1,111 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const earthRadiusM = 6371008.8
+
+// ErrInvalidGeometry — ошибка Validate: прямоугольник или полигон задан неверно
+var ErrInvalidGeometry = errors.New("invalid geometry")
+
+// Point — координата в градусах
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// BBox — прямоугольник в градусах (без перехода через антимеридиан)
+type BBox struct {
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+// Polygon — внешний контур, точки по порядку; замыкающая точка не обязательна
+type Polygon []Point
+
+// Valid — широта и долгота в пределах; NaN и бесконечности не проходят
+func (p Point) Valid() bool {
+	if math.IsNaN(p.Lat) || math.IsNaN(p.Lon) || math.IsInf(p.Lat, 0) || math.IsInf(p.Lon, 0) {
+		return false
+	}
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
+
+func (b BBox) Validate() error {
+	if !(Point{b.MinLat, b.MinLon}).Valid() || !(Point{b.MaxLat, b.MaxLon}).Valid() {
+		return fmt.Errorf("%w: bbox is out of range", ErrInvalidGeometry)
+	}
+	if b.MinLat > b.MaxLat || b.MinLon > b.MaxLon {
+		return fmt.Errorf("%w: bbox min must not exceed max", ErrInvalidGeometry)
+	}
+	return nil
+}
+
+func (b BBox) Contains(p Point) bool {
+	return p.Lat >= b.MinLat && p.Lat <= b.MaxLat && p.Lon >= b.MinLon && p.Lon <= b.MaxLon
+}
+
+// DistanceM — расстояние по большому кругу (haversine) в метрах
+func DistanceM(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusM * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// RadiusBBox — описанный прямоугольник круга, используем как грубый фильтр в SQL
+func RadiusBBox(center Point, radiusM float64) BBox {
+	dLat := radiusM / earthRadiusM * 180 / math.Pi
+	dLon := 180.0
+	if c := math.Cos(center.Lat * math.Pi / 180); c > 1e-9 {
+		dLon = math.Min(180, dLat/c)
+	}
+
+	return BBox{
+		MinLat: math.Max(-90, center.Lat-dLat),
+		MaxLat: math.Min(90, center.Lat+dLat),
+		MinLon: math.Max(-180, center.Lon-dLon),
+		MaxLon: math.Min(180, center.Lon+dLon),
+	}
+}
+
+func (p Polygon) Validate() error {
+	if len(p) < 3 {
+		return fmt.Errorf("%w: polygon needs at least 3 points", ErrInvalidGeometry)
+	}
+	for _, pt := range p {
+		if !pt.Valid() {
+			return fmt.Errorf("%w: polygon point is out of range", ErrInvalidGeometry)
+		}
+	}
+	return nil
+}
+
+func (p Polygon) Bounds() BBox {
+	b := BBox{MinLat: 90, MinLon: 180, MaxLat: -90, MaxLon: -180}
+	for _, pt := range p {
+		b.MinLat = math.Min(b.MinLat, pt.Lat)
+		b.MaxLat = math.Max(b.MaxLat, pt.Lat)
+		b.MinLon = math.Min(b.MinLon, pt.Lon)
+		b.MaxLon = math.Max(b.MaxLon, pt.Lon)
+	}
+	return b
+}
+
+// Contains — ray casting; на масштабах города плоского приближения достаточно
+func (p Polygon) Contains(pt Point) bool {
+	inside := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+			pt.Lon < (b.Lon-a.Lon)*(pt.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
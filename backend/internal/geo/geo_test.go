@@ -0,0 +1,136 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestBBoxValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		b    BBox
+		ok   bool
+	}{
+		{"moscow", BBox{MinLat: 55.5, MinLon: 37.3, MaxLat: 56, MaxLon: 38}, true},
+		{"whole world", BBox{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}, true},
+		{"point", BBox{MinLat: 1, MinLon: 1, MaxLat: 1, MaxLon: 1}, true},
+		{"min lat above max", BBox{MinLat: 56, MinLon: 37, MaxLat: 55, MaxLon: 38}, false},
+		{"min lon above max", BBox{MinLat: 55, MinLon: 38, MaxLat: 56, MaxLon: 37}, false},
+		{"lat out of range", BBox{MinLat: -91, MinLon: 0, MaxLat: 0, MaxLon: 1}, false},
+		{"lon out of range", BBox{MinLat: 0, MinLon: 0, MaxLat: 1, MaxLon: 181}, false},
+		{"nan", BBox{MinLat: math.NaN(), MinLon: 0, MaxLat: 1, MaxLon: 1}, false},
+		{"inf", BBox{MinLat: 0, MinLon: math.Inf(-1), MaxLat: 1, MaxLon: 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.b.Validate()
+			if tt.ok != (err == nil) {
+				t.Fatalf("Validate() = %v, want ok=%v", err, tt.ok)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidGeometry) {
+				t.Errorf("Validate() = %v, want ErrInvalidGeometry", err)
+			}
+		})
+	}
+}
+
+func TestPolygonValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Polygon
+		ok   bool
+	}{
+		{"triangle", Polygon{{0, 0}, {0, 1}, {1, 0}}, true},
+		{"closed square", Polygon{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}}, true},
+		{"empty", nil, false},
+		{"two points", Polygon{{0, 0}, {1, 1}}, false},
+		{"point out of range", Polygon{{0, 0}, {0, 1}, {91, 0}}, false},
+		{"nan point", Polygon{{0, 0}, {0, 1}, {1, math.NaN()}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Validate()
+			if tt.ok != (err == nil) {
+				t.Fatalf("Validate() = %v, want ok=%v", err, tt.ok)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidGeometry) {
+				t.Errorf("Validate() = %v, want ErrInvalidGeometry", err)
+			}
+		})
+	}
+}
+
+func TestDistanceM(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64 // метры, с точностью 0.5%
+	}{
+		{"same point", Point{55.75, 37.62}, Point{55.75, 37.62}, 0},
+		{"one degree of latitude", Point{0, 0}, Point{1, 0}, 111_195},
+		{"moscow to saint petersburg", Point{55.7558, 37.6173}, Point{59.9343, 30.3351}, 634_000},
+		{"antipodes", Point{0, 0}, Point{0, 180}, math.Pi * earthRadiusM},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DistanceM(tt.a, tt.b)
+			if math.Abs(got-tt.want) > tt.want*0.005+1e-6 {
+				t.Errorf("DistanceM = %.0f, want %.0f", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRadiusBBox — прямоугольник покрывает круг и не выходит за пределы координат
+func TestRadiusBBox(t *testing.T) {
+	tests := []struct {
+		center  Point
+		radiusM float64
+	}{
+		{Point{55.75, 37.62}, 2000},
+		{Point{0, 179.99}, 5000},
+		{Point{89.99, 0}, 10000},
+		{Point{-90, 0}, 1000},
+	}
+	for _, tt := range tests {
+		b := RadiusBBox(tt.center, tt.radiusM)
+		if err := b.Validate(); err != nil {
+			t.Errorf("RadiusBBox(%v, %v) = %+v: %v", tt.center, tt.radiusM, b, err)
+		}
+		if !b.Contains(tt.center) {
+			t.Errorf("RadiusBBox(%v, %v) = %+v does not contain the center", tt.center, tt.radiusM, b)
+		}
+		// точка на окружности к северу / к югу
+		dLat := tt.radiusM / earthRadiusM * 180 / math.Pi * 0.999
+		for _, lat := range []float64{tt.center.Lat + dLat, tt.center.Lat - dLat} {
+			if p := (Point{lat, tt.center.Lon}); p.Valid() && !b.Contains(p) {
+				t.Errorf("RadiusBBox(%v, %v) = %+v does not contain %v", tt.center, tt.radiusM, b, p)
+			}
+		}
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	square := Polygon{{0, 0}, {0, 2}, {2, 2}, {2, 0}}
+	concave := Polygon{{0, 0}, {0, 4}, {4, 4}, {4, 3}, {1, 3}, {1, 1}, {4, 1}, {4, 0}} // «U», открытая к северу
+
+	tests := []struct {
+		name string
+		p    Polygon
+		pt   Point
+		want bool
+	}{
+		{"inside", square, Point{1, 1}, true},
+		{"outside", square, Point{3, 1}, false},
+		{"closed ring", append(square, square[0]), Point{1, 1}, true},
+		{"concave base", concave, Point{0.5, 2}, true},
+		{"concave notch", concave, Point{2, 2}, false},
+		{"concave arm", concave, Point{3.5, 0.5}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Contains(tt.pt); got != tt.want {
+			t.Errorf("%s: Contains(%v) = %v, want %v", tt.name, tt.pt, got, tt.want)
+		}
+	}
+}
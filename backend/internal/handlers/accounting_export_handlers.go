@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"mediawork/internal/models"
+	"mediawork/internal/services"
+)
+
+// AccountingExportHandler — выгрузки счетов, строк и оплат для бухгалтерии (только админ)
+type AccountingExportHandler struct {
+	svc *services.AccountingExportService
+}
+
+func NewAccountingExportHandler(s *services.AccountingExportService) *AccountingExportHandler {
+	return &AccountingExportHandler{svc: s}
+}
+
+type accountingExportRequest struct {
+	Format      string `json:"format"`      // csv | json | xml
+	Incremental bool   `json:"incremental"` // всё новое с прошлой инкрементальной выгрузки
+	From        string `json:"from"`        // YYYY-MM-DD, включительно
+	To          string `json:"to"`
+}
+
+// writeExport отдаёт файл целиком: ошибку формирования ещё можно вернуть статусом
+func writeExport(w http.ResponseWriter, e *models.AccountingExport, format string, body *bytes.Buffer) {
+	if format == "" {
+		format = e.Format
+	}
+	contentType, ext := services.ExportContentType(format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="accounting-export-%d.%s"`, e.ID, ext))
+	w.Header().Set("X-Export-ID", strconv.FormatInt(e.ID, 10))
+	w.Write(body.Bytes())
+}
+
+// POST /api/admin/accounting-exports {format, incremental | from, to}
+func (h *AccountingExportHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req accountingExportRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	from, to, err := services.ParseExportPeriod(req.From, req.To)
+	if err != nil {
+		writeError(w, r, err, "")
+		return
+	}
+	ex := services.ExportRequest{
+		Format:      req.Format,
+		Incremental: req.Incremental,
+		PeriodFrom:  from,
+		PeriodTo:    to,
+	}
+	if claims := GetUserClaims(r); claims != nil {
+		ex.CreatedBy = &claims.UserID
+	}
+
+	var body bytes.Buffer
+	e, err := h.svc.Export(r.Context(), ex, &body)
+	switch {
+	case err != nil && e == nil:
+		writeError(w, r, err, "")
+		return
+	case err != nil:
+		// выгрузка уже в журнале — её можно скачать повторно
+		slog.ErrorContext(r.Context(), "accounting export not generated", "export_id", e.ID, "err", err)
+		httpError(w, r, http.StatusInternalServerError, fmt.Sprintf("export %d recorded but not generated, download it again", e.ID))
+		return
+	}
+	writeExport(w, e, req.Format, &body)
+}
+
+// GET /api/admin/accounting-exports/{id}/download?format= — повтор выгрузки (тот же состав)
+func (h *AccountingExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "export")
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+
+	var body bytes.Buffer
+	e, err := h.svc.Redownload(r.Context(), id, format, &body)
+	if err != nil {
+		writeError(w, r, err, "export not found")
+		return
+	}
+	writeExport(w, e, format, &body)
+}
+
+// GET /api/admin/accounting-exports?limit=&offset=
+func (h *AccountingExportHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pageParams(r)
+
+	list, err := h.svc.List(r.Context(), limit, offset)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
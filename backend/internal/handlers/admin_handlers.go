@@ -1,9 +1,9 @@
 package handlers
 
 import (
-    "encoding/json"
     "mediawork/internal/services"
     "net/http"
+    "strconv"
 )
 
 type AdminHandler struct {
@@ -14,12 +14,135 @@ func NewAdminHandler(s *services.AdminService) *AdminHandler {
     return &AdminHandler{svc: s}
 }
 
+// pageParams — ?limit=&offset= (нормализует сервис)
+func pageParams(r *http.Request) (int, int) {
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+    return limit, offset
+}
+
+// GET /api/admin/users?q=&role=&status=active|disabled&from=&to=&sort=&limit=&cursor=
+// (active=true|false — прежняя форма status)
 func (h *AdminHandler) Users(w http.ResponseWriter, r *http.Request) {
-    list, _ := h.svc.ListUsers(r.Context(), 0, 50)
-    json.NewEncoder(w).Encode(list)
+    q, ok := listQuery(w, r)
+    if !ok {
+        return
+    }
+
+    if v := r.URL.Query().Get("active"); v != "" && q.Status == "" {
+        active, err := strconv.ParseBool(v)
+        if err != nil {
+            fieldError(w, r, "active", "active must be true or false")
+            return
+        }
+        q.Status = "disabled"
+        if active {
+            q.Status = "active"
+        }
+    }
+
+    page, err := h.svc.ListUsers(r.Context(), q)
+    if err != nil {
+        writeError(w, r, err, "")
+        return
+    }
+    writeJSON(w, http.StatusOK, page)
+}
+
+func (h *AdminHandler) User(w http.ResponseWriter, r *http.Request) {
+    id, ok := pathID(w, r, "id", "user")
+    if !ok {
+        return
+    }
+
+    u, err := h.svc.GetUser(r.Context(), id)
+    if err != nil {
+        writeError(w, r, err, "user not found")
+        return
+    }
+    writeJSON(w, http.StatusOK, u)
 }
 
+// GET /api/admin/companies?q=&limit=&offset=
+// GET /api/admin/companies?q=&status=active|inactive&from=&to=&sort=&limit=&cursor=
 func (h *AdminHandler) Companies(w http.ResponseWriter, r *http.Request) {
-    list, _ := h.svc.ListCompanies(r.Context(), 0, 50)
-    json.NewEncoder(w).Encode(list)
+    q, ok := listQuery(w, r)
+    if !ok {
+        return
+    }
+
+    page, err := h.svc.ListCompanies(r.Context(), q)
+    if err != nil {
+        writeError(w, r, err, "")
+        return
+    }
+    writeJSON(w, http.StatusOK, page)
+}
+
+type setRoleRequest struct {
+    Role string `json:"role"`
+}
+
+func (h *AdminHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+    id, ok := pathID(w, r, "id", "user")
+    if !ok {
+        return
+    }
+
+    var req setRoleRequest
+    if !decodeJSON(w, r, &req) {
+        return
+    }
+    if req.Role == "" {
+        fieldError(w, r, "role", "role is required")
+        return
+    }
+
+    if err := h.svc.SetRole(r.Context(), id, req.Role); err != nil {
+        writeError(w, r, err, "user not found")
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) setActive(w http.ResponseWriter, r *http.Request, active bool) {
+    id, ok := pathID(w, r, "id", "user")
+    if !ok {
+        return
+    }
+
+    if err := h.svc.SetActive(r.Context(), GetUserClaims(r).UserID, id, active); err != nil {
+        writeError(w, r, err, "user not found")
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) Disable(w http.ResponseWriter, r *http.Request) {
+    h.setActive(w, r, false)
+}
+
+func (h *AdminHandler) Enable(w http.ResponseWriter, r *http.Request) {
+    h.setActive(w, r, true)
+}
+
+func (h *AdminHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+    id, ok := pathID(w, r, "id", "user")
+    if !ok {
+        return
+    }
+
+    token, user, err := h.svc.Impersonate(r.Context(), GetUserClaims(r).UserID, id)
+    if err != nil {
+        writeError(w, r, err, "user not found")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, map[string]any{
+        "token":          token,
+        "user":           user,
+        "expires_in_sec": int(services.ImpersonationTTL.Seconds()),
+    })
 }
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mediawork/internal/services"
+)
+
+type AnalyticsHandler struct {
+	svc *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(s *services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{svc: s}
+}
+
+// parseTimeParam принимает YYYY-MM-DD или RFC3339.
+// Для "to" в формате даты берём конец дня, чтобы ?from=2024-05-01&to=2024-05-31 включал 31-е.
+func parseTimeParam(r *http.Request, name string, endOfDay bool) (*time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, &services.ValidationError{Field: name, Message: fmt.Sprintf("invalid %s: expected YYYY-MM-DD or RFC3339", name)}
+	}
+	if endOfDay {
+		t = t.Add(24 * time.Hour)
+	}
+	return &t, nil
+}
+
+func parseRange(r *http.Request) (*time.Time, *time.Time, error) {
+	from, err := parseTimeParam(r, "from", false)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := parseTimeParam(r, "to", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return from, to, nil
+}
+
+func wantsCSV(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "csv" || r.Header.Get("Accept") == "text/csv"
+}
+
+func writeCSV(w http.ResponseWriter, filename string, header []string, rows [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(rows)
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+// GET /analytics/campaigns/{id}/delivery
+func (h *AnalyticsHandler) Delivery(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "campaign")
+	if !ok {
+		return
+	}
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeError(w, r, err, "")
+		return
+	}
+
+	d, err := h.svc.Delivery(r.Context(), id, from, to)
+	if err != nil {
+		writeError(w, r, err, "campaign not found")
+		return
+	}
+
+	if wantsCSV(r) {
+		writeCSV(w, fmt.Sprintf("campaign-%d-delivery.csv", id),
+			[]string{
+				"campaign_id", "from", "to", "plays", "airtime_sec", "booked_sec", "pacing",
+				"avg_bitrate_kbps", "avg_sync_latency_ms", "max_sync_latency_ms",
+			},
+			[][]string{{
+				strconv.FormatInt(d.CampaignID, 10),
+				d.From.Format(time.RFC3339),
+				d.To.Format(time.RFC3339),
+				strconv.FormatInt(d.Plays, 10),
+				strconv.FormatInt(d.AirtimeSec, 10),
+				strconv.FormatInt(d.BookedSec, 10),
+				strconv.FormatFloat(d.Pacing, 'f', 4, 64),
+				ftoa(d.AvgBitrateKbps),
+				ftoa(d.AvgSyncLatencyMS),
+				strconv.Itoa(d.MaxSyncLatencyMS),
+			}},
+		)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, d)
+}
+
+// GET /analytics/campaigns/{id}/breakdown?by=facade|day|hour
+func (h *AnalyticsHandler) Breakdown(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "campaign")
+	if !ok {
+		return
+	}
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeError(w, r, err, "")
+		return
+	}
+
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = "day"
+	}
+	if by != "facade" && by != "day" && by != "hour" {
+		fieldError(w, r, "by", "by must be one of facade, day, hour")
+		return
+	}
+
+	list, err := h.svc.Breakdown(r.Context(), id, by, from, to)
+	if err != nil {
+		writeError(w, r, err, "campaign not found")
+		return
+	}
+
+	if wantsCSV(r) {
+		header := []string{by, "plays", "airtime_sec", "avg_bitrate_kbps", "avg_sync_latency_ms", "max_sync_latency_ms"}
+		if by == "facade" {
+			header = append([]string{"facade_id", "facade_name"}, header[1:]...)
+		}
+
+		rows := make([][]string, 0, len(list))
+		for _, row := range list {
+			var key []string
+			if row.FacadeID != nil {
+				key = []string{strconv.FormatInt(*row.FacadeID, 10), row.FacadeName}
+			} else {
+				key = []string{row.Bucket.Format(time.RFC3339)}
+			}
+			rows = append(rows, append(key,
+				strconv.FormatInt(row.Plays, 10),
+				strconv.FormatInt(row.AirtimeSec, 10),
+				ftoa(row.AvgBitrateKbps),
+				ftoa(row.AvgSyncLatencyMS),
+				strconv.Itoa(row.MaxSyncLatencyMS),
+			))
+		}
+
+		writeCSV(w, fmt.Sprintf("campaign-%d-by-%s.csv", id, by), header, rows)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// GET /analytics/campaigns/{id}/impressions
+func (h *AnalyticsHandler) Impressions(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "campaign")
+	if !ok {
+		return
+	}
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeError(w, r, err, "")
+		return
+	}
+
+	res, err := h.svc.Impressions(r.Context(), id, from, to)
+	if err != nil {
+		writeError(w, r, err, "campaign not found")
+		return
+	}
+
+	if wantsCSV(r) {
+		rows := make([][]string, 0, len(res.Facades))
+		for _, f := range res.Facades {
+			rows = append(rows, []string{
+				strconv.FormatInt(f.FacadeID, 10),
+				f.FacadeName,
+				strconv.FormatInt(f.Plays, 10),
+				strconv.FormatInt(f.AirtimeSec, 10),
+				ftoa(f.Impressions),
+				ftoa(f.Reach),
+				ftoa(f.Frequency),
+				ftoa(f.Cost),
+				f.Currency,
+				ftoa(f.CPM),
+			})
+		}
+
+		writeCSV(w, fmt.Sprintf("campaign-%d-impressions.csv", id),
+			[]string{
+				"facade_id", "facade_name", "plays", "airtime_sec", "impressions",
+				"reach", "frequency", "cost", "currency", "cpm",
+			},
+			rows,
+		)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
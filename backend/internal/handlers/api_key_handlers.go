@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/services"
+)
+
+// APIKeyHandler — выпуск, список и отзыв API-ключей компании (admin / owner)
+type APIKeyHandler struct {
+	svc *services.APIKeyService
+}
+
+func NewAPIKeyHandler(s *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{svc: s}
+}
+
+func writeAPIKeyError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, services.ErrCompanyInactive) {
+		httpError(w, r, http.StatusForbidden, err.Error())
+		return
+	}
+	writeError(w, r, err, "api key not found")
+}
+
+// GET /api/companies/{id}/api-keys — без самих ключей, только prefix и last_used_at
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	companyID, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	list, err := h.svc.List(r.Context(), companyID, GetUserClaims(r))
+	if err != nil {
+		writeAPIKeyError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+type apiKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// POST /api/companies/{id}/api-keys {name, scopes, expires_at} — ключ в ответе показывается один раз
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	companyID, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	var req apiKeyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	k := models.APIKey{CompanyID: companyID, Name: req.Name, Scopes: req.Scopes, ExpiresAt: req.ExpiresAt}
+	if err := h.svc.Create(r.Context(), &k, GetUserClaims(r)); err != nil {
+		writeAPIKeyError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, k)
+}
+
+// DELETE /api/companies/{id}/api-keys/{keyID} — отзыв
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	companyID, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+	keyID, ok := pathID(w, r, "keyID", "api key")
+	if !ok {
+		return
+	}
+
+	k, err := h.svc.Revoke(r.Context(), companyID, keyID, GetUserClaims(r))
+	if err != nil {
+		writeAPIKeyError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, k)
+}
+
+//
+// ---------- ACCESS POLICY FOR API KEYS ----------
+//
+// APIKeyRoute — эндпоинт, доступный по API-ключу.
+// Pattern в синтаксисе http.ServeMux ("GET /api/campaigns/{id}"); Owner — компания ресурса {id},
+// ключ чужой компании получает 404, как будто ресурса нет.
+type APIKeyRoute struct {
+	Pattern string
+	Scope   string
+	Owner   func(ctx context.Context, id int64) (int64, error)
+}
+
+// APIKeyGuard пропускает запросы по JWT как есть, а запросы по ключу — только на
+// перечисленные маршруты и только при нужном scope. Всё остальное для ключа закрыто.
+func APIKeyGuard(routes []APIKeyRoute) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		policy := http.NewServeMux()
+		for _, rt := range routes {
+			policy.Handle(rt.Pattern, apiKeyRoute(rt, next))
+		}
+		policy.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			httpError(w, r, http.StatusForbidden, "endpoint is not available for api keys")
+		})
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetUserClaims(r)
+			if claims == nil || claims.APIKeyID == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			policy.ServeHTTP(w, r)
+		})
+	}
+}
+
+func apiKeyRoute(rt APIKeyRoute, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := GetUserClaims(r)
+		if !services.HasScope(claims, rt.Scope) {
+			httpError(w, r, http.StatusForbidden, "api key lacks scope "+rt.Scope)
+			return
+		}
+
+		if rt.Owner != nil {
+			id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+			if err != nil {
+				httpError(w, r, http.StatusBadRequest, "invalid id")
+				return
+			}
+			owner, err := rt.Owner(r.Context(), id)
+			if errors.Is(err, sql.ErrNoRows) || (err == nil && owner != claims.CompanyID) {
+				httpError(w, r, http.StatusNotFound, "not found")
+				return
+			}
+			if err != nil {
+				serverError(w, r, err)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiKeyCompany — компания API-ключа запроса; 0 — запрос пользователя по JWT
+func apiKeyCompany(r *http.Request) int64 {
+	if claims := GetUserClaims(r); claims != nil && claims.APIKeyID != 0 {
+		return claims.CompanyID
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"mediawork/internal/models"
+	"mediawork/internal/services"
+)
+
+type AuditHandler struct {
+	svc *services.AuditService
+}
+
+func NewAuditHandler(s *services.AuditService) *AuditHandler {
+	return &AuditHandler{svc: s}
+}
+
+func queryInt64(r *http.Request, name string) (*int64, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, true
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &n, true
+}
+
+// GET /api/admin/audit?entity_type=&entity_id=&actor_id=&action=&from=&to=&limit=&offset=
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	f := models.AuditFilter{
+		EntityType: q.Get("entity_type"),
+		Action:     q.Get("action"),
+	}
+
+	var ok bool
+	if f.EntityID, ok = queryInt64(r, "entity_id"); !ok {
+		fieldError(w, r, "entity_id", "entity_id must be an integer")
+		return
+	}
+	if f.ActorID, ok = queryInt64(r, "actor_id"); !ok {
+		fieldError(w, r, "actor_id", "actor_id must be an integer")
+		return
+	}
+
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeError(w, r, err, "")
+		return
+	}
+	f.From, f.To = from, to
+
+	f.Limit, _ = strconv.Atoi(q.Get("limit"))
+	f.Offset, _ = strconv.Atoi(q.Get("offset"))
+
+	list, err := h.svc.List(r.Context(), f)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
@@ -1,8 +1,8 @@
 package handlers
 
 import (
-	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"mediawork/internal/services"
 	"net/http"
 )
@@ -22,18 +22,25 @@ type loginRequest struct {
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
     var req loginRequest
-    json.NewDecoder(r.Body).Decode(&req)
+    if !decodeJSON(w, r, &req) {
+        return
+    }
 
     token, user, err := h.auth.Login(r.Context(), req.Email, req.Password)
-    log.Println("Login attempt for:", req.Email)
-    log.Println("Error:", err)
-    log.Println("Generated Token:", token)
     if err != nil {
-        http.Error(w, "invalid credentials", 401)
+        // адрес маскируется при записи, пароль и токен в лог не попадают
+        slog.InfoContext(r.Context(), "login failed", "email", req.Email, "err", err)
+    }
+    if errors.Is(err, services.ErrAccountDisabled) {
+        httpError(w, r, http.StatusForbidden, "account disabled")
+        return
+    }
+    if err != nil {
+        httpError(w, r, http.StatusUnauthorized, "invalid credentials")
         return
     }
 
-    json.NewEncoder(w).Encode(map[string]interface{}{
+    writeJSON(w, http.StatusOK, map[string]interface{}{
         "token": token,
         "user":  user,
     })
@@ -47,13 +54,15 @@ type registerRequest struct {
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
     var req registerRequest
-    json.NewDecoder(r.Body).Decode(&req)
+    if !decodeJSON(w, r, &req) {
+        return
+    }
 
     user, err := h.auth.Register(r.Context(), req.Email, req.Password, req.Name)
     if err != nil {
-        http.Error(w, err.Error(), 400)
+        writeError(w, r, err, "")
         return
     }
 
-    json.NewEncoder(w).Encode(user)
+    writeJSON(w, http.StatusCreated, user)
 }
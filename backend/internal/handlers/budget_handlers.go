@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/services"
+)
+
+// BudgetHandler — бюджеты кампаний / компаний и предоплаченные кошельки
+type BudgetHandler struct {
+	svc *services.BudgetService
+}
+
+func NewBudgetHandler(s *services.BudgetService) *BudgetHandler {
+	return &BudgetHandler{svc: s}
+}
+
+//
+// ---------- CAMPAIGN BUDGET ----------
+//
+// GET /api/campaigns/{id}/budget — лимиты, потрачено, кошелёк и снята ли кампания с расписания
+func (h *BudgetHandler) Campaign(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "campaign")
+	if !ok {
+		return
+	}
+
+	st, err := h.svc.CampaignStatus(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "campaign not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, st)
+}
+
+// PUT /api/campaigns/{id}/budget {amount_limit, airtime_limit_sec}
+func (h *BudgetHandler) SetCampaign(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "campaign")
+	if !ok {
+		return
+	}
+
+	var req services.BudgetLimits
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	b, err := h.svc.SetCampaignBudget(r.Context(), GetUserClaims(r), id, req)
+	if err != nil {
+		writeError(w, r, err, "campaign not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+func (h *BudgetHandler) DeleteCampaign(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "campaign")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteCampaignBudget(r.Context(), GetUserClaims(r), id); err != nil {
+		writeError(w, r, err, "budget not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//
+// ---------- COMPANY BUDGET (admin) ----------
+//
+func (h *BudgetHandler) Company(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	b, err := h.svc.CompanyBudget(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "budget not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+func (h *BudgetHandler) SetCompany(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	var req services.BudgetLimits
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	b, err := h.svc.SetCompanyBudget(r.Context(), id, req)
+	if err != nil {
+		writeError(w, r, err, "company not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+func (h *BudgetHandler) DeleteCompany(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteCompanyBudget(r.Context(), id); err != nil {
+		writeError(w, r, err, "budget not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//
+// ---------- WALLET (admin) ----------
+//
+func (h *BudgetHandler) Wallet(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	wallet, err := h.svc.Wallet(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "wallet not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, wallet)
+}
+
+// GET /api/admin/companies/{id}/wallet/transactions?limit=&offset=
+func (h *BudgetHandler) Transactions(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+	limit, offset := pageParams(r)
+
+	list, err := h.svc.Transactions(r.Context(), id, limit, offset)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+type topUpRequest struct {
+	Amount     money.Decimal `json:"amount"`
+	Reference  string        `json:"reference"` // номер платёжки и т.п.
+	Note       string        `json:"note"`
+	Adjustment bool          `json:"adjustment"` // ручная корректировка, сумма со знаком
+}
+
+// POST /api/admin/companies/{id}/wallet/topup
+func (h *BudgetHandler) TopUp(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	var req topUpRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	t := models.WalletTransaction{
+		CompanyID: id,
+		Amount:    req.Amount,
+		Reference: req.Reference,
+		Note:      req.Note,
+	}
+	if claims := GetUserClaims(r); claims != nil {
+		t.CreatedBy = &claims.UserID
+	}
+
+	wallet, err := h.svc.TopUp(r.Context(), &t, req.Adjustment)
+	if err != nil {
+		writeError(w, r, err, "company not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, wallet)
+}
@@ -1,13 +1,9 @@
 package handlers
 
 import (
-    "encoding/json"
     "mediawork/internal/models"
     "mediawork/internal/services"
     "net/http"
-    "strconv"
-
-    "github.com/go-chi/chi/v5"
 )
 
 type CampaignHandler struct {
@@ -25,35 +21,73 @@ type campaignCreateRequest struct {
 
 func (h *CampaignHandler) Create(w http.ResponseWriter, r *http.Request) {
     var req campaignCreateRequest
-    json.NewDecoder(r.Body).Decode(&req)
+    if !decodeJSON(w, r, &req) {
+        return
+    }
 
-    id, err := h.svc.Create(r.Context(), &req.Campaign, req.Slots)
+    // по API-ключу кампания создаётся только в компании ключа
+    if companyID := apiKeyCompany(r); companyID != 0 {
+        req.Campaign.CompanyID = companyID
+    }
+
+    id, err := h.svc.Create(r.Context(), GetUserClaims(r), &req.Campaign, req.Slots)
     if err != nil {
-        http.Error(w, err.Error(), 400)
+        writeError(w, r, err, "company not found")
         return
     }
 
-    json.NewEncoder(w).Encode(map[string]any{"id": id})
+    writeJSON(w, http.StatusCreated, map[string]any{"id": id})
 }
 
 func (h *CampaignHandler) Get(w http.ResponseWriter, r *http.Request) {
-    id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+    id, ok := pathID(w, r, "id", "campaign")
+    if !ok {
+        return
+    }
 
     data, err := h.svc.GetDetailed(r.Context(), id)
     if err != nil {
-        http.Error(w, err.Error(), 400)
+        writeError(w, r, err, "campaign not found")
         return
     }
 
-    json.NewEncoder(w).Encode(data)
+    writeJSON(w, http.StatusOK, data)
 }
 
+// GET /api/campaigns?status=&company_id=&from=&to=&q=&sort=&limit=&cursor=
+// (по API-ключу — только кампании его компании)
 func (h *CampaignHandler) List(w http.ResponseWriter, r *http.Request) {
-    data, err := h.svc.List(r.Context())
+    q, ok := listQuery(w, r)
+    if !ok {
+        return
+    }
+
+    page, err := h.svc.List(r.Context(), q)
+    if err != nil {
+        writeError(w, r, err, "")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, page)
+}
+
+func (h *CampaignHandler) Update(w http.ResponseWriter, r *http.Request) {
+    id, ok := pathID(w, r, "id", "campaign")
+    if !ok {
+        return
+    }
+
+    // меняются только переданные поля
+    var patch services.CampaignPatch
+    if !decodeJSON(w, r, &patch) {
+        return
+    }
+
+    c, err := h.svc.Update(r.Context(), GetUserClaims(r), id, patch)
     if err != nil {
-        http.Error(w, err.Error(), 500)
+        writeError(w, r, err, "campaign not found")
         return
     }
 
-    json.NewEncoder(w).Encode(data)
+    writeJSON(w, http.StatusOK, c)
 }
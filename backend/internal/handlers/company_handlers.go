@@ -1,13 +1,9 @@
 package handlers
 
 import (
-    "encoding/json"
     "mediawork/internal/models"
     "mediawork/internal/services"
     "net/http"
-    "strconv"
-
-    "github.com/go-chi/chi/v5"
 )
 
 type CompanyHandler struct {
@@ -20,34 +16,107 @@ func NewCompanyHandler(s *services.CompanyService) *CompanyHandler {
 
 func (h *CompanyHandler) Create(w http.ResponseWriter, r *http.Request) {
     var body models.Company
-    json.NewDecoder(r.Body).Decode(&body)
+    if !decodeJSON(w, r, &body) {
+        return
+    }
+
+    // создатель становится владельцем
+    body.OwnerID = GetUserClaims(r).UserID
+    body.IsActive = true
 
     id, err := h.svc.CreateCompany(r.Context(), &body)
     if err != nil {
-        http.Error(w, err.Error(), 400)
+        writeError(w, r, err, "company not found")
         return
     }
 
-    json.NewEncoder(w).Encode(map[string]any{"id": id})
+    writeJSON(w, http.StatusCreated, map[string]any{"id": id})
 }
 
+// GET /api/companies?status=&from=&to=&q=&sort=&limit=&cursor=
 func (h *CompanyHandler) List(w http.ResponseWriter, r *http.Request) {
-    list, _ := h.svc.ListCompanies(r.Context(), 0, 50)
-    json.NewEncoder(w).Encode(list)
+    q, ok := listQuery(w, r)
+    if !ok {
+        return
+    }
+
+    page, err := h.svc.ListCompanies(r.Context(), q)
+    if err != nil {
+        writeError(w, r, err, "")
+        return
+    }
+    writeJSON(w, http.StatusOK, page)
 }
 
 func (h *CompanyHandler) GetDetailed(w http.ResponseWriter, r *http.Request) {
-    id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+    id, ok := pathID(w, r, "id", "company")
+    if !ok {
+        return
+    }
 
     company, members, err := h.svc.GetDetailed(r.Context(), id)
     if err != nil {
-        http.Error(w, err.Error(), 400)
+        writeError(w, r, err, "company not found")
         return
     }
 
-    json.NewEncoder(w).Encode(map[string]any{
+    writeJSON(w, http.StatusOK, map[string]any{
         "company":  company,
         "members":  members,
     })
 }
 
+type deactivateRequest struct {
+    Reason string `json:"reason"`
+}
+
+func (h *CompanyHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+    id, ok := pathID(w, r, "id", "company")
+    if !ok {
+        return
+    }
+
+    // причина необязательна — пустое тело допустимо
+    var req deactivateRequest
+    if !decodeOptionalJSON(w, r, &req) {
+        return
+    }
+
+    susp, err := h.svc.DeactivateCompany(r.Context(), id, req.Reason, GetUserClaims(r).UserID)
+    if err != nil {
+        writeError(w, r, err, "company not found")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, susp)
+}
+
+func (h *CompanyHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+    id, ok := pathID(w, r, "id", "company")
+    if !ok {
+        return
+    }
+
+    susp, err := h.svc.ReactivateCompany(r.Context(), id, GetUserClaims(r).UserID)
+    if err != nil {
+        writeError(w, r, err, "company not found")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, susp)
+}
+
+func (h *CompanyHandler) Suspensions(w http.ResponseWriter, r *http.Request) {
+    id, ok := pathID(w, r, "id", "company")
+    if !ok {
+        return
+    }
+
+    list, err := h.svc.ListSuspensions(r.Context(), id)
+    if err != nil {
+        serverError(w, r, err)
+        return
+    }
+
+    writeJSON(w, http.StatusOK, list)
+}
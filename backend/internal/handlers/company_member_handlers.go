@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"mediawork/internal/services"
+)
+
+// membershipError — статус по ошибке CompanyService
+func membershipError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrForbidden), errors.Is(err, services.ErrInvitationMismatch),
+		errors.Is(err, services.ErrCompanyInactive):
+		httpError(w, r, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrInvitationInvalid):
+		httpError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInvitationExpired):
+		httpError(w, r, http.StatusGone, err.Error())
+	default:
+		writeError(w, r, err, "not found")
+	}
+}
+
+// ---------- MEMBERS ----------
+
+func (h *CompanyHandler) Members(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	list, err := h.svc.ListMembers(r.Context(), id, GetUserClaims(r).UserID)
+	if err != nil {
+		membershipError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+type memberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func (h *CompanyHandler) ChangeMemberRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+	userID, ok := pathID(w, r, "userID", "user")
+	if !ok {
+		return
+	}
+
+	var req memberRoleRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.svc.ChangeMemberRole(r.Context(), id, GetUserClaims(r).UserID, userID, req.Role); err != nil {
+		membershipError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CompanyHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+	userID, ok := pathID(w, r, "userID", "user")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.RemoveMember(r.Context(), id, GetUserClaims(r).UserID, userID); err != nil {
+		membershipError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type transferOwnershipRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+func (h *CompanyHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	var req transferOwnershipRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == 0 {
+		fieldError(w, r, "user_id", "user_id is required")
+		return
+	}
+
+	if err := h.svc.TransferOwnership(r.Context(), id, GetUserClaims(r).UserID, req.UserID); err != nil {
+		membershipError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ---------- INVITATIONS ----------
+
+type inviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+func (h *CompanyHandler) Invite(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	var req inviteRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	inv, err := h.svc.Invite(r.Context(), id, GetUserClaims(r).UserID, req.Email, req.Role)
+	if err != nil {
+		membershipError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, inv)
+}
+
+func (h *CompanyHandler) Invitations(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	list, err := h.svc.ListInvitations(r.Context(), id, GetUserClaims(r).UserID)
+	if err != nil {
+		membershipError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *CompanyHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+	invID, ok := pathID(w, r, "invitationID", "invitation")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.RevokeInvitation(r.Context(), id, GetUserClaims(r).UserID, invID); err != nil {
+		membershipError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MyInvitations — GET /api/me/invitations
+func (h *CompanyHandler) MyInvitations(w http.ResponseWriter, r *http.Request) {
+	list, err := h.svc.PendingInvitations(r.Context(), GetUserClaims(r).UserID)
+	if err != nil {
+		membershipError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *CompanyHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	inv, err := h.svc.AcceptInvitation(r.Context(), chi.URLParam(r, "token"), GetUserClaims(r).UserID)
+	if err != nil {
+		membershipError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, inv)
+}
+
+func (h *CompanyHandler) DeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeclineInvitation(r.Context(), chi.URLParam(r, "token"), GetUserClaims(r).UserID); err != nil {
+		membershipError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mediawork/internal/services"
+)
+
+type CreativeHandler struct {
+	svc *services.CreativeService
+}
+
+func NewCreativeHandler(s *services.CreativeService) *CreativeHandler {
+	return &CreativeHandler{svc: s}
+}
+
+// GET /api/creatives?company_id=&from=&to=&q=&sort=&limit=&cursor=
+func (h *CreativeHandler) List(w http.ResponseWriter, r *http.Request) {
+	q, ok := listQuery(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.svc.List(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err, "")
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mediawork/internal/geo"
+	"mediawork/internal/services"
+)
+
+// geoQueryFromRequest разбирает общие параметры поиска: status, available, from, to
+func geoQueryFromRequest(r *http.Request) (services.GeoQuery, error) {
+	q := services.GeoQuery{
+		Status:        r.URL.Query().Get("status"),
+		OnlyAvailable: r.URL.Query().Get("available") == "true",
+	}
+
+	from, to, err := parseRange(r)
+	if err != nil {
+		return q, err
+	}
+	if from != nil {
+		q.From = *from
+	}
+	if to != nil {
+		q.To = *to
+	}
+	return q, nil
+}
+
+func parseFloats(v string, n int) ([]float64, error) {
+	parts := strings.Split(v, ",")
+	if len(parts) != n {
+		return nil, errors.New("wrong number of coordinates")
+	}
+	out := make([]float64, n)
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = f
+	}
+	return out, nil
+}
+
+func writeGeoJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/geo+json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// GET /facades/geo?bbox=minLon,minLat,maxLon,maxLat
+// GET /facades/geo?lat=..&lon=..&radius_m=2000
+func (h *FacadeHandler) GeoSearch(w http.ResponseWriter, r *http.Request) {
+	q, err := geoQueryFromRequest(r)
+	if err != nil {
+		writeError(w, r, err, "")
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case query.Get("bbox") != "":
+		c, err := parseFloats(query.Get("bbox"), 4)
+		if err != nil {
+			fieldError(w, r, "bbox", "bbox must be minLon,minLat,maxLon,maxLat")
+			return
+		}
+		q.BBox = &geo.BBox{MinLon: c[0], MinLat: c[1], MaxLon: c[2], MaxLat: c[3]}
+
+	case query.Get("lat") != "" || query.Get("lon") != "":
+		lat, err1 := strconv.ParseFloat(query.Get("lat"), 64)
+		lon, err2 := strconv.ParseFloat(query.Get("lon"), 64)
+		radius, err3 := strconv.ParseFloat(query.Get("radius_m"), 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			fieldError(w, r, "radius_m", "lat, lon and radius_m must be numbers")
+			return
+		}
+		q.Center = &geo.Point{Lat: lat, Lon: lon}
+		q.RadiusM = radius
+
+	default:
+		// без фильтра — весь мир, для карты со всеми экранами
+		q.BBox = &geo.BBox{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}
+	}
+
+	fc, err := h.svc.Search(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err, "")
+		return
+	}
+
+	writeGeoJSON(w, fc)
+}
+
+type polygonRequest struct {
+	Type        string          `json:"type"`
+	Coordinates [][][2]float64  `json:"coordinates"`
+	Geometry    *polygonRequest `json:"geometry"` // допускаем и Feature, и голую геометрию
+
+	// прочие члены Feature — принимаем, но не используем
+	ID         json.RawMessage `json:"id,omitempty"`
+	BBox       json.RawMessage `json:"bbox,omitempty"`
+	Properties json.RawMessage `json:"properties,omitempty"`
+}
+
+// POST /facades/geo/within — тело: GeoJSON Polygon (или Feature с Polygon)
+func (h *FacadeHandler) GeoWithin(w http.ResponseWriter, r *http.Request) {
+	q, err := geoQueryFromRequest(r)
+	if err != nil {
+		writeError(w, r, err, "")
+		return
+	}
+
+	var body polygonRequest
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	if body.Geometry != nil {
+		body = *body.Geometry
+	}
+	if body.Type != "Polygon" || len(body.Coordinates) == 0 {
+		fieldError(w, r, "type", "geometry must be a Polygon")
+		return
+	}
+
+	// берём только внешний контур; дыры в полигоне не поддерживаем
+	for _, c := range body.Coordinates[0] {
+		q.Polygon = append(q.Polygon, geo.Point{Lon: c[0], Lat: c[1]})
+	}
+
+	fc, err := h.svc.Search(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err, "")
+		return
+	}
+
+	writeGeoJSON(w, fc)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"mediawork/internal/models"
+	"mediawork/internal/services"
+)
+
+type FacadeGroupHandler struct {
+	svc *services.FacadeGroupService
+}
+
+func NewFacadeGroupHandler(s *services.FacadeGroupService) *FacadeGroupHandler {
+	return &FacadeGroupHandler{svc: s}
+}
+
+func urlID(r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	return id, err == nil
+}
+
+// ---------- TAGS ----------
+
+func (h *FacadeGroupHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "facade")
+	if !ok {
+		return
+	}
+
+	tags, err := h.svc.GetTags(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "facade not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tags)
+}
+
+func (h *FacadeGroupHandler) SetTags(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "facade")
+	if !ok {
+		return
+	}
+
+	var tags map[string]string
+	if !decodeJSON(w, r, &tags) {
+		return
+	}
+
+	saved, err := h.svc.SetTags(r.Context(), id, tags)
+	if err != nil {
+		writeError(w, r, err, "facade not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, saved)
+}
+
+func (h *FacadeGroupHandler) TagValues(w http.ResponseWriter, r *http.Request) {
+	values, err := h.svc.TagValues(r.Context())
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"known_keys": services.KnownFacadeTagKeys,
+		"values":     values,
+	})
+}
+
+// ---------- GROUPS ----------
+
+func (h *FacadeGroupHandler) List(w http.ResponseWriter, r *http.Request) {
+	list, err := h.svc.List(r.Context())
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *FacadeGroupHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var g models.DynamicFacadeGroup
+	if !decodeJSON(w, r, &g) {
+		return
+	}
+
+	if err := h.svc.Create(r.Context(), &g); err != nil {
+		writeError(w, r, err, "")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, g)
+}
+
+func (h *FacadeGroupHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "group")
+	if !ok {
+		return
+	}
+
+	g, facades, err := h.svc.GetWithFacades(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "group not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"group":   g,
+		"facades": facades,
+	})
+}
+
+func (h *FacadeGroupHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "group")
+	if !ok {
+		return
+	}
+
+	var g models.DynamicFacadeGroup
+	if !decodeJSON(w, r, &g) {
+		return
+	}
+	g.ID = id
+
+	if err := h.svc.Update(r.Context(), &g); err != nil {
+		writeError(w, r, err, "group not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, g)
+}
+
+func (h *FacadeGroupHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "group")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ---------- CAMPAIGN TARGETS ----------
+
+func (h *FacadeGroupHandler) ListTargets(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "campaign")
+	if !ok {
+		return
+	}
+
+	list, err := h.svc.ListTargets(r.Context(), id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+type targetRequest struct {
+	GroupID int64 `json:"group_id"`
+}
+
+func (h *FacadeGroupHandler) AddTarget(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "campaign")
+	if !ok {
+		return
+	}
+
+	var req targetRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.GroupID == 0 {
+		fieldError(w, r, "group_id", "group_id is required")
+		return
+	}
+
+	if err := h.svc.AddTarget(r.Context(), GetUserClaims(r), id, req.GroupID); err != nil {
+		writeError(w, r, err, "campaign or group not found")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"campaign_id": id, "group_id": req.GroupID})
+}
+
+func (h *FacadeGroupHandler) RemoveTarget(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "campaign")
+	if !ok {
+		return
+	}
+	groupID, ok := pathID(w, r, "groupID", "group")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.RemoveTarget(r.Context(), GetUserClaims(r), id, groupID); err != nil {
+		writeError(w, r, err, "campaign not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
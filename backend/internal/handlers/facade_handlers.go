@@ -2,12 +2,12 @@ package handlers
 
 import (
     "encoding/json"
+    "log/slog"
+    "mediawork/internal/models"
     "mediawork/internal/services"
+    "mediawork/internal/ws"
     "net/http"
-    "strconv"
-    "log"
 
-    "github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 )
 
@@ -26,45 +26,105 @@ func NewFacadeHandler(s *services.FacadeService) *FacadeHandler {
 }
 
 func (h *FacadeHandler) Status(w http.ResponseWriter, r *http.Request) {
-    id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+    id, ok := pathID(w, r, "id", "facade")
+    if !ok {
+        return
+    }
 
     data, err := h.svc.GetStatus(r.Context(), id)
     if err != nil {
-        http.Error(w, err.Error(), 400)
+        writeError(w, r, err, "facade not found")
         return
     }
 
-    json.NewEncoder(w).Encode(data)
+    writeJSON(w, http.StatusOK, data)
 }
 
 
+// GET /api/facades?status=&from=&to=&q=&sort=&limit=&cursor=
 func (h *FacadeHandler) List(w http.ResponseWriter, r *http.Request) {
-    data, err := h.svc.List(r.Context())
+    q, ok := listQuery(w, r)
+    if !ok {
+        return
+    }
+
+    page, err := h.svc.List(r.Context(), q)
+    if err != nil {
+        writeError(w, r, err, "")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, page)
+}
+
+func (h *FacadeHandler) Audience(w http.ResponseWriter, r *http.Request) {
+    id, ok := pathID(w, r, "id", "facade")
+    if !ok {
+        return
+    }
+
+    p, err := h.svc.GetAudience(r.Context(), id)
     if err != nil {
-        http.Error(w, err.Error(), 500)
+        writeError(w, r, err, "audience profile not found")
         return
     }
 
-    json.NewEncoder(w).Encode(data)
+    writeJSON(w, http.StatusOK, p)
+}
+
+func (h *FacadeHandler) SaveAudience(w http.ResponseWriter, r *http.Request) {
+    id, ok := pathID(w, r, "id", "facade")
+    if !ok {
+        return
+    }
+
+    var p models.AudienceProfile
+    if !decodeJSON(w, r, &p) {
+        return
+    }
+    p.FacadeID = id
+
+    if err := h.svc.SaveAudience(r.Context(), &p); err != nil {
+        writeError(w, r, err, "facade not found")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, p)
+}
+
+// IssueDeviceToken — POST /api/admin/facades/{id}/device-token; токен виден только в этом ответе
+func (h *FacadeHandler) IssueDeviceToken(w http.ResponseWriter, r *http.Request) {
+    id, ok := pathID(w, r, "id", "facade")
+    if !ok {
+        return
+    }
+
+    token, err := h.svc.IssueDeviceToken(r.Context(), id)
+    if err != nil {
+        writeError(w, r, err, "facade not found")
+        return
+    }
+
+    writeJSON(w, http.StatusCreated, token)
 }
 
 func (h *FacadeHandler) LiveWS(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	facadeID, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		http.Error(w, "invalid facade id", http.StatusBadRequest)
+	facadeID, ok := pathID(w, r, "id", "facade")
+	if !ok {
 		return
 	}
 
 	// Upgrade to WebSocket
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println("WS Upgrade error:", err)
+		slog.WarnContext(r.Context(), "ws: upgrade failed", "facade_id", facadeID, "err", err)
 		return
 	}
 	defer conn.Close()
+	ws.ClearDeadlines(conn)
 
-	log.Printf("Client connected to facade %d", facadeID)
+	ws.Connections.With("facade_live").Inc()
+	defer ws.Connections.With("facade_live").Dec()
 
 	// ------------------------
 	//  Реальный стрим
@@ -80,7 +140,7 @@ func (h *FacadeHandler) LiveWS(w http.ResponseWriter, r *http.Request) {
 		b, _ := json.Marshal(msg)
 		err := conn.WriteMessage(websocket.TextMessage, b)
 		if err != nil {
-			log.Println("WS write error:", err)
+			slog.InfoContext(r.Context(), "ws: stream closed", "facade_id", facadeID, "err", err)
 			return
 		}
 	}
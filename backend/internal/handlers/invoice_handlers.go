@@ -1,13 +1,10 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
-	"strconv"
-
-	"github.com/go-chi/chi/v5"
 
 	"mediawork/internal/models"
+	"mediawork/internal/repositories"
 	"mediawork/internal/services"
 )
 
@@ -20,57 +17,212 @@ func NewInvoiceHandler(s *services.BillingService) *InvoiceHandler {
 }
 
 func (h *InvoiceHandler) GetPDF(w http.ResponseWriter, r *http.Request) {
-    id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+    id, ok := pathID(w, r, "id", "invoice")
+    if !ok {
+        return
+    }
 
     data, err := h.svc.PreparePDF(r.Context(), id)
     if err != nil {
-        http.Error(w, err.Error(), 400)
+        writeError(w, r, err, "invoice not found")
         return
     }
 
-    json.NewEncoder(w).Encode(data)
+    writeJSON(w, http.StatusOK, data)
 }
 
+// GET /api/invoices?status=&company_id=&from=&to=&q=&sort=&limit=&cursor=
+// (по API-ключу — только документы его компании)
 func (h *InvoiceHandler) List(w http.ResponseWriter, r *http.Request) {
-    data, err := h.svc.List(r.Context())
+    q, ok := listQuery(w, r)
+    if !ok {
+        return
+    }
+
+    page, err := h.svc.List(r.Context(), q)
     if err != nil {
-        http.Error(w, err.Error(), 500)
+        writeError(w, r, err, "")
         return
     }
 
-    json.NewEncoder(w).Encode(data)
+    writeJSON(w, http.StatusOK, page)
 }
 
 func (h *InvoiceHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-    id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+    id, ok := pathID(w, r, "id", "invoice")
+    if !ok {
+        return
+    }
 
     inv, err := h.svc.GetByID(r.Context(), id)
     if err != nil {
-        http.Error(w, "invoice not found", 404)
+        writeError(w, r, err, "invoice not found")
         return
     }
 
-    json.NewEncoder(w).Encode(inv)
+    writeJSON(w, http.StatusOK, inv)
 }
 
 func (h *InvoiceHandler) Create(w http.ResponseWriter, r *http.Request) {
     var inv models.Invoice
 
     // Парсим тело запроса
-    err := json.NewDecoder(r.Body).Decode(&inv)
-    if err != nil {
-        http.Error(w, "Invalid input", http.StatusBadRequest)
+    if !decodeJSON(w, r, &inv) {
         return
     }
 
-    // Создаем инвойс с помощью сервиса
-    err = h.svc.Create(r.Context(), &inv)
-    if err != nil {
-        http.Error(w, "Failed to create invoice", http.StatusInternalServerError)
+    // Создаем инвойс с помощью сервиса; без ставки налога / курса — 422, пока админ их не добавит
+    if err := h.svc.Create(r.Context(), &inv); err != nil {
+        writeError(w, r, err, "company not found")
         return
     }
 
     // Отправляем успешный ответ
-    w.WriteHeader(http.StatusCreated)
-    json.NewEncoder(w).Encode(inv)
-}
\ No newline at end of file
+    writeJSON(w, http.StatusCreated, inv)
+}
+
+func (h *InvoiceHandler) Payments(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "invoice")
+	if !ok {
+		return
+	}
+
+	list, err := h.svc.ListPayments(r.Context(), id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *InvoiceHandler) RecordPayment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "invoice")
+	if !ok {
+		return
+	}
+
+	var p models.Payment
+	if !decodeJSON(w, r, &p) {
+		return
+	}
+	p.InvoiceID = id
+	if claims := GetUserClaims(r); claims != nil {
+		p.RecordedBy = &claims.UserID
+	}
+
+	inv, err := h.svc.RecordPayment(r.Context(), &p)
+	if err != nil {
+		writeError(w, r, err, "invoice not found")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"payment": p,
+		"invoice": inv,
+	})
+}
+
+// ---------- COMPANY BILLING (admin) ----------
+func (h *InvoiceHandler) CompanyBilling(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	b, err := h.svc.CompanyBilling(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "company not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+func (h *InvoiceHandler) SetCompanyBilling(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	var b models.CompanyBilling
+	if !decodeJSON(w, r, &b) {
+		return
+	}
+	b.CompanyID = id
+
+	res, err := h.svc.SetCompanyBilling(r.Context(), &b)
+	if err != nil {
+		writeError(w, r, err, "company not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// GET /api/admin/companies/{id}/billing/summary?start=YYYY-MM-DD&end=YYYY-MM-DD
+func (h *InvoiceHandler) CompanySummary(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+	start, end := r.URL.Query().Get("start"), r.URL.Query().Get("end")
+	if start == "" {
+		fieldError(w, r, "start", "start is required")
+		return
+	}
+	if end == "" {
+		fieldError(w, r, "end", "end is required")
+		return
+	}
+
+	sum, err := h.svc.Calculate(r.Context(), id, start, end)
+	if err != nil {
+		writeError(w, r, err, "company not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, sum)
+}
+
+// ---------- CREDIT NOTES / ADJUSTMENTS ----------
+// Тело — как у счёта: reason, refs{facade_id, outage_start, outage_end, play_history_ids},
+// lines или amount_total (с налогом). Для кредит-ноты суммы положительные.
+func (h *InvoiceHandler) CreditNote(w http.ResponseWriter, r *http.Request) {
+	h.issueAdjustment(w, r, repositories.DocCreditNote)
+}
+
+func (h *InvoiceHandler) Adjustment(w http.ResponseWriter, r *http.Request) {
+	h.issueAdjustment(w, r, repositories.DocAdjustment)
+}
+
+func (h *InvoiceHandler) issueAdjustment(w http.ResponseWriter, r *http.Request, docType string) {
+	id, ok := pathID(w, r, "id", "invoice")
+	if !ok {
+		return
+	}
+
+	var doc models.Invoice
+	if !decodeJSON(w, r, &doc) {
+		return
+	}
+	doc.DocumentType = docType
+
+	if err := h.svc.IssueAdjustment(r.Context(), id, &doc); err != nil {
+		writeError(w, r, err, "invoice not found")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, doc)
+}
+
+func (h *InvoiceHandler) Adjustments(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "invoice")
+	if !ok {
+		return
+	}
+
+	list, err := h.svc.ListAdjustments(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "invoice not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"mediawork/internal/jobs"
+	"mediawork/internal/services"
+)
+
+type JobsHandler struct {
+	jobs      *jobs.Scheduler
+	retention *services.RetentionService
+}
+
+func NewJobsHandler(s *jobs.Scheduler, retention *services.RetentionService) *JobsHandler {
+	return &JobsHandler{jobs: s, retention: retention}
+}
+
+func (h *JobsHandler) List(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.jobs.Status())
+}
+
+// Run — ручной запуск задачи (например, после изменения сроков хранения)
+func (h *JobsHandler) Run(w http.ResponseWriter, r *http.Request) {
+	found, err := h.jobs.RunNow(r.Context(), chi.URLParam(r, "name"))
+	if !found {
+		httpError(w, r, http.StatusNotFound, "job not found")
+		return
+	}
+	if errors.Is(err, jobs.ErrJobRunning) {
+		httpError(w, r, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+func (h *JobsHandler) Archives(w http.ResponseWriter, r *http.Request) {
+	list, err := h.retention.ListArchives(r.Context())
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
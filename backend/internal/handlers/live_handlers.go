@@ -1,7 +1,6 @@
 package handlers
 
 import (
-    "encoding/json"
     "mediawork/internal/models"
     "mediawork/internal/services"
     "net/http"
@@ -17,16 +16,26 @@ func NewLiveHandler(s *services.LiveStreamService) *LiveHandler {
 
 func (h *LiveHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
     var hb models.Heartbeat
-    json.NewDecoder(r.Body).Decode(&hb)
+    if !decodeJSON(w, r, &hb) {
+        return
+    }
 
-    _ = h.svc.Heartbeat(r.Context(), &hb)
-    w.WriteHeader(200)
+    if err := h.svc.Heartbeat(r.Context(), DeviceFacadeID(r), &hb); err != nil {
+        writeError(w, r, err, "facade not found")
+        return
+    }
+    w.WriteHeader(http.StatusOK)
 }
 
 func (h *LiveHandler) PlayEvent(w http.ResponseWriter, r *http.Request) {
     var ev models.PlayEvent
-    json.NewDecoder(r.Body).Decode(&ev)
+    if !decodeJSON(w, r, &ev) {
+        return
+    }
 
-    _ = h.svc.PlayEvent(r.Context(), &ev)
-    w.WriteHeader(200)
+    if err := h.svc.PlayEvent(r.Context(), DeviceFacadeID(r), &ev); err != nil {
+        writeError(w, r, err, "campaign or facade not found")
+        return
+    }
+    w.WriteHeader(http.StatusOK)
 }
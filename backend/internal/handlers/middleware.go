@@ -2,39 +2,161 @@ package handlers
 
 import (
     "context"
+    "errors"
+    "fmt"
+    "log/slog"
+    "mediawork/internal/audit"
     "mediawork/internal/models"
     "mediawork/internal/services"
+    "mediawork/internal/tracing"
     "net/http"
+    "runtime/debug"
     "strings"
+
+    "github.com/go-chi/chi/v5/middleware"
 )
 
 type contextKey string
 var userKey contextKey = "user"
+var deviceKey contextKey = "device"
+
+// RequestMeta кладёт в контекст актора для audit_log: request ID и IP.
+// Ставится после middleware.RequestID и RealIP; пользователя дописывает AuthMiddleware.
+func RequestMeta(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        actor := &audit.Actor{
+            RequestID: middleware.GetReqID(r.Context()),
+            IP:        services.ClientIP(r.RemoteAddr),
+        }
+        next.ServeHTTP(w, r.WithContext(audit.WithActor(r.Context(), actor)))
+    })
+}
 
-func AuthMiddleware(auth *services.AuthService) func(http.Handler) http.Handler {
+// LimitBody ограничивает тело запроса; decodeJSON отвечает на превышение 413
+func LimitBody(n int64) func(http.Handler) http.Handler {
     return func(next http.Handler) http.Handler {
         return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if r.Body != nil && r.Body != http.NoBody {
+                r.Body = http.MaxBytesReader(w, r.Body, n)
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
 
-            authHeader := r.Header.Get("Authorization")
-            if authHeader == "" {
-                http.Error(w, "unauthorized", 401)
+// Recoverer — вместо middleware.Recoverer: паника обработчика пишется в лог со стеком,
+// клиент получает 500 в обычном JSON-конверте ошибки. http.ErrAbortHandler пробрасывается
+// дальше — им обработчик сам обрывает ответ.
+func Recoverer(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        defer func() {
+            p := recover()
+            if p == nil {
                 return
             }
+            if p == http.ErrAbortHandler {
+                panic(p)
+            }
+            slog.ErrorContext(r.Context(), "handler panic",
+                "method", r.Method, "path", r.URL.Path, "panic", p, "stack", string(debug.Stack()))
+            tracing.FromContext(r.Context()).RecordError(fmt.Errorf("panic: %v", p))
+            // соединение WebSocket уже перехвачено — писать некуда
+            if r.Header.Get("Connection") != "Upgrade" {
+                httpError(w, r, http.StatusInternalServerError, "internal server error")
+            }
+        }()
+        next.ServeHTTP(w, r)
+    })
+}
 
-            token := strings.TrimPrefix(authHeader, "Bearer ")
+// AuthMiddleware принимает JWT пользователя или API-ключ компании
+// (Authorization: Bearer mwk_... или X-API-Key). Куда пускать ключ, решает APIKeyGuard.
+func AuthMiddleware(auth *services.AuthService, keys *services.APIKeyService) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+            token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+            if token == "" {
+                token = r.Header.Get("X-API-Key")
+            }
+            if token == "" {
+                httpError(w, r, http.StatusUnauthorized, "authentication required")
+                return
+            }
 
-            claims, err := auth.ParseToken(token)
+            var claims *models.UserClaims
+            var err error
+            if strings.HasPrefix(token, services.APIKeyPrefix) && keys != nil {
+                claims, err = keys.Authenticate(r.Context(), token, services.ClientIP(r.RemoteAddr))
+            } else {
+                claims, err = auth.Authenticate(r.Context(), token)
+            }
+            if errors.Is(err, services.ErrAccountDisabled) {
+                httpError(w, r, http.StatusForbidden, "account disabled")
+                return
+            }
+            if errors.Is(err, services.ErrCompanyInactive) {
+                httpError(w, r, http.StatusForbidden, err.Error())
+                return
+            }
             if err != nil {
-                http.Error(w, "invalid token", 401)
+                httpError(w, r, http.StatusUnauthorized, "invalid token")
                 return
             }
 
             ctx := context.WithValue(r.Context(), userKey, claims)
+
+            actor := audit.Actor{}
+            if a := audit.ActorFrom(ctx); a != nil {
+                actor = *a
+            }
+            actor.UserID = claims.UserID
+            actor.Email = claims.Email
+            actor.Role = claims.Role
+            actor.ImpersonatorID = claims.ImpersonatorID
+            ctx = audit.WithActor(ctx, &actor)
+
+            next.ServeHTTP(w, r.WithContext(ctx))
+        })
+    }
+}
+
+// DeviceAuth пускает на /api/live/* только плеер фасада с токеном устройства
+// (Authorization: Bearer mwd_... или X-Device-Token); фасад кладётся в контекст.
+func DeviceAuth(facades *services.FacadeService) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            token := r.Header.Get("X-Device-Token")
+            if token == "" {
+                token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+            }
+            if token == "" {
+                httpError(w, r, http.StatusUnauthorized, "device token required")
+                return
+            }
+
+            facadeID, err := facades.AuthenticateDevice(r.Context(), token)
+            if errors.Is(err, services.ErrDeviceTokenInvalid) {
+                httpError(w, r, http.StatusUnauthorized, "invalid device token")
+                return
+            }
+            if err != nil {
+                serverError(w, r, err)
+                return
+            }
+
+            ctx := context.WithValue(r.Context(), deviceKey, facadeID)
             next.ServeHTTP(w, r.WithContext(ctx))
         })
     }
 }
 
+// DeviceFacadeID — фасад, чей токен устройства прошёл DeviceAuth (0 — не прошёл)
+func DeviceFacadeID(r *http.Request) int64 {
+    id, _ := r.Context().Value(deviceKey).(int64)
+    return id
+}
+
 func GetUserClaims(r *http.Request) *models.UserClaims {
     val := r.Context().Value(userKey)
     if val == nil { return nil }
@@ -46,7 +168,7 @@ func RoleGuard(role string) func(http.Handler) http.Handler {
         return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
             claims := GetUserClaims(r)
             if claims == nil || claims.Role != role {
-                http.Error(w, "forbidden", 403)
+                httpError(w, r, http.StatusForbidden, "forbidden")
                 return
             }
             next.ServeHTTP(w, r)
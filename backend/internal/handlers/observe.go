@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gorilla/websocket"
+
+	"mediawork/internal/metrics"
+	"mediawork/internal/services"
+	"mediawork/internal/tracing"
+)
+
+var (
+	httpDuration = metrics.NewHistogramVec("mediawork_http_request_duration_seconds",
+		"HTTP request latency by route pattern.", metrics.DefBuckets, "method", "route", "status")
+	httpInFlight = metrics.NewGauge("mediawork_http_requests_in_flight", "HTTP requests being served.")
+)
+
+// probeRoutes — проверки оркестратора каждые несколько секунд: в access-лог только на debug
+// (упавшая проверка готовности пишется в лог самим /readyz)
+var probeRoutes = map[string]bool{"/healthz": true, "/readyz": true}
+
+// Observe — спан запроса (родитель из traceparent), метрика задержки по шаблону маршрута
+// и строка access-лога. Ставится сразу после middleware.RequestID и RealIP, до Recoverer,
+// чтобы паника тоже попала в метрики как 500.
+func Observe(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, span := tracing.StartRemote(r.Context(), r.Header, r.Method,
+			tracing.Attr{Key: "http.request.method", Value: r.Method},
+			tracing.Attr{Key: "url.path", Value: r.URL.Path},
+		)
+		defer span.End()
+
+		httpInFlight.Inc()
+		defer httpInFlight.Dec()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		switch {
+		case status != 0:
+		case websocket.IsWebSocketUpgrade(r):
+			status = http.StatusSwitchingProtocols // ответ ушёл в hijacked-соединение
+		default:
+			status = http.StatusOK // обработчик ничего не записал
+		}
+		// шаблон вместо пути: /api/invoices/{id}, а не тысячи рядов на каждый id
+		route := "unmatched"
+		if rc := chi.RouteContext(ctx); rc != nil && rc.RoutePattern() != "" {
+			route = rc.RoutePattern()
+		}
+		elapsed := time.Since(start)
+		httpDuration.With(r.Method, route, strconv.Itoa(status)).Observe(elapsed.Seconds())
+
+		span.SetName(r.Method + " " + route)
+		span.SetAttr("http.route", route)
+		span.SetAttr("http.response.status_code", status)
+		if status >= 500 {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case probeRoutes[route]:
+			level = slog.LevelDebug
+		case status >= 500:
+			level = slog.LevelError
+		}
+		slog.LogAttrs(ctx, level, "http request",
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", ww.BytesWritten()),
+			slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+			slog.String("ip", services.ClientIP(r.RemoteAddr)),
+		)
+	})
+}
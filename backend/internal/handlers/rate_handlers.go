@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/services"
+)
+
+// RateHandler — справочники для биллинга: ставки налога и курсы валют (только админ)
+type RateHandler struct {
+	taxes *services.TaxService
+	fx    *services.ExchangeRateService
+}
+
+func NewRateHandler(t *services.TaxService, fx *services.ExchangeRateService) *RateHandler {
+	return &RateHandler{taxes: t, fx: fx}
+}
+
+// parseDate — YYYY-MM-DD; пустая строка — нулевое время (сервис подставит сегодня)
+func parseDate(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+//
+// ---------- TAX RATES ----------
+//
+type taxRateRequest struct {
+	Jurisdiction string        `json:"jurisdiction"`
+	Name         string        `json:"name"`
+	Rate         money.Decimal `json:"rate"`
+	ValidFrom    string        `json:"valid_from"` // YYYY-MM-DD
+	ValidTo      string        `json:"valid_to"`
+}
+
+// GET /api/admin/tax-rates?jurisdiction=
+func (h *RateHandler) TaxRates(w http.ResponseWriter, r *http.Request) {
+	list, err := h.taxes.List(r.Context(), r.URL.Query().Get("jurisdiction"))
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *RateHandler) CreateTaxRate(w http.ResponseWriter, r *http.Request) {
+	var req taxRateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	t := models.TaxRate{Jurisdiction: req.Jurisdiction, Name: req.Name, Rate: req.Rate}
+	var err error
+	if t.ValidFrom, err = parseDate(req.ValidFrom); err != nil {
+		fieldError(w, r, "valid_from", "valid_from must be YYYY-MM-DD")
+		return
+	}
+	if req.ValidTo != "" {
+		to, err := parseDate(req.ValidTo)
+		if err != nil {
+			fieldError(w, r, "valid_to", "valid_to must be YYYY-MM-DD")
+			return
+		}
+		t.ValidTo = &to
+	}
+
+	if err := h.taxes.Create(r.Context(), &t); err != nil {
+		writeError(w, r, err, "tax rate not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func (h *RateHandler) DeleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "tax rate")
+	if !ok {
+		return
+	}
+
+	err := h.taxes.Delete(r.Context(), id)
+
+	if err != nil {
+
+		writeError(w, r, err, "tax rate not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//
+// ---------- EXCHANGE RATES ----------
+//
+type exchangeRateRequest struct {
+	Base    string        `json:"base"`
+	Quote   string        `json:"quote"`
+	Rate    money.Decimal `json:"rate"`
+	ValidOn string        `json:"valid_on"` // YYYY-MM-DD
+}
+
+// GET /api/admin/exchange-rates?base=&quote=&limit=&offset=
+func (h *RateHandler) ExchangeRates(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pageParams(r)
+	q := r.URL.Query()
+
+	list, err := h.fx.List(r.Context(), q.Get("base"), q.Get("quote"), limit, offset)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// POST — создать или исправить курс пары на дату
+func (h *RateHandler) SetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	var req exchangeRateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	e := models.ExchangeRate{Base: req.Base, Quote: req.Quote, Rate: req.Rate}
+	var err error
+	if e.ValidOn, err = parseDate(req.ValidOn); err != nil {
+		fieldError(w, r, "valid_on", "valid_on must be YYYY-MM-DD")
+		return
+	}
+	if claims := GetUserClaims(r); claims != nil {
+		e.CreatedBy = &claims.UserID
+	}
+
+	if err := h.fx.Set(r.Context(), &e); err != nil {
+		writeError(w, r, err, "exchange rate not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+func (h *RateHandler) DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "exchange rate")
+	if !ok {
+		return
+	}
+
+	err := h.fx.Delete(r.Context(), id)
+
+	if err != nil {
+
+		writeError(w, r, err, "exchange rate not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mediawork/internal/ratelimit"
+	"mediawork/internal/services"
+)
+
+// RateKey — по какому признаку считать запросы; пустой ключ — запрос не ограничивается
+type RateKey func(r *http.Request) string
+
+// RateLimit — 429 Too Many Requests с Retry-After, когда ведро ключа пусто.
+// nil limiter — политика выключена.
+func RateLimit(l *ratelimit.Limiter, key RateKey) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			d := l.Allow(l.Policy().Name + "|" + k)
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			if !d.Allowed {
+				retry := max(1, int(math.Ceil(d.RetryAfter.Seconds())))
+				w.Header().Set("Retry-After", strconv.Itoa(retry))
+				httpError(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IPRateKey — адрес клиента (после RealIP: заголовки прокси учтены, только если он доверенный)
+func IPRateKey(r *http.Request) string {
+	return "ip:" + services.ClientIP(r.RemoteAddr)
+}
+
+// ClientRateKey — API-ключ или пользователь из AuthMiddleware, иначе IP
+func ClientRateKey(r *http.Request) string {
+	claims := GetUserClaims(r)
+	switch {
+	case claims == nil:
+		return IPRateKey(r)
+	case claims.APIKeyID != 0:
+		return "key:" + strconv.FormatInt(claims.APIKeyID, 10)
+	default:
+		return "user:" + strconv.FormatInt(claims.UserID, 10)
+	}
+}
+
+// FacadeRateKey — фасад, от имени которого плеер шлёт телеметрию (по токену устройства,
+// ставится после DeviceAuth). Без него считаем по IP.
+func FacadeRateKey(r *http.Request) string {
+	if id := DeviceFacadeID(r); id != 0 {
+		return "facade:" + strconv.FormatInt(id, 10)
+	}
+	return IPRateKey(r)
+}
+
+// LoginRateKey — email из тела входа вместе с IP: перебор пароля одного аккаунта.
+// Ведро по одному email позволило бы любому заблокировать вход владельцу аккаунта;
+// перебор с многих адресов ограничивают ведра по IP.
+func LoginRateKey(r *http.Request) string {
+	var body struct {
+		Email string `json:"email"`
+	}
+	if !peekJSON(r, &body) {
+		return ""
+	}
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+	if email == "" {
+		return ""
+	}
+	return "email:" + email + "|" + IPRateKey(r)
+}
+
+// maxPeekBody — тела телеметрии и входа маленькие; больше не читаем ради ключа
+const maxPeekBody = 64 << 10
+
+// peekJSON читает тело в v и возвращает его обратно в запрос для хендлера
+func peekJSON(r *http.Request, v any) bool {
+	if r.Body == nil {
+		return false
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBody))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), r.Body))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP подставляет в r.RemoteAddr адрес клиента из X-Forwarded-For / X-Real-IP, но только
+// если соединение пришло от доверенного прокси (server.trusted_proxies). Иначе заголовки
+// подделываются любым клиентом, и лимиты по IP, журнал и last_used_ip ключа считались бы
+// по чужому адресу. В X-Forwarded-For берётся самый правый адрес, не принадлежащий
+// доверенным прокси: левее него клиент мог дописать что угодно.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := forwardedIP(r, trusted); ip != "" {
+				r.RemoteAddr = ip
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedIP(r *http.Request, trusted []netip.Prefix) string {
+	if len(trusted) == 0 {
+		return ""
+	}
+	peer, ok := parseIP(r.RemoteAddr)
+	if !ok || !inPrefixes(peer, trusted) {
+		return ""
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, ok := parseIP(strings.TrimSpace(hops[i]))
+		if !ok {
+			break // мусор в цепочке — дальше ей не верим
+		}
+		if !inPrefixes(ip, trusted) {
+			return ip.String()
+		}
+	}
+	if ip, ok := parseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ok {
+		return ip.String()
+	}
+	return ""
+}
+
+// parseIP — адрес из "ip", "ip:port" или "[ipv6]:port"
+func parseIP(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+func inPrefixes(ip netip.Addr, list []netip.Prefix) bool {
+	for _, p := range list {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"mediawork/internal/geo"
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/repositories"
+	"mediawork/internal/services"
+	"mediawork/internal/tracing"
+)
+
+//
+// ---------- RESPONSES ----------
+//
+// writeJSON — успешный ответ; Content-Type выставляется всегда
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("write response", "err", err)
+	}
+}
+
+// ErrorResponse — тело любого ответа с ошибкой: {"error": {...}}
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+type APIError struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// FieldError — ошибка конкретного поля тела или параметра запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Коды ошибок API; клиенту стоит смотреть на code, а не на текст message
+const (
+	CodeBadRequest   = "bad_request"
+	CodeInvalidJSON  = "invalid_json"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeNotFound     = "not_found"
+	CodeMethod       = "method_not_allowed"
+	CodeConflict     = "conflict"
+	CodeGone         = "gone"
+	CodeTooLarge     = "body_too_large"
+	CodeValidation   = "validation_failed"
+	CodeRateLimited  = "rate_limited"
+	CodeInternal     = "internal_error"
+	CodeUnavailable  = "unavailable"
+)
+
+// codeFor — код по умолчанию для HTTP-статуса
+func codeFor(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethod
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeValidation
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+func writeErrorBody(w http.ResponseWriter, r *http.Request, status int, e APIError) {
+	e.RequestID = middleware.GetReqID(r.Context())
+	writeJSON(w, status, ErrorResponse{Error: e})
+}
+
+// httpError — ошибка со статусом и сообщением для клиента (код по статусу)
+func httpError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeErrorBody(w, r, status, APIError{Code: codeFor(status), Message: message})
+}
+
+// fieldError — 422 по одному полю (параметр запроса или поле тела)
+func fieldError(w http.ResponseWriter, r *http.Request, field, message string) {
+	writeErrorBody(w, r, http.StatusUnprocessableEntity, APIError{
+		Code:    CodeValidation,
+		Message: message,
+		Fields:  []FieldError{{Field: field, Message: message}},
+	})
+}
+
+// serverError — 500 без подробностей: текст ошибки (SQL и т.п.) остаётся в логе
+func serverError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "err", err)
+	tracing.FromContext(r.Context()).RecordError(err)
+	httpError(w, r, http.StatusInternalServerError, "internal server error")
+}
+
+// Error — httpError для обработчиков вне пакета (WebSocket и т.п.)
+func Error(w http.ResponseWriter, r *http.Request, status int, message string) {
+	httpError(w, r, status, message)
+}
+
+// NotFound / MethodNotAllowed — для маршрутов, которых нет
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	httpError(w, r, http.StatusNotFound, "route not found")
+}
+
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	httpError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+//
+// ---------- ERROR MAPPING ----------
+//
+// Ошибки домена с фиксированным статусом; остальное решает writeError
+var conflictErrors = []error{
+	services.ErrCompanyInactive,
+	repositories.ErrAlreadyInState,
+	repositories.ErrCampaignSuspended,
+	repositories.ErrInsufficientFunds,
+	repositories.ErrOverpayment,
+	repositories.ErrInvoiceClosed,
+	repositories.ErrStatusFromPayments,
+	repositories.ErrOverCredit,
+	repositories.ErrNotCreditable,
+}
+
+var unprocessableErrors = []error{
+	services.ErrNoTaxRate,
+	services.ErrNoExchangeRate,
+	services.ErrPlayNotScheduled,
+	repositories.ErrCurrencyMismatch,
+	repositories.ErrUnknownDocument,
+	money.ErrInvalidDecimal,
+	geo.ErrInvalidGeometry,
+}
+
+func isAny(err error, targets []error) bool {
+	for _, t := range targets {
+		if errors.Is(err, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlState — код ошибки PostgreSQL (pq.Error и ошибки ограничений хранилища в памяти)
+func sqlState(err error) string {
+	var e interface{ SQLState() string }
+	if errors.As(err, &e) {
+		return e.SQLState()
+	}
+	return ""
+}
+
+// writeError отвечает по ошибке сервиса: sql.ErrNoRows → 404 (notFound — текст ответа),
+// ValidationError → 422 с полем, нарушение уникальности → 409, прочее → 500 без подробностей
+func writeError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	if v, ok := services.AsValidation(err); ok {
+		e := APIError{Code: CodeValidation, Message: err.Error()}
+		if v.Field != "" {
+			e.Fields = []FieldError{{Field: v.Field, Message: err.Error()}}
+		}
+		writeErrorBody(w, r, http.StatusUnprocessableEntity, e)
+		return
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		httpError(w, r, http.StatusNotFound, notFound)
+	case errors.Is(err, services.ErrForbidden):
+		httpError(w, r, http.StatusForbidden, err.Error())
+	case isAny(err, conflictErrors):
+		httpError(w, r, http.StatusConflict, err.Error())
+	case isAny(err, unprocessableErrors):
+		httpError(w, r, http.StatusUnprocessableEntity, err.Error())
+	default:
+		switch state := sqlState(err); {
+		case state == "23505":
+			httpError(w, r, http.StatusConflict, "resource already exists")
+		case state == "23503":
+			httpError(w, r, http.StatusUnprocessableEntity, "referenced resource does not exist")
+		case state == "23502", state == "23514", strings.HasPrefix(state, "22"):
+			httpError(w, r, http.StatusUnprocessableEntity, "invalid field value")
+		default:
+			serverError(w, r, err)
+		}
+	}
+}
+
+//
+// ---------- REQUEST PARSING ----------
+//
+// pathID — числовой {name} из пути; при ошибке уже ответил 400
+func pathID(w http.ResponseWriter, r *http.Request, name, what string) (int64, bool) {
+	id, ok := urlID(r, name)
+	if !ok || id <= 0 {
+		writeErrorBody(w, r, http.StatusBadRequest, APIError{
+			Code:    CodeBadRequest,
+			Message: "invalid " + what + " id",
+			Fields:  []FieldError{{Field: name, Message: "must be a positive integer"}},
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// listQuery — параметры постраничного списка из строки запроса:
+// ?status=&company_id=&from=&to=&q=&sort=[-]field&limit=&cursor=
+// (sort с минусом — по убыванию; cursor — next_cursor / prev_cursor из предыдущего ответа).
+// Какие фильтры и поля сортировки понимает список, проверяет сервис. При ошибке уже ответил.
+func listQuery(w http.ResponseWriter, r *http.Request) (models.ListQuery, bool) {
+	v := r.URL.Query()
+	q := models.ListQuery{
+		Status: v.Get("status"),
+		Role:   v.Get("role"),
+		Search: strings.TrimSpace(v.Get("q")),
+	}
+
+	var ok bool
+	if q.CompanyID, ok = queryInt64(r, "company_id"); !ok {
+		fieldError(w, r, "company_id", "company_id must be an integer")
+		return q, false
+	}
+
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeError(w, r, err, "")
+		return q, false
+	}
+	q.From, q.To = from, to
+
+	if sort := v.Get("sort"); sort != "" {
+		q.Sort, q.Desc = strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+	}
+
+	if limit := v.Get("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil || q.Limit <= 0 {
+			fieldError(w, r, "limit", "limit must be a positive integer")
+			return q, false
+		}
+	}
+
+	if cursor := v.Get("cursor"); cursor != "" {
+		if q.Cursor, err = services.DecodeCursor(cursor); err != nil {
+			writeError(w, r, err, "")
+			return q, false
+		}
+	}
+
+	// ключ API видит только свою компанию, что бы ни пришло в company_id
+	if companyID := apiKeyCompany(r); companyID != 0 {
+		q.CompanyID = &companyID
+	}
+	return q, true
+}
+
+// decodeJSON строго разбирает тело в v: неизвестные поля, лишние данные после объекта и
+// пустое тело — ошибка. Размер тела ограничивает LimitBody. При ошибке уже ответил.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	return decodeBody(w, r, v, false)
+}
+
+// decodeOptionalJSON — то же, но пустое тело допустимо (v остаётся нулевым)
+func decodeOptionalJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	return decodeBody(w, r, v, true)
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any, optional bool) bool {
+	if r.Body == nil {
+		if optional {
+			return true
+		}
+		httpError(w, r, http.StatusBadRequest, "request body is required")
+		return false
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if errors.Is(err, io.EOF) {
+		if optional {
+			return true
+		}
+		httpError(w, r, http.StatusBadRequest, "request body is required")
+		return false
+	}
+	if err == nil {
+		if dec.Decode(&struct{}{}) != io.EOF {
+			err = errors.New("body must contain a single JSON value")
+		}
+	}
+	if err != nil {
+		writeDecodeError(w, r, err)
+		return false
+	}
+	return true
+}
+
+func writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		tooLarge  *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &tooLarge):
+		httpError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+	case errors.As(err, &syntaxErr):
+		invalidJSON(w, r, fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		invalidJSON(w, r, "malformed JSON: unexpected end of body")
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			invalidJSON(w, r, "request body must be "+jsonType(typeErr.Type.Kind().String()))
+			return
+		}
+		fieldError(w, r, field, fmt.Sprintf("%s must be %s", field, jsonType(typeErr.Type.Kind().String())))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		fieldError(w, r, field, "unknown field "+field)
+	default:
+		// ошибки UnmarshalJSON типов поля (дата, сумма и т.п.) — без имени поля
+		invalidJSON(w, r, err.Error())
+	}
+}
+
+func invalidJSON(w http.ResponseWriter, r *http.Request, message string) {
+	writeErrorBody(w, r, http.StatusBadRequest, APIError{Code: CodeInvalidJSON, Message: message})
+}
+
+// jsonType — имя JSON-типа для reflect.Kind поля
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "bool":
+		return "a boolean"
+	case kind == "string":
+		return "a string"
+	case kind == "slice", kind == "array":
+		return "an array"
+	}
+	return "an object"
+}
@@ -1,8 +1,6 @@
 package handlers
 
 import (
-	"encoding/json"
-	"log"
 	"mediawork/internal/services"
 	"net/http"
 )
@@ -18,11 +16,10 @@ func NewUserHandler(s *services.UserService) *UserHandler {
 func (h *UserHandler) Profile(w http.ResponseWriter, r *http.Request) {
     claims := GetUserClaims(r)
     user, err := h.users.Profile(r.Context(), claims.UserID)
-    log.Println("Handling profile request for user ID:", claims.UserID)
     if err != nil {
-        http.Error(w, err.Error(), 400)
+        writeError(w, r, err, "user not found")
         return
     }
-    json.NewEncoder(w).Encode(user)
+    writeJSON(w, http.StatusOK, user)
 }
 
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mediawork/internal/models"
+	"mediawork/internal/services"
+)
+
+// WebhookHandler — endpoints компании, журнал доставок и ручной повтор
+type WebhookHandler struct {
+	svc *services.WebhookService
+}
+
+func NewWebhookHandler(s *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{svc: s}
+}
+
+// webhookIDs — id компании и endpoint-а из пути
+func webhookIDs(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	companyID, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return 0, 0, false
+	}
+	webhookID, ok := pathID(w, r, "webhookID", "webhook")
+	if !ok {
+		return 0, 0, false
+	}
+	return companyID, webhookID, true
+}
+
+type webhookRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+//
+// ---------- ENDPOINTS ----------
+//
+// GET /api/companies/{id}/webhooks
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	companyID, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	list, err := h.svc.ListEndpoints(r.Context(), companyID, GetUserClaims(r))
+	if err != nil {
+		writeError(w, r, err, "company not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// POST /api/companies/{id}/webhooks {url, description, event_types} — в ответе секрет подписи (только здесь)
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	companyID, ok := pathID(w, r, "id", "company")
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	e := models.WebhookEndpoint{
+		CompanyID:   companyID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+	}
+	if err := h.svc.CreateEndpoint(r.Context(), &e, GetUserClaims(r)); err != nil {
+		writeError(w, r, err, "company not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, e)
+}
+
+// PUT /api/companies/{id}/webhooks/{webhookID} {url, description, event_types, is_active}
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	companyID, webhookID, ok := webhookIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	e := models.WebhookEndpoint{
+		ID:          webhookID,
+		CompanyID:   companyID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+	}
+	after, err := h.svc.UpdateEndpoint(r.Context(), &e, GetUserClaims(r))
+	if err != nil {
+		writeError(w, r, err, "webhook not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, after)
+}
+
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	companyID, webhookID, ok := webhookIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteEndpoint(r.Context(), companyID, webhookID, GetUserClaims(r)); err != nil {
+		writeError(w, r, err, "webhook not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/companies/{id}/webhooks/{webhookID}/rotate-secret
+func (h *WebhookHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	companyID, webhookID, ok := webhookIDs(w, r)
+	if !ok {
+		return
+	}
+
+	e, err := h.svc.RotateSecret(r.Context(), companyID, webhookID, GetUserClaims(r))
+	if err != nil {
+		writeError(w, r, err, "webhook not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+// POST /api/companies/{id}/webhooks/{webhookID}/test — webhook.test уходит со следующим проходом диспетчера
+func (h *WebhookHandler) Test(w http.ResponseWriter, r *http.Request) {
+	companyID, webhookID, ok := webhookIDs(w, r)
+	if !ok {
+		return
+	}
+
+	ev, err := h.svc.Test(r.Context(), companyID, webhookID, GetUserClaims(r))
+	if err != nil {
+		writeError(w, r, err, "webhook not found")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, ev)
+}
+
+//
+// ---------- DELIVERIES ----------
+//
+// GET /api/companies/{id}/webhooks/{webhookID}/deliveries?status=pending|succeeded|failed&limit=&offset=
+func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	companyID, webhookID, ok := webhookIDs(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := pageParams(r)
+
+	list, err := h.svc.Deliveries(r.Context(), companyID, webhookID, r.URL.Query().Get("status"), limit, offset, GetUserClaims(r))
+	if err != nil {
+		writeError(w, r, err, "webhook not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *WebhookHandler) Attempts(w http.ResponseWriter, r *http.Request) {
+	companyID, webhookID, ok := webhookIDs(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, ok := pathID(w, r, "deliveryID", "delivery")
+	if !ok {
+		return
+	}
+
+	list, err := h.svc.Attempts(r.Context(), companyID, webhookID, deliveryID, GetUserClaims(r))
+	if err != nil {
+		writeError(w, r, err, "delivery not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// POST /api/companies/{id}/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver — отправка сейчас, в ответе попытка
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	companyID, webhookID, ok := webhookIDs(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, ok := pathID(w, r, "deliveryID", "delivery")
+	if !ok {
+		return
+	}
+
+	a, err := h.svc.Redeliver(r.Context(), companyID, webhookID, deliveryID, GetUserClaims(r))
+	if err != nil {
+		writeError(w, r, err, "delivery not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
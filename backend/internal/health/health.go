@@ -0,0 +1,123 @@
+// Package health — проверки живости и готовности для балансировщика и оркестратора.
+//
+//	GET /healthz — процесс жив и отвечает (без обращений к зависимостям)
+//	GET /readyz  — можно слать трафик: все проверки прошли и сервер не останавливается
+//
+// Проверки (пинг базы, версия схемы, планировщик задач) добавляет код, создающий
+// соответствующие зависимости. Подробности ошибок пишутся в лог, наружу — только имя проверки.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckTimeout — на все проверки одного запроса /readyz
+const CheckTimeout = 3 * time.Second
+
+// Check возвращает подробности для ответа (версия схемы, число задач) или ошибку
+type Check func(ctx context.Context) (detail any, err error)
+
+// Result — итог одной проверки в ответе /readyz
+type Result struct {
+	Status     string  `json:"status"` // ok | fail
+	Detail     any     `json:"detail,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Report — ответ /healthz и /readyz; status — ok | fail | draining
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type named struct {
+	name  string
+	check Check
+}
+
+// Checker — набор проверок готовности и флаг остановки
+type Checker struct {
+	mu       sync.Mutex
+	checks   []named
+	draining atomic.Bool
+}
+
+func New() *Checker {
+	return &Checker{}
+}
+
+// Add регистрирует проверку готовности
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, named{name, check})
+}
+
+// Drain — начало остановки: /readyz отвечает 503, чтобы балансировщик
+// перестал слать новые запросы, пока дорабатывают текущие
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Live — GET /healthz
+func (c *Checker) Live(w http.ResponseWriter, r *http.Request) {
+	write(w, http.StatusOK, Report{Status: "ok"})
+}
+
+// Ready — GET /readyz; проверки выполняются параллельно
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	checks := append([]named(nil), c.checks...)
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), CheckTimeout)
+	defer cancel()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, ch := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			detail, err := ch.check(ctx)
+			res := Result{Status: "ok", Detail: detail, DurationMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				res.Status = "fail"
+				slog.WarnContext(ctx, "readiness check failed", "check", ch.name, "err", err)
+			}
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+
+	rep := Report{Status: "ok", Checks: make(map[string]Result, len(checks))}
+	for i, ch := range checks {
+		rep.Checks[ch.name] = results[i]
+		if results[i].Status != "ok" {
+			rep.Status = "fail"
+		}
+	}
+	if c.draining.Load() {
+		rep.Status = "draining"
+	}
+
+	status := http.StatusOK
+	if rep.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	write(w, status, rep)
+}
+
+func write(w http.ResponseWriter, status int, rep Report) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(rep)
+}
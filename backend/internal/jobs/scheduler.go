@@ -0,0 +1,215 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"mediawork/internal/metrics"
+	"mediawork/internal/tracing"
+)
+
+// Решения планировщика: запуск прошёл (ok), упал (failed) или пропущен, потому что
+// предыдущий запуск той же задачи ещё идёт (skipped)
+var (
+	jobRuns = metrics.NewCounterVec("mediawork_job_runs_total",
+		"Background job runs by result: ok, failed, skipped (previous run still in progress).", "job", "result")
+	jobDuration = metrics.NewHistogramVec("mediawork_job_duration_seconds",
+		"Background job run duration.", []float64{.1, .5, 1, 5, 15, 60, 300, 900}, "job")
+)
+
+// ErrJobRunning — ручной запуск пропущен: предыдущий запуск задачи ещё идёт
+var ErrJobRunning = errors.New("job is already running")
+
+// Job — периодическая фоновая задача
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// JobStatus — состояние задачи для админки / health-проверок
+type JobStatus struct {
+	Name      string     `json:"name"`
+	Interval  string     `json:"interval"`
+	Running   bool       `json:"running"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	Runs      int64      `json:"runs"`
+	Failures  int64      `json:"failures"`
+}
+
+// Scheduler запускает задачи по интервалу, каждую в своей горутине.
+// Одна и та же задача никогда не выполняется параллельно сама с собой.
+type Scheduler struct {
+	mu     sync.Mutex
+	jobs   []*Job
+	status map[string]*JobStatus
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		status: make(map[string]*JobStatus),
+	}
+}
+
+// --------------------- ADD ---------------------
+func (s *Scheduler) Add(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j := job
+	s.jobs = append(s.jobs, &j)
+	s.status[j.Name] = &JobStatus{Name: j.Name, Interval: j.Interval.String()}
+}
+
+// --------------------- START ---------------------
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+}
+
+// --------------------- STOP ---------------------
+// Stop отменяет контекст задач и ждёт завершения текущих запусков.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	s.wg.Wait()
+}
+
+// Running — планировщик запущен (Start без Stop)
+func (s *Scheduler) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cancel != nil
+}
+
+// --------------------- RUN NOW ---------------------
+// RunNow синхронно выполняет задачу по имени (для админки и CLI).
+// Если задача уже выполняется, возвращает ErrJobRunning и ничего не запускает.
+func (s *Scheduler) RunNow(ctx context.Context, name string) (bool, error) {
+	s.mu.Lock()
+	var job *Job
+	for _, j := range s.jobs {
+		if j.Name == name {
+			job = j
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if job == nil {
+		return false, nil
+	}
+	return true, s.runOnce(ctx, job)
+}
+
+// --------------------- STATUS ---------------------
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		list = append(list, *s.status[j.Name])
+	}
+	return list
+}
+
+func (s *Scheduler) loop(ctx context.Context, job *Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	// первый запуск сразу, дальше — по тикеру
+	for {
+		_ = s.runOnce(ctx, job) // ошибки уже в логе и статусе; ErrJobRunning — следующий тик
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	st := s.status[job.Name]
+	if st.Running {
+		s.mu.Unlock()
+		jobRuns.With(job.Name, "skipped").Inc()
+		return ErrJobRunning
+	}
+	st.Running = true
+	s.mu.Unlock()
+
+	ctx, span := tracing.Start(ctx, "job "+job.Name, tracing.KindInternal)
+	start := time.Now()
+	err := runJob(ctx, job)
+	jobDuration.With(job.Name).ObserveSince(start)
+	span.RecordError(err)
+	span.End()
+
+	s.mu.Lock()
+	now := time.Now()
+	st.Running = false
+	st.LastRun = &now
+	st.Runs++
+	st.LastError = ""
+	if err != nil {
+		st.Failures++
+		st.LastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	result := "ok"
+	if err != nil {
+		result = "failed"
+	}
+	jobRuns.With(job.Name, result).Inc()
+
+	if err != nil && ctx.Err() == nil {
+		slog.ErrorContext(ctx, "job failed", "job", job.Name, "err", err)
+	}
+	return err
+}
+
+// runJob — job.Run, паника в котором становится ошибкой запуска: горутина задачи
+// не роняет процесс, а статус не остаётся «выполняется» навсегда
+func runJob(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			slog.ErrorContext(ctx, "job panic", "job", job.Name, "panic", p, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
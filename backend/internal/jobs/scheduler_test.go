@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunNowPanic — паника задачи становится ошибкой запуска; задача не остаётся
+// «выполняется», следующий запуск проходит
+func TestRunNowPanic(t *testing.T) {
+	s := NewScheduler()
+	calls := 0
+	s.Add(Job{Name: "flaky", Interval: time.Hour, Run: func(context.Context) error {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		return nil
+	}})
+	ctx := context.Background()
+
+	found, err := s.RunNow(ctx, "flaky")
+	if !found || err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("RunNow = %v, %v, want panic error", found, err)
+	}
+	st := s.Status()[0]
+	if st.Running || st.Failures != 1 || !strings.Contains(st.LastError, "boom") {
+		t.Fatalf("status after panic = %+v", st)
+	}
+
+	if _, err := s.RunNow(ctx, "flaky"); err != nil {
+		t.Fatalf("RunNow after panic: %v", err)
+	}
+	if st := s.Status()[0]; st.Running || st.Runs != 2 || st.LastError != "" {
+		t.Errorf("status after rerun = %+v", st)
+	}
+}
+
+// TestRunNowWhileRunning — ручной запуск не пересекается с идущим запуском той же задачи
+func TestRunNowWhileRunning(t *testing.T) {
+	s := NewScheduler()
+	started, release := make(chan struct{}), make(chan struct{})
+	s.Add(Job{Name: "slow", Interval: time.Hour, Run: func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}})
+	ctx := context.Background()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.RunNow(ctx, "slow")
+		done <- err
+	}()
+	<-started
+	if _, err := s.RunNow(ctx, "slow"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("second RunNow = %v, want ErrJobRunning", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := s.RunNow(ctx, "missing"); found {
+		t.Error("RunNow found an unknown job")
+	}
+}
@@ -0,0 +1,106 @@
+// Package logging — структурированный лог приложения поверх log/slog.
+//
+// Setup ставит обработчик по умолчанию: уровень и формат (json для сборщиков логов,
+// text для консоли) из конфига, к каждой записи с контекстом добавляются request_id
+// и trace_id, а значения секретных ключей (password, token, ...) вырезаются.
+// Пишут через slog.InfoContext(ctx, ...) и т.п.; старый log.Printf тоже попадает сюда (уровень info).
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"mediawork/internal/tracing"
+)
+
+// Setup настраивает slog.Default; level — debug | info | warn | error, format — json | text
+func Setup(w io.Writer, level, format string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("log level: %w", err)
+	}
+	opts := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redact}
+
+	var h slog.Handler
+	switch format {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("log format must be json or text, got %q", format)
+	}
+	slog.SetDefault(slog.New(contextHandler{h}))
+	return nil
+}
+
+// contextHandler дописывает к записи ID запроса и трассировки из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := middleware.GetReqID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := tracing.SpanContextFrom(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID.String()), slog.String("span_id", sc.SpanID.String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+//
+// ---------- REDACTION ----------
+//
+// secretKeys — значения не пишутся никогда; emailKeys — адреса маскируются (i***@example.com)
+var (
+	secretKeys = map[string]bool{
+		"password": true, "password_hash": true, "token": true, "secret": true,
+		"authorization": true, "cookie": true, "api_key": true, "key": true,
+	}
+	emailKeys = map[string]bool{"email": true, "to": true}
+)
+
+const redacted = "[REDACTED]"
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	switch {
+	case secretKeys[key]:
+		return slog.String(a.Key, redacted)
+	case emailKeys[key]:
+		switch v := a.Value.Any().(type) {
+		case string:
+			return slog.String(a.Key, MaskEmail(v))
+		case []string:
+			masked := make([]string, len(v))
+			for i, e := range v {
+				masked[i] = MaskEmail(e)
+			}
+			return slog.Any(a.Key, masked)
+		}
+	}
+	return a
+}
+
+// MaskEmail оставляет первую букву и домен: по логу видно, чей адрес, но не сам адрес
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	return string([]rune(local)[:1]) + "***@" + domain
+}
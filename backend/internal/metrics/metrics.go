@@ -0,0 +1,317 @@
+// Package metrics — метрики в текстовом формате Prometheus (exposition format 0.0.4).
+//
+// Метрики объявляются переменными пакета, которому принадлежат, и регистрируются
+// при объявлении (имя — mediawork_<область>_<что>[_total|_seconds]):
+//
+//	var heartbeats = metrics.NewCounter("mediawork_heartbeats_ingested_total", "Heartbeats stored.")
+//
+// Handler отдаёт все зарегистрированные метрики; его слушает отдельный адрес (metrics_addr),
+// а не публичный API.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefBuckets — границы гистограмм длительности по умолчанию, секунды
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+//
+// ---------- REGISTRY ----------
+//
+// family — метрика с именем и типом; write выводит её строки
+type family struct {
+	name, help, typ string
+	write           func(w *bufio.Writer)
+}
+
+var registry = struct {
+	sync.Mutex
+	families map[string]*family
+}{families: map[string]*family{}}
+
+// register паникует на повторном имени: это ошибка в коде, а не во время работы
+func register(name, help, typ string, write func(w *bufio.Writer)) {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, dup := registry.families[name]; dup {
+		panic("metrics: duplicate metric " + name)
+	}
+	registry.families[name] = &family{name: name, help: help, typ: typ, write: write}
+}
+
+// Write выводит все метрики в порядке имён
+func Write(out io.Writer) error {
+	registry.Lock()
+	list := make([]*family, 0, len(registry.families))
+	for _, f := range registry.families {
+		list = append(list, f)
+	}
+	registry.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+
+	w := bufio.NewWriter(out)
+	for _, f := range list {
+		w.WriteString("# HELP " + f.name + " " + strings.ReplaceAll(f.help, "\n", " ") + "\n")
+		w.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+		f.write(w)
+	}
+	return w.Flush()
+}
+
+// Handler — GET /metrics
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w)
+	})
+}
+
+//
+// ---------- COUNTER / GAUGE ----------
+//
+// atomicFloat — float64 без блокировок
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) load() float64 { return math.Float64frombits(f.bits.Load()) }
+
+// Counter — только растёт
+type Counter struct {
+	v atomicFloat
+}
+
+func (c *Counter) Inc()          { c.v.add(1) }
+func (c *Counter) Add(v float64) { c.v.add(v) }
+
+func (c *Counter) sample(w *bufio.Writer, name string, labels []string) {
+	writeSample(w, name, labels, c.v.load())
+}
+
+// Gauge — текущее значение
+type Gauge struct {
+	v atomicFloat
+}
+
+func (g *Gauge) Set(v float64) { g.v.bits.Store(math.Float64bits(v)) }
+func (g *Gauge) Add(v float64) { g.v.add(v) }
+func (g *Gauge) Inc()          { g.v.add(1) }
+func (g *Gauge) Dec()          { g.v.add(-1) }
+
+func (g *Gauge) sample(w *bufio.Writer, name string, labels []string) {
+	writeSample(w, name, labels, g.v.load())
+}
+
+func NewCounter(name, help string) *Counter {
+	c := &Counter{}
+	register(name, help, "counter", func(w *bufio.Writer) { c.sample(w, name, nil) })
+	return c
+}
+
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{}
+	register(name, help, "gauge", func(w *bufio.Writer) { g.sample(w, name, nil) })
+	return g
+}
+
+// NewGaugeFunc / NewCounterFunc — значение считывается при каждом запросе /metrics
+// (статистика пула соединений, число горутин)
+func NewGaugeFunc(name, help string, fn func() float64) {
+	register(name, help, "gauge", func(w *bufio.Writer) { writeSample(w, name, nil, fn()) })
+}
+
+func NewCounterFunc(name, help string, fn func() float64) {
+	register(name, help, "counter", func(w *bufio.Writer) { writeSample(w, name, nil, fn()) })
+}
+
+//
+// ---------- HISTOGRAM ----------
+//
+// Histogram — распределение значений по корзинам (обычно длительности в секундах)
+type Histogram struct {
+	upper  []float64
+	counts []atomic.Uint64 // по корзинам, последняя — +Inf
+	count  atomic.Uint64
+	sum    atomicFloat
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{upper: buckets, counts: make([]atomic.Uint64, len(buckets)+1)}
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.counts[sort.SearchFloat64s(h.upper, v)].Add(1)
+	h.count.Add(1)
+	h.sum.add(v)
+}
+
+// ObserveSince — длительность от start в секундах
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+func (h *Histogram) sample(w *bufio.Writer, name string, labels []string) {
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		le := "+Inf"
+		if i < len(h.upper) {
+			le = formatFloat(h.upper[i])
+		}
+		writeSample(w, name+"_bucket", append(labels[:len(labels):len(labels)], "le", le), float64(cumulative))
+	}
+	writeSample(w, name+"_sum", labels, h.sum.load())
+	writeSample(w, name+"_count", labels, float64(h.count.Load()))
+}
+
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	h := newHistogram(buckets)
+	register(name, help, "histogram", func(w *bufio.Writer) { h.sample(w, name, nil) })
+	return h
+}
+
+//
+// ---------- LABELS ----------
+//
+// Vec — семейство метрик с метками; дочерняя метрика создаётся при первом With
+type Vec[M any] struct {
+	labels []string
+	new    func() *M
+
+	mu       sync.Mutex
+	children map[string]*child[M]
+}
+
+type child[M any] struct {
+	values []string
+	m      *M
+}
+
+type (
+	CounterVec   = Vec[Counter]
+	GaugeVec     = Vec[Gauge]
+	HistogramVec = Vec[Histogram]
+)
+
+// With — метрика с значениями меток в порядке их объявления
+func (v *Vec[M]) With(values ...string) *M {
+	if len(values) != len(v.labels) {
+		panic("metrics: want labels " + strings.Join(v.labels, ", "))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, ok := v.children[key]
+	if !ok {
+		c = &child[M]{values: append([]string(nil), values...), m: v.new()}
+		v.children[key] = c
+	}
+	return c.m
+}
+
+func newVec[M any](name, help, typ string, labels []string, newM func() *M, sample func(*M, *bufio.Writer, string, []string)) *Vec[M] {
+	v := &Vec[M]{labels: labels, new: newM, children: map[string]*child[M]{}}
+	register(name, help, typ, func(w *bufio.Writer) {
+		v.mu.Lock()
+		list := make([]*child[M], 0, len(v.children))
+		for _, c := range v.children {
+			list = append(list, c)
+		}
+		v.mu.Unlock()
+		sort.Slice(list, func(i, j int) bool {
+			return strings.Join(list[i].values, "\xff") < strings.Join(list[j].values, "\xff")
+		})
+
+		for _, c := range list {
+			pairs := make([]string, 0, 2*len(labels))
+			for i, l := range labels {
+				pairs = append(pairs, l, c.values[i])
+			}
+			sample(c.m, w, name, pairs)
+		}
+	})
+	return v
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return newVec(name, help, "counter", labels, func() *Counter { return &Counter{} }, (*Counter).sample)
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return newVec(name, help, "gauge", labels, func() *Gauge { return &Gauge{} }, (*Gauge).sample)
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return newVec(name, help, "histogram", labels, func() *Histogram { return newHistogram(buckets) }, (*Histogram).sample)
+}
+
+//
+// ---------- FORMAT ----------
+//
+// writeSample — строка `name{l1="v1",l2="v2"} value`; labels — пары имя, значение
+func writeSample(w *bufio.Writer, name string, labels []string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(labels[i])
+			w.WriteString(`="`)
+			w.WriteString(labelEscaper.Replace(labels[i+1]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// ---------- RUNTIME ----------
+var startTime = float64(time.Now().Unix())
+
+func init() {
+	NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	NewGaugeFunc("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", func() float64 {
+		return startTime
+	})
+}
@@ -1,6 +1,13 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"encoding/xml"
+	"log/slog"
+	"time"
+
+	"mediawork/internal/money"
+)
 
 //
 // ─── USERS / AUTH ──────────────────────────────────────────────────────────────
@@ -12,15 +19,65 @@ type User struct {
 	FullName     string    `json:"full_name"`
 	Name         string    `json:"name"` // алиас, можно использовать на фронте
 	Role         string    `json:"role"`
+	IsActive     bool      `json:"is_active"`
 	PasswordHash string    `json:"-"` // не отдаём наружу
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// LogValue — пользователь в логе: только ID и роль, без адреса и хэша пароля
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int64("id", u.ID), slog.String("role", u.Role))
+}
+
 type UserClaims struct {
 	UserID int64  `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+
+	// ImpersonatorID — админ, выдавший токен через impersonation; 0 для обычного входа
+	ImpersonatorID int64 `json:"impersonator_id,omitempty"`
+
+	// запрос по API-ключу компании: UserID = 0, доступ ограничен компанией и scopes
+	APIKeyID  int64    `json:"api_key_id,omitempty"`
+	CompanyID int64    `json:"company_id,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+}
+
+//
+// ─── LISTS ────────────────────────────────────────────────────────────────────
+//
+
+// ListQuery — фильтры, сортировка и позиция страницы списка (кампании, счета, фасады,
+// креативы, пользователи, компании). Что поддерживает конкретный список — см. его ListSpec.
+type ListQuery struct {
+	Status    string
+	CompanyID *int64
+	Role      string     // пользователи: глобальная роль
+	From, To  *time.Time // по дате списка, To не включительно
+	Search    string     // подстрока без учёта регистра
+	Sort      string     // поле сортировки; пустое — по умолчанию списка
+	Desc      bool
+	Cursor    *Cursor // nil — первая страница
+	Limit     int
+}
+
+// Cursor — граница страницы: значение поля сортировки и id крайней строки.
+// Backward — страница перед этой строкой (prev_cursor), иначе после неё (next_cursor).
+type Cursor struct {
+	Sort     string `json:"s"`
+	Desc     bool   `json:"d,omitempty"`
+	Value    string `json:"v"`
+	ID       int64  `json:"id"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// Page — страница списка; курсора нет, если в ту сторону страниц больше нет
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Limit      int    `json:"limit"`
 }
 
 //
@@ -43,6 +100,57 @@ type CompanyMember struct {
 	Role   string `json:"role"`
 }
 
+// CompanySuspension — одна деактивация компании и что она затронула
+type CompanySuspension struct {
+	ID              int64      `json:"id"`
+	CompanyID       int64      `json:"company_id"`
+	Kind            string     `json:"kind"` // deactivation | dunning
+	Reason          string     `json:"reason,omitempty"`
+	SuspendedBy     *int64     `json:"suspended_by,omitempty"`
+	SuspendedAt     time.Time  `json:"suspended_at"`
+	ReactivatedBy   *int64     `json:"reactivated_by,omitempty"`
+	ReactivatedAt   *time.Time `json:"reactivated_at,omitempty"`
+	PausedCampaigns int        `json:"paused_campaigns"`
+	SuspendedSlots  int        `json:"suspended_slots"`
+}
+
+// SuspendedCampaign — кампания, поставленная на паузу приостановкой, и её текущий статус
+type SuspendedCampaign struct {
+	CampaignID int64  `json:"campaign_id"`
+	PrevStatus string `json:"prev_status"`
+	Status     string `json:"status"`
+}
+
+// UserCompany — компания пользователя и его роль в ней (для /api/me)
+type UserCompany struct {
+	CompanyID int64  `json:"company_id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	IsActive  bool   `json:"is_active"`
+}
+
+// UserProfile — ответ /api/me: поля пользователя + его компании
+type UserProfile struct {
+	*User
+	Companies []UserCompany `json:"companies"`
+}
+
+// CompanyInvitation — приглашение по email. Token отдаётся только при создании,
+// в БД хранится его sha256.
+type CompanyInvitation struct {
+	ID          int64      `json:"id"`
+	CompanyID   int64      `json:"company_id"`
+	CompanyName string     `json:"company_name,omitempty"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	Token       string     `json:"token,omitempty"`
+	InvitedBy   int64      `json:"invited_by"`
+	Status      string     `json:"status"` // pending | accepted | declined | revoked
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
 //
 // ─── FACADES ──────────────────────────────────────────────────────────────────
 //
@@ -78,6 +186,23 @@ type FacadeStatus struct {
 	AvgLatencyMS float64   `json:"avg_latency_ms"`
 }
 
+// FacadeDeviceToken — токен плеера фасада для /api/live/*. В базе только хэш;
+// сам токен отдаётся один раз при выпуске, новый выпуск отзывает прежний.
+type FacadeDeviceToken struct {
+	FacadeID int64     `json:"facade_id"`
+	Token    string    `json:"token"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// FacadeTransition — фасад сменил online/offline по heartbeat (см. FacadeRepository.SyncOnlineStatus)
+type FacadeTransition struct {
+	FacadeID   int64      `json:"facade_id"`
+	Code       string     `json:"code"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"` // online | offline
+	LastPingAt *time.Time `json:"last_ping_at,omitempty"`
+}
+
 type FacadeFullStatus struct {
 	Facade      *Facade       `json:"facade"`
 	Status      *FacadeStatus `json:"status"`
@@ -179,15 +304,28 @@ type Creative struct {
 type Invoice struct {
     ID            int64      `json:"id"`
     CompanyID     int64      `json:"company_id"`
-    InvoiceNumber string     `json:"invoice_number"`
+    InvoiceNumber string     `json:"invoice_number"` // присваивается при выставлении: INV-12-000042
+    DocumentType  string     `json:"document_type"`  // invoice | credit_note | adjustment
+
+    // для credit_note / adjustment — исходный счёт, причина и что затронуто
+    OriginalInvoiceID *int64          `json:"original_invoice_id,omitempty"`
+    Reason            string          `json:"reason,omitempty"`
+    Refs              *AdjustmentRefs `json:"refs,omitempty"`
     
     PeriodStart   time.Time  `json:"period_start"`
     PeriodEnd     time.Time  `json:"period_end"`
 
-    AmountTotal   float64    `json:"amount_total"`
-    Currency      string     `json:"currency"`
+    AmountNet     money.Decimal `json:"amount_net"`
+    AmountTax     money.Decimal `json:"amount_tax"`
+    AmountTotal   money.Decimal `json:"amount_total"` // gross = net + tax
+    AmountPaid    money.Decimal `json:"amount_paid"`  // сумма по журналу payments
+    AmountCredited money.Decimal `json:"amount_credited"` // зачтено кредит-нотами / отрицательными корректировками
+    Currency      string        `json:"currency"`     // валюта счёта = billing currency компании
+    TaxJurisdiction string      `json:"tax_jurisdiction,omitempty"`
+
+    Lines         []InvoiceLine `json:"lines,omitempty"`
 
-    Status        string     `json:"status"`       // pending | paid | failed
+    Status        string     `json:"status"`       // pending | partially_paid | paid | overdue | failed | applied
     DueDate       *time.Time `json:"due_date,omitempty"`
     IssuedAt      time.Time  `json:"issued_at"`
     PaidAt        *time.Time `json:"paid_at,omitempty"`
@@ -196,6 +334,103 @@ type Invoice struct {
     UpdatedAt     time.Time  `json:"updated_at"`
 }
 
+// AdjustmentRefs — основание корректировки: простой фасада за период и / или конкретные показы
+type AdjustmentRefs struct {
+    FacadeID       *int64     `json:"facade_id,omitempty"`
+    OutageStart    *time.Time `json:"outage_start,omitempty"`
+    OutageEnd      *time.Time `json:"outage_end,omitempty"`
+    PlayHistoryIDs []int64    `json:"play_history_ids,omitempty"`
+}
+
+// InvoiceLine — строка счёта. UnitPrice — в валюте строки (Currency); если она отличается
+// от валюты счёта, net пересчитывается по курсу FXRate на дату выставления.
+// Суммы AmountNet / AmountTax / AmountGross — уже в валюте счёта.
+type InvoiceLine struct {
+    ID          int64          `json:"id"`
+    InvoiceID   int64          `json:"invoice_id"`
+    LineType    string         `json:"line_type"` // campaign | facade | service | manual
+    CampaignID  *int64         `json:"campaign_id,omitempty"`
+    FacadeID    *int64         `json:"facade_id,omitempty"`
+    Description string         `json:"description"`
+    Quantity    money.Decimal  `json:"quantity"`
+    Unit        string         `json:"unit,omitempty"`
+    UnitPrice   money.Decimal  `json:"unit_price"`
+    Currency    string         `json:"currency"`
+    FXRate      *money.Decimal `json:"fx_rate,omitempty"`
+    TaxRate     money.Decimal  `json:"tax_rate"` // процент, 20 = 20%
+    TaxIncluded bool           `json:"tax_included"` // UnitPrice уже с налогом
+    AmountNet   money.Decimal  `json:"amount_net"`
+    AmountTax   money.Decimal  `json:"amount_tax"`
+    AmountGross money.Decimal  `json:"amount_gross"`
+    CreatedAt   time.Time      `json:"created_at"`
+}
+
+// TaxRate — ставка налога юрисдикции (RU, DE, US-CA ...), действует с ValidFrom до ValidTo (не включая)
+type TaxRate struct {
+    ID           int64         `json:"id"`
+    Jurisdiction string        `json:"jurisdiction"`
+    Name         string        `json:"name"` // VAT, НДС, Sales tax
+    Rate         money.Decimal `json:"rate"` // процент
+    ValidFrom    time.Time     `json:"valid_from"`
+    ValidTo      *time.Time    `json:"valid_to,omitempty"`
+    CreatedAt    time.Time     `json:"created_at"`
+}
+
+// ExchangeRate — 1 Base = Rate Quote на дату ValidOn (ведёт админ)
+type ExchangeRate struct {
+    ID        int64         `json:"id"`
+    Base      string        `json:"base"`
+    Quote     string        `json:"quote"`
+    Rate      money.Decimal `json:"rate"`
+    ValidOn   time.Time     `json:"valid_on"`
+    CreatedBy *int64        `json:"created_by,omitempty"`
+    CreatedAt time.Time     `json:"created_at"`
+}
+
+// CompanyBilling — валюта выставления счетов и налоговая юрисдикция компании
+type CompanyBilling struct {
+    CompanyID       int64  `json:"company_id"`
+    Currency        string `json:"currency"`
+    TaxJurisdiction string `json:"tax_jurisdiction,omitempty"`
+    TaxExempt       bool   `json:"tax_exempt"`
+}
+
+// CurrencyAmount — сумма в одной валюте и её пересчёт в целевую
+type CurrencyAmount struct {
+    Currency  string        `json:"currency"`
+    Amount    money.Decimal `json:"amount"`
+    Rate      money.Decimal `json:"rate"`
+    Converted money.Decimal `json:"converted"`
+}
+
+// PeriodAmount — сумма счетов компании за период в её billing currency
+type PeriodAmount struct {
+    CompanyID int64            `json:"company_id"`
+    Currency  string           `json:"currency"`
+    Total     money.Decimal    `json:"total"`
+    Parts     []CurrencyAmount `json:"parts"`
+}
+
+// Payment — запись журнала оплат по счёту (частичная или полная)
+type Payment struct {
+    ID         int64     `json:"id"`
+    InvoiceID  int64     `json:"invoice_id"`
+    Amount     money.Decimal `json:"amount"`
+    Currency   string    `json:"currency"`
+    Method     string    `json:"method,omitempty"`    // bank_transfer | card | cash | ...
+    Reference  string    `json:"reference,omitempty"` // номер платёжки / транзакции
+    Note       string    `json:"note,omitempty"`
+    PaidAt     time.Time `json:"paid_at"`
+    RecordedBy *int64    `json:"recorded_by,omitempty"`
+    CreatedAt  time.Time `json:"created_at"`
+}
+
+// OverdueInvoice — неоплаченный счёт с просрочкой (для напоминаний и паузы кампаний)
+type OverdueInvoice struct {
+    Invoice
+    DaysOverdue int `json:"days_overdue"`
+}
+
 // Для PDF
 type InvoicePDF struct {
     Invoice     Invoice       `json:"invoice"`
@@ -203,6 +438,171 @@ type InvoicePDF struct {
     PlayHistory []PlayHistory `json:"play_history"`
 }
 
+//
+// ─── BUDGETS / PREPAID WALLETS ────────────────────────────────────────────────
+//
+
+// Budget — лимит расходов кампании (CampaignID != nil) или всей компании, в деньгах и / или эфире.
+// Nil-лимит — без ограничения по этому измерению. Spent растёт с каждым показом.
+type Budget struct {
+	ID              int64          `json:"id"`
+	CompanyID       int64          `json:"company_id"`
+	CampaignID      *int64         `json:"campaign_id,omitempty"`
+	Currency        string         `json:"currency"`
+	AmountLimit     *money.Decimal `json:"amount_limit,omitempty"`
+	AirtimeLimitSec *int64         `json:"airtime_limit_sec,omitempty"`
+	AmountSpent     money.Decimal  `json:"amount_spent"`
+	AirtimeSpentSec int64          `json:"airtime_spent_sec"`
+	Exhausted       bool           `json:"exhausted"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// Wallet — предоплаченный баланс компании; показы списывают с него стоимость.
+// Компании без кошелька работают по постоплате (счета).
+type Wallet struct {
+	CompanyID int64         `json:"company_id"`
+	Currency  string        `json:"currency"`
+	Balance   money.Decimal `json:"balance"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// WalletTransaction — движение по кошельку: topup (+), charge (−, за показ), adjustment (±)
+type WalletTransaction struct {
+	ID            int64         `json:"id"`
+	CompanyID     int64         `json:"company_id"`
+	Kind          string        `json:"kind"`
+	Amount        money.Decimal `json:"amount"`
+	BalanceAfter  money.Decimal `json:"balance_after"`
+	CampaignID    *int64        `json:"campaign_id,omitempty"`
+	PlayHistoryID *int64        `json:"play_history_id,omitempty"`
+	Reference     string        `json:"reference,omitempty"`
+	Note          string        `json:"note,omitempty"`
+	CreatedBy     *int64        `json:"created_by,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// CampaignBudgetStatus — всё, что ограничивает показы кампании
+type CampaignBudgetStatus struct {
+	CampaignID    int64      `json:"campaign_id"`
+	Budget        *Budget    `json:"budget,omitempty"`
+	CompanyBudget *Budget    `json:"company_budget,omitempty"`
+	Wallet        *Wallet    `json:"wallet,omitempty"`
+	ExhaustedAt   *time.Time `json:"exhausted_at,omitempty"` // кампания снята с расписания
+}
+
+// PlayCharge — списание за один показ (суммы уже в валюте каждого бюджета / кошелька)
+type PlayCharge struct {
+	PlayHistoryID  int64
+	CampaignID     int64
+	CompanyID      int64
+	Seconds        int64
+	CampaignAmount money.Decimal
+	CompanyAmount  money.Decimal
+	WalletAmount   money.Decimal
+}
+
+//
+// ─── ACCOUNTING EXPORT ────────────────────────────────────────────────────────
+//
+
+// AccountingExport — одна выгрузка в бухгалтерию. Диапазоны id (From — не включая, To — включая)
+// фиксируют состав выгрузки: её можно повторить в любом формате, а следующая инкрементальная
+// начнётся с InvoiceIDTo / PaymentIDTo.
+type AccountingExport struct {
+	ID            int64      `json:"id"`
+	Format        string     `json:"format"` // csv | json | xml
+	Incremental   bool       `json:"incremental"`
+	PeriodFrom    *time.Time `json:"period_from,omitempty"`
+	PeriodTo      *time.Time `json:"period_to,omitempty"`
+	InvoiceIDFrom int64      `json:"invoice_id_from"`
+	InvoiceIDTo   int64      `json:"invoice_id_to"`
+	PaymentIDFrom int64      `json:"payment_id_from"`
+	PaymentIDTo   int64      `json:"payment_id_to"`
+	InvoiceCount  int        `json:"invoice_count"`
+	PaymentCount  int        `json:"payment_count"`
+	CreatedBy     *int64     `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ExportDocument — выставленный документ с реквизитами контрагента
+type ExportDocument struct {
+	Invoice
+	CompanyName      string
+	CompanyLegalName string
+	CompanyVAT       string
+}
+
+// ExportPayment — оплата со ссылкой на номер документа
+type ExportPayment struct {
+	Payment
+	CompanyID     int64
+	InvoiceNumber string
+}
+
+// AccountingLedger — структурированная выгрузка (JSON / XML). Суммы — строки с точностью валюты,
+// идентификаторы стабильны между выгрузками: mw-doc-<id>, mw-line-<id>, mw-pay-<id>.
+type AccountingLedger struct {
+	XMLName     xml.Name         `json:"-" xml:"ledger"`
+	ExportID    int64            `json:"export_id" xml:"export_id,attr"`
+	GeneratedAt time.Time        `json:"generated_at" xml:"generated_at,attr"`
+	PeriodFrom  *time.Time       `json:"period_from,omitempty" xml:"period_from,attr,omitempty"`
+	PeriodTo    *time.Time       `json:"period_to,omitempty" xml:"period_to,attr,omitempty"`
+	Documents   []LedgerDocument `json:"documents" xml:"documents>document"`
+	Payments    []LedgerPayment  `json:"payments" xml:"payments>payment"`
+}
+
+type LedgerDocument struct {
+	ID               string       `json:"id" xml:"id,attr"`
+	Number           string       `json:"number" xml:"number"`
+	Type             string       `json:"type" xml:"type"` // invoice | credit_note | adjustment
+	OriginalID       string       `json:"original_id,omitempty" xml:"original_id,omitempty"`
+	Reason           string       `json:"reason,omitempty" xml:"reason,omitempty"`
+	CompanyID        int64        `json:"company_id" xml:"company>id"`
+	CompanyName      string       `json:"company_name" xml:"company>name"`
+	CompanyLegalName string       `json:"company_legal_name,omitempty" xml:"company>legal_name,omitempty"`
+	CompanyVAT       string       `json:"company_vat,omitempty" xml:"company>vat,omitempty"`
+	IssueDate        string       `json:"issue_date" xml:"issue_date"` // YYYY-MM-DD
+	DueDate          string       `json:"due_date,omitempty" xml:"due_date,omitempty"`
+	PeriodStart      string       `json:"period_start" xml:"period_start"`
+	PeriodEnd        string       `json:"period_end" xml:"period_end"`
+	Currency         string       `json:"currency" xml:"currency"`
+	TaxJurisdiction  string       `json:"tax_jurisdiction,omitempty" xml:"tax_jurisdiction,omitempty"`
+	Net              string       `json:"net" xml:"net"`
+	Tax              string       `json:"tax" xml:"tax"`
+	Total            string       `json:"total" xml:"total"`
+	Lines            []LedgerLine `json:"lines" xml:"lines>line"`
+}
+
+type LedgerLine struct {
+	ID          string `json:"id" xml:"id,attr"`
+	Type        string `json:"type" xml:"type"`
+	Description string `json:"description" xml:"description"`
+	CampaignID  *int64 `json:"campaign_id,omitempty" xml:"campaign_id,omitempty"`
+	FacadeID    *int64 `json:"facade_id,omitempty" xml:"facade_id,omitempty"`
+	Quantity    string `json:"quantity" xml:"quantity"`
+	Unit        string `json:"unit,omitempty" xml:"unit,omitempty"`
+	UnitPrice   string `json:"unit_price" xml:"unit_price"`
+	// цена в другой валюте — валюта цены и курс пересчёта в валюту документа
+	PriceCurrency string `json:"price_currency,omitempty" xml:"price_currency,omitempty"`
+	FXRate        string `json:"fx_rate,omitempty" xml:"fx_rate,omitempty"`
+	TaxRate       string `json:"tax_rate" xml:"tax_rate"`
+	Net           string `json:"net" xml:"net"`
+	Tax           string `json:"tax" xml:"tax"`
+	Gross         string `json:"gross" xml:"gross"`
+}
+
+type LedgerPayment struct {
+	ID         string `json:"id" xml:"id,attr"`
+	DocumentID string `json:"document_id" xml:"document_id"`
+	Number     string `json:"document_number" xml:"document_number"`
+	CompanyID  int64  `json:"company_id" xml:"company_id"`
+	Date       string `json:"date" xml:"date"`
+	Amount     string `json:"amount" xml:"amount"`
+	Currency   string `json:"currency" xml:"currency"`
+	Method     string `json:"method,omitempty" xml:"method,omitempty"`
+	Reference  string `json:"reference,omitempty" xml:"reference,omitempty"`
+}
+
 //
 // ─── PLAY HISTORY / LIVE STREAM ───────────────────────────────────────────────
 //
@@ -235,3 +635,294 @@ type Heartbeat struct {
 	LatencyMS int    `json:"latency_ms"`
 	SourceIP  string `json:"source_ip"`
 }
+
+//
+// ─── ROLLUPS / ARCHIVE ────────────────────────────────────────────────────────
+//
+
+// PlayRollup — агрегат play_history по фасаду и кампании (granularity: hour | day)
+type PlayRollup struct {
+	Granularity    string    `json:"granularity"`
+	BucketStart    time.Time `json:"bucket_start"`
+	FacadeID       int64     `json:"facade_id"`
+	CampaignID     int64     `json:"campaign_id"`
+	Plays          int64     `json:"plays"`
+	AirtimeSec     int64     `json:"airtime_sec"`
+	BitrateSum     int64     `json:"bitrate_sum"`
+	SyncLatencySum int64     `json:"sync_latency_sum"`
+	SyncLatencyMax int       `json:"sync_latency_max"`
+}
+
+// HeartbeatRollup — агрегат facade_heartbeat по фасаду
+type HeartbeatRollup struct {
+	Granularity string    `json:"granularity"`
+	BucketStart time.Time `json:"bucket_start"`
+	FacadeID    int64     `json:"facade_id"`
+	Beats       int64     `json:"beats"`
+	LatencySum  int64     `json:"latency_sum"`
+	LatencyMax  int       `json:"latency_max"`
+}
+
+// PlayHistoryArchive — выгруженный на диск кусок сырых событий
+type PlayHistoryArchive struct {
+	ID          int64     `json:"id"`
+	Source      string    `json:"source"` // play_history | facade_heartbeat
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Part        int       `json:"part"` // 1 — выгрузка суток, 2+ — строки, пришедшие позже
+	FilePath    string    `json:"file_path"`
+	RowCount    int64     `json:"row_count"`
+	SizeBytes   int64     `json:"size_bytes"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+//
+// ─── ANALYTICS ────────────────────────────────────────────────────────────────
+//
+
+// CampaignDelivery — сводка по доставке кампании за период
+type CampaignDelivery struct {
+	CampaignID       int64     `json:"campaign_id"`
+	From             time.Time `json:"from"`
+	To               time.Time `json:"to"`
+	Plays            int64     `json:"plays"`
+	AirtimeSec       int64     `json:"airtime_sec"`
+	BookedSec        int64     `json:"booked_sec"`
+	Pacing           float64   `json:"pacing"` // airtime / booked, 1.0 — ровно по плану
+	AvgBitrateKbps   float64   `json:"avg_bitrate_kbps"`
+	AvgSyncLatencyMS float64   `json:"avg_sync_latency_ms"`
+	MaxSyncLatencyMS int       `json:"max_sync_latency_ms"`
+}
+
+// DeliveryBreakdownRow — одна строка разбивки (по фасаду, дню или часу)
+type DeliveryBreakdownRow struct {
+	FacadeID         *int64     `json:"facade_id,omitempty"`
+	FacadeName       string     `json:"facade_name,omitempty"`
+	Bucket           *time.Time `json:"bucket,omitempty"`
+	Plays            int64      `json:"plays"`
+	AirtimeSec       int64      `json:"airtime_sec"`
+	AvgBitrateKbps   float64    `json:"avg_bitrate_kbps"`
+	AvgSyncLatencyMS float64    `json:"avg_sync_latency_ms"`
+	MaxSyncLatencyMS int        `json:"max_sync_latency_ms"`
+}
+
+//
+// ─── AUDIENCE / IMPRESSIONS ───────────────────────────────────────────────────
+//
+
+// AudienceProfile — оценка аудитории фасада: поток людей по часам недели
+type AudienceProfile struct {
+	FacadeID         int64          `json:"facade_id"`
+	Timezone         string         `json:"timezone"`          // в каком поясе заданы часы профиля
+	VisibilityFactor float64        `json:"visibility_factor"` // доля прохожих, которые реально видят экран (0..1)
+	DwellSec         int            `json:"dwell_sec"`         // сколько секунд человек в зоне видимости
+	Hours            []AudienceHour `json:"hours"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+type AudienceHour struct {
+	Weekday  int     `json:"weekday"` // 0 = воскресенье, как time.Weekday
+	Hour     int     `json:"hour"`    // 0..23
+	Footfall float64 `json:"footfall"`
+}
+
+// FacadeImpressions — оценка показов кампании на одном фасаде
+type FacadeImpressions struct {
+	FacadeID    int64   `json:"facade_id"`
+	FacadeName  string  `json:"facade_name"`
+	Plays       int64   `json:"plays"`
+	AirtimeSec  int64   `json:"airtime_sec"`
+	Impressions float64 `json:"impressions"`
+	Reach       float64 `json:"reach"`
+	Frequency   float64 `json:"frequency"`
+	Cost        float64 `json:"cost"`
+	Currency    string  `json:"currency,omitempty"`
+	CPM         float64 `json:"cpm"`
+}
+
+// CampaignImpressions — итог по кампании + разбивка по фасадам
+type CampaignImpressions struct {
+	CampaignID  int64               `json:"campaign_id"`
+	From        time.Time           `json:"from"`
+	To          time.Time           `json:"to"`
+	Plays       int64               `json:"plays"`
+	AirtimeSec  int64               `json:"airtime_sec"`
+	Impressions float64             `json:"impressions"`
+	Reach       float64             `json:"reach"`
+	Frequency   float64             `json:"frequency"`
+	Cost        float64             `json:"cost"`
+	Currency    string              `json:"currency,omitempty"` // "mixed", если у фасадов разные валюты
+	CPM         float64             `json:"cpm"`
+	Facades     []FacadeImpressions `json:"facades"`
+}
+
+//
+// ─── GEOJSON ──────────────────────────────────────────────────────────────────
+//
+
+type GeoFeatureCollection struct {
+	Type     string       `json:"type"` // FeatureCollection
+	Features []GeoFeature `json:"features"`
+}
+
+type GeoFeature struct {
+	Type       string         `json:"type"` // Feature
+	ID         int64          `json:"id"`
+	Geometry   GeoGeometry    `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// GeoGeometry — Point ([lon, lat]) или Polygon ([[[lon, lat], ...]])
+type GeoGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+//
+// ─── FACADE TAGS / DYNAMIC GROUPS ─────────────────────────────────────────────
+//
+
+// FacadeFilter — условие динамической группы.
+// Tags: ключ → допустимые значения (между ключами AND, внутри ключа OR).
+// Онлайн-статус сюда намеренно не входит: состав группы не должен «мигать» вместе со связью.
+type FacadeFilter struct {
+	Tags map[string][]string `json:"tags,omitempty"`
+}
+
+// DynamicFacadeGroup — сохранённый фильтр; состав вычисляется на лету
+type DynamicFacadeGroup struct {
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Filter      FacadeFilter `json:"filter"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// CampaignGroupTarget — кампания нацелена на динамическую группу
+type CampaignGroupTarget struct {
+	CampaignID int64     `json:"campaign_id"`
+	GroupID    int64     `json:"group_id"`
+	GroupName  string    `json:"group_name"`
+	Facades    int       `json:"facades"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+//
+// ─── AUDIT LOG ────────────────────────────────────────────────────────────────
+//
+
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// AuditEntry — запись append-only журнала изменений
+type AuditEntry struct {
+	ID          int64  `json:"id"`
+	ActorUserID *int64 `json:"actor_user_id,omitempty"`
+	ActorEmail  string `json:"actor_email,omitempty"`
+	// ImpersonatorID — админ, от чьего имени на самом деле выполнено действие
+	ImpersonatorID *int64                 `json:"impersonator_id,omitempty"`
+	CompanyID      *int64                 `json:"company_id,omitempty"`
+	Action         string                 `json:"action"`      // например user.role_change, invoice.status_change
+	EntityType     string                 `json:"entity_type"` // user | company | campaign | invoice | ...
+	EntityID       *int64                 `json:"entity_id,omitempty"`
+	Before         json.RawMessage        `json:"before,omitempty"`
+	After          json.RawMessage        `json:"after,omitempty"`
+	Diff           map[string]AuditChange `json:"diff,omitempty"`
+	RequestID      string                 `json:"request_id,omitempty"`
+	IP             string                 `json:"ip,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+type AuditFilter struct {
+	EntityType string
+	EntityID   *int64
+	ActorID    *int64
+	Action     string
+	From, To   *time.Time
+	Limit      int
+	Offset     int
+}
+
+//
+// ─── WEBHOOKS ─────────────────────────────────────────────────────────────────
+//
+
+// WebhookEndpoint — URL компании, подписанный на типы событий. Secret — ключ HMAC подписи,
+// отдаётся только при создании и ротации.
+type WebhookEndpoint struct {
+	ID          int64     `json:"id"`
+	CompanyID   int64     `json:"company_id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	EventTypes  []string  `json:"event_types"`
+	Secret      string    `json:"secret,omitempty"`
+	IsActive    bool      `json:"is_active"`
+	CreatedBy   *int64    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookEvent — запись outbox: событие сохраняется до отправки и переживает рестарт
+type WebhookEvent struct {
+	ID        int64           `json:"id"`
+	CompanyID int64           `json:"company_id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// WebhookDelivery — доставка одного события на один endpoint
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	EventID        int64      `json:"event_id"`
+	EndpointID     int64      `json:"endpoint_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"` // pending | succeeded | failed
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// для отправки: событие и endpoint (в API не отдаются)
+	Event    *WebhookEvent    `json:"-"`
+	Endpoint *WebhookEndpoint `json:"-"`
+}
+
+// WebhookAttempt — одна попытка доставки (журнал)
+type WebhookAttempt struct {
+	ID           int64     `json:"id"`
+	DeliveryID   int64     `json:"delivery_id"`
+	Attempt      int       `json:"attempt"`
+	Manual       bool      `json:"manual"` // повтор вручную из API
+	StatusCode   *int      `json:"status_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMS   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+//
+// ─── API KEYS ─────────────────────────────────────────────────────────────────
+//
+
+// APIKey — ключ компании для автоматизации. В базе только хэш; сам ключ (Key)
+// отдаётся один раз при создании, дальше виден только Prefix.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	CompanyID  int64      `json:"company_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *int64     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy  *int64     `json:"revoked_by,omitempty"`
+}
@@ -0,0 +1,51 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCurrency — валюта по умолчанию (как DEFAULT в таблицах invoices / payments)
+const DefaultCurrency = "RUB"
+
+// валюты без дробной части; остальные считаем с двумя знаками
+var zeroDecimalCurrencies = map[string]bool{
+	"JPY": true, "KRW": true, "VND": true, "CLP": true, "ISK": true, "UGX": true,
+}
+
+// NormalizeCurrency — код ISO 4217 в верхнем регистре; пустая строка — ошибка
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("invalid currency code %q", code)
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("invalid currency code %q", code)
+		}
+	}
+	return code, nil
+}
+
+// MinorUnits — знаков после запятой у валюты (копейки, центы)
+func MinorUnits(currency string) int32 {
+	if zeroDecimalCurrencies[strings.ToUpper(currency)] {
+		return 0
+	}
+	return 2
+}
+
+// RoundTo — округление суммы до минимальной единицы валюты
+func (d Decimal) RoundTo(currency string) Decimal {
+	return d.Round(MinorUnits(currency))
+}
+
+// RoundToErr — то же, что RoundTo, но переполнение — ErrOverflow (для сумм из запроса)
+func (d Decimal) RoundToErr(currency string) (Decimal, error) {
+	return d.RoundErr(MinorUnits(currency))
+}
+
+// Format — "1234.50 EUR"
+func Format(d Decimal, currency string) string {
+	return d.StringFixed(MinorUnits(currency)) + " " + currency
+}
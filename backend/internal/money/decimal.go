@@ -0,0 +1,389 @@
+// Package money — денежные суммы без float64: десятичное число с фиксированной точкой
+// и валюты (ISO 4217) с количеством знаков после запятой.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale — знаков после запятой во внутреннем представлении.
+// Хватает и на курсы валют (0.010852), и на суммы до ~9·10^12.
+const Scale = 6
+
+var scaleFactor = pow10(Scale)
+
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// ErrOverflow — результат не помещается в Decimal; errors.Is(err, ErrInvalidDecimal) тоже true,
+// так что HTTP-слой отвечает 422, как на неверное число в запросе
+var ErrOverflow = fmt.Errorf("%w: out of range", ErrInvalidDecimal)
+
+// Decimal — v / 10^Scale. Нулевое значение — 0.
+// Арифметика точная (сложение) либо с округлением половины от нуля (умножение, деление);
+// переполнение int64 в Add / Sub / Mul / Round — паника, как деление на ноль. Для значений из запроса —
+// AddErr / SubErr / MulErr / RoundErr, которые вместо паники возвращают ErrOverflow.
+// math.MinInt64 не допускается: диапазон симметричен, и Neg не переполняется.
+type Decimal struct {
+	v int64
+}
+
+var (
+	Zero    = Decimal{}
+	One     = Decimal{v: scaleFactor}
+	Hundred = Decimal{v: 100 * scaleFactor}
+)
+
+func NewFromInt(n int64) Decimal {
+	return Decimal{v: mustFit(new(big.Int).Mul(big.NewInt(n), big.NewInt(scaleFactor)))}
+}
+
+//
+// ---------- PARSE ----------
+//
+// Parse принимает "123", "-0.5", "1234.567890"; знаков после запятой — не больше Scale.
+func Parse(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Zero, ErrInvalidDecimal
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg, s = true, s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, frac, _ := strings.Cut(s, ".")
+	if intPart == "" && frac == "" || len(frac) > Scale || !digits(intPart) || !digits(frac) {
+		return Zero, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	frac += strings.Repeat("0", Scale-len(frac))
+
+	n, ok := new(big.Int).SetString("0"+intPart+frac, 10)
+	if !ok || !n.IsInt64() {
+		return Zero, fmt.Errorf("%w: %q out of range", ErrInvalidDecimal, s)
+	}
+	v := n.Int64()
+	if neg {
+		v = -v
+	}
+	return Decimal{v: v}, nil
+}
+
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func digits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+//
+// ---------- ARITHMETIC ----------
+//
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{v: mustFit(new(big.Int).Add(big.NewInt(d.v), big.NewInt(o.v)))}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	return Decimal{v: mustFit(new(big.Int).Sub(big.NewInt(d.v), big.NewInt(o.v)))}
+}
+
+func (d Decimal) Neg() Decimal { return Decimal{v: -d.v} }
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{v: mustFit(d.mul(o))}
+}
+
+// AddErr / SubErr / MulErr — то же, что Add / Sub / Mul, но переполнение — ErrOverflow
+func (d Decimal) AddErr(o Decimal) (Decimal, error) {
+	return checked(new(big.Int).Add(big.NewInt(d.v), big.NewInt(o.v)))
+}
+
+func (d Decimal) SubErr(o Decimal) (Decimal, error) {
+	return checked(new(big.Int).Sub(big.NewInt(d.v), big.NewInt(o.v)))
+}
+
+func (d Decimal) MulErr(o Decimal) (Decimal, error) {
+	return checked(d.mul(o))
+}
+
+func (d Decimal) mul(o Decimal) *big.Int {
+	n := new(big.Int).Mul(big.NewInt(d.v), big.NewInt(o.v))
+	return divRound(n, big.NewInt(scaleFactor))
+}
+
+// Div — d / o с округлением до Scale знаков; деление на ноль паникует
+func (d Decimal) Div(o Decimal) Decimal {
+	if o.v == 0 {
+		panic("money: division by zero")
+	}
+	n := new(big.Int).Mul(big.NewInt(d.v), big.NewInt(scaleFactor))
+	return Decimal{v: mustFit(divRound(n, big.NewInt(o.v)))}
+}
+
+// Round — округление до places знаков (половина — от нуля)
+func (d Decimal) Round(places int32) Decimal {
+	return Decimal{v: mustFit(d.round(places))}
+}
+
+// RoundErr — то же, что Round, но округление за пределы диапазона — ErrOverflow
+func (d Decimal) RoundErr(places int32) (Decimal, error) {
+	return checked(d.round(places))
+}
+
+func (d Decimal) round(places int32) *big.Int {
+	if places >= Scale {
+		return big.NewInt(d.v)
+	}
+	if places < 0 {
+		places = 0
+	}
+	q := d.units(places)
+	return q.Mul(q, big.NewInt(pow10(Scale-places)))
+}
+
+// units — d в единицах 10^-places с округлением; places — от 0 до Scale
+func (d Decimal) units(places int32) *big.Int {
+	return divRound(big.NewInt(d.v), big.NewInt(pow10(Scale-places)))
+}
+
+func (d Decimal) Sign() int {
+	switch {
+	case d.v > 0:
+		return 1
+	case d.v < 0:
+		return -1
+	}
+	return 0
+}
+
+func (d Decimal) IsZero() bool { return d.v == 0 }
+
+func (d Decimal) Cmp(o Decimal) int {
+	switch {
+	case d.v < o.v:
+		return -1
+	case d.v > o.v:
+		return 1
+	}
+	return 0
+}
+
+func (d Decimal) Equal(o Decimal) bool { return d.v == o.v }
+
+// Sum — сумма списка (для итогов по строкам)
+func Sum(list ...Decimal) Decimal {
+	total := Zero
+	for _, d := range list {
+		total = total.Add(d)
+	}
+	return total
+}
+
+// divRound — n / m с округлением половины от нуля
+func divRound(n, m *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(n, m, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	if twice.Cmp(new(big.Int).Abs(m)) >= 0 {
+		if (n.Sign() < 0) != (m.Sign() < 0) {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func mustFit(n *big.Int) int64 {
+	if !fits(n) {
+		panic("money: decimal overflow")
+	}
+	return n.Int64()
+}
+
+func checked(n *big.Int) (Decimal, error) {
+	if !fits(n) {
+		return Zero, ErrOverflow
+	}
+	return Decimal{v: n.Int64()}, nil
+}
+
+// fits — n помещается в Decimal: int64 без math.MinInt64
+func fits(n *big.Int) bool {
+	return n.IsInt64() && n.Int64() != math.MinInt64
+}
+
+func pow10(n int32) int64 {
+	p := int64(1)
+	for i := int32(0); i < n; i++ {
+		p *= 10
+	}
+	return p
+}
+
+//
+// ---------- FORMAT ----------
+//
+// String — без лишних нулей: "12.5", "-3", "0.010852"
+func (d Decimal) String() string {
+	s := d.StringFixed(Scale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// StringFixed — ровно places знаков после запятой (с округлением): "12.50"
+func (d Decimal) StringFixed(places int32) string {
+	if places > Scale {
+		places = Scale
+	}
+	if places < 0 {
+		places = 0
+	}
+	// в единицах 10^-places: округление вверх у края диапазона не переполняет int64
+	n := d.units(places)
+
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+		n.Neg(n)
+	}
+	s := n.String()
+	if places == 0 {
+		return sign + s
+	}
+	if pad := int(places) + 1 - len(s); pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+	return sign + s[:len(s)-int(places)] + "." + s[len(s)-int(places):]
+}
+
+//
+// ---------- JSON / SQL ----------
+//
+// В JSON — число (фронт ждёт number), без промежуточного float64.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON принимает и число, и строку ("12.50"); null — ноль.
+// Снимается ровно одна пара кавычек: "12 или ""12"" — ошибка.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		*d = Zero
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	parse := Parse
+	if strings.ContainsAny(s, "eE") {
+		parse = parseExp
+	}
+	v, err := parse(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// maxExp — больше по модулю показатель не бывает у значения, помещающегося в Decimal
+// (если мантисса без сотен лишних нулей); заодно не даёт считать 10^1000000000
+const maxExp = 64
+
+// parseExp — "1.5e3", "-2E-4": мантисса и показатель точно, через big.Rat, без float64.
+// Знаков после запятой в результате — не больше Scale, как у Parse.
+func parseExp(s string) (Decimal, error) {
+	mant, exp, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "e")
+	e, err := strconv.Atoi(exp)
+	if err != nil || e < -maxExp || e > maxExp {
+		return Zero, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	unsigned := strings.TrimLeft(mant, "+-")
+	intPart, frac, _ := strings.Cut(unsigned, ".")
+	if len(mant)-len(unsigned) > 1 || intPart == "" && frac == "" || !digits(intPart) || !digits(frac) {
+		return Zero, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	r, ok := new(big.Rat).SetString(mant)
+	if !ok {
+		return Zero, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	shift := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(e))), nil))
+	if e < 0 {
+		shift.Inv(shift)
+	}
+	r.Mul(r, shift)
+	r.Mul(r, new(big.Rat).SetInt64(scaleFactor))
+	if !r.IsInt() {
+		return Zero, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidDecimal, s, Scale)
+	}
+	if !fits(r.Num()) {
+		return Zero, fmt.Errorf("%w: %q out of range", ErrInvalidDecimal, s)
+	}
+	return Decimal{v: r.Num().Int64()}, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Scan — NUMERIC из lib/pq приходит как []byte ("123.45")
+func (d *Decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Zero
+		return nil
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	case int64:
+		*d = NewFromInt(v)
+		return nil
+	case float64:
+		return d.scanString(strconv.FormatFloat(v, 'f', Scale, 64))
+	}
+	return fmt.Errorf("money: cannot scan %T into Decimal", src)
+}
+
+func (d *Decimal) scanString(s string) error {
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
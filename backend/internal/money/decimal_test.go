@@ -0,0 +1,215 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr error
+	}{
+		{in: "123", want: "123"},
+		{in: "-0.5", want: "-0.5"},
+		{in: "+7.25", want: "7.25"},
+		{in: " 1234.567890 ", want: "1234.56789"},
+		{in: ".5", want: "0.5"},
+		{in: "5.", want: "5"},
+		{in: "0.000001", want: "0.000001"},
+		{in: "9223372036854.775807", want: "9223372036854.775807"},
+		{in: "", wantErr: ErrInvalidDecimal},
+		{in: "-", wantErr: ErrInvalidDecimal},
+		{in: ".", wantErr: ErrInvalidDecimal},
+		{in: "1.2.3", wantErr: ErrInvalidDecimal},
+		{in: "12a", wantErr: ErrInvalidDecimal},
+		{in: "--1", wantErr: ErrInvalidDecimal},
+		{in: "0.0000001", wantErr: ErrInvalidDecimal}, // больше Scale знаков
+		{in: "9223372036854.775808", wantErr: ErrInvalidDecimal},
+		{in: "1e3", wantErr: ErrInvalidDecimal}, // показатель — только в JSON
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Parse(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.in, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr error
+	}{
+		{in: `12.5`, want: "12.5"},
+		{in: `"12.50"`, want: "12.5"},
+		{in: `null`, want: "0"},
+		{in: `1.5e3`, want: "1500"},
+		{in: `-2E-4`, want: "-0.0002"},
+		{in: `"1e2"`, want: "100"},
+		{in: `1e-7`, wantErr: ErrInvalidDecimal},
+		{in: `1e20`, wantErr: ErrInvalidDecimal},
+		{in: `1e1000000000`, wantErr: ErrInvalidDecimal},
+		{in: `1e`, wantErr: ErrInvalidDecimal},
+		{in: `e5`, wantErr: ErrInvalidDecimal},
+		{in: `+-1e2`, wantErr: ErrInvalidDecimal},
+		{in: `"12`, wantErr: ErrInvalidDecimal},
+		{in: `12"`, wantErr: ErrInvalidDecimal},
+		{in: `""12""`, wantErr: ErrInvalidDecimal},
+		{in: `"-9223372036854.775808e0"`, wantErr: ErrInvalidDecimal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var d Decimal
+			err := d.UnmarshalJSON([]byte(tt.in))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UnmarshalJSON(%s) error = %v, want %v", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalJSON(%s): %v", tt.in, err)
+			}
+			if d.String() != tt.want {
+				t.Errorf("UnmarshalJSON(%s) = %s, want %s", tt.in, d, tt.want)
+			}
+		})
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		in     string
+		places int32
+		want   string
+	}{
+		{"1.005", 2, "1.01"},
+		{"1.004999", 2, "1"},
+		{"-1.005", 2, "-1.01"}, // половина — от нуля
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"0.123456", 6, "0.123456"},
+		{"0.123456", 9, "0.123456"},
+		{"12.345", -1, "12"}, // отрицательное places — как 0
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.in).Round(tt.places).String(); got != tt.want {
+			t.Errorf("%s.Round(%d) = %s, want %s", tt.in, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestRoundToCurrency(t *testing.T) {
+	tests := []struct {
+		in, currency, want string
+	}{
+		{"10.555", "EUR", "10.56 EUR"},
+		{"10.5", "JPY", "11 JPY"},
+		{"-0.004", "RUB", "0.00 RUB"}, // без «минус ноля»
+	}
+	for _, tt := range tests {
+		d := MustParse(tt.in).RoundTo(tt.currency)
+		if got := Format(d, tt.currency); got != tt.want {
+			t.Errorf("Format(%s.RoundTo(%s)) = %s, want %s", tt.in, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		a, b, mul, div string
+	}{
+		{"2.5", "4", "10", "0.625"},
+		{"0.333333", "3", "0.999999", "0.111111"},
+		{"-1.5", "0.5", "-0.75", "-3"},
+		{"1", "3", "3", "0.333333"},
+		{"2", "3", "6", "0.666667"},
+	}
+	for _, tt := range tests {
+		a, b := MustParse(tt.a), MustParse(tt.b)
+		if got := a.Mul(b).String(); got != tt.mul {
+			t.Errorf("%s * %s = %s, want %s", tt.a, tt.b, got, tt.mul)
+		}
+		if got := a.Div(b).String(); got != tt.div {
+			t.Errorf("%s / %s = %s, want %s", tt.a, tt.b, got, tt.div)
+		}
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	max := Decimal{v: math.MaxInt64}
+	min := max.Neg()
+	big := NewFromInt(10_000_000)
+
+	tests := []struct {
+		name string
+		op   func() (Decimal, error)
+		want string // "" — ErrOverflow
+	}{
+		{"add", func() (Decimal, error) { return max.AddErr(One) }, ""},
+		{"sub", func() (Decimal, error) { return min.SubErr(One) }, ""},
+		{"mul", func() (Decimal, error) { return big.MulErr(big) }, ""},
+		{"round", func() (Decimal, error) { return max.RoundErr(2) }, ""},
+		{"round to currency", func() (Decimal, error) { return min.RoundToErr("EUR") }, ""},
+		{"add fits", func() (Decimal, error) { return max.AddErr(One.Neg()) }, "9223372036853.775807"},
+		{"mul fits", func() (Decimal, error) { return big.MulErr(NewFromInt(100)) }, "1000000000"},
+		{"round fits", func() (Decimal, error) { return MustParse("9223372036854.7754").RoundErr(3) }, "9223372036854.775"},
+		{"neg", func() (Decimal, error) { return min.Neg(), nil }, "9223372036854.775807"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op()
+			if tt.want == "" {
+				if !errors.Is(err, ErrOverflow) || !errors.Is(err, ErrInvalidDecimal) {
+					t.Fatalf("error = %v, want ErrOverflow", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("= %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Add past int64 must panic")
+		}
+	}()
+	max.Add(One)
+}
+
+// TestFormatEdge — форматирование не паникует там, где Round вышел бы за диапазон
+func TestFormatEdge(t *testing.T) {
+	max := Decimal{v: math.MaxInt64}
+	if got := max.StringFixed(2); got != "9223372036854.78" {
+		t.Errorf("StringFixed(2) = %s", got)
+	}
+	if got := Format(max.Neg(), "JPY"); got != "-9223372036855 JPY" {
+		t.Errorf("Format = %s", got)
+	}
+	if got := MustParse("0.05").StringFixed(1); got != "0.1" {
+		t.Errorf("StringFixed(1) = %s", got)
+	}
+	if got := MustParse("-0.004").StringFixed(4); got != "-0.0040" {
+		t.Errorf("StringFixed(4) = %s", got)
+	}
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Message — уведомление для людей (email, чат и т.п.)
+type Message struct {
+	Kind    string   // например invoice.reminder, invoice.overdue
+	To      []string // адреса получателей
+	Subject string
+	Body    string
+	Meta    map[string]any
+}
+
+// Notifier — точка расширения доставки уведомлений.
+// Реализация должна быть безопасна для конкурентного вызова.
+type Notifier interface {
+	Notify(ctx context.Context, m Message) error
+}
+
+// Func позволяет передать функцию как Notifier
+type Func func(ctx context.Context, m Message) error
+
+func (f Func) Notify(ctx context.Context, m Message) error { return f(ctx, m) }
+
+// LogNotifier пишет уведомления в лог — вариант по умолчанию, пока нет почты
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, m Message) error {
+	slog.InfoContext(ctx, "notify", "kind", m.Kind, "to", m.To, "subject", m.Subject)
+	return nil
+}
+
+// Multi рассылает через все notifier-ы; ошибки собираются, доставка не прерывается
+type Multi []Notifier
+
+func (mn Multi) Notify(ctx context.Context, m Message) error {
+	var errs []error
+	for _, n := range mn {
+		if err := n.Notify(ctx, m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,228 @@
+// Package openapi — спецификация API (openapi.json) и проверка ответов по ней.
+//
+// openapi.json ведётся вручную рядом с кодом: при изменении маршрутов в api.NewRouter
+// или JSON-полей моделей её нужно поправить в том же коммите. Расхождения ловит
+// go test (internal/api/contract_test.go): сверяет маршруты роутера со спецификацией
+// и прогоняет запросы по демо-данным, проверяя каждый ответ.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// Handler отдаёт спецификацию: GET /api/openapi.json
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(spec)
+}
+
+// Document — разобранная спецификация
+type Document struct {
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas    map[string]Schema    `json:"schemas"`
+		Responses  map[string]*Response `json:"responses"`
+		Parameters map[string]Parameter `json:"parameters"`
+	} `json:"components"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+
+	Method string `json:"-"`
+	Path   string `json:"-"`
+}
+
+type Parameter struct {
+	Ref      string `json:"$ref"`
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+	Example  any    `json:"example"`
+}
+
+type Response struct {
+	Ref     string                             `json:"$ref"`
+	Content map[string]struct{ Schema Schema } `json:"content"`
+}
+
+// Schema — JSON Schema в том виде, как она записана в спецификации
+type Schema = map[string]any
+
+var (
+	loadOnce sync.Once
+	loaded   *Document
+	loadErr  error
+)
+
+// Load разбирает встроенную спецификацию (один раз)
+func Load() (*Document, error) {
+	loadOnce.Do(func() {
+		var d Document
+		if err := json.Unmarshal(spec, &d); err != nil {
+			loadErr = fmt.Errorf("openapi.json: %w", err)
+			return
+		}
+		for path, item := range d.Paths {
+			for method, op := range item {
+				op.Method, op.Path = strings.ToUpper(method), path
+			}
+		}
+		loaded = &d
+	})
+	return loaded, loadErr
+}
+
+// Operations — все операции в порядке пути и метода
+func (d *Document) Operations() []*Operation {
+	var ops []*Operation
+	for _, item := range d.Paths {
+		for _, op := range item {
+			ops = append(ops, op)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+	return ops
+}
+
+// Public — операция без авторизации (security: [])
+func (op *Operation) Public() bool {
+	return op.Security != nil && len(op.Security) == 0
+}
+
+// PathParams — параметры пути с их example (подставляются в запросы проверки)
+func (d *Document) PathParams(op *Operation) []Parameter {
+	var out []Parameter
+	for _, p := range op.Parameters {
+		if p.Ref != "" {
+			p = d.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+		}
+		if p.In == "path" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+//
+// ---------- ROUTES ----------
+//
+// CheckRoutes сверяет маршруты роутера ("GET /api/facades/{id}/status") с путями спецификации:
+// чего нет в спецификации и что в ней описано, но не зарегистрировано
+func (d *Document) CheckRoutes(routes []string) (undocumented, missing []string) {
+	documented := map[string]bool{}
+	for _, op := range d.Operations() {
+		documented[op.Method+" "+op.Path] = true
+	}
+	registered := map[string]bool{}
+	for _, r := range routes {
+		method, path, _ := strings.Cut(r, " ")
+		if len(path) > 1 {
+			path = strings.TrimSuffix(path, "/")
+		}
+		key := method + " " + path
+		registered[key] = true
+		if !documented[key] {
+			undocumented = append(undocumented, key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(missing)
+	return undocumented, missing
+}
+
+//
+// ---------- RESPONSES ----------
+//
+// Find — операция по методу и фактическому пути запроса ({id} совпадает с любым сегментом)
+func (d *Document) Find(method, path string) *Operation {
+	segs := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	for tmpl, item := range d.Paths {
+		op := item[strings.ToLower(method)]
+		if op == nil {
+			continue
+		}
+		t := strings.Split(tmpl, "/")
+		if len(t) != len(segs) {
+			continue
+		}
+		match := true
+		for i := range t {
+			if !strings.HasPrefix(t[i], "{") && t[i] != segs[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return op
+		}
+	}
+	return nil
+}
+
+// ValidateResponse проверяет ответ операции: статус должен быть описан (ошибки 4xx/5xx —
+// через default), а JSON-тело — соответствовать схеме своего Content-Type
+func (d *Document) ValidateResponse(op *Operation, status int, contentType string, body []byte) error {
+	resp := op.Responses[strconv.Itoa(status)]
+	if resp == nil && status >= 400 {
+		resp = op.Responses["default"]
+	}
+	if resp == nil {
+		return fmt.Errorf("status %d is not documented", status)
+	}
+	if resp.Ref != "" {
+		resp = d.Components.Responses[strings.TrimPrefix(resp.Ref, "#/components/responses/")]
+	}
+
+	if len(resp.Content) == 0 {
+		if len(body) > 0 {
+			return fmt.Errorf("status %d: documented without body, got %d bytes", status, len(body))
+		}
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	media, ok := resp.Content[mediaType]
+	if !ok {
+		return fmt.Errorf("status %d: content type %q is not documented", status, contentType)
+	}
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil // файлы и CSV — только тип
+	}
+
+	dec := json.NewDecoder(strings.NewReader(string(body)))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("status %d: invalid JSON: %w", status, err)
+	}
+	if errs := d.validate(media.Schema, v, "$"); len(errs) > 0 {
+		return fmt.Errorf("status %d: %s", status, strings.Join(errs, "; "))
+	}
+	return nil
+}
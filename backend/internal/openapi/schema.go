@@ -0,0 +1,179 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+//
+// ---------- JSON SCHEMA ----------
+//
+// validate — подмножество JSON Schema, которым написана спецификация: $ref, type (строка или
+// список), enum, const, properties / required / additionalProperties, items, oneOf, format date-time.
+// Возвращает все найденные расхождения с путём до значения ($.items[0].status).
+func (d *Document) validate(s Schema, v any, at string) []string {
+	if ref, ok := s["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		target, ok := d.Components.Schemas[name]
+		if !ok {
+			return []string{fmt.Sprintf("%s: unknown schema %s", at, ref)}
+		}
+		return d.validate(target, v, at)
+	}
+
+	if variants, ok := s["oneOf"].([]any); ok {
+		matched := 0
+		var first []string
+		for _, variant := range variants {
+			errs := d.validate(variant.(Schema), v, at)
+			if len(errs) == 0 {
+				matched++
+			} else if first == nil {
+				first = errs
+			}
+		}
+		switch matched {
+		case 1:
+		case 0:
+			return first
+		default:
+			return []string{fmt.Sprintf("%s: matches %d oneOf variants", at, matched)}
+		}
+	}
+
+	if t, ok := s["type"]; ok && !typeMatches(t, v) {
+		return []string{fmt.Sprintf("%s: expected %v, got %s", at, t, jsonTypeOf(v))}
+	}
+	if c, ok := s["const"]; ok && fmt.Sprint(c) != fmt.Sprint(v) {
+		return []string{fmt.Sprintf("%s: expected %v, got %v", at, c, v)}
+	}
+	if enum, ok := s["enum"].([]any); ok && v != nil {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: %v is not one of %v", at, v, enum)}
+		}
+	}
+
+	var errs []string
+	switch v := v.(type) {
+	case string:
+		if s["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %q is not RFC 3339 date-time", at, v))
+			}
+		}
+
+	case []any:
+		if items, ok := s["items"].(Schema); ok {
+			for i, item := range v {
+				errs = append(errs, d.validate(items, item, fmt.Sprintf("%s[%d]", at, i))...)
+			}
+		}
+
+	case map[string]any:
+		props, _ := s["properties"].(Schema)
+		if required, ok := s["required"].([]any); ok {
+			for _, name := range required {
+				if _, ok := v[name.(string)]; !ok {
+					errs = append(errs, fmt.Sprintf("%s: missing required field %q", at, name))
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, ok := props[k].(Schema); ok {
+				errs = append(errs, d.validate(p, v[k], at+"."+k)...)
+				continue
+			}
+			switch extra := s["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					errs = append(errs, fmt.Sprintf("%s: undocumented field %q", at, k))
+				}
+			case Schema:
+				errs = append(errs, d.validate(extra, v[k], at+"."+k)...)
+			}
+		}
+	}
+	return errs
+}
+
+// typeMatches — type может быть строкой или списком ("string", ["string", "null"])
+func typeMatches(t any, v any) bool {
+	switch t := t.(type) {
+	case string:
+		return typeIs(t, v)
+	case []any:
+		for _, one := range t {
+			if name, ok := one.(string); ok && typeIs(name, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeIs(name string, v any) bool {
+	switch name {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	}
+	return false
+}
+
+func jsonTypeOf(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy — лимит Limit запросов за Window с допустимым всплеском Burst
+// (token bucket: ведро на Burst токенов пополняется со скоростью Limit/Window).
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Burst  int // 0 — равен Limit
+}
+
+// ParsePolicy разбирает запись вида "120/1m" или "120/1m:30" (лимит / окно : всплеск)
+func ParsePolicy(name, s string) (Policy, error) {
+	p := Policy{Name: name}
+	rate, burst, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	limit, window, ok := strings.Cut(rate, "/")
+	if !ok {
+		return p, fmt.Errorf("rate limit %s: want LIMIT/WINDOW[:BURST], got %q", name, s)
+	}
+
+	var err error
+	if p.Limit, err = strconv.Atoi(limit); err != nil || p.Limit <= 0 {
+		return p, fmt.Errorf("rate limit %s: bad limit %q", name, limit)
+	}
+	if p.Window, err = time.ParseDuration(window); err != nil || p.Window <= 0 {
+		return p, fmt.Errorf("rate limit %s: bad window %q", name, window)
+	}
+	if hasBurst {
+		if p.Burst, err = strconv.Atoi(burst); err != nil || p.Burst <= 0 {
+			return p, fmt.Errorf("rate limit %s: bad burst %q", name, burst)
+		}
+	}
+	return p, nil
+}
+
+func (p Policy) String() string {
+	return fmt.Sprintf("%d/%s:%d", p.Limit, p.Window, p.burst())
+}
+
+func (p Policy) burst() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// perSecond — скорость пополнения ведра
+func (p Policy) perSecond() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+// Decision — результат проверки запроса
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // когда появится следующий токен (для 429)
+}
+
+type bucket struct {
+	tokens float64
+	seen   time.Time
+}
+
+// Limiter — ведра одной политики по ключам (пользователь, ключ API, фасад, IP).
+// Состояние в памяти процесса: при нескольких инстансах лимит действует на каждый отдельно.
+type Limiter struct {
+	policy Policy
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func New(p Policy) *Limiter {
+	return &Limiter{policy: p, buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (l *Limiter) Policy() Policy {
+	return l.policy
+}
+
+// Allow списывает токен с ведра key, если он есть
+func (l *Limiter) Allow(key string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	burst := float64(l.policy.burst())
+	rate := l.policy.perSecond()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, seen: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.seen).Seconds()*rate)
+		b.seen = now
+	}
+
+	d := Decision{Limit: l.policy.Limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Allowed = true
+		d.Remaining = int(b.tokens)
+		return d
+	}
+	d.RetryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	return d
+}
+
+// sweep раз в окно выбрасывает ведра, которые за время простоя наполнились бы до краёв:
+// такое ведро неотличимо от нового, а карта не растёт от разовых IP
+func (l *Limiter) sweep(now time.Time) {
+	refill := time.Duration(float64(l.policy.burst()) / l.policy.perSecond() * float64(time.Second))
+	every := max(l.policy.Window, time.Minute)
+	if now.Sub(l.lastSweep) < every {
+		return
+	}
+	l.lastSweep = now
+	for k, b := range l.buckets {
+		if now.Sub(b.seen) >= refill {
+			delete(l.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "120/1m", want: "120/1m0s:120"},
+		{in: "120/1m:30", want: "120/1m0s:30"},
+		{in: " 10/15m:5 ", want: "10/15m0s:5"},
+		{in: "120", wantErr: true},
+		{in: "0/1m", wantErr: true},
+		{in: "-1/1m", wantErr: true},
+		{in: "10/0s", wantErr: true},
+		{in: "10/fortnight", wantErr: true},
+		{in: "10/1m:0", wantErr: true},
+		{in: "10/1m:x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			p, err := ParsePolicy("test", tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParsePolicy(%q) = %s, want error", tt.in, p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePolicy(%q): %v", tt.in, err)
+			}
+			if p.String() != tt.want {
+				t.Errorf("ParsePolicy(%q) = %s, want %s", tt.in, p, tt.want)
+			}
+		})
+	}
+}
+
+// clock — ручные часы лимитера
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(p Policy) (*Limiter, *clock) {
+	c := &clock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	l := New(p)
+	l.now = c.now
+	return l, c
+}
+
+func TestAllow(t *testing.T) {
+	// 60 в минуту — токен в секунду, всплеск 3
+	policy := Policy{Name: "test", Limit: 60, Window: time.Minute, Burst: 3}
+
+	type step struct {
+		wait      time.Duration
+		key       string
+		allowed   bool
+		remaining int
+		retry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"burst then deny", []step{
+			{key: "a", allowed: true, remaining: 2},
+			{key: "a", allowed: true, remaining: 1},
+			{key: "a", allowed: true, remaining: 0},
+			{key: "a", allowed: false, retry: time.Second},
+		}},
+		{"refill", []step{
+			{key: "a", allowed: true, remaining: 2},
+			{key: "a", allowed: true, remaining: 1},
+			{key: "a", allowed: true, remaining: 0},
+			{wait: 500 * time.Millisecond, key: "a", allowed: false, retry: 500 * time.Millisecond},
+			{wait: 500 * time.Millisecond, key: "a", allowed: true, remaining: 0},
+		}},
+		{"refill caps at burst", []step{
+			{key: "a", allowed: true, remaining: 2},
+			{wait: time.Hour, key: "a", allowed: true, remaining: 2},
+		}},
+		{"keys are independent", []step{
+			{key: "a", allowed: true, remaining: 2},
+			{key: "a", allowed: true, remaining: 1},
+			{key: "a", allowed: true, remaining: 0},
+			{key: "a", allowed: false, retry: time.Second},
+			{key: "b", allowed: true, remaining: 2},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, c := newTestLimiter(policy)
+			for i, s := range tt.steps {
+				c.advance(s.wait)
+				d := l.Allow(s.key)
+				if d.Allowed != s.allowed || d.Remaining != s.remaining || d.RetryAfter != s.retry || d.Limit != policy.Limit {
+					t.Fatalf("step %d: Allow(%q) = %+v, want allowed=%v remaining=%d retry=%s",
+						i, s.key, d, s.allowed, s.remaining, s.retry)
+				}
+			}
+		})
+	}
+}
+
+func TestSweep(t *testing.T) {
+	l, c := newTestLimiter(Policy{Name: "test", Limit: 10, Window: time.Minute})
+	l.Allow("once")
+	l.Allow("busy")
+
+	// через окно «once» наполнилось бы до краёв — выбрасывается; «busy» только что был
+	c.advance(time.Minute)
+	l.Allow("busy")
+	c.advance(time.Minute)
+	l.Allow("busy")
+
+	if _, ok := l.buckets["once"]; ok {
+		t.Error("idle bucket was not swept")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("active bucket was swept")
+	}
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mediawork/internal/models"
+)
+
+// AnalyticsRepository читает часовые агрегаты play_history_rollups,
+// поэтому отчёты работают и за периоды, сырые данные которых уже в архиве.
+type AnalyticsRepository struct {
+	db *sql.DB
+}
+
+func NewAnalyticsRepository(db *sql.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+//
+// ----------------------- CAMPAIGN TOTALS -----------------------
+//
+func (r *AnalyticsRepository) CampaignTotals(
+	ctx context.Context,
+	campaignID int64,
+	from, to time.Time,
+) (*models.CampaignDelivery, error) {
+
+	query := `
+        SELECT
+            COALESCE(SUM(plays), 0),
+            COALESCE(SUM(airtime_sec), 0),
+            COALESCE(SUM(bitrate_sum)::float8 / NULLIF(SUM(plays), 0), 0),
+            COALESCE(SUM(sync_latency_sum)::float8 / NULLIF(SUM(plays), 0), 0),
+            COALESCE(MAX(sync_latency_max), 0)
+        FROM play_history_rollups
+        WHERE granularity = 'hour'
+          AND campaign_id = $1
+          AND bucket_start >= $2
+          AND bucket_start < $3
+    `
+
+	d := models.CampaignDelivery{CampaignID: campaignID, From: from, To: to}
+	err := r.db.QueryRowContext(ctx, query, campaignID, from, to).Scan(
+		&d.Plays,
+		&d.AirtimeSec,
+		&d.AvgBitrateKbps,
+		&d.AvgSyncLatencyMS,
+		&d.MaxSyncLatencyMS,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+//
+// ----------------------- BREAKDOWN -----------------------
+//
+// by: facade | day | hour
+func (r *AnalyticsRepository) CampaignBreakdown(
+	ctx context.Context,
+	campaignID int64,
+	from, to time.Time,
+	by string,
+) ([]models.DeliveryBreakdownRow, error) {
+
+	metrics := `
+            SUM(ph.plays),
+            SUM(ph.airtime_sec),
+            COALESCE(SUM(ph.bitrate_sum)::float8 / NULLIF(SUM(ph.plays), 0), 0),
+            COALESCE(SUM(ph.sync_latency_sum)::float8 / NULLIF(SUM(ph.plays), 0), 0),
+            MAX(ph.sync_latency_max)
+    `
+	where := `
+        WHERE ph.granularity = 'hour'
+          AND ph.campaign_id = $1
+          AND ph.bucket_start >= $2
+          AND ph.bucket_start < $3
+    `
+
+	var query string
+	switch by {
+	case "facade":
+		query = `
+        SELECT ph.facade_id, COALESCE(f.name, ''),` + metrics + `
+        FROM play_history_rollups ph
+        LEFT JOIN facades f ON f.id = ph.facade_id` + where + `
+        GROUP BY ph.facade_id, f.name
+        ORDER BY SUM(ph.airtime_sec) DESC`
+	case "day", "hour":
+		query = `
+        SELECT date_trunc('` + by + `', ph.bucket_start) AS bucket,` + metrics + `
+        FROM play_history_rollups ph` + where + `
+        GROUP BY bucket
+        ORDER BY bucket ASC`
+	default:
+		return nil, fmt.Errorf("unknown breakdown %q", by)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, campaignID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.DeliveryBreakdownRow{}
+	for rows.Next() {
+		var row models.DeliveryBreakdownRow
+		dest := []any{
+			&row.Plays,
+			&row.AirtimeSec,
+			&row.AvgBitrateKbps,
+			&row.AvgSyncLatencyMS,
+			&row.MaxSyncLatencyMS,
+		}
+
+		if by == "facade" {
+			var facadeID int64
+			row.FacadeID = &facadeID
+			dest = append([]any{&facadeID, &row.FacadeName}, dest...)
+		} else {
+			var bucket time.Time
+			row.Bucket = &bucket
+			dest = append([]any{&bucket}, dest...)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		list = append(list, row)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"mediawork/internal/models"
+)
+
+// APIKeyRepository — API-ключи компаний (хранится sha256 ключа, не сам ключ)
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `
+    id, company_id, name, prefix, scopes, created_by, created_at, expires_at,
+    last_used_at, COALESCE(last_used_ip, ''), revoked_at, revoked_by
+`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*models.APIKey, error) {
+	var k models.APIKey
+	if err := row.Scan(
+		&k.ID,
+		&k.CompanyID,
+		&k.Name,
+		&k.Prefix,
+		pq.Array(&k.Scopes),
+		&k.CreatedBy,
+		&k.CreatedAt,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+		&k.LastUsedIP,
+		&k.RevokedAt,
+		&k.RevokedBy,
+	); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, k *models.APIKey, hash string) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO api_keys (company_id, name, prefix, key_hash, scopes, created_by, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at
+    `,
+		k.CompanyID,
+		k.Name,
+		k.Prefix,
+		hash,
+		pq.Array(k.Scopes),
+		k.CreatedBy,
+		k.ExpiresAt,
+	).Scan(&k.ID, &k.CreatedAt)
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id int64) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id))
+}
+
+// GetActiveByHash — действующий ключ: не отозван и не истёк
+func (r *APIKeyRepository) GetActiveByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx, `
+        SELECT `+apiKeyColumns+`
+        FROM api_keys
+        WHERE key_hash = $1
+          AND revoked_at IS NULL
+          AND (expires_at IS NULL OR expires_at > NOW())
+    `, hash))
+}
+
+// ListByCompany — все ключи компании, включая отозванные (для истории)
+func (r *APIKeyRepository) ListByCompany(ctx context.Context, companyID int64) ([]models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+apiKeyColumns+`
+        FROM api_keys
+        WHERE company_id = $1
+        ORDER BY revoked_at IS NOT NULL, id DESC
+    `, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *k)
+	}
+	return list, rows.Err()
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int64, revokedBy *int64) error {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE api_keys
+        SET revoked_at = NOW(), revoked_by = $2
+        WHERE id = $1 AND revoked_at IS NULL
+    `, id, revokedBy)
+	if err != nil {
+		return err
+	}
+	return expectOne(res)
+}
+
+// Touch отмечает использование ключа. Пишет не чаще раза в every,
+// чтобы поток запросов автоматизации не превращался в поток UPDATE.
+func (r *APIKeyRepository) Touch(ctx context.Context, id int64, ip string, every time.Duration) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE api_keys
+        SET last_used_at = NOW(), last_used_ip = NULLIF($2, '')
+        WHERE id = $1
+          AND (last_used_at IS NULL OR last_used_at < NOW() - make_interval(secs => $3))
+    `, id, ip, every.Seconds())
+	return err
+}
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"mediawork/internal/models"
+)
+
+type AudienceRepository struct {
+	db *sql.DB
+}
+
+func NewAudienceRepository(db *sql.DB) *AudienceRepository {
+	return &AudienceRepository{db: db}
+}
+
+//
+// --------------------- GET PROFILE ---------------------
+//
+func (r *AudienceRepository) GetProfile(ctx context.Context, facadeID int64) (*models.AudienceProfile, error) {
+	query := `
+        SELECT facade_id, timezone, visibility_factor, dwell_sec, updated_at
+        FROM facade_audience
+        WHERE facade_id = $1
+    `
+
+	var p models.AudienceProfile
+	err := r.db.QueryRowContext(ctx, query, facadeID).Scan(
+		&p.FacadeID,
+		&p.Timezone,
+		&p.VisibilityFactor,
+		&p.DwellSec,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT weekday, hour, footfall
+        FROM facade_audience_hours
+        WHERE facade_id = $1
+        ORDER BY weekday, hour
+    `, facadeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	p.Hours = []models.AudienceHour{}
+	for rows.Next() {
+		var h models.AudienceHour
+		if err := rows.Scan(&h.Weekday, &h.Hour, &h.Footfall); err != nil {
+			return nil, err
+		}
+		p.Hours = append(p.Hours, h)
+	}
+
+	return &p, rows.Err()
+}
+
+//
+// --------------------- SAVE PROFILE ---------------------
+//
+// Профиль заменяется целиком: настройки upsert-ом, часы — delete + insert в одной транзакции.
+func (r *AudienceRepository) SaveProfile(ctx context.Context, p *models.AudienceProfile) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO facade_audience (facade_id, timezone, visibility_factor, dwell_sec, updated_at)
+        VALUES ($1, $2, $3, $4, NOW())
+        ON CONFLICT (facade_id)
+        DO UPDATE SET
+            timezone = EXCLUDED.timezone,
+            visibility_factor = EXCLUDED.visibility_factor,
+            dwell_sec = EXCLUDED.dwell_sec,
+            updated_at = NOW()
+        RETURNING updated_at
+    `, p.FacadeID, p.Timezone, p.VisibilityFactor, p.DwellSec).Scan(&p.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM facade_audience_hours WHERE facade_id = $1`, p.FacadeID); err != nil {
+		return err
+	}
+
+	for _, h := range p.Hours {
+		_, err := tx.ExecContext(ctx, `
+            INSERT INTO facade_audience_hours (facade_id, weekday, hour, footfall)
+            VALUES ($1, $2, $3, $4)
+        `, p.FacadeID, h.Weekday, h.Hour, h.Footfall)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+//
+// --------------------- CAMPAIGN IMPRESSIONS BY FACADE ---------------------
+//
+// Для каждого часового бакета доставки:
+//   audience    = footfall(weekday, hour) * visibility_factor
+//   impressions = audience * (airtime_sec + plays * dwell_sec) / 3600
+//   reach       = audience * (1 - exp(-impressions / audience))   -- пуассоновское приближение
+// Часы профиля берутся в его timezone. Фасады без профиля дают 0 показов.
+// Cost считается по последнему активному rate card фасада.
+func (r *AudienceRepository) CampaignImpressions(
+	ctx context.Context,
+	campaignID int64,
+	from, to time.Time,
+) ([]models.FacadeImpressions, error) {
+
+	query := `
+        WITH h AS (
+            SELECT
+                ph.facade_id,
+                ph.plays,
+                ph.airtime_sec,
+                COALESCE(ah.footfall, 0) * COALESCE(a.visibility_factor, 0) AS audience,
+                COALESCE(ah.footfall, 0) * COALESCE(a.visibility_factor, 0)
+                    * (ph.airtime_sec + ph.plays * COALESCE(a.dwell_sec, 0)) / 3600.0 AS impressions
+            FROM play_history_rollups ph
+            LEFT JOIN facade_audience a ON a.facade_id = ph.facade_id
+            LEFT JOIN facade_audience_hours ah
+                ON ah.facade_id = ph.facade_id
+               AND ah.weekday = EXTRACT(DOW FROM ph.bucket_start AT TIME ZONE COALESCE(a.timezone, 'UTC'))
+               AND ah.hour = EXTRACT(HOUR FROM ph.bucket_start AT TIME ZONE COALESCE(a.timezone, 'UTC'))
+            WHERE ph.granularity = 'hour'
+              AND ph.campaign_id = $1
+              AND ph.bucket_start >= $2
+              AND ph.bucket_start < $3
+        ),
+        agg AS (
+            SELECT
+                facade_id,
+                SUM(plays) AS plays,
+                SUM(airtime_sec) AS airtime_sec,
+                SUM(impressions) AS impressions,
+                SUM(CASE WHEN audience > 0
+                         THEN audience * (1 - EXP(-impressions / audience))
+                         ELSE 0 END) AS reach
+            FROM h
+            GROUP BY facade_id
+        )
+        SELECT
+            agg.facade_id,
+            COALESCE(f.name, ''),
+            agg.plays,
+            agg.airtime_sec,
+            agg.impressions,
+            agg.reach,
+            COALESCE(rc.cost_per_second, 0) * agg.airtime_sec
+                + COALESCE(rc.cost_per_spot, 0) * agg.plays,
+            COALESCE(rc.currency, '')
+        FROM agg
+        LEFT JOIN facades f ON f.id = agg.facade_id
+        LEFT JOIN LATERAL (
+            SELECT cost_per_second, cost_per_spot, currency
+            FROM rate_cards
+            WHERE facade_id = agg.facade_id AND is_active
+            ORDER BY created_at DESC
+            LIMIT 1
+        ) rc ON TRUE
+        ORDER BY agg.impressions DESC
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, campaignID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.FacadeImpressions{}
+	for rows.Next() {
+		var fi models.FacadeImpressions
+		if err := rows.Scan(
+			&fi.FacadeID,
+			&fi.FacadeName,
+			&fi.Plays,
+			&fi.AirtimeSec,
+			&fi.Impressions,
+			&fi.Reach,
+			&fi.Cost,
+			&fi.Currency,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, fi)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mediawork/internal/models"
+)
+
+// AuditRepository — только вставка и чтение; UPDATE/DELETE на audit_log запрещены триггером
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func nullJSON(v json.RawMessage) any {
+	if len(v) == 0 {
+		return nil
+	}
+	return []byte(v)
+}
+
+//
+// --------------------- INSERT ---------------------
+//
+func (r *AuditRepository) Insert(ctx context.Context, e *models.AuditEntry) error {
+	diff, err := json.Marshal(e.Diff)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO audit_log (
+            actor_user_id, actor_email, company_id, action, entity_type, entity_id,
+            before_data, after_data, diff, request_id, ip, impersonator_id, created_at
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
+        RETURNING id, created_at
+    `
+	return r.db.QueryRowContext(ctx, query,
+		e.ActorUserID,
+		e.ActorEmail,
+		e.CompanyID,
+		e.Action,
+		e.EntityType,
+		e.EntityID,
+		nullJSON(e.Before),
+		nullJSON(e.After),
+		diff,
+		e.RequestID,
+		e.IP,
+		e.ImpersonatorID,
+	).Scan(&e.ID, &e.CreatedAt)
+}
+
+//
+// --------------------- LIST (FILTERED) ---------------------
+//
+func (r *AuditRepository) List(ctx context.Context, f models.AuditFilter) ([]models.AuditEntry, error) {
+	var where []string
+	var args []any
+
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.EntityType != "" {
+		add("entity_type = $%d", f.EntityType)
+	}
+	if f.EntityID != nil {
+		add("entity_id = $%d", *f.EntityID)
+	}
+	if f.ActorID != nil {
+		add("actor_user_id = $%d", *f.ActorID)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.From != nil {
+		add("created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("created_at < $%d", *f.To)
+	}
+
+	query := `
+        SELECT id, actor_user_id, COALESCE(actor_email, ''), company_id, action, entity_type, entity_id,
+               before_data, after_data, diff, COALESCE(request_id, ''), COALESCE(ip, ''),
+               impersonator_id, created_at
+        FROM audit_log
+    `
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	args = append(args, f.Limit, f.Offset)
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		var before, after, diff []byte
+		if err := rows.Scan(
+			&e.ID,
+			&e.ActorUserID,
+			&e.ActorEmail,
+			&e.CompanyID,
+			&e.Action,
+			&e.EntityType,
+			&e.EntityID,
+			&before,
+			&after,
+			&diff,
+			&e.RequestID,
+			&e.IP,
+			&e.ImpersonatorID,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		e.Before = before
+		e.After = after
+		if len(diff) > 0 {
+			if err := json.Unmarshal(diff, &e.Diff); err != nil {
+				return nil, err
+			}
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,366 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+)
+
+// BudgetRepository — бюджеты кампаний / компаний, предоплаченные кошельки
+// и снятие кампаний с расписания, когда деньги или эфир закончились
+type BudgetRepository struct {
+	db *sql.DB
+}
+
+func NewBudgetRepository(db *sql.DB) *BudgetRepository {
+	return &BudgetRepository{db: db}
+}
+
+var ErrInsufficientFunds = errors.New("wallet balance would become negative")
+
+const budgetColumns = `
+    id, company_id, campaign_id, currency, amount_limit, airtime_limit_sec,
+    amount_spent, airtime_spent_sec, updated_at
+`
+
+// budgetExhausted — условие исчерпания бюджета b (SQL)
+const budgetExhausted = `
+    ((b.amount_limit IS NOT NULL AND b.amount_spent >= b.amount_limit)
+     OR (b.airtime_limit_sec IS NOT NULL AND b.airtime_spent_sec >= b.airtime_limit_sec))
+`
+
+// budgetCrossed — после списания ($2 денег, $3 секунд) бюджет b упёрся в лимит, а до него — нет (SQL)
+const budgetCrossed = `
+    ((b.amount_limit IS NOT NULL AND b.amount_spent >= b.amount_limit AND b.amount_spent - $2 < b.amount_limit)
+     OR (b.airtime_limit_sec IS NOT NULL AND b.airtime_spent_sec >= b.airtime_limit_sec
+         AND b.airtime_spent_sec - $3 < b.airtime_limit_sec))
+`
+
+// campaignExhausted — кампания c не может показываться: исчерпан её бюджет,
+// бюджет компании или предоплаченный кошелёк пуст
+const campaignExhausted = `(
+    EXISTS (SELECT 1 FROM budgets b WHERE b.campaign_id = c.id AND ` + budgetExhausted + `)
+    OR EXISTS (SELECT 1 FROM budgets b WHERE b.company_id = c.company_id AND b.campaign_id IS NULL AND ` + budgetExhausted + `)
+    OR EXISTS (SELECT 1 FROM company_wallets w WHERE w.company_id = c.company_id AND w.balance <= 0)
+)`
+
+func scanBudget(row interface{ Scan(...any) error }) (*models.Budget, error) {
+	var b models.Budget
+	if err := row.Scan(
+		&b.ID, &b.CompanyID, &b.CampaignID, &b.Currency, &b.AmountLimit, &b.AirtimeLimitSec,
+		&b.AmountSpent, &b.AirtimeSpentSec, &b.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	b.Exhausted = (b.AmountLimit != nil && b.AmountSpent.Cmp(*b.AmountLimit) >= 0) ||
+		(b.AirtimeLimitSec != nil && b.AirtimeSpentSec >= *b.AirtimeLimitSec)
+	return &b, nil
+}
+
+//
+// --------------------- BUDGETS ---------------------
+//
+// GetCampaignBudget / GetCompanyBudget — sql.ErrNoRows, если бюджет не задан
+func (r *BudgetRepository) GetCampaignBudget(ctx context.Context, campaignID int64) (*models.Budget, error) {
+	return scanBudget(r.db.QueryRowContext(ctx,
+		`SELECT `+budgetColumns+` FROM budgets WHERE campaign_id = $1`, campaignID))
+}
+
+func (r *BudgetRepository) GetCompanyBudget(ctx context.Context, companyID int64) (*models.Budget, error) {
+	return scanBudget(r.db.QueryRowContext(ctx,
+		`SELECT `+budgetColumns+` FROM budgets WHERE company_id = $1 AND campaign_id IS NULL`, companyID))
+}
+
+// SetBudget создаёт или меняет лимиты; потраченное сохраняется
+func (r *BudgetRepository) SetBudget(ctx context.Context, b *models.Budget) error {
+	conflict := `(company_id) WHERE campaign_id IS NULL`
+	if b.CampaignID != nil {
+		conflict = `(campaign_id) WHERE campaign_id IS NOT NULL`
+	}
+	row := r.db.QueryRowContext(ctx, `
+        INSERT INTO budgets (company_id, campaign_id, currency, amount_limit, airtime_limit_sec, updated_at)
+        VALUES ($1, $2, $3, $4, $5, NOW())
+        ON CONFLICT `+conflict+`
+        DO UPDATE SET currency = EXCLUDED.currency,
+                      amount_limit = EXCLUDED.amount_limit,
+                      airtime_limit_sec = EXCLUDED.airtime_limit_sec,
+                      updated_at = NOW()
+        RETURNING `+budgetColumns,
+		b.CompanyID, b.CampaignID, b.Currency, b.AmountLimit, b.AirtimeLimitSec,
+	)
+	saved, err := scanBudget(row)
+	if err != nil {
+		return err
+	}
+	*b = *saved
+	return nil
+}
+
+func (r *BudgetRepository) DeleteCampaignBudget(ctx context.Context, campaignID int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE campaign_id = $1`, campaignID)
+	return err
+}
+
+func (r *BudgetRepository) DeleteCompanyBudget(ctx context.Context, companyID int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE company_id = $1 AND campaign_id IS NULL`, companyID)
+	return err
+}
+
+// ExhaustedAt — когда кампания снята с расписания по бюджету (nil — показывается)
+func (r *BudgetRepository) ExhaustedAt(ctx context.Context, campaignID int64) (*time.Time, error) {
+	var at *time.Time
+	err := r.db.QueryRowContext(ctx,
+		`SELECT budget_exhausted_at FROM campaigns WHERE id = $1`, campaignID,
+	).Scan(&at)
+	return at, err
+}
+
+//
+// --------------------- PLAY PRICE ---------------------
+//
+// PlayPrice — цена показа по активной карточке тарифа фасада: за выход + за секунду.
+// ok = false — у фасада нет тарифа (списывается только эфир).
+func (r *BudgetRepository) PlayPrice(ctx context.Context, facadeID int64, seconds int64) (money.Decimal, string, bool, error) {
+	var spot, perSecond money.Decimal
+	var currency string
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COALESCE(cost_per_spot, 0), COALESCE(cost_per_second, 0), currency
+        FROM rate_cards
+        WHERE facade_id = $1 AND is_active
+        ORDER BY created_at DESC
+        LIMIT 1
+    `, facadeID).Scan(&spot, &perSecond, &currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return money.Zero, "", false, nil
+	}
+	if err != nil {
+		return money.Zero, "", false, err
+	}
+	return spot.Add(perSecond.Mul(money.NewFromInt(seconds))), currency, true, nil
+}
+
+//
+// --------------------- CONSUME ---------------------
+//
+// Consume списывает показ с бюджета кампании, бюджета компании и кошелька (что из них есть).
+// Показ уже состоялся, поэтому отказать нельзя: кошелёк может уйти в минус на один показ.
+// crossed — списание перевело бюджет или кошелёк через порог исчерпания; только тогда
+// нужен Reevaluate, чтобы снять кампании с расписания.
+func (r *BudgetRepository) Consume(ctx context.Context, c *models.PlayCharge) (crossed bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	for _, q := range []struct {
+		where  string
+		id     int64
+		amount money.Decimal
+	}{
+		{"campaign_id = $1", c.CampaignID, c.CampaignAmount},
+		{"company_id = $1 AND campaign_id IS NULL", c.CompanyID, c.CompanyAmount},
+	} {
+		var hit bool
+		err := tx.QueryRowContext(ctx, `
+        UPDATE budgets b
+        SET amount_spent = amount_spent + $2,
+            airtime_spent_sec = airtime_spent_sec + $3,
+            updated_at = NOW()
+        WHERE `+q.where+`
+        RETURNING `+budgetCrossed, q.id, q.amount, c.Seconds).Scan(&hit)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return false, err
+		}
+		crossed = crossed || hit
+	}
+
+	if c.WalletAmount.Sign() > 0 {
+		t := &models.WalletTransaction{
+			CompanyID:     c.CompanyID,
+			Kind:          "charge",
+			Amount:        c.WalletAmount.Neg(),
+			CampaignID:    &c.CampaignID,
+			PlayHistoryID: &c.PlayHistoryID,
+		}
+		if err := walletMove(ctx, tx, t, true); err != nil {
+			return false, err
+		}
+		// баланс был положительным (до списания BalanceAfter - Amount), а стал <= 0
+		if t.ID != 0 && t.BalanceAfter.Sign() <= 0 && t.BalanceAfter.Sub(t.Amount).Sign() > 0 {
+			crossed = true
+		}
+	}
+
+	return crossed, tx.Commit()
+}
+
+// Reevaluate снимает с расписания кампании компании, упёршиеся в бюджет / пустой кошелёк,
+// и возвращает те, у которых ограничение снято (пополнили, подняли лимит)
+func (r *BudgetRepository) Reevaluate(ctx context.Context, companyID int64) (exhausted, resumed []int64, err error) {
+	if exhausted, err = collectIDs(r.db.QueryContext(ctx, `
+        UPDATE campaigns c
+        SET budget_exhausted_at = NOW()
+        WHERE c.company_id = $1
+          AND c.budget_exhausted_at IS NULL
+          AND `+campaignExhausted+`
+        RETURNING c.id
+    `, companyID)); err != nil {
+		return nil, nil, err
+	}
+	if resumed, err = collectIDs(r.db.QueryContext(ctx, `
+        UPDATE campaigns c
+        SET budget_exhausted_at = NULL
+        WHERE c.company_id = $1
+          AND c.budget_exhausted_at IS NOT NULL
+          AND NOT `+campaignExhausted+`
+        RETURNING c.id
+    `, companyID)); err != nil {
+		return nil, nil, err
+	}
+	return exhausted, resumed, nil
+}
+
+func collectIDs(rows *sql.Rows, err error) ([]int64, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+//
+// --------------------- WALLETS ---------------------
+//
+// GetWallet — sql.ErrNoRows, если компания не на предоплате
+func (r *BudgetRepository) GetWallet(ctx context.Context, companyID int64) (*models.Wallet, error) {
+	var w models.Wallet
+	err := r.db.QueryRowContext(ctx, `
+        SELECT company_id, currency, balance, updated_at
+        FROM company_wallets
+        WHERE company_id = $1
+    `, companyID).Scan(&w.CompanyID, &w.Currency, &w.Balance, &w.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// TopUp пополняет кошелёк (создаёт его в currency при первом пополнении)
+// или корректирует баланс (kind = adjustment, сумма со знаком)
+func (r *BudgetRepository) TopUp(ctx context.Context, t *models.WalletTransaction, currency string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO company_wallets (company_id, currency, balance, updated_at)
+        VALUES ($1, $2, 0, NOW())
+        ON CONFLICT (company_id) DO NOTHING
+    `, t.CompanyID, currency); err != nil {
+		return err
+	}
+	if err := walletMove(ctx, tx, t, false); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// walletMove меняет баланс под блокировкой и пишет движение. allowNegative — списание
+// за уже состоявшийся показ; ручная корректировка в минус не допускается.
+func walletMove(ctx context.Context, tx *sql.Tx, t *models.WalletTransaction, allowNegative bool) error {
+	var balance money.Decimal
+	if err := tx.QueryRowContext(ctx, `
+        SELECT balance FROM company_wallets WHERE company_id = $1 FOR UPDATE
+    `, t.CompanyID).Scan(&balance); err != nil {
+		if errors.Is(err, sql.ErrNoRows) && allowNegative {
+			// кошелька нет — компания на постоплате, списывать не с чего
+			return nil
+		}
+		return err
+	}
+
+	t.BalanceAfter = balance.Add(t.Amount)
+	if t.BalanceAfter.Sign() < 0 && !allowNegative {
+		return ErrInsufficientFunds
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE company_wallets SET balance = $2, updated_at = NOW() WHERE company_id = $1
+    `, t.CompanyID, t.BalanceAfter); err != nil {
+		return err
+	}
+	return tx.QueryRowContext(ctx, `
+        INSERT INTO wallet_transactions (
+            company_id, kind, amount, balance_after, campaign_id, play_history_id,
+            reference, note, created_by
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9)
+        RETURNING id, created_at
+    `,
+		t.CompanyID,
+		t.Kind,
+		t.Amount,
+		t.BalanceAfter,
+		t.CampaignID,
+		t.PlayHistoryID,
+		t.Reference,
+		t.Note,
+		t.CreatedBy,
+	).Scan(&t.ID, &t.CreatedAt)
+}
+
+// ListTransactions — движения по кошельку, новые сверху
+func (r *BudgetRepository) ListTransactions(ctx context.Context, companyID int64, limit, offset int) ([]models.WalletTransaction, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, company_id, kind, amount, balance_after, campaign_id, play_history_id,
+               COALESCE(reference, ''), COALESCE(note, ''), created_by, created_at
+        FROM wallet_transactions
+        WHERE company_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT $2 OFFSET $3
+    `, companyID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.WalletTransaction{}
+	for rows.Next() {
+		var t models.WalletTransaction
+		if err := rows.Scan(
+			&t.ID,
+			&t.CompanyID,
+			&t.Kind,
+			&t.Amount,
+			&t.BalanceAfter,
+			&t.CampaignID,
+			&t.PlayHistoryID,
+			&t.Reference,
+			&t.Note,
+			&t.CreatedBy,
+			&t.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
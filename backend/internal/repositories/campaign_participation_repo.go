@@ -73,11 +73,11 @@ func (r *CampaignParticipationRepository) GetFacadesForCampaign(
 ) ([]models.Facade, error) {
 
     query := `
-        SELECT f.id, f.name, f.city, f.address, f.width_px, f.height_px, f.status, f.created_at
+        SELECT f.id, f.name, COALESCE(f.address, ''), f.resolution_x, f.resolution_y, f.status, f.created_at
         FROM facades f
         JOIN campaign_participation cp ON cp.facade_id = f.id
         WHERE cp.campaign_id = $1
-        ORDER BY f.city, f.name
+        ORDER BY f.name
     `
 
     rows, err := r.db.QueryContext(ctx, query, campaignID)
@@ -121,11 +121,11 @@ func (r *CampaignParticipationRepository) GetCampaignsForFacade(
             c.id,
             c.company_id,
             c.name,
-            c.description,
-            c.start_time,
-            c.end_time,
+            COALESCE(c.external_ref, ''),
+            c.start_at,
+            c.end_at,
             c.status,
-            c.priority,
+            0 AS priority,
             c.created_at
         FROM campaigns c
         JOIN campaign_participation cp ON cp.campaign_id = c.id
@@ -3,10 +3,18 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 
 	"mediawork/internal/models"
 )
 
+// ErrCampaignSuspended — кампания на паузе из-за приостановки компании (деактивация или
+// просрочка по счетам): вывести её из паузы или запустить другую можно только возобновлением
+var ErrCampaignSuspended = errors.New("campaign is paused while the company is suspended")
+
+// campaignRunning — статусы, при которых кампания идёт в эфир или ждёт своего слота
+const campaignRunning = `('scheduled', 'active', 'live')`
+
 type CampaignRepository struct {
 	db *sql.DB
 }
@@ -83,23 +91,44 @@ func (r *CampaignRepository) GetByID(ctx context.Context, id int64) (*models.Cam
     return &c, nil
 }
 
-func (r *CampaignRepository) List(ctx context.Context) ([]models.Campaign, error) {
-    query := `
+// CampaignList — фильтры и сортировки списка кампаний; дата — начало показа
+var CampaignList = ListSpec[models.Campaign]{
+    ID: func(c models.Campaign) int64 { return c.ID },
+    Sorts: map[string]Field[models.Campaign]{
+        "created_at": {Column: "created_at", Value: func(c models.Campaign) any { return c.CreatedAt }},
+        "start_time": {Column: "start_at", Value: func(c models.Campaign) any { return c.StartTime }},
+        "end_time":   {Column: "end_at", Value: func(c models.Campaign) any { return c.EndTime }},
+        "name":       {Column: "name", Value: func(c models.Campaign) any { return c.Name }},
+    },
+    DefaultSort: "created_at",
+    DefaultDesc: true,
+    Status:      &Field[models.Campaign]{Column: "status", Value: func(c models.Campaign) any { return c.Status }},
+    Company:     &Field[models.Campaign]{Column: "company_id", Value: func(c models.Campaign) any { return c.CompanyID }},
+    Date:        &Field[models.Campaign]{Column: "start_at", Value: func(c models.Campaign) any { return c.StartTime }},
+    Search: []Field[models.Campaign]{
+        {Column: "name", Value: func(c models.Campaign) any { return c.Name }},
+        {Column: "COALESCE(external_ref, '')", Value: func(c models.Campaign) any { return c.Description }},
+    },
+}
+
+// --------------------- LIST ---------------------
+// List — страница кампаний по CampaignList (до q.Limit+1 строк в порядке обхода)
+func (r *CampaignRepository) List(ctx context.Context, q models.ListQuery) ([]models.Campaign, error) {
+    query, args := CampaignList.Query(`
         SELECT
             id,
             company_id,
             name,
-            external_ref,
+            COALESCE(external_ref, ''),
             start_at,
             end_at,
             status,
             0 AS priority,
             created_at
         FROM campaigns
-        ORDER BY created_at DESC
-    `
+    `, q)
 
-    rows, err := r.db.QueryContext(ctx, query)
+    rows, err := r.db.QueryContext(ctx, query, args...)
     if err != nil {
         return nil, err
     }
@@ -128,3 +157,48 @@ func (r *CampaignRepository) List(ctx context.Context) ([]models.Campaign, error
 
     return list, nil
 }
+
+// --------------------- UPDATE ---------------------
+// Пока у компании открыта приостановка, кампанию нельзя вывести из паузы или запустить
+func (r *CampaignRepository) Update(ctx context.Context, c *models.Campaign) error {
+    query := `
+        UPDATE campaigns
+        SET name = $1,
+            external_ref = $2,
+            start_at = $3,
+            end_at = $4,
+            status = $5,
+            updated_at = NOW()
+        WHERE id = $6
+          AND NOT (
+              ((status = 'paused' AND $5 <> 'paused') OR ($5 IN ` + campaignRunning + ` AND $5 <> status))
+              AND EXISTS (
+                  SELECT 1 FROM company_suspensions s
+                  WHERE s.company_id = campaigns.company_id AND s.reactivated_at IS NULL
+              )
+          )
+    `
+    res, err := r.db.ExecContext(ctx, query,
+        c.Name,
+        c.Description,
+        c.StartTime,
+        c.EndTime,
+        c.Status,
+        c.ID,
+    )
+    if err != nil {
+        return err
+    }
+    if n, _ := res.RowsAffected(); n > 0 {
+        return nil
+    }
+
+    var exists bool
+    if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM campaigns WHERE id = $1)`, c.ID).Scan(&exists); err != nil {
+        return err
+    }
+    if exists {
+        return ErrCampaignSuspended
+    }
+    return sql.ErrNoRows
+}
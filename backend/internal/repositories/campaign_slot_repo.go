@@ -16,12 +16,12 @@ func NewCampaignSlotRepository(db *sql.DB) *CampaignSlotRepository {
 
 func (r *CampaignSlotRepository) Insert(ctx context.Context, slot *models.CampaignSlot) error {
     query := `
-        INSERT INTO campaign_slots (campaign_id, facade_id, start_time, end_time, priority)
-        VALUES($1, $2, $3, $4, $5)
+        INSERT INTO campaign_slots (campaign_id, facade_id, day_of_week, start_time, end_time, priority)
+        VALUES($1, NULLIF($2, 0), $3, $4, $5, $6)
         RETURNING id
     `
     return r.db.QueryRowContext(ctx, query,
-        slot.CampaignID, slot.FacadeID, slot.StartTime, slot.EndTime, slot.Priority,
+        slot.CampaignID, slot.FacadeID, slot.DayOfWeek, slot.StartTime, slot.EndTime, slot.Priority,
     ).Scan(&slot.ID)
 }
 
@@ -30,13 +30,16 @@ func (r *CampaignSlotRepository) ListByCampaign(ctx context.Context, id int64) (
         SELECT 
             id, 
             campaign_id, 
-            NULL AS facade_id, -- в таблице нет facade_id, ставим NULL
+            COALESCE(facade_id, 0), -- 0 — слот на все фасады кампании
+            day_of_week,
             start_time, 
             end_time, 
-            priority
-        FROM campaign_schedules
+            duration_sec,
+            priority,
+            created_at
+        FROM campaign_slots
         WHERE campaign_id = $1
-        ORDER BY priority DESC
+        ORDER BY priority DESC, day_of_week, start_time
     `
     rows, err := r.db.QueryContext(ctx, query, id)
     if err != nil {
@@ -50,10 +53,13 @@ func (r *CampaignSlotRepository) ListByCampaign(ctx context.Context, id int64) (
         if err := rows.Scan(
             &s.ID,
             &s.CampaignID,
-            &s.FacadeID, // будет NULL
+            &s.FacadeID,
+            &s.DayOfWeek,
             &s.StartTime,
             &s.EndTime,
+            &s.DurationSec,
             &s.Priority,
+            &s.CreatedAt,
         ); err != nil {
             return nil, err
         }
@@ -161,7 +161,7 @@ func (r *CampaignSlotsRepository) GetActiveSlotsForFacade(
             cs.start_time,
             cs.end_time,
             cs.priority,
-            c.media_url
+            COALESCE(c.media_url, '')
         FROM campaign_slots cs
         JOIN campaigns c ON c.id = cs.campaign_id
         JOIN campaign_participation cp ON cp.campaign_id = c.id
@@ -170,6 +170,8 @@ func (r *CampaignSlotsRepository) GetActiveSlotsForFacade(
           AND cs.start_time <= $3
           AND cs.end_time >= $3
           AND c.status = 'active'
+          AND NOT cs.suspended
+          AND c.budget_exhausted_at IS NULL
         ORDER BY cs.priority DESC
     `
 
@@ -201,15 +203,17 @@ func (r *CampaignSlotsRepository) GetActiveSlotsForFacade(
 
 func (r *CampaignSlotRepository) Create(ctx context.Context, slot *models.CampaignSlot) (int64, error) {
     query := `
-        INSERT INTO campaign_slots (campaign_id, facade_id, start_time, end_time)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO campaign_slots (campaign_id, facade_id, day_of_week, start_time, end_time, priority)
+        VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6)
         RETURNING id
     `
     err := r.db.QueryRowContext(ctx, query,
         slot.CampaignID,
         slot.FacadeID,
+        slot.DayOfWeek,
         slot.StartTime,
         slot.EndTime,
+        slot.Priority,
     ).Scan(&slot.ID)
 
     if err != nil {
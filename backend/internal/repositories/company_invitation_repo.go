@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"mediawork/internal/models"
+)
+
+type CompanyInvitationRepository struct {
+	db *sql.DB
+}
+
+func NewCompanyInvitationRepository(db *sql.DB) *CompanyInvitationRepository {
+	return &CompanyInvitationRepository{db: db}
+}
+
+const invitationColumns = `
+    i.id, i.company_id, c.name, i.email, i.role, COALESCE(i.invited_by, 0),
+    i.status, i.expires_at, i.responded_at, i.created_at
+`
+
+func scanInvitation(row interface{ Scan(...any) error }) (*models.CompanyInvitation, error) {
+	var inv models.CompanyInvitation
+	if err := row.Scan(
+		&inv.ID,
+		&inv.CompanyID,
+		&inv.CompanyName,
+		&inv.Email,
+		&inv.Role,
+		&inv.InvitedBy,
+		&inv.Status,
+		&inv.ExpiresAt,
+		&inv.RespondedAt,
+		&inv.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+//
+// --------------------- CREATE ---------------------
+//
+// Предыдущее ожидающее приглашение на тот же email в ту же компанию отзывается
+func (r *CompanyInvitationRepository) Create(ctx context.Context, inv *models.CompanyInvitation, tokenHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE company_invitations
+        SET status = 'revoked', responded_at = NOW()
+        WHERE company_id = $1 AND lower(email) = lower($2) AND status = 'pending'
+    `, inv.CompanyID, inv.Email); err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO company_invitations (company_id, email, role, token_hash, invited_by, status, expires_at)
+        VALUES ($1, $2, $3, $4, $5, 'pending', $6)
+        RETURNING id, status, created_at
+    `
+	if err := tx.QueryRowContext(ctx, query,
+		inv.CompanyID,
+		inv.Email,
+		inv.Role,
+		tokenHash,
+		inv.InvitedBy,
+		inv.ExpiresAt,
+	).Scan(&inv.ID, &inv.Status, &inv.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//
+// --------------------- GET ---------------------
+//
+func (r *CompanyInvitationRepository) GetByID(ctx context.Context, id int64) (*models.CompanyInvitation, error) {
+	query := `SELECT ` + invitationColumns + `
+        FROM company_invitations i
+        JOIN companies c ON c.id = i.company_id
+        WHERE i.id = $1
+    `
+	return scanInvitation(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *CompanyInvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.CompanyInvitation, error) {
+	query := `SELECT ` + invitationColumns + `
+        FROM company_invitations i
+        JOIN companies c ON c.id = i.company_id
+        WHERE i.token_hash = $1
+    `
+	return scanInvitation(r.db.QueryRowContext(ctx, query, tokenHash))
+}
+
+//
+// --------------------- LIST ---------------------
+//
+func (r *CompanyInvitationRepository) ListByCompany(ctx context.Context, companyID int64) ([]models.CompanyInvitation, error) {
+	query := `SELECT ` + invitationColumns + `
+        FROM company_invitations i
+        JOIN companies c ON c.id = i.company_id
+        WHERE i.company_id = $1
+        ORDER BY i.created_at DESC
+    `
+	return r.list(ctx, query, companyID)
+}
+
+// ListPendingByEmail — непросроченные приглашения для пользователя
+func (r *CompanyInvitationRepository) ListPendingByEmail(ctx context.Context, email string, now time.Time) ([]models.CompanyInvitation, error) {
+	query := `SELECT ` + invitationColumns + `
+        FROM company_invitations i
+        JOIN companies c ON c.id = i.company_id
+        WHERE lower(i.email) = lower($1) AND i.status = 'pending' AND i.expires_at > $2
+        ORDER BY i.created_at DESC
+    `
+	return r.list(ctx, query, email, now)
+}
+
+func (r *CompanyInvitationRepository) list(ctx context.Context, query string, args ...any) ([]models.CompanyInvitation, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.CompanyInvitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *inv)
+	}
+	return list, rows.Err()
+}
+
+//
+// --------------------- RESPOND ---------------------
+//
+// SetStatus меняет только ожидающее приглашение; false — если его уже обработали
+func (r *CompanyInvitationRepository) SetStatus(ctx context.Context, id int64, status string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE company_invitations
+        SET status = $1, responded_at = NOW()
+        WHERE id = $2 AND status = 'pending'
+    `, status, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// Accept помечает приглашение принятым и добавляет участника одной транзакцией.
+// Если пользователь уже состоит в компании, роль не понижается.
+func (r *CompanyInvitationRepository) Accept(ctx context.Context, inv *models.CompanyInvitation, userID int64) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+        UPDATE company_invitations
+        SET status = 'accepted', responded_at = NOW()
+        WHERE id = $1 AND status = 'pending'
+    `, inv.ID)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	var current string
+	err = tx.QueryRowContext(ctx,
+		`SELECT role FROM company_memberships WHERE company_id = $1 AND user_id = $2`,
+		inv.CompanyID, userID,
+	).Scan(&current)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO company_memberships (company_id, user_id, role) VALUES ($1, $2, $3)`,
+			inv.CompanyID, userID, inv.Role,
+		)
+	case err == nil && CompanyRoleRank(inv.Role) > CompanyRoleRank(current):
+		_, err = tx.ExecContext(ctx,
+			`UPDATE company_memberships SET role = $1 WHERE company_id = $2 AND user_id = $3`,
+			inv.Role, inv.CompanyID, userID,
+		)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
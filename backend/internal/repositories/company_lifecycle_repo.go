@@ -0,0 +1,408 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"mediawork/internal/models"
+)
+
+// CompanyLifecycleRepository — деактивация / реактивация компании со всеми последствиями.
+// Что именно было поставлено на паузу, запоминается в company_suspension_campaigns,
+// чтобы реактивация вернула ровно это состояние.
+type CompanyLifecycleRepository struct {
+	db *sql.DB
+}
+
+func NewCompanyLifecycleRepository(db *sql.DB) *CompanyLifecycleRepository {
+	return &CompanyLifecycleRepository{db: db}
+}
+
+var ErrAlreadyInState = errors.New("company is already in requested state")
+
+// Виды приостановки: деактивация компании админом и пауза за просрочку оплаты
+const (
+	SuspensionDeactivation = "deactivation"
+	SuspensionDunning      = "dunning"
+)
+
+// кампании в этих статусах считаются работающими и ставятся на паузу
+const runningCampaignStatuses = `('active', 'scheduled', 'live')`
+
+const suspensionColumns = `
+    id, company_id, kind, COALESCE(reason, ''), suspended_by, suspended_at,
+    reactivated_by, reactivated_at, paused_campaigns, suspended_slots
+`
+
+func scanSuspension(row interface{ Scan(...any) error }) (*models.CompanySuspension, error) {
+	var s models.CompanySuspension
+	if err := row.Scan(
+		&s.ID, &s.CompanyID, &s.Kind, &s.Reason, &s.SuspendedBy, &s.SuspendedAt,
+		&s.ReactivatedBy, &s.ReactivatedAt, &s.PausedCampaigns, &s.SuspendedSlots,
+	); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// suspend ставит на паузу работающие кампании компании и снимает их слоты с расписания
+func suspend(ctx context.Context, tx *sql.Tx, companyID int64, kind, reason string, actorID *int64) (*models.CompanySuspension, error) {
+	s := models.CompanySuspension{CompanyID: companyID, Kind: kind, Reason: reason, SuspendedBy: actorID}
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO company_suspensions (company_id, kind, reason, suspended_by, suspended_at)
+        VALUES ($1, $2, $3, $4, NOW())
+        RETURNING id, suspended_at
+    `, companyID, kind, reason, actorID).Scan(&s.ID, &s.SuspendedAt); err != nil {
+		return nil, err
+	}
+
+	// запоминаем прежний статус и ставим на паузу незавершённые кампании
+	res, err := tx.ExecContext(ctx, `
+        INSERT INTO company_suspension_campaigns (suspension_id, campaign_id, prev_status)
+        SELECT $1, id, status
+        FROM campaigns
+        WHERE company_id = $2
+          AND status IN `+runningCampaignStatuses+`
+          AND (end_at IS NULL OR end_at > NOW())
+    `, s.ID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	paused, _ := res.RowsAffected()
+	s.PausedCampaigns = int(paused)
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE campaigns c
+        SET status = 'paused', updated_at = NOW()
+        FROM company_suspension_campaigns sc
+        WHERE sc.suspension_id = $1 AND sc.campaign_id = c.id
+    `, s.ID); err != nil {
+		return nil, err
+	}
+
+	// слоты этих кампаний убираем из расписания (плеер их не видит)
+	res, err = tx.ExecContext(ctx, `
+        UPDATE campaign_slots cs
+        SET suspended = TRUE
+        FROM company_suspension_campaigns sc
+        WHERE sc.suspension_id = $1 AND sc.campaign_id = cs.campaign_id AND NOT cs.suspended
+    `, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	slots, _ := res.RowsAffected()
+	s.SuspendedSlots = int(slots)
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE company_suspensions SET paused_campaigns = $1, suspended_slots = $2 WHERE id = $3`,
+		s.PausedCampaigns, s.SuspendedSlots, s.ID,
+	); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// restore закрывает последнюю открытую приостановку вида kind и возвращает кампаниям
+// прежний статус, если их с тех пор не трогали (всё ещё paused) и они не закончились.
+// Если у компании открыта приостановка другого вида (деактивация при просрочке или
+// наоборот), кампании остаются на паузе и переходят к ней: снимается только та причина,
+// которую сняли. nil без ошибки — открытой приостановки нет.
+func restore(ctx context.Context, tx *sql.Tx, companyID int64, kind string, actorID *int64) (*models.CompanySuspension, error) {
+	s, err := scanSuspension(tx.QueryRowContext(ctx, `
+        UPDATE company_suspensions
+        SET reactivated_by = $3, reactivated_at = NOW()
+        WHERE id = (
+            SELECT id FROM company_suspensions
+            WHERE company_id = $1 AND kind = $2 AND reactivated_at IS NULL
+            ORDER BY suspended_at DESC
+            LIMIT 1
+        )
+        RETURNING `+suspensionColumns, companyID, kind, actorID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var heldBy int64
+	err = tx.QueryRowContext(ctx, `
+        SELECT id FROM company_suspensions
+        WHERE company_id = $1 AND reactivated_at IS NULL
+        ORDER BY suspended_at DESC
+        LIMIT 1
+    `, companyID).Scan(&heldBy)
+	switch {
+	case err == nil:
+		return s, hand(ctx, tx, s.ID, heldBy)
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE campaigns c
+        SET status = sc.prev_status, updated_at = NOW()
+        FROM company_suspension_campaigns sc
+        WHERE sc.suspension_id = $1
+          AND sc.campaign_id = c.id
+          AND c.status = 'paused'
+          AND (c.end_at IS NULL OR c.end_at > NOW())
+    `, s.ID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE campaign_slots cs
+        SET suspended = FALSE
+        FROM company_suspension_campaigns sc
+        WHERE sc.suspension_id = $1 AND sc.campaign_id = cs.campaign_id
+    `, s.ID); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// hand передаёт всё ещё приостановленные кампании закрытой приостановки from открытой to:
+// их слоты остаются вне расписания, а прежний статус вернёт снятие to
+func hand(ctx context.Context, tx *sql.Tx, from, to int64) error {
+	res, err := tx.ExecContext(ctx, `
+        INSERT INTO company_suspension_campaigns (suspension_id, campaign_id, prev_status)
+        SELECT $2, sc.campaign_id, sc.prev_status
+        FROM company_suspension_campaigns sc
+        JOIN campaigns c ON c.id = sc.campaign_id
+        WHERE sc.suspension_id = $1 AND c.status = 'paused'
+        ON CONFLICT (suspension_id, campaign_id) DO NOTHING
+    `, from, to)
+	if err != nil {
+		return err
+	}
+	n, _ := res.RowsAffected()
+	_, err = tx.ExecContext(ctx,
+		`UPDATE company_suspensions SET paused_campaigns = paused_campaigns + $1 WHERE id = $2`,
+		n, to,
+	)
+	return err
+}
+
+//
+// --------------------- DEACTIVATE ---------------------
+//
+func (r *CompanyLifecycleRepository) Deactivate(
+	ctx context.Context,
+	companyID int64,
+	reason string,
+	actorID *int64,
+) (*models.CompanySuspension, error) {
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE companies SET is_active = FALSE, updated_at = NOW() WHERE id = $1 AND is_active`,
+		companyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrAlreadyInState
+	}
+
+	s, err := suspend(ctx, tx, companyID, SuspensionDeactivation, reason, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//
+// --------------------- REACTIVATE ---------------------
+//
+// Пауза за просрочку (dunning) реактивацией не снимается — только оплатой: пока она
+// открыта, кампании, остановленные деактивацией, остаются на паузе (см. restore).
+func (r *CompanyLifecycleRepository) Reactivate(
+	ctx context.Context,
+	companyID int64,
+	actorID *int64,
+) (*models.CompanySuspension, error) {
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE companies SET is_active = TRUE, updated_at = NOW() WHERE id = $1 AND NOT is_active`,
+		companyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrAlreadyInState
+	}
+
+	s, err := restore(ctx, tx, companyID, SuspensionDeactivation, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		// деактивирована в обход lifecycle (старые данные) — восстанавливать нечего
+		s = &models.CompanySuspension{CompanyID: companyID, Kind: SuspensionDeactivation}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//
+// --------------------- DUNNING PAUSE / RESUME ---------------------
+//
+// PauseCampaigns ставит кампании на паузу без деактивации компании.
+// nil — приостановка этого вида уже открыта.
+func (r *CompanyLifecycleRepository) PauseCampaigns(ctx context.Context, companyID int64, kind, reason string) (*models.CompanySuspension, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// блокируем компанию, чтобы параллельный запуск не открыл вторую паузу
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM companies WHERE id = $1 FOR UPDATE`, companyID); err != nil {
+		return nil, err
+	}
+
+	var open bool
+	if err := tx.QueryRowContext(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM company_suspensions
+            WHERE company_id = $1 AND kind = $2 AND reactivated_at IS NULL
+        )
+    `, companyID, kind).Scan(&open); err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, nil
+	}
+
+	s, err := suspend(ctx, tx, companyID, kind, reason, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ResumeCampaigns снимает паузу вида kind. У деактивированной компании ничего не делает —
+// иначе кампании заработали бы в обход деактивации; nil — снимать нечего.
+func (r *CompanyLifecycleRepository) ResumeCampaigns(ctx context.Context, companyID int64, kind string) (*models.CompanySuspension, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var active bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT is_active FROM companies WHERE id = $1 FOR UPDATE`, companyID,
+	).Scan(&active); err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, nil
+	}
+
+	s, err := restore(ctx, tx, companyID, kind, nil)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenSuspensionCompanies — компании с незакрытой приостановкой вида kind
+func (r *CompanyLifecycleRepository) OpenSuspensionCompanies(ctx context.Context, kind string) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT DISTINCT company_id
+        FROM company_suspensions
+        WHERE kind = $1 AND reactivated_at IS NULL
+    `, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SuspensionCampaigns — кампании приостановки с прежним и текущим статусом
+func (r *CompanyLifecycleRepository) SuspensionCampaigns(ctx context.Context, suspensionID int64) ([]models.SuspendedCampaign, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT sc.campaign_id, sc.prev_status, c.status
+        FROM company_suspension_campaigns sc
+        JOIN campaigns c ON c.id = sc.campaign_id
+        WHERE sc.suspension_id = $1
+        ORDER BY sc.campaign_id
+    `, suspensionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.SuspendedCampaign{}
+	for rows.Next() {
+		var c models.SuspendedCampaign
+		if err := rows.Scan(&c.CampaignID, &c.PrevStatus, &c.Status); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+//
+// --------------------- HISTORY ---------------------
+//
+func (r *CompanyLifecycleRepository) ListSuspensions(ctx context.Context, companyID int64) ([]models.CompanySuspension, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+suspensionColumns+`
+        FROM company_suspensions
+        WHERE company_id = $1
+        ORDER BY suspended_at DESC
+    `, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.CompanySuspension{}
+	for rows.Next() {
+		s, err := scanSuspension(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *s)
+	}
+	return list, rows.Err()
+}
@@ -82,11 +82,11 @@ func (r *CompanyMembershipRepository) ListMembers(
 ) ([]models.CompanyMember, error) {
 
     query := `
-        SELECT cm.user_id, u.email, u.name, cm.role
+        SELECT cm.user_id, u.email, u.full_name, cm.role
         FROM company_memberships cm
         JOIN users u ON u.id = cm.user_id
         WHERE cm.company_id = $1
-        ORDER BY u.name ASC
+        ORDER BY u.full_name ASC
     `
     rows, err := r.db.QueryContext(ctx, query, companyID)
     if err != nil {
@@ -115,7 +115,7 @@ func (r *CompanyMembershipRepository) ListUserCompanies(
 ) ([]models.Company, error) {
 
     query := `
-        SELECT c.id, c.name, c.industry, c.created_at
+        SELECT c.id, c.name, COALESCE(c.industry, ''), c.created_at
         FROM company_memberships cm
         JOIN companies c ON c.id = cm.company_id
         WHERE cm.user_id = $1
@@ -162,14 +162,97 @@ func (r *CompanyMembershipRepository) CheckAccess(
         return false, err
     }
 
-    // Порядок важности ролей:
-    // owner > admin > editor > viewer
-    rank := map[string]int{
-        "viewer": 1,
-        "editor": 2,
-        "admin":  3,
-        "owner":  4,
+    return CompanyRoleRank(actual) >= CompanyRoleRank(required), nil
+}
+
+// Порядок важности ролей:
+// owner > admin > editor > viewer
+var companyRoleRank = map[string]int{
+    "viewer": 1,
+    "editor": 2,
+    "admin":  3,
+    "owner":  4,
+}
+
+// CompanyRoleRank — вес роли; 0 для неизвестной
+func CompanyRoleRank(role string) int {
+    return companyRoleRank[role]
+}
+
+//
+// --------------------- LIST USER MEMBERSHIPS (WITH ROLES) ---------------------
+//
+func (r *CompanyMembershipRepository) ListUserMemberships(
+    ctx context.Context,
+    userID int64,
+) ([]models.UserCompany, error) {
+
+    query := `
+        SELECT c.id, c.name, cm.role, c.is_active
+        FROM company_memberships cm
+        JOIN companies c ON c.id = cm.company_id
+        WHERE cm.user_id = $1
+        ORDER BY c.name ASC
+    `
+    rows, err := r.db.QueryContext(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    list := []models.UserCompany{}
+    for rows.Next() {
+        var uc models.UserCompany
+        if err := rows.Scan(&uc.CompanyID, &uc.Name, &uc.Role, &uc.IsActive); err != nil {
+            return nil, err
+        }
+        list = append(list, uc)
+    }
+
+    return list, rows.Err()
+}
+
+//
+// --------------------- TRANSFER OWNERSHIP ---------------------
+//
+// Новый владелец получает owner, прежний — admin; companies.owner_id меняется в той же транзакции
+func (r *CompanyMembershipRepository) TransferOwnership(
+    ctx context.Context,
+    companyID int64,
+    fromUserID int64,
+    toUserID int64,
+) error {
+
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx,
+        `UPDATE company_memberships SET role = 'admin' WHERE company_id = $1 AND user_id = $2`,
+        companyID, fromUserID,
+    ); err != nil {
+        return err
+    }
+
+    res, err := tx.ExecContext(ctx,
+        `UPDATE company_memberships SET role = 'owner' WHERE company_id = $1 AND user_id = $2`,
+        companyID, toUserID,
+    )
+    if err != nil {
+        return err
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+
+    if _, err := tx.ExecContext(ctx,
+        `UPDATE companies SET owner_id = $1, updated_at = NOW() WHERE id = $2`,
+        toUserID, companyID,
+    ); err != nil {
+        return err
     }
 
-    return rank[actual] >= rank[required], nil
+    return tx.Commit()
 }
@@ -47,17 +47,39 @@ func (r *CompanyRepository) GetByID(ctx context.Context, id int64) (*models.Comp
     return &c, nil
 }
 
+// CompanyList — фильтры и сортировки списка компаний; status — active | inactive, дата — создание
+var CompanyList = ListSpec[models.Company]{
+    ID: func(c models.Company) int64 { return c.ID },
+    Sorts: map[string]Field[models.Company]{
+        "created_at": {Column: "created_at", Value: func(c models.Company) any { return c.CreatedAt }},
+        "name":       {Column: "name", Value: func(c models.Company) any { return c.Name }},
+    },
+    DefaultSort: "created_at",
+    DefaultDesc: true,
+    Status: &Field[models.Company]{
+        Column: "CASE WHEN is_active THEN 'active' ELSE 'inactive' END",
+        Value: func(c models.Company) any {
+            if c.IsActive {
+                return "active"
+            }
+            return "inactive"
+        },
+    },
+    Date:   &Field[models.Company]{Column: "created_at", Value: func(c models.Company) any { return c.CreatedAt }},
+    Search: []Field[models.Company]{{Column: "name", Value: func(c models.Company) any { return c.Name }}},
+}
+
 //
-// --------------------- LIST ALL / PAGED ---------------------
+// --------------------- LIST ---------------------
 //
-func (r *CompanyRepository) List(ctx context.Context, limit, offset int) ([]models.Company, error) {
-    query := `
+// List — страница компаний по CompanyList (до q.Limit+1 строк в порядке обхода)
+func (r *CompanyRepository) List(ctx context.Context, q models.ListQuery) ([]models.Company, error) {
+    query, args := CompanyList.Query(`
         SELECT id, name, owner_id, is_active, created_at
         FROM companies
-        ORDER BY created_at DESC
-        LIMIT $1 OFFSET $2
-    `
-    rows, err := r.db.QueryContext(ctx, query, limit, offset)
+    `, q)
+
+    rows, err := r.db.QueryContext(ctx, query, args...)
     if err != nil {
         return nil, err
     }
@@ -71,7 +93,7 @@ func (r *CompanyRepository) List(ctx context.Context, limit, offset int) ([]mode
         }
         list = append(list, c)
     }
-    return list, nil
+    return list, rows.Err()
 }
 
 //
@@ -141,3 +163,38 @@ func (r *CompanyRepository) SetActive(ctx context.Context, id int64, active bool
     _, err := r.db.ExecContext(ctx, query, active, id)
     return err
 }
+
+//
+// ----------------------- BILLING SETTINGS -----------------------
+//
+// Валюта счетов и налоговая юрисдикция (ставку ищет TaxRateRepository)
+func (r *CompanyRepository) GetBilling(ctx context.Context, id int64) (*models.CompanyBilling, error) {
+    b := models.CompanyBilling{CompanyID: id}
+    err := r.db.QueryRowContext(ctx, `
+        SELECT billing_currency, COALESCE(tax_jurisdiction, ''), tax_exempt
+        FROM companies
+        WHERE id = $1
+    `, id).Scan(&b.Currency, &b.TaxJurisdiction, &b.TaxExempt)
+    if err != nil {
+        return nil, err
+    }
+    return &b, nil
+}
+
+func (r *CompanyRepository) UpdateBilling(ctx context.Context, b *models.CompanyBilling) error {
+    res, err := r.db.ExecContext(ctx, `
+        UPDATE companies
+        SET billing_currency = $1,
+            tax_jurisdiction = NULLIF($2, ''),
+            tax_exempt = $3,
+            updated_at = NOW()
+        WHERE id = $4
+    `, b.Currency, b.TaxJurisdiction, b.TaxExempt, b.CompanyID)
+    if err != nil {
+        return err
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+    return nil
+}
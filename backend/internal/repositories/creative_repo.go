@@ -59,19 +59,33 @@ func (r *CreativeRepository) GetByID(ctx context.Context, id int64) (*models.Cre
     return &c, nil
 }
 
+// CreativeList — фильтры и сортировки списка роликов; дата — загрузка, статуса у ролика нет
+var CreativeList = ListSpec[models.Creative]{
+    ID: func(c models.Creative) int64 { return c.ID },
+    Sorts: map[string]Field[models.Creative]{
+        "uploaded_at": {Column: "uploaded_at", Value: func(c models.Creative) any { return c.UploadedAt }},
+        "filename":    {Column: "filename", Value: func(c models.Creative) any { return c.FileName }},
+        "duration":    {Column: "duration", Value: func(c models.Creative) any { return int64(c.Duration) }},
+    },
+    DefaultSort: "uploaded_at",
+    DefaultDesc: true,
+    Company:     &Field[models.Creative]{Column: "company_id", Value: func(c models.Creative) any { return c.CompanyID }},
+    Date:        &Field[models.Creative]{Column: "uploaded_at", Value: func(c models.Creative) any { return c.UploadedAt }},
+    Search:      []Field[models.Creative]{{Column: "filename", Value: func(c models.Creative) any { return c.FileName }}},
+}
+
 //
-// --------------------- LIST ALL ---------------------
+// --------------------- LIST ---------------------
 //
-func (r *CreativeRepository) List(ctx context.Context, limit, offset int) ([]models.Creative, error) {
-    query := `
+// List — страница роликов по CreativeList (до q.Limit+1 строк в порядке обхода)
+func (r *CreativeRepository) List(ctx context.Context, q models.ListQuery) ([]models.Creative, error) {
+    query, args := CreativeList.Query(`
         SELECT id, company_id, campaign_id, filename, file_type,
                duration, resolution, uploaded_at
         FROM creatives
-        ORDER BY uploaded_at DESC
-        LIMIT $1 OFFSET $2
-    `
+    `, q)
 
-    rows, err := r.db.QueryContext(ctx, query, limit, offset)
+    rows, err := r.db.QueryContext(ctx, query, args...)
     if err != nil {
         return nil, err
     }
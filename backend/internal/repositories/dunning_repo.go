@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"mediawork/internal/models"
+)
+
+// DunningRepository — выборки для просрочек и напоминаний по счетам
+type DunningRepository struct {
+	db *sql.DB
+}
+
+func NewDunningRepository(db *sql.DB) *DunningRepository {
+	return &DunningRepository{db: db}
+}
+
+const unpaidStatuses = `('pending', 'partially_paid', 'overdue')`
+
+//
+// --------------------- MARK OVERDUE ---------------------
+//
+// MarkOverdue переводит неоплаченные счета с прошедшим due_date в overdue
+func (r *DunningRepository) MarkOverdue(ctx context.Context, today time.Time) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        UPDATE invoices
+        SET status = 'overdue', updated_at = NOW()
+        WHERE status IN ('pending', 'partially_paid')
+          AND due_date IS NOT NULL
+          AND due_date < $1::date
+        RETURNING id
+    `, today)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+//
+// --------------------- UNPAID WITH DUE DATE ---------------------
+//
+// ListUnpaid — неоплаченные счета со сроком; DaysOverdue < 0 — до срока ещё столько дней
+func (r *DunningRepository) ListUnpaid(ctx context.Context, today time.Time) ([]models.OverdueInvoice, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, company_id, invoice_number, amount_total, amount_paid, amount_credited, currency,
+               status, due_date, ($1::date - due_date) AS days_overdue
+        FROM invoices
+        WHERE status IN `+unpaidStatuses+`
+          AND due_date IS NOT NULL
+        ORDER BY due_date ASC
+    `, today)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.OverdueInvoice{}
+	for rows.Next() {
+		var o models.OverdueInvoice
+		if err := rows.Scan(
+			&o.ID,
+			&o.CompanyID,
+			&o.InvoiceNumber,
+			&o.AmountTotal,
+			&o.AmountPaid,
+			&o.AmountCredited,
+			&o.Currency,
+			&o.Status,
+			&o.DueDate,
+			&o.DaysOverdue,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, o)
+	}
+	return list, rows.Err()
+}
+
+//
+// --------------------- REMINDERS ---------------------
+//
+// ClaimReminder отмечает напоминание (счёт + смещение в днях от due_date) как отправленное.
+// false — уже отправляли; так повторный запуск задачи не шлёт дубли.
+func (r *DunningRepository) ClaimReminder(ctx context.Context, invoiceID int64, offsetDays int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        INSERT INTO invoice_reminders (invoice_id, offset_days, sent_at)
+        VALUES ($1, $2, NOW())
+        ON CONFLICT (invoice_id, offset_days) DO NOTHING
+    `, invoiceID, offsetDays)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ReleaseReminder — откат ClaimReminder, если доставка не удалась
+func (r *DunningRepository) ReleaseReminder(ctx context.Context, invoiceID int64, offsetDays int) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM invoice_reminders WHERE invoice_id = $1 AND offset_days = $2`,
+		invoiceID, offsetDays,
+	)
+	return err
+}
+
+//
+// --------------------- PAUSE POLICY ---------------------
+//
+// CompaniesOverdue — компании, у которых есть счёт с просрочкой не меньше minDays
+func (r *DunningRepository) CompaniesOverdue(ctx context.Context, today time.Time, minDays int) (map[int64]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT DISTINCT company_id
+        FROM invoices
+        WHERE status IN `+unpaidStatuses+`
+          AND due_date IS NOT NULL
+          AND ($1::date - due_date) >= $2
+    `, today, minDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	set := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		set[id] = true
+	}
+	return set, rows.Err()
+}
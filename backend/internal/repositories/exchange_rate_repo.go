@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"mediawork/internal/models"
+)
+
+// ExchangeRateRepository — курсы валют, которые ведёт админ (1 base = rate quote на дату)
+type ExchangeRateRepository struct {
+	db *sql.DB
+}
+
+func NewExchangeRateRepository(db *sql.DB) *ExchangeRateRepository {
+	return &ExchangeRateRepository{db: db}
+}
+
+const exchangeRateColumns = `id, base, quote, rate, valid_on, created_by, created_at`
+
+func scanExchangeRate(row interface{ Scan(...any) error }) (*models.ExchangeRate, error) {
+	var e models.ExchangeRate
+	if err := row.Scan(&e.ID, &e.Base, &e.Quote, &e.Rate, &e.ValidOn, &e.CreatedBy, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+//
+// --------------------- UPSERT ---------------------
+//
+// Upsert — один курс на пару и дату; повторная запись исправляет курс
+func (r *ExchangeRateRepository) Upsert(ctx context.Context, e *models.ExchangeRate) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO exchange_rates (base, quote, rate, valid_on, created_by)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (base, quote, valid_on)
+        DO UPDATE SET rate = EXCLUDED.rate, created_by = EXCLUDED.created_by, created_at = NOW()
+        RETURNING id, created_at
+    `, e.Base, e.Quote, e.Rate, e.ValidOn, e.CreatedBy).Scan(&e.ID, &e.CreatedAt)
+}
+
+//
+// --------------------- FIND ---------------------
+//
+// Find — последний курс пары на дату (valid_on <= on); sql.ErrNoRows, если курса нет
+func (r *ExchangeRateRepository) Find(ctx context.Context, base, quote string, on time.Time) (*models.ExchangeRate, error) {
+	return scanExchangeRate(r.db.QueryRowContext(ctx, `
+        SELECT `+exchangeRateColumns+`
+        FROM exchange_rates
+        WHERE base = $1 AND quote = $2 AND valid_on <= $3
+        ORDER BY valid_on DESC
+        LIMIT 1
+    `, base, quote, on))
+}
+
+// List — курсы (фильтр по base / quote, пустое — любые), свежие сверху
+func (r *ExchangeRateRepository) List(ctx context.Context, base, quote string, limit, offset int) ([]models.ExchangeRate, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+exchangeRateColumns+`
+        FROM exchange_rates
+        WHERE ($1 = '' OR base = $1)
+          AND ($2 = '' OR quote = $2)
+        ORDER BY valid_on DESC, base, quote
+        LIMIT $3 OFFSET $4
+    `, base, quote, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.ExchangeRate{}
+	for rows.Next() {
+		e, err := scanExchangeRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *e)
+	}
+	return list, rows.Err()
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM exchange_rates WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"mediawork/internal/models"
+)
+
+// FacadeGroupRepository — динамические группы (dynamic_facade_groups).
+// Не путать со статическими facade_groups (город / таймзона площадки).
+type FacadeGroupRepository struct {
+	db *sql.DB
+}
+
+func NewFacadeGroupRepository(db *sql.DB) *FacadeGroupRepository {
+	return &FacadeGroupRepository{db: db}
+}
+
+//
+// --------------------- CREATE ---------------------
+//
+func (r *FacadeGroupRepository) Create(ctx context.Context, g *models.DynamicFacadeGroup) error {
+	filter, err := json.Marshal(g.Filter)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO dynamic_facade_groups (name, description, filter, created_at, updated_at)
+        VALUES ($1, $2, $3, NOW(), NOW())
+        RETURNING id, created_at, updated_at
+    `
+	return r.db.QueryRowContext(ctx, query, g.Name, g.Description, filter).
+		Scan(&g.ID, &g.CreatedAt, &g.UpdatedAt)
+}
+
+//
+// --------------------- GET BY ID ---------------------
+//
+func (r *FacadeGroupRepository) GetByID(ctx context.Context, id int64) (*models.DynamicFacadeGroup, error) {
+	query := `
+        SELECT id, name, description, filter, created_at, updated_at
+        FROM dynamic_facade_groups
+        WHERE id = $1
+    `
+
+	var g models.DynamicFacadeGroup
+	var filter []byte
+	err := r.db.QueryRowContext(ctx, query, id).
+		Scan(&g.ID, &g.Name, &g.Description, &filter, &g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(filter, &g.Filter); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+//
+// --------------------- LIST ---------------------
+//
+func (r *FacadeGroupRepository) List(ctx context.Context) ([]models.DynamicFacadeGroup, error) {
+	query := `
+        SELECT id, name, description, filter, created_at, updated_at
+        FROM dynamic_facade_groups
+        ORDER BY name ASC
+    `
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.DynamicFacadeGroup{}
+	for rows.Next() {
+		var g models.DynamicFacadeGroup
+		var filter []byte
+		if err := rows.Scan(&g.ID, &g.Name, &g.Description, &filter, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(filter, &g.Filter); err != nil {
+			return nil, err
+		}
+		list = append(list, g)
+	}
+	return list, rows.Err()
+}
+
+//
+// --------------------- UPDATE ---------------------
+//
+func (r *FacadeGroupRepository) Update(ctx context.Context, g *models.DynamicFacadeGroup) error {
+	filter, err := json.Marshal(g.Filter)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        UPDATE dynamic_facade_groups
+        SET name = $1,
+            description = $2,
+            filter = $3,
+            updated_at = NOW()
+        WHERE id = $4
+        RETURNING updated_at
+    `
+	return r.db.QueryRowContext(ctx, query, g.Name, g.Description, filter, g.ID).Scan(&g.UpdatedAt)
+}
+
+//
+// --------------------- DELETE ---------------------
+//
+// Автоматически добавленные группой участия кампаний удаляются вместе с ней
+// (ON DELETE CASCADE по campaign_participation.source_group_id).
+func (r *FacadeGroupRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM dynamic_facade_groups WHERE id = $1`, id)
+	return err
+}
+
+//
+// --------------------- MATCH FACADES ---------------------
+//
+func (r *FacadeGroupRepository) MatchFacades(ctx context.Context, f models.FacadeFilter) ([]int64, error) {
+	var where []string
+	var args []any
+
+	// сортируем ключи, чтобы текст запроса был стабильным
+	keys := make([]string, 0, len(f.Tags))
+	for k := range f.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		args = append(args, k, pq.Array(f.Tags[k]))
+		where = append(where, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM facade_tags t WHERE t.facade_id = f.id AND t.key = $%d AND t.value = ANY($%d))",
+			len(args)-1, len(args),
+		))
+	}
+
+	query := `SELECT f.id FROM facades f`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY f.id`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+//
+// --------------------- CAMPAIGN TARGETS ---------------------
+//
+func (r *FacadeGroupRepository) AddTarget(ctx context.Context, campaignID, groupID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO campaign_group_targets (campaign_id, group_id, created_at)
+        VALUES ($1, $2, NOW())
+        ON CONFLICT (campaign_id, group_id) DO NOTHING
+    `, campaignID, groupID)
+	return err
+}
+
+// RemoveTarget снимает таргетинг и убирает фасады, добавленные через эту группу
+func (r *FacadeGroupRepository) RemoveTarget(ctx context.Context, campaignID, groupID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM campaign_group_targets WHERE campaign_id = $1 AND group_id = $2`,
+		campaignID, groupID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM campaign_participation WHERE campaign_id = $1 AND source_group_id = $2`,
+		campaignID, groupID,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *FacadeGroupRepository) ListTargets(ctx context.Context, campaignID int64) ([]models.CampaignGroupTarget, error) {
+	return r.listTargets(ctx, `WHERE t.campaign_id = $1`, campaignID)
+}
+
+func (r *FacadeGroupRepository) ListAllTargets(ctx context.Context) ([]models.CampaignGroupTarget, error) {
+	return r.listTargets(ctx, ``)
+}
+
+func (r *FacadeGroupRepository) listTargets(ctx context.Context, where string, args ...any) ([]models.CampaignGroupTarget, error) {
+	query := `
+        SELECT
+            t.campaign_id,
+            t.group_id,
+            g.name,
+            (SELECT COUNT(*) FROM campaign_participation cp
+              WHERE cp.campaign_id = t.campaign_id AND cp.source_group_id = t.group_id),
+            t.created_at
+        FROM campaign_group_targets t
+        JOIN dynamic_facade_groups g ON g.id = t.group_id
+        ` + where + `
+        ORDER BY t.campaign_id, g.name
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.CampaignGroupTarget{}
+	for rows.Next() {
+		var t models.CampaignGroupTarget
+		if err := rows.Scan(&t.CampaignID, &t.GroupID, &t.GroupName, &t.Facades, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+//
+// --------------------- SYNC TARGET PARTICIPATION ---------------------
+//
+// Приводит campaign_participation к текущему составу группы: добавляет новые совпадения,
+// удаляет автоматически добавленные фасады, которые перестали подходить.
+// Фасады, привязанные к кампании вручную (source_group_id IS NULL), не трогаем.
+func (r *FacadeGroupRepository) SyncTarget(ctx context.Context, campaignID, groupID int64, facadeIDs []int64) (added, removed int64, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+        DELETE FROM campaign_participation
+        WHERE campaign_id = $1
+          AND source_group_id = $2
+          AND NOT (facade_id = ANY($3))
+    `, campaignID, groupID, pq.Array(facadeIDs))
+	if err != nil {
+		return 0, 0, err
+	}
+	removed, _ = res.RowsAffected()
+
+	res, err = tx.ExecContext(ctx, `
+        INSERT INTO campaign_participation (campaign_id, facade_id, source_group_id, created_at)
+        SELECT $1, ids.facade_id, $2, NOW()
+        FROM UNNEST($3::bigint[]) AS ids(facade_id)
+        ON CONFLICT (campaign_id, facade_id) DO NOTHING
+    `, campaignID, groupID, pq.Array(facadeIDs))
+	if err != nil {
+		return 0, 0, err
+	}
+	added, _ = res.RowsAffected()
+
+	return added, removed, tx.Commit()
+}
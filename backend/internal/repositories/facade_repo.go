@@ -4,6 +4,9 @@ import (
     "context"
     "database/sql"
     "mediawork/internal/models"
+    "time"
+
+    "github.com/lib/pq"
 )
 
 type FacadeRepository struct {
@@ -92,11 +95,31 @@ func (r *FacadeRepository) GetByID(ctx context.Context, id int64) (*models.Facad
     return &f, nil
 }
 
+// FacadeList — фильтры и сортировки списка фасадов; дата — регистрация фасада
+var FacadeList = ListSpec[models.Facade]{
+    ID: func(f models.Facade) int64 { return f.ID },
+    Sorts: map[string]Field[models.Facade]{
+        "created_at": {Column: "created_at", Value: func(f models.Facade) any { return f.CreatedAt }},
+        "code":       {Column: "code", Value: func(f models.Facade) any { return f.Code }},
+        "name":       {Column: "name", Value: func(f models.Facade) any { return f.Name }},
+    },
+    DefaultSort: "created_at",
+    DefaultDesc: true,
+    Status:      &Field[models.Facade]{Column: "status", Value: func(f models.Facade) any { return f.Status }},
+    Date:        &Field[models.Facade]{Column: "created_at", Value: func(f models.Facade) any { return f.CreatedAt }},
+    Search: []Field[models.Facade]{
+        {Column: "code", Value: func(f models.Facade) any { return f.Code }},
+        {Column: "name", Value: func(f models.Facade) any { return f.Name }},
+        {Column: "COALESCE(address, '')", Value: func(f models.Facade) any { return f.Address }},
+    },
+}
+
 //
 // --------------------- LIST ---------------------
 //
-func (r *FacadeRepository) List(ctx context.Context) ([]models.Facade, error) {
-    query := `
+// List — страница фасадов по FacadeList (до q.Limit+1 строк в порядке обхода)
+func (r *FacadeRepository) List(ctx context.Context, q models.ListQuery) ([]models.Facade, error) {
+    query, args := FacadeList.Query(`
         SELECT 
             id,
             code,
@@ -114,10 +137,9 @@ func (r *FacadeRepository) List(ctx context.Context) ([]models.Facade, error) {
             created_at,
             updated_at
         FROM facades
-        ORDER BY created_at DESC;
-    `
+    `, q)
 
-    rows, err := r.db.QueryContext(ctx, query)
+    rows, err := r.db.QueryContext(ctx, query, args...)
     if err != nil {
         return nil, err
     }
@@ -158,6 +180,8 @@ func (r *FacadeRepository) List(ctx context.Context) ([]models.Facade, error) {
 //
 // --------------------- LIST BY CITY ---------------------
 //
+// Город хранится у группы фасадов (facade_groups.city), у самого фасада его нет
+//
 func (r *FacadeRepository) ListByCity(ctx context.Context, city string) ([]models.Facade, error) {
     q := `
         SELECT 
@@ -177,7 +201,7 @@ func (r *FacadeRepository) ListByCity(ctx context.Context, city string) ([]model
             created_at,
             updated_at
         FROM facades
-        WHERE city = $1
+        WHERE group_id IN (SELECT id FROM facade_groups WHERE city = $1)
         ORDER BY created_at DESC
     `
 
@@ -219,6 +243,116 @@ func (r *FacadeRepository) ListByCity(ctx context.Context, city string) ([]model
     return result, nil
 }
 
+//
+// --------------------- LIST IN BBOX ---------------------
+//
+// Грубый прямоугольный фильтр; точную геометрию (радиус, полигон) досчитывает сервис
+//
+func (r *FacadeRepository) ListInBBox(
+    ctx context.Context,
+    minLat, minLon, maxLat, maxLon float64,
+) ([]models.Facade, error) {
+    q := `
+        SELECT 
+            id,
+            code,
+            name,
+            address,
+            latitude,
+            longitude,
+            resolution_x,
+            resolution_y,
+            virtual_rows,
+            virtual_cols,
+            status,
+            last_ping_at,
+            last_latency_ms,
+            created_at,
+            updated_at
+        FROM facades
+        WHERE latitude BETWEEN $1 AND $3
+          AND longitude BETWEEN $2 AND $4
+        ORDER BY id
+    `
+
+    rows, err := r.db.QueryContext(ctx, q, minLat, minLon, maxLat, maxLon)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    list := []models.Facade{}
+
+    for rows.Next() {
+        var f models.Facade
+
+        err := rows.Scan(
+            &f.ID,
+            &f.Code,
+            &f.Name,
+            &f.Address,
+            &f.Latitude,
+            &f.Longitude,
+            &f.WidthPx,
+            &f.HeightPx,
+            &f.Rows,
+            &f.Cols,
+            &f.Status,
+            &f.LastSeen,
+            &f.LatencyMS,
+            &f.CreatedAt,
+            &f.UpdatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+
+        list = append(list, f)
+    }
+
+    return list, nil
+}
+
+//
+// --------------------- BOOKED CAMPAIGNS IN WINDOW ---------------------
+//
+// Сколько кампаний (не черновики / не завершённые) занимают каждый фасад в [from, to)
+//
+func (r *FacadeRepository) CountBookedCampaigns(
+    ctx context.Context,
+    facadeIDs []int64,
+    from, to time.Time,
+) (map[int64]int, error) {
+    q := `
+        SELECT cp.facade_id, COUNT(DISTINCT c.id)
+        FROM campaign_participation cp
+        JOIN campaigns c ON c.id = cp.campaign_id
+        WHERE cp.facade_id = ANY($1)
+          AND c.status NOT IN ('draft', 'finished', 'cancelled')
+          AND (c.start_at IS NULL OR c.start_at < $3)
+          AND (c.end_at IS NULL OR c.end_at > $2)
+        GROUP BY cp.facade_id
+    `
+
+    rows, err := r.db.QueryContext(ctx, q, pq.Array(facadeIDs), from, to)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    result := map[int64]int{}
+    for rows.Next() {
+        var id int64
+        var n int
+        if err := rows.Scan(&id, &n); err != nil {
+            return nil, err
+        }
+        result[id] = n
+    }
+
+    return result, nil
+}
+
 //
 // --------------------- LIST BY COMPANY ---------------------
 //
@@ -241,8 +375,8 @@ func (r *FacadeRepository) ListByCompany(ctx context.Context, companyID int64) (
             f.created_at,
             f.updated_at
         FROM facades f
-        JOIN campaign_facades cf ON cf.facade_id = f.id
-        JOIN campaigns c ON c.id = cf.campaign_id
+        JOIN campaign_participation cp ON cp.facade_id = f.id
+        JOIN campaigns c ON c.id = cp.campaign_id
         WHERE c.company_id = $1
         ORDER BY f.name
     `
@@ -327,3 +461,102 @@ func (r *FacadeRepository) Delete(ctx context.Context, id int64) error {
     _, err := r.db.ExecContext(ctx, `DELETE FROM facades WHERE id = $1`, id)
     return err
 }
+
+//
+// --------------------- DEVICE TOKEN ---------------------
+//
+// SetDeviceTokenHash заменяет токен устройства фасада (хранится только хэш)
+func (r *FacadeRepository) SetDeviceTokenHash(ctx context.Context, facadeID int64, hash string) error {
+    res, err := r.db.ExecContext(ctx, `
+        UPDATE facades
+        SET device_token_hash = $2, device_token_issued_at = NOW(), updated_at = NOW()
+        WHERE id = $1
+    `, facadeID, hash)
+    if err != nil {
+        return err
+    }
+    n, err := res.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if n == 0 {
+        return sql.ErrNoRows
+    }
+    return nil
+}
+
+// FacadeIDByDeviceTokenHash — фасад по хэшу токена устройства (sql.ErrNoRows, если такого нет)
+func (r *FacadeRepository) FacadeIDByDeviceTokenHash(ctx context.Context, hash string) (int64, error) {
+    var id int64
+    err := r.db.QueryRowContext(ctx, `
+        SELECT id FROM facades WHERE device_token_hash = $1
+    `, hash).Scan(&id)
+    return id, err
+}
+
+//
+// --------------------- SYNC ONLINE STATUS (WATCHDOG) ---------------------
+//
+// SyncOnlineStatus переводит facades.status по heartbeat: без сигнала дольше silence — offline,
+// с сигналом — online. Возвращает только смены статуса (они же пишутся в facade_status_log).
+func (r *FacadeRepository) SyncOnlineStatus(ctx context.Context, silence time.Duration) ([]models.FacadeTransition, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    rows, err := tx.QueryContext(ctx, `
+        WITH went_offline AS (
+            UPDATE facades f
+            SET status = 'offline', updated_at = NOW()
+            WHERE f.status = 'online'
+              AND NOT EXISTS (
+                  SELECT 1 FROM facade_heartbeat h
+                  WHERE h.facade_id = f.id AND h.timestamp > NOW() - make_interval(secs => $1)
+              )
+            RETURNING f.id, f.code, f.name, f.status, f.last_ping_at
+        ),
+        went_online AS (
+            UPDATE facades f
+            SET status = 'online', last_ping_at = h.seen, updated_at = NOW()
+            FROM (
+                SELECT facade_id, MAX(timestamp) AS seen
+                FROM facade_heartbeat
+                WHERE timestamp > NOW() - make_interval(secs => $1)
+                GROUP BY facade_id
+            ) h
+            WHERE h.facade_id = f.id AND f.status <> 'online'
+            RETURNING f.id, f.code, f.name, f.status, f.last_ping_at
+        )
+        SELECT * FROM went_offline
+        UNION ALL
+        SELECT * FROM went_online
+    `, silence.Seconds())
+    if err != nil {
+        return nil, err
+    }
+
+    list := []models.FacadeTransition{}
+    for rows.Next() {
+        var t models.FacadeTransition
+        if err := rows.Scan(&t.FacadeID, &t.Code, &t.Name, &t.Status, &t.LastPingAt); err != nil {
+            rows.Close()
+            return nil, err
+        }
+        list = append(list, t)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    for _, t := range list {
+        if _, err := tx.ExecContext(ctx,
+            `INSERT INTO facade_status_log (facade_id, status) VALUES ($1, $2)`, t.FacadeID, t.Status,
+        ); err != nil {
+            return nil, err
+        }
+    }
+    return list, tx.Commit()
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+)
+
+type FacadeTagRepository struct {
+	db *sql.DB
+}
+
+func NewFacadeTagRepository(db *sql.DB) *FacadeTagRepository {
+	return &FacadeTagRepository{db: db}
+}
+
+//
+// --------------------- GET TAGS ---------------------
+//
+func (r *FacadeTagRepository) GetTags(ctx context.Context, facadeID int64) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT key, value FROM facade_tags WHERE facade_id = $1 ORDER BY key`,
+		facadeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := map[string]string{}
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		tags[k] = v
+	}
+	return tags, rows.Err()
+}
+
+//
+// --------------------- REPLACE TAGS ---------------------
+//
+func (r *FacadeTagRepository) ReplaceTags(ctx context.Context, facadeID int64, tags map[string]string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM facade_tags WHERE facade_id = $1`, facadeID); err != nil {
+		return err
+	}
+
+	for k, v := range tags {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO facade_tags (facade_id, key, value) VALUES ($1, $2, $3)`,
+			facadeID, k, v,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+//
+// --------------------- DISTINCT KEYS / VALUES ---------------------
+//
+// Для подсказок в UI при построении фильтра группы
+func (r *FacadeTagRepository) ListValues(ctx context.Context) (map[string][]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT DISTINCT key, value FROM facade_tags ORDER BY key, value`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := map[string][]string{}
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		values[k] = append(values[k], v)
+	}
+	return values, rows.Err()
+}
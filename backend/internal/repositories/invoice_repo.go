@@ -3,8 +3,14 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
 
 	"mediawork/internal/models"
+	"mediawork/internal/money"
 )
 
 type InvoiceRepository struct {
@@ -15,42 +21,358 @@ func NewInvoiceRepository(db *sql.DB) *InvoiceRepository {
 	return &InvoiceRepository{db: db}
 }
 
+// Виды документов. Выставленный документ не меняется (см. триггер invoices_immutable):
+// исправления — только кредит-нотой или корректировкой со ссылкой на исходный счёт.
+const (
+	DocInvoice    = "invoice"
+	DocCreditNote = "credit_note"
+	DocAdjustment = "adjustment"
+)
+
+// префиксы номеров; нумерация сквозная по компании и виду документа
+var documentPrefixes = map[string]string{
+	DocInvoice:    "INV",
+	DocCreditNote: "CN",
+	DocAdjustment: "ADJ",
+}
+
+var (
+	ErrOverCredit      = errors.New("credit exceeds the uncredited amount of the original invoice")
+	ErrNotCreditable   = errors.New("only invoices can be credited or adjusted")
+	ErrUnknownDocument = errors.New("unknown document type")
+)
+
+// nextNumber выдаёт следующий номер документа (INV-12-000042) под блокировкой строки счётчика
+func nextNumber(ctx context.Context, tx *sql.Tx, companyID int64, docType string) (string, error) {
+	prefix, ok := documentPrefixes[docType]
+	if !ok {
+		return "", ErrUnknownDocument
+	}
+
+	var n int64
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO document_sequences (company_id, document_type, last_value)
+        VALUES ($1, $2, 1)
+        ON CONFLICT (company_id, document_type)
+        DO UPDATE SET last_value = document_sequences.last_value + 1
+        RETURNING last_value
+    `, companyID, docType).Scan(&n); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%d-%06d", prefix, companyID, n), nil
+}
+
 // --------------------- CREATE ---------------------
+// Create выставляет счёт вместе со строками в одной транзакции; номер присваивается здесь
 func (r *InvoiceRepository) Create(ctx context.Context, inv *models.Invoice) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertDocument(ctx, tx, inv); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateAdjustment выставляет кредит-ноту / корректировку к счёту inv.OriginalInvoiceID.
+// credit > 0 зачитывается в исходный счёт (amount_credited): больше незачтённого остатка нельзя,
+// а полностью закрытый оплатами и зачётами счёт становится paid.
+func (r *InvoiceRepository) CreateAdjustment(ctx context.Context, inv *models.Invoice, credit money.Decimal) error {
+	if inv.OriginalInvoiceID == nil {
+		return ErrNotCreditable
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var total, credited money.Decimal
+	var docType string
+	if err := tx.QueryRowContext(ctx, `
+        SELECT amount_total, amount_credited, document_type
+        FROM invoices
+        WHERE id = $1
+        FOR UPDATE
+    `, *inv.OriginalInvoiceID).Scan(&total, &credited, &docType); err != nil {
+		return err
+	}
+	if docType != DocInvoice {
+		return ErrNotCreditable
+	}
+	if credit.Cmp(total.Sub(credited)) > 0 {
+		return ErrOverCredit
+	}
+
+	if err := insertDocument(ctx, tx, inv); err != nil {
+		return err
+	}
+
+	if credit.Sign() > 0 {
+		if _, err := tx.ExecContext(ctx, `
+            UPDATE invoices
+            SET amount_credited = amount_credited + $1,
+                status = CASE
+                    WHEN amount_paid + amount_credited + $1 >= amount_total THEN 'paid'
+                    ELSE status
+                END,
+                paid_at = CASE
+                    WHEN amount_paid + amount_credited + $1 >= amount_total THEN COALESCE(paid_at, NOW())
+                    ELSE paid_at
+                END,
+                updated_at = NOW()
+            WHERE id = $2
+        `, credit, *inv.OriginalInvoiceID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertDocument(ctx context.Context, tx *sql.Tx, inv *models.Invoice) error {
+	if inv.DocumentType == "" {
+		inv.DocumentType = DocInvoice
+	}
+	number, err := nextNumber(ctx, tx, inv.CompanyID, inv.DocumentType)
+	if err != nil {
+		return err
+	}
+	inv.InvoiceNumber = number
+
+	var refs models.AdjustmentRefs
+	if inv.Refs != nil {
+		refs = *inv.Refs
+	}
+
 	query := `
         INSERT INTO invoices (
             company_id,
             invoice_number,
+            document_type,
+            original_invoice_id,
+            reason,
             period_start,
             period_end,
+            amount_net,
+            amount_tax,
             amount_total,
             currency,
+            tax_jurisdiction,
             status,
             due_date,
             issued_at,
             paid_at,
+            facade_id,
+            outage_start,
+            outage_end,
             created_at,
             updated_at
         )
-        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,NOW(),NOW())
+        VALUES ($1,$2,$3,$4,NULLIF($5, ''),$6,$7,$8,$9,$10,$11,NULLIF($12, ''),$13,$14,$15,$16,$17,$18,$19,NOW(),NOW())
         RETURNING id, created_at, issued_at
     `
-	return r.db.QueryRowContext(ctx, query,
+	if err := tx.QueryRowContext(ctx, query,
 		inv.CompanyID,
 		inv.InvoiceNumber,
+		inv.DocumentType,
+		inv.OriginalInvoiceID,
+		inv.Reason,
 		inv.PeriodStart,
 		inv.PeriodEnd,
+		inv.AmountNet,
+		inv.AmountTax,
 		inv.AmountTotal,
 		inv.Currency,
+		inv.TaxJurisdiction,
 		inv.Status,
 		inv.DueDate,
 		inv.IssuedAt,
 		inv.PaidAt,
+		refs.FacadeID,
+		refs.OutageStart,
+		refs.OutageEnd,
 	).Scan(
 		&inv.ID,
 		&inv.CreatedAt,
 		&inv.IssuedAt,
-	)
+	); err != nil {
+		return err
+	}
+
+	for i := range inv.Lines {
+		l := &inv.Lines[i]
+		l.InvoiceID = inv.ID
+		if err := tx.QueryRowContext(ctx, `
+            INSERT INTO invoice_lines (
+                invoice_id, line_type, campaign_id, facade_id, description,
+                quantity, unit, unit_price, currency, fx_rate, tax_rate, tax_included,
+                amount, tax_amount, gross_amount
+            )
+            VALUES ($1,$2,$3,$4,$5,$6,NULLIF($7, ''),$8,$9,$10,$11,$12,$13,$14,$15)
+            RETURNING id, created_at
+        `,
+			l.InvoiceID,
+			l.LineType,
+			l.CampaignID,
+			l.FacadeID,
+			l.Description,
+			l.Quantity,
+			l.Unit,
+			l.UnitPrice,
+			l.Currency,
+			l.FXRate,
+			l.TaxRate,
+			l.TaxIncluded,
+			l.AmountNet,
+			l.AmountTax,
+			l.AmountGross,
+		).Scan(&l.ID, &l.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	if len(refs.PlayHistoryIDs) > 0 {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO invoice_play_refs (invoice_id, play_history_id)
+            SELECT $1, unnest($2::bigint[])
+            ON CONFLICT DO NOTHING
+        `, inv.ID, pq.Array(refs.PlayHistoryIDs)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --------------------- ADJUSTMENTS ---------------------
+// GetRefs — основание корректировки (фасад, период простоя, показы); nil у обычного счёта
+func (r *InvoiceRepository) GetRefs(ctx context.Context, invoiceID int64) (*models.AdjustmentRefs, error) {
+	var refs models.AdjustmentRefs
+	if err := r.db.QueryRowContext(ctx, `
+        SELECT facade_id, outage_start, outage_end
+        FROM invoices
+        WHERE id = $1
+    `, invoiceID).Scan(&refs.FacadeID, &refs.OutageStart, &refs.OutageEnd); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT play_history_id FROM invoice_play_refs WHERE invoice_id = $1 ORDER BY play_history_id
+    `, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		refs.PlayHistoryIDs = append(refs.PlayHistoryIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if refs.FacadeID == nil && refs.OutageStart == nil && len(refs.PlayHistoryIDs) == 0 {
+		return nil, nil
+	}
+	return &refs, nil
+}
+
+// ListAdjustments — кредит-ноты и корректировки к счёту, по порядку выставления
+func (r *InvoiceRepository) ListAdjustments(ctx context.Context, originalID int64) ([]models.Invoice, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id FROM invoices WHERE original_invoice_id = $1 ORDER BY issued_at, id
+    `, originalID)
+	if err != nil {
+		return nil, err
+	}
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	list := []models.Invoice{}
+	for _, id := range ids {
+		inv, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *inv)
+	}
+	return list, nil
+}
+
+// CountCompanyPlays — сколько из показов ids относятся к кампаниям компании
+// (проверка ссылок корректировки; архивированные ретеншеном показы не найдутся)
+func (r *InvoiceRepository) CountCompanyPlays(ctx context.Context, companyID int64, ids []int64) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(DISTINCT ph.id)
+        FROM play_history ph
+        JOIN campaigns c ON c.id = ph.campaign_id
+        WHERE ph.id = ANY($1::bigint[])
+          AND c.company_id = $2
+    `, pq.Array(ids), companyID).Scan(&n)
+	return n, err
+}
+
+// --------------------- LINES ---------------------
+func (r *InvoiceRepository) ListLines(ctx context.Context, invoiceID int64) ([]models.InvoiceLine, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, invoice_id, line_type, campaign_id, facade_id, description,
+               quantity, COALESCE(unit, ''), COALESCE(unit_price, 0), currency, fx_rate, tax_rate,
+               tax_included, amount, tax_amount, gross_amount, created_at
+        FROM invoice_lines
+        WHERE invoice_id = $1
+        ORDER BY id
+    `, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []models.InvoiceLine{}
+	for rows.Next() {
+		var l models.InvoiceLine
+		if err := rows.Scan(
+			&l.ID,
+			&l.InvoiceID,
+			&l.LineType,
+			&l.CampaignID,
+			&l.FacadeID,
+			&l.Description,
+			&l.Quantity,
+			&l.Unit,
+			&l.UnitPrice,
+			&l.Currency,
+			&l.FXRate,
+			&l.TaxRate,
+			&l.TaxIncluded,
+			&l.AmountNet,
+			&l.AmountTax,
+			&l.AmountGross,
+			&l.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
 }
 
 // --------------------- GET BY ID ---------------------
@@ -62,12 +384,20 @@ func (r *InvoiceRepository) GetByID(ctx context.Context, id int64) (*models.Invo
             invoice_number,
             period_start,
             period_end,
+            amount_net,
+            amount_tax,
             amount_total,
             currency,
+            COALESCE(tax_jurisdiction, ''),
             status,
             due_date,
             issued_at,
             paid_at,
+            amount_paid,
+            amount_credited,
+            document_type,
+            original_invoice_id,
+            COALESCE(reason, ''),
             created_at,
             updated_at
         FROM invoices
@@ -82,12 +412,20 @@ func (r *InvoiceRepository) GetByID(ctx context.Context, id int64) (*models.Invo
 		&inv.InvoiceNumber,
 		&inv.PeriodStart,
 		&inv.PeriodEnd,
+		&inv.AmountNet,
+		&inv.AmountTax,
 		&inv.AmountTotal,
 		&inv.Currency,
+		&inv.TaxJurisdiction,
 		&inv.Status,
 		&inv.DueDate,
 		&inv.IssuedAt,
 		&inv.PaidAt,
+		&inv.AmountPaid,
+		&inv.AmountCredited,
+		&inv.DocumentType,
+		&inv.OriginalInvoiceID,
+		&inv.Reason,
 		&inv.CreatedAt,
 		&inv.UpdatedAt,
 	)
@@ -98,28 +436,56 @@ func (r *InvoiceRepository) GetByID(ctx context.Context, id int64) (*models.Invo
 	return &inv, nil
 }
 
-// --------------------- LIST ALL ---------------------
-func (r *InvoiceRepository) List(ctx context.Context) ([]models.Invoice, error) {
-    query := `
+// InvoiceList — фильтры и сортировки списка документов; дата — выставление
+var InvoiceList = ListSpec[models.Invoice]{
+    ID: func(inv models.Invoice) int64 { return inv.ID },
+    Sorts: map[string]Field[models.Invoice]{
+        "created_at":     {Column: "created_at", Value: func(inv models.Invoice) any { return inv.CreatedAt }},
+        "issued_at":      {Column: "issued_at", Value: func(inv models.Invoice) any { return inv.IssuedAt }},
+        "amount_total":   {Column: "amount_total", Value: func(inv models.Invoice) any { return inv.AmountTotal }},
+        "invoice_number": {Column: "invoice_number", Value: func(inv models.Invoice) any { return inv.InvoiceNumber }},
+    },
+    DefaultSort: "created_at",
+    DefaultDesc: true,
+    Status:      &Field[models.Invoice]{Column: "status", Value: func(inv models.Invoice) any { return inv.Status }},
+    Company:     &Field[models.Invoice]{Column: "company_id", Value: func(inv models.Invoice) any { return inv.CompanyID }},
+    Date:        &Field[models.Invoice]{Column: "issued_at", Value: func(inv models.Invoice) any { return inv.IssuedAt }},
+    Search: []Field[models.Invoice]{
+        {Column: "invoice_number", Value: func(inv models.Invoice) any { return inv.InvoiceNumber }},
+        {Column: "COALESCE(reason, '')", Value: func(inv models.Invoice) any { return inv.Reason }},
+    },
+}
+
+// --------------------- LIST ---------------------
+// List — страница документов по InvoiceList (до q.Limit+1 строк в порядке обхода)
+func (r *InvoiceRepository) List(ctx context.Context, q models.ListQuery) ([]models.Invoice, error) {
+    query, args := InvoiceList.Query(`
         SELECT
             id,
             company_id,
             invoice_number,
             period_start,
             period_end,
+            amount_net,
+            amount_tax,
             amount_total,
             currency,
+            COALESCE(tax_jurisdiction, ''),
             status,
             due_date,
             issued_at,
             paid_at,
+            amount_paid,
+            amount_credited,
+            document_type,
+            original_invoice_id,
+            COALESCE(reason, ''),
             created_at,
             updated_at
         FROM invoices
-        ORDER BY created_at DESC
-    `
-    
-    rows, err := r.db.QueryContext(ctx, query)
+    `, q)
+
+    rows, err := r.db.QueryContext(ctx, query, args...)
     if err != nil {
         return nil, err
     }
@@ -135,12 +501,20 @@ func (r *InvoiceRepository) List(ctx context.Context) ([]models.Invoice, error)
             &inv.InvoiceNumber,
             &inv.PeriodStart,
             &inv.PeriodEnd,
+            &inv.AmountNet,
+            &inv.AmountTax,
             &inv.AmountTotal,
             &inv.Currency,
+            &inv.TaxJurisdiction,
             &inv.Status,
             &inv.DueDate,
             &inv.IssuedAt,
             &inv.PaidAt,
+            &inv.AmountPaid,
+            &inv.AmountCredited,
+            &inv.DocumentType,
+            &inv.OriginalInvoiceID,
+            &inv.Reason,
             &inv.CreatedAt,
             &inv.UpdatedAt,
         )
@@ -154,58 +528,6 @@ func (r *InvoiceRepository) List(ctx context.Context) ([]models.Invoice, error)
     return invoices, nil
 }
 
-
-// --------------------- LIST BY COMPANY ---------------------
-func (r *InvoiceRepository) ListByCompany(ctx context.Context, companyID int64) ([]models.Invoice, error) {
-	query := `
-        SELECT
-            id,
-            company_id,
-            invoice_number,
-            period_start,
-            period_end,
-            amount_total,
-            currency,
-            status,
-            issued_at,
-            paid_at,
-            created_at
-        FROM invoices
-        WHERE company_id = $1
-        ORDER BY created_at DESC
-    `
-
-	rows, err := r.db.QueryContext(ctx, query, companyID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	items := []models.Invoice{}
-
-	for rows.Next() {
-		var inv models.Invoice
-		if err := rows.Scan(
-			&inv.ID,
-			&inv.CompanyID,
-			&inv.InvoiceNumber,
-			&inv.PeriodStart,
-			&inv.PeriodEnd,
-			&inv.AmountTotal,
-			&inv.Currency,
-			&inv.Status,
-			&inv.IssuedAt,
-			&inv.PaidAt,
-			&inv.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, inv)
-	}
-
-	return items, nil
-}
-
 // --------------------- LIST BY STATUS ---------------------
 func (r *InvoiceRepository) ListByStatus(ctx context.Context, status string) ([]models.Invoice, error) {
 	query := `
@@ -215,11 +537,19 @@ func (r *InvoiceRepository) ListByStatus(ctx context.Context, status string) ([]
             invoice_number,
             period_start,
             period_end,
+            amount_net,
+            amount_tax,
             amount_total,
             currency,
+            COALESCE(tax_jurisdiction, ''),
             status,
             issued_at,
             paid_at,
+            amount_paid,
+            amount_credited,
+            document_type,
+            original_invoice_id,
+            COALESCE(reason, ''),
             created_at
         FROM invoices
         WHERE status = $1
@@ -241,11 +571,19 @@ func (r *InvoiceRepository) ListByStatus(ctx context.Context, status string) ([]
 			&inv.InvoiceNumber,
 			&inv.PeriodStart,
 			&inv.PeriodEnd,
+			&inv.AmountNet,
+			&inv.AmountTax,
 			&inv.AmountTotal,
 			&inv.Currency,
+			&inv.TaxJurisdiction,
 			&inv.Status,
 			&inv.IssuedAt,
 			&inv.PaidAt,
+			&inv.AmountPaid,
+			&inv.AmountCredited,
+			&inv.DocumentType,
+			&inv.OriginalInvoiceID,
+			&inv.Reason,
 			&inv.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -257,55 +595,38 @@ func (r *InvoiceRepository) ListByStatus(ctx context.Context, status string) ([]
 }
 
 // --------------------- UPDATE STATUS ---------------------
+// ErrStatusFromPayments — статус счёта с оплатами (paid, partially_paid) и кредитного
+// документа выводится из журнала оплат, вручную его не сменить
+var ErrStatusFromPayments = errors.New("invoice status follows recorded payments")
+
+// UpdateStatus — ручная смена статуса только между pending, overdue и failed.
+// pending для счёта с частичной оплатой (просроченного) становится partially_paid.
 func (r *InvoiceRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
 	query := `
         UPDATE invoices
-        SET status = $1,
-            paid_at = CASE WHEN $1 = 'paid' THEN NOW() ELSE paid_at END,
+        SET status = CASE
+                WHEN $1 = 'pending' AND amount_paid + amount_credited > 0 THEN 'partially_paid'
+                ELSE $1
+            END,
             updated_at = NOW()
         WHERE id = $2
+          AND status IN ('pending', 'overdue', 'failed')
+          AND $1 IN ('pending', 'overdue', 'failed')
     `
-	_, err := r.db.ExecContext(ctx, query, status, id)
-	return err
-}
-
-// --------------------- UPDATE FULL ---------------------
-func (r *InvoiceRepository) Update(ctx context.Context, inv *models.Invoice) error {
-	query := `
-        UPDATE invoices
-        SET 
-            invoice_number = $1,
-            period_start = $2,
-            period_end = $3,
-            amount_total = $4,
-            currency = $5,
-            status = $6,
-            due_date = $7,
-            issued_at = $8,
-            paid_at = $9,
-            updated_at = NOW()
-        WHERE id = $10
-    `
-
-	_, err := r.db.ExecContext(ctx, query,
-		inv.InvoiceNumber,
-		inv.PeriodStart,
-		inv.PeriodEnd,
-		inv.AmountTotal,
-		inv.Currency,
-		inv.Status,
-		inv.DueDate,
-		inv.IssuedAt,
-		inv.PaidAt,
-		inv.ID,
-	)
-	return err
-}
-
-// --------------------- DELETE ---------------------
-func (r *InvoiceRepository) Delete(ctx context.Context, id int64) error {
-	_, err := r.db.ExecContext(ctx, `DELETE FROM invoices WHERE id = $1`, id)
-	return err
+	res, err := r.db.ExecContext(ctx, query, status, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists bool
+		if err := r.db.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM invoices WHERE id = $1)`, id,
+		).Scan(&exists); err != nil || !exists {
+			return err
+		}
+		return ErrStatusFromPayments
+	}
+	return nil
 }
 
 // ---------- PDF DATA ----------
@@ -317,8 +638,11 @@ func (r *InvoiceRepository) PreparePDFData(ctx context.Context, invoiceID int64)
             invoice_number,
             period_start,
             period_end,
+            amount_net,
+            amount_tax,
             amount_total,
             currency,
+            COALESCE(tax_jurisdiction, ''),
             status,
             issued_at,
             paid_at,
@@ -334,8 +658,11 @@ func (r *InvoiceRepository) PreparePDFData(ctx context.Context, invoiceID int64)
 		&inv.InvoiceNumber,
 		&inv.PeriodStart,
 		&inv.PeriodEnd,
+		&inv.AmountNet,
+		&inv.AmountTax,
 		&inv.AmountTotal,
 		&inv.Currency,
+		&inv.TaxJurisdiction,
 		&inv.Status,
 		&inv.IssuedAt,
 		&inv.PaidAt,
@@ -352,22 +679,307 @@ func (r *InvoiceRepository) PreparePDFData(ctx context.Context, invoiceID int64)
 }
 
 // ---------- SUM FOR PERIOD (start, end) ----------
+// Суммы по валютам: складывать счета в разных валютах напрямую нельзя,
+// пересчёт в billing currency делает BillingService по таблице курсов.
 func (r *InvoiceRepository) CalculateAmountForPeriod(
     ctx context.Context,
     companyID int64,
     start string, // YYYY-MM-DD
     end string,   // YYYY-MM-DD
-) (float64, error) {
+) ([]models.CurrencyAmount, error) {
 
     query := `
-        SELECT COALESCE(SUM(amount_total), 0)
+        SELECT currency, COALESCE(SUM(amount_total), 0)
         FROM invoices
         WHERE company_id = $1
           AND period_start >= $2
           AND period_end   <= $3
+        GROUP BY currency
+        ORDER BY currency
     `
 
-    var sum float64
-    err := r.db.QueryRowContext(ctx, query, companyID, start, end).Scan(&sum)
-    return sum, err
+    rows, err := r.db.QueryContext(ctx, query, companyID, start, end)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    sums := []models.CurrencyAmount{}
+    for rows.Next() {
+        var a models.CurrencyAmount
+        if err := rows.Scan(&a.Currency, &a.Amount); err != nil {
+            return nil, err
+        }
+        sums = append(sums, a)
+    }
+    return sums, rows.Err()
+}
+
+//
+// --------------------- ACCOUNTING EXPORT ---------------------
+//
+const exportColumns = `
+    id, format, incremental, period_from, period_to,
+    invoice_id_from, invoice_id_to, payment_id_from, payment_id_to,
+    invoice_count, payment_count, created_by, created_at
+`
+
+func scanExport(row interface{ Scan(...any) error }) (*models.AccountingExport, error) {
+	var e models.AccountingExport
+	if err := row.Scan(
+		&e.ID,
+		&e.Format,
+		&e.Incremental,
+		&e.PeriodFrom,
+		&e.PeriodTo,
+		&e.InvoiceIDFrom,
+		&e.InvoiceIDTo,
+		&e.PaymentIDFrom,
+		&e.PaymentIDTo,
+		&e.InvoiceCount,
+		&e.PaymentCount,
+		&e.CreatedBy,
+		&e.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ExportBounds — верхние границы id для новой выгрузки. Строки моложе settle не берём:
+// id выдаются до COMMIT, и более ранний id может появиться в таблице позже более позднего.
+func (r *InvoiceRepository) ExportBounds(ctx context.Context, settle time.Duration) (invoiceID, paymentID int64, err error) {
+	err = r.db.QueryRowContext(ctx, `
+        SELECT
+            (SELECT COALESCE(MAX(id), 0) FROM invoices WHERE created_at <= NOW() - make_interval(secs => $1)),
+            (SELECT COALESCE(MAX(id), 0) FROM payments WHERE created_at <= NOW() - make_interval(secs => $1))
+    `, settle.Seconds()).Scan(&invoiceID, &paymentID)
+	return invoiceID, paymentID, err
+}
+
+// LastIncrementalExport — последняя инкрементальная выгрузка (sql.ErrNoRows — ещё не было)
+func (r *InvoiceRepository) LastIncrementalExport(ctx context.Context) (*models.AccountingExport, error) {
+	return scanExport(r.db.QueryRowContext(ctx, `
+        SELECT `+exportColumns+`
+        FROM accounting_exports
+        WHERE incremental
+        ORDER BY id DESC
+        LIMIT 1
+    `))
+}
+
+func (r *InvoiceRepository) CreateExport(ctx context.Context, e *models.AccountingExport) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO accounting_exports (
+            format, incremental, period_from, period_to,
+            invoice_id_from, invoice_id_to, payment_id_from, payment_id_to,
+            invoice_count, payment_count, created_by
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING id, created_at
+    `,
+		e.Format,
+		e.Incremental,
+		e.PeriodFrom,
+		e.PeriodTo,
+		e.InvoiceIDFrom,
+		e.InvoiceIDTo,
+		e.PaymentIDFrom,
+		e.PaymentIDTo,
+		e.InvoiceCount,
+		e.PaymentCount,
+		e.CreatedBy,
+	).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *InvoiceRepository) GetExport(ctx context.Context, id int64) (*models.AccountingExport, error) {
+	return scanExport(r.db.QueryRowContext(ctx,
+		`SELECT `+exportColumns+` FROM accounting_exports WHERE id = $1`, id))
+}
+
+func (r *InvoiceRepository) ListExports(ctx context.Context, limit, offset int) ([]models.AccountingExport, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+exportColumns+`
+        FROM accounting_exports
+        ORDER BY id DESC
+        LIMIT $1 OFFSET $2
+    `, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.AccountingExport{}
+	for rows.Next() {
+		e, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *e)
+	}
+	return list, rows.Err()
+}
+
+// ExportDocuments — документы выгрузки (id в (InvoiceIDFrom, InvoiceIDTo], дата выставления в периоде)
+// вместе со строками и реквизитами компании, по возрастанию id
+func (r *InvoiceRepository) ExportDocuments(ctx context.Context, e *models.AccountingExport) ([]models.ExportDocument, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT
+            i.id,
+            i.company_id,
+            i.invoice_number,
+            i.document_type,
+            i.original_invoice_id,
+            COALESCE(i.reason, ''),
+            i.period_start,
+            i.period_end,
+            i.amount_net,
+            i.amount_tax,
+            i.amount_total,
+            i.currency,
+            COALESCE(i.tax_jurisdiction, ''),
+            i.status,
+            i.due_date,
+            i.issued_at,
+            i.created_at,
+            c.name,
+            COALESCE(c.legal_name, ''),
+            COALESCE(c.vat_number, '')
+        FROM invoices i
+        JOIN companies c ON c.id = i.company_id
+        WHERE i.id > $1 AND i.id <= $2
+          AND ($3::timestamptz IS NULL OR i.issued_at >= $3)
+          AND ($4::timestamptz IS NULL OR i.issued_at < $4)
+        ORDER BY i.id
+    `, e.InvoiceIDFrom, e.InvoiceIDTo, e.PeriodFrom, e.PeriodTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := []models.ExportDocument{}
+	index := map[int64]int{}
+	for rows.Next() {
+		var d models.ExportDocument
+		if err := rows.Scan(
+			&d.ID,
+			&d.CompanyID,
+			&d.InvoiceNumber,
+			&d.DocumentType,
+			&d.OriginalInvoiceID,
+			&d.Reason,
+			&d.PeriodStart,
+			&d.PeriodEnd,
+			&d.AmountNet,
+			&d.AmountTax,
+			&d.AmountTotal,
+			&d.Currency,
+			&d.TaxJurisdiction,
+			&d.Status,
+			&d.DueDate,
+			&d.IssuedAt,
+			&d.CreatedAt,
+			&d.CompanyName,
+			&d.CompanyLegalName,
+			&d.CompanyVAT,
+		); err != nil {
+			return nil, err
+		}
+		d.Lines = []models.InvoiceLine{}
+		index[d.ID] = len(docs)
+		docs = append(docs, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return docs, nil
+	}
+
+	ids := make([]int64, 0, len(docs))
+	for _, d := range docs {
+		ids = append(ids, d.ID)
+	}
+	lines, err := r.db.QueryContext(ctx, `
+        SELECT id, invoice_id, line_type, campaign_id, facade_id, description,
+               quantity, COALESCE(unit, ''), COALESCE(unit_price, 0), currency, fx_rate, tax_rate,
+               tax_included, amount, tax_amount, gross_amount, created_at
+        FROM invoice_lines
+        WHERE invoice_id = ANY($1)
+        ORDER BY invoice_id, id
+    `, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var l models.InvoiceLine
+		if err := lines.Scan(
+			&l.ID,
+			&l.InvoiceID,
+			&l.LineType,
+			&l.CampaignID,
+			&l.FacadeID,
+			&l.Description,
+			&l.Quantity,
+			&l.Unit,
+			&l.UnitPrice,
+			&l.Currency,
+			&l.FXRate,
+			&l.TaxRate,
+			&l.TaxIncluded,
+			&l.AmountNet,
+			&l.AmountTax,
+			&l.AmountGross,
+			&l.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		d := &docs[index[l.InvoiceID]]
+		d.Lines = append(d.Lines, l)
+	}
+	return docs, lines.Err()
+}
+
+// ExportPayments — оплаты выгрузки (id в (PaymentIDFrom, PaymentIDTo], дата оплаты в периоде)
+func (r *InvoiceRepository) ExportPayments(ctx context.Context, e *models.AccountingExport) ([]models.ExportPayment, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT p.id, p.invoice_id, p.amount, p.currency, COALESCE(p.method, ''), COALESCE(p.reference, ''),
+               COALESCE(p.note, ''), p.paid_at, p.recorded_by, p.created_at,
+               i.company_id, i.invoice_number
+        FROM payments p
+        JOIN invoices i ON i.id = p.invoice_id
+        WHERE p.id > $1 AND p.id <= $2
+          AND ($3::timestamptz IS NULL OR p.paid_at >= $3)
+          AND ($4::timestamptz IS NULL OR p.paid_at < $4)
+        ORDER BY p.id
+    `, e.PaymentIDFrom, e.PaymentIDTo, e.PeriodFrom, e.PeriodTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.ExportPayment{}
+	for rows.Next() {
+		var p models.ExportPayment
+		if err := rows.Scan(
+			&p.ID,
+			&p.InvoiceID,
+			&p.Amount,
+			&p.Currency,
+			&p.Method,
+			&p.Reference,
+			&p.Note,
+			&p.PaidAt,
+			&p.RecordedBy,
+			&p.CreatedAt,
+			&p.CompanyID,
+			&p.InvoiceNumber,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
 }
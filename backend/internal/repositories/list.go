@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+)
+
+//
+// ---------- LIST SPECS ----------
+//
+// ListSpec описывает фильтры и сортировки списка одной сущности (models.ListQuery).
+// Одна спецификация обслуживает оба хранилища: Query строит SQL для PostgreSQL,
+// Apply — то же самое над строками в памяти, поэтому порядок и курсоры у них совпадают.
+type ListSpec[T any] struct {
+	ID          func(T) int64
+	Sorts       map[string]Field[T]
+	DefaultSort string
+	DefaultDesc bool
+
+	// фильтры; nil / пустой — список фильтр не поддерживает
+	Status  *Field[T]
+	Company *Field[T]
+	Role    *Field[T]
+	Date    *Field[T]
+	Search  []Field[T]
+}
+
+// Field — поле строки: выражение в SQL и значение в Go.
+// Value возвращает time.Time, string, int64 или money.Decimal и не должна падать на нулевом T.
+type Field[T any] struct {
+	Column string
+	Value  func(T) any
+}
+
+// SortNames — допустимые поля сортировки по алфавиту (для сообщений об ошибке)
+func (s ListSpec[T]) SortNames() []string {
+	names := make([]string, 0, len(s.Sorts))
+	for name := range s.Sorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Key — значение поля сортировки и id строки для курсора
+func (s ListSpec[T]) Key(item T, sortField string) (string, int64) {
+	return formatValue(s.Sorts[sortField].Value(item)), s.ID(item)
+}
+
+// CheckCursor — значение курсора разбирается в тип поля сортировки
+func (s ListSpec[T]) CheckCursor(c *models.Cursor) error {
+	f, ok := s.Sorts[c.Sort]
+	if !ok {
+		return fmt.Errorf("unknown sort field %q", c.Sort)
+	}
+	var zero T
+	_, err := parseValue(f.Value(zero), c.Value)
+	return err
+}
+
+// descending — порядок обхода: страница назад читается в обратном порядке от курсора
+func descending(q models.ListQuery) bool {
+	if q.Cursor != nil && q.Cursor.Backward {
+		return !q.Desc
+	}
+	return q.Desc
+}
+
+//
+// ---------- POSTGRESQL ----------
+//
+// Query дописывает к SELECT ... FROM фильтры, условие курсора, ORDER BY и LIMIT. Строк читается
+// на одну больше страницы (так сервис узнаёт, есть ли следующая), при странице назад — в обратном порядке.
+func (s ListSpec[T]) Query(base string, q models.ListQuery) (string, []any) {
+	var where []string
+	var args []any
+
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if q.Status != "" && s.Status != nil {
+		add(s.Status.Column+" = $%d", q.Status)
+	}
+	if q.CompanyID != nil && s.Company != nil {
+		add(s.Company.Column+" = $%d", *q.CompanyID)
+	}
+	if q.Role != "" && s.Role != nil {
+		add(s.Role.Column+" = $%d", q.Role)
+	}
+	if s.Date != nil {
+		if q.From != nil {
+			add(s.Date.Column+" >= $%d", *q.From)
+		}
+		if q.To != nil {
+			add(s.Date.Column+" < $%d", *q.To)
+		}
+	}
+	if text := strings.TrimSpace(q.Search); text != "" && len(s.Search) > 0 {
+		args = append(args, "%"+text+"%")
+		or := make([]string, len(s.Search))
+		for i, f := range s.Search {
+			or[i] = fmt.Sprintf("%s ILIKE $%d", f.Column, len(args))
+		}
+		where = append(where, "("+strings.Join(or, " OR ")+")")
+	}
+
+	column := s.Sorts[q.Sort].Column
+	desc := descending(q)
+	dir, op := "ASC", ">"
+	if desc {
+		dir, op = "DESC", "<"
+	}
+	if c := q.Cursor; c != nil {
+		args = append(args, c.Value, c.ID)
+		where = append(where, fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, op, len(args)-1, len(args)))
+	}
+
+	query := base
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	args = append(args, q.Limit+1)
+	query += fmt.Sprintf(` ORDER BY %s %s, id %s LIMIT $%d`, column, dir, dir, len(args))
+	return query, args
+}
+
+//
+// ---------- IN MEMORY ----------
+//
+// Apply — Query над строками в памяти: те же фильтры, порядок, курсор и Limit+1 строк
+func (s ListSpec[T]) Apply(list []T, q models.ListQuery) ([]T, error) {
+	text := strings.ToLower(strings.TrimSpace(q.Search))
+
+	var cursor any
+	if q.Cursor != nil {
+		var zero T
+		v, err := parseValue(s.Sorts[q.Sort].Value(zero), q.Cursor.Value)
+		if err != nil {
+			return nil, err
+		}
+		cursor = v
+	}
+
+	field := s.Sorts[q.Sort]
+	desc := descending(q)
+	// order — сравнение (значение, id) в направлении обхода
+	order := func(v any, id int64, cv any, cid int64) int {
+		c := compareValues(v, cv)
+		if c == 0 {
+			c = cmp.Compare(id, cid)
+		}
+		if desc {
+			c = -c
+		}
+		return c
+	}
+
+	out := []T{}
+	for _, item := range list {
+		if q.Status != "" && s.Status != nil && s.Status.Value(item) != q.Status {
+			continue
+		}
+		if q.CompanyID != nil && s.Company != nil && s.Company.Value(item) != *q.CompanyID {
+			continue
+		}
+		if q.Role != "" && s.Role != nil && s.Role.Value(item) != q.Role {
+			continue
+		}
+		if s.Date != nil && (q.From != nil || q.To != nil) {
+			at := s.Date.Value(item).(time.Time)
+			if (q.From != nil && at.Before(*q.From)) || (q.To != nil && !at.Before(*q.To)) {
+				continue
+			}
+		}
+		if text != "" && len(s.Search) > 0 && !s.matches(item, text) {
+			continue
+		}
+		if cursor != nil && order(field.Value(item), s.ID(item), cursor, q.Cursor.ID) <= 0 {
+			continue
+		}
+		out = append(out, item)
+	}
+
+	slices.SortStableFunc(out, func(a, b T) int {
+		return order(field.Value(a), s.ID(a), field.Value(b), s.ID(b))
+	})
+	if len(out) > q.Limit+1 {
+		out = out[:q.Limit+1]
+	}
+	return out, nil
+}
+
+func (s ListSpec[T]) matches(item T, text string) bool {
+	for _, f := range s.Search {
+		if v, ok := f.Value(item).(string); ok && strings.Contains(strings.ToLower(v), text) {
+			return true
+		}
+	}
+	return false
+}
+
+//
+// ---------- VALUES ----------
+//
+// formatValue / parseValue — значение поля сортировки в курсоре и обратно
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case money.Decimal:
+		return v.String()
+	case string:
+		return v
+	}
+	panic(fmt.Sprintf("list: unsupported sort value %T", v))
+}
+
+func parseValue(sample any, s string) (any, error) {
+	switch sample.(type) {
+	case time.Time:
+		return time.Parse(time.RFC3339Nano, s)
+	case int64:
+		return strconv.ParseInt(s, 10, 64)
+	case money.Decimal:
+		return money.Parse(s)
+	case string:
+		return s, nil
+	}
+	return nil, fmt.Errorf("list: unsupported sort value %T", sample)
+}
+
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case int64:
+		return cmp.Compare(a, b.(int64))
+	case money.Decimal:
+		return a.Cmp(b.(money.Decimal))
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	panic(fmt.Sprintf("list: unsupported sort value %T", a))
+}
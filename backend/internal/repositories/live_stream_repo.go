@@ -51,8 +51,52 @@ func (r *LiveStreamRepository) RegisterPlayEvent(
         event.BitrateKbps,
         event.SyncLatencyMS,
     ).Scan(&id)
+    if err != nil {
+        return err
+    }
 
-    return err
+    event.ID = id
+    return nil
+}
+
+//
+// ----------------------- COVERING SLOT -----------------------
+//
+// CoveringSlot — слот кампании, в который попадает показ фасада, закончившийся сейчас
+// и длившийся durationSec (время слотов — UTC). slotID = 0 — любой слот кампании.
+// Кампания должна быть активна, в своём периоде и не упираться в бюджет, фасад — участвовать
+// в ней. sql.ErrNoRows — такого слота нет.
+func (r *LiveStreamRepository) CoveringSlot(
+    ctx context.Context,
+    facadeID, campaignID, slotID int64,
+    durationSec int,
+) (int64, error) {
+
+    query := `
+        WITH t AS (SELECT NOW() AT TIME ZONE 'UTC' AS ended)
+        SELECT cs.id
+        FROM campaign_slots cs
+        JOIN campaigns c ON c.id = cs.campaign_id
+        JOIN campaign_participation cp ON cp.campaign_id = c.id AND cp.facade_id = $1
+        CROSS JOIN t
+        WHERE c.id = $2
+          AND ($3 = 0 OR cs.id = $3)
+          AND (cs.facade_id IS NULL OR cs.facade_id = $1)
+          AND cs.day_of_week = EXTRACT(DOW FROM t.ended)
+          AND cs.start_time <= t.ended::time
+          AND cs.end_time >= (t.ended - make_interval(secs => $4))::time
+          AND NOT cs.suspended
+          AND c.status = 'active'
+          AND c.budget_exhausted_at IS NULL
+          AND (c.start_at IS NULL OR c.start_at <= NOW())
+          AND (c.end_at IS NULL OR c.end_at >= NOW() - make_interval(secs => $4))
+        ORDER BY cs.priority DESC, cs.id
+        LIMIT 1
+    `
+
+    var id int64
+    err := r.db.QueryRowContext(ctx, query, facadeID, campaignID, slotID, durationSec).Scan(&id)
+    return id, err
 }
 
 //
@@ -188,13 +232,3 @@ func (r *LiveStreamRepository) GetRecentEvents(
 
     return events, nil
 }
-
-//
-// ----------------------- CLEAN OLD HISTORY -----------------------
-//
-func (r *LiveStreamRepository) CleanupOldEvents(ctx context.Context) error {
-    _, err := r.db.ExecContext(ctx,
-        `DELETE FROM play_history WHERE played_at < NOW() - INTERVAL '90 days'`,
-    )
-    return err
-}
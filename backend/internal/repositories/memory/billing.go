@@ -0,0 +1,825 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/repositories"
+)
+
+//
+// ---------- INVOICES ----------
+//
+
+type InvoiceRepository struct {
+	s *Store
+}
+
+func NewInvoiceRepository(s *Store) *InvoiceRepository {
+	return &InvoiceRepository{s: s}
+}
+
+// префиксы номеров, как в document_sequences PostgreSQL-версии
+var documentPrefixes = map[string]string{
+	repositories.DocInvoice:    "INV",
+	repositories.DocCreditNote: "CN",
+	repositories.DocAdjustment: "ADJ",
+}
+
+// invoice — строка счёта в том виде, в каком её читает SELECT (без строк и оснований)
+func invoice(row *invoiceRow) models.Invoice {
+	inv := row.Invoice
+	inv.Lines = nil
+	inv.Refs = nil
+	return inv
+}
+
+func (r *InvoiceRepository) Create(ctx context.Context, inv *models.Invoice) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return r.s.insertDocument(inv)
+}
+
+// CreateAdjustment — кредит-нота / корректировка; credit > 0 зачитывается в исходный счёт
+func (r *InvoiceRepository) CreateAdjustment(ctx context.Context, inv *models.Invoice, credit money.Decimal) error {
+	if inv.OriginalInvoiceID == nil {
+		return repositories.ErrNotCreditable
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	orig, ok := r.s.invoices[*inv.OriginalInvoiceID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if orig.DocumentType != repositories.DocInvoice {
+		return repositories.ErrNotCreditable
+	}
+	if credit.Cmp(orig.AmountTotal.Sub(orig.AmountCredited)) > 0 {
+		return repositories.ErrOverCredit
+	}
+
+	if err := r.s.insertDocument(inv); err != nil {
+		return err
+	}
+
+	if credit.Sign() > 0 {
+		now := r.s.now()
+		orig.AmountCredited = orig.AmountCredited.Add(credit)
+		if orig.AmountPaid.Add(orig.AmountCredited).Cmp(orig.AmountTotal) >= 0 {
+			orig.Status = "paid"
+			if orig.PaidAt == nil {
+				orig.PaidAt = timePtr(now)
+			}
+		}
+		orig.UpdatedAt = now
+	}
+	return nil
+}
+
+// insertDocument присваивает номер и сохраняет документ со строками и основаниями
+func (s *Store) insertDocument(inv *models.Invoice) error {
+	if inv.DocumentType == "" {
+		inv.DocumentType = repositories.DocInvoice
+	}
+	prefix, ok := documentPrefixes[inv.DocumentType]
+	if !ok {
+		return repositories.ErrUnknownDocument
+	}
+	if _, ok := s.companies[inv.CompanyID]; !ok {
+		return ErrForeignKey
+	}
+
+	key := sequenceKey{companyID: inv.CompanyID, docType: inv.DocumentType}
+	s.sequences[key]++
+	inv.InvoiceNumber = fmt.Sprintf("%s-%d-%06d", prefix, inv.CompanyID, s.sequences[key])
+
+	now := s.now()
+	inv.ID = s.nextID("invoices")
+	inv.CreatedAt = now
+
+	row := &invoiceRow{Invoice: *inv}
+	row.PeriodStart = truncDay(inv.PeriodStart)
+	row.PeriodEnd = truncDay(inv.PeriodEnd)
+	if inv.DueDate != nil {
+		row.DueDate = timePtr(truncDay(*inv.DueDate))
+	}
+	row.AmountPaid, row.AmountCredited = money.Zero, money.Zero
+	row.UpdatedAt = now
+	if inv.Refs != nil {
+		row.Refs = *inv.Refs
+		row.Refs.PlayHistoryIDs = append([]int64{}, inv.Refs.PlayHistoryIDs...)
+	}
+
+	lines := make([]models.InvoiceLine, 0, len(inv.Lines))
+	for i := range inv.Lines {
+		l := &inv.Lines[i]
+		l.ID = s.nextID("invoice_lines")
+		l.InvoiceID = inv.ID
+		l.CreatedAt = now
+		lines = append(lines, *l)
+	}
+	row.Lines = nil
+	s.invoices[inv.ID] = row
+	s.invoiceLines[inv.ID] = lines
+	return nil
+}
+
+// GetRefs — основание корректировки; nil у обычного счёта
+func (r *InvoiceRepository) GetRefs(ctx context.Context, invoiceID int64) (*models.AdjustmentRefs, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.invoices[invoiceID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	refs := row.Refs
+	if refs.FacadeID == nil && refs.OutageStart == nil && len(refs.PlayHistoryIDs) == 0 {
+		return nil, nil
+	}
+
+	ids := map[int64]bool{}
+	for _, id := range refs.PlayHistoryIDs {
+		ids[id] = true
+	}
+	refs.PlayHistoryIDs = nil
+	for _, id := range sortedIDs(ids) {
+		refs.PlayHistoryIDs = append(refs.PlayHistoryIDs, id)
+	}
+	return &refs, nil
+}
+
+// ListAdjustments — кредит-ноты и корректировки к счёту, по порядку выставления
+func (r *InvoiceRepository) ListAdjustments(ctx context.Context, originalID int64) ([]models.Invoice, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.Invoice{}
+	for _, row := range r.s.invoices {
+		if row.OriginalInvoiceID != nil && *row.OriginalInvoiceID == originalID {
+			list = append(list, invoice(row))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].IssuedAt.Equal(list[j].IssuedAt) {
+			return list[i].IssuedAt.Before(list[j].IssuedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
+// CountCompanyPlays — сколько из показов ids относятся к кампаниям компании
+func (r *InvoiceRepository) CountCompanyPlays(ctx context.Context, companyID int64, ids []int64) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	seen := map[int64]bool{}
+	for _, id := range ids {
+		ev, ok := r.s.plays[id]
+		if !ok {
+			continue
+		}
+		if c, ok := r.s.campaigns[ev.CampaignID]; ok && c.CompanyID == companyID {
+			seen[id] = true
+		}
+	}
+	return len(seen), nil
+}
+
+func (r *InvoiceRepository) ListLines(ctx context.Context, invoiceID int64) ([]models.InvoiceLine, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return append([]models.InvoiceLine{}, r.s.invoiceLines[invoiceID]...), nil
+}
+
+func (r *InvoiceRepository) GetByID(ctx context.Context, id int64) (*models.Invoice, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.invoices[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	inv := invoice(row)
+	return &inv, nil
+}
+
+// List — страница документов по repositories.InvoiceList
+func (r *InvoiceRepository) List(ctx context.Context, q models.ListQuery) ([]models.Invoice, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := make([]models.Invoice, 0, len(r.s.invoices))
+	for _, row := range r.s.invoices {
+		list = append(list, invoice(row))
+	}
+	return repositories.InvoiceList.Apply(list, q)
+}
+
+// UpdateStatus — только между pending, overdue и failed; отсутствующий счёт не ошибка
+func (r *InvoiceRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.invoices[id]
+	if !ok {
+		return nil
+	}
+	if !manualStatus(row.Status) || !manualStatus(status) {
+		return repositories.ErrStatusFromPayments
+	}
+	if status == "pending" && row.AmountPaid.Add(row.AmountCredited).Sign() > 0 {
+		status = "partially_paid"
+	}
+	row.Status = status
+	row.UpdatedAt = r.s.now()
+	return nil
+}
+
+func manualStatus(status string) bool {
+	return status == "pending" || status == "overdue" || status == "failed"
+}
+
+func (r *InvoiceRepository) PreparePDFData(ctx context.Context, invoiceID int64) (*models.InvoicePDF, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.invoices[invoiceID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	inv := models.Invoice{
+		ID:              row.ID,
+		CompanyID:       row.CompanyID,
+		InvoiceNumber:   row.InvoiceNumber,
+		PeriodStart:     row.PeriodStart,
+		PeriodEnd:       row.PeriodEnd,
+		AmountNet:       row.AmountNet,
+		AmountTax:       row.AmountTax,
+		AmountTotal:     row.AmountTotal,
+		Currency:        row.Currency,
+		TaxJurisdiction: row.TaxJurisdiction,
+		Status:          row.Status,
+		IssuedAt:        row.IssuedAt,
+		PaidAt:          row.PaidAt,
+		CreatedAt:       row.CreatedAt,
+	}
+	return &models.InvoicePDF{
+		Invoice:     inv,
+		PlayHistory: []models.PlayHistory{},
+	}, nil
+}
+
+// CalculateAmountForPeriod — суммы счетов компании за период по валютам
+func (r *InvoiceRepository) CalculateAmountForPeriod(ctx context.Context, companyID int64, start string, end string) ([]models.CurrencyAmount, error) {
+	from, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return nil, err
+	}
+	to, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return nil, err
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	sums := map[string]money.Decimal{}
+	for _, row := range r.s.invoices {
+		if row.CompanyID != companyID || row.PeriodStart.Before(from) || row.PeriodEnd.After(to) {
+			continue
+		}
+		if _, ok := sums[row.Currency]; !ok {
+			sums[row.Currency] = money.Zero
+		}
+		sums[row.Currency] = sums[row.Currency].Add(row.AmountTotal)
+	}
+
+	list := []models.CurrencyAmount{}
+	for currency, amount := range sums {
+		list = append(list, models.CurrencyAmount{Currency: currency, Amount: amount})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Currency < list[j].Currency })
+	return list, nil
+}
+
+//
+// ---------- ACCOUNTING EXPORT ----------
+//
+
+// ExportBounds — максимальные id счетов и оплат старше settle
+func (r *InvoiceRepository) ExportBounds(ctx context.Context, settle time.Duration) (invoiceID, paymentID int64, err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	cutoff := r.s.now().Add(-settle)
+	for id, row := range r.s.invoices {
+		if !row.CreatedAt.After(cutoff) && id > invoiceID {
+			invoiceID = id
+		}
+	}
+	for id, p := range r.s.payments {
+		if !p.CreatedAt.After(cutoff) && id > paymentID {
+			paymentID = id
+		}
+	}
+	return invoiceID, paymentID, nil
+}
+
+// LastIncrementalExport — sql.ErrNoRows, если инкрементальных выгрузок ещё не было
+func (r *InvoiceRepository) LastIncrementalExport(ctx context.Context) (*models.AccountingExport, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var last *models.AccountingExport
+	for _, e := range r.s.exports {
+		if e.Incremental && (last == nil || e.ID > last.ID) {
+			last = e
+		}
+	}
+	if last == nil {
+		return nil, sql.ErrNoRows
+	}
+	out := *last
+	return &out, nil
+}
+
+func (r *InvoiceRepository) CreateExport(ctx context.Context, e *models.AccountingExport) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	e.ID = r.s.nextID("accounting_exports")
+	e.CreatedAt = r.s.now()
+	row := *e
+	r.s.exports[e.ID] = &row
+	return nil
+}
+
+func (r *InvoiceRepository) GetExport(ctx context.Context, id int64) (*models.AccountingExport, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	e, ok := r.s.exports[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	out := *e
+	return &out, nil
+}
+
+func (r *InvoiceRepository) ListExports(ctx context.Context, limit, offset int) ([]models.AccountingExport, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ids := sortedIDs(r.s.exports)
+	list := make([]models.AccountingExport, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		list = append(list, *r.s.exports[ids[i]])
+	}
+	return page(list, limit, offset), nil
+}
+
+// inPeriod — ($from IS NULL OR t >= $from) AND ($to IS NULL OR t < $to)
+func inPeriod(t time.Time, from, to *time.Time) bool {
+	return (from == nil || !t.Before(*from)) && (to == nil || t.Before(*to))
+}
+
+// ExportDocuments — документы с id в (InvoiceIDFrom, InvoiceIDTo], выставленные в периоде
+func (r *InvoiceRepository) ExportDocuments(ctx context.Context, e *models.AccountingExport) ([]models.ExportDocument, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	docs := []models.ExportDocument{}
+	for _, id := range sortedIDs(r.s.invoices) {
+		row := r.s.invoices[id]
+		if id <= e.InvoiceIDFrom || id > e.InvoiceIDTo || !inPeriod(row.IssuedAt, e.PeriodFrom, e.PeriodTo) {
+			continue
+		}
+		c, ok := r.s.companies[row.CompanyID]
+		if !ok {
+			continue
+		}
+		d := models.ExportDocument{
+			Invoice:          invoice(row),
+			CompanyName:      c.Name,
+			CompanyLegalName: c.LegalName,
+			CompanyVAT:       c.VATNumber,
+		}
+		d.Lines = append([]models.InvoiceLine{}, r.s.invoiceLines[id]...)
+		docs = append(docs, d)
+	}
+	return docs, nil
+}
+
+// ExportPayments — оплаты с id в (PaymentIDFrom, PaymentIDTo], проведённые в периоде
+func (r *InvoiceRepository) ExportPayments(ctx context.Context, e *models.AccountingExport) ([]models.ExportPayment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.ExportPayment{}
+	for _, id := range sortedIDs(r.s.payments) {
+		p := r.s.payments[id]
+		if id <= e.PaymentIDFrom || id > e.PaymentIDTo || !inPeriod(p.PaidAt, e.PeriodFrom, e.PeriodTo) {
+			continue
+		}
+		inv, ok := r.s.invoices[p.InvoiceID]
+		if !ok {
+			continue
+		}
+		list = append(list, models.ExportPayment{
+			Payment:       *p,
+			CompanyID:     inv.CompanyID,
+			InvoiceNumber: inv.InvoiceNumber,
+		})
+	}
+	return list, nil
+}
+
+//
+// ---------- PAYMENTS ----------
+//
+
+type PaymentRepository struct {
+	s *Store
+}
+
+func NewPaymentRepository(s *Store) *PaymentRepository {
+	return &PaymentRepository{s: s}
+}
+
+// Record пишет оплату и пересчитывает amount_paid / статус счёта
+func (r *PaymentRepository) Record(ctx context.Context, p *models.Payment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	inv, ok := r.s.invoices[p.InvoiceID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if inv.Status == "paid" || inv.Status == "failed" || inv.Status == "applied" {
+		return repositories.ErrInvoiceClosed
+	}
+	if p.Currency == "" {
+		p.Currency = inv.Currency
+	}
+	if !strings.EqualFold(p.Currency, inv.Currency) {
+		return repositories.ErrCurrencyMismatch
+	}
+	if p.Amount.Cmp(inv.AmountTotal.Sub(inv.AmountPaid).Sub(inv.AmountCredited)) > 0 {
+		return repositories.ErrOverpayment
+	}
+
+	now := r.s.now()
+	p.ID = r.s.nextID("payments")
+	p.CreatedAt = now
+	row := *p
+	row.Currency = inv.Currency
+	r.s.payments[p.ID] = &row
+
+	inv.AmountPaid = inv.AmountPaid.Add(p.Amount)
+	switch {
+	case inv.AmountPaid.Add(inv.AmountCredited).Cmp(inv.AmountTotal) >= 0:
+		inv.Status = "paid"
+		inv.PaidAt = timePtr(p.PaidAt)
+	case inv.Status != "overdue":
+		inv.Status = "partially_paid"
+	}
+	inv.UpdatedAt = now
+	return nil
+}
+
+// ListByInvoice — по дате оплаты
+func (r *PaymentRepository) ListByInvoice(ctx context.Context, invoiceID int64) ([]models.Payment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.Payment{}
+	for _, p := range r.s.payments {
+		if p.InvoiceID == invoiceID {
+			list = append(list, *p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].PaidAt.Equal(list[j].PaidAt) {
+			return list[i].PaidAt.Before(list[j].PaidAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
+//
+// ---------- DUNNING ----------
+//
+
+type DunningRepository struct {
+	s *Store
+}
+
+func NewDunningRepository(s *Store) *DunningRepository {
+	return &DunningRepository{s: s}
+}
+
+func unpaid(status string) bool {
+	return status == "pending" || status == "partially_paid" || status == "overdue"
+}
+
+// daysBetween — ($1::date - due_date)
+func daysBetween(today, due time.Time) int {
+	return int(truncDay(today).Sub(truncDay(due)).Hours() / 24)
+}
+
+// MarkOverdue переводит неоплаченные счета с прошедшим due_date в overdue
+func (r *DunningRepository) MarkOverdue(ctx context.Context, today time.Time) ([]int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := r.s.now()
+	ids := []int64{}
+	for _, id := range sortedIDs(r.s.invoices) {
+		inv := r.s.invoices[id]
+		if (inv.Status != "pending" && inv.Status != "partially_paid") || inv.DueDate == nil {
+			continue
+		}
+		if daysBetween(today, *inv.DueDate) > 0 {
+			inv.Status = "overdue"
+			inv.UpdatedAt = now
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// ListUnpaid — неоплаченные счета со сроком; DaysOverdue < 0 — до срока ещё столько дней
+func (r *DunningRepository) ListUnpaid(ctx context.Context, today time.Time) ([]models.OverdueInvoice, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.OverdueInvoice{}
+	for _, id := range sortedIDs(r.s.invoices) {
+		row := r.s.invoices[id]
+		if !unpaid(row.Status) || row.DueDate == nil {
+			continue
+		}
+		list = append(list, models.OverdueInvoice{
+			Invoice: models.Invoice{
+				ID:             row.ID,
+				CompanyID:      row.CompanyID,
+				InvoiceNumber:  row.InvoiceNumber,
+				AmountTotal:    row.AmountTotal,
+				AmountPaid:     row.AmountPaid,
+				AmountCredited: row.AmountCredited,
+				Currency:       row.Currency,
+				Status:         row.Status,
+				DueDate:        timePtr(*row.DueDate),
+			},
+			DaysOverdue: daysBetween(today, *row.DueDate),
+		})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].DueDate.Before(*list[j].DueDate) })
+	return list, nil
+}
+
+// ClaimReminder — false, если напоминание с таким смещением уже отправляли
+func (r *DunningRepository) ClaimReminder(ctx context.Context, invoiceID int64, offsetDays int) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.invoices[invoiceID]; !ok {
+		return false, ErrForeignKey
+	}
+	key := reminderKey{invoiceID: invoiceID, offsetDays: offsetDays}
+	if _, ok := r.s.reminders[key]; ok {
+		return false, nil
+	}
+	r.s.reminders[key] = r.s.now()
+	return true, nil
+}
+
+func (r *DunningRepository) ReleaseReminder(ctx context.Context, invoiceID int64, offsetDays int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delete(r.s.reminders, reminderKey{invoiceID: invoiceID, offsetDays: offsetDays})
+	return nil
+}
+
+// CompaniesOverdue — компании, у которых есть счёт с просрочкой не меньше minDays
+func (r *DunningRepository) CompaniesOverdue(ctx context.Context, today time.Time, minDays int) (map[int64]bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	set := map[int64]bool{}
+	for _, inv := range r.s.invoices {
+		if unpaid(inv.Status) && inv.DueDate != nil && daysBetween(today, *inv.DueDate) >= minDays {
+			set[inv.CompanyID] = true
+		}
+	}
+	return set, nil
+}
+
+//
+// ---------- TAX RATES ----------
+//
+
+type TaxRateRepository struct {
+	s *Store
+}
+
+func NewTaxRateRepository(s *Store) *TaxRateRepository {
+	return &TaxRateRepository{s: s}
+}
+
+// Create закрывает действующую ставку юрисдикции датой начала новой
+func (r *TaxRateRepository) Create(ctx context.Context, t *models.TaxRate) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	from := truncDay(t.ValidFrom)
+	for _, old := range r.s.taxRates {
+		if old.Jurisdiction == t.Jurisdiction && old.ValidFrom.Before(from) && (old.ValidTo == nil || old.ValidTo.After(from)) {
+			old.ValidTo = timePtr(from)
+		}
+	}
+
+	t.ID = r.s.nextID("tax_rates")
+	t.CreatedAt = r.s.now()
+	row := *t
+	row.ValidFrom = from
+	if t.ValidTo != nil {
+		row.ValidTo = timePtr(truncDay(*t.ValidTo))
+	}
+	r.s.taxRates[t.ID] = &row
+	return nil
+}
+
+// Find — ставка, действующая в юрисдикции на дату; sql.ErrNoRows, если такой нет
+func (r *TaxRateRepository) Find(ctx context.Context, jurisdiction string, on time.Time) (*models.TaxRate, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	day := truncDay(on)
+	var found *models.TaxRate
+	for _, id := range sortedIDs(r.s.taxRates) {
+		t := r.s.taxRates[id]
+		if t.Jurisdiction != jurisdiction || t.ValidFrom.After(day) || (t.ValidTo != nil && !t.ValidTo.After(day)) {
+			continue
+		}
+		if found == nil || t.ValidFrom.After(found.ValidFrom) {
+			found = t
+		}
+	}
+	if found == nil {
+		return nil, sql.ErrNoRows
+	}
+	out := *found
+	return &out, nil
+}
+
+func (r *TaxRateRepository) GetByID(ctx context.Context, id int64) (*models.TaxRate, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	t, ok := r.s.taxRates[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	out := *t
+	return &out, nil
+}
+
+// List — все ставки (jurisdiction пустая — по всем юрисдикциям), новые сверху
+func (r *TaxRateRepository) List(ctx context.Context, jurisdiction string) ([]models.TaxRate, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.TaxRate{}
+	for _, id := range sortedIDs(r.s.taxRates) {
+		if t := r.s.taxRates[id]; jurisdiction == "" || t.Jurisdiction == jurisdiction {
+			list = append(list, *t)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Jurisdiction != list[j].Jurisdiction {
+			return list[i].Jurisdiction < list[j].Jurisdiction
+		}
+		return list[i].ValidFrom.After(list[j].ValidFrom)
+	})
+	return list, nil
+}
+
+func (r *TaxRateRepository) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.taxRates[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.s.taxRates, id)
+	return nil
+}
+
+//
+// ---------- EXCHANGE RATES ----------
+//
+
+type ExchangeRateRepository struct {
+	s *Store
+}
+
+func NewExchangeRateRepository(s *Store) *ExchangeRateRepository {
+	return &ExchangeRateRepository{s: s}
+}
+
+// Upsert — один курс на пару и дату; повторная запись исправляет курс
+func (r *ExchangeRateRepository) Upsert(ctx context.Context, e *models.ExchangeRate) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := r.s.now()
+	day := truncDay(e.ValidOn)
+	for _, row := range r.s.exchangeRates {
+		if row.Base == e.Base && row.Quote == e.Quote && row.ValidOn.Equal(day) {
+			row.Rate = e.Rate
+			row.CreatedBy = e.CreatedBy
+			row.CreatedAt = now
+			e.ID, e.CreatedAt = row.ID, now
+			return nil
+		}
+	}
+
+	e.ID = r.s.nextID("exchange_rates")
+	e.CreatedAt = now
+	row := *e
+	row.ValidOn = day
+	r.s.exchangeRates[e.ID] = &row
+	return nil
+}
+
+// Find — последний курс пары на дату (valid_on <= on); sql.ErrNoRows, если курса нет
+func (r *ExchangeRateRepository) Find(ctx context.Context, base, quote string, on time.Time) (*models.ExchangeRate, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	day := truncDay(on)
+	var found *models.ExchangeRate
+	for _, e := range r.s.exchangeRates {
+		if e.Base != base || e.Quote != quote || e.ValidOn.After(day) {
+			continue
+		}
+		if found == nil || e.ValidOn.After(found.ValidOn) {
+			found = e
+		}
+	}
+	if found == nil {
+		return nil, sql.ErrNoRows
+	}
+	out := *found
+	return &out, nil
+}
+
+// List — курсы (фильтр по base / quote, пустое — любые), свежие сверху
+func (r *ExchangeRateRepository) List(ctx context.Context, base, quote string, limit, offset int) ([]models.ExchangeRate, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.ExchangeRate{}
+	for _, id := range sortedIDs(r.s.exchangeRates) {
+		e := r.s.exchangeRates[id]
+		if (base == "" || e.Base == base) && (quote == "" || e.Quote == quote) {
+			list = append(list, *e)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if !list[i].ValidOn.Equal(list[j].ValidOn) {
+			return list[i].ValidOn.After(list[j].ValidOn)
+		}
+		if list[i].Base != list[j].Base {
+			return list[i].Base < list[j].Base
+		}
+		return list[i].Quote < list[j].Quote
+	})
+	return page(list, limit, offset), nil
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.exchangeRates[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.s.exchangeRates, id)
+	return nil
+}
@@ -0,0 +1,332 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/repositories"
+)
+
+//
+// ---------- BUDGETS ----------
+//
+
+type BudgetRepository struct {
+	s *Store
+}
+
+func NewBudgetRepository(s *Store) *BudgetRepository {
+	return &BudgetRepository{s: s}
+}
+
+func budgetExhausted(b *models.Budget) bool {
+	return (b.AmountLimit != nil && b.AmountSpent.Cmp(*b.AmountLimit) >= 0) ||
+		(b.AirtimeLimitSec != nil && b.AirtimeSpentSec >= *b.AirtimeLimitSec)
+}
+
+// budget — копия строки с вычисленным Exhausted
+func budget(b *models.Budget) *models.Budget {
+	out := *b
+	if b.CampaignID != nil {
+		id := *b.CampaignID
+		out.CampaignID = &id
+	}
+	if b.AmountLimit != nil {
+		limit := *b.AmountLimit
+		out.AmountLimit = &limit
+	}
+	if b.AirtimeLimitSec != nil {
+		limit := *b.AirtimeLimitSec
+		out.AirtimeLimitSec = &limit
+	}
+	out.Exhausted = budgetExhausted(&out)
+	return &out
+}
+
+// campaignBudget / companyBudget — nil, если бюджет не задан
+func (s *Store) campaignBudget(campaignID int64) *models.Budget {
+	for _, b := range s.budgets {
+		if b.CampaignID != nil && *b.CampaignID == campaignID {
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *Store) companyBudget(companyID int64) *models.Budget {
+	for _, b := range s.budgets {
+		if b.CampaignID == nil && b.CompanyID == companyID {
+			return b
+		}
+	}
+	return nil
+}
+
+// campaignExhausted — исчерпан бюджет кампании, бюджет компании или кошелёк пуст
+func (s *Store) campaignExhausted(c *campaignRow) bool {
+	if b := s.campaignBudget(c.ID); b != nil && budgetExhausted(b) {
+		return true
+	}
+	if b := s.companyBudget(c.CompanyID); b != nil && budgetExhausted(b) {
+		return true
+	}
+	w, ok := s.wallets[c.CompanyID]
+	return ok && w.Balance.Sign() <= 0
+}
+
+func (r *BudgetRepository) GetCampaignBudget(ctx context.Context, campaignID int64) (*models.Budget, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	b := r.s.campaignBudget(campaignID)
+	if b == nil {
+		return nil, sql.ErrNoRows
+	}
+	return budget(b), nil
+}
+
+func (r *BudgetRepository) GetCompanyBudget(ctx context.Context, companyID int64) (*models.Budget, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	b := r.s.companyBudget(companyID)
+	if b == nil {
+		return nil, sql.ErrNoRows
+	}
+	return budget(b), nil
+}
+
+// SetBudget создаёт или меняет лимиты; потраченное сохраняется
+func (r *BudgetRepository) SetBudget(ctx context.Context, b *models.Budget) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var row *models.Budget
+	if b.CampaignID != nil {
+		if _, ok := r.s.campaigns[*b.CampaignID]; !ok {
+			return ErrForeignKey
+		}
+		row = r.s.campaignBudget(*b.CampaignID)
+	} else {
+		if _, ok := r.s.companies[b.CompanyID]; !ok {
+			return ErrForeignKey
+		}
+		row = r.s.companyBudget(b.CompanyID)
+	}
+	if row == nil {
+		row = &models.Budget{
+			ID:          r.s.nextID("budgets"),
+			CompanyID:   b.CompanyID,
+			CampaignID:  b.CampaignID,
+			AmountSpent: money.Zero,
+		}
+		r.s.budgets[row.ID] = row
+	}
+
+	saved := budget(b)
+	row.Currency = saved.Currency
+	row.AmountLimit = saved.AmountLimit
+	row.AirtimeLimitSec = saved.AirtimeLimitSec
+	row.UpdatedAt = r.s.now()
+
+	*b = *budget(row)
+	return nil
+}
+
+func (r *BudgetRepository) DeleteCampaignBudget(ctx context.Context, campaignID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if b := r.s.campaignBudget(campaignID); b != nil {
+		delete(r.s.budgets, b.ID)
+	}
+	return nil
+}
+
+func (r *BudgetRepository) DeleteCompanyBudget(ctx context.Context, companyID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if b := r.s.companyBudget(companyID); b != nil {
+		delete(r.s.budgets, b.ID)
+	}
+	return nil
+}
+
+// ExhaustedAt — когда кампания снята с расписания по бюджету (nil — показывается)
+func (r *BudgetRepository) ExhaustedAt(ctx context.Context, campaignID int64) (*time.Time, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.campaigns[campaignID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if c.BudgetExhaustedAt == nil {
+		return nil, nil
+	}
+	return timePtr(*c.BudgetExhaustedAt), nil
+}
+
+// PlayPrice — цена показа по активной карточке тарифа фасада; ok = false — тарифа нет
+func (r *BudgetRepository) PlayPrice(ctx context.Context, facadeID int64, seconds int64) (money.Decimal, string, bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	rc := r.s.rateCard(facadeID)
+	if rc == nil {
+		return money.Zero, "", false, nil
+	}
+	return rc.CostPerSpot.Add(rc.CostPerSecond.Mul(money.NewFromInt(seconds))), rc.Currency, true, nil
+}
+
+// Consume списывает показ с бюджета кампании, бюджета компании и кошелька (что из них есть);
+// crossed — бюджет или кошелёк исчерпан этим списанием, а до него не был
+func (r *BudgetRepository) Consume(ctx context.Context, c *models.PlayCharge) (crossed bool, err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := r.s.now()
+	charge := func(b *models.Budget, amount money.Decimal) {
+		if b == nil {
+			return
+		}
+		before := budgetExhausted(b)
+		b.AmountSpent = b.AmountSpent.Add(amount)
+		b.AirtimeSpentSec += c.Seconds
+		b.UpdatedAt = now
+		crossed = crossed || (!before && budgetExhausted(b))
+	}
+	charge(r.s.campaignBudget(c.CampaignID), c.CampaignAmount)
+	charge(r.s.companyBudget(c.CompanyID), c.CompanyAmount)
+
+	if w, ok := r.s.wallets[c.CompanyID]; ok && c.WalletAmount.Sign() > 0 {
+		before := w.Balance.Sign() > 0
+		campaignID, playID := c.CampaignID, c.PlayHistoryID
+		if err := r.s.walletMove(&models.WalletTransaction{
+			CompanyID:     c.CompanyID,
+			Kind:          "charge",
+			Amount:        c.WalletAmount.Neg(),
+			CampaignID:    &campaignID,
+			PlayHistoryID: &playID,
+		}, true); err != nil {
+			return false, err
+		}
+		crossed = crossed || (before && w.Balance.Sign() <= 0)
+	}
+	return crossed, nil
+}
+
+// Reevaluate снимает с расписания упёршиеся в бюджет кампании и возвращает освободившиеся
+func (r *BudgetRepository) Reevaluate(ctx context.Context, companyID int64) (exhausted, resumed []int64, err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := r.s.now()
+	exhausted, resumed = []int64{}, []int64{}
+	for _, id := range sortedIDs(r.s.campaigns) {
+		c := r.s.campaigns[id]
+		if c.CompanyID != companyID {
+			continue
+		}
+		switch out := r.s.campaignExhausted(c); {
+		case c.BudgetExhaustedAt == nil && out:
+			c.BudgetExhaustedAt = timePtr(now)
+			exhausted = append(exhausted, id)
+		case c.BudgetExhaustedAt != nil && !out:
+			c.BudgetExhaustedAt = nil
+			resumed = append(resumed, id)
+		}
+	}
+	return exhausted, resumed, nil
+}
+
+//
+// ---------- WALLETS ----------
+//
+
+// GetWallet — sql.ErrNoRows, если компания не на предоплате
+func (r *BudgetRepository) GetWallet(ctx context.Context, companyID int64) (*models.Wallet, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	w, ok := r.s.wallets[companyID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	out := *w
+	return &out, nil
+}
+
+// TopUp пополняет кошелёк (создаёт его в currency при первом пополнении) или корректирует баланс
+func (r *BudgetRepository) TopUp(ctx context.Context, t *models.WalletTransaction, currency string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.companies[t.CompanyID]; !ok {
+		return ErrForeignKey
+	}
+	created := false
+	if _, ok := r.s.wallets[t.CompanyID]; !ok {
+		r.s.wallets[t.CompanyID] = &models.Wallet{CompanyID: t.CompanyID, Currency: currency, Balance: money.Zero, UpdatedAt: r.s.now()}
+		created = true
+	}
+	if err := r.s.walletMove(t, false); err != nil {
+		// откат транзакции: кошелёк, созданный этим вызовом, не остаётся
+		if created {
+			delete(r.s.wallets, t.CompanyID)
+		}
+		return err
+	}
+	return nil
+}
+
+// walletMove меняет баланс и пишет движение; allowNegative — списание за состоявшийся показ
+func (s *Store) walletMove(t *models.WalletTransaction, allowNegative bool) error {
+	w, ok := s.wallets[t.CompanyID]
+	if !ok {
+		if allowNegative {
+			// кошелька нет — компания на постоплате, списывать не с чего
+			return nil
+		}
+		return sql.ErrNoRows
+	}
+
+	t.BalanceAfter = w.Balance.Add(t.Amount)
+	if t.BalanceAfter.Sign() < 0 && !allowNegative {
+		return repositories.ErrInsufficientFunds
+	}
+
+	now := s.now()
+	w.Balance = t.BalanceAfter
+	w.UpdatedAt = now
+
+	t.ID = s.nextID("wallet_transactions")
+	t.CreatedAt = now
+	s.walletTx = append(s.walletTx, *t)
+	return nil
+}
+
+// ListTransactions — движения по кошельку, новые сверху
+func (r *BudgetRepository) ListTransactions(ctx context.Context, companyID int64, limit, offset int) ([]models.WalletTransaction, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.WalletTransaction{}
+	for _, t := range r.s.walletTx {
+		if t.CompanyID == companyID {
+			list = append(list, t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.After(list[j].CreatedAt)
+		}
+		return list[i].ID > list[j].ID
+	})
+	return page(list, limit, offset), nil
+}
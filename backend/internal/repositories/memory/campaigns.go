@@ -0,0 +1,380 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/repositories"
+)
+
+//
+// ---------- CAMPAIGNS ----------
+//
+
+type CampaignRepository struct {
+	s *Store
+}
+
+func NewCampaignRepository(s *Store) *CampaignRepository {
+	return &CampaignRepository{s: s}
+}
+
+// campaign — строка в том виде, в каком её читает SELECT (priority в таблице нет)
+func campaign(row *campaignRow) models.Campaign {
+	c := row.Campaign
+	c.Priority = 0
+	return c
+}
+
+func (r *CampaignRepository) Create(ctx context.Context, c *models.Campaign) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.companies[c.CompanyID]; !ok {
+		return 0, ErrForeignKey
+	}
+	c.ID = r.s.nextID("campaigns")
+	c.CreatedAt = r.s.now()
+	r.s.campaigns[c.ID] = &campaignRow{Campaign: *c}
+	return c.ID, nil
+}
+
+func (r *CampaignRepository) GetByID(ctx context.Context, id int64) (*models.Campaign, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.campaigns[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	c := campaign(row)
+	return &c, nil
+}
+
+// List — страница кампаний по repositories.CampaignList
+func (r *CampaignRepository) List(ctx context.Context, q models.ListQuery) ([]models.Campaign, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := make([]models.Campaign, 0, len(r.s.campaigns))
+	for _, row := range r.s.campaigns {
+		list = append(list, campaign(row))
+	}
+	return repositories.CampaignList.Apply(list, q)
+}
+
+func (r *CampaignRepository) Update(ctx context.Context, c *models.Campaign) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.campaigns[c.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	running := c.Status == "scheduled" || c.Status == "active" || c.Status == "live"
+	if (row.Status == "paused" && c.Status != "paused") || (running && c.Status != row.Status) {
+		for _, sp := range r.s.suspensions {
+			if sp.CompanyID == row.CompanyID && sp.ReactivatedAt == nil {
+				return repositories.ErrCampaignSuspended
+			}
+		}
+	}
+	row.Name = c.Name
+	row.Description = c.Description
+	row.StartTime = c.StartTime
+	row.EndTime = c.EndTime
+	row.Status = c.Status
+	return nil
+}
+
+//
+// ---------- SLOTS ----------
+//
+
+type CampaignSlotRepository struct {
+	s *Store
+}
+
+func NewCampaignSlotRepository(s *Store) *CampaignSlotRepository {
+	return &CampaignSlotRepository{s: s}
+}
+
+// slotsOf — слоты кампании, отсортированные less
+func (s *Store) slotsOf(campaignID int64, less func(a, b *models.CampaignSlot) bool) []models.CampaignSlot {
+	slots := []models.CampaignSlot{}
+	for _, id := range sortedIDs(s.slots) {
+		if row := s.slots[id]; row.CampaignID == campaignID {
+			slots = append(slots, row.CampaignSlot)
+		}
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return less(&slots[i], &slots[j]) })
+	return slots
+}
+
+// ListByCampaign — сначала приоритетные, затем по дню недели и времени
+func (r *CampaignSlotRepository) ListByCampaign(ctx context.Context, id int64) ([]models.CampaignSlot, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return r.s.slotsOf(id, func(a, b *models.CampaignSlot) bool {
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if a.DayOfWeek != b.DayOfWeek {
+			return a.DayOfWeek < b.DayOfWeek
+		}
+		return a.StartTime < b.StartTime
+	}), nil
+}
+
+// Create — facade_id = 0 означает слот на все фасады кампании; длительность по умолчанию 15 с
+func (r *CampaignSlotRepository) Create(ctx context.Context, slot *models.CampaignSlot) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.campaigns[slot.CampaignID]; !ok {
+		return 0, ErrForeignKey
+	}
+	if _, ok := r.s.facades[slot.FacadeID]; slot.FacadeID != 0 && !ok {
+		return 0, ErrForeignKey
+	}
+	slot.ID = r.s.nextID("campaign_slots")
+
+	row := &slotRow{CampaignSlot: *slot}
+	row.DurationSec = 15
+	row.CreatedAt = r.s.now()
+	r.s.slots[slot.ID] = row
+	return slot.ID, nil
+}
+
+type CampaignSlotsRepository struct {
+	s *Store
+}
+
+func NewCampaignSlotsRepository(s *Store) *CampaignSlotsRepository {
+	return &CampaignSlotsRepository{s: s}
+}
+
+// GetSlotsByCampaign — недельная сетка: по дню недели и времени, без facade_id
+func (r *CampaignSlotsRepository) GetSlotsByCampaign(ctx context.Context, campaignID int64) ([]models.CampaignSlot, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	slots := r.s.slotsOf(campaignID, func(a, b *models.CampaignSlot) bool {
+		if a.DayOfWeek != b.DayOfWeek {
+			return a.DayOfWeek < b.DayOfWeek
+		}
+		return a.StartTime < b.StartTime
+	})
+	for i := range slots {
+		slots[i].FacadeID = 0
+	}
+	return slots, nil
+}
+
+//
+// ---------- CREATIVES ----------
+//
+
+type CreativeRepository struct {
+	s *Store
+}
+
+func NewCreativeRepository(s *Store) *CreativeRepository {
+	return &CreativeRepository{s: s}
+}
+
+func creative(c *models.Creative) models.Creative {
+	out := *c
+	if c.CampaignID != nil {
+		id := *c.CampaignID
+		out.CampaignID = &id
+	}
+	return out
+}
+
+func (r *CreativeRepository) Create(ctx context.Context, c *models.Creative) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.companies[c.CompanyID]; !ok {
+		return ErrForeignKey
+	}
+	if c.CampaignID != nil {
+		if _, ok := r.s.campaigns[*c.CampaignID]; !ok {
+			return ErrForeignKey
+		}
+	}
+	c.ID = r.s.nextID("creatives")
+	c.UploadedAt = r.s.now()
+	row := creative(c)
+	r.s.creatives[c.ID] = &row
+	return nil
+}
+
+func (r *CreativeRepository) GetByID(ctx context.Context, id int64) (*models.Creative, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.creatives[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	out := creative(c)
+	return &out, nil
+}
+
+// List — страница роликов по repositories.CreativeList
+func (r *CreativeRepository) List(ctx context.Context, q models.ListQuery) ([]models.Creative, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := make([]models.Creative, 0, len(r.s.creatives))
+	for _, c := range r.s.creatives {
+		list = append(list, creative(c))
+	}
+	return repositories.CreativeList.Apply(list, q)
+}
+
+//
+// ---------- ANALYTICS ----------
+//
+
+// AnalyticsRepository, как и PostgreSQL-версия, читает только часовые свёртки
+type AnalyticsRepository struct {
+	s *Store
+}
+
+func NewAnalyticsRepository(s *Store) *AnalyticsRepository {
+	return &AnalyticsRepository{s: s}
+}
+
+// deliveryAcc — SUM / MAX по группе свёрток
+type deliveryAcc struct {
+	plays, airtime, bitrate, latency int64
+	maxLatency                       int
+}
+
+func (a *deliveryAcc) add(r *models.PlayRollup) {
+	a.plays += r.Plays
+	a.airtime += r.AirtimeSec
+	a.bitrate += r.BitrateSum
+	a.latency += r.SyncLatencySum
+	if r.SyncLatencyMax > a.maxLatency {
+		a.maxLatency = r.SyncLatencyMax
+	}
+}
+
+// avg — SUM(x)::float8 / NULLIF(SUM(plays), 0), NULL → 0
+func (a *deliveryAcc) avg(sum int64) float64 {
+	if a.plays == 0 {
+		return 0
+	}
+	return float64(sum) / float64(a.plays)
+}
+
+// hourRollups — часовые свёртки кампании с bucket_start в [from, to)
+func (s *Store) hourRollups(campaignID int64, from, to time.Time) []*models.PlayRollup {
+	var list []*models.PlayRollup
+	for k, r := range s.playRollups {
+		if k.granularity == "hour" && k.campaignID == campaignID && !k.bucket.Before(from) && k.bucket.Before(to) {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+func (r *AnalyticsRepository) CampaignTotals(ctx context.Context, campaignID int64, from, to time.Time) (*models.CampaignDelivery, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var acc deliveryAcc
+	for _, ru := range r.s.hourRollups(campaignID, from, to) {
+		acc.add(ru)
+	}
+	return &models.CampaignDelivery{
+		CampaignID:       campaignID,
+		From:             from,
+		To:               to,
+		Plays:            acc.plays,
+		AirtimeSec:       acc.airtime,
+		AvgBitrateKbps:   acc.avg(acc.bitrate),
+		AvgSyncLatencyMS: acc.avg(acc.latency),
+		MaxSyncLatencyMS: acc.maxLatency,
+	}, nil
+}
+
+// CampaignBreakdown — by: facade | day | hour
+func (r *AnalyticsRepository) CampaignBreakdown(ctx context.Context, campaignID int64, from, to time.Time, by string) ([]models.DeliveryBreakdownRow, error) {
+	var bucketOf func(t time.Time) time.Time
+	switch by {
+	case "facade":
+	case "day":
+		bucketOf = truncDay
+	case "hour":
+		bucketOf = func(t time.Time) time.Time { return t.UTC().Truncate(time.Hour) }
+	default:
+		return nil, fmt.Errorf("unknown breakdown %q", by)
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	type group struct {
+		facadeID int64
+		bucket   time.Time
+		acc      deliveryAcc
+	}
+	groups := map[any]*group{}
+	for _, ru := range r.s.hourRollups(campaignID, from, to) {
+		var key any = ru.FacadeID
+		g := &group{facadeID: ru.FacadeID}
+		if bucketOf != nil {
+			g = &group{bucket: bucketOf(ru.BucketStart)}
+			key = g.bucket
+		}
+		if existing, ok := groups[key]; ok {
+			g = existing
+		} else {
+			groups[key] = g
+		}
+		g.acc.add(ru)
+	}
+
+	list := []models.DeliveryBreakdownRow{}
+	for _, g := range groups {
+		row := models.DeliveryBreakdownRow{
+			Plays:            g.acc.plays,
+			AirtimeSec:       g.acc.airtime,
+			AvgBitrateKbps:   g.acc.avg(g.acc.bitrate),
+			AvgSyncLatencyMS: g.acc.avg(g.acc.latency),
+			MaxSyncLatencyMS: g.acc.maxLatency,
+		}
+		if bucketOf == nil {
+			facadeID := g.facadeID
+			row.FacadeID = &facadeID
+			if f, ok := r.s.facades[facadeID]; ok {
+				row.FacadeName = f.Name
+			}
+		} else {
+			bucket := g.bucket
+			row.Bucket = &bucket
+		}
+		list = append(list, row)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if bucketOf != nil {
+			return list[i].Bucket.Before(*list[j].Bucket)
+		}
+		if list[i].AirtimeSec != list[j].AirtimeSec {
+			return list[i].AirtimeSec > list[j].AirtimeSec
+		}
+		return *list[i].FacadeID < *list[j].FacadeID
+	})
+	return list, nil
+}
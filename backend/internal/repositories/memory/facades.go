@@ -0,0 +1,616 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/repositories"
+)
+
+//
+// ---------- FACADES ----------
+//
+
+type FacadeRepository struct {
+	s *Store
+}
+
+func NewFacadeRepository(s *Store) *FacadeRepository {
+	return &FacadeRepository{s: s}
+}
+
+func (r *FacadeRepository) GetByID(ctx context.Context, id int64) (*models.Facade, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	f, ok := r.s.facades[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	out := *f
+	return &out, nil
+}
+
+// List — новые сверху
+// List — страница фасадов по repositories.FacadeList
+func (r *FacadeRepository) List(ctx context.Context, q models.ListQuery) ([]models.Facade, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := make([]models.Facade, 0, len(r.s.facades))
+	for _, f := range r.s.facades {
+		list = append(list, *f)
+	}
+	return repositories.FacadeList.Apply(list, q)
+}
+
+// ListInBBox — грубый прямоугольный фильтр (границы включительно), по id
+func (r *FacadeRepository) ListInBBox(ctx context.Context, minLat, minLon, maxLat, maxLon float64) ([]models.Facade, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.Facade{}
+	for _, id := range sortedIDs(r.s.facades) {
+		f := r.s.facades[id]
+		if f.Latitude >= minLat && f.Latitude <= maxLat && f.Longitude >= minLon && f.Longitude <= maxLon {
+			list = append(list, *f)
+		}
+	}
+	return list, nil
+}
+
+// CountBookedCampaigns — сколько кампаний (не черновики / не завершённые) занимают фасады в [from, to)
+func (r *FacadeRepository) CountBookedCampaigns(ctx context.Context, facadeIDs []int64, from, to time.Time) (map[int64]int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	wanted := map[int64]bool{}
+	for _, id := range facadeIDs {
+		wanted[id] = true
+	}
+
+	booked := map[int64]map[int64]bool{}
+	for _, p := range r.s.participation {
+		c, ok := r.s.campaigns[p.CampaignID]
+		if !wanted[p.FacadeID] || !ok {
+			continue
+		}
+		switch c.Status {
+		case "draft", "finished", "cancelled":
+			continue
+		}
+		if (!c.StartTime.IsZero() && !c.StartTime.Before(to)) || (!c.EndTime.IsZero() && !c.EndTime.After(from)) {
+			continue
+		}
+		if booked[p.FacadeID] == nil {
+			booked[p.FacadeID] = map[int64]bool{}
+		}
+		booked[p.FacadeID][c.ID] = true
+	}
+
+	result := map[int64]int{}
+	for id, campaigns := range booked {
+		result[id] = len(campaigns)
+	}
+	return result, nil
+}
+
+// SyncOnlineStatus переводит facades.status по heartbeat и возвращает только смены статуса:
+// сначала ушедшие в offline, затем вернувшиеся online
+func (r *FacadeRepository) SyncOnlineStatus(ctx context.Context, silence time.Duration) ([]models.FacadeTransition, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := r.s.now()
+	since := now.Add(-silence)
+
+	seen := map[int64]time.Time{}
+	for _, hb := range r.s.heartbeats {
+		if hb.At.After(since) && hb.At.After(seen[hb.FacadeID]) {
+			seen[hb.FacadeID] = hb.At
+		}
+	}
+
+	var offline, online []models.FacadeTransition
+	for _, id := range sortedIDs(r.s.facades) {
+		f := r.s.facades[id]
+		last, alive := seen[id]
+		switch {
+		case f.Status == "online" && !alive:
+			f.Status = "offline"
+			f.UpdatedAt = now
+			offline = append(offline, transition(f))
+		case f.Status != "online" && alive:
+			f.Status = "online"
+			f.LastSeen = timePtr(last)
+			f.UpdatedAt = now
+			online = append(online, transition(f))
+		}
+	}
+	return append(append([]models.FacadeTransition{}, offline...), online...), nil
+}
+
+func transition(f *models.Facade) models.FacadeTransition {
+	t := models.FacadeTransition{FacadeID: f.ID, Code: f.Code, Name: f.Name, Status: f.Status}
+	if f.LastSeen != nil {
+		t.LastPingAt = timePtr(*f.LastSeen)
+	}
+	return t
+}
+
+// SetDeviceTokenHash заменяет токен устройства фасада
+func (r *FacadeRepository) SetDeviceTokenHash(ctx context.Context, facadeID int64, hash string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	f, ok := r.s.facades[facadeID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	for id, h := range r.s.deviceTokens {
+		if h == hash && id != facadeID {
+			return ErrDuplicate
+		}
+	}
+	r.s.deviceTokens[facadeID] = hash
+	f.UpdatedAt = r.s.now()
+	return nil
+}
+
+func (r *FacadeRepository) FacadeIDByDeviceTokenHash(ctx context.Context, hash string) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for id, h := range r.s.deviceTokens {
+		if h == hash {
+			return id, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+//
+// ---------- TAGS ----------
+//
+
+type FacadeTagRepository struct {
+	s *Store
+}
+
+func NewFacadeTagRepository(s *Store) *FacadeTagRepository {
+	return &FacadeTagRepository{s: s}
+}
+
+func (r *FacadeTagRepository) GetTags(ctx context.Context, facadeID int64) (map[string]string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	tags := map[string]string{}
+	for k, v := range r.s.facadeTags[facadeID] {
+		tags[k] = v
+	}
+	return tags, nil
+}
+
+func (r *FacadeTagRepository) ReplaceTags(ctx context.Context, facadeID int64, tags map[string]string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.facades[facadeID]; !ok && len(tags) > 0 {
+		return ErrForeignKey
+	}
+	saved := map[string]string{}
+	for k, v := range tags {
+		saved[k] = v
+	}
+	r.s.facadeTags[facadeID] = saved
+	return nil
+}
+
+// ListValues — ключи тегов и их различные значения по возрастанию
+func (r *FacadeTagRepository) ListValues(ctx context.Context) (map[string][]string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	set := map[string]map[string]bool{}
+	for _, tags := range r.s.facadeTags {
+		for k, v := range tags {
+			if set[k] == nil {
+				set[k] = map[string]bool{}
+			}
+			set[k][v] = true
+		}
+	}
+
+	values := map[string][]string{}
+	for k, vs := range set {
+		for v := range vs {
+			values[k] = append(values[k], v)
+		}
+		sort.Strings(values[k])
+	}
+	return values, nil
+}
+
+//
+// ---------- DYNAMIC GROUPS ----------
+//
+
+type FacadeGroupRepository struct {
+	s *Store
+}
+
+func NewFacadeGroupRepository(s *Store) *FacadeGroupRepository {
+	return &FacadeGroupRepository{s: s}
+}
+
+// cloneFilter — фильтр хранится как jsonb, поэтому наружу отдаём копию
+func cloneFilter(f models.FacadeFilter) models.FacadeFilter {
+	if f.Tags == nil {
+		return models.FacadeFilter{}
+	}
+	tags := make(map[string][]string, len(f.Tags))
+	for k, v := range f.Tags {
+		tags[k] = cloneStrings(v)
+	}
+	return models.FacadeFilter{Tags: tags}
+}
+
+func cloneGroup(g *models.DynamicFacadeGroup) models.DynamicFacadeGroup {
+	out := *g
+	out.Filter = cloneFilter(g.Filter)
+	return out
+}
+
+func (r *FacadeGroupRepository) Create(ctx context.Context, g *models.DynamicFacadeGroup) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := r.s.now()
+	g.ID = r.s.nextID("dynamic_facade_groups")
+	g.CreatedAt, g.UpdatedAt = now, now
+
+	row := cloneGroup(g)
+	r.s.groups[g.ID] = &row
+	return nil
+}
+
+func (r *FacadeGroupRepository) GetByID(ctx context.Context, id int64) (*models.DynamicFacadeGroup, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	g, ok := r.s.groups[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	out := cloneGroup(g)
+	return &out, nil
+}
+
+// List — по названию
+func (r *FacadeGroupRepository) List(ctx context.Context) ([]models.DynamicFacadeGroup, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.DynamicFacadeGroup{}
+	for _, g := range r.s.groups {
+		list = append(list, cloneGroup(g))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
+func (r *FacadeGroupRepository) Update(ctx context.Context, g *models.DynamicFacadeGroup) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.groups[g.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	row.Name = g.Name
+	row.Description = g.Description
+	row.Filter = cloneFilter(g.Filter)
+	row.UpdatedAt = r.s.now()
+	g.UpdatedAt = row.UpdatedAt
+	return nil
+}
+
+// Delete — вместе с таргетингом и участиями, добавленными через группу (ON DELETE CASCADE)
+func (r *FacadeGroupRepository) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delete(r.s.groups, id)
+	for key := range r.s.targets {
+		if key.groupID == id {
+			delete(r.s.targets, key)
+		}
+	}
+	for pid, p := range r.s.participation {
+		if p.SourceGroupID != nil && *p.SourceGroupID == id {
+			delete(r.s.participation, pid)
+		}
+	}
+	return nil
+}
+
+// MatchFacades — между ключами AND, внутри ключа OR; по id
+func (r *FacadeGroupRepository) MatchFacades(ctx context.Context, f models.FacadeFilter) ([]int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ids := []int64{}
+	for _, id := range sortedIDs(r.s.facades) {
+		if matchTags(r.s.facadeTags[id], f) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func matchTags(tags map[string]string, f models.FacadeFilter) bool {
+	for key, allowed := range f.Tags {
+		value, ok := tags[key]
+		if !ok {
+			return false
+		}
+		found := false
+		for _, a := range allowed {
+			if a == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *FacadeGroupRepository) AddTarget(ctx context.Context, campaignID, groupID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	_, campaign := r.s.campaigns[campaignID]
+	_, group := r.s.groups[groupID]
+	if !campaign || !group {
+		return ErrForeignKey
+	}
+	key := targetKey{campaignID, groupID}
+	if _, ok := r.s.targets[key]; !ok {
+		r.s.targets[key] = r.s.now()
+	}
+	return nil
+}
+
+// RemoveTarget снимает таргетинг и убирает фасады, добавленные через эту группу
+func (r *FacadeGroupRepository) RemoveTarget(ctx context.Context, campaignID, groupID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delete(r.s.targets, targetKey{campaignID, groupID})
+	for pid, p := range r.s.participation {
+		if p.CampaignID == campaignID && p.SourceGroupID != nil && *p.SourceGroupID == groupID {
+			delete(r.s.participation, pid)
+		}
+	}
+	return nil
+}
+
+func (r *FacadeGroupRepository) ListTargets(ctx context.Context, campaignID int64) ([]models.CampaignGroupTarget, error) {
+	return r.listTargets(func(k targetKey) bool { return k.campaignID == campaignID }), nil
+}
+
+func (r *FacadeGroupRepository) ListAllTargets(ctx context.Context) ([]models.CampaignGroupTarget, error) {
+	return r.listTargets(func(targetKey) bool { return true }), nil
+}
+
+// listTargets — по кампании, затем по названию группы
+func (r *FacadeGroupRepository) listTargets(match func(targetKey) bool) []models.CampaignGroupTarget {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.CampaignGroupTarget{}
+	for key, created := range r.s.targets {
+		g, ok := r.s.groups[key.groupID]
+		if !ok || !match(key) {
+			continue
+		}
+		t := models.CampaignGroupTarget{CampaignID: key.campaignID, GroupID: key.groupID, GroupName: g.Name, CreatedAt: created}
+		for _, p := range r.s.participation {
+			if p.CampaignID == key.campaignID && p.SourceGroupID != nil && *p.SourceGroupID == key.groupID {
+				t.Facades++
+			}
+		}
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CampaignID != list[j].CampaignID {
+			return list[i].CampaignID < list[j].CampaignID
+		}
+		if list[i].GroupName != list[j].GroupName {
+			return list[i].GroupName < list[j].GroupName
+		}
+		return list[i].GroupID < list[j].GroupID
+	})
+	return list
+}
+
+// SyncTarget приводит участие кампании к составу группы. Фасады, привязанные вручную, не трогает.
+func (r *FacadeGroupRepository) SyncTarget(ctx context.Context, campaignID, groupID int64, facadeIDs []int64) (added, removed int64, err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	want := map[int64]bool{}
+	for _, id := range facadeIDs {
+		want[id] = true
+	}
+
+	attached := map[int64]bool{}
+	for pid, p := range r.s.participation {
+		if p.CampaignID != campaignID {
+			continue
+		}
+		if p.SourceGroupID != nil && *p.SourceGroupID == groupID && !want[p.FacadeID] {
+			delete(r.s.participation, pid)
+			removed++
+			continue
+		}
+		attached[p.FacadeID] = true
+	}
+
+	for _, id := range facadeIDs {
+		if attached[id] {
+			continue
+		}
+		attached[id] = true
+		gid := groupID
+		pid := r.s.nextID("campaign_participation")
+		r.s.participation[pid] = &participationRow{
+			ID:            pid,
+			CampaignID:    campaignID,
+			FacadeID:      id,
+			SourceGroupID: &gid,
+			CreatedAt:     r.s.now(),
+		}
+		added++
+	}
+	return added, removed, nil
+}
+
+//
+// ---------- AUDIENCE ----------
+//
+
+type AudienceRepository struct {
+	s *Store
+}
+
+func NewAudienceRepository(s *Store) *AudienceRepository {
+	return &AudienceRepository{s: s}
+}
+
+func cloneProfile(p *models.AudienceProfile) *models.AudienceProfile {
+	out := *p
+	out.Hours = append([]models.AudienceHour{}, p.Hours...)
+	sort.Slice(out.Hours, func(i, j int) bool {
+		if out.Hours[i].Weekday != out.Hours[j].Weekday {
+			return out.Hours[i].Weekday < out.Hours[j].Weekday
+		}
+		return out.Hours[i].Hour < out.Hours[j].Hour
+	})
+	return &out
+}
+
+func (r *AudienceRepository) GetProfile(ctx context.Context, facadeID int64) (*models.AudienceProfile, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	p, ok := r.s.audience[facadeID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return cloneProfile(p), nil
+}
+
+// SaveProfile заменяет профиль целиком
+func (r *AudienceRepository) SaveProfile(ctx context.Context, p *models.AudienceProfile) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.facades[p.FacadeID]; !ok {
+		return ErrForeignKey
+	}
+	p.UpdatedAt = r.s.now()
+	r.s.audience[p.FacadeID] = cloneProfile(p)
+	return nil
+}
+
+// CampaignImpressions — оценка показов по часовым агрегатам, как в SQL-версии:
+//
+//	audience    = footfall(weekday, hour) * visibility_factor
+//	impressions = audience * (airtime_sec + plays * dwell_sec) / 3600
+//	reach       = audience * (1 - exp(-impressions / audience))
+//
+// Часы профиля берутся в его timezone; cost — по последнему активному тарифу фасада.
+func (r *AudienceRepository) CampaignImpressions(ctx context.Context, campaignID int64, from, to time.Time) ([]models.FacadeImpressions, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	byFacade := map[int64]*models.FacadeImpressions{}
+	for key, ru := range r.s.playRollups {
+		if key.granularity != "hour" || key.campaignID != campaignID || key.bucket.Before(from) || !key.bucket.Before(to) {
+			continue
+		}
+
+		var audience, impressions float64
+		if p, ok := r.s.audience[key.facadeID]; ok {
+			loc, err := time.LoadLocation(p.Timezone)
+			if err != nil {
+				return nil, err
+			}
+			at := key.bucket.In(loc)
+			for _, h := range p.Hours {
+				if h.Weekday == int(at.Weekday()) && h.Hour == at.Hour() {
+					audience = h.Footfall * p.VisibilityFactor
+				}
+			}
+			impressions = audience * float64(ru.AirtimeSec+ru.Plays*int64(p.DwellSec)) / 3600
+		}
+
+		fi := byFacade[key.facadeID]
+		if fi == nil {
+			fi = &models.FacadeImpressions{FacadeID: key.facadeID}
+			byFacade[key.facadeID] = fi
+		}
+		fi.Plays += ru.Plays
+		fi.AirtimeSec += ru.AirtimeSec
+		fi.Impressions += impressions
+		if audience > 0 {
+			fi.Reach += audience * (1 - math.Exp(-impressions/audience))
+		}
+	}
+
+	list := []models.FacadeImpressions{}
+	for id, fi := range byFacade {
+		if f, ok := r.s.facades[id]; ok {
+			fi.FacadeName = f.Name
+		}
+		if rc := r.s.rateCard(id); rc != nil {
+			perSecond, perSpot := toFloat(rc.CostPerSecond), toFloat(rc.CostPerSpot)
+			fi.Cost = perSecond*float64(fi.AirtimeSec) + perSpot*float64(fi.Plays)
+			fi.Currency = rc.Currency
+		}
+		list = append(list, *fi)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Impressions != list[j].Impressions {
+			return list[i].Impressions > list[j].Impressions
+		}
+		return list[i].FacadeID < list[j].FacadeID
+	})
+	return list, nil
+}
+
+// rateCard — последний активный тариф фасада
+func (s *Store) rateCard(facadeID int64) *RateCard {
+	var last *RateCard
+	for i := range s.rateCards {
+		rc := &s.rateCards[i]
+		if rc.FacadeID == facadeID && rc.IsActive && (last == nil || !rc.CreatedAt.Before(last.CreatedAt)) {
+			last = rc
+		}
+	}
+	return last
+}
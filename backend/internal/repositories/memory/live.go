@@ -0,0 +1,511 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"mediawork/internal/models"
+)
+
+//
+// ---------- LIVE STREAM ----------
+//
+
+type LiveStreamRepository struct {
+	s *Store
+}
+
+func NewLiveStreamRepository(s *Store) *LiveStreamRepository {
+	return &LiveStreamRepository{s: s}
+}
+
+// RegisterPlayEvent — played_at проставляет хранилище
+func (r *LiveStreamRepository) RegisterPlayEvent(ctx context.Context, event *models.PlayEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.facades[event.FacadeID]; !ok {
+		return ErrForeignKey
+	}
+	event.ID = r.s.nextID("play_history")
+
+	row := *event
+	row.PlayedAt = r.s.now()
+	r.s.plays[event.ID] = &row
+	return nil
+}
+
+// CoveringSlot — слот кампании, в который попадает показ, закончившийся сейчас (время слотов — UTC)
+func (r *LiveStreamRepository) CoveringSlot(ctx context.Context, facadeID, campaignID, slotID int64, durationSec int) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ended := r.s.now().UTC()
+	started := ended.Add(-time.Duration(durationSec) * time.Second)
+	c, ok := r.s.campaigns[campaignID]
+	if !ok || c.Status != "active" || c.BudgetExhaustedAt != nil ||
+		(!c.StartTime.IsZero() && c.StartTime.After(ended)) || (!c.EndTime.IsZero() && c.EndTime.Before(started)) {
+		return 0, sql.ErrNoRows
+	}
+	if !r.s.participates(campaignID, facadeID) {
+		return 0, sql.ErrNoRows
+	}
+
+	clock := func(t time.Time) string { return t.Format("15:04:05") }
+	var best *slotRow
+	for _, id := range sortedIDs(r.s.slots) {
+		sl := r.s.slots[id]
+		switch {
+		case sl.CampaignID != campaignID, slotID != 0 && sl.ID != slotID, sl.Suspended,
+			sl.FacadeID != 0 && sl.FacadeID != facadeID,
+			sl.DayOfWeek != int(ended.Weekday()),
+			sl.StartTime > clock(ended), sl.EndTime < clock(started):
+			continue
+		}
+		if best == nil || sl.Priority > best.Priority {
+			best = sl
+		}
+	}
+	if best == nil {
+		return 0, sql.ErrNoRows
+	}
+	return best.ID, nil
+}
+
+// participates — фасад участвует в кампании (campaign_participation)
+func (s *Store) participates(campaignID, facadeID int64) bool {
+	for _, p := range s.participation {
+		if p.CampaignID == campaignID && p.FacadeID == facadeID {
+			return true
+		}
+	}
+	return false
+}
+
+// playsOf — показы фасада, свежие сверху
+func (s *Store) playsOf(facadeID int64) []models.PlayEvent {
+	events := []models.PlayEvent{}
+	for _, ev := range s.plays {
+		if ev.FacadeID == facadeID {
+			events = append(events, *ev)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].PlayedAt.Equal(events[j].PlayedAt) {
+			return events[i].PlayedAt.After(events[j].PlayedAt)
+		}
+		return events[i].ID > events[j].ID
+	})
+	return events
+}
+
+// GetLastPlayed — nil, если фасад ещё ничего не показывал
+func (r *LiveStreamRepository) GetLastPlayed(ctx context.Context, facadeID int64) (*models.PlayEvent, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	events := r.s.playsOf(facadeID)
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return &events[0], nil
+}
+
+func (r *LiveStreamRepository) RegisterHeartbeat(ctx context.Context, hb *models.Heartbeat) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.facades[hb.FacadeID]; !ok {
+		return ErrForeignKey
+	}
+	r.s.heartbeats = append(r.s.heartbeats, heartbeatRow{Heartbeat: *hb, ID: r.s.nextID("facade_heartbeat"), At: r.s.now()})
+	return nil
+}
+
+// GetFacadeStatus — online, если последний heartbeat был меньше 10 секунд назад
+func (r *LiveStreamRepository) GetFacadeStatus(ctx context.Context, facadeID int64) (*models.FacadeStatus, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var (
+		last  time.Time
+		sum   int64
+		count int64
+	)
+	for _, hb := range r.s.heartbeats {
+		if hb.FacadeID != facadeID {
+			continue
+		}
+		if hb.At.After(last) {
+			last = hb.At
+		}
+		sum += int64(hb.LatencyMS)
+		count++
+	}
+
+	status := &models.FacadeStatus{FacadeID: facadeID}
+	if count > 0 {
+		status.IsOnline = r.s.now().Sub(last) < 10*time.Second
+		status.AvgLatencyMS = float64(sum) / float64(count)
+	}
+	return status, nil
+}
+
+func (r *LiveStreamRepository) GetRecentEvents(ctx context.Context, facadeID int64, limit int) ([]models.PlayEvent, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return page(r.s.playsOf(facadeID), limit, 0), nil
+}
+
+//
+// ---------- ROLLUPS / ARCHIVES ----------
+//
+
+type RollupRepository struct {
+	s *Store
+}
+
+func NewRollupRepository(s *Store) *RollupRepository {
+	return &RollupRepository{s: s}
+}
+
+// truncBy — date_trunc(granularity, t) для поддерживаемых единиц
+func truncBy(granularity string) (func(time.Time) time.Time, error) {
+	switch granularity {
+	case "hour":
+		return func(t time.Time) time.Time { return t.UTC().Truncate(time.Hour) }, nil
+	case "day":
+		return truncDay, nil
+	}
+	return nil, fmt.Errorf("unknown rollup granularity %q", granularity)
+}
+
+// RollupPlayHistory пересчитывает бакеты за [from, to) целиком — запуск идемпотентен
+func (r *RollupRepository) RollupPlayHistory(ctx context.Context, granularity string, from, to time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return r.rollupPlayHistory(granularity, from, to, 0, false)
+}
+
+// rollupPlayHistory — события [from, to) с id <= maxID (0 — без ограничения); merge
+// прибавляет их к существующим бакетам. Вызывается под блокировкой хранилища.
+func (r *RollupRepository) rollupPlayHistory(granularity string, from, to time.Time, maxID int64, merge bool) (int64, error) {
+	trunc, err := truncBy(granularity)
+	if err != nil {
+		return 0, err
+	}
+
+	groups := map[rollupKey]*models.PlayRollup{}
+	for _, ev := range r.s.plays {
+		if ev.PlayedAt.Before(from) || !ev.PlayedAt.Before(to) || (maxID > 0 && ev.ID > maxID) {
+			continue
+		}
+		key := rollupKey{granularity: granularity, bucket: trunc(ev.PlayedAt), facadeID: ev.FacadeID, campaignID: ev.CampaignID}
+		g, ok := groups[key]
+		if !ok {
+			g = &models.PlayRollup{Granularity: granularity, BucketStart: key.bucket, FacadeID: ev.FacadeID, CampaignID: ev.CampaignID}
+			groups[key] = g
+		}
+		g.Plays++
+		g.AirtimeSec += int64(ev.DurationSec)
+		g.BitrateSum += int64(ev.BitrateKbps)
+		g.SyncLatencySum += int64(ev.SyncLatencyMS)
+		if ev.SyncLatencyMS > g.SyncLatencyMax {
+			g.SyncLatencyMax = ev.SyncLatencyMS
+		}
+	}
+
+	for key, g := range groups {
+		if old, ok := r.s.playRollups[key]; ok && merge {
+			g.Plays += old.Plays
+			g.AirtimeSec += old.AirtimeSec
+			g.BitrateSum += old.BitrateSum
+			g.SyncLatencySum += old.SyncLatencySum
+			g.SyncLatencyMax = max(g.SyncLatencyMax, old.SyncLatencyMax)
+		}
+		r.s.playRollups[key] = g
+	}
+	return int64(len(groups)), nil
+}
+
+func (r *RollupRepository) RollupHeartbeats(ctx context.Context, granularity string, from, to time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return r.rollupHeartbeats(granularity, from, to, 0, false)
+}
+
+func (r *RollupRepository) rollupHeartbeats(granularity string, from, to time.Time, maxID int64, merge bool) (int64, error) {
+	trunc, err := truncBy(granularity)
+	if err != nil {
+		return 0, err
+	}
+
+	groups := map[rollupKey]*models.HeartbeatRollup{}
+	for _, hb := range r.s.heartbeats {
+		if hb.At.Before(from) || !hb.At.Before(to) || (maxID > 0 && hb.ID > maxID) {
+			continue
+		}
+		key := rollupKey{granularity: granularity, bucket: trunc(hb.At), facadeID: hb.FacadeID}
+		g, ok := groups[key]
+		if !ok {
+			g = &models.HeartbeatRollup{Granularity: granularity, BucketStart: key.bucket, FacadeID: hb.FacadeID}
+			groups[key] = g
+		}
+		g.Beats++
+		g.LatencySum += int64(hb.LatencyMS)
+		if hb.LatencyMS > g.LatencyMax {
+			g.LatencyMax = hb.LatencyMS
+		}
+	}
+
+	for key, g := range groups {
+		if old, ok := r.s.heartbeatRollups[key]; ok && merge {
+			g.Beats += old.Beats
+			g.LatencySum += old.LatencySum
+			g.LatencyMax = max(g.LatencyMax, old.LatencyMax)
+		}
+		r.s.heartbeatRollups[key] = g
+	}
+	return int64(len(groups)), nil
+}
+
+func (r *RollupRepository) OldestPlayEvent(ctx context.Context) (*time.Time, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var oldest *time.Time
+	for _, ev := range r.s.plays {
+		if oldest == nil || ev.PlayedAt.Before(*oldest) {
+			oldest = timePtr(ev.PlayedAt)
+		}
+	}
+	return oldest, nil
+}
+
+func (r *RollupRepository) OldestHeartbeat(ctx context.Context) (*time.Time, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var oldest *time.Time
+	for _, hb := range r.s.heartbeats {
+		if oldest == nil || hb.At.Before(*oldest) {
+			oldest = timePtr(hb.At)
+		}
+	}
+	return oldest, nil
+}
+
+// PlayHistoryWatermark — наибольший id события за [from, to), 0 — событий нет
+func (r *RollupRepository) PlayHistoryWatermark(ctx context.Context, from, to time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var id int64
+	for _, ev := range r.s.plays {
+		if !ev.PlayedAt.Before(from) && ev.PlayedAt.Before(to) {
+			id = max(id, ev.ID)
+		}
+	}
+	return id, nil
+}
+
+func (r *RollupRepository) HeartbeatWatermark(ctx context.Context, from, to time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var id int64
+	for _, hb := range r.s.heartbeats {
+		if !hb.At.Before(from) && hb.At.Before(to) {
+			id = max(id, hb.ID)
+		}
+	}
+	return id, nil
+}
+
+// ExportPlayHistory отдаёт события [from, to) с id <= maxID по времени; fn вызывается под блокировкой хранилища
+func (r *RollupRepository) ExportPlayHistory(ctx context.Context, from, to time.Time, maxID int64, fn func(ev *models.PlayEvent) error) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	events := []models.PlayEvent{}
+	for _, ev := range r.s.plays {
+		if !ev.PlayedAt.Before(from) && ev.PlayedAt.Before(to) && ev.ID <= maxID {
+			events = append(events, *ev)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].PlayedAt.Equal(events[j].PlayedAt) {
+			return events[i].PlayedAt.Before(events[j].PlayedAt)
+		}
+		return events[i].ID < events[j].ID
+	})
+
+	var n int64
+	for i := range events {
+		if err := fn(&events[i]); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (r *RollupRepository) ExportHeartbeats(ctx context.Context, from, to time.Time, maxID int64, fn func(hb *models.Heartbeat, at time.Time) error) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	beats := []heartbeatRow{}
+	for _, hb := range r.s.heartbeats {
+		if !hb.At.Before(from) && hb.At.Before(to) && hb.ID <= maxID {
+			beats = append(beats, hb)
+		}
+	}
+	sort.Slice(beats, func(i, j int) bool {
+		if !beats[i].At.Equal(beats[j].At) {
+			return beats[i].At.Before(beats[j].At)
+		}
+		return beats[i].ID < beats[j].ID
+	})
+
+	var n int64
+	for i := range beats {
+		if err := fn(&beats[i].Heartbeat, beats[i].At); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// LastArchivePart — номер последней выгруженной части суток, 0 — сутки ещё не выгружались
+func (r *RollupRepository) LastArchivePart(ctx context.Context, source string, periodStart time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	part := 0
+	for _, a := range r.s.archives {
+		if a.Source == source && a.PeriodStart.Equal(periodStart) {
+			part = max(part, a.Part)
+		}
+	}
+	return part, nil
+}
+
+// CommitPlayHistoryArchive — как в PostgreSQL: свёртка части, удаление её строк и запись
+// в каталог под одной блокировкой; при расхождении числа строк ничего не меняется
+func (r *RollupRepository) CommitPlayHistoryArchive(ctx context.Context, a *models.PlayHistoryArchive, maxID int64) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var ids []int64
+	for id, ev := range r.s.plays {
+		if !ev.PlayedAt.Before(a.PeriodStart) && ev.PlayedAt.Before(a.PeriodEnd) && ev.ID <= maxID {
+			ids = append(ids, id)
+		}
+	}
+	if err := r.checkArchive(a, int64(len(ids))); err != nil {
+		return 0, err
+	}
+
+	for _, granularity := range []string{"hour", "day"} {
+		if _, err := r.rollupPlayHistory(granularity, a.PeriodStart, a.PeriodEnd, maxID, a.Part > 1); err != nil {
+			return 0, err
+		}
+	}
+	for _, id := range ids {
+		delete(r.s.plays, id)
+	}
+	r.insertArchive(a)
+	return int64(len(ids)), nil
+}
+
+func (r *RollupRepository) CommitHeartbeatArchive(ctx context.Context, a *models.PlayHistoryArchive, maxID int64) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	inPart := func(hb heartbeatRow) bool {
+		return !hb.At.Before(a.PeriodStart) && hb.At.Before(a.PeriodEnd) && hb.ID <= maxID
+	}
+	var n int64
+	for _, hb := range r.s.heartbeats {
+		if inPart(hb) {
+			n++
+		}
+	}
+	if err := r.checkArchive(a, n); err != nil {
+		return 0, err
+	}
+
+	for _, granularity := range []string{"hour", "day"} {
+		if _, err := r.rollupHeartbeats(granularity, a.PeriodStart, a.PeriodEnd, maxID, a.Part > 1); err != nil {
+			return 0, err
+		}
+	}
+	kept := r.s.heartbeats[:0]
+	for _, hb := range r.s.heartbeats {
+		if !inPart(hb) {
+			kept = append(kept, hb)
+		}
+	}
+	r.s.heartbeats = kept
+	r.insertArchive(a)
+	return n, nil
+}
+
+// checkArchive — число строк части и UNIQUE (source, period_start, part) / UNIQUE (file_path)
+func (r *RollupRepository) checkArchive(a *models.PlayHistoryArchive, rows int64) error {
+	if rows != a.RowCount {
+		return fmt.Errorf("%s %s: exported %d rows, pruning %d", a.Source, a.PeriodStart.Format("2006-01-02"), a.RowCount, rows)
+	}
+	for _, ex := range r.s.archives {
+		if ex.FilePath == a.FilePath ||
+			(ex.Source == a.Source && ex.PeriodStart.Equal(a.PeriodStart) && ex.Part == a.Part) {
+			return ErrDuplicate
+		}
+	}
+	return nil
+}
+
+func (r *RollupRepository) insertArchive(a *models.PlayHistoryArchive) {
+	a.ID = r.s.nextID("play_history_archives")
+	a.CreatedAt = r.s.now()
+	row := *a
+	r.s.archives[a.ID] = &row
+}
+
+// ListArchives — свежие периоды сверху; periodBefore ограничивает period_end
+func (r *RollupRepository) ListArchives(ctx context.Context, periodBefore *time.Time) ([]models.PlayHistoryArchive, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.PlayHistoryArchive{}
+	for _, a := range r.s.archives {
+		if periodBefore == nil || a.PeriodEnd.Before(*periodBefore) {
+			list = append(list, *a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].PeriodStart.Equal(list[j].PeriodStart) {
+			return list[i].PeriodStart.After(list[j].PeriodStart)
+		}
+		if list[i].Part != list[j].Part {
+			return list[i].Part > list[j].Part
+		}
+		return list[i].ID > list[j].ID
+	})
+	return list, nil
+}
+
+func (r *RollupRepository) DeleteArchive(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delete(r.s.archives, id)
+	return nil
+}
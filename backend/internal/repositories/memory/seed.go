@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+)
+
+//
+// ---------- FIXTURES ----------
+//
+// таблицы, которые в PostgreSQL наполняются миграциями / админкой, а не через сервисы
+
+// AddFacade регистрирует фасад (id назначается хранилищем)
+func (s *Store) AddFacade(f *models.Facade) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	f.ID = s.nextID("facades")
+	f.CreatedAt, f.UpdatedAt = now, now
+	if f.Status == "" {
+		f.Status = "offline"
+	}
+	row := *f
+	s.facades[f.ID] = &row
+}
+
+// AddRateCard добавляет тариф фасада; действует последний активный
+func (s *Store) AddRateCard(rc RateCard) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rc.CreatedAt.IsZero() {
+		rc.CreatedAt = s.now()
+	}
+	s.rateCards = append(s.rateCards, rc)
+}
+
+// AddParticipation ставит кампанию на фасад напрямую (без динамической группы)
+func (s *Store) AddParticipation(campaignID, facadeID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID("campaign_participation")
+	s.participation[id] = &participationRow{
+		ID:         id,
+		CampaignID: campaignID,
+		FacadeID:   facadeID,
+		CreatedAt:  s.now(),
+	}
+}
+
+//
+// ---------- DEMO DATA ----------
+//
+
+// Учётная запись администратора демо-режима
+const (
+	DemoAdminEmail    = "admin@demo.local"
+	DemoAdminPassword = "demo"
+)
+
+// Seed наполняет пустое хранилище демо-данными: администратор, компания,
+// три фасада с тарифами и идущая кампания с недельной сеткой слотов
+func Seed(ctx context.Context, s *Store) error {
+	repos := s.Repositories()
+	now := s.now()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(DemoAdminPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	admin := &models.User{
+		Email:        DemoAdminEmail,
+		FullName:     "Demo Admin",
+		Role:         "admin",
+		PasswordHash: string(hash),
+	}
+	if _, err := repos.Users.Create(ctx, admin); err != nil {
+		return err
+	}
+
+	company := &models.Company{Name: "Demo Media", OwnerID: admin.ID, IsActive: true}
+	if err := repos.Companies.Create(ctx, company); err != nil {
+		return err
+	}
+	if err := repos.Memberships.AddMember(ctx, company.ID, admin.ID, "owner"); err != nil {
+		return err
+	}
+	if err := repos.Companies.UpdateBilling(ctx, &models.CompanyBilling{
+		CompanyID:       company.ID,
+		Currency:        "RUB",
+		TaxJurisdiction: "RU",
+	}); err != nil {
+		return err
+	}
+	if err := repos.TaxRates.Create(ctx, &models.TaxRate{
+		Jurisdiction: "RU",
+		Name:         "НДС",
+		Rate:         money.NewFromInt(20),
+		ValidFrom:    time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+	}); err != nil {
+		return err
+	}
+
+	facades := []struct {
+		facade models.Facade
+		tags   map[string]string
+		spot   string
+	}{
+		{models.Facade{Code: "MSK-001", Name: "Тверская, 7", Address: "Москва, Тверская ул., 7", Latitude: 55.7579, Longitude: 37.6136, WidthPx: 1920, HeightPx: 1080, Rows: 1, Cols: 1},
+			map[string]string{"city": "moscow", "district": "center"}, "150"},
+		{models.Facade{Code: "MSK-002", Name: "Новый Арбат, 15", Address: "Москва, ул. Новый Арбат, 15", Latitude: 55.7527, Longitude: 37.5872, WidthPx: 3840, HeightPx: 1080, Rows: 1, Cols: 2},
+			map[string]string{"city": "moscow", "district": "arbat"}, "120"},
+		{models.Facade{Code: "SPB-001", Name: "Невский, 28", Address: "Санкт-Петербург, Невский пр., 28", Latitude: 59.9358, Longitude: 30.3259, WidthPx: 1920, HeightPx: 1080, Rows: 1, Cols: 1},
+			map[string]string{"city": "spb", "district": "center"}, "100"},
+	}
+
+	campaign := &models.Campaign{
+		CompanyID:   company.ID,
+		Name:        "Demo campaign",
+		Description: "demo",
+		StartTime:   truncDay(now).AddDate(0, 0, -1),
+		EndTime:     truncDay(now).AddDate(0, 1, 0),
+		Status:      "active",
+	}
+	if _, err := repos.Campaigns.Create(ctx, campaign); err != nil {
+		return err
+	}
+
+	for _, fx := range facades {
+		f := fx.facade
+		s.AddFacade(&f)
+		if err := repos.FacadeTags.ReplaceTags(ctx, f.ID, fx.tags); err != nil {
+			return err
+		}
+		s.AddRateCard(RateCard{
+			FacadeID:      f.ID,
+			CostPerSpot:   money.MustParse(fx.spot),
+			CostPerSecond: money.MustParse("2.5"),
+			Currency:      "RUB",
+			IsActive:      true,
+		})
+		s.AddParticipation(campaign.ID, f.ID)
+	}
+
+	// слоты на все фасады кампании: будни утром и вечером, выходные днём
+	for day := 0; day < 7; day++ {
+		windows := [][2]string{{"08:00:00", "11:00:00"}, {"17:00:00", "21:00:00"}}
+		if day == 0 || day == 6 {
+			windows = [][2]string{{"11:00:00", "20:00:00"}}
+		}
+		for _, w := range windows {
+			slot := &models.CampaignSlot{CampaignID: campaign.ID, DayOfWeek: day, StartTime: w[0], EndTime: w[1]}
+			if _, err := repos.CampaignSlot.Create(ctx, slot); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+// Package memory — хранилища сервисов в памяти процесса. Повторяют семантику запросов
+// PostgreSQL-репозиториев (сортировки, ON CONFLICT, sql.ErrNoRows), но ничего не сохраняют
+// между запусками. Используются в тестах и в демо-режиме без базы.
+package memory
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/services"
+)
+
+// Ошибки ограничений, которые в PostgreSQL проверяет сама база; SQLState — как у pq.Error,
+// чтобы HTTP-слой отвечал на них так же, как на ошибки базы
+var (
+	ErrDuplicate  error = &constraintError{code: "23505", msg: "duplicate key value violates unique constraint"}
+	ErrForeignKey error = &constraintError{code: "23503", msg: "insert or update violates foreign key constraint"}
+)
+
+type constraintError struct {
+	code string
+	msg  string
+}
+
+func (e *constraintError) Error() string    { return e.msg }
+func (e *constraintError) SQLState() string { return e.code }
+
+// Store — общие «таблицы» всех репозиториев. Один мьютекс на всё хранилище:
+// каждый метод репозитория выполняется целиком под ним, как транзакция.
+type Store struct {
+	mu  sync.Mutex
+	ids map[string]int64
+
+	// Now — текущее время; тесты могут подменить, чтобы управлять сроками
+	Now func() time.Time
+
+	users               map[int64]*models.User
+	companies           map[int64]*companyRow
+	memberships         map[memberKey]*memberRow
+	invitations         map[int64]*invitationRow
+	suspensions         map[int64]*models.CompanySuspension
+	suspensionCampaigns map[int64][]suspendedRow
+	apiKeys             map[int64]*apiKeyRow
+	audit               []models.AuditEntry
+
+	facades       map[int64]*models.Facade
+	facadeTags    map[int64]map[string]string
+	deviceTokens  map[int64]string // фасад → sha256 токена устройства
+	groups        map[int64]*models.DynamicFacadeGroup
+	targets       map[targetKey]time.Time
+	participation map[int64]*participationRow
+	audience      map[int64]*models.AudienceProfile
+	rateCards     []RateCard
+
+	campaigns map[int64]*campaignRow
+	slots     map[int64]*slotRow
+	creatives map[int64]*models.Creative
+
+	plays            map[int64]*models.PlayEvent
+	heartbeats       []heartbeatRow
+	playRollups      map[rollupKey]*models.PlayRollup
+	heartbeatRollups map[rollupKey]*models.HeartbeatRollup
+	archives         map[int64]*models.PlayHistoryArchive
+
+	invoices      map[int64]*invoiceRow
+	invoiceLines  map[int64][]models.InvoiceLine
+	sequences     map[sequenceKey]int64
+	payments      map[int64]*models.Payment
+	reminders     map[reminderKey]time.Time
+	exports       map[int64]*models.AccountingExport
+	taxRates      map[int64]*models.TaxRate
+	exchangeRates map[int64]*models.ExchangeRate
+
+	budgets  map[int64]*models.Budget
+	wallets  map[int64]*models.Wallet
+	walletTx []models.WalletTransaction
+
+	endpoints    map[int64]*models.WebhookEndpoint
+	events       map[int64]*models.WebhookEvent
+	deliveries   map[int64]*models.WebhookDelivery
+	attempts     []models.WebhookAttempt
+	playCounters map[int64]int64
+}
+
+func NewStore() *Store {
+	return &Store{
+		ids: map[string]int64{},
+		Now: time.Now,
+
+		users:               map[int64]*models.User{},
+		companies:           map[int64]*companyRow{},
+		memberships:         map[memberKey]*memberRow{},
+		invitations:         map[int64]*invitationRow{},
+		suspensions:         map[int64]*models.CompanySuspension{},
+		suspensionCampaigns: map[int64][]suspendedRow{},
+		apiKeys:             map[int64]*apiKeyRow{},
+
+		facades:       map[int64]*models.Facade{},
+		facadeTags:    map[int64]map[string]string{},
+		deviceTokens:  map[int64]string{},
+		groups:        map[int64]*models.DynamicFacadeGroup{},
+		targets:       map[targetKey]time.Time{},
+		participation: map[int64]*participationRow{},
+		audience:      map[int64]*models.AudienceProfile{},
+
+		campaigns: map[int64]*campaignRow{},
+		slots:     map[int64]*slotRow{},
+		creatives: map[int64]*models.Creative{},
+
+		plays:            map[int64]*models.PlayEvent{},
+		playRollups:      map[rollupKey]*models.PlayRollup{},
+		heartbeatRollups: map[rollupKey]*models.HeartbeatRollup{},
+		archives:         map[int64]*models.PlayHistoryArchive{},
+
+		invoices:      map[int64]*invoiceRow{},
+		invoiceLines:  map[int64][]models.InvoiceLine{},
+		sequences:     map[sequenceKey]int64{},
+		payments:      map[int64]*models.Payment{},
+		reminders:     map[reminderKey]time.Time{},
+		exports:       map[int64]*models.AccountingExport{},
+		taxRates:      map[int64]*models.TaxRate{},
+		exchangeRates: map[int64]*models.ExchangeRate{},
+
+		budgets: map[int64]*models.Budget{},
+		wallets: map[int64]*models.Wallet{},
+
+		endpoints:    map[int64]*models.WebhookEndpoint{},
+		events:       map[int64]*models.WebhookEvent{},
+		deliveries:   map[int64]*models.WebhookDelivery{},
+		playCounters: map[int64]int64{},
+	}
+}
+
+// nextID — аналог BIGSERIAL: своя последовательность у каждой таблицы
+func (s *Store) nextID(table string) int64 {
+	s.ids[table]++
+	return s.ids[table]
+}
+
+func (s *Store) now() time.Time {
+	return s.Now()
+}
+
+//
+// ---------- ROWS ----------
+//
+// строки с колонками, которых нет в моделях
+
+type companyRow struct {
+	models.Company
+	Billing   models.CompanyBilling
+	LegalName string
+	VATNumber string
+}
+
+type memberKey struct{ companyID, userID int64 }
+
+type memberRow struct {
+	Role      string
+	CreatedAt time.Time
+}
+
+type invitationRow struct {
+	models.CompanyInvitation
+	TokenHash string
+}
+
+type suspendedRow struct {
+	CampaignID int64
+	PrevStatus string
+}
+
+type apiKeyRow struct {
+	models.APIKey
+	Hash string
+}
+
+type targetKey struct{ campaignID, groupID int64 }
+
+type participationRow struct {
+	ID            int64
+	CampaignID    int64
+	FacadeID      int64
+	SourceGroupID *int64
+	CreatedAt     time.Time
+}
+
+// RateCard — тариф фасада (rate_cards); в демо-режиме задаётся сидом
+type RateCard struct {
+	FacadeID      int64
+	CostPerSpot   money.Decimal
+	CostPerSecond money.Decimal
+	Currency      string
+	IsActive      bool
+	CreatedAt     time.Time
+}
+
+type campaignRow struct {
+	models.Campaign
+	MediaURL          string
+	BudgetExhaustedAt *time.Time
+}
+
+type slotRow struct {
+	models.CampaignSlot
+	Suspended bool
+}
+
+type heartbeatRow struct {
+	models.Heartbeat
+	ID int64
+	At time.Time
+}
+
+type rollupKey struct {
+	granularity string
+	bucket      time.Time
+	facadeID    int64
+	campaignID  int64
+}
+
+type invoiceRow struct {
+	models.Invoice
+	Refs models.AdjustmentRefs
+}
+
+type sequenceKey struct {
+	companyID int64
+	docType   string
+}
+
+type reminderKey struct {
+	invoiceID  int64
+	offsetDays int
+}
+
+//
+// ---------- HELPERS ----------
+//
+
+func cloneStrings(v []string) []string {
+	if v == nil {
+		return nil
+	}
+	return append([]string{}, v...)
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
+
+// toFloat — NUMERIC::float8
+func toFloat(d money.Decimal) float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// page — LIMIT / OFFSET над уже отсортированным срезом
+func page[T any](list []T, limit, offset int) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(list) {
+		return []T{}
+	}
+	list = list[offset:]
+	if limit >= 0 && limit < len(list) {
+		list = list[:limit]
+	}
+	return list
+}
+
+// sortedIDs — ключи map по возрастанию (детерминированный обход)
+func sortedIDs[T any](m map[int64]T) []int64 {
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// truncDay — date_trunc('day', t) в UTC
+func truncDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Repositories — все хранилища поверх одного Store
+func (s *Store) Repositories() services.Repositories {
+	return services.Repositories{
+		Users:            NewUserRepository(s),
+		Companies:        NewCompanyRepository(s),
+		Memberships:      NewCompanyMembershipRepository(s),
+		Invitations:      NewCompanyInvitationRepository(s),
+		CompanyLifecycle: NewCompanyLifecycleRepository(s),
+		APIKeys:          NewAPIKeyRepository(s),
+		Audit:            NewAuditRepository(s),
+		Facades:          NewFacadeRepository(s),
+		FacadeTags:       NewFacadeTagRepository(s),
+		FacadeGroups:     NewFacadeGroupRepository(s),
+		Audience:         NewAudienceRepository(s),
+		Campaigns:        NewCampaignRepository(s),
+		Creatives:        NewCreativeRepository(s),
+		CampaignSlot:     NewCampaignSlotRepository(s),
+		CampaignSlots:    NewCampaignSlotsRepository(s),
+		Analytics:        NewAnalyticsRepository(s),
+		LiveStream:       NewLiveStreamRepository(s),
+		Rollups:          NewRollupRepository(s),
+		Invoices:         NewInvoiceRepository(s),
+		Payments:         NewPaymentRepository(s),
+		Dunning:          NewDunningRepository(s),
+		TaxRates:         NewTaxRateRepository(s),
+		ExchangeRates:    NewExchangeRateRepository(s),
+		Budgets:          NewBudgetRepository(s),
+		Webhooks:         NewWebhookRepository(s),
+	}
+}
@@ -0,0 +1,895 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/repositories"
+)
+
+//
+// ---------- USERS ----------
+//
+
+type UserRepository struct {
+	s *Store
+}
+
+func NewUserRepository(s *Store) *UserRepository {
+	return &UserRepository{s: s}
+}
+
+func (r *UserRepository) Create(ctx context.Context, u *models.User) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.users {
+		if existing.Email == u.Email {
+			return 0, ErrDuplicate
+		}
+	}
+
+	now := r.s.now()
+	u.ID = r.s.nextID("users")
+	u.IsActive = true
+	u.CreatedAt, u.UpdatedAt = now, now
+	u.Name = u.FullName
+
+	row := *u
+	r.s.users[u.ID] = &row
+	return u.ID, nil
+}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, id int64, role string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if u, ok := r.s.users[id]; ok {
+		u.Role = role
+		u.UpdatedAt = r.s.now()
+	}
+	return nil
+}
+
+func (r *UserRepository) SetActive(ctx context.Context, id int64, active bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	u, ok := r.s.users[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	u.IsActive = active
+	u.UpdatedAt = r.s.now()
+	return nil
+}
+
+func (r *UserRepository) IsActive(ctx context.Context, id int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	u, ok := r.s.users[id]
+	if !ok {
+		return false, sql.ErrNoRows
+	}
+	return u.IsActive, nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, u *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.users[u.ID]
+	if !ok {
+		return nil
+	}
+	for id, other := range r.s.users {
+		if id != u.ID && other.Email == u.Email {
+			return ErrDuplicate
+		}
+	}
+	row.Email = u.Email
+	row.FullName = u.FullName
+	row.Name = u.FullName
+	row.Role = u.Role
+	row.UpdatedAt = r.s.now()
+	return nil
+}
+
+// GetByID — без хэша пароля, как и SQL-версия
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	u, ok := r.s.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	out := *u
+	out.PasswordHash = ""
+	return &out, nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, u := range r.s.users {
+		if u.Email == email {
+			out := *u
+			return &out, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// List — страница пользователей по repositories.UserList
+func (r *UserRepository) List(ctx context.Context, q models.ListQuery) ([]models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := make([]models.User, 0, len(r.s.users))
+	for _, u := range r.s.users {
+		out := *u
+		out.PasswordHash = ""
+		list = append(list, out)
+	}
+	return repositories.UserList.Apply(list, q)
+}
+
+//
+// ---------- COMPANIES ----------
+//
+
+type CompanyRepository struct {
+	s *Store
+}
+
+func NewCompanyRepository(s *Store) *CompanyRepository {
+	return &CompanyRepository{s: s}
+}
+
+func (r *CompanyRepository) Create(ctx context.Context, c *models.Company) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c.ID = r.s.nextID("companies")
+	c.CreatedAt = r.s.now()
+	r.s.companies[c.ID] = &companyRow{
+		Company: models.Company{ID: c.ID, Name: c.Name, OwnerID: c.OwnerID, IsActive: c.IsActive, CreatedAt: c.CreatedAt},
+		Billing: models.CompanyBilling{CompanyID: c.ID, Currency: "RUB"},
+	}
+	return nil
+}
+
+func (r *CompanyRepository) GetByID(ctx context.Context, id int64) (*models.Company, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.companies[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	out := c.Company
+	return &out, nil
+}
+
+// List — страница компаний по repositories.CompanyList
+func (r *CompanyRepository) List(ctx context.Context, q models.ListQuery) ([]models.Company, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := make([]models.Company, 0, len(r.s.companies))
+	for _, c := range r.s.companies {
+		list = append(list, c.Company)
+	}
+	return repositories.CompanyList.Apply(list, q)
+}
+
+func (r *CompanyRepository) Update(ctx context.Context, c *models.Company) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if row, ok := r.s.companies[c.ID]; ok {
+		row.Name = c.Name
+		row.OwnerID = c.OwnerID
+		row.IsActive = c.IsActive
+	}
+	return nil
+}
+
+func (r *CompanyRepository) GetBilling(ctx context.Context, id int64) (*models.CompanyBilling, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.companies[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	b := c.Billing
+	return &b, nil
+}
+
+func (r *CompanyRepository) UpdateBilling(ctx context.Context, b *models.CompanyBilling) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.companies[b.CompanyID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	c.Billing = *b
+	return nil
+}
+
+//
+// ---------- MEMBERSHIPS ----------
+//
+
+type CompanyMembershipRepository struct {
+	s *Store
+}
+
+func NewCompanyMembershipRepository(s *Store) *CompanyMembershipRepository {
+	return &CompanyMembershipRepository{s: s}
+}
+
+func (r *CompanyMembershipRepository) AddMember(ctx context.Context, companyID int64, userID int64, role string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.upsertMember(companyID, userID, role)
+	return nil
+}
+
+func (s *Store) upsertMember(companyID, userID int64, role string) {
+	key := memberKey{companyID, userID}
+	if m, ok := s.memberships[key]; ok {
+		m.Role = role
+		return
+	}
+	s.memberships[key] = &memberRow{Role: role, CreatedAt: s.now()}
+}
+
+func (r *CompanyMembershipRepository) RemoveMember(ctx context.Context, companyID int64, userID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delete(r.s.memberships, memberKey{companyID, userID})
+	return nil
+}
+
+func (r *CompanyMembershipRepository) GetUserRole(ctx context.Context, companyID int64, userID int64) (string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	m, ok := r.s.memberships[memberKey{companyID, userID}]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return m.Role, nil
+}
+
+func (r *CompanyMembershipRepository) ListMembers(ctx context.Context, companyID int64) ([]models.CompanyMember, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	members := []models.CompanyMember{}
+	for key, m := range r.s.memberships {
+		u, ok := r.s.users[key.userID]
+		if key.companyID != companyID || !ok {
+			continue
+		}
+		members = append(members, models.CompanyMember{UserID: u.ID, Email: u.Email, Name: u.FullName, Role: m.Role})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Name != members[j].Name {
+			return members[i].Name < members[j].Name
+		}
+		return members[i].UserID < members[j].UserID
+	})
+	return members, nil
+}
+
+func (r *CompanyMembershipRepository) ListUserMemberships(ctx context.Context, userID int64) ([]models.UserCompany, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.UserCompany{}
+	for key, m := range r.s.memberships {
+		c, ok := r.s.companies[key.companyID]
+		if key.userID != userID || !ok {
+			continue
+		}
+		list = append(list, models.UserCompany{CompanyID: c.ID, Name: c.Name, Role: m.Role, IsActive: c.IsActive})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].CompanyID < list[j].CompanyID
+	})
+	return list, nil
+}
+
+// TransferOwnership: новый владелец — owner, прежний — admin, companies.owner_id меняется
+func (r *CompanyMembershipRepository) TransferOwnership(ctx context.Context, companyID int64, fromUserID int64, toUserID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	to, ok := r.s.memberships[memberKey{companyID, toUserID}]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if from, ok := r.s.memberships[memberKey{companyID, fromUserID}]; ok {
+		from.Role = "admin"
+	}
+	to.Role = "owner"
+	if c, ok := r.s.companies[companyID]; ok {
+		c.OwnerID = toUserID
+	}
+	return nil
+}
+
+//
+// ---------- INVITATIONS ----------
+//
+
+type CompanyInvitationRepository struct {
+	s *Store
+}
+
+func NewCompanyInvitationRepository(s *Store) *CompanyInvitationRepository {
+	return &CompanyInvitationRepository{s: s}
+}
+
+// Create отзывает прежнее ожидающее приглашение на тот же email в ту же компанию
+func (r *CompanyInvitationRepository) Create(ctx context.Context, inv *models.CompanyInvitation, tokenHash string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, row := range r.s.invitations {
+		if row.TokenHash == tokenHash {
+			return ErrDuplicate
+		}
+	}
+
+	now := r.s.now()
+	for _, row := range r.s.invitations {
+		if row.CompanyID == inv.CompanyID && strings.EqualFold(row.Email, inv.Email) && row.Status == "pending" {
+			row.Status = "revoked"
+			row.RespondedAt = timePtr(now)
+		}
+	}
+
+	inv.ID = r.s.nextID("company_invitations")
+	inv.Status = "pending"
+	inv.CreatedAt = now
+
+	row := &invitationRow{CompanyInvitation: *inv, TokenHash: tokenHash}
+	row.Token, row.CompanyName, row.RespondedAt = "", "", nil
+	r.s.invitations[inv.ID] = row
+	return nil
+}
+
+func (s *Store) invitation(row *invitationRow) (models.CompanyInvitation, bool) {
+	c, ok := s.companies[row.CompanyID]
+	if !ok {
+		return models.CompanyInvitation{}, false
+	}
+	inv := row.CompanyInvitation
+	inv.CompanyName = c.Name
+	return inv, true
+}
+
+func (r *CompanyInvitationRepository) GetByID(ctx context.Context, id int64) (*models.CompanyInvitation, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if row, ok := r.s.invitations[id]; ok {
+		if inv, ok := r.s.invitation(row); ok {
+			return &inv, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *CompanyInvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.CompanyInvitation, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, row := range r.s.invitations {
+		if row.TokenHash != tokenHash {
+			continue
+		}
+		if inv, ok := r.s.invitation(row); ok {
+			return &inv, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *CompanyInvitationRepository) ListByCompany(ctx context.Context, companyID int64) ([]models.CompanyInvitation, error) {
+	return r.list(func(inv *invitationRow) bool { return inv.CompanyID == companyID }), nil
+}
+
+func (r *CompanyInvitationRepository) ListPendingByEmail(ctx context.Context, email string, now time.Time) ([]models.CompanyInvitation, error) {
+	return r.list(func(inv *invitationRow) bool {
+		return strings.EqualFold(inv.Email, email) && inv.Status == "pending" && inv.ExpiresAt.After(now)
+	}), nil
+}
+
+// list — приглашения по условию, новые сверху
+func (r *CompanyInvitationRepository) list(match func(*invitationRow) bool) []models.CompanyInvitation {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.CompanyInvitation{}
+	for _, row := range r.s.invitations {
+		if !match(row) {
+			continue
+		}
+		if inv, ok := r.s.invitation(row); ok {
+			list = append(list, inv)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.After(list[j].CreatedAt)
+		}
+		return list[i].ID > list[j].ID
+	})
+	return list
+}
+
+func (r *CompanyInvitationRepository) SetStatus(ctx context.Context, id int64, status string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.invitations[id]
+	if !ok || row.Status != "pending" {
+		return false, nil
+	}
+	row.Status = status
+	row.RespondedAt = timePtr(r.s.now())
+	return true, nil
+}
+
+// Accept принимает приглашение и добавляет участника; существующую роль не понижает
+func (r *CompanyInvitationRepository) Accept(ctx context.Context, inv *models.CompanyInvitation, userID int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.invitations[inv.ID]
+	if !ok || row.Status != "pending" {
+		return false, nil
+	}
+	row.Status = "accepted"
+	row.RespondedAt = timePtr(r.s.now())
+
+	current, member := r.s.memberships[memberKey{inv.CompanyID, userID}]
+	switch {
+	case !member:
+		r.s.upsertMember(inv.CompanyID, userID, inv.Role)
+	case repositories.CompanyRoleRank(inv.Role) > repositories.CompanyRoleRank(current.Role):
+		current.Role = inv.Role
+	}
+	return true, nil
+}
+
+//
+// ---------- LIFECYCLE ----------
+//
+
+// CompanyLifecycleRepository — деактивация и пауза за просрочку; что поставлено на паузу,
+// запоминается в приостановке, чтобы снятие вернуло ровно это состояние
+type CompanyLifecycleRepository struct {
+	s *Store
+}
+
+func NewCompanyLifecycleRepository(s *Store) *CompanyLifecycleRepository {
+	return &CompanyLifecycleRepository{s: s}
+}
+
+// campaignRunning — статус из runningCampaignStatuses
+func campaignRunning(status string) bool {
+	return status == "active" || status == "scheduled" || status == "live"
+}
+
+// notEnded — end_at IS NULL OR end_at > now (нулевое время — NULL)
+func notEnded(c *campaignRow, now time.Time) bool {
+	return c.EndTime.IsZero() || c.EndTime.After(now)
+}
+
+func (s *Store) suspend(companyID int64, kind, reason string, actorID *int64) *models.CompanySuspension {
+	now := s.now()
+	sp := &models.CompanySuspension{
+		ID:          s.nextID("company_suspensions"),
+		CompanyID:   companyID,
+		Kind:        kind,
+		Reason:      reason,
+		SuspendedBy: actorID,
+		SuspendedAt: now,
+	}
+
+	paused := []suspendedRow{}
+	for _, id := range sortedIDs(s.campaigns) {
+		c := s.campaigns[id]
+		if c.CompanyID != companyID || !campaignRunning(c.Status) || !notEnded(c, now) {
+			continue
+		}
+		paused = append(paused, suspendedRow{CampaignID: c.ID, PrevStatus: c.Status})
+		c.Status = "paused"
+	}
+	sp.PausedCampaigns = len(paused)
+
+	for _, pc := range paused {
+		for _, slot := range s.slots {
+			if slot.CampaignID == pc.CampaignID && !slot.Suspended {
+				slot.Suspended = true
+				sp.SuspendedSlots++
+			}
+		}
+	}
+
+	s.suspensions[sp.ID] = sp
+	s.suspensionCampaigns[sp.ID] = paused
+	out := *sp
+	return &out
+}
+
+// restore закрывает последнюю открытую приостановку вида kind; nil — открытой нет.
+// При открытой приостановке другого вида кампании остаются на паузе и переходят к ней.
+func (s *Store) restore(companyID int64, kind string, actorID *int64) *models.CompanySuspension {
+	var open *models.CompanySuspension
+	for _, sp := range s.suspensions {
+		if sp.CompanyID != companyID || sp.Kind != kind || sp.ReactivatedAt != nil {
+			continue
+		}
+		if open == nil || sp.SuspendedAt.After(open.SuspendedAt) {
+			open = sp
+		}
+	}
+	if open == nil {
+		return nil
+	}
+
+	now := s.now()
+	open.ReactivatedBy = actorID
+	open.ReactivatedAt = timePtr(now)
+
+	var heldBy *models.CompanySuspension
+	for _, sp := range s.suspensions {
+		if sp.CompanyID != companyID || sp.ReactivatedAt != nil {
+			continue
+		}
+		if heldBy == nil || sp.SuspendedAt.After(heldBy.SuspendedAt) {
+			heldBy = sp
+		}
+	}
+	if heldBy != nil {
+		s.hand(open.ID, heldBy)
+		out := *open
+		return &out
+	}
+
+	for _, pc := range s.suspensionCampaigns[open.ID] {
+		if c, ok := s.campaigns[pc.CampaignID]; ok && c.Status == "paused" && notEnded(c, now) {
+			c.Status = pc.PrevStatus
+		}
+		for _, slot := range s.slots {
+			if slot.CampaignID == pc.CampaignID {
+				slot.Suspended = false
+			}
+		}
+	}
+	out := *open
+	return &out
+}
+
+// hand — кампании закрытой приостановки from, всё ещё стоящие на паузе, переходят к to
+func (s *Store) hand(from int64, to *models.CompanySuspension) {
+	held := map[int64]bool{}
+	for _, pc := range s.suspensionCampaigns[to.ID] {
+		held[pc.CampaignID] = true
+	}
+	for _, pc := range s.suspensionCampaigns[from] {
+		if c, ok := s.campaigns[pc.CampaignID]; !ok || c.Status != "paused" || held[pc.CampaignID] {
+			continue
+		}
+		s.suspensionCampaigns[to.ID] = append(s.suspensionCampaigns[to.ID], pc)
+		to.PausedCampaigns++
+	}
+}
+
+func (r *CompanyLifecycleRepository) Deactivate(ctx context.Context, companyID int64, reason string, actorID *int64) (*models.CompanySuspension, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.companies[companyID]
+	if !ok || !c.IsActive {
+		return nil, repositories.ErrAlreadyInState
+	}
+	c.IsActive = false
+	return r.s.suspend(companyID, repositories.SuspensionDeactivation, reason, actorID), nil
+}
+
+// Reactivate не снимает паузу за просрочку — только оплата
+func (r *CompanyLifecycleRepository) Reactivate(ctx context.Context, companyID int64, actorID *int64) (*models.CompanySuspension, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.companies[companyID]
+	if !ok || c.IsActive {
+		return nil, repositories.ErrAlreadyInState
+	}
+	c.IsActive = true
+
+	sp := r.s.restore(companyID, repositories.SuspensionDeactivation, actorID)
+	if sp == nil {
+		sp = &models.CompanySuspension{CompanyID: companyID, Kind: repositories.SuspensionDeactivation}
+	}
+	return sp, nil
+}
+
+// PauseCampaigns — nil, если приостановка этого вида уже открыта
+func (r *CompanyLifecycleRepository) PauseCampaigns(ctx context.Context, companyID int64, kind, reason string) (*models.CompanySuspension, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, sp := range r.s.suspensions {
+		if sp.CompanyID == companyID && sp.Kind == kind && sp.ReactivatedAt == nil {
+			return nil, nil
+		}
+	}
+	return r.s.suspend(companyID, kind, reason, nil), nil
+}
+
+// ResumeCampaigns у деактивированной компании ничего не делает
+func (r *CompanyLifecycleRepository) ResumeCampaigns(ctx context.Context, companyID int64, kind string) (*models.CompanySuspension, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.companies[companyID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if !c.IsActive {
+		return nil, nil
+	}
+	return r.s.restore(companyID, kind, nil), nil
+}
+
+func (r *CompanyLifecycleRepository) OpenSuspensionCompanies(ctx context.Context, kind string) ([]int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	set := map[int64]bool{}
+	for _, sp := range r.s.suspensions {
+		if sp.Kind == kind && sp.ReactivatedAt == nil {
+			set[sp.CompanyID] = true
+		}
+	}
+	return sortedIDs(set), nil
+}
+
+func (r *CompanyLifecycleRepository) SuspensionCampaigns(ctx context.Context, suspensionID int64) ([]models.SuspendedCampaign, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.SuspendedCampaign{}
+	for _, pc := range r.s.suspensionCampaigns[suspensionID] {
+		c, ok := r.s.campaigns[pc.CampaignID]
+		if !ok {
+			continue
+		}
+		list = append(list, models.SuspendedCampaign{CampaignID: pc.CampaignID, PrevStatus: pc.PrevStatus, Status: c.Status})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CampaignID < list[j].CampaignID })
+	return list, nil
+}
+
+func (r *CompanyLifecycleRepository) ListSuspensions(ctx context.Context, companyID int64) ([]models.CompanySuspension, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.CompanySuspension{}
+	for _, sp := range r.s.suspensions {
+		if sp.CompanyID == companyID {
+			list = append(list, *sp)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].SuspendedAt.Equal(list[j].SuspendedAt) {
+			return list[i].SuspendedAt.After(list[j].SuspendedAt)
+		}
+		return list[i].ID > list[j].ID
+	})
+	return list, nil
+}
+
+//
+// ---------- API KEYS ----------
+//
+
+// APIKeyRepository — хранится только sha256 ключа
+type APIKeyRepository struct {
+	s *Store
+}
+
+func NewAPIKeyRepository(s *Store) *APIKeyRepository {
+	return &APIKeyRepository{s: s}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, k *models.APIKey, hash string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, row := range r.s.apiKeys {
+		if row.Hash == hash {
+			return ErrDuplicate
+		}
+	}
+	k.ID = r.s.nextID("api_keys")
+	k.CreatedAt = r.s.now()
+
+	row := &apiKeyRow{APIKey: *k, Hash: hash}
+	row.Key = ""
+	row.Scopes = cloneStrings(k.Scopes)
+	r.s.apiKeys[k.ID] = row
+	return nil
+}
+
+func apiKey(row *apiKeyRow) *models.APIKey {
+	k := row.APIKey
+	k.Scopes = cloneStrings(row.Scopes)
+	return &k
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id int64) (*models.APIKey, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.apiKeys[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return apiKey(row), nil
+}
+
+// GetActiveByHash — не отозван и не истёк
+func (r *APIKeyRepository) GetActiveByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := r.s.now()
+	for _, row := range r.s.apiKeys {
+		if row.Hash == hash && row.RevokedAt == nil && (row.ExpiresAt == nil || row.ExpiresAt.After(now)) {
+			return apiKey(row), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// ListByCompany — действующие сверху, затем отозванные; внутри — новые сверху
+func (r *APIKeyRepository) ListByCompany(ctx context.Context, companyID int64) ([]models.APIKey, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.APIKey{}
+	for _, row := range r.s.apiKeys {
+		if row.CompanyID == companyID {
+			list = append(list, *apiKey(row))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		ri, rj := list[i].RevokedAt != nil, list[j].RevokedAt != nil
+		if ri != rj {
+			return !ri
+		}
+		return list[i].ID > list[j].ID
+	})
+	return list, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int64, revokedBy *int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.apiKeys[id]
+	if !ok || row.RevokedAt != nil {
+		return sql.ErrNoRows
+	}
+	row.RevokedAt = timePtr(r.s.now())
+	row.RevokedBy = revokedBy
+	return nil
+}
+
+// Touch пишет использование не чаще раза в every
+func (r *APIKeyRepository) Touch(ctx context.Context, id int64, ip string, every time.Duration) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.apiKeys[id]
+	now := r.s.now()
+	if !ok || (row.LastUsedAt != nil && !row.LastUsedAt.Before(now.Add(-every))) {
+		return nil
+	}
+	row.LastUsedAt = timePtr(now)
+	row.LastUsedIP = ip
+	return nil
+}
+
+//
+// ---------- AUDIT ----------
+//
+
+// AuditRepository — журнал только дописывается
+type AuditRepository struct {
+	s *Store
+}
+
+func NewAuditRepository(s *Store) *AuditRepository {
+	return &AuditRepository{s: s}
+}
+
+func (r *AuditRepository) Insert(ctx context.Context, e *models.AuditEntry) error {
+	// diff проходит через JSON, как при записи в jsonb: читатель получает те же типы значений
+	diff, err := json.Marshal(e.Diff)
+	if err != nil {
+		return err
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	e.ID = r.s.nextID("audit_log")
+	e.CreatedAt = r.s.now()
+
+	row := *e
+	row.Diff = nil
+	if err := json.Unmarshal(diff, &row.Diff); err != nil {
+		return err
+	}
+	if len(e.Before) > 0 {
+		row.Before = append(json.RawMessage{}, e.Before...)
+	}
+	if len(e.After) > 0 {
+		row.After = append(json.RawMessage{}, e.After...)
+	}
+	r.s.audit = append(r.s.audit, row)
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, f models.AuditFilter) ([]models.AuditEntry, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	eq := func(p *int64, v int64) bool { return p != nil && *p == v }
+
+	list := []models.AuditEntry{}
+	for _, e := range r.s.audit {
+		switch {
+		case f.EntityType != "" && e.EntityType != f.EntityType,
+			f.EntityID != nil && !eq(e.EntityID, *f.EntityID),
+			f.ActorID != nil && !eq(e.ActorUserID, *f.ActorID),
+			f.Action != "" && e.Action != f.Action,
+			f.From != nil && e.CreatedAt.Before(*f.From),
+			f.To != nil && !e.CreatedAt.Before(*f.To):
+			continue
+		}
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.After(list[j].CreatedAt)
+		}
+		return list[i].ID > list[j].ID
+	})
+	return page(list, f.Limit, f.Offset), nil
+}
@@ -0,0 +1,349 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/repositories"
+)
+
+//
+// ---------- WEBHOOKS ----------
+//
+
+type WebhookRepository struct {
+	s *Store
+}
+
+func NewWebhookRepository(s *Store) *WebhookRepository {
+	return &WebhookRepository{s: s}
+}
+
+func endpoint(e *models.WebhookEndpoint) *models.WebhookEndpoint {
+	out := *e
+	out.EventTypes = cloneStrings(e.EventTypes)
+	return &out
+}
+
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, e *models.WebhookEndpoint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.companies[e.CompanyID]; !ok {
+		return ErrForeignKey
+	}
+	now := r.s.now()
+	e.ID = r.s.nextID("webhook_endpoints")
+	e.CreatedAt, e.UpdatedAt = now, now
+	r.s.endpoints[e.ID] = endpoint(e)
+	return nil
+}
+
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id int64) (*models.WebhookEndpoint, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	e, ok := r.s.endpoints[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return endpoint(e), nil
+}
+
+func (r *WebhookRepository) ListEndpoints(ctx context.Context, companyID int64) ([]models.WebhookEndpoint, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.WebhookEndpoint{}
+	for _, id := range sortedIDs(r.s.endpoints) {
+		if e := r.s.endpoints[id]; e.CompanyID == companyID {
+			list = append(list, *endpoint(e))
+		}
+	}
+	return list, nil
+}
+
+func (r *WebhookRepository) UpdateEndpoint(ctx context.Context, e *models.WebhookEndpoint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.endpoints[e.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	row.URL = e.URL
+	row.Description = e.Description
+	row.EventTypes = cloneStrings(e.EventTypes)
+	row.IsActive = e.IsActive
+	row.UpdatedAt = r.s.now()
+	return nil
+}
+
+func (r *WebhookRepository) SetSecret(ctx context.Context, id int64, secret string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	row, ok := r.s.endpoints[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	row.Secret = secret
+	row.UpdatedAt = r.s.now()
+	return nil
+}
+
+// DeleteEndpoint — вместе с доставками и журналом попыток
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.endpoints[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.s.endpoints, id)
+
+	removed := map[int64]bool{}
+	for did, d := range r.s.deliveries {
+		if d.EndpointID == id {
+			removed[did] = true
+			delete(r.s.deliveries, did)
+		}
+	}
+	kept := r.s.attempts[:0]
+	for _, a := range r.s.attempts {
+		if !removed[a.DeliveryID] {
+			kept = append(kept, a)
+		}
+	}
+	r.s.attempts = kept
+	return nil
+}
+
+// Publish сохраняет событие и по доставке на каждый подписанный активный endpoint компании
+// (или только на endpointID); событие без подписчиков не сохраняется
+func (r *WebhookRepository) Publish(ctx context.Context, ev *models.WebhookEvent, endpointID *int64) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var targets []int64
+	for _, id := range sortedIDs(r.s.endpoints) {
+		e := r.s.endpoints[id]
+		if e.CompanyID != ev.CompanyID || !e.IsActive {
+			continue
+		}
+		if endpointID != nil {
+			if id == *endpointID {
+				targets = append(targets, id)
+			}
+			continue
+		}
+		for _, t := range e.EventTypes {
+			if t == ev.Type {
+				targets = append(targets, id)
+				break
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return 0, nil
+	}
+
+	now := r.s.now()
+	ev.ID = r.s.nextID("webhook_events")
+	ev.CreatedAt = now
+	row := *ev
+	row.Payload = append(json.RawMessage{}, ev.Payload...)
+	r.s.events[ev.ID] = &row
+
+	for _, id := range targets {
+		did := r.s.nextID("webhook_deliveries")
+		r.s.deliveries[did] = &models.WebhookDelivery{
+			ID:            did,
+			EventID:       ev.ID,
+			EndpointID:    id,
+			EventType:     ev.Type,
+			Status:        repositories.DeliveryPending,
+			NextAttemptAt: timePtr(now),
+			CreatedAt:     now,
+		}
+	}
+	return len(targets), nil
+}
+
+// delivery — копия строки без события и endpoint
+func delivery(d *models.WebhookDelivery) models.WebhookDelivery {
+	out := *d
+	if d.NextAttemptAt != nil {
+		out.NextAttemptAt = timePtr(*d.NextAttemptAt)
+	}
+	if d.LastAttemptAt != nil {
+		out.LastAttemptAt = timePtr(*d.LastAttemptAt)
+	}
+	if d.LastStatusCode != nil {
+		code := *d.LastStatusCode
+		out.LastStatusCode = &code
+	}
+	out.Event, out.Endpoint = nil, nil
+	return out
+}
+
+// withTargets — доставка вместе с событием и endpoint (для отправки)
+func (s *Store) withTargets(d *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	out := delivery(d)
+	ev, ok := s.events[d.EventID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	e, ok := s.endpoints[d.EndpointID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	event := *ev
+	event.Payload = append(json.RawMessage{}, ev.Payload...)
+	out.Event = &event
+	out.Endpoint = endpoint(e)
+	return &out, nil
+}
+
+// ClaimDue забирает до limit доставок, которым пора отправляться, и сдвигает их next_attempt_at на lease
+func (r *WebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := r.s.now()
+	due := []*models.WebhookDelivery{}
+	for _, id := range sortedIDs(r.s.deliveries) {
+		d := r.s.deliveries[id]
+		if d.Status != repositories.DeliveryPending || d.NextAttemptAt == nil || d.NextAttemptAt.After(now) {
+			continue
+		}
+		if e, ok := r.s.endpoints[d.EndpointID]; !ok || !e.IsActive {
+			continue
+		}
+		due = append(due, d)
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt) })
+	due = page(due, limit, 0)
+
+	list := make([]models.WebhookDelivery, 0, len(due))
+	for _, d := range due {
+		d.NextAttemptAt = timePtr(now.Add(lease))
+		out, err := r.s.withTargets(d)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *out)
+	}
+	return list, nil
+}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	d, ok := r.s.deliveries[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return r.s.withTargets(d)
+}
+
+// ListDeliveries — журнал доставок endpoint-а, новые сверху; status пустой — любые
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpointID int64, status string, limit, offset int) ([]models.WebhookDelivery, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ids := sortedIDs(r.s.deliveries)
+	list := []models.WebhookDelivery{}
+	for i := len(ids) - 1; i >= 0; i-- {
+		d := r.s.deliveries[ids[i]]
+		if d.EndpointID == endpointID && (status == "" || d.Status == status) {
+			list = append(list, delivery(d))
+		}
+	}
+	return page(list, limit, offset), nil
+}
+
+// RecordAttempt пишет попытку в журнал и обновляет доставку; ручной повтор не расходует попытки
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, a *models.WebhookAttempt, status string, next *time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	d, ok := r.s.deliveries[a.DeliveryID]
+	if !ok {
+		return ErrForeignKey
+	}
+
+	a.ID = r.s.nextID("webhook_delivery_attempts")
+	a.CreatedAt = r.s.now()
+	r.s.attempts = append(r.s.attempts, *a)
+
+	d.Status = status
+	if !a.Manual {
+		d.Attempts++
+	}
+	d.NextAttemptAt = nil
+	if next != nil {
+		d.NextAttemptAt = timePtr(*next)
+	}
+	d.LastAttemptAt = timePtr(a.CreatedAt)
+	d.LastStatusCode = nil
+	if a.StatusCode != nil {
+		code := *a.StatusCode
+		d.LastStatusCode = &code
+	}
+	d.LastError = a.Error
+	return nil
+}
+
+func (r *WebhookRepository) ListAttempts(ctx context.Context, deliveryID int64) ([]models.WebhookAttempt, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	list := []models.WebhookAttempt{}
+	for _, a := range r.s.attempts {
+		if a.DeliveryID == deliveryID {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// CountPlay увеличивает счётчик показов кампании; возвращает новое значение и компанию
+func (r *WebhookRepository) CountPlay(ctx context.Context, campaignID int64) (plays, companyID int64, err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.campaigns[campaignID]
+	if !ok {
+		return 0, 0, sql.ErrNoRows
+	}
+	r.s.playCounters[campaignID]++
+	return r.s.playCounters[campaignID], c.CompanyID, nil
+}
+
+// FacadeCompanies — компании с идущими кампаниями на фасаде
+func (r *WebhookRepository) FacadeCompanies(ctx context.Context, facadeID int64) ([]int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	set := map[int64]bool{}
+	for _, p := range r.s.participation {
+		if p.FacadeID != facadeID {
+			continue
+		}
+		if c, ok := r.s.campaigns[p.CampaignID]; ok && campaignRunning(c.Status) {
+			set[c.CompanyID] = true
+		}
+	}
+	ids := []int64{}
+	for _, id := range sortedIDs(set) {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
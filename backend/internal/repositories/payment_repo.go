@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+)
+
+type PaymentRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentRepository(db *sql.DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+var (
+	ErrOverpayment      = errors.New("payment exceeds outstanding amount")
+	ErrCurrencyMismatch = errors.New("payment currency differs from invoice currency")
+	ErrInvoiceClosed    = errors.New("invoice is already paid, failed or is a credit document")
+)
+
+//
+// --------------------- RECORD PAYMENT ---------------------
+//
+// Record пишет оплату в журнал и пересчитывает amount_paid / статус счёта под блокировкой строки.
+// Полностью оплаченный счёт становится paid (paid_at = дата последней оплаты),
+// частично — partially_paid (просроченный остаётся overdue до полной оплаты).
+func (r *PaymentRepository) Record(ctx context.Context, p *models.Payment) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var total, paid, credited money.Decimal
+	var currency, status string
+	if err := tx.QueryRowContext(ctx, `
+        SELECT amount_total, amount_paid, amount_credited, currency, status
+        FROM invoices
+        WHERE id = $1
+        FOR UPDATE
+    `, p.InvoiceID).Scan(&total, &paid, &credited, &currency, &status); err != nil {
+		return err
+	}
+
+	// кредит-ноты и отрицательные корректировки не оплачиваются, а зачитываются (applied)
+	if status == "paid" || status == "failed" || status == "applied" {
+		return ErrInvoiceClosed
+	}
+	if p.Currency == "" {
+		p.Currency = currency
+	}
+	if !strings.EqualFold(p.Currency, currency) {
+		return ErrCurrencyMismatch
+	}
+	if p.Amount.Cmp(total.Sub(paid).Sub(credited)) > 0 {
+		return ErrOverpayment
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO payments (invoice_id, amount, currency, method, reference, note, paid_at, recorded_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, created_at
+    `,
+		p.InvoiceID,
+		p.Amount,
+		currency,
+		p.Method,
+		p.Reference,
+		p.Note,
+		p.PaidAt,
+		p.RecordedBy,
+	).Scan(&p.ID, &p.CreatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE invoices
+        SET amount_paid = amount_paid + $1,
+            status = CASE
+                WHEN amount_paid + amount_credited + $1 >= amount_total THEN 'paid'
+                WHEN status = 'overdue' THEN 'overdue'
+                ELSE 'partially_paid'
+            END,
+            paid_at = CASE WHEN amount_paid + amount_credited + $1 >= amount_total THEN $2 ELSE paid_at END,
+            updated_at = NOW()
+        WHERE id = $3
+    `, p.Amount, p.PaidAt, p.InvoiceID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//
+// --------------------- LIST BY INVOICE ---------------------
+//
+func (r *PaymentRepository) ListByInvoice(ctx context.Context, invoiceID int64) ([]models.Payment, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, invoice_id, amount, currency, COALESCE(method, ''), COALESCE(reference, ''),
+               COALESCE(note, ''), paid_at, recorded_by, created_at
+        FROM payments
+        WHERE invoice_id = $1
+        ORDER BY paid_at ASC, id ASC
+    `, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.Payment{}
+	for rows.Next() {
+		var p models.Payment
+		if err := rows.Scan(
+			&p.ID,
+			&p.InvoiceID,
+			&p.Amount,
+			&p.Currency,
+			&p.Method,
+			&p.Reference,
+			&p.Note,
+			&p.PaidAt,
+			&p.RecordedBy,
+			&p.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,458 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mediawork/internal/models"
+)
+
+type RollupRepository struct {
+	db *sql.DB
+}
+
+func NewRollupRepository(db *sql.DB) *RollupRepository {
+	return &RollupRepository{db: db}
+}
+
+// date_trunc принимает только известные единицы, поэтому granularity валидируем заранее
+func truncUnit(granularity string) (string, error) {
+	switch granularity {
+	case "hour", "day":
+		return granularity, nil
+	}
+	return "", fmt.Errorf("unknown rollup granularity %q", granularity)
+}
+
+// execer — *sql.DB для пересчёта окна и *sql.Tx для фиксации выгруженных суток
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+//
+// ----------------------- ROLLUP PLAY HISTORY -----------------------
+//
+// Пересчитывает агрегаты за [from, to) целиком из сырых строк — запуск идемпотентен,
+// поздно пришедшие события просто обновят бакет. Только для периода, сырые строки
+// которого ещё не удалялись, иначе бакет потеряет выгруженные в архив события.
+func (r *RollupRepository) RollupPlayHistory(
+	ctx context.Context,
+	granularity string,
+	from, to time.Time,
+) (int64, error) {
+	return rollupPlayHistory(ctx, r.db, granularity, from, to, 0, playRollupReplace)
+}
+
+const (
+	playRollupReplace = `
+            plays = EXCLUDED.plays,
+            airtime_sec = EXCLUDED.airtime_sec,
+            bitrate_sum = EXCLUDED.bitrate_sum,
+            sync_latency_sum = EXCLUDED.sync_latency_sum,
+            sync_latency_max = EXCLUDED.sync_latency_max,`
+
+	// прибавление к существующим бакетам — для событий, пришедших за уже выгруженные сутки
+	playRollupMerge = `
+            plays = play_history_rollups.plays + EXCLUDED.plays,
+            airtime_sec = play_history_rollups.airtime_sec + EXCLUDED.airtime_sec,
+            bitrate_sum = play_history_rollups.bitrate_sum + EXCLUDED.bitrate_sum,
+            sync_latency_sum = play_history_rollups.sync_latency_sum + EXCLUDED.sync_latency_sum,
+            sync_latency_max = GREATEST(play_history_rollups.sync_latency_max, EXCLUDED.sync_latency_max),`
+)
+
+// rollupPlayHistory — строки [from, to) с id <= maxID (0 — без ограничения по id)
+func rollupPlayHistory(
+	ctx context.Context,
+	db execer,
+	granularity string,
+	from, to time.Time,
+	maxID int64,
+	set string,
+) (int64, error) {
+
+	unit, err := truncUnit(granularity)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+        INSERT INTO play_history_rollups (
+            granularity, bucket_start, facade_id, campaign_id,
+            plays, airtime_sec, bitrate_sum, sync_latency_sum, sync_latency_max,
+            updated_at
+        )
+        SELECT
+            $1,
+            date_trunc($1, played_at),
+            facade_id,
+            campaign_id,
+            COUNT(*),
+            COALESCE(SUM(duration_sec), 0),
+            COALESCE(SUM(bitrate_kbps), 0),
+            COALESCE(SUM(sync_latency_ms), 0),
+            COALESCE(MAX(sync_latency_ms), 0),
+            NOW()
+        FROM play_history
+        WHERE played_at >= $2 AND played_at < $3
+          AND ($4::bigint = 0 OR id <= $4)
+        GROUP BY date_trunc($1, played_at), facade_id, campaign_id
+        ON CONFLICT (granularity, bucket_start, facade_id, campaign_id)
+        DO UPDATE SET` + set + `
+            updated_at = NOW()
+    `
+
+	res, err := db.ExecContext(ctx, query, unit, from, to, maxID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+//
+// ----------------------- ROLLUP HEARTBEATS -----------------------
+//
+// Те же два режима, что у play_history: пересчёт периода и прибавление поздних строк
+func (r *RollupRepository) RollupHeartbeats(
+	ctx context.Context,
+	granularity string,
+	from, to time.Time,
+) (int64, error) {
+	return rollupHeartbeats(ctx, r.db, granularity, from, to, 0, heartbeatRollupReplace)
+}
+
+const (
+	heartbeatRollupReplace = `
+            beats = EXCLUDED.beats,
+            latency_sum = EXCLUDED.latency_sum,
+            latency_max = EXCLUDED.latency_max,`
+
+	heartbeatRollupMerge = `
+            beats = facade_heartbeat_rollups.beats + EXCLUDED.beats,
+            latency_sum = facade_heartbeat_rollups.latency_sum + EXCLUDED.latency_sum,
+            latency_max = GREATEST(facade_heartbeat_rollups.latency_max, EXCLUDED.latency_max),`
+)
+
+func rollupHeartbeats(
+	ctx context.Context,
+	db execer,
+	granularity string,
+	from, to time.Time,
+	maxID int64,
+	set string,
+) (int64, error) {
+
+	unit, err := truncUnit(granularity)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+        INSERT INTO facade_heartbeat_rollups (
+            granularity, bucket_start, facade_id,
+            beats, latency_sum, latency_max, updated_at
+        )
+        SELECT
+            $1,
+            date_trunc($1, timestamp),
+            facade_id,
+            COUNT(*),
+            COALESCE(SUM(latency_ms), 0),
+            COALESCE(MAX(latency_ms), 0),
+            NOW()
+        FROM facade_heartbeat
+        WHERE timestamp >= $2 AND timestamp < $3
+          AND ($4::bigint = 0 OR id <= $4)
+        GROUP BY date_trunc($1, timestamp), facade_id
+        ON CONFLICT (granularity, bucket_start, facade_id)
+        DO UPDATE SET` + set + `
+            updated_at = NOW()
+    `
+
+	res, err := db.ExecContext(ctx, query, unit, from, to, maxID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+//
+// ----------------------- OLDEST RAW ROWS -----------------------
+//
+func (r *RollupRepository) OldestPlayEvent(ctx context.Context) (*time.Time, error) {
+	var t sql.NullTime
+	err := r.db.QueryRowContext(ctx, `SELECT MIN(played_at) FROM play_history`).Scan(&t)
+	if err != nil || !t.Valid {
+		return nil, err
+	}
+	return &t.Time, nil
+}
+
+func (r *RollupRepository) OldestHeartbeat(ctx context.Context) (*time.Time, error) {
+	var t sql.NullTime
+	err := r.db.QueryRowContext(ctx, `SELECT MIN(timestamp) FROM facade_heartbeat`).Scan(&t)
+	if err != nil || !t.Valid {
+		return nil, err
+	}
+	return &t.Time, nil
+}
+
+//
+// ----------------------- WATERMARKS -----------------------
+//
+// PlayHistoryWatermark — наибольший id строки за [from, to), 0 — строк нет.
+// Выгрузка, свёртка и удаление суток ограничены этим id, поэтому строки,
+// пришедшие во время архивации, остаются в БД до следующей части.
+func (r *RollupRepository) PlayHistoryWatermark(ctx context.Context, from, to time.Time) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COALESCE(MAX(id), 0)
+        FROM play_history
+        WHERE played_at >= $1 AND played_at < $2
+    `, from, to).Scan(&id)
+	return id, err
+}
+
+func (r *RollupRepository) HeartbeatWatermark(ctx context.Context, from, to time.Time) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COALESCE(MAX(id), 0)
+        FROM facade_heartbeat
+        WHERE timestamp >= $1 AND timestamp < $2
+    `, from, to).Scan(&id)
+	return id, err
+}
+
+//
+// ----------------------- EXPORT RAW PLAY HISTORY -----------------------
+//
+func (r *RollupRepository) ExportPlayHistory(
+	ctx context.Context,
+	from, to time.Time,
+	maxID int64,
+	fn func(ev *models.PlayEvent) error,
+) (int64, error) {
+
+	query := `
+        SELECT id, facade_id, campaign_id, slot_id, media_url,
+               played_at, duration_sec, resolution_w, resolution_h,
+               bitrate_kbps, sync_latency_ms
+        FROM play_history
+        WHERE played_at >= $1 AND played_at < $2 AND id <= $3
+        ORDER BY played_at ASC, id ASC
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, from, to, maxID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n int64
+	for rows.Next() {
+		var ev models.PlayEvent
+		if err := rows.Scan(
+			&ev.ID,
+			&ev.FacadeID,
+			&ev.CampaignID,
+			&ev.SlotID,
+			&ev.MediaURL,
+			&ev.PlayedAt,
+			&ev.DurationSec,
+			&ev.ResolutionW,
+			&ev.ResolutionH,
+			&ev.BitrateKbps,
+			&ev.SyncLatencyMS,
+		); err != nil {
+			return n, err
+		}
+		if err := fn(&ev); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, rows.Err()
+}
+
+//
+// ----------------------- EXPORT RAW HEARTBEATS -----------------------
+//
+func (r *RollupRepository) ExportHeartbeats(
+	ctx context.Context,
+	from, to time.Time,
+	maxID int64,
+	fn func(hb *models.Heartbeat, at time.Time) error,
+) (int64, error) {
+
+	query := `
+        SELECT facade_id, timestamp, COALESCE(latency_ms, 0), COALESCE(source_ip, '')
+        FROM facade_heartbeat
+        WHERE timestamp >= $1 AND timestamp < $2 AND id <= $3
+        ORDER BY timestamp ASC, id ASC
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, from, to, maxID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n int64
+	for rows.Next() {
+		var hb models.Heartbeat
+		var at time.Time
+		if err := rows.Scan(&hb.FacadeID, &at, &hb.LatencyMS, &hb.SourceIP); err != nil {
+			return n, err
+		}
+		if err := fn(&hb, at); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, rows.Err()
+}
+
+//
+// ----------------------- ARCHIVES -----------------------
+//
+// LastArchivePart — номер последней выгруженной части суток, 0 — сутки ещё не выгружались
+func (r *RollupRepository) LastArchivePart(ctx context.Context, source string, periodStart time.Time) (int, error) {
+	var part int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COALESCE(MAX(part), 0)
+        FROM play_history_archives
+        WHERE source = $1 AND period_start = $2
+    `, source, periodStart).Scan(&part)
+	return part, err
+}
+
+// CommitPlayHistoryArchive фиксирует выгруженную часть суток одной транзакцией:
+// сворачивает строки части в агрегаты (первая часть заменяет бакеты, следующие
+// прибавляют), удаляет их и записывает архив в каталог. Часть — строки периода
+// с id <= maxID; если их число разошлось с выгруженным, ничего не меняется.
+func (r *RollupRepository) CommitPlayHistoryArchive(ctx context.Context, a *models.PlayHistoryArchive, maxID int64) (int64, error) {
+	set := playRollupReplace
+	if a.Part > 1 {
+		set = playRollupMerge
+	}
+	return r.commitArchive(ctx, a, maxID,
+		func(tx *sql.Tx, granularity string) error {
+			_, err := rollupPlayHistory(ctx, tx, granularity, a.PeriodStart, a.PeriodEnd, maxID, set)
+			return err
+		},
+		`DELETE FROM play_history WHERE played_at >= $1 AND played_at < $2 AND id <= $3`,
+	)
+}
+
+func (r *RollupRepository) CommitHeartbeatArchive(ctx context.Context, a *models.PlayHistoryArchive, maxID int64) (int64, error) {
+	set := heartbeatRollupReplace
+	if a.Part > 1 {
+		set = heartbeatRollupMerge
+	}
+	return r.commitArchive(ctx, a, maxID,
+		func(tx *sql.Tx, granularity string) error {
+			_, err := rollupHeartbeats(ctx, tx, granularity, a.PeriodStart, a.PeriodEnd, maxID, set)
+			return err
+		},
+		`DELETE FROM facade_heartbeat WHERE timestamp >= $1 AND timestamp < $2 AND id <= $3`,
+	)
+}
+
+// commitArchive — REPEATABLE READ: свёртка и удаление видят один снимок, так что
+// агрегаты получают ровно те строки, которые удаляются.
+// Повтор (source, period_start, part) или file_path нарушает уникальность (23505).
+func (r *RollupRepository) commitArchive(
+	ctx context.Context,
+	a *models.PlayHistoryArchive,
+	maxID int64,
+	rollup func(tx *sql.Tx, granularity string) error,
+	prune string,
+) (int64, error) {
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, granularity := range []string{"hour", "day"} {
+		if err := rollup(tx, granularity); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, prune, a.PeriodStart, a.PeriodEnd, maxID)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if deleted != a.RowCount {
+		return 0, fmt.Errorf("%s %s: exported %d rows, pruning %d", a.Source, a.PeriodStart.Format("2006-01-02"), a.RowCount, deleted)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO play_history_archives (
+            source, period_start, period_end, part, file_path, row_count, size_bytes, created_at
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+        RETURNING id, created_at
+    `,
+		a.Source,
+		a.PeriodStart,
+		a.PeriodEnd,
+		a.Part,
+		a.FilePath,
+		a.RowCount,
+		a.SizeBytes,
+	).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
+func (r *RollupRepository) ListArchives(ctx context.Context, periodBefore *time.Time) ([]models.PlayHistoryArchive, error) {
+	query := `
+        SELECT id, source, period_start, period_end, part, file_path, row_count, size_bytes, created_at
+        FROM play_history_archives
+        WHERE ($1::timestamptz IS NULL OR period_end < $1)
+        ORDER BY period_start DESC, part DESC
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, periodBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.PlayHistoryArchive{}
+	for rows.Next() {
+		var a models.PlayHistoryArchive
+		if err := rows.Scan(
+			&a.ID,
+			&a.Source,
+			&a.PeriodStart,
+			&a.PeriodEnd,
+			&a.Part,
+			&a.FilePath,
+			&a.RowCount,
+			&a.SizeBytes,
+			&a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+func (r *RollupRepository) DeleteArchive(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM play_history_archives WHERE id = $1`, id)
+	return err
+}
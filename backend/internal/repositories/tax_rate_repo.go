@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"mediawork/internal/models"
+)
+
+// TaxRateRepository — ставки налога по юрисдикциям с периодом действия
+type TaxRateRepository struct {
+	db *sql.DB
+}
+
+func NewTaxRateRepository(db *sql.DB) *TaxRateRepository {
+	return &TaxRateRepository{db: db}
+}
+
+const taxRateColumns = `id, jurisdiction, name, rate, valid_from, valid_to, created_at`
+
+func scanTaxRate(row interface{ Scan(...any) error }) (*models.TaxRate, error) {
+	var t models.TaxRate
+	if err := row.Scan(&t.ID, &t.Jurisdiction, &t.Name, &t.Rate, &t.ValidFrom, &t.ValidTo, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+//
+// --------------------- CREATE ---------------------
+//
+// Create закрывает действующую ставку юрисдикции датой начала новой
+func (r *TaxRateRepository) Create(ctx context.Context, t *models.TaxRate) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE tax_rates
+        SET valid_to = $2
+        WHERE jurisdiction = $1
+          AND valid_from < $2
+          AND (valid_to IS NULL OR valid_to > $2)
+    `, t.Jurisdiction, t.ValidFrom); err != nil {
+		return err
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO tax_rates (jurisdiction, name, rate, valid_from, valid_to)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `, t.Jurisdiction, t.Name, t.Rate, t.ValidFrom, t.ValidTo).Scan(&t.ID, &t.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//
+// --------------------- FIND ---------------------
+//
+// Find — ставка, действующая в юрисдикции на дату; sql.ErrNoRows, если такой нет
+func (r *TaxRateRepository) Find(ctx context.Context, jurisdiction string, on time.Time) (*models.TaxRate, error) {
+	return scanTaxRate(r.db.QueryRowContext(ctx, `
+        SELECT `+taxRateColumns+`
+        FROM tax_rates
+        WHERE jurisdiction = $1
+          AND valid_from <= $2
+          AND (valid_to IS NULL OR valid_to > $2)
+        ORDER BY valid_from DESC
+        LIMIT 1
+    `, jurisdiction, on))
+}
+
+func (r *TaxRateRepository) GetByID(ctx context.Context, id int64) (*models.TaxRate, error) {
+	return scanTaxRate(r.db.QueryRowContext(ctx, `SELECT `+taxRateColumns+` FROM tax_rates WHERE id = $1`, id))
+}
+
+// List — все ставки (jurisdiction пустая — по всем юрисдикциям), новые сверху
+func (r *TaxRateRepository) List(ctx context.Context, jurisdiction string) ([]models.TaxRate, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+taxRateColumns+`
+        FROM tax_rates
+        WHERE $1 = '' OR jurisdiction = $1
+        ORDER BY jurisdiction, valid_from DESC
+    `, jurisdiction)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.TaxRate{}
+	for rows.Next() {
+		t, err := scanTaxRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *t)
+	}
+	return list, rows.Err()
+}
+
+func (r *TaxRateRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM tax_rates WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package repositories
 import (
     "context"
     "database/sql"
-    "log"
 
     "mediawork/internal/models"
 )
@@ -19,9 +18,9 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 // --------------------- CREATE USER ---------------------
 func (r *UserRepository) Create(ctx context.Context, u *models.User) (int64, error) {
     query := `
-        INSERT INTO users (email, password_hash, full_name, role, created_at, updated_at)
+        INSERT INTO users (email, password_hash, full_name, global_role, created_at, updated_at)
         VALUES ($1, $2, $3, $4, NOW(), NOW())
-        RETURNING id, created_at, updated_at
+        RETURNING id, is_active, created_at, updated_at
     `
 
     err := r.db.QueryRowContext(ctx, query,
@@ -29,7 +28,7 @@ func (r *UserRepository) Create(ctx context.Context, u *models.User) (int64, err
         u.PasswordHash,
         u.FullName,
         u.Role,
-    ).Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+    ).Scan(&u.ID, &u.IsActive, &u.CreatedAt, &u.UpdatedAt)
 
     if err != nil {
         return 0, err
@@ -44,19 +43,41 @@ func (r *UserRepository) Create(ctx context.Context, u *models.User) (int64, err
 // --------------------- UPDATE ROLE ---------------------
 func (r *UserRepository) UpdateRole(ctx context.Context, id int64, role string) error {
     _, err := r.db.ExecContext(ctx,
-        `UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2`,
+        `UPDATE users SET global_role = $1, updated_at = NOW() WHERE id = $2`,
         role, id,
     )
     return err
 }
 
+// --------------------- ENABLE / DISABLE ---------------------
+func (r *UserRepository) SetActive(ctx context.Context, id int64, active bool) error {
+    res, err := r.db.ExecContext(ctx,
+        `UPDATE users SET is_active = $1, updated_at = NOW() WHERE id = $2`,
+        active, id,
+    )
+    if err != nil {
+        return err
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+    return nil
+}
+
+// IsActive — дешёвая проверка для AuthMiddleware
+func (r *UserRepository) IsActive(ctx context.Context, id int64) (bool, error) {
+    var active bool
+    err := r.db.QueryRowContext(ctx, `SELECT is_active FROM users WHERE id = $1`, id).Scan(&active)
+    return active, err
+}
+
 // --------------------- UPDATE PROFILE ---------------------
 func (r *UserRepository) Update(ctx context.Context, u *models.User) error {
     query := `
         UPDATE users
         SET email = $1,
             full_name = $2,
-            role = $3,
+            global_role = $3,
             updated_at = NOW()
         WHERE id = $4
     `
@@ -77,20 +98,19 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, e
             email, 
             full_name,
             global_role AS role,
+            is_active,
             created_at,
-            created_at AS updated_at
+            updated_at
         FROM users
         WHERE id = $1
     `
 
     var u models.User
     err := r.db.QueryRowContext(ctx, query, id).
-        Scan(&u.ID, &u.Email, &u.FullName, &u.Role, &u.CreatedAt, &u.UpdatedAt)
+        Scan(&u.ID, &u.Email, &u.FullName, &u.Role, &u.IsActive, &u.CreatedAt, &u.UpdatedAt)
     if err != nil {
         return nil, err
     }
-    log.Println("Fetched user by ID:", u.ID)
-    log.Println("User full name:", u.FullName)
     u.Name = u.FullName
     return &u, nil
 }
@@ -104,32 +124,60 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
             full_name, 
             password_hash, 
             global_role AS role,
+            is_active,
             created_at,
-            created_at -- пока заглушка для updated_at
+            updated_at
         FROM users
         WHERE email = $1
     `
 
     var u models.User
     err := r.db.QueryRowContext(ctx, query, email).
-        Scan(&u.ID, &u.Email, &u.FullName, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt)
+        Scan(&u.ID, &u.Email, &u.FullName, &u.PasswordHash, &u.Role, &u.IsActive, &u.CreatedAt, &u.UpdatedAt)
     if err != nil {
         return nil, err
     }
-    log.Println("Fetched user by email:", u.Email)
     u.Name = u.FullName
     return &u, nil
 }
 
+// UserList — фильтры и сортировки админского списка пользователей;
+// status — active | disabled, дата — регистрация
+var UserList = ListSpec[models.User]{
+    ID: func(u models.User) int64 { return u.ID },
+    Sorts: map[string]Field[models.User]{
+        "created_at": {Column: "created_at", Value: func(u models.User) any { return u.CreatedAt }},
+        "email":      {Column: "email", Value: func(u models.User) any { return u.Email }},
+        "full_name":  {Column: "full_name", Value: func(u models.User) any { return u.FullName }},
+    },
+    DefaultSort: "created_at",
+    DefaultDesc: true,
+    Status: &Field[models.User]{
+        Column: "CASE WHEN is_active THEN 'active' ELSE 'disabled' END",
+        Value: func(u models.User) any {
+            if u.IsActive {
+                return "active"
+            }
+            return "disabled"
+        },
+    },
+    Role: &Field[models.User]{Column: "global_role", Value: func(u models.User) any { return u.Role }},
+    Date: &Field[models.User]{Column: "created_at", Value: func(u models.User) any { return u.CreatedAt }},
+    Search: []Field[models.User]{
+        {Column: "email", Value: func(u models.User) any { return u.Email }},
+        {Column: "full_name", Value: func(u models.User) any { return u.FullName }},
+    },
+}
+
 // --------------------- LIST ---------------------
-func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]models.User, error) {
-    query := `
-        SELECT id, email, full_name, role, created_at, updated_at
+// List — страница пользователей по UserList (до q.Limit+1 строк в порядке обхода)
+func (r *UserRepository) List(ctx context.Context, q models.ListQuery) ([]models.User, error) {
+    query, args := UserList.Query(`
+        SELECT id, email, full_name, global_role, is_active, created_at, updated_at
         FROM users
-        ORDER BY created_at DESC
-        LIMIT $1 OFFSET $2
-    `
-    rows, err := r.db.QueryContext(ctx, query, limit, offset)
+    `, q)
+
+    rows, err := r.db.QueryContext(ctx, query, args...)
     if err != nil {
         return nil, err
     }
@@ -138,13 +186,13 @@ func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]models.
     list := []models.User{}
     for rows.Next() {
         var u models.User
-        if err := rows.Scan(&u.ID, &u.Email, &u.FullName, &u.Role, &u.CreatedAt, &u.UpdatedAt); err != nil {
+        if err := rows.Scan(&u.ID, &u.Email, &u.FullName, &u.Role, &u.IsActive, &u.CreatedAt, &u.UpdatedAt); err != nil {
             return nil, err
         }
         u.Name = u.FullName
         list = append(list, u)
     }
-    return list, nil
+    return list, rows.Err()
 }
 
 // --------------------- DELETE ---------------------
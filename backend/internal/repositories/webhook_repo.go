@@ -0,0 +1,430 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"mediawork/internal/models"
+)
+
+// WebhookRepository — endpoints компаний, outbox событий, доставки и журнал попыток
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Статусы доставки
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+//
+// --------------------- ENDPOINTS ---------------------
+//
+const endpointColumns = `
+    id, company_id, url, COALESCE(description, ''), event_types, secret, is_active,
+    created_by, created_at, updated_at
+`
+
+func scanEndpoint(row interface{ Scan(...any) error }) (*models.WebhookEndpoint, error) {
+	var e models.WebhookEndpoint
+	if err := row.Scan(
+		&e.ID,
+		&e.CompanyID,
+		&e.URL,
+		&e.Description,
+		pq.Array(&e.EventTypes),
+		&e.Secret,
+		&e.IsActive,
+		&e.CreatedBy,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, e *models.WebhookEndpoint) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO webhook_endpoints (company_id, url, description, event_types, secret, is_active, created_by)
+        VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+        RETURNING id, created_at, updated_at
+    `,
+		e.CompanyID,
+		e.URL,
+		e.Description,
+		pq.Array(e.EventTypes),
+		e.Secret,
+		e.IsActive,
+		e.CreatedBy,
+	).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+}
+
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id int64) (*models.WebhookEndpoint, error) {
+	return scanEndpoint(r.db.QueryRowContext(ctx,
+		`SELECT `+endpointColumns+` FROM webhook_endpoints WHERE id = $1`, id))
+}
+
+func (r *WebhookRepository) ListEndpoints(ctx context.Context, companyID int64) ([]models.WebhookEndpoint, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+endpointColumns+`
+        FROM webhook_endpoints
+        WHERE company_id = $1
+        ORDER BY id
+    `, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.WebhookEndpoint{}
+	for rows.Next() {
+		e, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *e)
+	}
+	return list, rows.Err()
+}
+
+func (r *WebhookRepository) UpdateEndpoint(ctx context.Context, e *models.WebhookEndpoint) error {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_endpoints
+        SET url = $2, description = NULLIF($3, ''), event_types = $4, is_active = $5, updated_at = NOW()
+        WHERE id = $1
+    `, e.ID, e.URL, e.Description, pq.Array(e.EventTypes), e.IsActive)
+	if err != nil {
+		return err
+	}
+	return expectOne(res)
+}
+
+func (r *WebhookRepository) SetSecret(ctx context.Context, id int64, secret string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_endpoints SET secret = $2, updated_at = NOW() WHERE id = $1`, id, secret)
+	if err != nil {
+		return err
+	}
+	return expectOne(res)
+}
+
+// DeleteEndpoint — вместе с доставками (журнал попыток уходит каскадом)
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectOne(res)
+}
+
+func expectOne(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+//
+// --------------------- OUTBOX ---------------------
+//
+// Publish сохраняет событие и по доставке на каждый активный endpoint компании, подписанный
+// на этот тип (или только на endpointID — для тестового события). Событие без подписчиков
+// не сохраняется; возвращает число созданных доставок.
+func (r *WebhookRepository) Publish(ctx context.Context, ev *models.WebhookEvent, endpointID *int64) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	endpoints, err := collectIDs(tx.QueryContext(ctx, `
+        SELECT id
+        FROM webhook_endpoints
+        WHERE company_id = $1
+          AND is_active
+          AND ($3::bigint IS NOT NULL AND id = $3 OR $3::bigint IS NULL AND $2 = ANY(event_types))
+    `, ev.CompanyID, ev.Type, endpointID))
+	if err != nil {
+		return 0, err
+	}
+	if len(endpoints) == 0 {
+		return 0, nil
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO webhook_events (company_id, event_type, payload)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at
+    `, ev.CompanyID, ev.Type, []byte(ev.Payload)).Scan(&ev.ID, &ev.CreatedAt); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO webhook_deliveries (event_id, endpoint_id, status, next_attempt_at)
+        SELECT $1, unnest($2::bigint[]), 'pending', NOW()
+    `, ev.ID, pq.Array(endpoints)); err != nil {
+		return 0, err
+	}
+	return len(endpoints), tx.Commit()
+}
+
+//
+// --------------------- DELIVERIES ---------------------
+//
+const deliveryColumns = `
+    d.id, d.event_id, d.endpoint_id, ev.event_type, d.status, d.attempts, d.next_attempt_at,
+    d.last_attempt_at, d.last_status_code, COALESCE(d.last_error, ''), d.created_at
+`
+
+func scanDelivery(row interface{ Scan(...any) error }) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	if err := row.Scan(
+		&d.ID,
+		&d.EventID,
+		&d.EndpointID,
+		&d.EventType,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastAttemptAt,
+		&d.LastStatusCode,
+		&d.LastError,
+		&d.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ClaimDue забирает до limit доставок, которым пора отправляться, и сдвигает их next_attempt_at
+// на lease вперёд: параллельный диспетчер (другой инстанс) их не возьмёт, а если процесс упадёт
+// посреди отправки, доставка вернётся в очередь сама.
+func (r *WebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids, err := collectIDs(tx.QueryContext(ctx, `
+        UPDATE webhook_deliveries
+        SET next_attempt_at = NOW() + make_interval(secs => $2)
+        WHERE id IN (
+            SELECT id FROM webhook_deliveries
+            WHERE status = 'pending' AND next_attempt_at <= NOW()
+              AND endpoint_id IN (SELECT id FROM webhook_endpoints WHERE is_active)
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id
+    `, limit, lease.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	list := make([]models.WebhookDelivery, 0, len(ids))
+	for _, id := range ids {
+		d, err := r.GetDelivery(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *d)
+	}
+	return list, nil
+}
+
+// GetDelivery — доставка вместе с событием и endpoint (для отправки)
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	var ev models.WebhookEvent
+	var payload []byte
+
+	row := r.db.QueryRowContext(ctx, `
+        SELECT `+deliveryColumns+`, ev.company_id, ev.payload, ev.created_at
+        FROM webhook_deliveries d
+        JOIN webhook_events ev ON ev.id = d.event_id
+        WHERE d.id = $1
+    `, id)
+
+	var d models.WebhookDelivery
+	if err := row.Scan(
+		&d.ID,
+		&d.EventID,
+		&d.EndpointID,
+		&d.EventType,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastAttemptAt,
+		&d.LastStatusCode,
+		&d.LastError,
+		&d.CreatedAt,
+		&ev.CompanyID,
+		&payload,
+		&ev.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	ev.ID, ev.Type, ev.Payload = d.EventID, d.EventType, payload
+	d.Event = &ev
+
+	endpoint, err := r.GetEndpoint(ctx, d.EndpointID)
+	if err != nil {
+		return nil, err
+	}
+	d.Endpoint = endpoint
+	return &d, nil
+}
+
+// ListDeliveries — журнал доставок endpoint-а, новые сверху; status пустой — любые
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpointID int64, status string, limit, offset int) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+deliveryColumns+`
+        FROM webhook_deliveries d
+        JOIN webhook_events ev ON ev.id = d.event_id
+        WHERE d.endpoint_id = $1
+          AND ($2 = '' OR d.status = $2)
+        ORDER BY d.id DESC
+        LIMIT $3 OFFSET $4
+    `, endpointID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *d)
+	}
+	return list, rows.Err()
+}
+
+// RecordAttempt пишет попытку в журнал и обновляет доставку: status и next (nil — больше не пробуем).
+// Ручной повтор не расходует автоматические попытки.
+func (r *WebhookRepository) RecordAttempt(
+	ctx context.Context,
+	a *models.WebhookAttempt,
+	status string,
+	next *time.Time,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO webhook_delivery_attempts (
+            delivery_id, attempt, manual, status_code, error, duration_ms
+        )
+        VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+        RETURNING id, created_at
+    `,
+		a.DeliveryID,
+		a.Attempt,
+		a.Manual,
+		a.StatusCode,
+		a.Error,
+		a.DurationMS,
+	).Scan(&a.ID, &a.CreatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE webhook_deliveries
+        SET status = $2,
+            attempts = attempts + CASE WHEN $3 THEN 0 ELSE 1 END,
+            next_attempt_at = $4,
+            last_attempt_at = $5,
+            last_status_code = $6,
+            last_error = NULLIF($7, '')
+        WHERE id = $1
+    `, a.DeliveryID, status, a.Manual, next, a.CreatedAt, a.StatusCode, a.Error); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *WebhookRepository) ListAttempts(ctx context.Context, deliveryID int64) ([]models.WebhookAttempt, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, delivery_id, attempt, manual, status_code, COALESCE(error, ''),
+               duration_ms, created_at
+        FROM webhook_delivery_attempts
+        WHERE delivery_id = $1
+        ORDER BY id
+    `, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.WebhookAttempt{}
+	for rows.Next() {
+		var a models.WebhookAttempt
+		if err := rows.Scan(
+			&a.ID,
+			&a.DeliveryID,
+			&a.Attempt,
+			&a.Manual,
+			&a.StatusCode,
+			&a.Error,
+			&a.DurationMS,
+			&a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+//
+// --------------------- EVENT SOURCES ---------------------
+//
+// CountPlay увеличивает счётчик показов кампании; возвращает новое значение и компанию
+func (r *WebhookRepository) CountPlay(ctx context.Context, campaignID int64) (plays, companyID int64, err error) {
+	err = r.db.QueryRowContext(ctx, `
+        WITH c AS (
+            INSERT INTO campaign_play_counters (campaign_id, plays)
+            VALUES ($1, 1)
+            ON CONFLICT (campaign_id) DO UPDATE SET plays = campaign_play_counters.plays + 1
+            RETURNING plays
+        )
+        SELECT c.plays, cp.company_id
+        FROM c, campaigns cp
+        WHERE cp.id = $1
+    `, campaignID).Scan(&plays, &companyID)
+	return plays, companyID, err
+}
+
+// FacadeCompanies — компании с идущими кампаниями на фасаде (кого касается его простой)
+func (r *WebhookRepository) FacadeCompanies(ctx context.Context, facadeID int64) ([]int64, error) {
+	return collectIDs(r.db.QueryContext(ctx, `
+        SELECT DISTINCT c.company_id
+        FROM campaign_participation cp
+        JOIN campaigns c ON c.id = cp.campaign_id
+        WHERE cp.facade_id = $1
+          AND c.status IN `+runningCampaignStatuses+`
+    `, facadeID))
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+)
+
+// Форматы выгрузки в бухгалтерию
+const (
+	ExportCSV  = "csv"  // zip: invoices.csv, invoice_lines.csv, payments.csv
+	ExportJSON = "json" // models.AccountingLedger
+	ExportXML  = "xml"  // тот же ledger в XML
+)
+
+var (
+	ErrExportFormat = invalid("format", "format must be csv, json or xml")
+	ErrExportPeriod = invalid("incremental", "incremental export cannot be limited by period")
+	ErrExportRange  = invalid("to", "period end must be after period start")
+)
+
+// exportSettle — свежие строки (моложе минуты) уходят в следующую выгрузку, см. ExportBounds
+const exportSettle = time.Minute
+
+// AccountingExportService — выгрузка выставленных документов, строк и оплат в бухгалтерию.
+// Документы неизменяемы, поэтому каждый уходит в выгрузку один раз; оплаты — отдельными записями.
+type AccountingExportService struct {
+	invoices InvoiceRepository
+	audit    *AuditService
+}
+
+func NewAccountingExportService(inv InvoiceRepository, a *AuditService) *AccountingExportService {
+	return &AccountingExportService{invoices: inv, audit: a}
+}
+
+// ExportRequest — инкрементальная выгрузка («с прошлой») или за период [PeriodFrom, PeriodTo)
+type ExportRequest struct {
+	Format      string
+	Incremental bool
+	PeriodFrom  *time.Time
+	PeriodTo    *time.Time
+	CreatedBy   *int64
+}
+
+// ExportContentType — MIME-тип и расширение файла выгрузки
+func ExportContentType(format string) (contentType, ext string) {
+	switch format {
+	case ExportCSV:
+		return "application/zip", "zip"
+	case ExportXML:
+		return "application/xml", "xml"
+	}
+	return "application/json", "json"
+}
+
+// ParseExportPeriod — даты YYYY-MM-DD включительно → [from, to+1 день); пустая — без границы
+func ParseExportPeriod(from, to string) (*time.Time, *time.Time, error) {
+	var pf, pt *time.Time
+	if from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, nil, invalidf("from", "invalid from: %v", err)
+		}
+		pf = &t
+	}
+	if to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, nil, invalidf("to", "invalid to: %v", err)
+		}
+		t = t.Add(oneDay)
+		pt = &t
+	}
+	return pf, pt, nil
+}
+
+func validExportFormat(format string) bool {
+	return format == ExportCSV || format == ExportJSON || format == ExportXML
+}
+
+//
+// ---------- EXPORT ----------
+//
+// Export фиксирует состав новой выгрузки (диапазоны id), записывает её в журнал и пишет файл в w.
+// Если запись файла оборвалась, выгрузку можно получить повторно через Redownload.
+func (s *AccountingExportService) Export(ctx context.Context, req ExportRequest, w io.Writer) (*models.AccountingExport, error) {
+	if !validExportFormat(req.Format) {
+		return nil, ErrExportFormat
+	}
+	if req.Incremental && (req.PeriodFrom != nil || req.PeriodTo != nil) {
+		return nil, ErrExportPeriod
+	}
+	if req.PeriodFrom != nil && req.PeriodTo != nil && !req.PeriodTo.After(*req.PeriodFrom) {
+		return nil, ErrExportRange
+	}
+
+	e := &models.AccountingExport{
+		Format:      req.Format,
+		Incremental: req.Incremental,
+		PeriodFrom:  req.PeriodFrom,
+		PeriodTo:    req.PeriodTo,
+		CreatedBy:   req.CreatedBy,
+	}
+
+	var err error
+	if e.InvoiceIDTo, e.PaymentIDTo, err = s.invoices.ExportBounds(ctx, exportSettle); err != nil {
+		return nil, err
+	}
+	if req.Incremental {
+		last, err := s.invoices.LastIncrementalExport(ctx)
+		switch {
+		case err == nil:
+			e.InvoiceIDFrom, e.PaymentIDFrom = last.InvoiceIDTo, last.PaymentIDTo
+		case !errors.Is(err, sql.ErrNoRows):
+			return nil, err
+		}
+		// границы не откатываются назад, даже если свежих строк пока нет
+		e.InvoiceIDTo = max(e.InvoiceIDTo, e.InvoiceIDFrom)
+		e.PaymentIDTo = max(e.PaymentIDTo, e.PaymentIDFrom)
+	}
+
+	docs, payments, err := s.load(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	e.InvoiceCount, e.PaymentCount = len(docs), len(payments)
+
+	if err := s.invoices.CreateExport(ctx, e); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, AuditEvent{
+		Action: "accounting.export", EntityType: "accounting_export", EntityID: e.ID, After: e,
+	})
+
+	return e, writeLedger(w, e.Format, buildLedger(e, docs, payments))
+}
+
+// Redownload — повтор выгрузки id в любом формате: тот же состав документов и оплат
+func (s *AccountingExportService) Redownload(ctx context.Context, id int64, format string, w io.Writer) (*models.AccountingExport, error) {
+	e, err := s.invoices.GetExport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = e.Format
+	}
+	if !validExportFormat(format) {
+		return nil, ErrExportFormat
+	}
+
+	docs, payments, err := s.load(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	return e, writeLedger(w, format, buildLedger(e, docs, payments))
+}
+
+func (s *AccountingExportService) List(ctx context.Context, limit, offset int) ([]models.AccountingExport, error) {
+	limit, offset = normalizePage(limit, offset)
+	return s.invoices.ListExports(ctx, limit, offset)
+}
+
+func (s *AccountingExportService) load(ctx context.Context, e *models.AccountingExport) ([]models.ExportDocument, []models.ExportPayment, error) {
+	docs, err := s.invoices.ExportDocuments(ctx, e)
+	if err != nil {
+		return nil, nil, err
+	}
+	payments, err := s.invoices.ExportPayments(ctx, e)
+	if err != nil {
+		return nil, nil, err
+	}
+	return docs, payments, nil
+}
+
+//
+// ---------- LEDGER ----------
+//
+// стабильные идентификаторы для учётной системы
+func docRef(id int64) string     { return "mw-doc-" + strconv.FormatInt(id, 10) }
+func lineRef(id int64) string    { return "mw-line-" + strconv.FormatInt(id, 10) }
+func paymentRef(id int64) string { return "mw-pay-" + strconv.FormatInt(id, 10) }
+
+func ledgerDate(t time.Time) string { return t.UTC().Format("2006-01-02") }
+
+func buildLedger(e *models.AccountingExport, docs []models.ExportDocument, payments []models.ExportPayment) *models.AccountingLedger {
+	l := &models.AccountingLedger{
+		ExportID:    e.ID,
+		GeneratedAt: time.Now().UTC(),
+		PeriodFrom:  e.PeriodFrom,
+		PeriodTo:    e.PeriodTo,
+		Documents:   make([]models.LedgerDocument, 0, len(docs)),
+		Payments:    make([]models.LedgerPayment, 0, len(payments)),
+	}
+
+	for _, d := range docs {
+		amount := func(v money.Decimal) string { return v.StringFixed(money.MinorUnits(d.Currency)) }
+
+		doc := models.LedgerDocument{
+			ID:               docRef(d.ID),
+			Number:           d.InvoiceNumber,
+			Type:             d.DocumentType,
+			Reason:           d.Reason,
+			CompanyID:        d.CompanyID,
+			CompanyName:      d.CompanyName,
+			CompanyLegalName: d.CompanyLegalName,
+			CompanyVAT:       d.CompanyVAT,
+			IssueDate:        ledgerDate(d.IssuedAt),
+			PeriodStart:      ledgerDate(d.PeriodStart),
+			PeriodEnd:        ledgerDate(d.PeriodEnd),
+			Currency:         d.Currency,
+			TaxJurisdiction:  d.TaxJurisdiction,
+			Net:              amount(d.AmountNet),
+			Tax:              amount(d.AmountTax),
+			Total:            amount(d.AmountTotal),
+			Lines:            make([]models.LedgerLine, 0, len(d.Lines)),
+		}
+		if d.OriginalInvoiceID != nil {
+			doc.OriginalID = docRef(*d.OriginalInvoiceID)
+		}
+		if d.DueDate != nil {
+			doc.DueDate = ledgerDate(*d.DueDate)
+		}
+
+		for _, ln := range d.Lines {
+			line := models.LedgerLine{
+				ID:          lineRef(ln.ID),
+				Type:        ln.LineType,
+				Description: ln.Description,
+				CampaignID:  ln.CampaignID,
+				FacadeID:    ln.FacadeID,
+				Quantity:    ln.Quantity.String(),
+				Unit:        ln.Unit,
+				UnitPrice:   ln.UnitPrice.String(),
+				TaxRate:     ln.TaxRate.String(),
+				Net:         amount(ln.AmountNet),
+				Tax:         amount(ln.AmountTax),
+				Gross:       amount(ln.AmountGross),
+			}
+			if ln.Currency != "" && ln.Currency != d.Currency {
+				line.PriceCurrency = ln.Currency
+				if ln.FXRate != nil {
+					line.FXRate = ln.FXRate.String()
+				}
+			}
+			doc.Lines = append(doc.Lines, line)
+		}
+		l.Documents = append(l.Documents, doc)
+	}
+
+	for _, p := range payments {
+		l.Payments = append(l.Payments, models.LedgerPayment{
+			ID:         paymentRef(p.ID),
+			DocumentID: docRef(p.InvoiceID),
+			Number:     p.InvoiceNumber,
+			CompanyID:  p.CompanyID,
+			Date:       ledgerDate(p.PaidAt),
+			Amount:     p.Amount.StringFixed(money.MinorUnits(p.Currency)),
+			Currency:   p.Currency,
+			Method:     p.Method,
+			Reference:  p.Reference,
+		})
+	}
+	return l
+}
+
+func writeLedger(w io.Writer, format string, l *models.AccountingLedger) error {
+	switch format {
+	case ExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(l)
+	case ExportXML:
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		if err := enc.Encode(l); err != nil {
+			return err
+		}
+		return enc.Close()
+	case ExportCSV:
+		return writeLedgerCSV(w, l)
+	}
+	return ErrExportFormat
+}
+
+//
+// ---------- CSV ----------
+//
+// writeLedgerCSV — три таблицы в zip; строки и оплаты ссылаются на document_id
+func writeLedgerCSV(w io.Writer, l *models.AccountingLedger) error {
+	z := zip.NewWriter(w)
+
+	optID := func(id *int64) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatInt(*id, 10)
+	}
+
+	tables := []struct {
+		name   string
+		header []string
+		rows   func(add func(...string) error) error
+	}{
+		{
+			name: "invoices.csv",
+			header: []string{
+				"document_id", "number", "type", "original_document_id", "reason",
+				"company_id", "company_name", "company_legal_name", "company_vat",
+				"issue_date", "due_date", "period_start", "period_end",
+				"currency", "tax_jurisdiction", "net", "tax", "total",
+			},
+			rows: func(add func(...string) error) error {
+				for _, d := range l.Documents {
+					if err := add(
+						d.ID, d.Number, d.Type, d.OriginalID, d.Reason,
+						strconv.FormatInt(d.CompanyID, 10), d.CompanyName, d.CompanyLegalName, d.CompanyVAT,
+						d.IssueDate, d.DueDate, d.PeriodStart, d.PeriodEnd,
+						d.Currency, d.TaxJurisdiction, d.Net, d.Tax, d.Total,
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			name: "invoice_lines.csv",
+			header: []string{
+				"line_id", "document_id", "document_number", "type", "description",
+				"campaign_id", "facade_id", "quantity", "unit", "unit_price",
+				"price_currency", "fx_rate", "tax_rate", "net", "tax", "gross",
+			},
+			rows: func(add func(...string) error) error {
+				for _, d := range l.Documents {
+					for _, ln := range d.Lines {
+						if err := add(
+							ln.ID, d.ID, d.Number, ln.Type, ln.Description,
+							optID(ln.CampaignID), optID(ln.FacadeID), ln.Quantity, ln.Unit, ln.UnitPrice,
+							ln.PriceCurrency, ln.FXRate, ln.TaxRate, ln.Net, ln.Tax, ln.Gross,
+						); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			},
+		},
+		{
+			name: "payments.csv",
+			header: []string{
+				"payment_id", "document_id", "document_number", "company_id",
+				"date", "amount", "currency", "method", "reference",
+			},
+			rows: func(add func(...string) error) error {
+				for _, p := range l.Payments {
+					if err := add(
+						p.ID, p.DocumentID, p.Number, strconv.FormatInt(p.CompanyID, 10),
+						p.Date, p.Amount, p.Currency, p.Method, p.Reference,
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, t := range tables {
+		f, err := z.Create(t.name)
+		if err != nil {
+			return err
+		}
+		cw := csv.NewWriter(f)
+		if err := cw.Write(t.header); err != nil {
+			return err
+		}
+		if err := t.rows(func(v ...string) error { return cw.Write(v) }); err != nil {
+			return fmt.Errorf("%s: %w", t.name, err)
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
@@ -6,23 +6,139 @@ import (
     "mediawork/internal/repositories"
 )
 
+// GlobalRoles — допустимые глобальные роли (см. Role на фронте)
+var GlobalRoles = map[string]bool{"admin": true, "manager": true, "viewer": true}
+
 type AdminService struct {
-    users    *repositories.UserRepository
-    companies *repositories.CompanyRepository
+    users     UserRepository
+    companies CompanyRepository
+    members   CompanyMembershipRepository
+    auth      *AuthService
+    audit     *AuditService
+}
+
+func NewAdminService(
+    u UserRepository,
+    c CompanyRepository,
+    m CompanyMembershipRepository,
+    auth *AuthService,
+    a *AuditService,
+) *AdminService {
+    return &AdminService{users: u, companies: c, members: m, auth: auth, audit: a}
 }
 
-func NewAdminService(u *repositories.UserRepository, c *repositories.CompanyRepository) *AdminService {
-    return &AdminService{users: u, companies: c}
+// normalizePage — лимит 1..200 (по умолчанию 50), offset >= 0
+func normalizePage(limit, offset int) (int, int) {
+    if limit <= 0 || limit > 200 {
+        limit = 50
+    }
+    if offset < 0 {
+        offset = 0
+    }
+    return limit, offset
 }
 
-func (s *AdminService) ListUsers(ctx context.Context, limit int, offset int) ([]models.User, error) {
-    return s.users.List(ctx, limit, offset)
+// ListUsers — страница пользователей (фильтры и сортировки — repositories.UserList)
+func (s *AdminService) ListUsers(ctx context.Context, q models.ListQuery) (*models.Page[models.User], error) {
+    if err := prepareList(&q, repositories.UserList); err != nil {
+        return nil, err
+    }
+    rows, err := s.users.List(ctx, q)
+    if err != nil {
+        return nil, err
+    }
+    return newPage(rows, q, repositories.UserList), nil
 }
 
-func (s *AdminService) ListCompanies(ctx context.Context, limit int, offset int) ([]models.Company, error) {
-    return s.companies.List(ctx, limit, offset)
+// GetUser — пользователь + его компании и роли в них
+func (s *AdminService) GetUser(ctx context.Context, id int64) (*models.UserProfile, error) {
+    u, err := s.users.GetByID(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+    companies, err := s.members.ListUserMemberships(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+    return &models.UserProfile{User: u, Companies: companies}, nil
+}
+
+// ListCompanies — страница компаний (фильтры и сортировки — repositories.CompanyList)
+func (s *AdminService) ListCompanies(ctx context.Context, q models.ListQuery) (*models.Page[models.Company], error) {
+    if err := prepareList(&q, repositories.CompanyList); err != nil {
+        return nil, err
+    }
+    rows, err := s.companies.List(ctx, q)
+    if err != nil {
+        return nil, err
+    }
+    return newPage(rows, q, repositories.CompanyList), nil
 }
 
 func (s *AdminService) SetRole(ctx context.Context, id int64, role string) error {
-    return s.users.UpdateRole(ctx, id, role)
+    if !GlobalRoles[role] {
+        return invalidf("role", "unknown role %q", role)
+    }
+
+    before, err := s.users.GetByID(ctx, id)
+    if err != nil {
+        return err
+    }
+    if err := s.users.UpdateRole(ctx, id, role); err != nil {
+        return err
+    }
+
+    after := *before
+    after.Role = role
+    s.audit.Record(ctx, AuditEvent{
+        Action: "user.role_change", EntityType: "user", EntityID: id,
+        Before: before, After: &after,
+    })
+    return nil
+}
+
+// SetActive отключает / включает аккаунт. Отключённый пользователь не может войти,
+// а уже выданные токены отклоняет AuthMiddleware.
+func (s *AdminService) SetActive(ctx context.Context, adminID, id int64, active bool) error {
+    if !active && adminID == id {
+        return invalid("", "cannot disable your own account")
+    }
+
+    before, err := s.users.GetByID(ctx, id)
+    if err != nil {
+        return err
+    }
+    if before.IsActive == active {
+        return nil
+    }
+    if err := s.users.SetActive(ctx, id, active); err != nil {
+        return err
+    }
+
+    action := "user.enable"
+    if !active {
+        action = "user.disable"
+    }
+    after := *before
+    after.IsActive = active
+    s.audit.Record(ctx, AuditEvent{
+        Action: action, EntityType: "user", EntityID: id,
+        Before: before, After: &after,
+    })
+    return nil
+}
+
+// Impersonate — токен для входа под пользователем (поддержка).
+// Всё, что делается с этим токеном, попадает в audit_log с impersonator_id.
+func (s *AdminService) Impersonate(ctx context.Context, adminID, id int64) (string, *models.User, error) {
+    token, user, err := s.auth.Impersonate(ctx, adminID, id)
+    if err != nil {
+        return "", nil, err
+    }
+
+    s.audit.Record(ctx, AuditEvent{
+        Action: "user.impersonate", EntityType: "user", EntityID: id,
+        After: map[string]any{"admin_id": adminID, "expires_in_sec": int(ImpersonationTTL.Seconds())},
+    })
+    return token, user, nil
 }
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"mediawork/internal/models"
+)
+
+type AnalyticsService struct {
+	analytics AnalyticsRepository
+	campaigns CampaignRepository
+	slots     CampaignSlotsRepository
+	audience  AudienceRepository
+}
+
+func NewAnalyticsService(
+	a AnalyticsRepository,
+	c CampaignRepository,
+	s CampaignSlotsRepository,
+	au AudienceRepository,
+) *AnalyticsService {
+	return &AnalyticsService{analytics: a, campaigns: c, slots: s, audience: au}
+}
+
+// ---------- DEFAULT RANGE ----------
+// Если период не задан — от старта кампании до её конца (или до текущего момента)
+func (s *AnalyticsService) resolveRange(c *models.Campaign, from, to *time.Time) (time.Time, time.Time) {
+	start, end := c.StartTime, time.Now().UTC()
+	if !c.EndTime.IsZero() && c.EndTime.Before(end) {
+		end = c.EndTime
+	}
+	if from != nil {
+		start = *from
+	}
+	if to != nil {
+		end = *to
+	}
+	return start, end
+}
+
+//
+// ---------- DELIVERY SUMMARY (+ PACING) ----------
+//
+func (s *AnalyticsService) Delivery(ctx context.Context, campaignID int64, from, to *time.Time) (*models.CampaignDelivery, error) {
+	c, err := s.campaigns.GetByID(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	start, end := s.resolveRange(c, from, to)
+
+	d, err := s.analytics.CampaignTotals(ctx, campaignID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	slots, err := s.slots.GetSlotsByCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	d.BookedSec = BookedSeconds(slots, c, start, end)
+	if d.BookedSec > 0 {
+		d.Pacing = float64(d.AirtimeSec) / float64(d.BookedSec)
+	}
+	return d, nil
+}
+
+//
+// ---------- BREAKDOWN BY FACADE / DAY / HOUR ----------
+//
+func (s *AnalyticsService) Breakdown(
+	ctx context.Context,
+	campaignID int64,
+	by string,
+	from, to *time.Time,
+) ([]models.DeliveryBreakdownRow, error) {
+	c, err := s.campaigns.GetByID(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	start, end := s.resolveRange(c, from, to)
+
+	return s.analytics.CampaignBreakdown(ctx, campaignID, start, end, by)
+}
+
+//
+// ---------- ESTIMATED IMPRESSIONS / REACH / CPM ----------
+//
+func (s *AnalyticsService) Impressions(ctx context.Context, campaignID int64, from, to *time.Time) (*models.CampaignImpressions, error) {
+	c, err := s.campaigns.GetByID(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	start, end := s.resolveRange(c, from, to)
+
+	facades, err := s.audience.CampaignImpressions(ctx, campaignID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.CampaignImpressions{
+		CampaignID: campaignID,
+		From:       start,
+		To:         end,
+		Facades:    facades,
+	}
+
+	for i := range facades {
+		f := &facades[i]
+		f.Frequency = frequency(f.Impressions, f.Reach)
+		f.CPM = cpm(f.Cost, f.Impressions)
+
+		res.Plays += f.Plays
+		res.AirtimeSec += f.AirtimeSec
+		res.Impressions += f.Impressions
+		// аудитории разных фасадов считаем непересекающимися — это верхняя оценка охвата
+		res.Reach += f.Reach
+
+		switch {
+		case f.Currency == "" || res.Currency == "mixed":
+		case res.Currency == "":
+			res.Currency = f.Currency
+		case res.Currency != f.Currency:
+			res.Currency = "mixed"
+		}
+		res.Cost += f.Cost
+	}
+
+	res.Frequency = frequency(res.Impressions, res.Reach)
+	if res.Currency == "mixed" {
+		// складывать разные валюты бессмысленно — смотрите разбивку по фасадам
+		res.Cost = 0
+	}
+	res.CPM = cpm(res.Cost, res.Impressions)
+
+	return res, nil
+}
+
+func frequency(impressions, reach float64) float64 {
+	if reach <= 0 {
+		return 0
+	}
+	return math.Round(impressions/reach*100) / 100
+}
+
+func cpm(cost, impressions float64) float64 {
+	if impressions <= 0 {
+		return 0
+	}
+	return math.Round(cost/impressions*1000*100) / 100
+}
+
+// BookedSeconds — сколько секунд эфира забронировано слотами кампании в [from, to).
+// Слот — еженедельное окно day_of_week + start_time..end_time (UTC), время кампании
+// вне start/end не считается.
+func BookedSeconds(slots []models.CampaignSlot, c *models.Campaign, from, to time.Time) int64 {
+	if !c.StartTime.IsZero() && from.Before(c.StartTime) {
+		from = c.StartTime
+	}
+	if !c.EndTime.IsZero() && to.After(c.EndTime) {
+		to = c.EndTime
+	}
+	if !from.Before(to) {
+		return 0
+	}
+
+	var total time.Duration
+	for d := truncDay(from); d.Before(to); d = d.Add(oneDay) {
+		for _, slot := range slots {
+			if slot.DayOfWeek != int(d.Weekday()) {
+				continue
+			}
+			startOff, ok1 := clockOffset(slot.StartTime)
+			endOff, ok2 := clockOffset(slot.EndTime)
+			if !ok1 || !ok2 || endOff <= startOff {
+				continue
+			}
+
+			winStart, winEnd := d.Add(startOff), d.Add(endOff)
+			if winStart.Before(from) {
+				winStart = from
+			}
+			if winEnd.After(to) {
+				winEnd = to
+			}
+			if winEnd.After(winStart) {
+				total += winEnd.Sub(winStart)
+			}
+		}
+	}
+
+	return int64(total / time.Second)
+}
+
+// clockOffset разбирает "HH:MM" / "HH:MM:SS" (так отдаёт postgres TIME) в смещение от полуночи
+func clockOffset(v string) (time.Duration, bool) {
+	if len(v) >= 8 && strings.Count(v, ":") == 2 {
+		v = v[:8]
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return time.Duration(t.Hour())*time.Hour +
+				time.Duration(t.Minute())*time.Minute +
+				time.Duration(t.Second())*time.Second, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mediawork/internal/models"
+)
+
+// Scopes API-ключа
+const (
+	ScopeCampaignsRead  = "campaigns:read"
+	ScopeCampaignsWrite = "campaigns:write"
+	ScopeAnalyticsRead  = "analytics:read"
+	ScopeInvoicesRead   = "invoices:read"
+)
+
+var APIKeyScopes = []string{ScopeCampaignsRead, ScopeCampaignsWrite, ScopeAnalyticsRead, ScopeInvoicesRead}
+
+// APIKeyPrefix — по нему AuthMiddleware отличает ключ от JWT
+const APIKeyPrefix = "mwk_"
+
+// apiKeyTouchEvery — last_used_at обновляется не чаще
+const apiKeyTouchEvery = time.Minute
+
+var (
+	ErrAPIKeyInvalid = errors.New("invalid api key")
+	ErrAPIKeyScopes  = invalid("scopes", "scopes must list known api key scopes")
+)
+
+// APIKeyService — ключи компаний для автоматизации вместо JWT пользователя
+type APIKeyService struct {
+	keys      APIKeyRepository
+	companies CompanyRepository
+	members   CompanyMembershipRepository
+	audit     *AuditService
+}
+
+func NewAPIKeyService(
+	keys APIKeyRepository,
+	companies CompanyRepository,
+	members CompanyMembershipRepository,
+	audit *AuditService,
+) *APIKeyService {
+	return &APIKeyService{keys: keys, companies: companies, members: members, audit: audit}
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAPIKey — mwk_ + 48 hex-символов; prefix (первые 12 символов) виден в списке ключей
+func newAPIKey() (key, prefix string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key = APIKeyPrefix + hex.EncodeToString(b)
+	return key, key[:len(APIKeyPrefix)+8], nil
+}
+
+// HasScope — есть ли у запроса право scope. Пользователь по JWT ограничен ролями, а не scopes.
+func HasScope(claims *models.UserClaims, scope string) bool {
+	if claims == nil {
+		return false
+	}
+	if claims.APIKeyID == 0 {
+		return true
+	}
+	for _, s := range claims.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func validateScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrAPIKeyScopes
+	}
+	seen := map[string]bool{}
+	list := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		known := false
+		for _, k := range APIKeyScopes {
+			known = known || k == s
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: unknown %q", ErrAPIKeyScopes, s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+//
+// ---------- MANAGEMENT (company admin) ----------
+//
+func (s *APIKeyService) List(ctx context.Context, companyID int64, actor *models.UserClaims) ([]models.APIKey, error) {
+	if err := requireCompanyAdmin(ctx, s.members, companyID, actor); err != nil {
+		return nil, err
+	}
+	return s.keys.ListByCompany(ctx, companyID)
+}
+
+// Create выпускает ключ; k.Key заполнен только в ответе на этот вызов
+func (s *APIKeyService) Create(ctx context.Context, k *models.APIKey, actor *models.UserClaims) error {
+	if err := requireCompanyAdmin(ctx, s.members, k.CompanyID, actor); err != nil {
+		return err
+	}
+	if err := ensureCompanyActive(ctx, s.companies, k.CompanyID); err != nil {
+		return err
+	}
+
+	k.Name = strings.TrimSpace(k.Name)
+	if k.Name == "" {
+		return invalid("name", "name is required")
+	}
+	scopes, err := validateScopes(k.Scopes)
+	if err != nil {
+		return err
+	}
+	k.Scopes = scopes
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return invalid("expires_at", "expires_at must be in the future")
+	}
+
+	key, prefix, err := newAPIKey()
+	if err != nil {
+		return err
+	}
+	k.Prefix = prefix
+	k.CreatedBy = nullableID(actor.UserID)
+	if err := s.keys.Create(ctx, k, hashAPIKey(key)); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "api_key.create", EntityType: "api_key", EntityID: k.ID, CompanyID: k.CompanyID,
+		After: *k,
+	})
+	k.Key = key
+	return nil
+}
+
+// Revoke — ключ перестаёт работать сразу; запись остаётся для истории
+func (s *APIKeyService) Revoke(ctx context.Context, companyID, id int64, actor *models.UserClaims) (*models.APIKey, error) {
+	if err := requireCompanyAdmin(ctx, s.members, companyID, actor); err != nil {
+		return nil, err
+	}
+	before, err := s.keys.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if before.CompanyID != companyID {
+		return nil, sql.ErrNoRows
+	}
+	if err := s.keys.Revoke(ctx, id, nullableID(actor.UserID)); err != nil {
+		return nil, err
+	}
+	after, err := s.keys.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "api_key.revoke", EntityType: "api_key", EntityID: id, CompanyID: companyID,
+		Before: before, After: after,
+	})
+	return after, nil
+}
+
+//
+// ---------- AUTHENTICATE ----------
+//
+// Authenticate проверяет ключ и собирает claims запроса: компания ключа, scopes,
+// Email вида "api-key:mwk_xxxxxxxx" — так ключ виден в audit_log.
+func (s *APIKeyService) Authenticate(ctx context.Context, key, ip string) (*models.UserClaims, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+	k, err := s.keys.GetActiveByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		return nil, ErrAPIKeyInvalid
+	}
+	// ключи деактивированной компании не работают, как и доступ её участников
+	if err := ensureCompanyActive(ctx, s.companies, k.CompanyID); err != nil {
+		return nil, err
+	}
+
+	if err := s.keys.Touch(ctx, k.ID, ip, apiKeyTouchEvery); err != nil {
+		slog.WarnContext(ctx, "api keys: touch", "api_key_id", k.ID, "err", err)
+	}
+	return &models.UserClaims{
+		Email:     "api-key:" + k.Prefix,
+		Role:      "api_key",
+		APIKeyID:  k.ID,
+		CompanyID: k.CompanyID,
+		Scopes:    k.Scopes,
+	}, nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"reflect"
+
+	"mediawork/internal/audit"
+	"mediawork/internal/models"
+)
+
+// AuditEvent — что записать в журнал. Before/After — любые сериализуемые в JSON значения
+// (обычно модели до и после изменения); nil для создания / удаления.
+type AuditEvent struct {
+	Action     string
+	EntityType string
+	EntityID   int64
+	CompanyID  int64
+	Before     any
+	After      any
+}
+
+type AuditService struct {
+	repo AuditRepository
+}
+
+func NewAuditService(repo AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+//
+// ---------- RECORD ----------
+//
+// Ошибка записи в журнал не отменяет уже выполненное изменение — только логируется.
+// Безопасно вызывать на nil *AuditService.
+func (s *AuditService) Record(ctx context.Context, ev AuditEvent) {
+	if s == nil {
+		return
+	}
+
+	e := models.AuditEntry{
+		Action:     ev.Action,
+		EntityType: ev.EntityType,
+	}
+	if ev.EntityID != 0 {
+		e.EntityID = &ev.EntityID
+	}
+	if ev.CompanyID != 0 {
+		e.CompanyID = &ev.CompanyID
+	}
+
+	if a := audit.ActorFrom(ctx); a != nil {
+		if a.UserID != 0 {
+			id := a.UserID
+			e.ActorUserID = &id
+		}
+		e.ActorEmail = a.Email
+		if a.ImpersonatorID != 0 {
+			imp := a.ImpersonatorID
+			e.ImpersonatorID = &imp
+		}
+		e.RequestID = a.RequestID
+		e.IP = a.IP
+	}
+
+	var beforeMap, afterMap map[string]any
+	e.Before, beforeMap = snapshot(ev.Before)
+	e.After, afterMap = snapshot(ev.After)
+	e.Diff = diffMaps(beforeMap, afterMap)
+
+	// запрос мог уже завершиться, а запись в журнал терять нельзя
+	if err := s.repo.Insert(context.WithoutCancel(ctx), &e); err != nil {
+		slog.ErrorContext(ctx, "audit: cannot record", "action", ev.Action,
+			"entity_type", ev.EntityType, "entity_id", ev.EntityID, "err", err)
+	}
+}
+
+func (s *AuditService) List(ctx context.Context, f models.AuditFilter) ([]models.AuditEntry, error) {
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	return s.repo.List(ctx, f)
+}
+
+func snapshot(v any) (json.RawMessage, map[string]any) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil
+	}
+
+	m := map[string]any{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		// не объект (число, строка) — сравниваем целиком
+		var scalar any
+		json.Unmarshal(raw, &scalar)
+		return raw, map[string]any{"value": scalar}
+	}
+	return raw, m
+}
+
+// diffMaps — изменения верхнего уровня: ключ → {from, to}
+func diffMaps(before, after map[string]any) map[string]models.AuditChange {
+	diff := map[string]models.AuditChange{}
+	for k, b := range before {
+		if a, ok := after[k]; !ok || !reflect.DeepEqual(a, b) {
+			diff[k] = models.AuditChange{From: b, To: after[k]}
+		}
+	}
+	for k, a := range after {
+		if _, ok := before[k]; !ok {
+			diff[k] = models.AuditChange{From: nil, To: a}
+		}
+	}
+	// updated_at меняется при любом изменении и только шумит
+	delete(diff, "updated_at")
+	return diff
+}
+
+// ClientIP — адрес без порта (handlers.RealIP уже подставил адрес из X-Forwarded-For / X-Real-IP,
+// если запрос пришёл от доверенного прокси)
+func ClientIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
@@ -3,9 +3,9 @@ package services
 import (
 	"context"
 	"errors"
-	"log"
 	"mediawork/internal/models"
-	"mediawork/internal/repositories"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -13,11 +13,11 @@ import (
 )
 
 type AuthService struct {
-    users *repositories.UserRepository
+    users UserRepository
     jwtSecret []byte
 }
 
-func NewAuthService(users *repositories.UserRepository, secret string) *AuthService {
+func NewAuthService(users UserRepository, secret string) *AuthService {
     return &AuthService{
         users: users,
         jwtSecret: []byte(secret),
@@ -25,6 +25,13 @@ func NewAuthService(users *repositories.UserRepository, secret string) *AuthServ
 }
 
 var ErrInvalidCredentials = errors.New("invalid email or password")
+var ErrAccountDisabled = errors.New("account is disabled")
+
+// MinPasswordLength — минимальная длина пароля при регистрации
+const MinPasswordLength = 8
+
+// ImpersonationTTL — срок жизни токена, выданного админом для входа под пользователем
+const ImpersonationTTL = time.Hour
 
 //
 // ------------------------ LOGIN ------------------------
@@ -34,18 +41,18 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (string
     if err != nil {
         return "", nil, ErrInvalidCredentials
     }
-    log.Println("Fetched user for login:", user)
-
-    // if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-    //     return "", nil, ErrInvalidCredentials
-    // }
-    log.Println("Password verified for user:", user.Email)
+    if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+        return "", nil, ErrInvalidCredentials
+    }
+    // после пароля: иначе по ответу можно узнать, что адрес зарегистрирован
+    if !user.IsActive {
+        return "", nil, ErrAccountDisabled
+    }
 
-    token, err := s.generateJWT(user)
+    token, err := s.generateJWT(user, 0, 24*time.Hour)
     if err != nil {
         return "", nil, err
     }
-    log.Println("JWT generated for user:", user.Email)
 
     return token, user, nil
 }
@@ -54,17 +61,28 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (string
 // ------------------------ REGISTER ------------------------
 //
 func (s *AuthService) Register(ctx context.Context, email, password, name string) (*models.User, error) {
-    hashed, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    addr, err := mail.ParseAddress(strings.TrimSpace(email))
+    if err != nil {
+        return nil, invalidf("email", "invalid email %q", email)
+    }
+    if len(password) < MinPasswordLength {
+        return nil, invalidf("password", "password must be at least %d characters", MinPasswordLength)
+    }
+
+    hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    if err != nil {
+        return nil, err
+    }
 
     user := &models.User{
-        Email:        email,
+        Email:        addr.Address,
         PasswordHash: string(hashed),
         FullName:     name,
         Name:         name,
         Role:         "viewer",
     }
 
-    _, err := s.users.Create(ctx, user)
+    _, err = s.users.Create(ctx, user)
     return user, err
 }
 
@@ -72,12 +90,15 @@ func (s *AuthService) Register(ctx context.Context, email, password, name string
 //
 // ------------------------ JWT ------------------------
 //
-func (s *AuthService) generateJWT(user *models.User) (string, error) {
+func (s *AuthService) generateJWT(user *models.User, impersonatorID int64, ttl time.Duration) (string, error) {
     claims := jwt.MapClaims{
         "sub": user.ID,
         "email": user.Email,
         "role": user.Role,
-        "exp": time.Now().Add(24 * time.Hour).Unix(),
+        "exp": time.Now().Add(ttl).Unix(),
+    }
+    if impersonatorID != 0 {
+        claims["imp"] = impersonatorID
     }
 
     token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -96,9 +117,61 @@ func (s *AuthService) ParseToken(tokenStr string) (*models.UserClaims, error) {
     }
 
     claims := tok.Claims.(jwt.MapClaims)
-    return &models.UserClaims{
+    uc := &models.UserClaims{
         UserID: int64(claims["sub"].(float64)),
         Email: claims["email"].(string),
         Role: claims["role"].(string),
-    }, nil
+    }
+    if imp, ok := claims["imp"].(float64); ok {
+        uc.ImpersonatorID = int64(imp)
+    }
+    return uc, nil
+}
+
+//
+// ------------------------ AUTHENTICATE ------------------------
+//
+// Authenticate — ParseToken + проверка, что аккаунт не отключён после выдачи токена
+func (s *AuthService) Authenticate(ctx context.Context, tokenStr string) (*models.UserClaims, error) {
+    claims, err := s.ParseToken(tokenStr)
+    if err != nil {
+        return nil, err
+    }
+
+    active, err := s.users.IsActive(ctx, claims.UserID)
+    if err != nil {
+        return nil, errors.New("invalid token")
+    }
+    if !active {
+        return nil, ErrAccountDisabled
+    }
+    return claims, nil
+}
+
+//
+// ------------------------ IMPERSONATE ------------------------
+//
+// Impersonate выдаёт короткоживущий токен пользователя с пометкой админа (claim "imp").
+// Под другого админа или отключённого пользователя войти нельзя.
+func (s *AuthService) Impersonate(ctx context.Context, adminID, userID int64) (string, *models.User, error) {
+    if adminID == userID {
+        return "", nil, invalid("", "cannot impersonate yourself")
+    }
+
+    user, err := s.users.GetByID(ctx, userID)
+    if err != nil {
+        return "", nil, err
+    }
+    if !user.IsActive {
+        return "", nil, ErrAccountDisabled
+    }
+    if user.Role == "admin" {
+        return "", nil, invalid("", "cannot impersonate another admin")
+    }
+
+    token, err := s.generateJWT(user, adminID, ImpersonationTTL)
+    if err != nil {
+        return "", nil, err
+    }
+    return token, user, nil
 }
@@ -0,0 +1,166 @@
+package services_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"mediawork/internal/models"
+	"mediawork/internal/repositories/memory"
+	"mediawork/internal/services"
+)
+
+const userPassword = "correct horse"
+
+// authEnv — демо-админ, активный и отключённый пользователи и ещё один админ
+type authEnv struct {
+	*env
+	adminID, userID, disabledID, otherAdminID int64
+}
+
+func newAuthEnv(t *testing.T) *authEnv {
+	t.Helper()
+	e := &authEnv{env: newEnv(t)}
+	ctx := context.Background()
+
+	admin, err := e.repos.Users.GetByEmail(ctx, memory.DemoAdminEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.adminID = admin.ID
+
+	register := func(email string) int64 {
+		u, err := e.auth.Register(ctx, email, userPassword, email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u.ID
+	}
+	e.userID = register("user@example.com")
+	e.disabledID = register("disabled@example.com")
+	e.otherAdminID = register("admin2@example.com")
+
+	if err := e.admin.SetActive(ctx, e.adminID, e.disabledID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.admin.SetRole(ctx, e.otherAdminID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestLogin(t *testing.T) {
+	e := newAuthEnv(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name, email, password string
+		wantErr               error
+		role                  string
+	}{
+		{name: "admin", email: memory.DemoAdminEmail, password: memory.DemoAdminPassword, role: "admin"},
+		{name: "user", email: "user@example.com", password: userPassword, role: "viewer"},
+		{name: "wrong password", email: "user@example.com", password: "wrong", wantErr: services.ErrInvalidCredentials},
+		{name: "unknown email", email: "nobody@example.com", password: userPassword, wantErr: services.ErrInvalidCredentials},
+		{name: "disabled", email: "disabled@example.com", password: userPassword, wantErr: services.ErrAccountDisabled},
+		// без верного пароля отключённый аккаунт не отличить от чужого
+		{name: "disabled wrong password", email: "disabled@example.com", password: "wrong", wantErr: services.ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, user, err := e.auth.Login(ctx, tt.email, tt.password)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || token != "" {
+					t.Fatalf("Login = %q, %v, want %v", token, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Login: %v", err)
+			}
+
+			claims, err := e.auth.Authenticate(ctx, token)
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if claims.UserID != user.ID || claims.Email != tt.email || claims.Role != tt.role || claims.ImpersonatorID != 0 {
+				t.Errorf("claims = %+v, want user %d %s role %s", *claims, user.ID, tt.email, tt.role)
+			}
+		})
+	}
+}
+
+// TestAuthenticateDisabled — токен, выданный до отключения аккаунта, больше не принимается
+func TestAuthenticateDisabled(t *testing.T) {
+	e := newAuthEnv(t)
+	ctx := context.Background()
+
+	token, _, err := e.auth.Login(ctx, "user@example.com", userPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.admin.SetActive(ctx, e.adminID, e.userID, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.auth.Authenticate(ctx, token); !errors.Is(err, services.ErrAccountDisabled) {
+		t.Errorf("Authenticate after disable = %v, want ErrAccountDisabled", err)
+	}
+
+	if err := e.admin.SetActive(ctx, e.adminID, e.adminID, false); !isValidation(err, "") {
+		t.Errorf("admin disabling themselves = %v, want validation error", err)
+	}
+}
+
+func TestImpersonate(t *testing.T) {
+	e := newAuthEnv(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		targetID int64
+		wantErr  error
+		invalid  bool
+	}{
+		{name: "user", targetID: e.userID},
+		{name: "self", targetID: e.adminID, invalid: true},
+		{name: "another admin", targetID: e.otherAdminID, invalid: true},
+		{name: "disabled", targetID: e.disabledID, wantErr: services.ErrAccountDisabled},
+		{name: "missing", targetID: 999, wantErr: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, user, err := e.admin.Impersonate(ctx, e.adminID, tt.targetID)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Impersonate error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.invalid:
+				if !isValidation(err, "") {
+					t.Fatalf("Impersonate error = %v, want validation error", err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("Impersonate: %v", err)
+			}
+
+			claims, err := e.auth.Authenticate(ctx, token)
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if claims.UserID != user.ID || claims.UserID != tt.targetID || claims.ImpersonatorID != e.adminID {
+				t.Errorf("claims = %+v, want user %d impersonated by %d", *claims, tt.targetID, e.adminID)
+			}
+		})
+	}
+
+	entries, err := e.repos.Audit.List(ctx, models.AuditFilter{Action: "user.impersonate", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].EntityID == nil || *entries[0].EntityID != e.userID {
+		t.Errorf("audit has %d user.impersonate entries, want one for user %d", len(entries), e.userID)
+	}
+}
@@ -2,39 +2,579 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"mediawork/internal/models"
+	"mediawork/internal/money"
 	"mediawork/internal/repositories"
 )
 
 type BillingService struct {
-	invoices *repositories.InvoiceRepository
-	playRepo *repositories.PlayHistoryRepository
+	invoices  InvoiceRepository
+	companies CompanyRepository
+	payments  PaymentRepository
+	taxes     *TaxService
+	fx        *ExchangeRateService
+	dunning   *DunningService
+	audit     *AuditService
+	webhooks  *WebhookService
 }
 
-func NewBillingService(inv *repositories.InvoiceRepository, ph *repositories.PlayHistoryRepository) *BillingService {
-	return &BillingService{invoices: inv, playRepo: ph}
+func NewBillingService(
+	inv InvoiceRepository,
+	companies CompanyRepository,
+	payments PaymentRepository,
+	taxes *TaxService,
+	fx *ExchangeRateService,
+	dunning *DunningService,
+	audit *AuditService,
+	webhooks *WebhookService,
+) *BillingService {
+	return &BillingService{
+		invoices:  inv,
+		companies: companies,
+		payments:  payments,
+		taxes:     taxes,
+		fx:        fx,
+		dunning:   dunning,
+		audit:     audit,
+		webhooks:  webhooks,
+	}
 }
 
+// invoiceStatuses — статусы, которые задаются вручную (при выставлении и UpdateStatus);
+// partially_paid и paid выводятся только из журнала оплат (PaymentRepository.Record)
+var invoiceStatuses = map[string]bool{
+	"pending": true, "overdue": true, "failed": true,
+}
+
+var invoiceLineTypes = map[string]bool{
+	"campaign": true, "facade": true, "service": true, "manual": true,
+}
+
+// Пределы строки счёта: произведение и итоги считаются с проверкой переполнения
+// (money.ErrOverflow), а эти границы отсекают заведомо ошибочный ввод до расчёта
+var (
+	maxLineQuantity  = money.NewFromInt(1_000_000)
+	maxLineUnitPrice = money.NewFromInt(1_000_000_000)
+)
+
 // ---------- CALCULATE TOTAL COST FOR INVOICE ----------
-func (s *BillingService) Calculate(ctx context.Context, companyID int64, start, end string) (float64, error) {
-	return s.invoices.CalculateAmountForPeriod(ctx, companyID, start, end)
+// Calculate — сумма счетов компании за период в её billing currency.
+// Счета в других валютах пересчитываются по курсу на конец периода.
+func (s *BillingService) Calculate(ctx context.Context, companyID int64, start, end string) (*models.PeriodAmount, error) {
+	on, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return nil, invalidf("end", "invalid end date %q", end)
+	}
+	billing, err := s.companies.GetBilling(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := s.invoices.CalculateAmountForPeriod(ctx, companyID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.PeriodAmount{CompanyID: companyID, Currency: billing.Currency, Parts: parts}
+	for i := range res.Parts {
+		p := &res.Parts[i]
+		if p.Converted, p.Rate, err = s.fx.Convert(ctx, p.Amount, p.Currency, billing.Currency, on); err != nil {
+			return nil, err
+		}
+		if res.Total, err = res.Total.AddErr(p.Converted); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
 }
 
 // ---------- BUILD PDF MODEL ----------
 func (s *BillingService) PreparePDF(ctx context.Context, invoiceID int64) (*models.InvoicePDF, error) {
-	return s.invoices.PreparePDFData(ctx, invoiceID)
+	pdf, err := s.invoices.PreparePDFData(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// PreparePDFData читает только шапку счёта: документ целиком и реквизиты компании — отдельно
+	inv, err := s.GetByID(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	company, err := s.companies.GetByID(ctx, inv.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	pdf.Invoice, pdf.Company = *inv, *company
+	return pdf, nil
 }
 
-func (s *BillingService) List(ctx context.Context) ([]models.Invoice, error) {
-	return s.invoices.List(ctx) // лимит можешь поменять
+// List — страница документов (фильтры и сортировки — repositories.InvoiceList)
+func (s *BillingService) List(ctx context.Context, q models.ListQuery) (*models.Page[models.Invoice], error) {
+	if err := prepareList(&q, repositories.InvoiceList); err != nil {
+		return nil, err
+	}
+	rows, err := s.invoices.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(rows, q, repositories.InvoiceList), nil
 }
 
+// CompanyOf — компания счёта (проверка доступа по API-ключу)
+func (s *BillingService) CompanyOf(ctx context.Context, id int64) (int64, error) {
+	inv, err := s.invoices.GetByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return inv.CompanyID, nil
+}
+
+// GetByID — счёт вместе со строками
 func (s *BillingService) GetByID(ctx context.Context, id int64) (*models.Invoice, error) {
-	return s.invoices.GetByID(ctx, id)
+	inv, err := s.invoices.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.Lines, err = s.invoices.ListLines(ctx, id); err != nil {
+		return nil, err
+	}
+	if inv.DocumentType != repositories.DocInvoice {
+		if inv.Refs, err = s.invoices.GetRefs(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return inv, nil
 }
 
 // --------------------- CREATE INVOICE ---------------------
-func (s *BillingService) Create(ctx context.Context, inv *models.Invoice) (error) {
-    return s.invoices.Create(ctx, inv)
-}
\ No newline at end of file
+// Деактивированной компании счета не выставляются (инвойсинг заморожен).
+//
+// Счёт всегда выставляется в billing currency компании. Currency из запроса — валюта,
+// в которой указаны цены строк (по умолчанию billing currency); строки в другой валюте
+// пересчитываются по курсу на дату выставления. Налог — по ставке юрисдикции компании.
+// Без строк amount_total считается суммой с налогом и становится одной строкой manual.
+// Номер присваивается при выставлении (INV-<company>-<n>), после чего счёт не меняется.
+func (s *BillingService) Create(ctx context.Context, inv *models.Invoice) error {
+	if err := ensureCompanyActive(ctx, s.companies, inv.CompanyID); err != nil {
+		return err
+	}
+	billing, err := s.companies.GetBilling(ctx, inv.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	priceCurrency := billing.Currency
+	if inv.Currency != "" {
+		if priceCurrency, err = money.NormalizeCurrency(inv.Currency); err != nil {
+			return invalid("currency", err.Error())
+		}
+	}
+	if inv.IssuedAt.IsZero() {
+		inv.IssuedAt = time.Now()
+	}
+	if inv.Status == "" {
+		inv.Status = "pending"
+	}
+	if !invoiceStatuses[inv.Status] {
+		return invalidf("status", "status must be pending, overdue or failed, got %q", inv.Status)
+	}
+	on := truncDay(inv.IssuedAt)
+
+	taxRate, err := s.taxes.RateFor(ctx, billing, on)
+	if err != nil {
+		return err
+	}
+
+	if len(inv.Lines) == 0 {
+		if inv.AmountTotal.Sign() <= 0 {
+			return invalid("amount_total", "amount_total must be positive")
+		}
+		inv.Lines = []models.InvoiceLine{{
+			LineType:    "manual",
+			Description: fmt.Sprintf("Services %s – %s", inv.PeriodStart.Format("2006-01-02"), inv.PeriodEnd.Format("2006-01-02")),
+			Quantity:    money.One,
+			UnitPrice:   inv.AmountTotal,
+			TaxIncluded: true,
+		}}
+	}
+
+	inv.DocumentType = repositories.DocInvoice
+	inv.OriginalInvoiceID, inv.Refs = nil, nil
+	inv.Currency = billing.Currency
+	inv.TaxJurisdiction = ""
+	if !billing.TaxExempt {
+		inv.TaxJurisdiction = billing.TaxJurisdiction
+	}
+	if err := s.priceDocument(ctx, inv, priceCurrency, taxRate, on, false, false); err != nil {
+		return err
+	}
+
+	if err := s.invoices.Create(ctx, inv); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "invoice.create", EntityType: "invoice", EntityID: inv.ID, CompanyID: inv.CompanyID,
+		After: inv,
+	})
+	s.webhooks.InvoiceIssued(ctx, inv)
+	return nil
+}
+
+// priceDocument считает строки и итоги документа. negate — кредит-нота:
+// цены в запросе положительные, в документе суммы со знаком минус.
+// allowNegative — корректировка, где отрицательная цена означает скидку.
+func (s *BillingService) priceDocument(ctx context.Context, inv *models.Invoice, priceCurrency string, taxRate money.Decimal, on time.Time, negate, allowNegative bool) error {
+	inv.AmountNet, inv.AmountTax, inv.AmountTotal = money.Zero, money.Zero, money.Zero
+	inv.AmountPaid, inv.AmountCredited = money.Zero, money.Zero
+
+	for i := range inv.Lines {
+		l := &inv.Lines[i]
+		if l.Currency == "" {
+			l.Currency = priceCurrency
+		}
+		if err := s.priceLine(ctx, l, inv.Currency, taxRate, on, allowNegative); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if negate {
+			l.UnitPrice, l.AmountNet, l.AmountTax, l.AmountGross = l.UnitPrice.Neg(), l.AmountNet.Neg(), l.AmountTax.Neg(), l.AmountGross.Neg()
+		}
+		var err error
+		if inv.AmountNet, err = inv.AmountNet.AddErr(l.AmountNet); err != nil {
+			return fmt.Errorf("amount_net: %w", err)
+		}
+		if inv.AmountTax, err = inv.AmountTax.AddErr(l.AmountTax); err != nil {
+			return fmt.Errorf("amount_tax: %w", err)
+		}
+		if inv.AmountTotal, err = inv.AmountTotal.AddErr(l.AmountGross); err != nil {
+			return fmt.Errorf("amount_total: %w", err)
+		}
+	}
+	return nil
+}
+
+// --------------------- CREDIT NOTES / ADJUSTMENTS ---------------------
+// IssueAdjustment выставляет к счёту originalID кредит-ноту (DocCreditNote — возврат,
+// например за простой фасада) или корректировку (DocAdjustment — доначисление или скидка).
+// Валюта и ставка налога берутся из исходного счёта; основание (показы play_history
+// и / или период простоя) обязательно. Отрицательный итог зачитывается в исходный счёт.
+func (s *BillingService) IssueAdjustment(ctx context.Context, originalID int64, doc *models.Invoice) error {
+	if doc.DocumentType != repositories.DocCreditNote && doc.DocumentType != repositories.DocAdjustment {
+		return repositories.ErrUnknownDocument
+	}
+	if doc.Reason == "" {
+		return invalid("reason", "reason is required")
+	}
+
+	original, err := s.GetByID(ctx, originalID)
+	if err != nil {
+		return err
+	}
+	if original.DocumentType != repositories.DocInvoice {
+		return repositories.ErrNotCreditable
+	}
+	if err := s.validateRefs(ctx, original.CompanyID, doc.Refs); err != nil {
+		return err
+	}
+
+	taxRate := money.Zero
+	if len(original.Lines) > 0 {
+		taxRate = original.Lines[0].TaxRate
+	}
+
+	doc.CompanyID = original.CompanyID
+	doc.OriginalInvoiceID = &original.ID
+	doc.Currency = original.Currency
+	doc.TaxJurisdiction = original.TaxJurisdiction
+	doc.IssuedAt = time.Now()
+	doc.PaidAt = nil
+	if doc.PeriodStart.IsZero() || doc.PeriodEnd.IsZero() {
+		doc.PeriodStart, doc.PeriodEnd = original.PeriodStart, original.PeriodEnd
+	}
+
+	negate := doc.DocumentType == repositories.DocCreditNote
+	if len(doc.Lines) == 0 {
+		if doc.AmountTotal.IsZero() {
+			return invalid("lines", "lines or amount_total are required")
+		}
+		amount := doc.AmountTotal
+		if negate && amount.Sign() < 0 {
+			amount = amount.Neg()
+		}
+		doc.Lines = []models.InvoiceLine{{
+			LineType:    "manual",
+			Description: doc.Reason,
+			Quantity:    money.One,
+			UnitPrice:   amount,
+			TaxIncluded: true,
+		}}
+	}
+	// в корректировке допустимы отрицательные цены (скидка), в кредит-ноте знак ставим сами
+	if err := s.priceDocument(ctx, doc, original.Currency, taxRate, truncDay(doc.IssuedAt), negate, !negate); err != nil {
+		return err
+	}
+
+	credit := money.Zero
+	switch sign := doc.AmountTotal.Sign(); {
+	case sign == 0:
+		return invalid("amount_total", "adjustment total must not be zero")
+	case sign < 0:
+		// зачитывается в исходный счёт, к оплате не выставляется
+		credit = doc.AmountTotal.Neg()
+		doc.Status, doc.DueDate = "applied", nil
+	default:
+		// доначисление — обычный документ к оплате; компания должна быть активна
+		if err := ensureCompanyActive(ctx, s.companies, doc.CompanyID); err != nil {
+			return err
+		}
+		doc.Status = "pending"
+	}
+
+	if err := s.invoices.CreateAdjustment(ctx, doc, credit); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "invoice." + doc.DocumentType, EntityType: "invoice", EntityID: doc.ID, CompanyID: doc.CompanyID,
+		After: doc,
+	})
+	s.webhooks.InvoiceIssued(ctx, doc)
+	if credit.Sign() > 0 {
+		after, err := s.invoices.GetByID(ctx, original.ID)
+		if err == nil {
+			original.Lines = nil
+			s.audit.Record(ctx, AuditEvent{
+				Action: "invoice.credit_applied", EntityType: "invoice", EntityID: original.ID, CompanyID: original.CompanyID,
+				Before: original, After: after,
+			})
+			// кредит мог закрыть остаток исходного счёта
+			s.paidTransition(ctx, original, after)
+		}
+	}
+	return nil
+}
+
+// validateRefs — основание корректировки: показы компании и / или корректный период простоя
+func (s *BillingService) validateRefs(ctx context.Context, companyID int64, refs *models.AdjustmentRefs) error {
+	if refs == nil || (len(refs.PlayHistoryIDs) == 0 && refs.OutageStart == nil && refs.OutageEnd == nil) {
+		return invalid("refs", "refs must reference play_history_ids or an outage period")
+	}
+	if (refs.OutageStart == nil) != (refs.OutageEnd == nil) {
+		return invalid("refs.outage_end", "outage_start and outage_end must be set together")
+	}
+	if refs.OutageStart != nil && !refs.OutageEnd.After(*refs.OutageStart) {
+		return invalid("refs.outage_end", "outage_end must be after outage_start")
+	}
+
+	if len(refs.PlayHistoryIDs) > 0 {
+		ids := map[int64]bool{}
+		for _, id := range refs.PlayHistoryIDs {
+			ids[id] = true
+		}
+		n, err := s.invoices.CountCompanyPlays(ctx, companyID, refs.PlayHistoryIDs)
+		if err != nil {
+			return err
+		}
+		if n != len(ids) {
+			return invalid("refs.play_history_ids", "play_history_ids must reference plays of the company's campaigns")
+		}
+	}
+	return nil
+}
+
+// ListAdjustments — кредит-ноты и корректировки к счёту
+func (s *BillingService) ListAdjustments(ctx context.Context, invoiceID int64) ([]models.Invoice, error) {
+	if _, err := s.invoices.GetByID(ctx, invoiceID); err != nil {
+		return nil, err
+	}
+	return s.invoices.ListAdjustments(ctx, invoiceID)
+}
+
+// priceLine считает net / tax / gross строки в валюте счёта.
+// Округление — по строке до минимальной единицы валюты (симметрично для отрицательных),
+// итоги счёта — сумма строк.
+func (s *BillingService) priceLine(ctx context.Context, l *models.InvoiceLine, currency string, taxRate money.Decimal, on time.Time, allowNegative bool) error {
+	var err error
+	if l.LineType == "" {
+		l.LineType = "manual"
+	}
+	if !invoiceLineTypes[l.LineType] {
+		return invalidf("lines.line_type", "unknown line type %q", l.LineType)
+	}
+	if l.Description == "" {
+		return invalid("lines.description", "description is required")
+	}
+	if l.Quantity.IsZero() {
+		l.Quantity = money.One
+	}
+	if l.Quantity.Sign() < 0 {
+		return invalid("lines.quantity", "quantity must be positive")
+	}
+	if l.Quantity.Cmp(maxLineQuantity) > 0 {
+		return fmt.Errorf("%w: quantity must not exceed %s", money.ErrInvalidDecimal, maxLineQuantity)
+	}
+	if l.UnitPrice.Sign() < 0 && !allowNegative {
+		return invalid("lines.unit_price", "unit_price must not be negative")
+	}
+	if l.UnitPrice.Cmp(maxLineUnitPrice) > 0 || l.UnitPrice.Cmp(maxLineUnitPrice.Neg()) < 0 {
+		return fmt.Errorf("%w: unit_price must not exceed %s in absolute value", money.ErrInvalidDecimal, maxLineUnitPrice)
+	}
+	if l.Currency, err = money.NormalizeCurrency(l.Currency); err != nil {
+		return invalid("lines.currency", err.Error())
+	}
+
+	amount, err := l.Quantity.MulErr(l.UnitPrice)
+	if err != nil {
+		return err
+	}
+	l.FXRate = nil
+	if l.Currency != currency {
+		rate, err := s.fx.Rate(ctx, l.Currency, currency, on)
+		if err != nil {
+			return err
+		}
+		if amount, err = amount.MulErr(rate); err != nil {
+			return err
+		}
+		l.FXRate = &rate
+	}
+	if amount, err = amount.RoundToErr(currency); err != nil {
+		return err
+	}
+
+	l.TaxRate = taxRate
+	if l.TaxIncluded {
+		l.AmountGross = amount
+		net, err := amount.MulErr(money.Hundred)
+		if err != nil {
+			return err
+		}
+		l.AmountNet = net.Div(money.Hundred.Add(taxRate)).RoundTo(currency)
+		l.AmountTax = l.AmountGross.Sub(l.AmountNet)
+	} else {
+		l.AmountNet = amount
+		tax, err := amount.MulErr(taxRate)
+		if err != nil {
+			return err
+		}
+		l.AmountTax = tax.Div(money.Hundred).RoundTo(currency)
+		if l.AmountGross, err = l.AmountNet.AddErr(l.AmountTax); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ---------- COMPANY BILLING SETTINGS ----------
+func (s *BillingService) CompanyBilling(ctx context.Context, companyID int64) (*models.CompanyBilling, error) {
+	return s.companies.GetBilling(ctx, companyID)
+}
+
+// SetCompanyBilling меняет валюту / юрисдикцию для новых счетов; выставленные не пересчитываются
+func (s *BillingService) SetCompanyBilling(ctx context.Context, b *models.CompanyBilling) (*models.CompanyBilling, error) {
+	var err error
+	if b.Currency, err = money.NormalizeCurrency(b.Currency); err != nil {
+		return nil, invalid("currency", err.Error())
+	}
+	b.TaxJurisdiction = NormalizeJurisdiction(b.TaxJurisdiction)
+
+	before, err := s.companies.GetBilling(ctx, b.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.companies.UpdateBilling(ctx, b); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "company.billing_update", EntityType: "company", EntityID: b.CompanyID, CompanyID: b.CompanyID,
+		Before: before, After: b,
+	})
+	return b, nil
+}
+
+// --------------------- UPDATE STATUS ---------------------
+// UpdateStatus — ручная смена между pending, overdue и failed (например, отменить
+// просрочку после переноса срока). Оплаченным счёт делает только RecordPayment.
+func (s *BillingService) UpdateStatus(ctx context.Context, id int64, status string) (*models.Invoice, error) {
+	if !invoiceStatuses[status] {
+		return nil, invalidf("status", "status must be pending, overdue or failed, got %q", status)
+	}
+
+	before, err := s.invoices.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !invoiceStatuses[before.Status] {
+		return nil, repositories.ErrStatusFromPayments
+	}
+	if err := s.invoices.UpdateStatus(ctx, id, status); err != nil {
+		return nil, err
+	}
+	after, err := s.invoices.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "invoice.status_change", EntityType: "invoice", EntityID: id, CompanyID: before.CompanyID,
+		Before: before, After: after,
+	})
+	return after, nil
+}
+
+// --------------------- PAYMENTS ---------------------
+func (s *BillingService) ListPayments(ctx context.Context, invoiceID int64) ([]models.Payment, error) {
+	return s.payments.ListByInvoice(ctx, invoiceID)
+}
+
+// RecordPayment — частичная или полная оплата. После оплаты сразу пересматривается
+// пауза кампаний за просрочку, не дожидаясь задачи dunning.
+func (s *BillingService) RecordPayment(ctx context.Context, p *models.Payment) (*models.Invoice, error) {
+	if p.Amount.Sign() <= 0 {
+		return nil, invalid("amount", "amount must be positive")
+	}
+	if p.PaidAt.IsZero() {
+		p.PaidAt = time.Now()
+	}
+
+	before, err := s.invoices.GetByID(ctx, p.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	// округление у края диапазона переполняется только при лишних знаках
+	if rounded, err := p.Amount.RoundToErr(before.Currency); err != nil || !rounded.Equal(p.Amount) {
+		return nil, invalidf("amount", "amount has more decimal places than %s allows", before.Currency)
+	}
+	if err := s.payments.Record(ctx, p); err != nil {
+		return nil, err
+	}
+	after, err := s.invoices.GetByID(ctx, p.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "invoice.payment", EntityType: "invoice", EntityID: p.InvoiceID, CompanyID: before.CompanyID,
+		Before: before, After: after,
+	})
+	s.paidTransition(ctx, before, after)
+
+	if s.dunning != nil && before.Status == "overdue" && after.Status == "paid" {
+		if err := s.dunning.ApplyPausePolicy(ctx, truncDay(time.Now())); err != nil {
+			slog.ErrorContext(ctx, "billing: dunning policy after payment", "invoice_id", p.InvoiceID, "err", err)
+		}
+	}
+	return after, nil
+}
+
+// paidTransition — invoice.paid, когда оплата или кредит-нота только что закрыли счёт;
+// ручная смена статуса (UpdateStatus) его не вызывает
+func (s *BillingService) paidTransition(ctx context.Context, before, after *models.Invoice) {
+	if before.Status != "paid" && after.Status == "paid" {
+		s.webhooks.InvoicePaid(ctx, after)
+	}
+}
@@ -0,0 +1,237 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/repositories"
+	"mediawork/internal/services"
+)
+
+var issuedOn = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func line(unitPrice, currency string, taxIncluded bool) models.InvoiceLine {
+	return models.InvoiceLine{
+		Description: "airtime",
+		Quantity:    money.One,
+		UnitPrice:   money.MustParse(unitPrice),
+		Currency:    currency,
+		TaxIncluded: taxIncluded,
+	}
+}
+
+func (e *env) setRate(t *testing.T, base, quote, rate string, on time.Time) {
+	t.Helper()
+	if err := e.fx.Set(context.Background(), &models.ExchangeRate{Base: base, Quote: quote, Rate: money.MustParse(rate), ValidOn: on}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestInvoicePricing — строки, налог по юрисдикции компании (RU, 20%) и пересчёт в RUB
+func TestInvoicePricing(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, e *env)
+		lines   []models.InvoiceLine
+		net     string
+		tax     string
+		total   string
+		fx      string // курс первой строки; "" — строка в валюте счёта
+		wantErr error
+		field   string // ValidationError по полю
+	}{
+		{name: "tax on top", lines: []models.InvoiceLine{line("100", "", false), line("50", "RUB", false)},
+			net: "150", tax: "30", total: "180"},
+		{name: "tax included", lines: []models.InvoiceLine{line("120", "", true)},
+			net: "100", tax: "20", total: "120"},
+		{name: "rounded per line", lines: []models.InvoiceLine{line("10.005", "", false), line("0.125", "", false)},
+			net: "10.14", tax: "2.03", total: "12.17"},
+		{name: "direct rate",
+			setup: func(t *testing.T, e *env) { e.setRate(t, "USD", "RUB", "90.5", issuedOn.AddDate(0, 0, -1)) },
+			lines: []models.InvoiceLine{line("10", "USD", false)},
+			net:   "905", tax: "181", total: "1086", fx: "90.5"},
+		{name: "inverse rate",
+			setup: func(t *testing.T, e *env) { e.setRate(t, "RUB", "EUR", "0.01", issuedOn) },
+			lines: []models.InvoiceLine{line("2", "EUR", false)},
+			net:   "200", tax: "40", total: "240", fx: "100"},
+		{name: "fresher of two directions",
+			setup: func(t *testing.T, e *env) {
+				e.setRate(t, "USD", "RUB", "80", issuedOn.AddDate(0, 0, -10))
+				e.setRate(t, "RUB", "USD", "0.01", issuedOn.AddDate(0, 0, -2))
+			},
+			lines: []models.InvoiceLine{line("1", "USD", false)},
+			net:   "100", tax: "20", total: "120", fx: "100"},
+		{name: "rate from the future is not used",
+			setup:   func(t *testing.T, e *env) { e.setRate(t, "USD", "RUB", "90", issuedOn.AddDate(0, 0, 1)) },
+			lines:   []models.InvoiceLine{line("10", "USD", false)},
+			wantErr: services.ErrNoExchangeRate},
+		{name: "no rate", lines: []models.InvoiceLine{line("10", "GBP", false)},
+			wantErr: services.ErrNoExchangeRate},
+		{name: "tax exempt",
+			setup: func(t *testing.T, e *env) {
+				e.setBilling(t, &models.CompanyBilling{CompanyID: demoCompanyID, Currency: "RUB", TaxJurisdiction: "RU", TaxExempt: true})
+			},
+			lines: []models.InvoiceLine{line("100", "", false)},
+			net:   "100", tax: "0", total: "100"},
+		{name: "no tax rate for jurisdiction",
+			setup: func(t *testing.T, e *env) {
+				e.setBilling(t, &models.CompanyBilling{CompanyID: demoCompanyID, Currency: "RUB", TaxJurisdiction: "DE"})
+			},
+			lines:   []models.InvoiceLine{line("100", "", false)},
+			wantErr: services.ErrNoTaxRate},
+		{name: "negative price", lines: []models.InvoiceLine{line("-1", "", false)},
+			field: "lines.unit_price"},
+		{name: "unknown currency", lines: []models.InvoiceLine{line("1", "XYZW", false)},
+			field: "lines.currency"},
+		{name: "price too large", lines: []models.InvoiceLine{line("1000000001", "", false)},
+			wantErr: money.ErrInvalidDecimal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newEnv(t)
+			if tt.setup != nil {
+				tt.setup(t, e)
+			}
+			inv := &models.Invoice{CompanyID: demoCompanyID, IssuedAt: issuedOn, Lines: tt.lines}
+			err := e.billing.Create(context.Background(), inv)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Create error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.field != "":
+				if !isValidation(err, tt.field) {
+					t.Fatalf("Create error = %v, want validation error on %s", err, tt.field)
+				}
+				return
+			case err != nil:
+				t.Fatalf("Create: %v", err)
+			}
+
+			if inv.Currency != "RUB" || inv.AmountNet.String() != tt.net || inv.AmountTax.String() != tt.tax || inv.AmountTotal.String() != tt.total {
+				t.Errorf("net/tax/total = %s/%s/%s %s, want %s/%s/%s RUB",
+					inv.AmountNet, inv.AmountTax, inv.AmountTotal, inv.Currency, tt.net, tt.tax, tt.total)
+			}
+			fx := inv.Lines[0].FXRate
+			switch {
+			case tt.fx == "" && fx != nil:
+				t.Errorf("fx_rate = %s, want none", fx)
+			case tt.fx != "" && (fx == nil || fx.String() != tt.fx):
+				t.Errorf("fx_rate = %v, want %s", fx, tt.fx)
+			}
+		})
+	}
+}
+
+func (e *env) setBilling(t *testing.T, b *models.CompanyBilling) {
+	t.Helper()
+	if _, err := e.billing.SetCompanyBilling(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+	e.setRate(t, "USD", "RUB", "92.3456", issuedOn)
+	e.setRate(t, "USD", "JPY", "150", issuedOn)
+
+	tests := []struct {
+		amount, from, to string
+		want, rate       string
+		wantErr          error
+	}{
+		{amount: "10", from: "RUB", to: "RUB", want: "10", rate: "1"},
+		{amount: "1.5", from: "USD", to: "RUB", want: "138.52", rate: "92.3456"},
+		{amount: "1000", from: "RUB", to: "USD", want: "10.83", rate: "0.010829"},
+		{amount: "0.99", from: "USD", to: "JPY", want: "149", rate: "150"},
+		{amount: "1", from: "RUB", to: "JPY", wantErr: services.ErrNoExchangeRate},
+		{amount: "61489146912.365", from: "USD", to: "JPY", wantErr: money.ErrOverflow},
+	}
+	for _, tt := range tests {
+		got, rate, err := e.fx.Convert(ctx, money.MustParse(tt.amount), tt.from, tt.to, issuedOn)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Convert(%s %s→%s) error = %v, want %v", tt.amount, tt.from, tt.to, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Convert(%s %s→%s): %v", tt.amount, tt.from, tt.to, err)
+			continue
+		}
+		if got.String() != tt.want || rate.String() != tt.rate {
+			t.Errorf("Convert(%s %s→%s) = %s at %s, want %s at %s", tt.amount, tt.from, tt.to, got, rate, tt.want, tt.rate)
+		}
+	}
+}
+
+// TestPaymentLedger — оплаты одного счёта на 1200 RUB по порядку
+func TestPaymentLedger(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+
+	inv := &models.Invoice{CompanyID: demoCompanyID, IssuedAt: issuedOn, AmountTotal: money.NewFromInt(1200)}
+	if err := e.billing.Create(ctx, inv); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name     string
+		amount   string
+		currency string
+		wantErr  error
+		field    string
+		status   string
+		paid     string
+	}{
+		{name: "partial", amount: "500", status: "partially_paid", paid: "500"},
+		{name: "overpayment", amount: "700.01", wantErr: repositories.ErrOverpayment},
+		{name: "other currency", amount: "10", currency: "USD", wantErr: repositories.ErrCurrencyMismatch},
+		{name: "currency case", amount: "100", currency: "rub", status: "partially_paid", paid: "600"},
+		{name: "sub-kopeck", amount: "0.001", field: "amount"},
+		{name: "sub-kopeck at range edge", amount: "9223372036854.775807", field: "amount"},
+		{name: "zero", amount: "0", field: "amount"},
+		{name: "negative", amount: "-5", field: "amount"},
+		{name: "rest", amount: "600", status: "paid", paid: "1200"},
+		{name: "after paid", amount: "1", wantErr: repositories.ErrInvoiceClosed},
+	}
+	for _, s := range steps {
+		after, err := e.billing.RecordPayment(ctx, &models.Payment{
+			InvoiceID: inv.ID,
+			Amount:    money.MustParse(s.amount),
+			Currency:  s.currency,
+		})
+		switch {
+		case s.wantErr != nil:
+			if !errors.Is(err, s.wantErr) {
+				t.Fatalf("%s: error = %v, want %v", s.name, err, s.wantErr)
+			}
+			continue
+		case s.field != "":
+			if !isValidation(err, s.field) {
+				t.Fatalf("%s: error = %v, want validation error on %s", s.name, err, s.field)
+			}
+			continue
+		case err != nil:
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if after.Status != s.status || after.AmountPaid.String() != s.paid {
+			t.Fatalf("%s: status=%s paid=%s, want %s / %s", s.name, after.Status, after.AmountPaid, s.status, s.paid)
+		}
+	}
+
+	payments, err := e.billing.ListPayments(ctx, inv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 3 {
+		t.Errorf("ledger has %d payments, want 3 (rejected payments must not be recorded)", len(payments))
+	}
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/notify"
+)
+
+// BudgetService — бюджеты кампаний и компаний (деньги и / или эфир), предоплаченные кошельки.
+// Каждый показ списывается с бюджетов и кошелька; исчерпанные кампании снимаются
+// с расписания (campaigns.budget_exhausted_at) и возвращаются после пополнения / увеличения лимита.
+type BudgetService struct {
+	budgets   BudgetRepository
+	campaigns CampaignRepository
+	companies CompanyRepository
+	members   CompanyMembershipRepository
+	fx        *ExchangeRateService
+	notifier  notify.Notifier
+	audit     *AuditService
+}
+
+func NewBudgetService(
+	b BudgetRepository,
+	c CampaignRepository,
+	companies CompanyRepository,
+	m CompanyMembershipRepository,
+	fx *ExchangeRateService,
+	n notify.Notifier,
+	a *AuditService,
+) *BudgetService {
+	if n == nil {
+		n = notify.LogNotifier{}
+	}
+	return &BudgetService{budgets: b, campaigns: c, companies: companies, members: m, fx: fx, notifier: n, audit: a}
+}
+
+// BudgetLimits — что задаёт клиент / админ; хотя бы один лимит обязателен
+type BudgetLimits struct {
+	AmountLimit     *money.Decimal `json:"amount_limit"`
+	AirtimeLimitSec *int64         `json:"airtime_limit_sec"`
+}
+
+func (l BudgetLimits) validate() error {
+	if l.AmountLimit == nil && l.AirtimeLimitSec == nil {
+		return invalid("amount_limit", "amount_limit or airtime_limit_sec is required")
+	}
+	if l.AmountLimit != nil && l.AmountLimit.Sign() < 0 {
+		return invalid("amount_limit", "amount_limit must not be negative")
+	}
+	if l.AirtimeLimitSec != nil && *l.AirtimeLimitSec < 0 {
+		return invalid("airtime_limit_sec", "airtime_limit_sec must not be negative")
+	}
+	return nil
+}
+
+// ---------- CAMPAIGN BUDGET ----------
+// CampaignStatus — бюджет кампании, бюджет компании, кошелёк и снята ли кампания с расписания
+func (s *BudgetService) CampaignStatus(ctx context.Context, campaignID int64) (*models.CampaignBudgetStatus, error) {
+	c, err := s.campaigns.GetByID(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &models.CampaignBudgetStatus{CampaignID: campaignID}
+	if st.Budget, err = optional(s.budgets.GetCampaignBudget(ctx, campaignID)); err != nil {
+		return nil, err
+	}
+	if st.CompanyBudget, err = optional(s.budgets.GetCompanyBudget(ctx, c.CompanyID)); err != nil {
+		return nil, err
+	}
+	if st.Wallet, err = optional(s.budgets.GetWallet(ctx, c.CompanyID)); err != nil {
+		return nil, err
+	}
+	if st.ExhaustedAt, err = s.budgets.ExhaustedAt(ctx, campaignID); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// SetCampaignBudget — фиксированный бюджет вместо фиксированных слотов. Валюта — billing currency компании.
+// Задаёт и снимает бюджет admin компании (или платформы): от него зависит, сколько кампания потратит.
+func (s *BudgetService) SetCampaignBudget(ctx context.Context, actor *models.UserClaims, campaignID int64, l BudgetLimits) (*models.Budget, error) {
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+	c, err := s.campaigns.GetByID(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireCompanyAdmin(ctx, s.members, c.CompanyID, actor); err != nil {
+		return nil, err
+	}
+	if err := ensureCompanyActive(ctx, s.companies, c.CompanyID); err != nil {
+		return nil, err
+	}
+	before, err := optional(s.budgets.GetCampaignBudget(ctx, campaignID))
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.saveBudget(ctx, c.CompanyID, &campaignID, l)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "campaign.budget_set", EntityType: "campaign", EntityID: campaignID, CompanyID: c.CompanyID,
+		Before: before, After: b,
+	})
+	s.reevaluate(ctx, c.CompanyID)
+	return b, nil
+}
+
+func (s *BudgetService) DeleteCampaignBudget(ctx context.Context, actor *models.UserClaims, campaignID int64) error {
+	c, err := s.campaigns.GetByID(ctx, campaignID)
+	if err != nil {
+		return err
+	}
+	if err := requireCompanyAdmin(ctx, s.members, c.CompanyID, actor); err != nil {
+		return err
+	}
+	if err := s.budgets.DeleteCampaignBudget(ctx, campaignID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditEvent{
+		Action: "campaign.budget_delete", EntityType: "campaign", EntityID: campaignID, CompanyID: c.CompanyID,
+	})
+	s.reevaluate(ctx, c.CompanyID)
+	return nil
+}
+
+// ---------- COMPANY BUDGET (admin) ----------
+func (s *BudgetService) CompanyBudget(ctx context.Context, companyID int64) (*models.Budget, error) {
+	return s.budgets.GetCompanyBudget(ctx, companyID)
+}
+
+func (s *BudgetService) SetCompanyBudget(ctx context.Context, companyID int64, l BudgetLimits) (*models.Budget, error) {
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+	before, err := optional(s.budgets.GetCompanyBudget(ctx, companyID))
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.saveBudget(ctx, companyID, nil, l)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "company.budget_set", EntityType: "company", EntityID: companyID, CompanyID: companyID,
+		Before: before, After: b,
+	})
+	s.reevaluate(ctx, companyID)
+	return b, nil
+}
+
+func (s *BudgetService) DeleteCompanyBudget(ctx context.Context, companyID int64) error {
+	if err := s.budgets.DeleteCompanyBudget(ctx, companyID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditEvent{
+		Action: "company.budget_delete", EntityType: "company", EntityID: companyID, CompanyID: companyID,
+	})
+	s.reevaluate(ctx, companyID)
+	return nil
+}
+
+func (s *BudgetService) saveBudget(ctx context.Context, companyID int64, campaignID *int64, l BudgetLimits) (*models.Budget, error) {
+	billing, err := s.companies.GetBilling(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	b := &models.Budget{
+		CompanyID:       companyID,
+		CampaignID:      campaignID,
+		Currency:        billing.Currency,
+		AmountLimit:     l.AmountLimit,
+		AirtimeLimitSec: l.AirtimeLimitSec,
+	}
+	if b.AmountLimit != nil {
+		rounded, err := b.AmountLimit.RoundToErr(b.Currency)
+		if err != nil {
+			return nil, err
+		}
+		b.AmountLimit = &rounded
+	}
+	if err := s.budgets.SetBudget(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// ---------- WALLET ----------
+func (s *BudgetService) Wallet(ctx context.Context, companyID int64) (*models.Wallet, error) {
+	return s.budgets.GetWallet(ctx, companyID)
+}
+
+func (s *BudgetService) Transactions(ctx context.Context, companyID int64, limit, offset int) ([]models.WalletTransaction, error) {
+	limit, offset = normalizePage(limit, offset)
+	return s.budgets.ListTransactions(ctx, companyID, limit, offset)
+}
+
+// TopUp пополняет предоплаченный кошелёк (первое пополнение переводит компанию на предоплату).
+// adjustment = true — ручная корректировка баланса, сумма со знаком.
+func (s *BudgetService) TopUp(ctx context.Context, t *models.WalletTransaction, adjustment bool) (*models.Wallet, error) {
+	t.Kind = "topup"
+	if adjustment {
+		t.Kind = "adjustment"
+		if t.Amount.IsZero() {
+			return nil, invalid("amount", "amount must not be zero")
+		}
+	} else if t.Amount.Sign() <= 0 {
+		return nil, invalid("amount", "amount must be positive")
+	}
+
+	currency := ""
+	if w, err := optional(s.budgets.GetWallet(ctx, t.CompanyID)); err != nil {
+		return nil, err
+	} else if w != nil {
+		currency = w.Currency
+	} else {
+		billing, err := s.companies.GetBilling(ctx, t.CompanyID)
+		if err != nil {
+			return nil, err
+		}
+		currency = billing.Currency
+	}
+	// округление у края диапазона переполняется только при лишних знаках
+	if rounded, err := t.Amount.RoundToErr(currency); err != nil || !rounded.Equal(t.Amount) {
+		return nil, invalidf("amount", "amount has more decimal places than %s allows", currency)
+	}
+
+	if err := s.budgets.TopUp(ctx, t, currency); err != nil {
+		return nil, err
+	}
+	w, err := s.budgets.GetWallet(ctx, t.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "company.wallet_" + t.Kind, EntityType: "company", EntityID: t.CompanyID, CompanyID: t.CompanyID,
+		After: t,
+	})
+	s.reevaluate(ctx, t.CompanyID)
+	return w, nil
+}
+
+// ---------- CONSUME ----------
+// Charge — стоимость показа ev для бюджетов и кошелька его компании; nil — списывать нечего.
+// Считается до записи показа: без курса (ErrNoExchangeRate) или цены показ отклоняется,
+// а не записывается без списания — иначе это бесплатный эфир.
+func (s *BudgetService) Charge(ctx context.Context, ev *models.PlayEvent) (*models.PlayCharge, error) {
+	if s == nil || ev.CampaignID == 0 {
+		return nil, nil
+	}
+	c, err := s.campaigns.GetByID(ctx, ev.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	campaignBudget, err := optional(s.budgets.GetCampaignBudget(ctx, c.ID))
+	if err != nil {
+		return nil, err
+	}
+	companyBudget, err := optional(s.budgets.GetCompanyBudget(ctx, c.CompanyID))
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := optional(s.budgets.GetWallet(ctx, c.CompanyID))
+	if err != nil {
+		return nil, err
+	}
+	if campaignBudget == nil && companyBudget == nil && wallet == nil {
+		return nil, nil
+	}
+
+	seconds := int64(ev.DurationSec)
+	if seconds < 0 {
+		seconds = 0
+	}
+	price, priceCurrency, priced, err := s.budgets.PlayPrice(ctx, ev.FacadeID, seconds)
+	if err != nil {
+		return nil, err
+	}
+
+	on := time.Now()
+	if !ev.PlayedAt.IsZero() {
+		on = ev.PlayedAt
+	}
+	// стоимость в валюте каждого бюджета / кошелька
+	convert := func(currency string) (money.Decimal, error) {
+		if !priced || price.IsZero() {
+			return money.Zero, nil
+		}
+		amount, _, err := s.fx.Convert(ctx, price, priceCurrency, currency, truncDay(on))
+		return amount, err
+	}
+
+	charge := &models.PlayCharge{
+		CampaignID: c.ID,
+		CompanyID:  c.CompanyID,
+		Seconds:    seconds,
+	}
+	if campaignBudget != nil {
+		if charge.CampaignAmount, err = convert(campaignBudget.Currency); err != nil {
+			return nil, err
+		}
+	}
+	if companyBudget != nil {
+		if charge.CompanyAmount, err = convert(companyBudget.Currency); err != nil {
+			return nil, err
+		}
+	}
+	if wallet != nil {
+		if charge.WalletAmount, err = convert(wallet.Currency); err != nil {
+			return nil, err
+		}
+	}
+	return charge, nil
+}
+
+// Consume списывает записанный показ ev по расчёту из Charge. Ошибка (база) логируется
+// и считается в mediawork_play_charge_failures_total; показ при этом уже записан, поэтому
+// вызывающий приём событий от плееров из-за неё не отклоняет.
+func (s *BudgetService) Consume(ctx context.Context, ev *models.PlayEvent, charge *models.PlayCharge) error {
+	if s == nil || charge == nil || ev.ID == 0 {
+		return nil
+	}
+	charge.PlayHistoryID = ev.ID
+	crossed, err := s.budgets.Consume(ctx, charge)
+	if err != nil {
+		playChargeFailures.Inc()
+		slog.ErrorContext(ctx, "budget: cannot charge play", "play_id", ev.ID, "campaign_id", ev.CampaignID, "err", err)
+		return err
+	}
+	// полный пересчёт по компании — только когда этот показ исчерпал бюджет или кошелёк
+	if crossed {
+		s.reevaluate(ctx, charge.CompanyID)
+	}
+	return nil
+}
+
+// reevaluate снимает с расписания / возвращает кампании компании и уведомляет об исчерпании
+func (s *BudgetService) reevaluate(ctx context.Context, companyID int64) {
+	exhausted, resumed, err := s.budgets.Reevaluate(ctx, companyID)
+	if err != nil {
+		slog.ErrorContext(ctx, "budget: reevaluate", "company_id", companyID, "err", err)
+		return
+	}
+	scheduleDecisions.With("budget_paused").Add(float64(len(exhausted)))
+	scheduleDecisions.With("budget_resumed").Add(float64(len(resumed)))
+
+	for _, id := range exhausted {
+		s.audit.Record(ctx, AuditEvent{
+			Action: "campaign.budget_exhausted", EntityType: "campaign", EntityID: id, CompanyID: companyID,
+		})
+	}
+	for _, id := range resumed {
+		s.audit.Record(ctx, AuditEvent{
+			Action: "campaign.budget_resumed", EntityType: "campaign", EntityID: id, CompanyID: companyID,
+		})
+	}
+	if len(exhausted) == 0 {
+		return
+	}
+
+	to, err := billingContacts(ctx, s.members, companyID)
+	if err != nil || len(to) == 0 {
+		return
+	}
+	msg := notify.Message{
+		Kind:    "campaign.budget_exhausted",
+		To:      to,
+		Subject: "Campaign budget exhausted",
+		Body: fmt.Sprintf("%d campaign(s) ran out of budget or prepaid balance and were taken off the schedule. "+
+			"Top up the balance or raise the budget to resume.", len(exhausted)),
+		Meta: map[string]any{"company_id": companyID, "campaign_ids": exhausted},
+	}
+	if err := s.notifier.Notify(context.WithoutCancel(ctx), msg); err != nil {
+		slog.ErrorContext(ctx, "budget: exhaustion notice failed", "company_id", companyID, "err", err)
+	}
+}
+
+// optional — sql.ErrNoRows → nil без ошибки (бюджет / кошелёк не заданы)
+func optional[T any](v *T, err error) (*T, error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return v, err
+}
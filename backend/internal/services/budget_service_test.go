@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/services"
+)
+
+// TestCampaignBudgetRoles — задавать и снимать бюджет кампании может только admin
+// её компании и админ платформы
+func TestCampaignBudgetRoles(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+	sec := int64(600)
+	limits := services.BudgetLimits{AirtimeLimitSec: &sec}
+
+	tests := []struct {
+		name  string
+		actor *models.UserClaims
+		want  error
+	}{
+		{"viewer", e.member(t, "viewer@example.com", "viewer"), services.ErrForbidden},
+		{"editor", e.member(t, "editor@example.com", "editor"), services.ErrForbidden},
+		{"admin", e.member(t, "admin@example.com", "admin"), nil},
+		{"not a member", e.member(t, "outsider@example.com", ""), services.ErrForbidden},
+		{"platform admin", platformAdmin, nil},
+		{"api key of the company", &models.UserClaims{APIKeyID: 1, CompanyID: demoCompanyID}, services.ErrForbidden},
+		{"anonymous", nil, services.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := e.budgets.SetCampaignBudget(ctx, tt.actor, demoCampaignID, limits); !errors.Is(err, tt.want) {
+				t.Fatalf("SetCampaignBudget = %v, want %v", err, tt.want)
+			}
+			if err := e.budgets.DeleteCampaignBudget(ctx, tt.actor, demoCampaignID); !errors.Is(err, tt.want) {
+				t.Fatalf("DeleteCampaignBudget = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestBudgetAmountRange — лимит и пополнение у края диапазона Decimal — ошибка, не паника
+func TestBudgetAmountRange(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+	huge := money.MustParse("9223372036854.775807")
+
+	if _, err := e.budgets.SetCampaignBudget(ctx, platformAdmin, demoCampaignID, services.BudgetLimits{AmountLimit: &huge}); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("SetCampaignBudget = %v, want ErrOverflow", err)
+	}
+	if _, err := e.budgets.TopUp(ctx, &models.WalletTransaction{CompanyID: demoCompanyID, Amount: huge}, false); !isValidation(err, "amount") {
+		t.Errorf("TopUp = %v, want validation error on amount", err)
+	}
+}
+
+// TestPlayWithoutRate — показ, который нельзя списать из-за отсутствия курса,
+// не записывается; с курсом он принимается и списывается
+func TestPlayWithoutRate(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+	e.setBilling(t, &models.CompanyBilling{CompanyID: demoCompanyID, Currency: "USD", TaxJurisdiction: "RU"})
+	if _, err := e.budgets.TopUp(ctx, &models.WalletTransaction{CompanyID: demoCompanyID, Amount: money.NewFromInt(100)}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.play(10); !errors.Is(err, services.ErrNoExchangeRate) {
+		t.Fatalf("PlayEvent = %v, want ErrNoExchangeRate", err)
+	}
+	recent, err := e.repos.LiveStream.GetRecentEvents(ctx, demoFacadeID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 0 {
+		t.Errorf("recorded plays = %d, want 0", len(recent))
+	}
+
+	e.setRate(t, "USD", "RUB", "100", e.now.AddDate(0, 0, -1))
+	if err := e.play(10); err != nil {
+		t.Fatal(err)
+	}
+	w, err := e.budgets.Wallet(ctx, demoCompanyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Balance.String() != "98.25" {
+		t.Errorf("wallet balance = %s USD, want 98.25 (175 RUB at 100 RUB/USD)", w.Balance)
+	}
+}
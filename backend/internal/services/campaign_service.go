@@ -4,21 +4,73 @@ import (
     "context"
     "mediawork/internal/models"
     "mediawork/internal/repositories"
+    "strings"
+    "time"
 )
 
 type CampaignService struct {
-    repoCampaigns *repositories.CampaignRepository
-    repoSlots     *repositories.CampaignSlotRepository
+    repoCampaigns CampaignRepository
+    repoSlots     CampaignSlotRepository
+    companies     CompanyRepository
+    members       CompanyMembershipRepository
+    audit         *AuditService
+    webhooks      *WebhookService
 }
 
-func NewCampaignService(cRepo *repositories.CampaignRepository, slotRepo *repositories.CampaignSlotRepository) *CampaignService {
-    return &CampaignService{repoCampaigns: cRepo, repoSlots: slotRepo}
+func NewCampaignService(
+    cRepo CampaignRepository,
+    slotRepo CampaignSlotRepository,
+    companies CompanyRepository,
+    members CompanyMembershipRepository,
+    audit *AuditService,
+    webhooks *WebhookService,
+) *CampaignService {
+    return &CampaignService{repoCampaigns: cRepo, repoSlots: slotRepo, companies: companies, members: members, audit: audit, webhooks: webhooks}
+}
+
+// campaignStatuses — допустимые значения campaigns.status
+var campaignStatuses = map[string]bool{
+    "draft": true, "scheduled": true, "active": true, "live": true,
+    "paused": true, "finished": true, "cancelled": true,
+}
+
+func validateCampaign(c *models.Campaign) error {
+    c.Name = strings.TrimSpace(c.Name)
+    if c.Name == "" {
+        return invalid("name", "name is required")
+    }
+    if !campaignStatuses[c.Status] {
+        return invalidf("status", "unknown status %q", c.Status)
+    }
+    if !c.StartTime.IsZero() && !c.EndTime.IsZero() && !c.EndTime.After(c.StartTime) {
+        return invalid("end_time", "end_time must be after start_time")
+    }
+    return nil
 }
 
 //
 // --------------- CREATE WITH SLOTS ---------------
 //
-func (s *CampaignService) Create(ctx context.Context, c *models.Campaign, slots []models.CampaignSlot) (int64, error) {
+// Создавать и менять кампании может editor и выше в её компании
+func (s *CampaignService) Create(ctx context.Context, actor *models.UserClaims, c *models.Campaign, slots []models.CampaignSlot) (int64, error) {
+    if c.Status == "" {
+        c.Status = "draft"
+    }
+    if err := validateCampaign(c); err != nil {
+        return 0, err
+    }
+    for _, sl := range slots {
+        if sl.DayOfWeek < 0 || sl.DayOfWeek > 6 {
+            return 0, invalidf("slots.day_of_week", "invalid day_of_week %d", sl.DayOfWeek)
+        }
+    }
+    if err := requireCompanyRole(ctx, s.members, c.CompanyID, actor, "editor"); err != nil {
+        return 0, err
+    }
+    if err := ensureCompanyActive(ctx, s.companies, c.CompanyID); err != nil {
+        return 0, err
+    }
+
     id, err := s.repoCampaigns.Create(ctx, c)
     if err != nil { return 0, err }
 
@@ -27,9 +79,74 @@ func (s *CampaignService) Create(ctx context.Context, c *models.Campaign, slots
         _, _ = s.repoSlots.Create(ctx, &slots[i])
     }
 
+    s.audit.Record(ctx, AuditEvent{
+        Action: "campaign.create", EntityType: "campaign", EntityID: id, CompanyID: c.CompanyID,
+        After: c,
+    })
     return id, nil
 }
 
+//
+// --------------- UPDATE ---------------
+//
+// CampaignPatch — изменяемые поля кампании; nil — поле остаётся как было
+type CampaignPatch struct {
+    Name        *string    `json:"name"`
+    Description *string    `json:"description"`
+    StartTime   *time.Time `json:"start_time"`
+    EndTime     *time.Time `json:"end_time"`
+    Status      *string    `json:"status"`
+}
+
+// Update меняет переданные поля. Пока у компании открыта приостановка (деактивация
+// или просрочка по счетам), кампанию нельзя вывести из паузы — репозиторий вернёт
+// ErrCampaignSuspended; снимает паузу только возобновление компании.
+func (s *CampaignService) Update(ctx context.Context, actor *models.UserClaims, id int64, p CampaignPatch) (*models.Campaign, error) {
+    before, err := s.repoCampaigns.GetByID(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+    if err := requireCompanyRole(ctx, s.members, before.CompanyID, actor, "editor"); err != nil {
+        return nil, err
+    }
+
+    c := *before
+    if p.Name != nil {
+        c.Name = *p.Name
+    }
+    if p.Description != nil {
+        c.Description = *p.Description
+    }
+    if p.StartTime != nil {
+        c.StartTime = *p.StartTime
+    }
+    if p.EndTime != nil {
+        c.EndTime = *p.EndTime
+    }
+    if p.Status != nil {
+        c.Status = *p.Status
+    }
+    if err := validateCampaign(&c); err != nil {
+        return nil, err
+    }
+
+    // кампании деактивированной компании заморожены до реактивации
+    if err := ensureCompanyActive(ctx, s.companies, c.CompanyID); err != nil {
+        return nil, err
+    }
+
+    if err := s.repoCampaigns.Update(ctx, &c); err != nil {
+        return nil, err
+    }
+
+    s.audit.Record(ctx, AuditEvent{
+        Action: "campaign.update", EntityType: "campaign", EntityID: c.ID, CompanyID: c.CompanyID,
+        Before: before, After: c,
+    })
+    s.webhooks.CampaignStatusChanged(ctx, c.CompanyID, c.ID, before.Status, c.Status, "update")
+    return &c, nil
+}
+
 //
 // --------------- GET FULL CAMPAIGN ---------------
 //
@@ -65,6 +182,23 @@ func (s *CampaignService) GetDetailed(ctx context.Context, id int64) (*models.Ca
     }, nil
 }
 
-func (s *CampaignService) List(ctx context.Context) ([]models.Campaign, error) {
-    return s.repoCampaigns.List(ctx)
+// List — страница кампаний (фильтры и сортировки — repositories.CampaignList)
+func (s *CampaignService) List(ctx context.Context, q models.ListQuery) (*models.Page[models.Campaign], error) {
+    if err := prepareList(&q, repositories.CampaignList); err != nil {
+        return nil, err
+    }
+    rows, err := s.repoCampaigns.List(ctx, q)
+    if err != nil {
+        return nil, err
+    }
+    return newPage(rows, q, repositories.CampaignList), nil
+}
+
+// CompanyOf — владелец кампании (проверка доступа по API-ключу)
+func (s *CampaignService) CompanyOf(ctx context.Context, id int64) (int64, error) {
+    c, err := s.repoCampaigns.GetByID(ctx, id)
+    if err != nil {
+        return 0, err
+    }
+    return c.CompanyID, nil
 }
@@ -0,0 +1,147 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/repositories"
+	"mediawork/internal/services"
+)
+
+func strPtr(s string) *string { return &s }
+
+// TestCampaignUpdatePartial — PUT меняет только переданные поля
+func TestCampaignUpdatePartial(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+	editor := e.member(t, "editor@example.com", "editor")
+
+	before, err := e.repos.Campaigns.GetByID(ctx, demoCampaignID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := e.campaigns.Update(ctx, editor, demoCampaignID, services.CampaignPatch{Name: strPtr("  Осень  ")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := *before
+	want.Name = "Осень"
+	if *after != want {
+		t.Errorf("Update(name) = %+v, want %+v", *after, want)
+	}
+	stored, err := e.repos.Campaigns.GetByID(ctx, demoCampaignID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "Осень" || stored.Status != before.Status || !stored.StartTime.Equal(before.StartTime) || !stored.EndTime.Equal(before.EndTime) {
+		t.Errorf("stored = %+v, want only the name changed from %+v", *stored, *before)
+	}
+}
+
+func TestCampaignUpdateInvalid(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+	editor := e.member(t, "editor@example.com", "editor")
+
+	tests := []struct {
+		name  string
+		patch services.CampaignPatch
+		field string
+	}{
+		{"unknown status", services.CampaignPatch{Status: strPtr("running")}, "status"},
+		{"empty status", services.CampaignPatch{Status: strPtr("")}, "status"},
+		{"blank name", services.CampaignPatch{Name: strPtr(" ")}, "name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := e.campaigns.Update(ctx, editor, demoCampaignID, tt.patch); !isValidation(err, tt.field) {
+				t.Errorf("Update = %v, want validation error on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestCampaignWriteRoles(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		actor *models.UserClaims
+		want  error
+	}{
+		{"viewer", e.member(t, "viewer@example.com", "viewer"), services.ErrForbidden},
+		{"not a member", e.member(t, "outsider@example.com", ""), services.ErrForbidden},
+		{"api key of another company", &models.UserClaims{APIKeyID: 1, CompanyID: demoCompanyID + 1}, services.ErrForbidden},
+		{"editor", e.member(t, "editor@example.com", "editor"), nil},
+		{"api key of the company", &models.UserClaims{APIKeyID: 2, CompanyID: demoCompanyID}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.campaigns.Update(ctx, tt.actor, demoCampaignID, services.CampaignPatch{Status: strPtr("cancelled")})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Update = %v, want %v", err, tt.want)
+			}
+			_, err = e.campaigns.Create(ctx, tt.actor, &models.Campaign{CompanyID: demoCompanyID, Name: "Новая"}, nil)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Create = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestCampaignUpdateWhileSuspended — кампанию, поставленную на паузу за просрочку,
+// нельзя возобновить вручную, пока счёт не оплачен
+func TestCampaignUpdateWhileSuspended(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+	editor := e.member(t, "editor@example.com", "editor")
+
+	draft := &models.Campaign{CompanyID: demoCompanyID, Name: "Черновик"}
+	if _, err := e.campaigns.Create(ctx, editor, draft, nil); err != nil {
+		t.Fatal(err)
+	}
+	due := e.now.AddDate(0, 0, -10)
+	inv := &models.Invoice{CompanyID: demoCompanyID, IssuedAt: due.AddDate(0, 0, -14), DueDate: &due, AmountTotal: money.NewFromInt(1200)}
+	if err := e.billing.Create(ctx, inv); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.dunning.Run(ctx, e.now); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := e.repos.Campaigns.GetByID(ctx, demoCampaignID); err != nil || c.Status != "paused" {
+		t.Fatalf("demo campaign after dunning = %+v, %v, want paused", c, err)
+	}
+
+	tests := []struct {
+		name   string
+		id     int64
+		status string
+		want   error
+	}{
+		{"resume paused", demoCampaignID, "active", repositories.ErrCampaignSuspended},
+		{"cancel paused", demoCampaignID, "cancelled", repositories.ErrCampaignSuspended},
+		{"start draft", draft.ID, "active", repositories.ErrCampaignSuspended},
+		{"keep paused", demoCampaignID, "paused", nil},
+		{"cancel draft", draft.ID, "cancelled", nil},
+	}
+	for _, tt := range tests {
+		if _, err := e.campaigns.Update(ctx, editor, tt.id, services.CampaignPatch{Status: strPtr(tt.status)}); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Update = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := e.billing.RecordPayment(ctx, &models.Payment{InvoiceID: inv.ID, Amount: money.NewFromInt(1200)}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := e.campaigns.Update(ctx, editor, demoCampaignID, services.CampaignPatch{Name: strPtr("После оплаты")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status == "paused" {
+		t.Errorf("status after payment = %s, want the campaign resumed", c.Status)
+	}
+}
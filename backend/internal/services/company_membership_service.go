@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/repositories"
+)
+
+// InvitationTTL — сколько живёт ссылка-приглашение
+const InvitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrForbidden          = errors.New("insufficient company role")
+	ErrInvitationInvalid  = errors.New("invitation not found or already used")
+	ErrInvitationExpired  = errors.New("invitation expired")
+	ErrInvitationMismatch = errors.New("invitation was sent to a different email")
+)
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newInvitationToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// requireRole возвращает роль актора в компании, если она не ниже required.
+// В деактивированную компанию не пускает никого.
+func (s *CompanyService) requireRole(ctx context.Context, companyID, actorID int64, required string) (string, error) {
+	if err := ensureCompanyActive(ctx, s.companies, companyID); err != nil {
+		return "", err
+	}
+
+	role, err := s.members.GetUserRole(ctx, companyID, actorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrForbidden
+	}
+	if err != nil {
+		return "", err
+	}
+	if repositories.CompanyRoleRank(role) < repositories.CompanyRoleRank(required) {
+		return "", ErrForbidden
+	}
+	return role, nil
+}
+
+// requireCompanyRole — админ платформы или участник компании с ролью не ниже required.
+// API-ключ действует от имени своей компании (область и владельца проверяет APIKeyGuard)
+// и не выше editor.
+func requireCompanyRole(ctx context.Context, members CompanyMembershipRepository, companyID int64, actor *models.UserClaims, required string) error {
+	if actor == nil {
+		return ErrForbidden
+	}
+	if actor.APIKeyID != 0 {
+		if actor.CompanyID != companyID || repositories.CompanyRoleRank(required) > repositories.CompanyRoleRank("editor") {
+			return ErrForbidden
+		}
+		return nil
+	}
+	if actor.Role == "admin" {
+		return nil
+	}
+	role, err := members.GetUserRole(ctx, companyID, actor.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+	if repositories.CompanyRoleRank(role) < repositories.CompanyRoleRank(required) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// requireCompanyAdmin — для настроек интеграций компании (webhooks, API-ключи):
+// админ платформы или участник с ролью не ниже admin. API-ключ сюда не проходит.
+func requireCompanyAdmin(ctx context.Context, members CompanyMembershipRepository, companyID int64, actor *models.UserClaims) error {
+	return requireCompanyRole(ctx, members, companyID, actor, "admin")
+}
+
+// validAssignableRole — роль, которую можно выдать приглашением / сменой роли.
+// owner выдаётся только передачей владения.
+func validAssignableRole(role string) error {
+	if repositories.CompanyRoleRank(role) == 0 {
+		return invalidf("role", "unknown role %q", role)
+	}
+	if role == "owner" {
+		return invalid("role", "use ownership transfer to assign owner")
+	}
+	return nil
+}
+
+//
+// ---------- MEMBERS ----------
+//
+func (s *CompanyService) ListMembers(ctx context.Context, companyID, actorID int64) ([]models.CompanyMember, error) {
+	if _, err := s.requireRole(ctx, companyID, actorID, "viewer"); err != nil {
+		return nil, err
+	}
+	return s.members.ListMembers(ctx, companyID)
+}
+
+// ChangeMemberRole: нужен admin+, менять можно только тех, кто ниже тебя,
+// и выдавать роль не выше своей
+func (s *CompanyService) ChangeMemberRole(ctx context.Context, companyID, actorID, userID int64, role string) error {
+	if err := validAssignableRole(role); err != nil {
+		return err
+	}
+	if actorID == userID {
+		return invalid("", "cannot change your own role")
+	}
+
+	actorRole, err := s.requireRole(ctx, companyID, actorID, "admin")
+	if err != nil {
+		return err
+	}
+	current, err := s.members.GetUserRole(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+
+	actorRank := repositories.CompanyRoleRank(actorRole)
+	if repositories.CompanyRoleRank(current) >= actorRank || repositories.CompanyRoleRank(role) > actorRank {
+		return ErrForbidden
+	}
+
+	if err := s.members.AddMember(ctx, companyID, userID, role); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "company.member_role_change", EntityType: "user", EntityID: userID, CompanyID: companyID,
+		Before: map[string]string{"role": current},
+		After:  map[string]string{"role": role},
+	})
+	return nil
+}
+
+// RemoveMember: участник может выйти сам (кроме владельца),
+// остальных удаляет admin+ с ролью выше удаляемого. Права проверяются до поиска
+// удаляемого: не-участник не отличит «нет такого участника» от «нельзя».
+func (s *CompanyService) RemoveMember(ctx context.Context, companyID, actorID, userID int64) error {
+	actorRole := ""
+	if actorID != userID {
+		var err error
+		if actorRole, err = s.requireRole(ctx, companyID, actorID, "admin"); err != nil {
+			return err
+		}
+	}
+
+	current, err := s.members.GetUserRole(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+	if current == "owner" {
+		return invalid("", "transfer ownership before removing the owner")
+	}
+	if actorRole != "" && repositories.CompanyRoleRank(current) >= repositories.CompanyRoleRank(actorRole) {
+		return ErrForbidden
+	}
+
+	if err := s.members.RemoveMember(ctx, companyID, userID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "company.member_remove", EntityType: "user", EntityID: userID, CompanyID: companyID,
+		Before: map[string]string{"role": current},
+	})
+	return nil
+}
+
+func (s *CompanyService) TransferOwnership(ctx context.Context, companyID, actorID, newOwnerID int64) error {
+	if actorID == newOwnerID {
+		return invalid("user_id", "you already own this company")
+	}
+	if _, err := s.requireRole(ctx, companyID, actorID, "owner"); err != nil {
+		return err
+	}
+	if _, err := s.members.GetUserRole(ctx, companyID, newOwnerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return invalid("user_id", "new owner must be a member of the company")
+		}
+		return err
+	}
+
+	if err := s.members.TransferOwnership(ctx, companyID, actorID, newOwnerID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "company.ownership_transfer", EntityType: "company", EntityID: companyID, CompanyID: companyID,
+		Before: map[string]int64{"owner_id": actorID},
+		After:  map[string]int64{"owner_id": newOwnerID},
+	})
+	return nil
+}
+
+//
+// ---------- INVITATIONS ----------
+//
+// Invite создаёт приглашение; в ответе есть token — его нужно передать приглашённому
+// (письмом или ссылкой), повторно он не показывается
+func (s *CompanyService) Invite(ctx context.Context, companyID, actorID int64, email, role string) (*models.CompanyInvitation, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return nil, invalidf("email", "invalid email %q", email)
+	}
+	if err := validAssignableRole(role); err != nil {
+		return nil, err
+	}
+
+	actorRole, err := s.requireRole(ctx, companyID, actorID, "admin")
+	if err != nil {
+		return nil, err
+	}
+	if repositories.CompanyRoleRank(role) > repositories.CompanyRoleRank(actorRole) {
+		return nil, ErrForbidden
+	}
+
+	token, err := newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &models.CompanyInvitation{
+		CompanyID: companyID,
+		Email:     strings.ToLower(addr.Address),
+		Role:      role,
+		InvitedBy: actorID,
+		ExpiresAt: time.Now().Add(InvitationTTL),
+	}
+	if err := s.invitations.Create(ctx, inv, hashInvitationToken(token)); err != nil {
+		return nil, err
+	}
+	inv.Token = token
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "company.invite", EntityType: "invitation", EntityID: inv.ID, CompanyID: companyID,
+		After: map[string]string{"email": inv.Email, "role": inv.Role},
+	})
+	return inv, nil
+}
+
+func (s *CompanyService) ListInvitations(ctx context.Context, companyID, actorID int64) ([]models.CompanyInvitation, error) {
+	if _, err := s.requireRole(ctx, companyID, actorID, "admin"); err != nil {
+		return nil, err
+	}
+	return s.invitations.ListByCompany(ctx, companyID)
+}
+
+func (s *CompanyService) RevokeInvitation(ctx context.Context, companyID, actorID, invitationID int64) error {
+	if _, err := s.requireRole(ctx, companyID, actorID, "admin"); err != nil {
+		return err
+	}
+
+	inv, err := s.invitations.GetByID(ctx, invitationID)
+	if err != nil || inv.CompanyID != companyID {
+		return ErrInvitationInvalid
+	}
+	ok, err := s.invitations.SetStatus(ctx, invitationID, "revoked")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvitationInvalid
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "company.invite_revoke", EntityType: "invitation", EntityID: invitationID, CompanyID: companyID,
+	})
+	return nil
+}
+
+// PendingInvitations — приглашения, ожидающие ответа пользователя
+func (s *CompanyService) PendingInvitations(ctx context.Context, userID int64) ([]models.CompanyInvitation, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.invitations.ListPendingByEmail(ctx, u.Email, time.Now())
+}
+
+// resolveInvitation находит приглашение по токену и проверяет, что оно адресовано userID
+func (s *CompanyService) resolveInvitation(ctx context.Context, token string, userID int64) (*models.CompanyInvitation, error) {
+	inv, err := s.invitations.GetByTokenHash(ctx, hashInvitationToken(token))
+	if err != nil || inv.Status != "pending" {
+		return nil, ErrInvitationInvalid
+	}
+	if time.Now().After(inv.ExpiresAt) {
+		return nil, ErrInvitationExpired
+	}
+	if err := ensureCompanyActive(ctx, s.companies, inv.CompanyID); err != nil {
+		return nil, err
+	}
+
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(u.Email, inv.Email) {
+		return nil, ErrInvitationMismatch
+	}
+	return inv, nil
+}
+
+func (s *CompanyService) AcceptInvitation(ctx context.Context, token string, userID int64) (*models.CompanyInvitation, error) {
+	inv, err := s.resolveInvitation(ctx, token, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.invitations.Accept(ctx, inv, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvitationInvalid
+	}
+	inv.Status = "accepted"
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "company.invite_accept", EntityType: "invitation", EntityID: inv.ID, CompanyID: inv.CompanyID,
+		After: map[string]any{"user_id": userID, "role": inv.Role},
+	})
+	return inv, nil
+}
+
+func (s *CompanyService) DeclineInvitation(ctx context.Context, token string, userID int64) error {
+	inv, err := s.resolveInvitation(ctx, token, userID)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.invitations.SetStatus(ctx, inv.ID, "declined")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvitationInvalid
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "company.invite_decline", EntityType: "invitation", EntityID: inv.ID, CompanyID: inv.CompanyID,
+	})
+	return nil
+}
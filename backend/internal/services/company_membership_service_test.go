@@ -0,0 +1,46 @@
+package services_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"mediawork/internal/services"
+)
+
+func TestRemoveMember(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+	admin := e.member(t, "admin@example.com", "admin")
+	editor := e.member(t, "editor@example.com", "editor")
+	viewer := e.member(t, "viewer@example.com", "viewer")
+	otherAdmin := e.member(t, "admin2@example.com", "admin")
+	outsider := e.member(t, "outsider@example.com", "")
+	const missing int64 = 999
+
+	tests := []struct {
+		name          string
+		actor, target int64
+		want          error
+	}{
+		// не-участнику одинаково отказано — есть такой участник или нет
+		{name: "outsider removes member", actor: outsider.UserID, target: viewer.UserID, want: services.ErrForbidden},
+		{name: "outsider probes missing", actor: outsider.UserID, target: missing, want: services.ErrForbidden},
+		{name: "viewer removes member", actor: viewer.UserID, target: editor.UserID, want: services.ErrForbidden},
+		{name: "editor probes missing", actor: editor.UserID, target: missing, want: services.ErrForbidden},
+		{name: "admin removes missing", actor: admin.UserID, target: missing, want: sql.ErrNoRows},
+		{name: "admin removes admin", actor: admin.UserID, target: otherAdmin.UserID, want: services.ErrForbidden},
+		{name: "admin removes editor", actor: admin.UserID, target: editor.UserID},
+		{name: "viewer leaves", actor: viewer.UserID, target: viewer.UserID},
+		{name: "outsider leaves", actor: outsider.UserID, target: outsider.UserID, want: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := e.companies.RemoveMember(ctx, demoCompanyID, tt.actor, tt.target)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("RemoveMember = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -2,29 +2,69 @@ package services
 
 import (
     "context"
+    "errors"
+    "strings"
 
     "mediawork/internal/models"
     "mediawork/internal/repositories"
 )
 
 type CompanyService struct {
-    companies *repositories.CompanyRepository
-    members   *repositories.CompanyMembershipRepository
+    companies   CompanyRepository
+    members     CompanyMembershipRepository
+    invitations CompanyInvitationRepository
+    users       UserRepository
+    lifecycle   CompanyLifecycleRepository
+    audit       *AuditService
+    webhooks    *WebhookService
+}
+
+// ErrCompanyInactive — компания деактивирована: участники теряют доступ,
+// кампании на паузе, новые счета не выставляются
+var ErrCompanyInactive = errors.New("company is deactivated")
+
+// ensureCompanyActive — общая проверка для сервисов, работающих от имени компании
+func ensureCompanyActive(ctx context.Context, companies CompanyRepository, id int64) error {
+    c, err := companies.GetByID(ctx, id)
+    if err != nil {
+        return err
+    }
+    if !c.IsActive {
+        return ErrCompanyInactive
+    }
+    return nil
 }
 
 func NewCompanyService(
-    companies *repositories.CompanyRepository,
-    members *repositories.CompanyMembershipRepository,
+    companies CompanyRepository,
+    members CompanyMembershipRepository,
+    invitations CompanyInvitationRepository,
+    users UserRepository,
+    lifecycle CompanyLifecycleRepository,
+    audit *AuditService,
+    webhooks *WebhookService,
 ) *CompanyService {
     return &CompanyService{
-        companies: companies,
-        members:   members,
+        companies:   companies,
+        members:     members,
+        invitations: invitations,
+        users:       users,
+        lifecycle:   lifecycle,
+        audit:       audit,
+        webhooks:    webhooks,
     }
 }
 
-// Список компаний (для админки / обзора)
-func (s *CompanyService) ListCompanies(ctx context.Context, limit, offset int) ([]models.Company, error) {
-    return s.companies.List(ctx, limit, offset)
+// Список компаний (для админки / обзора); фильтры и сортировки — repositories.CompanyList
+func (s *CompanyService) ListCompanies(ctx context.Context, q models.ListQuery) (*models.Page[models.Company], error) {
+    if err := prepareList(&q, repositories.CompanyList); err != nil {
+        return nil, err
+    }
+    rows, err := s.companies.List(ctx, q)
+    if err != nil {
+        return nil, err
+    }
+    return newPage(rows, q, repositories.CompanyList), nil
 }
 
 // Одна компания + участники
@@ -42,26 +82,100 @@ func (s *CompanyService) GetCompanyWithMembers(ctx context.Context, id int64) (*
     return c, members, nil
 }
 
-// Создать компанию
-// Создать компанию
+// Создать компанию; создатель (c.OwnerID) становится владельцем
 func (s *CompanyService) CreateCompany(ctx context.Context, c *models.Company) (int64, error) {
+    c.Name = strings.TrimSpace(c.Name)
+    if c.Name == "" {
+        return 0, invalid("name", "name is required")
+    }
+
     err := s.companies.Create(ctx, c)
     if err != nil {
         return 0, err
     }
+    if err := s.members.AddMember(ctx, c.ID, c.OwnerID, "owner"); err != nil {
+        return 0, err
+    }
 
+    s.audit.Record(ctx, AuditEvent{
+        Action: "company.create", EntityType: "company", EntityID: c.ID, CompanyID: c.ID,
+        After: c,
+    })
     return c.ID, nil
 }
 
 
 // Обновить компанию
 func (s *CompanyService) UpdateCompany(ctx context.Context, c *models.Company) error {
-    return s.companies.Update(ctx, c)
+    before, err := s.companies.GetByID(ctx, c.ID)
+    if err != nil {
+        return err
+    }
+    if err := s.companies.Update(ctx, c); err != nil {
+        return err
+    }
+
+    s.audit.Record(ctx, AuditEvent{
+        Action: "company.update", EntityType: "company", EntityID: c.ID, CompanyID: c.ID,
+        Before: before, After: c,
+    })
+    return nil
+}
+
+// Деактивировать (мягкое удаление): кампании на паузу, слоты из расписания,
+// участники теряют доступ, счета не выставляются
+func (s *CompanyService) DeactivateCompany(ctx context.Context, id int64, reason string, actorID int64) (*models.CompanySuspension, error) {
+    before, err := s.companies.GetByID(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+
+    susp, err := s.lifecycle.Deactivate(ctx, id, reason, nullableID(actorID))
+    if err != nil {
+        return nil, err
+    }
+
+    after := *before
+    after.IsActive = false
+    s.audit.Record(ctx, AuditEvent{
+        Action: "company.deactivate", EntityType: "company", EntityID: id, CompanyID: id,
+        Before: before, After: &after,
+    })
+    s.webhooks.CampaignsSuspended(ctx, susp, false)
+    return susp, nil
+}
+
+// Реактивировать: вернуть кампаниям статус до деактивации и слоты в расписание
+func (s *CompanyService) ReactivateCompany(ctx context.Context, id int64, actorID int64) (*models.CompanySuspension, error) {
+    before, err := s.companies.GetByID(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+
+    susp, err := s.lifecycle.Reactivate(ctx, id, nullableID(actorID))
+    if err != nil {
+        return nil, err
+    }
+
+    after := *before
+    after.IsActive = true
+    s.audit.Record(ctx, AuditEvent{
+        Action: "company.reactivate", EntityType: "company", EntityID: id, CompanyID: id,
+        Before: before, After: &after,
+    })
+    s.webhooks.CampaignsSuspended(ctx, susp, true)
+    return susp, nil
+}
+
+func (s *CompanyService) ListSuspensions(ctx context.Context, id int64) ([]models.CompanySuspension, error) {
+    return s.lifecycle.ListSuspensions(ctx, id)
 }
 
-// Деактивировать (мягкое удаление)
-func (s *CompanyService) DeactivateCompany(ctx context.Context, id int64) error {
-    return s.companies.SetActive(ctx, id, false)
+func nullableID(id int64) *int64 {
+    if id == 0 {
+        return nil
+    }
+    return &id
 }
 
 // Получить компанию + участников (детальный просмотр)
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+
+	"mediawork/internal/models"
+	"mediawork/internal/repositories"
+)
+
+// CreativeService — загруженные ролики компаний
+type CreativeService struct {
+	creatives CreativeRepository
+}
+
+func NewCreativeService(c CreativeRepository) *CreativeService {
+	return &CreativeService{creatives: c}
+}
+
+// List — страница роликов (фильтры и сортировки — repositories.CreativeList)
+func (s *CreativeService) List(ctx context.Context, q models.ListQuery) (*models.Page[models.Creative], error) {
+	if err := prepareList(&q, repositories.CreativeList); err != nil {
+		return nil, err
+	}
+	rows, err := s.creatives.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(rows, q, repositories.CreativeList), nil
+}
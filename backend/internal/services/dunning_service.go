@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/notify"
+	"mediawork/internal/repositories"
+)
+
+type DunningConfig struct {
+	// ReminderOffsets — когда напоминать, в днях от due_date (-3 = за три дня до срока)
+	ReminderOffsets []int
+	// PauseAfterDays — через сколько дней просрочки ставить кампании компании на паузу; 0 — не ставить
+	PauseAfterDays int
+}
+
+// DunningService — просрочки, напоминания и пауза кампаний должников
+type DunningService struct {
+	dunning   DunningRepository
+	lifecycle CompanyLifecycleRepository
+	members   CompanyMembershipRepository
+	notifier  notify.Notifier
+	audit     *AuditService
+	webhooks  *WebhookService
+	cfg       DunningConfig
+}
+
+func NewDunningService(
+	d DunningRepository,
+	l CompanyLifecycleRepository,
+	m CompanyMembershipRepository,
+	n notify.Notifier,
+	a *AuditService,
+	wh *WebhookService,
+	cfg DunningConfig,
+) *DunningService {
+	if n == nil {
+		n = notify.LogNotifier{}
+	}
+	offsets := append([]int(nil), cfg.ReminderOffsets...)
+	sort.Ints(offsets)
+	cfg.ReminderOffsets = offsets
+	return &DunningService{dunning: d, lifecycle: l, members: m, notifier: n, audit: a, webhooks: wh, cfg: cfg}
+}
+
+// Run — один проход задачи: просрочки → напоминания → политика паузы
+func (s *DunningService) Run(ctx context.Context, now time.Time) error {
+	today := truncDay(now)
+
+	ids, err := s.dunning.MarkOverdue(ctx, today)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		s.audit.Record(ctx, AuditEvent{
+			Action: "invoice.overdue", EntityType: "invoice", EntityID: id,
+			After: map[string]string{"status": "overdue"},
+		})
+	}
+
+	if err := s.SendReminders(ctx, today); err != nil {
+		return err
+	}
+	return s.ApplyPausePolicy(ctx, today)
+}
+
+// reminderOffset — самое позднее наступившее смещение; ранние пропущенные не досылаем,
+// чтобы первый запуск по старым счетам не засыпал клиента письмами
+func (s *DunningService) reminderOffset(daysOverdue int) (int, bool) {
+	offset, ok := 0, false
+	for _, o := range s.cfg.ReminderOffsets {
+		if o <= daysOverdue {
+			offset, ok = o, true
+		}
+	}
+	return offset, ok
+}
+
+// billingContacts — email владельцев и админов компании
+func billingContacts(ctx context.Context, m CompanyMembershipRepository, companyID int64) ([]string, error) {
+	members, err := m.ListMembers(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	var to []string
+	for _, m := range members {
+		if repositories.CompanyRoleRank(m.Role) >= repositories.CompanyRoleRank("admin") {
+			to = append(to, m.Email)
+		}
+	}
+	return to, nil
+}
+
+func (s *DunningService) SendReminders(ctx context.Context, today time.Time) error {
+	invoices, err := s.dunning.ListUnpaid(ctx, today)
+	if err != nil {
+		return err
+	}
+
+	for _, inv := range invoices {
+		offset, ok := s.reminderOffset(inv.DaysOverdue)
+		if !ok {
+			continue
+		}
+
+		to, err := billingContacts(ctx, s.members, inv.CompanyID)
+		if err != nil {
+			return err
+		}
+		if len(to) == 0 {
+			continue
+		}
+
+		claimed, err := s.dunning.ClaimReminder(ctx, inv.ID, offset)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.notifier.Notify(ctx, reminderMessage(inv, to)); err != nil {
+			slog.ErrorContext(ctx, "dunning: reminder failed", "invoice_id", inv.ID, "err", err)
+			if err := s.dunning.ReleaseReminder(ctx, inv.ID, offset); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func reminderMessage(inv models.OverdueInvoice, to []string) notify.Message {
+	outstanding := inv.AmountTotal.Sub(inv.AmountPaid).Sub(inv.AmountCredited)
+
+	kind, subject := "invoice.reminder", fmt.Sprintf("Invoice %s is due in %d day(s)", inv.InvoiceNumber, -inv.DaysOverdue)
+	switch {
+	case inv.DaysOverdue == 0:
+		subject = fmt.Sprintf("Invoice %s is due today", inv.InvoiceNumber)
+	case inv.DaysOverdue > 0:
+		kind, subject = "invoice.overdue", fmt.Sprintf("Invoice %s is %d day(s) overdue", inv.InvoiceNumber, inv.DaysOverdue)
+	}
+
+	return notify.Message{
+		Kind:    kind,
+		To:      to,
+		Subject: subject,
+		Body:    fmt.Sprintf("Outstanding amount: %s.", money.Format(outstanding, inv.Currency)),
+		Meta: map[string]any{
+			"invoice_id":   inv.ID,
+			"company_id":   inv.CompanyID,
+			"days_overdue": inv.DaysOverdue,
+			"outstanding":  outstanding,
+			"currency":     inv.Currency,
+		},
+	}
+}
+
+// ApplyPausePolicy ставит на паузу кампании компаний с просрочкой >= PauseAfterDays
+// и снимает паузу, когда просрочек больше нет (или политика выключена)
+func (s *DunningService) ApplyPausePolicy(ctx context.Context, today time.Time) error {
+	overdue := map[int64]bool{}
+	if s.cfg.PauseAfterDays > 0 {
+		var err error
+		if overdue, err = s.dunning.CompaniesOverdue(ctx, today, s.cfg.PauseAfterDays); err != nil {
+			return err
+		}
+	}
+
+	for companyID := range overdue {
+		reason := fmt.Sprintf("invoice overdue for %d+ days", s.cfg.PauseAfterDays)
+		susp, err := s.lifecycle.PauseCampaigns(ctx, companyID, repositories.SuspensionDunning, reason)
+		if err != nil {
+			return err
+		}
+		if susp == nil {
+			continue
+		}
+		scheduleDecisions.With("dunning_paused").Add(float64(susp.PausedCampaigns))
+
+		s.audit.Record(ctx, AuditEvent{
+			Action: "company.dunning_pause", EntityType: "company", EntityID: companyID, CompanyID: companyID,
+			After: susp,
+		})
+		s.webhooks.CampaignsSuspended(ctx, susp, false)
+		if to, err := billingContacts(ctx, s.members, companyID); err == nil && len(to) > 0 {
+			msg := notify.Message{
+				Kind:    "company.campaigns_paused",
+				To:      to,
+				Subject: "Campaigns paused due to overdue invoices",
+				Body:    fmt.Sprintf("%d campaign(s) were paused and will resume once overdue invoices are paid.", susp.PausedCampaigns),
+				Meta:    map[string]any{"company_id": companyID},
+			}
+			if err := s.notifier.Notify(ctx, msg); err != nil {
+				slog.ErrorContext(ctx, "dunning: pause notice failed", "company_id", companyID, "err", err)
+			}
+		}
+	}
+
+	paused, err := s.lifecycle.OpenSuspensionCompanies(ctx, repositories.SuspensionDunning)
+	if err != nil {
+		return err
+	}
+	for _, companyID := range paused {
+		if overdue[companyID] {
+			continue
+		}
+		susp, err := s.lifecycle.ResumeCampaigns(ctx, companyID, repositories.SuspensionDunning)
+		if err != nil {
+			return err
+		}
+		if susp != nil {
+			scheduleDecisions.With("dunning_resumed").Add(float64(susp.PausedCampaigns))
+			s.audit.Record(ctx, AuditEvent{
+				Action: "company.dunning_resume", EntityType: "company", EntityID: companyID, CompanyID: companyID,
+				Before: susp,
+			})
+			s.webhooks.CampaignsSuspended(ctx, susp, true)
+		}
+	}
+	return nil
+}
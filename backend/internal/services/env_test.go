@@ -0,0 +1,113 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/repositories/memory"
+	"mediawork/internal/services"
+)
+
+// Демо-данные memory.Seed: компания, кампания и её фасады
+const (
+	demoCompanyID  int64 = 1
+	demoCampaignID int64 = 1
+	demoFacadeID   int64 = 1
+)
+
+// platformAdmin — админ платформы: проходит проверку роли в любой компании
+var platformAdmin = &models.UserClaims{UserID: 1, Role: "admin"}
+
+// TestMain глушит журнал: ошибки списаний и уведомления в тестах ожидаемы
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// env — сервисы поверх хранилища в памяти с демо-данными; часы хранилища остановлены
+// на 18:00 UTC сегодняшнего дня, когда у демо-кампании идёт слот в любой день недели
+type env struct {
+	store *memory.Store
+	repos services.Repositories
+	now   time.Time
+
+	auth      *services.AuthService
+	admin     *services.AdminService
+	companies *services.CompanyService
+	campaigns *services.CampaignService
+	tax       *services.TaxService
+	fx        *services.ExchangeRateService
+	billing   *services.BillingService
+	budgets   *services.BudgetService
+	dunning   *services.DunningService
+	live      *services.LiveStreamService
+	facades   *services.FacadeService
+	groups    *services.FacadeGroupService
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+
+	now := time.Now().UTC().Truncate(24 * time.Hour).Add(18 * time.Hour)
+	store := memory.NewStore()
+	store.Now = func() time.Time { return now }
+	if err := memory.Seed(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+	r := store.Repositories()
+
+	e := &env{store: store, repos: r, now: now}
+	audit := services.NewAuditService(r.Audit)
+	webhooks := services.NewWebhookService(r.Webhooks, r.Memberships, r.Facades, r.CompanyLifecycle, audit, nil, services.WebhookConfig{})
+	e.auth = services.NewAuthService(r.Users, "test-secret")
+	e.admin = services.NewAdminService(r.Users, r.Companies, r.Memberships, e.auth, audit)
+	e.companies = services.NewCompanyService(r.Companies, r.Memberships, r.Invitations, r.Users, r.CompanyLifecycle, audit, webhooks)
+	e.campaigns = services.NewCampaignService(r.Campaigns, r.CampaignSlot, r.Companies, r.Memberships, audit, webhooks)
+	e.tax = services.NewTaxService(r.TaxRates, audit)
+	e.fx = services.NewExchangeRateService(r.ExchangeRates, audit)
+	e.dunning = services.NewDunningService(r.Dunning, r.CompanyLifecycle, r.Memberships, nil, audit, webhooks, services.DunningConfig{PauseAfterDays: 7})
+	e.billing = services.NewBillingService(r.Invoices, r.Companies, r.Payments, e.tax, e.fx, e.dunning, audit, webhooks)
+	e.budgets = services.NewBudgetService(r.Budgets, r.Campaigns, r.Companies, r.Memberships, e.fx, nil, audit)
+	e.live = services.NewLiveStreamService(r.LiveStream, e.budgets, webhooks)
+	e.facades = services.NewFacadeService(r.Facades, r.LiveStream, r.Audience, audit)
+	e.groups = services.NewFacadeGroupService(r.FacadeGroups, r.FacadeTags, r.Facades, r.Campaigns, r.Memberships, audit)
+	return e
+}
+
+// play — показ демо-кампании на первом фасаде, закончившийся только что
+func (e *env) play(durationSec int) error {
+	return e.live.PlayEvent(context.Background(), demoFacadeID, &models.PlayEvent{
+		CampaignID:  demoCampaignID,
+		PlayedAt:    e.now,
+		DurationSec: durationSec,
+	})
+}
+
+// member — новый пользователь (глобальная роль viewer) с ролью role в демо-компании;
+// пустая role — не участник
+func (e *env) member(t *testing.T, email, role string) *models.UserClaims {
+	t.Helper()
+	ctx := context.Background()
+	u, err := e.auth.Register(ctx, email, "member-password", email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role != "" {
+		if err := e.repos.Memberships.AddMember(ctx, demoCompanyID, u.ID, role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &models.UserClaims{UserID: u.ID, Email: email, Role: "viewer"}
+}
+
+// isValidation — ошибка проверки поля field (ValidationError → 422)
+func isValidation(err error, field string) bool {
+	var ve *services.ValidationError
+	return errors.As(err, &ve) && ve.Field == field
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ValidationError — входные данные не прошли проверку сервиса (HTTP 422).
+// Field — поле запроса в snake_case, как в JSON; пустое — ошибка относится к запросу целиком.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// invalid — ошибка проверки поля field
+func invalid(field, message string) error {
+	return &ValidationError{Field: field, Message: message}
+}
+
+func invalidf(field, format string, args ...any) error {
+	return &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// AsValidation — ValidationError из цепочки err (в том числе обёрнутой через %w)
+func AsValidation(err error) (*ValidationError, bool) {
+	var v *ValidationError
+	if errors.As(err, &v) {
+		return v, true
+	}
+	return nil, false
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+)
+
+var ErrNoExchangeRate = errors.New("no exchange rate for currency pair")
+
+// ExchangeRateService — курсы валют для пересчёта счетов. Курсы ведёт админ вручную;
+// если есть только обратная пара (USD→RUB при запросе RUB→USD), берётся 1/rate.
+type ExchangeRateService struct {
+	rates ExchangeRateRepository
+	audit *AuditService
+}
+
+func NewExchangeRateService(r ExchangeRateRepository, a *AuditService) *ExchangeRateService {
+	return &ExchangeRateService{rates: r, audit: a}
+}
+
+// ---------- RATE ----------
+// Rate — сколько единиц to за одну единицу from на дату on
+func (s *ExchangeRateService) Rate(ctx context.Context, from, to string, on time.Time) (money.Decimal, error) {
+	if from == to {
+		return money.One, nil
+	}
+
+	direct, err := s.rates.Find(ctx, from, to, on)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return money.Zero, err
+	}
+	inverse, err := s.rates.Find(ctx, to, from, on)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return money.Zero, err
+	}
+
+	// из двух направлений берём более свежий курс
+	switch {
+	case direct != nil && (inverse == nil || !inverse.ValidOn.After(direct.ValidOn)):
+		return direct.Rate, nil
+	case inverse != nil:
+		return money.One.Div(inverse.Rate), nil
+	}
+	return money.Zero, fmt.Errorf("%w %s/%s on %s", ErrNoExchangeRate, from, to, on.Format("2006-01-02"))
+}
+
+// Convert — сумма в валюте to, округлённая до её минимальной единицы
+func (s *ExchangeRateService) Convert(ctx context.Context, amount money.Decimal, from, to string, on time.Time) (money.Decimal, money.Decimal, error) {
+	rate, err := s.Rate(ctx, from, to, on)
+	if err != nil {
+		return money.Zero, money.Zero, err
+	}
+	converted, err := amount.MulErr(rate)
+	if err != nil {
+		return money.Zero, money.Zero, err
+	}
+	if converted, err = converted.RoundToErr(to); err != nil {
+		return money.Zero, money.Zero, err
+	}
+	return converted, rate, nil
+}
+
+// ---------- ADMIN ----------
+func (s *ExchangeRateService) Set(ctx context.Context, e *models.ExchangeRate) error {
+	var err error
+	if e.Base, err = money.NormalizeCurrency(e.Base); err != nil {
+		return invalid("base", err.Error())
+	}
+	if e.Quote, err = money.NormalizeCurrency(e.Quote); err != nil {
+		return invalid("quote", err.Error())
+	}
+	if e.Base == e.Quote {
+		return invalid("quote", "base and quote currencies must differ")
+	}
+	if e.Rate.Sign() <= 0 {
+		return invalid("rate", "rate must be positive")
+	}
+	if e.ValidOn.IsZero() {
+		e.ValidOn = time.Now()
+	}
+	e.ValidOn = truncDay(e.ValidOn)
+
+	if err := s.rates.Upsert(ctx, e); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "exchange_rate.set", EntityType: "exchange_rate", EntityID: e.ID,
+		After: e,
+	})
+	return nil
+}
+
+func (s *ExchangeRateService) List(ctx context.Context, base, quote string, limit, offset int) ([]models.ExchangeRate, error) {
+	limit, offset = normalizePage(limit, offset)
+	return s.rates.List(ctx, base, quote, limit, offset)
+}
+
+func (s *ExchangeRateService) Delete(ctx context.Context, id int64) error {
+	if err := s.rates.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditEvent{Action: "exchange_rate.delete", EntityType: "exchange_rate", EntityID: id})
+	return nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"mediawork/internal/geo"
+	"mediawork/internal/models"
+)
+
+// GeoQuery — ровно один из BBox / Center+RadiusM / Polygon
+type GeoQuery struct {
+	BBox    *geo.BBox
+	Center  *geo.Point
+	RadiusM float64
+	Polygon geo.Polygon
+
+	Status        string // online | offline, пусто — любые
+	OnlyAvailable bool   // только фасады без кампаний в окне From..To
+	From, To      time.Time
+}
+
+const maxSearchRadiusM = 100_000
+
+//
+// --------------- GEO SEARCH → GeoJSON ---------------
+//
+func (s *FacadeService) Search(ctx context.Context, q GeoQuery) (*models.GeoFeatureCollection, error) {
+	var bounds geo.BBox
+	switch {
+	case q.BBox != nil:
+		if err := q.BBox.Validate(); err != nil {
+			return nil, invalid("bbox", err.Error())
+		}
+		bounds = *q.BBox
+	case q.Center != nil:
+		if !q.Center.Valid() {
+			return nil, invalid("lat", "center is out of range")
+		}
+		if math.IsNaN(q.RadiusM) || q.RadiusM <= 0 || q.RadiusM > maxSearchRadiusM {
+			return nil, invalid("radius_m", "radius must be between 0 and 100000 meters")
+		}
+		bounds = geo.RadiusBBox(*q.Center, q.RadiusM)
+	case q.Polygon != nil:
+		if err := q.Polygon.Validate(); err != nil {
+			return nil, invalid("coordinates", err.Error())
+		}
+		bounds = q.Polygon.Bounds()
+	default:
+		return nil, invalid("", "one of bbox, center+radius or polygon is required")
+	}
+
+	if q.From.IsZero() {
+		q.From = time.Now().UTC()
+	}
+	if !q.To.After(q.From) {
+		q.To = q.From.Add(time.Second)
+	}
+
+	candidates, err := s.facades.ListInBBox(ctx, bounds.MinLat, bounds.MinLon, bounds.MaxLat, bounds.MaxLon)
+	if err != nil {
+		return nil, err
+	}
+
+	type hit struct {
+		facade   models.Facade
+		distance float64
+	}
+
+	hits := []hit{}
+	ids := []int64{}
+	for _, f := range candidates {
+		pt := geo.Point{Lat: f.Latitude, Lon: f.Longitude}
+		h := hit{facade: f, distance: -1}
+
+		if q.Center != nil {
+			h.distance = geo.DistanceM(*q.Center, pt)
+			if h.distance > q.RadiusM {
+				continue
+			}
+		}
+		if q.Polygon != nil && !q.Polygon.Contains(pt) {
+			continue
+		}
+		if q.Status != "" && f.Status != q.Status {
+			continue
+		}
+
+		hits = append(hits, h)
+		ids = append(ids, f.ID)
+	}
+
+	booked := map[int64]int{}
+	if len(ids) > 0 {
+		booked, err = s.facades.CountBookedCampaigns(ctx, ids, q.From, q.To)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if q.Center != nil {
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].distance < hits[j].distance })
+	}
+
+	fc := &models.GeoFeatureCollection{Type: "FeatureCollection", Features: []models.GeoFeature{}}
+	for _, h := range hits {
+		n := booked[h.facade.ID]
+		if q.OnlyAvailable && n > 0 {
+			continue
+		}
+
+		feature := FacadeFeature(&h.facade)
+		feature.Properties["booked_campaigns"] = n
+		feature.Properties["available"] = n == 0
+		if h.distance >= 0 {
+			feature.Properties["distance_m"] = int64(h.distance + 0.5)
+		}
+		fc.Features = append(fc.Features, feature)
+	}
+
+	return fc, nil
+}
+
+// FacadeFeature — фасад как GeoJSON Point (координаты в порядке lon, lat)
+func FacadeFeature(f *models.Facade) models.GeoFeature {
+	return models.GeoFeature{
+		Type: "Feature",
+		ID:   f.ID,
+		Geometry: models.GeoGeometry{
+			Type:        "Point",
+			Coordinates: []float64{f.Longitude, f.Latitude},
+		},
+		Properties: map[string]any{
+			"code":       f.Code,
+			"name":       f.Name,
+			"address":    f.Address,
+			"status":     f.Status,
+			"online":     f.Status == "online",
+			"last_seen":  f.LastSeen,
+			"latency_ms": f.LatencyMS,
+			"width_px":   f.WidthPx,
+			"height_px":  f.HeightPx,
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package services_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"mediawork/internal/geo"
+	"mediawork/internal/services"
+)
+
+// TestGeoSearchInvalid — ошибки в запросе поиска — ValidationError по полю (422), а не 500
+func TestGeoSearchInvalid(t *testing.T) {
+	e := newEnv(t)
+	center := &geo.Point{Lat: 55.75, Lon: 37.62}
+
+	tests := []struct {
+		name  string
+		q     services.GeoQuery
+		field string
+	}{
+		{"bbox min above max", services.GeoQuery{BBox: &geo.BBox{MinLat: 56, MinLon: 37, MaxLat: 55, MaxLon: 38}}, "bbox"},
+		{"bbox nan", services.GeoQuery{BBox: &geo.BBox{MinLat: math.NaN(), MaxLat: 1, MaxLon: 1}}, "bbox"},
+		{"polygon of two points", services.GeoQuery{Polygon: geo.Polygon{{Lat: 55, Lon: 37}, {Lat: 56, Lon: 38}}}, "coordinates"},
+		{"center out of range", services.GeoQuery{Center: &geo.Point{Lat: 95}, RadiusM: 100}, "lat"},
+		{"center inf", services.GeoQuery{Center: &geo.Point{Lat: math.Inf(1)}, RadiusM: 100}, "lat"},
+		{"radius nan", services.GeoQuery{Center: center, RadiusM: math.NaN()}, "radius_m"},
+		{"radius inf", services.GeoQuery{Center: center, RadiusM: math.Inf(1)}, "radius_m"},
+		{"radius zero", services.GeoQuery{Center: center}, "radius_m"},
+		{"nothing", services.GeoQuery{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fc, err := e.facades.Search(context.Background(), tt.q); !isValidation(err, tt.field) {
+				t.Errorf("Search = %v, %v, want validation error on %q", fc, err, tt.field)
+			}
+		})
+	}
+}
+
+func TestGeoSearchRadius(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+
+	f, err := e.repos.Facades.GetByID(ctx, demoFacadeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc, err := e.facades.Search(ctx, services.GeoQuery{Center: &geo.Point{Lat: f.Latitude, Lon: f.Longitude}, RadiusM: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.Features) == 0 || fc.Features[0].ID != f.ID {
+		t.Errorf("1 m around facade %d = %+v, want that facade first", f.ID, fc.Features)
+	}
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"mediawork/internal/models"
+)
+
+// Рекомендуемые ключи тегов; произвольные ключи тоже разрешены
+var KnownFacadeTagKeys = []string{"district", "venue_type", "orientation", "indoor_outdoor"}
+
+type FacadeGroupService struct {
+	groups    FacadeGroupRepository
+	tags      FacadeTagRepository
+	facades   FacadeRepository
+	campaigns CampaignRepository
+	members   CompanyMembershipRepository
+	audit     *AuditService
+}
+
+func NewFacadeGroupService(
+	g FacadeGroupRepository,
+	t FacadeTagRepository,
+	f FacadeRepository,
+	c CampaignRepository,
+	m CompanyMembershipRepository,
+	a *AuditService,
+) *FacadeGroupService {
+	return &FacadeGroupService{groups: g, tags: t, facades: f, campaigns: c, members: m, audit: a}
+}
+
+func normalizeTagKey(k string) string {
+	return strings.ToLower(strings.TrimSpace(k))
+}
+
+// --------------- TAGS ---------------
+func (s *FacadeGroupService) GetTags(ctx context.Context, facadeID int64) (map[string]string, error) {
+	if _, err := s.facades.GetByID(ctx, facadeID); err != nil {
+		return nil, err
+	}
+	return s.tags.GetTags(ctx, facadeID)
+}
+
+// SetTags заменяет теги фасада и сразу пересчитывает участие в кампаниях,
+// нацеленных на динамические группы
+func (s *FacadeGroupService) SetTags(ctx context.Context, facadeID int64, tags map[string]string) (map[string]string, error) {
+	if _, err := s.facades.GetByID(ctx, facadeID); err != nil {
+		return nil, err
+	}
+	before, _ := s.tags.GetTags(ctx, facadeID)
+
+	clean := make(map[string]string, len(tags))
+	for k, v := range tags {
+		k = normalizeTagKey(k)
+		v = strings.TrimSpace(v)
+		if k == "" || v == "" {
+			return nil, invalid("tags", "tag keys and values must not be empty")
+		}
+		clean[k] = v
+	}
+
+	if err := s.tags.ReplaceTags(ctx, facadeID, clean); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, AuditEvent{
+		Action: "facade.tags_update", EntityType: "facade", EntityID: facadeID,
+		Before: before, After: clean,
+	})
+	if err := s.SyncTargets(ctx); err != nil {
+		return nil, err
+	}
+	return clean, nil
+}
+
+func (s *FacadeGroupService) TagValues(ctx context.Context) (map[string][]string, error) {
+	return s.tags.ListValues(ctx)
+}
+
+// --------------- GROUPS ---------------
+func validateGroup(g *models.DynamicFacadeGroup) error {
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		return invalid("name", "name is required")
+	}
+
+	tags := make(map[string][]string, len(g.Filter.Tags))
+	for k, values := range g.Filter.Tags {
+		k = normalizeTagKey(k)
+		if k == "" || len(values) == 0 {
+			return invalid("filter.tags", "filter.tags needs a key and at least one value")
+		}
+		tags[k] = values
+	}
+	g.Filter.Tags = tags
+	return nil
+}
+
+func (s *FacadeGroupService) List(ctx context.Context) ([]models.DynamicFacadeGroup, error) {
+	return s.groups.List(ctx)
+}
+
+func (s *FacadeGroupService) Create(ctx context.Context, g *models.DynamicFacadeGroup) error {
+	if err := validateGroup(g); err != nil {
+		return err
+	}
+	if err := s.groups.Create(ctx, g); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "facade_group.create", EntityType: "facade_group", EntityID: g.ID,
+		After: g,
+	})
+	return nil
+}
+
+func (s *FacadeGroupService) Update(ctx context.Context, g *models.DynamicFacadeGroup) error {
+	if err := validateGroup(g); err != nil {
+		return err
+	}
+	before, err := s.groups.GetByID(ctx, g.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.groups.Update(ctx, g); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "facade_group.update", EntityType: "facade_group", EntityID: g.ID,
+		Before: before, After: g,
+	})
+	return s.SyncTargets(ctx)
+}
+
+func (s *FacadeGroupService) Delete(ctx context.Context, id int64) error {
+	before, err := s.groups.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.groups.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "facade_group.delete", EntityType: "facade_group", EntityID: id,
+		Before: before,
+	})
+	return nil
+}
+
+// GetWithFacades — группа + фасады, которые подходят под фильтр прямо сейчас
+func (s *FacadeGroupService) GetWithFacades(ctx context.Context, id int64) (*models.DynamicFacadeGroup, []models.Facade, error) {
+	g, err := s.groups.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids, err := s.groups.MatchFacades(ctx, g.Filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	facades := make([]models.Facade, 0, len(ids))
+	for _, fid := range ids {
+		f, err := s.facades.GetByID(ctx, fid)
+		if err != nil {
+			return nil, nil, err
+		}
+		facades = append(facades, *f)
+	}
+	return g, facades, nil
+}
+
+// --------------- CAMPAIGN TARGETING ---------------
+func (s *FacadeGroupService) ListTargets(ctx context.Context, campaignID int64) ([]models.CampaignGroupTarget, error) {
+	return s.groups.ListTargets(ctx, campaignID)
+}
+
+// AddTarget / RemoveTarget — editor и выше в компании кампании
+func (s *FacadeGroupService) AddTarget(ctx context.Context, actor *models.UserClaims, campaignID, groupID int64) error {
+	if err := s.requireCampaignEditor(ctx, actor, campaignID); err != nil {
+		return err
+	}
+	g, err := s.groups.GetByID(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.groups.AddTarget(ctx, campaignID, groupID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "campaign.target_add", EntityType: "campaign", EntityID: campaignID,
+		After: map[string]int64{"group_id": groupID},
+	})
+	return s.syncTarget(ctx, campaignID, g)
+}
+
+func (s *FacadeGroupService) RemoveTarget(ctx context.Context, actor *models.UserClaims, campaignID, groupID int64) error {
+	if err := s.requireCampaignEditor(ctx, actor, campaignID); err != nil {
+		return err
+	}
+	if err := s.groups.RemoveTarget(ctx, campaignID, groupID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "campaign.target_remove", EntityType: "campaign", EntityID: campaignID,
+		Before: map[string]int64{"group_id": groupID},
+	})
+	return nil
+}
+
+func (s *FacadeGroupService) requireCampaignEditor(ctx context.Context, actor *models.UserClaims, campaignID int64) error {
+	c, err := s.campaigns.GetByID(ctx, campaignID)
+	if err != nil {
+		return err
+	}
+	return requireCompanyRole(ctx, s.members, c.CompanyID, actor, "editor")
+}
+
+// SyncTargets пересчитывает все таргетинги. Вызывается после изменения тегов / фильтров
+// и периодически из планировщика — чтобы подхватить новые фасады.
+func (s *FacadeGroupService) SyncTargets(ctx context.Context) error {
+	targets, err := s.groups.ListAllTargets(ctx)
+	if err != nil {
+		return err
+	}
+
+	cache := map[int64]*models.DynamicFacadeGroup{}
+	for _, t := range targets {
+		g, ok := cache[t.GroupID]
+		if !ok {
+			if g, err = s.groups.GetByID(ctx, t.GroupID); err != nil {
+				return err
+			}
+			cache[t.GroupID] = g
+		}
+		if err := s.syncTarget(ctx, t.CampaignID, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FacadeGroupService) syncTarget(ctx context.Context, campaignID int64, g *models.DynamicFacadeGroup) error {
+	ids, err := s.groups.MatchFacades(ctx, g.Filter)
+	if err != nil {
+		return err
+	}
+
+	added, removed, err := s.groups.SyncTarget(ctx, campaignID, g.ID, ids)
+	if err != nil {
+		return fmt.Errorf("sync campaign %d / group %d: %w", campaignID, g.ID, err)
+	}
+	if added > 0 || removed > 0 {
+		slog.InfoContext(ctx, "facade groups: targets synced", "campaign_id", campaignID,
+			"group", g.Name, "added", added, "removed", removed)
+	}
+	return nil
+}
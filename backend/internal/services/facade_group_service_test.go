@@ -0,0 +1,46 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mediawork/internal/models"
+	"mediawork/internal/services"
+)
+
+// TestCampaignTargetRoles — нацеливать демо-кампанию на группу может editor и выше
+// в её компании, админ платформы и API-ключ этой компании
+func TestCampaignTargetRoles(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+
+	g := &models.DynamicFacadeGroup{Name: "Центр", Filter: models.FacadeFilter{Tags: map[string][]string{"district": {"center"}}}}
+	if err := e.groups.Create(ctx, g); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		actor *models.UserClaims
+		want  error
+	}{
+		{"viewer", e.member(t, "viewer@example.com", "viewer"), services.ErrForbidden},
+		{"editor", e.member(t, "editor@example.com", "editor"), nil},
+		{"admin", e.member(t, "admin@example.com", "admin"), nil},
+		{"not a member", e.member(t, "outsider@example.com", ""), services.ErrForbidden},
+		{"platform admin", &models.UserClaims{UserID: 1, Role: "admin"}, nil},
+		{"api key of the company", &models.UserClaims{APIKeyID: 1, CompanyID: demoCompanyID}, nil},
+		{"api key of another company", &models.UserClaims{APIKeyID: 2, CompanyID: demoCompanyID + 1}, services.ErrForbidden},
+		{"anonymous", nil, services.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := e.groups.AddTarget(ctx, tt.actor, demoCampaignID, g.ID); !errors.Is(err, tt.want) {
+				t.Fatalf("AddTarget = %v, want %v", err, tt.want)
+			}
+			if err := e.groups.RemoveTarget(ctx, tt.actor, demoCampaignID, g.ID); !errors.Is(err, tt.want) {
+				t.Fatalf("RemoveTarget = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
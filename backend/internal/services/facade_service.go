@@ -2,15 +2,27 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
 	"time"
 
 	"mediawork/internal/models"
 	"mediawork/internal/repositories"
 )
 
+// DeviceTokenPrefix — токен плеера фасада (Authorization: Bearer mwd_... или X-Device-Token)
+const DeviceTokenPrefix = "mwd_"
+
+var ErrDeviceTokenInvalid = errors.New("invalid device token")
+
 type FacadeService struct {
-	facades  *repositories.FacadeRepository
-	liveRepo *repositories.LiveStreamRepository
+	facades  FacadeRepository
+	liveRepo LiveStreamRepository
+	audience AudienceRepository
+	audit    *AuditService
 }
 
 var sampleBase64PNG = "https://i0.wp.com/f.partnerkin.com/storage/files/file_1646847200_8.gif?ssl=1"
@@ -19,8 +31,13 @@ type LiveFrame struct {
 	Base64Frame string
 }
 
-func NewFacadeService(fr *repositories.FacadeRepository, lr *repositories.LiveStreamRepository) *FacadeService {
-	return &FacadeService{facades: fr, liveRepo: lr}
+func NewFacadeService(
+	fr FacadeRepository,
+	lr LiveStreamRepository,
+	ar AudienceRepository,
+	audit *AuditService,
+) *FacadeService {
+	return &FacadeService{facades: fr, liveRepo: lr, audience: ar, audit: audit}
 }
 
 // --------------- GET FACADE FULL STATUS ---------------
@@ -42,8 +59,93 @@ func (s *FacadeService) GetStatus(ctx context.Context, facadeID int64) (*models.
 	}, nil
 }
 
-func (s *FacadeService) List(ctx context.Context) ([]models.Facade, error) {
-	return s.facades.List(ctx)
+// List — страница фасадов (фильтры и сортировки — repositories.FacadeList)
+func (s *FacadeService) List(ctx context.Context, q models.ListQuery) (*models.Page[models.Facade], error) {
+	if err := prepareList(&q, repositories.FacadeList); err != nil {
+		return nil, err
+	}
+	rows, err := s.facades.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(rows, q, repositories.FacadeList), nil
+}
+
+// --------------------- AUDIENCE PROFILE ---------------------
+func (s *FacadeService) GetAudience(ctx context.Context, facadeID int64) (*models.AudienceProfile, error) {
+	return s.audience.GetProfile(ctx, facadeID)
+}
+
+func (s *FacadeService) SaveAudience(ctx context.Context, p *models.AudienceProfile) error {
+	if p.Timezone == "" {
+		p.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return invalidf("timezone", "unknown timezone %q", p.Timezone)
+	}
+	if p.VisibilityFactor < 0 || p.VisibilityFactor > 1 {
+		return invalid("visibility_factor", "visibility_factor must be between 0 and 1")
+	}
+	if p.DwellSec < 0 {
+		return invalid("dwell_sec", "dwell_sec must not be negative")
+	}
+
+	seen := map[[2]int]bool{}
+	for _, h := range p.Hours {
+		if h.Weekday < 0 || h.Weekday > 6 || h.Hour < 0 || h.Hour > 23 {
+			return invalidf("hourly", "invalid weekday/hour %d/%d", h.Weekday, h.Hour)
+		}
+		if h.Footfall < 0 {
+			return invalid("hourly.footfall", "footfall must not be negative")
+		}
+		key := [2]int{h.Weekday, h.Hour}
+		if seen[key] {
+			return invalidf("hourly", "duplicate weekday/hour %d/%d", h.Weekday, h.Hour)
+		}
+		seen[key] = true
+	}
+
+	if _, err := s.facades.GetByID(ctx, p.FacadeID); err != nil {
+		return err
+	}
+	before, _ := s.audience.GetProfile(ctx, p.FacadeID)
+	if err := s.audience.SaveProfile(ctx, p); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "facade.audience_update", EntityType: "facade", EntityID: p.FacadeID,
+		Before: before, After: p,
+	})
+	return nil
+}
+
+// --------------------- DEVICE TOKEN ---------------------
+// IssueDeviceToken выпускает токен плеера фасада и отзывает прежний
+func (s *FacadeService) IssueDeviceToken(ctx context.Context, facadeID int64) (*models.FacadeDeviceToken, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := DeviceTokenPrefix + hex.EncodeToString(b)
+	if err := s.facades.SetDeviceTokenHash(ctx, facadeID, hashAPIKey(token)); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEvent{Action: "facade.device_token_issue", EntityType: "facade", EntityID: facadeID})
+	return &models.FacadeDeviceToken{FacadeID: facadeID, Token: token, IssuedAt: time.Now()}, nil
+}
+
+// AuthenticateDevice — фасад, которому выпущен token
+func (s *FacadeService) AuthenticateDevice(ctx context.Context, token string) (int64, error) {
+	if !strings.HasPrefix(token, DeviceTokenPrefix) {
+		return 0, ErrDeviceTokenInvalid
+	}
+	id, err := s.facades.FacadeIDByDeviceTokenHash(ctx, hashAPIKey(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrDeviceTokenInvalid
+	}
+	return id, err
 }
 
 func (s *FacadeService) StreamLiveFrames(ctx context.Context, facadeID int64) <-chan LiveFrame {
@@ -63,8 +165,12 @@ func (s *FacadeService) StreamLiveFrames(ctx context.Context, facadeID int64) <-
 			case <-ticker.C:
 				// здесь должен быть реальный кадр
 				// пока — тестовый
-				out <- LiveFrame{
+				select {
+				case out <- LiveFrame{
 					Base64Frame: sampleBase64PNG, // тестовое изображение
+				}:
+				case <-ctx.Done(): // читатель ушёл — не висим на отправке
+					return
 				}
 			}
 		}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"mediawork/internal/models"
+	"mediawork/internal/repositories"
+)
+
+// Размер страницы списков по умолчанию и наибольший
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+//
+// ---------- CURSORS ----------
+//
+// Курсор для клиента непрозрачен: base64url от JSON с полем сортировки и границей страницы
+func EncodeCursor(c models.Cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*models.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid("cursor", "invalid cursor")
+	}
+	var c models.Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Sort == "" {
+		return nil, invalid("cursor", "invalid cursor")
+	}
+	return &c, nil
+}
+
+//
+// ---------- LIST QUERIES ----------
+//
+// prepareList проверяет ListQuery по спецификации списка: фильтры, которых у списка нет,
+// неизвестное поле сортировки и курсор от другой сортировки — ошибка проверки.
+// Сортировку курсор задаёт сам; лимит приводится к 1..MaxPageSize.
+func prepareList[T any](q *models.ListQuery, spec repositories.ListSpec[T]) error {
+	switch {
+	case q.Limit <= 0:
+		q.Limit = DefaultPageSize
+	case q.Limit > MaxPageSize:
+		q.Limit = MaxPageSize
+	}
+
+	if q.Status != "" && spec.Status == nil {
+		return invalid("status", "status filter is not supported for this list")
+	}
+	if q.CompanyID != nil && spec.Company == nil {
+		return invalid("company_id", "company_id filter is not supported for this list")
+	}
+	if q.Role != "" && spec.Role == nil {
+		return invalid("role", "role filter is not supported for this list")
+	}
+	if (q.From != nil || q.To != nil) && spec.Date == nil {
+		return invalid("from", "date range is not supported for this list")
+	}
+	if q.From != nil && q.To != nil && !q.To.After(*q.From) {
+		return invalid("to", "to must be after from")
+	}
+	if q.Search != "" && len(spec.Search) == 0 {
+		return invalid("q", "search is not supported for this list")
+	}
+
+	if c := q.Cursor; c != nil {
+		if q.Sort != "" && (q.Sort != c.Sort || q.Desc != c.Desc) {
+			return invalid("cursor", "cursor was issued for a different sort")
+		}
+		if err := spec.CheckCursor(c); err != nil {
+			return invalid("cursor", "invalid cursor")
+		}
+		q.Sort, q.Desc = c.Sort, c.Desc
+	}
+	if q.Sort == "" {
+		q.Sort, q.Desc = spec.DefaultSort, spec.DefaultDesc
+	}
+	if _, ok := spec.Sorts[q.Sort]; !ok {
+		return invalidf("sort", "unknown sort field %q, expected one of: %s", q.Sort, strings.Join(spec.SortNames(), ", "))
+	}
+	return nil
+}
+
+// newPage собирает страницу из строк хранилища (до q.Limit+1 в порядке обхода) и курсоры
+// соседних страниц. Назад от курсора страница есть всегда, если пришли по next_cursor, и наоборот.
+func newPage[T any](rows []T, q models.ListQuery, spec repositories.ListSpec[T]) *models.Page[T] {
+	backward := q.Cursor != nil && q.Cursor.Backward
+	more := len(rows) > q.Limit
+	if more {
+		rows = rows[:q.Limit]
+	}
+	if backward {
+		slices.Reverse(rows)
+	}
+
+	p := &models.Page[T]{Items: rows, Limit: q.Limit}
+	if len(rows) == 0 {
+		p.Items = []T{} // "items": [], а не null
+		return p
+	}
+
+	cursor := func(item T, backward bool) string {
+		value, id := spec.Key(item, q.Sort)
+		return EncodeCursor(models.Cursor{Sort: q.Sort, Desc: q.Desc, Value: value, ID: id, Backward: backward})
+	}
+	if more || backward {
+		p.NextCursor = cursor(rows[len(rows)-1], false)
+	}
+	if (more && backward) || (q.Cursor != nil && !backward) {
+		p.PrevCursor = cursor(rows[0], true)
+	}
+	return p
+}
@@ -0,0 +1,106 @@
+package services_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"mediawork/internal/models"
+	"mediawork/internal/services"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []models.Cursor{
+		{Sort: "created_at", Value: "2026-01-02T03:04:05Z", ID: 42},
+		{Sort: "name", Desc: true, Value: "Тверская, 7", ID: 1, Backward: true},
+		{Sort: "code", Value: "", ID: 0},
+	}
+	for _, want := range tests {
+		s := services.EncodeCursor(want)
+		got, err := services.DecodeCursor(s)
+		if err != nil {
+			t.Fatalf("DecodeCursor(EncodeCursor(%+v)): %v", want, err)
+		}
+		if *got != want {
+			t.Errorf("round trip = %+v, want %+v", *got, want)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	enc := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name, in string
+	}{
+		{"empty", ""},
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"name","id":1}`))},
+		{"not json", enc("name:1")},
+		{"no sort", enc(`{"v":"x","id":1}`)},
+		{"wrong types", enc(`{"s":"name","id":"1"}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := services.DecodeCursor(tt.in); !isValidation(err, "cursor") {
+				t.Errorf("DecodeCursor(%q) = %+v, %v, want cursor validation error", tt.in, c, err)
+			}
+		})
+	}
+}
+
+// TestCursorPaging — страницы по next_cursor / prev_cursor списка фасадов
+func TestCursorPaging(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+
+	list := func(q models.ListQuery) *models.Page[models.Facade] {
+		t.Helper()
+		p, err := e.facades.List(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	cursor := func(s string) *models.Cursor {
+		t.Helper()
+		c, err := services.DecodeCursor(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	codes := func(p *models.Page[models.Facade]) []string {
+		out := []string{}
+		for _, f := range p.Items {
+			out = append(out, f.Code)
+		}
+		return out
+	}
+
+	first := list(models.ListQuery{Sort: "code", Limit: 2})
+	if got := codes(first); len(got) != 2 || got[0] != "MSK-001" || got[1] != "MSK-002" || first.PrevCursor != "" || first.NextCursor == "" {
+		t.Fatalf("first page = %v next=%q prev=%q", got, first.NextCursor, first.PrevCursor)
+	}
+
+	second := list(models.ListQuery{Cursor: cursor(first.NextCursor), Limit: 2})
+	if got := codes(second); len(got) != 1 || got[0] != "SPB-001" || second.NextCursor != "" || second.PrevCursor == "" {
+		t.Fatalf("second page = %v next=%q prev=%q", got, second.NextCursor, second.PrevCursor)
+	}
+
+	back := list(models.ListQuery{Cursor: cursor(second.PrevCursor), Limit: 2})
+	if got := codes(back); len(got) != 2 || got[0] != "MSK-001" || got[1] != "MSK-002" || back.PrevCursor != "" {
+		t.Fatalf("page before second = %v prev=%q", got, back.PrevCursor)
+	}
+
+	// курсор другой сортировки и поле, которого нет в списке
+	tests := []models.ListQuery{
+		{Sort: "name", Cursor: cursor(first.NextCursor)},
+		{Cursor: &models.Cursor{Sort: "password_hash", Value: "x", ID: 1}},
+		{Cursor: &models.Cursor{Sort: "created_at", Value: "yesterday", ID: 1}},
+	}
+	for _, q := range tests {
+		if _, err := e.facades.List(ctx, q); !isValidation(err, "cursor") {
+			t.Errorf("List(sort=%q, cursor=%+v) error = %v, want cursor validation error", q.Sort, *q.Cursor, err)
+		}
+	}
+}
@@ -2,30 +2,94 @@ package services
 
 import (
     "context"
+    "database/sql"
+    "errors"
+    "fmt"
     "mediawork/internal/models"
-    "mediawork/internal/repositories"
 )
 
+// MaxPlayDurationSec — верхняя граница duration_sec одного показа
+const MaxPlayDurationSec = 300
+
+// ErrPlayNotScheduled — у кампании нет активного слота на этом фасаде в момент показа
+var ErrPlayNotScheduled = errors.New("play is not covered by an active slot of the campaign on this facade")
+
+// ErrFacadeMismatch — facade_id в теле не совпадает с фасадом токена устройства (403)
+var ErrFacadeMismatch = fmt.Errorf("%w: facade_id does not match the device token", ErrForbidden)
+
 type LiveStreamService struct {
-    repo *repositories.LiveStreamRepository
+    repo     LiveStreamRepository
+    budgets  *BudgetService
+    webhooks *WebhookService
 }
 
-func NewLiveStreamService(repo *repositories.LiveStreamRepository) *LiveStreamService {
-    return &LiveStreamService{repo: repo}
+func NewLiveStreamService(repo LiveStreamRepository, budgets *BudgetService, webhooks *WebhookService) *LiveStreamService {
+    return &LiveStreamService{repo: repo, budgets: budgets, webhooks: webhooks}
 }
 
 //
 // ---------- HANDLE HEARTBEAT ----------
 //
-func (s *LiveStreamService) Heartbeat(ctx context.Context, hb *models.Heartbeat) error {
-    return s.repo.RegisterHeartbeat(ctx, hb)
+// facadeID — фасад по токену устройства; facade_id в теле может его только повторять
+func (s *LiveStreamService) Heartbeat(ctx context.Context, facadeID int64, hb *models.Heartbeat) error {
+    if err := deviceFacade(facadeID, &hb.FacadeID); err != nil {
+        return err
+    }
+    if err := s.repo.RegisterHeartbeat(ctx, hb); err != nil {
+        return err
+    }
+    heartbeatsIngested.Inc()
+    return nil
 }
 
 //
 // ---------- REGISTER PLAY EVENT ----------
 //
-func (s *LiveStreamService) PlayEvent(ctx context.Context, ev *models.PlayEvent) error {
-    return s.repo.RegisterPlayEvent(ctx, ev)
+// Показ принимается, только если его покрывает активный слот кампании на этом фасаде;
+// стоимость считается до записи, и показ без курса валюты не принимается (см. BudgetService.Charge).
+// Записанный показ сразу списывается с бюджетов / кошелька компании и учитывается в счётчике
+// для play.milestone.
+func (s *LiveStreamService) PlayEvent(ctx context.Context, facadeID int64, ev *models.PlayEvent) error {
+    if err := deviceFacade(facadeID, &ev.FacadeID); err != nil {
+        return err
+    }
+    if ev.CampaignID <= 0 {
+        return invalid("campaign_id", "campaign_id is required")
+    }
+    if ev.DurationSec < 1 || ev.DurationSec > MaxPlayDurationSec {
+        return invalidf("duration_sec", "duration_sec must be between 1 and %d", MaxPlayDurationSec)
+    }
+
+    slotID, err := s.repo.CoveringSlot(ctx, ev.FacadeID, ev.CampaignID, ev.SlotID, ev.DurationSec)
+    if errors.Is(err, sql.ErrNoRows) {
+        return ErrPlayNotScheduled
+    }
+    if err != nil {
+        return err
+    }
+    ev.SlotID = slotID
+
+    charge, err := s.budgets.Charge(ctx, ev)
+    if err != nil {
+        return err
+    }
+    if err := s.repo.RegisterPlayEvent(ctx, ev); err != nil {
+        return err
+    }
+    playEventsIngested.Inc()
+
+    s.budgets.Consume(ctx, ev, charge)
+    s.webhooks.PlayRecorded(ctx, ev)
+    return nil
+}
+
+// deviceFacade подставляет фасад токена в тело; чужой facade_id — ErrFacadeMismatch
+func deviceFacade(facadeID int64, bodyID *int64) error {
+    if *bodyID != 0 && *bodyID != facadeID {
+        return ErrFacadeMismatch
+    }
+    *bodyID = facadeID
+    return nil
 }
 
 //
@@ -0,0 +1,20 @@
+package services
+
+import "mediawork/internal/metrics"
+
+// Метрики сервисов. Приём телеметрии считается после записи в хранилище:
+// отклонённые запросы видны в mediawork_http_request_duration_seconds по статусу.
+var (
+	heartbeatsIngested = metrics.NewCounter("mediawork_heartbeats_ingested_total",
+		"Facade heartbeats stored.")
+	playEventsIngested = metrics.NewCounter("mediawork_play_events_ingested_total",
+		"Play events stored.")
+	// playChargeFailures — сохранённые показы, которые не удалось списать (ошибка базы; без курса показ не принимается)
+	playChargeFailures = metrics.NewCounter("mediawork_play_charge_failures_total",
+		"Stored play events that could not be charged to budgets or wallet.")
+
+	// scheduleDecisions — кампании, снятые с расписания или возвращённые на него:
+	// budget_paused / budget_resumed (бюджет, кошелёк), dunning_paused / dunning_resumed (просрочка)
+	scheduleDecisions = metrics.NewCounterVec("mediawork_schedule_decisions_total",
+		"Campaigns taken off or returned to the schedule, by decision.", "decision")
+)
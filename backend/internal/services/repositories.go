@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/repositories"
+)
+
+// Интерфейсы хранилищ, с которыми работают сервисы. Реализации: repositories (PostgreSQL)
+// и repositories/memory (в памяти — для тестов и демо-режима без базы).
+//
+// Постраничные List(ctx, models.ListQuery) фильтруют и сортируют по ListSpec сущности
+// (repositories.CampaignList и т.п.) и возвращают до q.Limit+1 строк в порядке обхода.
+
+//
+// ---------- USERS / COMPANIES ----------
+//
+// UserRepository — пользователи и их глобальные роли
+type UserRepository interface {
+	Create(ctx context.Context, u *models.User) (int64, error)
+	UpdateRole(ctx context.Context, id int64, role string) error
+	SetActive(ctx context.Context, id int64, active bool) error
+	IsActive(ctx context.Context, id int64) (bool, error)
+	Update(ctx context.Context, u *models.User) error
+	GetByID(ctx context.Context, id int64) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	List(ctx context.Context, q models.ListQuery) ([]models.User, error)
+}
+
+// CompanyRepository — компании и их биллинговые настройки
+type CompanyRepository interface {
+	Create(ctx context.Context, c *models.Company) error
+	GetByID(ctx context.Context, id int64) (*models.Company, error)
+	List(ctx context.Context, q models.ListQuery) ([]models.Company, error)
+	Update(ctx context.Context, c *models.Company) error
+	GetBilling(ctx context.Context, id int64) (*models.CompanyBilling, error)
+	UpdateBilling(ctx context.Context, b *models.CompanyBilling) error
+}
+
+// CompanyMembershipRepository — участники компаний и их роли
+type CompanyMembershipRepository interface {
+	AddMember(ctx context.Context, companyID int64, userID int64, role string) error
+	RemoveMember(ctx context.Context, companyID int64, userID int64) error
+	GetUserRole(ctx context.Context, companyID int64, userID int64) (string, error)
+	ListMembers(ctx context.Context, companyID int64) ([]models.CompanyMember, error)
+	ListUserMemberships(ctx context.Context, userID int64) ([]models.UserCompany, error)
+	TransferOwnership(ctx context.Context, companyID int64, fromUserID int64, toUserID int64) error
+}
+
+// CompanyInvitationRepository — приглашения в компанию по email
+type CompanyInvitationRepository interface {
+	Create(ctx context.Context, inv *models.CompanyInvitation, tokenHash string) error
+	GetByID(ctx context.Context, id int64) (*models.CompanyInvitation, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.CompanyInvitation, error)
+	ListByCompany(ctx context.Context, companyID int64) ([]models.CompanyInvitation, error)
+	ListPendingByEmail(ctx context.Context, email string, now time.Time) ([]models.CompanyInvitation, error)
+	SetStatus(ctx context.Context, id int64, status string) (bool, error)
+	Accept(ctx context.Context, inv *models.CompanyInvitation, userID int64) (bool, error)
+}
+
+// CompanyLifecycleRepository — деактивация / приостановка компании и возврат её кампаний
+type CompanyLifecycleRepository interface {
+	Deactivate(ctx context.Context, companyID int64, reason string, actorID *int64) (*models.CompanySuspension, error)
+	Reactivate(ctx context.Context, companyID int64, actorID *int64) (*models.CompanySuspension, error)
+	PauseCampaigns(ctx context.Context, companyID int64, kind, reason string) (*models.CompanySuspension, error)
+	ResumeCampaigns(ctx context.Context, companyID int64, kind string) (*models.CompanySuspension, error)
+	OpenSuspensionCompanies(ctx context.Context, kind string) ([]int64, error)
+	SuspensionCampaigns(ctx context.Context, suspensionID int64) ([]models.SuspendedCampaign, error)
+	ListSuspensions(ctx context.Context, companyID int64) ([]models.CompanySuspension, error)
+}
+
+// APIKeyRepository — API-ключи компаний; хранится только sha256 ключа
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *models.APIKey, hash string) error
+	GetByID(ctx context.Context, id int64) (*models.APIKey, error)
+	GetActiveByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListByCompany(ctx context.Context, companyID int64) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id int64, revokedBy *int64) error
+	Touch(ctx context.Context, id int64, ip string, every time.Duration) error
+}
+
+// AuditRepository — журнал действий (только дописывается)
+type AuditRepository interface {
+	Insert(ctx context.Context, e *models.AuditEntry) error
+	List(ctx context.Context, f models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+//
+// ---------- FACADES ----------
+//
+// FacadeRepository — фасады и их online-статус
+type FacadeRepository interface {
+	GetByID(ctx context.Context, id int64) (*models.Facade, error)
+	List(ctx context.Context, q models.ListQuery) ([]models.Facade, error)
+	ListInBBox(ctx context.Context, minLat, minLon, maxLat, maxLon float64) ([]models.Facade, error)
+	CountBookedCampaigns(ctx context.Context, facadeIDs []int64, from, to time.Time) (map[int64]int, error)
+	SyncOnlineStatus(ctx context.Context, silence time.Duration) ([]models.FacadeTransition, error)
+	SetDeviceTokenHash(ctx context.Context, facadeID int64, hash string) error
+	FacadeIDByDeviceTokenHash(ctx context.Context, hash string) (int64, error)
+}
+
+// FacadeTagRepository — теги фасадов для динамических групп
+type FacadeTagRepository interface {
+	GetTags(ctx context.Context, facadeID int64) (map[string]string, error)
+	ReplaceTags(ctx context.Context, facadeID int64, tags map[string]string) error
+	ListValues(ctx context.Context) (map[string][]string, error)
+}
+
+// FacadeGroupRepository — динамические группы фасадов и таргетинг кампаний на них
+type FacadeGroupRepository interface {
+	Create(ctx context.Context, g *models.DynamicFacadeGroup) error
+	GetByID(ctx context.Context, id int64) (*models.DynamicFacadeGroup, error)
+	List(ctx context.Context) ([]models.DynamicFacadeGroup, error)
+	Update(ctx context.Context, g *models.DynamicFacadeGroup) error
+	Delete(ctx context.Context, id int64) error
+	MatchFacades(ctx context.Context, f models.FacadeFilter) ([]int64, error)
+	AddTarget(ctx context.Context, campaignID, groupID int64) error
+	RemoveTarget(ctx context.Context, campaignID, groupID int64) error
+	ListTargets(ctx context.Context, campaignID int64) ([]models.CampaignGroupTarget, error)
+	ListAllTargets(ctx context.Context) ([]models.CampaignGroupTarget, error)
+	SyncTarget(ctx context.Context, campaignID, groupID int64, facadeIDs []int64) (added, removed int64, err error)
+}
+
+// AudienceRepository — профили аудитории фасадов и оценка показов
+type AudienceRepository interface {
+	GetProfile(ctx context.Context, facadeID int64) (*models.AudienceProfile, error)
+	SaveProfile(ctx context.Context, p *models.AudienceProfile) error
+	CampaignImpressions(ctx context.Context, campaignID int64, from, to time.Time) ([]models.FacadeImpressions, error)
+}
+
+//
+// ---------- CAMPAIGNS ----------
+//
+// CampaignRepository — кампании
+type CampaignRepository interface {
+	Create(ctx context.Context, c *models.Campaign) (int64, error)
+	GetByID(ctx context.Context, id int64) (*models.Campaign, error)
+	List(ctx context.Context, q models.ListQuery) ([]models.Campaign, error)
+	Update(ctx context.Context, c *models.Campaign) error
+}
+
+// CreativeRepository — загруженные ролики компаний
+type CreativeRepository interface {
+	Create(ctx context.Context, c *models.Creative) error
+	GetByID(ctx context.Context, id int64) (*models.Creative, error)
+	List(ctx context.Context, q models.ListQuery) ([]models.Creative, error)
+}
+
+// CampaignSlotRepository — слоты, создаваемые вместе с кампанией
+type CampaignSlotRepository interface {
+	ListByCampaign(ctx context.Context, id int64) ([]models.CampaignSlot, error)
+	Create(ctx context.Context, slot *models.CampaignSlot) (int64, error)
+}
+
+// CampaignSlotsRepository — недельная сетка слотов (аналитика)
+type CampaignSlotsRepository interface {
+	GetSlotsByCampaign(ctx context.Context, campaignID int64) ([]models.CampaignSlot, error)
+}
+
+// AnalyticsRepository — агрегаты доставки кампании
+type AnalyticsRepository interface {
+	CampaignTotals(ctx context.Context, campaignID int64, from, to time.Time) (*models.CampaignDelivery, error)
+	CampaignBreakdown(ctx context.Context, campaignID int64, from, to time.Time, by string) ([]models.DeliveryBreakdownRow, error)
+}
+
+//
+// ---------- LIVE / RETENTION ----------
+//
+// LiveStreamRepository — телеметрия плееров: показы и heartbeat
+type LiveStreamRepository interface {
+	RegisterPlayEvent(ctx context.Context, event *models.PlayEvent) error
+	CoveringSlot(ctx context.Context, facadeID, campaignID, slotID int64, durationSec int) (int64, error)
+	GetLastPlayed(ctx context.Context, facadeID int64) (*models.PlayEvent, error)
+	RegisterHeartbeat(ctx context.Context, hb *models.Heartbeat) error
+	GetFacadeStatus(ctx context.Context, facadeID int64) (*models.FacadeStatus, error)
+	GetRecentEvents(ctx context.Context, facadeID int64, limit int) ([]models.PlayEvent, error)
+}
+
+// RollupRepository — свёртки и архивы сырой телеметрии
+type RollupRepository interface {
+	RollupPlayHistory(ctx context.Context, granularity string, from, to time.Time) (int64, error)
+	RollupHeartbeats(ctx context.Context, granularity string, from, to time.Time) (int64, error)
+	OldestPlayEvent(ctx context.Context) (*time.Time, error)
+	OldestHeartbeat(ctx context.Context) (*time.Time, error)
+	PlayHistoryWatermark(ctx context.Context, from, to time.Time) (int64, error)
+	HeartbeatWatermark(ctx context.Context, from, to time.Time) (int64, error)
+	ExportPlayHistory(ctx context.Context, from, to time.Time, maxID int64, fn func(ev *models.PlayEvent) error) (int64, error)
+	ExportHeartbeats(ctx context.Context, from, to time.Time, maxID int64, fn func(hb *models.Heartbeat, at time.Time) error) (int64, error)
+	LastArchivePart(ctx context.Context, source string, periodStart time.Time) (int, error)
+	CommitPlayHistoryArchive(ctx context.Context, a *models.PlayHistoryArchive, maxID int64) (int64, error)
+	CommitHeartbeatArchive(ctx context.Context, a *models.PlayHistoryArchive, maxID int64) (int64, error)
+	ListArchives(ctx context.Context, periodBefore *time.Time) ([]models.PlayHistoryArchive, error)
+	DeleteArchive(ctx context.Context, id int64) error
+}
+
+//
+// ---------- BILLING ----------
+//
+// InvoiceRepository — документы (счета, кредит-ноты, корректировки), их строки и выгрузки в бухгалтерию
+type InvoiceRepository interface {
+	Create(ctx context.Context, inv *models.Invoice) error
+	CreateAdjustment(ctx context.Context, inv *models.Invoice, credit money.Decimal) error
+	GetRefs(ctx context.Context, invoiceID int64) (*models.AdjustmentRefs, error)
+	ListAdjustments(ctx context.Context, originalID int64) ([]models.Invoice, error)
+	CountCompanyPlays(ctx context.Context, companyID int64, ids []int64) (int, error)
+	ListLines(ctx context.Context, invoiceID int64) ([]models.InvoiceLine, error)
+	GetByID(ctx context.Context, id int64) (*models.Invoice, error)
+	List(ctx context.Context, q models.ListQuery) ([]models.Invoice, error)
+	UpdateStatus(ctx context.Context, id int64, status string) error
+	PreparePDFData(ctx context.Context, invoiceID int64) (*models.InvoicePDF, error)
+	CalculateAmountForPeriod(ctx context.Context, companyID int64, start string, end string) ([]models.CurrencyAmount, error)
+	ExportBounds(ctx context.Context, settle time.Duration) (invoiceID, paymentID int64, err error)
+	LastIncrementalExport(ctx context.Context) (*models.AccountingExport, error)
+	CreateExport(ctx context.Context, e *models.AccountingExport) error
+	GetExport(ctx context.Context, id int64) (*models.AccountingExport, error)
+	ListExports(ctx context.Context, limit, offset int) ([]models.AccountingExport, error)
+	ExportDocuments(ctx context.Context, e *models.AccountingExport) ([]models.ExportDocument, error)
+	ExportPayments(ctx context.Context, e *models.AccountingExport) ([]models.ExportPayment, error)
+}
+
+// PaymentRepository — журнал оплат
+type PaymentRepository interface {
+	Record(ctx context.Context, p *models.Payment) error
+	ListByInvoice(ctx context.Context, invoiceID int64) ([]models.Payment, error)
+}
+
+// DunningRepository — просрочка и напоминания об оплате
+type DunningRepository interface {
+	MarkOverdue(ctx context.Context, today time.Time) ([]int64, error)
+	ListUnpaid(ctx context.Context, today time.Time) ([]models.OverdueInvoice, error)
+	ClaimReminder(ctx context.Context, invoiceID int64, offsetDays int) (bool, error)
+	ReleaseReminder(ctx context.Context, invoiceID int64, offsetDays int) error
+	CompaniesOverdue(ctx context.Context, today time.Time, minDays int) (map[int64]bool, error)
+}
+
+// TaxRateRepository — ставки налога по юрисдикциям
+type TaxRateRepository interface {
+	Create(ctx context.Context, t *models.TaxRate) error
+	Find(ctx context.Context, jurisdiction string, on time.Time) (*models.TaxRate, error)
+	GetByID(ctx context.Context, id int64) (*models.TaxRate, error)
+	List(ctx context.Context, jurisdiction string) ([]models.TaxRate, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// ExchangeRateRepository — курсы валют
+type ExchangeRateRepository interface {
+	Upsert(ctx context.Context, e *models.ExchangeRate) error
+	Find(ctx context.Context, base, quote string, on time.Time) (*models.ExchangeRate, error)
+	List(ctx context.Context, base, quote string, limit, offset int) ([]models.ExchangeRate, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// BudgetRepository — бюджеты кампаний / компаний и предоплаченные кошельки
+type BudgetRepository interface {
+	GetCampaignBudget(ctx context.Context, campaignID int64) (*models.Budget, error)
+	GetCompanyBudget(ctx context.Context, companyID int64) (*models.Budget, error)
+	SetBudget(ctx context.Context, b *models.Budget) error
+	DeleteCampaignBudget(ctx context.Context, campaignID int64) error
+	DeleteCompanyBudget(ctx context.Context, companyID int64) error
+	ExhaustedAt(ctx context.Context, campaignID int64) (*time.Time, error)
+	PlayPrice(ctx context.Context, facadeID int64, seconds int64) (money.Decimal, string, bool, error)
+	Consume(ctx context.Context, c *models.PlayCharge) (crossed bool, err error)
+	Reevaluate(ctx context.Context, companyID int64) (exhausted, resumed []int64, err error)
+	GetWallet(ctx context.Context, companyID int64) (*models.Wallet, error)
+	TopUp(ctx context.Context, t *models.WalletTransaction, currency string) error
+	ListTransactions(ctx context.Context, companyID int64, limit, offset int) ([]models.WalletTransaction, error)
+}
+
+//
+// ---------- WEBHOOKS ----------
+//
+// WebhookRepository — эндпоинты, события и журнал доставок вебхуков
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, e *models.WebhookEndpoint) error
+	GetEndpoint(ctx context.Context, id int64) (*models.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, companyID int64) ([]models.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, e *models.WebhookEndpoint) error
+	SetSecret(ctx context.Context, id int64, secret string) error
+	DeleteEndpoint(ctx context.Context, id int64) error
+	Publish(ctx context.Context, ev *models.WebhookEvent, endpointID *int64) (int, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, endpointID int64, status string, limit, offset int) ([]models.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, a *models.WebhookAttempt, status string, next *time.Time) error
+	ListAttempts(ctx context.Context, deliveryID int64) ([]models.WebhookAttempt, error)
+	CountPlay(ctx context.Context, campaignID int64) (plays, companyID int64, err error)
+	FacadeCompanies(ctx context.Context, facadeID int64) ([]int64, error)
+}
+
+// реализации на PostgreSQL
+var (
+	_ UserRepository              = (*repositories.UserRepository)(nil)
+	_ CompanyRepository           = (*repositories.CompanyRepository)(nil)
+	_ CompanyMembershipRepository = (*repositories.CompanyMembershipRepository)(nil)
+	_ CompanyInvitationRepository = (*repositories.CompanyInvitationRepository)(nil)
+	_ CompanyLifecycleRepository  = (*repositories.CompanyLifecycleRepository)(nil)
+	_ APIKeyRepository            = (*repositories.APIKeyRepository)(nil)
+	_ AuditRepository             = (*repositories.AuditRepository)(nil)
+	_ FacadeRepository            = (*repositories.FacadeRepository)(nil)
+	_ FacadeTagRepository         = (*repositories.FacadeTagRepository)(nil)
+	_ FacadeGroupRepository       = (*repositories.FacadeGroupRepository)(nil)
+	_ AudienceRepository          = (*repositories.AudienceRepository)(nil)
+	_ CampaignRepository          = (*repositories.CampaignRepository)(nil)
+	_ CampaignSlotRepository      = (*repositories.CampaignSlotRepository)(nil)
+	_ CampaignSlotsRepository     = (*repositories.CampaignSlotsRepository)(nil)
+	_ AnalyticsRepository         = (*repositories.AnalyticsRepository)(nil)
+	_ LiveStreamRepository        = (*repositories.LiveStreamRepository)(nil)
+	_ RollupRepository            = (*repositories.RollupRepository)(nil)
+	_ InvoiceRepository           = (*repositories.InvoiceRepository)(nil)
+	_ PaymentRepository           = (*repositories.PaymentRepository)(nil)
+	_ DunningRepository           = (*repositories.DunningRepository)(nil)
+	_ TaxRateRepository           = (*repositories.TaxRateRepository)(nil)
+	_ ExchangeRateRepository      = (*repositories.ExchangeRateRepository)(nil)
+	_ BudgetRepository            = (*repositories.BudgetRepository)(nil)
+	_ WebhookRepository           = (*repositories.WebhookRepository)(nil)
+)
+
+// Repositories — все хранилища, из которых собираются сервисы: PostgreSQL
+// или реализация в памяти (тесты, демо-режим)
+type Repositories struct {
+	Users            UserRepository
+	Companies        CompanyRepository
+	Memberships      CompanyMembershipRepository
+	Invitations      CompanyInvitationRepository
+	CompanyLifecycle CompanyLifecycleRepository
+	APIKeys          APIKeyRepository
+	Audit            AuditRepository
+	Facades          FacadeRepository
+	FacadeTags       FacadeTagRepository
+	FacadeGroups     FacadeGroupRepository
+	Audience         AudienceRepository
+	Campaigns        CampaignRepository
+	Creatives        CreativeRepository
+	CampaignSlot     CampaignSlotRepository
+	CampaignSlots    CampaignSlotsRepository
+	Analytics        AnalyticsRepository
+	LiveStream       LiveStreamRepository
+	Rollups          RollupRepository
+	Invoices         InvoiceRepository
+	Payments         PaymentRepository
+	Dunning          DunningRepository
+	TaxRates         TaxRateRepository
+	ExchangeRates    ExchangeRateRepository
+	Budgets          BudgetRepository
+	Webhooks         WebhookRepository
+}
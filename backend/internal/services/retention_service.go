@@ -0,0 +1,324 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"mediawork/internal/models"
+)
+
+// RetentionConfig — сколько хранить сырые данные и куда складывать архивы
+type RetentionConfig struct {
+	PlayHistoryRetention time.Duration // сырые play_history старше этого уходят в архив
+	HeartbeatRetention   time.Duration // то же для facade_heartbeat
+	ArchiveDir           string        // локальная папка для .csv.gz
+	ArchiveRetention     time.Duration // 0 — архивы не удаляются никогда
+	RollupLookback       time.Duration // насколько назад пересчитываем агрегаты на каждом запуске
+}
+
+type RetentionService struct {
+	rollups RollupRepository
+	cfg     RetentionConfig
+}
+
+func NewRetentionService(
+	rollups RollupRepository,
+	cfg RetentionConfig,
+) *RetentionService {
+	if cfg.RollupLookback <= 0 {
+		cfg.RollupLookback = 48 * time.Hour
+	}
+	return &RetentionService{rollups: rollups, cfg: cfg}
+}
+
+const oneDay = 24 * time.Hour
+
+func truncDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+//
+// ---------- ROLLUP RECENT WINDOW ----------
+//
+// Пересчитывает часовые и дневные агрегаты за последние RollupLookback,
+// включая текущий (неполный) час — чтобы аналитика не отставала.
+// Пересчёт заменяет бакеты целиком, поэтому окно не заходит в сутки, которые
+// уже могли уйти в архив: их сырые строки удалены, и бакет обнулился бы.
+func (s *RetentionService) Rollup(ctx context.Context, now time.Time) error {
+	hourTo := now.UTC().Truncate(time.Hour).Add(time.Hour)
+	dayTo := truncDay(now).Add(oneDay)
+
+	from := s.windowStart(now, s.cfg.PlayHistoryRetention)
+	if _, err := s.rollups.RollupPlayHistory(ctx, "hour", from, hourTo); err != nil {
+		return fmt.Errorf("rollup play_history hourly: %w", err)
+	}
+	if _, err := s.rollups.RollupPlayHistory(ctx, "day", truncDay(from), dayTo); err != nil {
+		return fmt.Errorf("rollup play_history daily: %w", err)
+	}
+
+	from = s.windowStart(now, s.cfg.HeartbeatRetention)
+	if _, err := s.rollups.RollupHeartbeats(ctx, "hour", from, hourTo); err != nil {
+		return fmt.Errorf("rollup heartbeats hourly: %w", err)
+	}
+	if _, err := s.rollups.RollupHeartbeats(ctx, "day", truncDay(from), dayTo); err != nil {
+		return fmt.Errorf("rollup heartbeats daily: %w", err)
+	}
+	return nil
+}
+
+// windowStart — начало окна пересчёта, но не раньше первых суток, которые ещё не архивируются
+func (s *RetentionService) windowStart(now time.Time, retention time.Duration) time.Time {
+	from := now.UTC().Add(-s.cfg.RollupLookback).Truncate(time.Hour)
+	if retention > 0 {
+		if cutoff := truncDay(now.Add(-retention)); from.Before(cutoff) {
+			from = cutoff
+		}
+	}
+	return from
+}
+
+//
+// ---------- ARCHIVE + PRUNE RAW ROWS ----------
+//
+// Идём по полным суткам старше срока хранения: запоминаем наибольший id строк суток,
+// выгружаем строки до него в архив, а потом одной транзакцией сворачиваем в агрегаты,
+// удаляем ровно эти строки и записываем архив в каталог. Если любой шаг упал — сутки
+// остаются в БД и будут обработаны на следующем запуске.
+//
+// Строки, пришедшие во время выгрузки или за уже выгруженные сутки, уходят в следующую
+// часть архива (<source>_YYYY-MM-DD.pN.csv.gz) и прибавляются к агрегатам, а не заменяют
+// их: сырых строк первой выгрузки в БД уже нет.
+func (s *RetentionService) ArchiveAndPrune(ctx context.Context, now time.Time) error {
+	if s.cfg.PlayHistoryRetention > 0 {
+		if err := s.archivePlayHistory(ctx, truncDay(now.Add(-s.cfg.PlayHistoryRetention))); err != nil {
+			return err
+		}
+	}
+	if s.cfg.HeartbeatRetention > 0 {
+		if err := s.archiveHeartbeats(ctx, truncDay(now.Add(-s.cfg.HeartbeatRetention))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *RetentionService) archivePlayHistory(ctx context.Context, cutoff time.Time) error {
+	oldest, err := s.rollups.OldestPlayEvent(ctx)
+	if err != nil || oldest == nil {
+		return err
+	}
+
+	header := []string{
+		"id", "facade_id", "campaign_id", "slot_id", "media_url", "played_at",
+		"duration_sec", "resolution_w", "resolution_h", "bitrate_kbps", "sync_latency_ms",
+	}
+	for d := truncDay(*oldest); d.Before(cutoff); d = d.Add(oneDay) {
+		end := d.Add(oneDay)
+
+		maxID, err := s.rollups.PlayHistoryWatermark(ctx, d, end)
+		if err != nil {
+			return err
+		}
+		if maxID == 0 {
+			continue
+		}
+		part, err := s.rollups.LastArchivePart(ctx, "play_history", d)
+		if err != nil {
+			return err
+		}
+
+		archive, pruned, err := s.writeArchive("play_history", d, part+1, header,
+			func(w *csv.Writer) (int64, error) {
+				return s.rollups.ExportPlayHistory(ctx, d, end, maxID, func(ev *models.PlayEvent) error {
+					return w.Write([]string{
+						strconv.FormatInt(ev.ID, 10),
+						strconv.FormatInt(ev.FacadeID, 10),
+						strconv.FormatInt(ev.CampaignID, 10),
+						strconv.FormatInt(ev.SlotID, 10),
+						ev.MediaURL,
+						ev.PlayedAt.UTC().Format(time.RFC3339Nano),
+						strconv.Itoa(ev.DurationSec),
+						strconv.Itoa(ev.ResolutionW),
+						strconv.Itoa(ev.ResolutionH),
+						strconv.Itoa(ev.BitrateKbps),
+						strconv.Itoa(ev.SyncLatencyMS),
+					})
+				})
+			},
+			func(a *models.PlayHistoryArchive) (int64, error) {
+				return s.rollups.CommitPlayHistoryArchive(ctx, a, maxID)
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("archive play_history %s: %w", d.Format("2006-01-02"), err)
+		}
+		slog.InfoContext(ctx, "retention: play_history archived", "day", d.Format("2006-01-02"),
+			"part", archive.Part, "rows", archive.RowCount, "file", archive.FilePath, "pruned", pruned)
+	}
+	return nil
+}
+
+func (s *RetentionService) archiveHeartbeats(ctx context.Context, cutoff time.Time) error {
+	oldest, err := s.rollups.OldestHeartbeat(ctx)
+	if err != nil || oldest == nil {
+		return err
+	}
+
+	header := []string{"facade_id", "timestamp", "latency_ms", "source_ip"}
+	for d := truncDay(*oldest); d.Before(cutoff); d = d.Add(oneDay) {
+		end := d.Add(oneDay)
+
+		maxID, err := s.rollups.HeartbeatWatermark(ctx, d, end)
+		if err != nil {
+			return err
+		}
+		if maxID == 0 {
+			continue
+		}
+		part, err := s.rollups.LastArchivePart(ctx, "facade_heartbeat", d)
+		if err != nil {
+			return err
+		}
+
+		archive, pruned, err := s.writeArchive("facade_heartbeat", d, part+1, header,
+			func(w *csv.Writer) (int64, error) {
+				return s.rollups.ExportHeartbeats(ctx, d, end, maxID, func(hb *models.Heartbeat, at time.Time) error {
+					return w.Write([]string{
+						strconv.FormatInt(hb.FacadeID, 10),
+						at.UTC().Format(time.RFC3339Nano),
+						strconv.Itoa(hb.LatencyMS),
+						hb.SourceIP,
+					})
+				})
+			},
+			func(a *models.PlayHistoryArchive) (int64, error) {
+				return s.rollups.CommitHeartbeatArchive(ctx, a, maxID)
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("archive facade_heartbeat %s: %w", d.Format("2006-01-02"), err)
+		}
+		slog.InfoContext(ctx, "retention: facade_heartbeat archived", "day", d.Format("2006-01-02"),
+			"part", archive.Part, "rows", archive.RowCount, "file", archive.FilePath, "pruned", pruned)
+	}
+	return nil
+}
+
+// writeArchive пишет сутки в <dir>/<source>/<source>_YYYY-MM-DD[.pN].csv.gz через временный файл,
+// так что на диске никогда не остаётся обрезанного архива, и отдаёт его commit — свёртке,
+// удалению строк и записи в каталог. Существующий файл не перезаписывается.
+func (s *RetentionService) writeArchive(
+	source string,
+	d time.Time,
+	part int,
+	header []string,
+	fill func(w *csv.Writer) (int64, error),
+	commit func(a *models.PlayHistoryArchive) (int64, error),
+) (*models.PlayHistoryArchive, int64, error) {
+
+	dir := filepath.Join(s.cfg.ArchiveDir, source)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, 0, err
+	}
+
+	name := fmt.Sprintf("%s_%s", source, d.Format("2006-01-02"))
+	if part > 1 {
+		name += fmt.Sprintf(".p%d", part)
+	}
+	path := filepath.Join(dir, name+".csv.gz")
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	w := csv.NewWriter(gz)
+
+	rows, err := func() (int64, error) {
+		if err := w.Write(header); err != nil {
+			return 0, err
+		}
+		n, err := fill(w)
+		if err != nil {
+			return n, err
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return n, err
+		}
+		if err := gz.Close(); err != nil {
+			return n, err
+		}
+		return n, tmp.Sync()
+	}()
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	// Link, а не Rename: если файл с таким именем уже есть, получим ошибку вместо подмены
+	if err := os.Link(tmp.Name(), path); err != nil {
+		return nil, 0, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		os.Remove(path)
+		return nil, 0, err
+	}
+
+	archive := &models.PlayHistoryArchive{
+		Source:      source,
+		PeriodStart: d,
+		PeriodEnd:   d.Add(oneDay),
+		Part:        part,
+		FilePath:    path,
+		RowCount:    rows,
+		SizeBytes:   info.Size(),
+	}
+	pruned, err := commit(archive)
+	if err != nil {
+		os.Remove(path) // без записи в каталоге файл никто не найдёт и не удалит
+		return nil, 0, err
+	}
+	return archive, pruned, nil
+}
+
+//
+// ---------- PRUNE OLD ARCHIVE FILES ----------
+//
+func (s *RetentionService) PruneArchives(ctx context.Context, now time.Time) error {
+	if s.cfg.ArchiveRetention <= 0 {
+		return nil
+	}
+
+	before := now.Add(-s.cfg.ArchiveRetention)
+	list, err := s.rollups.ListArchives(ctx, &before)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range list {
+		if err := os.Remove(a.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := s.rollups.DeleteArchive(ctx, a.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *RetentionService) ListArchives(ctx context.Context) ([]models.PlayHistoryArchive, error) {
+	return s.rollups.ListArchives(ctx, nil)
+}
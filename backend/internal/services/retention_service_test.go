@@ -0,0 +1,214 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/services"
+)
+
+// lateRollups — хранилище, в которое между выгрузкой суток и их фиксацией приходят
+// новые строки, а фиксация может упасть
+type lateRollups struct {
+	services.RollupRepository
+	afterExport func()
+	failCommit  bool
+}
+
+func (r *lateRollups) ExportPlayHistory(ctx context.Context, from, to time.Time, maxID int64, fn func(ev *models.PlayEvent) error) (int64, error) {
+	n, err := r.RollupRepository.ExportPlayHistory(ctx, from, to, maxID, fn)
+	if r.afterExport != nil {
+		r.afterExport()
+		r.afterExport = nil
+	}
+	return n, err
+}
+
+func (r *lateRollups) CommitPlayHistoryArchive(ctx context.Context, a *models.PlayHistoryArchive, maxID int64) (int64, error) {
+	if r.failCommit {
+		r.failCommit = false
+		return 0, errors.New("connection reset")
+	}
+	return r.RollupRepository.CommitPlayHistoryArchive(ctx, a, maxID)
+}
+
+type retentionEnv struct {
+	*env
+	rollups *lateRollups
+	svc     *services.RetentionService
+	dir     string
+	day     time.Time // сутки старше срока хранения
+}
+
+func newRetentionEnv(t *testing.T) *retentionEnv {
+	t.Helper()
+	e := &retentionEnv{env: newEnv(t), dir: t.TempDir()}
+	e.day = e.now.Truncate(24*time.Hour).AddDate(0, 0, -10)
+	e.rollups = &lateRollups{RollupRepository: e.repos.Rollups}
+	e.svc = services.NewRetentionService(e.rollups, services.RetentionConfig{
+		PlayHistoryRetention: 7 * 24 * time.Hour,
+		HeartbeatRetention:   7 * 24 * time.Hour,
+		ArchiveDir:           e.dir,
+	})
+	return e
+}
+
+// playAt — показ демо-кампании в момент at
+func (e *retentionEnv) playAt(t *testing.T, at time.Time) {
+	t.Helper()
+	e.store.Now = func() time.Time { return at }
+	defer func() { e.store.Now = func() time.Time { return e.now } }()
+	if err := e.repos.LiveStream.RegisterPlayEvent(context.Background(), &models.PlayEvent{
+		FacadeID: demoFacadeID, CampaignID: demoCampaignID, DurationSec: 10,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// check — части архива суток, показы в агрегатах и оставшиеся в БД сырые строки
+func (e *retentionEnv) check(t *testing.T, parts []int64, plays int64, raw bool) {
+	t.Helper()
+	ctx := context.Background()
+
+	list, err := e.svc.ListArchives(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[int]int64{}
+	for _, a := range list {
+		if a.Source == "play_history" && a.PeriodStart.Equal(e.day) {
+			got[a.Part] = a.RowCount
+			if _, err := os.Stat(a.FilePath); err != nil {
+				t.Errorf("part %d: %v", a.Part, err)
+			}
+		}
+	}
+	if len(got) != len(parts) {
+		t.Errorf("archive parts = %v, want rows %v", got, parts)
+	}
+	for i, rows := range parts {
+		if got[i+1] != rows {
+			t.Errorf("part %d has %d rows, want %d", i+1, got[i+1], rows)
+		}
+	}
+
+	totals, err := e.repos.Analytics.CampaignTotals(ctx, demoCampaignID, e.day, e.day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if totals.Plays != plays {
+		t.Errorf("rolled up plays = %d, want %d", totals.Plays, plays)
+	}
+
+	n, err := e.rollups.PlayHistoryWatermark(ctx, e.day, e.day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (n > 0) != raw {
+		t.Errorf("raw rows left = %v, want %v", n > 0, raw)
+	}
+}
+
+// TestArchiveLateRows — строки, пришедшие во время выгрузки, не удаляются вместе
+// с выгруженными, а уходят во вторую часть и прибавляются к агрегатам ровно один раз
+func TestArchiveLateRows(t *testing.T) {
+	e := newRetentionEnv(t)
+	ctx := context.Background()
+	for h := 9; h < 12; h++ {
+		e.playAt(t, e.day.Add(time.Duration(h)*time.Hour))
+	}
+
+	e.rollups.afterExport = func() { e.playAt(t, e.day.Add(13*time.Hour)) }
+	if err := e.svc.ArchiveAndPrune(ctx, e.now); err != nil {
+		t.Fatal(err)
+	}
+	e.check(t, []int64{3}, 3, true)
+
+	if err := e.svc.ArchiveAndPrune(ctx, e.now); err != nil {
+		t.Fatal(err)
+	}
+	e.check(t, []int64{3, 1}, 4, false)
+
+	// повторный запуск без новых строк ничего не меняет
+	if err := e.svc.ArchiveAndPrune(ctx, e.now); err != nil {
+		t.Fatal(err)
+	}
+	e.check(t, []int64{3, 1}, 4, false)
+}
+
+// TestArchiveCommitFailure — упавшая фиксация не удаляет строки, не оставляет файл
+// и не прибавляет строки к агрегатам; следующий запуск выгружает их заново
+func TestArchiveCommitFailure(t *testing.T) {
+	e := newRetentionEnv(t)
+	ctx := context.Background()
+	e.playAt(t, e.day.Add(9*time.Hour))
+	e.playAt(t, e.day.Add(10*time.Hour))
+
+	for _, part := range []int{1, 2} {
+		e.rollups.failCommit = true
+		if err := e.svc.ArchiveAndPrune(ctx, e.now); err == nil {
+			t.Fatalf("part %d: ArchiveAndPrune succeeded despite failed commit", part)
+		}
+		files, err := filepath.Glob(filepath.Join(e.dir, "play_history", "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != part-1 {
+			t.Errorf("part %d: files after failed commit = %v", part, files)
+		}
+
+		if err := e.svc.ArchiveAndPrune(ctx, e.now); err != nil {
+			t.Fatal(err)
+		}
+		if part == 1 {
+			e.check(t, []int64{2}, 2, false)
+			e.playAt(t, e.day.Add(11*time.Hour))
+		}
+	}
+	e.check(t, []int64{2, 1}, 3, false)
+}
+
+func TestArchiveHeartbeats(t *testing.T) {
+	e := newRetentionEnv(t)
+	ctx := context.Background()
+
+	e.store.Now = func() time.Time { return e.day.Add(8 * time.Hour) }
+	for range 2 {
+		if err := e.repos.LiveStream.RegisterHeartbeat(ctx, &models.Heartbeat{FacadeID: demoFacadeID, LatencyMS: 40}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.store.Now = func() time.Time { return e.now }
+	if err := e.repos.LiveStream.RegisterHeartbeat(ctx, &models.Heartbeat{FacadeID: demoFacadeID, LatencyMS: 40}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.svc.ArchiveAndPrune(ctx, e.now); err != nil {
+		t.Fatal(err)
+	}
+	list, err := e.svc.ListArchives(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows int64
+	for _, a := range list {
+		if a.Source == "facade_heartbeat" {
+			rows += a.RowCount
+		}
+	}
+	if rows != 2 {
+		t.Errorf("archived heartbeats = %d, want 2", rows)
+	}
+	oldest, err := e.repos.Rollups.OldestHeartbeat(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oldest == nil || !oldest.Equal(e.now) {
+		t.Errorf("oldest heartbeat left = %v, want %v (only the fresh one)", oldest, e.now)
+	}
+}
@@ -0,0 +1,147 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+	"mediawork/internal/services"
+)
+
+// TestScheduleExclusion — когда кампания снимается с расписания и показы перестают
+// приниматься: вне слота, исчерпанный бюджет / кошелёк, просрочка по счетам, деактивация
+// компании. Показ демо-кампании стоит 2.5 RUB за секунду.
+func TestScheduleExclusion(t *testing.T) {
+	ctx := context.Background()
+	must := func(t *testing.T, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	topUp := func(t *testing.T, e *env, amount string) {
+		t.Helper()
+		_, err := e.budgets.TopUp(ctx, &models.WalletTransaction{CompanyID: demoCompanyID, Amount: money.MustParse(amount)}, false)
+		must(t, err)
+	}
+	amount := func(s string) services.BudgetLimits {
+		d := money.MustParse(s)
+		return services.BudgetLimits{AmountLimit: &d}
+	}
+	airtime := func(sec int64) services.BudgetLimits {
+		return services.BudgetLimits{AirtimeLimitSec: &sec}
+	}
+	// счёт со сроком оплаты days дней назад
+	overdueInvoice := func(t *testing.T, e *env, days int) *models.Invoice {
+		t.Helper()
+		due := e.now.AddDate(0, 0, -days)
+		inv := &models.Invoice{CompanyID: demoCompanyID, IssuedAt: due.AddDate(0, 0, -14), DueDate: &due, AmountTotal: money.NewFromInt(1200)}
+		must(t, e.billing.Create(ctx, inv))
+		return inv
+	}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, e *env)
+		want  error
+	}{
+		{name: "in slot"},
+		{name: "outside slot",
+			setup: func(t *testing.T, e *env) {
+				e.store.Now = func() time.Time { return e.now.Add(-15 * time.Hour) } // 03:00
+			},
+			want: services.ErrPlayNotScheduled},
+		{name: "wallet has balance",
+			setup: func(t *testing.T, e *env) {
+				topUp(t, e, "1000")
+				must(t, e.play(60))
+			}},
+		{name: "wallet exhausted",
+			setup: func(t *testing.T, e *env) {
+				topUp(t, e, "100")
+				must(t, e.play(60))
+			},
+			want: services.ErrPlayNotScheduled},
+		{name: "wallet topped up again",
+			setup: func(t *testing.T, e *env) {
+				topUp(t, e, "100")
+				must(t, e.play(60))
+				topUp(t, e, "1000")
+			}},
+		{name: "campaign airtime exhausted",
+			setup: func(t *testing.T, e *env) {
+				_, err := e.budgets.SetCampaignBudget(ctx, platformAdmin, demoCampaignID, airtime(60))
+				must(t, err)
+				must(t, e.play(30))
+				must(t, e.play(30))
+			},
+			want: services.ErrPlayNotScheduled},
+		{name: "company budget exhausted",
+			setup: func(t *testing.T, e *env) {
+				_, err := e.budgets.SetCompanyBudget(ctx, demoCompanyID, amount("100"))
+				must(t, err)
+				must(t, e.play(40))
+			},
+			want: services.ErrPlayNotScheduled},
+		{name: "company budget raised",
+			setup: func(t *testing.T, e *env) {
+				_, err := e.budgets.SetCompanyBudget(ctx, demoCompanyID, amount("100"))
+				must(t, err)
+				must(t, e.play(40))
+				_, err = e.budgets.SetCompanyBudget(ctx, demoCompanyID, amount("500"))
+				must(t, err)
+			}},
+		{name: "wallet in other currency without rate",
+			// без курса показ не принимается, а не записывается бесплатно
+			setup: func(t *testing.T, e *env) {
+				e.setBilling(t, &models.CompanyBilling{CompanyID: demoCompanyID, Currency: "USD", TaxJurisdiction: "RU"})
+				topUp(t, e, "1")
+			},
+			want: services.ErrNoExchangeRate},
+		{name: "overdue within grace",
+			setup: func(t *testing.T, e *env) {
+				overdueInvoice(t, e, 3)
+				must(t, e.dunning.Run(ctx, e.now))
+			}},
+		{name: "overdue past grace",
+			setup: func(t *testing.T, e *env) {
+				overdueInvoice(t, e, 10)
+				must(t, e.dunning.Run(ctx, e.now))
+			},
+			want: services.ErrPlayNotScheduled},
+		{name: "overdue invoice paid",
+			setup: func(t *testing.T, e *env) {
+				inv := overdueInvoice(t, e, 10)
+				must(t, e.dunning.Run(ctx, e.now))
+				_, err := e.billing.RecordPayment(ctx, &models.Payment{InvoiceID: inv.ID, Amount: money.NewFromInt(1200)})
+				must(t, err)
+			}},
+		{name: "company deactivated",
+			setup: func(t *testing.T, e *env) {
+				_, err := e.companies.DeactivateCompany(ctx, demoCompanyID, "test", 0)
+				must(t, err)
+			},
+			want: services.ErrPlayNotScheduled},
+		{name: "company reactivated",
+			setup: func(t *testing.T, e *env) {
+				_, err := e.companies.DeactivateCompany(ctx, demoCompanyID, "test", 0)
+				must(t, err)
+				_, err = e.companies.ReactivateCompany(ctx, demoCompanyID, 0)
+				must(t, err)
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newEnv(t)
+			if tt.setup != nil {
+				tt.setup(t, e)
+			}
+			if err := e.play(10); !errors.Is(err, tt.want) {
+				t.Errorf("PlayEvent error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
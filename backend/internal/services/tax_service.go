@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/money"
+)
+
+var ErrNoTaxRate = errors.New("no tax rate for jurisdiction")
+
+// TaxService — ставки налога по юрисдикциям (RU, DE, US-CA ...)
+type TaxService struct {
+	rates TaxRateRepository
+	audit *AuditService
+}
+
+func NewTaxService(r TaxRateRepository, a *AuditService) *TaxService {
+	return &TaxService{rates: r, audit: a}
+}
+
+// NormalizeJurisdiction — "ru" → "RU", "us-ca" → "US-CA"
+func NormalizeJurisdiction(j string) string {
+	return strings.ToUpper(strings.TrimSpace(j))
+}
+
+// ---------- RATE FOR COMPANY ----------
+// RateFor — ставка в процентах для компании на дату. Освобождённые от налога
+// и компании без юрисдикции — 0; юрисдикция без ставки — ошибка (выставлять счёт без налога нельзя).
+func (s *TaxService) RateFor(ctx context.Context, b *models.CompanyBilling, on time.Time) (money.Decimal, error) {
+	if b.TaxExempt || b.TaxJurisdiction == "" {
+		return money.Zero, nil
+	}
+	t, err := s.rates.Find(ctx, b.TaxJurisdiction, on)
+	if errors.Is(err, sql.ErrNoRows) {
+		return money.Zero, fmt.Errorf("%w %s on %s", ErrNoTaxRate, b.TaxJurisdiction, on.Format("2006-01-02"))
+	}
+	if err != nil {
+		return money.Zero, err
+	}
+	return t.Rate, nil
+}
+
+// ---------- ADMIN ----------
+func (s *TaxService) Create(ctx context.Context, t *models.TaxRate) error {
+	t.Jurisdiction = NormalizeJurisdiction(t.Jurisdiction)
+	if t.Jurisdiction == "" {
+		return invalid("jurisdiction", "jurisdiction is required")
+	}
+	if t.Name == "" {
+		t.Name = "VAT"
+	}
+	if t.Rate.Sign() < 0 || t.Rate.Cmp(money.Hundred) > 0 {
+		return invalid("rate", "rate must be between 0 and 100")
+	}
+	if t.ValidFrom.IsZero() {
+		t.ValidFrom = time.Now()
+	}
+	t.ValidFrom = truncDay(t.ValidFrom)
+	if t.ValidTo != nil && !t.ValidTo.After(t.ValidFrom) {
+		return invalid("valid_to", "valid_to must be after valid_from")
+	}
+
+	if err := s.rates.Create(ctx, t); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "tax_rate.create", EntityType: "tax_rate", EntityID: t.ID,
+		After: t,
+	})
+	return nil
+}
+
+func (s *TaxService) List(ctx context.Context, jurisdiction string) ([]models.TaxRate, error) {
+	return s.rates.List(ctx, NormalizeJurisdiction(jurisdiction))
+}
+
+func (s *TaxService) Delete(ctx context.Context, id int64) error {
+	before, err := s.rates.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.rates.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditEvent{
+		Action: "tax_rate.delete", EntityType: "tax_rate", EntityID: id,
+		Before: before,
+	})
+	return nil
+}
@@ -3,34 +3,71 @@ package services
 import (
     "context"
     "mediawork/internal/models"
-    "mediawork/internal/repositories"
 )
 
 type UserService struct {
-    users *repositories.UserRepository
+    users   UserRepository
+    members CompanyMembershipRepository
+    audit   *AuditService
 }
 
-func NewUserService(users *repositories.UserRepository) *UserService {
-    return &UserService{users: users}
+func NewUserService(users UserRepository, members CompanyMembershipRepository, audit *AuditService) *UserService {
+    return &UserService{users: users, members: members, audit: audit}
 }
 
 //
 // ---------------- GET PROFILE ----------------
 //
-func (s *UserService) Profile(ctx context.Context, userID int64) (*models.User, error) {
-    return s.users.GetByID(ctx, userID)
+func (s *UserService) Profile(ctx context.Context, userID int64) (*models.UserProfile, error) {
+    u, err := s.users.GetByID(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    companies, err := s.members.ListUserMemberships(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    return &models.UserProfile{User: u, Companies: companies}, nil
 }
 
 //
 // ---------------- UPDATE ROLE (ADMIN) ----------------
 //
 func (s *UserService) UpdateRole(ctx context.Context, userID int64, role string) error {
-    return s.users.UpdateRole(ctx, userID, role)
+    before, err := s.users.GetByID(ctx, userID)
+    if err != nil {
+        return err
+    }
+    if err := s.users.UpdateRole(ctx, userID, role); err != nil {
+        return err
+    }
+
+    after := *before
+    after.Role = role
+    s.audit.Record(ctx, AuditEvent{
+        Action: "user.role_change", EntityType: "user", EntityID: userID,
+        Before: before, After: &after,
+    })
+    return nil
 }
 
 //
 // ---------------- UPDATE BASIC INFO ----------------
 //
 func (s *UserService) UpdateProfile(ctx context.Context, u *models.User) error {
-    return s.users.Update(ctx, u)
+    before, err := s.users.GetByID(ctx, u.ID)
+    if err != nil {
+        return err
+    }
+    if err := s.users.Update(ctx, u); err != nil {
+        return err
+    }
+
+    s.audit.Record(ctx, AuditEvent{
+        Action: "user.update", EntityType: "user", EntityID: u.ID,
+        Before: before, After: u,
+    })
+    return nil
 }
@@ -0,0 +1,660 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	mrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/repositories"
+	"mediawork/internal/tracing"
+)
+
+// Типы событий, на которые подписываются endpoints
+const (
+	EventCampaignStatusChanged = "campaign.status_changed"
+	EventInvoiceIssued         = "invoice.issued"
+	EventInvoicePaid           = "invoice.paid"
+	EventFacadeOffline         = "facade.offline"
+	EventPlayMilestone         = "play.milestone"
+
+	// тестовое событие — только на выбранный endpoint, подписка не нужна
+	EventWebhookTest = "webhook.test"
+)
+
+var WebhookEventTypes = []string{
+	EventCampaignStatusChanged,
+	EventInvoiceIssued,
+	EventInvoicePaid,
+	EventFacadeOffline,
+	EventPlayMilestone,
+}
+
+// Заголовки запроса доставки. Подпись: hex(HMAC-SHA256(secret, "<timestamp>.<body>")).
+const (
+	HeaderWebhookEvent     = "X-MediaWork-Event"
+	HeaderWebhookEventID   = "X-MediaWork-Event-ID"
+	HeaderWebhookDelivery  = "X-MediaWork-Delivery"
+	HeaderWebhookTimestamp = "X-MediaWork-Timestamp"
+	HeaderWebhookSignature = "X-MediaWork-Signature"
+)
+
+var (
+	ErrWebhookURL        = invalid("url", "url must be an absolute http(s) URL")
+	ErrWebhookPublicURL  = invalid("url", "url must point to a public address")
+	ErrWebhookEventTypes = invalid("event_types", "event_types must list known event types")
+)
+
+// WebhookConfig — политика доставки
+type WebhookConfig struct {
+	MaxAttempts   int           // после стольких неудач доставка failed
+	BaseBackoff   time.Duration // задержка после первой неудачи, дальше ×2
+	MaxBackoff    time.Duration
+	Timeout       time.Duration // на один HTTP-запрос
+	BatchSize     int           // доставок за один проход диспетчера
+	Workers       int
+	FacadeSilence time.Duration // без heartbeat дольше — facade.offline
+}
+
+// WebhookService — исходящие webhooks: события пишутся в outbox (webhook_events + webhook_deliveries)
+// и рассылаются диспетчером (задача webhook-dispatch) с подписью и экспоненциальными повторами.
+type WebhookService struct {
+	repo      WebhookRepository
+	members   CompanyMembershipRepository
+	facades   FacadeRepository
+	lifecycle CompanyLifecycleRepository
+	audit     *AuditService
+	client    *http.Client
+	cfg       WebhookConfig
+}
+
+// NewWebhookService — client можно подменить (локальная заглушка, прокси); nil — клиент только
+// на публичные адреса и без редиректов (newWebhookClient) с cfg.Timeout
+func NewWebhookService(
+	repo WebhookRepository,
+	members CompanyMembershipRepository,
+	facades FacadeRepository,
+	lifecycle CompanyLifecycleRepository,
+	audit *AuditService,
+	client *http.Client,
+	cfg WebhookConfig,
+) *WebhookService {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 30 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 6 * time.Hour
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.FacadeSilence <= 0 {
+		cfg.FacadeSilence = 5 * time.Minute
+	}
+	if client == nil {
+		client = newWebhookClient(cfg.Timeout)
+	}
+	return &WebhookService{
+		repo:      repo,
+		members:   members,
+		facades:   facades,
+		lifecycle: lifecycle,
+		audit:     audit,
+		client:    client,
+		cfg:       cfg,
+	}
+}
+
+// SignWebhook — подпись тела доставки (та же функция нужна получателю для проверки)
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook — проверка подписи на стороне получателя; tolerance защищает от повтора старых запросов
+func VerifyWebhook(secret string, timestamp int64, body []byte, signature string, tolerance time.Duration) bool {
+	if tolerance > 0 {
+		age := time.Since(time.Unix(timestamp, 0))
+		if age > tolerance || age < -tolerance {
+			return false
+		}
+	}
+	return hmac.Equal([]byte(SignWebhook(secret, timestamp, body)), []byte(signature))
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+//
+// ---------- ENDPOINTS ----------
+//
+// authorize — webhooks настраивают admin / owner компании или админ платформы
+func (s *WebhookService) authorize(ctx context.Context, companyID int64, actor *models.UserClaims) error {
+	return requireCompanyAdmin(ctx, s.members, companyID, actor)
+}
+
+// endpoint — endpoint компании companyID (чужой — как несуществующий)
+func (s *WebhookService) endpoint(ctx context.Context, companyID, id int64, actor *models.UserClaims) (*models.WebhookEndpoint, error) {
+	if err := s.authorize(ctx, companyID, actor); err != nil {
+		return nil, err
+	}
+	e, err := s.repo.GetEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e.CompanyID != companyID {
+		return nil, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func validateEndpoint(e *models.WebhookEndpoint) error {
+	u, err := url.Parse(strings.TrimSpace(e.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrWebhookURL
+	}
+	if !publicHost(u.Hostname()) {
+		return ErrWebhookPublicURL
+	}
+	e.URL = u.String()
+
+	if len(e.EventTypes) == 0 {
+		return ErrWebhookEventTypes
+	}
+	seen := map[string]bool{}
+	types := make([]string, 0, len(e.EventTypes))
+	for _, t := range e.EventTypes {
+		known := false
+		for _, k := range WebhookEventTypes {
+			known = known || k == t
+		}
+		if !known {
+			return fmt.Errorf("%w: unknown %q", ErrWebhookEventTypes, t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	e.EventTypes = types
+	return nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, companyID int64, actor *models.UserClaims) ([]models.WebhookEndpoint, error) {
+	if err := s.authorize(ctx, companyID, actor); err != nil {
+		return nil, err
+	}
+	list, err := s.repo.ListEndpoints(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Secret = ""
+	}
+	return list, nil
+}
+
+// CreateEndpoint — секрет генерируется здесь и возвращается один раз
+func (s *WebhookService) CreateEndpoint(ctx context.Context, e *models.WebhookEndpoint, actor *models.UserClaims) error {
+	if err := s.authorize(ctx, e.CompanyID, actor); err != nil {
+		return err
+	}
+	if err := validateEndpoint(e); err != nil {
+		return err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return err
+	}
+	e.Secret, e.IsActive = secret, true
+	e.CreatedBy = nullableID(actor.UserID)
+	if err := s.repo.CreateEndpoint(ctx, e); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "webhook.create", EntityType: "webhook_endpoint", EntityID: e.ID, CompanyID: e.CompanyID,
+		After: redactedEndpoint(e),
+	})
+	return nil
+}
+
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, e *models.WebhookEndpoint, actor *models.UserClaims) (*models.WebhookEndpoint, error) {
+	before, err := s.endpoint(ctx, e.CompanyID, e.ID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEndpoint(e); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateEndpoint(ctx, e); err != nil {
+		return nil, err
+	}
+	after, err := s.repo.GetEndpoint(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	after.Secret = ""
+	s.audit.Record(ctx, AuditEvent{
+		Action: "webhook.update", EntityType: "webhook_endpoint", EntityID: e.ID, CompanyID: e.CompanyID,
+		Before: redactedEndpoint(before), After: after,
+	})
+	return after, nil
+}
+
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, companyID, id int64, actor *models.UserClaims) error {
+	before, err := s.endpoint(ctx, companyID, id, actor)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteEndpoint(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditEvent{
+		Action: "webhook.delete", EntityType: "webhook_endpoint", EntityID: id, CompanyID: companyID,
+		Before: redactedEndpoint(before),
+	})
+	return nil
+}
+
+// RotateSecret — новый секрет действует сразу, в том числе для повторов старых доставок
+func (s *WebhookService) RotateSecret(ctx context.Context, companyID, id int64, actor *models.UserClaims) (*models.WebhookEndpoint, error) {
+	e, err := s.endpoint(ctx, companyID, id, actor)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetSecret(ctx, id, secret); err != nil {
+		return nil, err
+	}
+	e.Secret = secret
+
+	s.audit.Record(ctx, AuditEvent{
+		Action: "webhook.rotate_secret", EntityType: "webhook_endpoint", EntityID: id, CompanyID: companyID,
+	})
+	return e, nil
+}
+
+func redactedEndpoint(e *models.WebhookEndpoint) models.WebhookEndpoint {
+	c := *e
+	c.Secret = ""
+	return c
+}
+
+// Test ставит в очередь webhook.test на этот endpoint (проверка URL и подписи)
+func (s *WebhookService) Test(ctx context.Context, companyID, id int64, actor *models.UserClaims) (*models.WebhookEvent, error) {
+	e, err := s.endpoint(ctx, companyID, id, actor)
+	if err != nil {
+		return nil, err
+	}
+	ev, err := s.publish(ctx, companyID, EventWebhookTest, map[string]any{"endpoint_id": e.ID}, &e.ID)
+	if err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+//
+// ---------- DELIVERY LOG ----------
+//
+func (s *WebhookService) Deliveries(ctx context.Context, companyID, endpointID int64, status string, limit, offset int, actor *models.UserClaims) ([]models.WebhookDelivery, error) {
+	if _, err := s.endpoint(ctx, companyID, endpointID, actor); err != nil {
+		return nil, err
+	}
+	limit, offset = normalizePage(limit, offset)
+	return s.repo.ListDeliveries(ctx, endpointID, status, limit, offset)
+}
+
+// delivery — доставка endpoint-а компании
+func (s *WebhookService) delivery(ctx context.Context, companyID, endpointID, deliveryID int64, actor *models.UserClaims) (*models.WebhookDelivery, error) {
+	if _, err := s.endpoint(ctx, companyID, endpointID, actor); err != nil {
+		return nil, err
+	}
+	d, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if d.EndpointID != endpointID {
+		return nil, sql.ErrNoRows
+	}
+	return d, nil
+}
+
+func (s *WebhookService) Attempts(ctx context.Context, companyID, endpointID, deliveryID int64, actor *models.UserClaims) ([]models.WebhookAttempt, error) {
+	if _, err := s.delivery(ctx, companyID, endpointID, deliveryID, actor); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAttempts(ctx, deliveryID)
+}
+
+// Redeliver — ручная отправка прямо сейчас (в том числе уже failed / succeeded доставки).
+// Успех закрывает доставку; неудача не трогает расписание автоматических повторов.
+func (s *WebhookService) Redeliver(ctx context.Context, companyID, endpointID, deliveryID int64, actor *models.UserClaims) (*models.WebhookAttempt, error) {
+	d, err := s.delivery(ctx, companyID, endpointID, deliveryID, actor)
+	if err != nil {
+		return nil, err
+	}
+	a, err := s.deliver(ctx, d, true)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, AuditEvent{
+		Action: "webhook.redeliver", EntityType: "webhook_delivery", EntityID: d.ID, CompanyID: companyID,
+		After: a,
+	})
+	return a, nil
+}
+
+//
+// ---------- PUBLISH ----------
+//
+// Publish кладёт событие в outbox для всех подписанных endpoints компании.
+// Ошибка только логируется: событие — побочный эффект уже выполненного действия.
+func (s *WebhookService) Publish(ctx context.Context, companyID int64, eventType string, data any) {
+	if s == nil || companyID == 0 {
+		return
+	}
+	if _, err := s.publish(context.WithoutCancel(ctx), companyID, eventType, data, nil); err != nil {
+		slog.ErrorContext(ctx, "webhooks: publish failed", "event_type", eventType, "company_id", companyID, "err", err)
+	}
+}
+
+func (s *WebhookService) publish(ctx context.Context, companyID int64, eventType string, data any, endpointID *int64) (*models.WebhookEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	ev := &models.WebhookEvent{CompanyID: companyID, Type: eventType, Payload: payload}
+	if _, err := s.repo.Publish(ctx, ev, endpointID); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+//
+// ---------- DISPATCH ----------
+//
+// Dispatch — один проход диспетчера (задача webhook-dispatch): отправляет созревшие доставки
+func (s *WebhookService) Dispatch(ctx context.Context) error {
+	// аренда с запасом на худший случай: все запросы пачки упираются в таймаут
+	lease := s.cfg.Timeout * time.Duration(s.cfg.BatchSize/s.cfg.Workers+2)
+	due, err := s.repo.ClaimDue(ctx, s.cfg.BatchSize, lease)
+	if err != nil {
+		return err
+	}
+
+	queue := make(chan *models.WebhookDelivery)
+	var wg sync.WaitGroup
+	for i := 0; i < s.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range queue {
+				if _, err := s.deliver(ctx, d, false); err != nil {
+					slog.ErrorContext(ctx, "webhooks: delivery failed", "delivery_id", d.ID, "err", err)
+				}
+			}
+		}()
+	}
+	for i := range due {
+		queue <- &due[i]
+	}
+	close(queue)
+	wg.Wait()
+	return nil
+}
+
+// webhookBody — конверт события; одинаковый при каждой попытке
+func webhookBody(ev *models.WebhookEvent) ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":         "evt_" + strconv.FormatInt(ev.ID, 10),
+		"type":       ev.Type,
+		"company_id": ev.CompanyID,
+		"created_at": ev.CreatedAt.UTC(),
+		"data":       ev.Payload,
+	})
+}
+
+// backoff — задержка после attempts неудачных попыток: base·2^(n-1), не больше max, ±10%
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	d := s.cfg.BaseBackoff
+	for i := 1; i < attempts && d < s.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, s.cfg.MaxBackoff)
+	jitter := time.Duration(mrand.Int64N(int64(d)/5+1)) - d/10
+	return d + jitter
+}
+
+// deliver — одна попытка: подписанный POST, запись в журнал, новое состояние доставки.
+// Тело ответа не сохраняется: журнал виден компании, а ответ мог прийти не от её сервера.
+func (s *WebhookService) deliver(ctx context.Context, d *models.WebhookDelivery, manual bool) (*models.WebhookAttempt, error) {
+	body, err := webhookBody(d.Event)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &models.WebhookAttempt{DeliveryID: d.ID, Attempt: d.Attempts + 1, Manual: manual}
+	status, next := d.Status, d.NextAttemptAt
+
+	start := time.Now()
+	code, sendErr := s.send(ctx, d, body)
+	a.DurationMS = time.Since(start).Milliseconds()
+	if code != 0 {
+		a.StatusCode = &code
+	}
+
+	switch {
+	case sendErr == nil && code >= 200 && code < 300:
+		status, next = repositories.DeliverySucceeded, nil
+	case sendErr != nil:
+		a.Error = sendErr.Error()
+	default:
+		a.Error = fmt.Sprintf("unexpected status %d", code)
+	}
+
+	if a.Error != "" && !manual {
+		if a.Attempt >= s.cfg.MaxAttempts {
+			status, next = repositories.DeliveryFailed, nil
+		} else {
+			at := time.Now().Add(s.backoff(a.Attempt))
+			status, next = repositories.DeliveryPending, &at
+		}
+	}
+
+	if err := s.repo.RecordAttempt(context.WithoutCancel(ctx), a, status, next); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *WebhookService) send(ctx context.Context, d *models.WebhookDelivery, body []byte) (status int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, "webhook "+d.EventType, tracing.KindClient,
+		tracing.Attr{Key: "webhook.delivery_id", Value: d.ID},
+		tracing.Attr{Key: "webhook.endpoint_id", Value: d.EndpointID},
+	)
+	defer func() {
+		span.SetAttr("http.response.status_code", status)
+		span.RecordError(err)
+		span.End()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MediaWork-Webhooks/1.0")
+	req.Header.Set(HeaderWebhookEvent, d.EventType)
+	req.Header.Set(HeaderWebhookEventID, "evt_"+strconv.FormatInt(d.EventID, 10))
+	req.Header.Set(HeaderWebhookDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderWebhookSignature, SignWebhook(d.Endpoint.Secret, ts, body))
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// дочитываем немного, чтобы соединение вернулось в пул; само тело не нужно
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	return resp.StatusCode, nil
+}
+
+//
+// ---------- EVENT SOURCES ----------
+//
+func (s *WebhookService) CampaignStatusChanged(ctx context.Context, companyID, campaignID int64, from, to, reason string) {
+	if from == to {
+		return
+	}
+	s.Publish(ctx, companyID, EventCampaignStatusChanged, map[string]any{
+		"campaign_id": campaignID,
+		"from":        from,
+		"to":          to,
+		"reason":      reason,
+	})
+}
+
+// CampaignsSuspended — массовая смена статуса при приостановке / возобновлении компании
+func (s *WebhookService) CampaignsSuspended(ctx context.Context, susp *models.CompanySuspension, resumed bool) {
+	if s == nil || susp == nil {
+		return
+	}
+	list, err := s.lifecycle.SuspensionCampaigns(ctx, susp.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "webhooks: campaigns of suspension", "suspension_id", susp.ID, "err", err)
+		return
+	}
+	for _, c := range list {
+		if resumed {
+			// кампании, которые за время паузы трогали вручную, остались как есть
+			s.CampaignStatusChanged(ctx, susp.CompanyID, c.CampaignID, "paused", c.Status, susp.Kind+"_resumed")
+		} else {
+			s.CampaignStatusChanged(ctx, susp.CompanyID, c.CampaignID, c.PrevStatus, "paused", susp.Kind)
+		}
+	}
+}
+
+func (s *WebhookService) InvoiceIssued(ctx context.Context, inv *models.Invoice) {
+	s.Publish(ctx, inv.CompanyID, EventInvoiceIssued, invoiceEventData(inv))
+}
+
+func (s *WebhookService) InvoicePaid(ctx context.Context, inv *models.Invoice) {
+	s.Publish(ctx, inv.CompanyID, EventInvoicePaid, invoiceEventData(inv))
+}
+
+func invoiceEventData(inv *models.Invoice) map[string]any {
+	return map[string]any{
+		"invoice_id":          inv.ID,
+		"invoice_number":      inv.InvoiceNumber,
+		"document_type":       inv.DocumentType,
+		"original_invoice_id": inv.OriginalInvoiceID,
+		"status":              inv.Status,
+		"currency":            inv.Currency,
+		"amount_total":        inv.AmountTotal,
+		"amount_paid":         inv.AmountPaid,
+		"amount_credited":     inv.AmountCredited,
+		"due_date":            inv.DueDate,
+		"paid_at":             inv.PaidAt,
+	}
+}
+
+// isPlayMilestone — первый показ, затем 100, 1 000, 10 000, ...
+func isPlayMilestone(n int64) bool {
+	if n == 1 {
+		return true
+	}
+	if n < 100 {
+		return false
+	}
+	for n%10 == 0 {
+		n /= 10
+	}
+	return n == 1
+}
+
+// PlayRecorded считает показ кампании и публикует play.milestone на круглых числах
+func (s *WebhookService) PlayRecorded(ctx context.Context, ev *models.PlayEvent) {
+	if s == nil || ev.CampaignID == 0 {
+		return
+	}
+	plays, companyID, err := s.repo.CountPlay(ctx, ev.CampaignID)
+	if err != nil {
+		slog.ErrorContext(ctx, "webhooks: count play", "campaign_id", ev.CampaignID, "err", err)
+		return
+	}
+	if !isPlayMilestone(plays) {
+		return
+	}
+	s.Publish(ctx, companyID, EventPlayMilestone, map[string]any{
+		"campaign_id": ev.CampaignID,
+		"plays":       plays,
+		"facade_id":   ev.FacadeID,
+	})
+}
+
+// WatchFacades — задача facade-watchdog: статус фасадов по heartbeat;
+// о переходе в offline узнают компании с идущими на фасаде кампаниями
+func (s *WebhookService) WatchFacades(ctx context.Context) error {
+	changes, err := s.facades.SyncOnlineStatus(ctx, s.cfg.FacadeSilence)
+	if err != nil {
+		return err
+	}
+	for _, t := range changes {
+		if t.Status != "offline" {
+			continue
+		}
+		companies, err := s.repo.FacadeCompanies(ctx, t.FacadeID)
+		if err != nil {
+			return err
+		}
+		for _, companyID := range companies {
+			s.Publish(ctx, companyID, EventFacadeOffline, t)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mediawork/internal/models"
+	"mediawork/internal/repositories"
+	"mediawork/internal/services"
+)
+
+// webhooks — сервис webhooks поверх хранилища env; client nil — клиент доставки по умолчанию
+func (e *env) webhooks(client *http.Client, cfg services.WebhookConfig) *services.WebhookService {
+	r := e.repos
+	return services.NewWebhookService(r.Webhooks, r.Memberships, r.Facades, r.CompanyLifecycle, services.NewAuditService(r.Audit), client, cfg)
+}
+
+// receiver — клиент доставки, который соединяется с получателем h, какой бы хост ни был
+// в URL endpoint-а
+func receiver(t *testing.T, h http.HandlerFunc) *http.Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+		},
+	}}
+}
+
+func TestWebhookEndpointValidation(t *testing.T) {
+	e := newEnv(t)
+	svc := e.webhooks(nil, services.WebhookConfig{})
+	ctx := context.Background()
+
+	tests := []struct {
+		url, field string // field "" — endpoint создаётся
+	}{
+		{"https://hooks.example.com/mediawork", ""},
+		{"http://93.184.216.34:8080/hook", ""},
+		{"ftp://hooks.example.com/", "url"},
+		{"/relative", "url"},
+		{"http://localhost:8080/", "url"},
+		{"http://api.localhost/", "url"},
+		{"http://127.0.0.1/", "url"},
+		{"http://10.1.2.3/", "url"},
+		{"http://192.168.0.10/", "url"},
+		{"http://169.254.169.254/latest/meta-data/", "url"},
+		{"http://100.64.0.1/", "url"},
+		{"http://0.0.0.0/", "url"},
+		{"http://[::1]/", "url"},
+		{"http://[::ffff:127.0.0.1]/", "url"},
+		{"http://[fe80::1]/", "url"},
+		{"http://[64:ff9b::a00:1]/", "url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := svc.CreateEndpoint(ctx, &models.WebhookEndpoint{
+				CompanyID: demoCompanyID, URL: tt.url, EventTypes: []string{services.EventInvoicePaid},
+			}, platformAdmin)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("CreateEndpoint(%s): %v", tt.url, err)
+				}
+				return
+			}
+			if !isValidation(err, tt.field) {
+				t.Fatalf("CreateEndpoint(%s) = %v, want validation error on %s", tt.url, err, tt.field)
+			}
+		})
+	}
+
+	err := svc.CreateEndpoint(ctx, &models.WebhookEndpoint{
+		CompanyID: demoCompanyID, URL: "https://hooks.example.com/", EventTypes: []string{"invoice.deleted"},
+	}, platformAdmin)
+	if !isValidation(err, "event_types") {
+		t.Errorf("unknown event type: %v, want validation error on event_types", err)
+	}
+}
+
+// TestWebhookRoles — endpoints настраивает только admin компании и админ платформы
+func TestWebhookRoles(t *testing.T) {
+	e := newEnv(t)
+	svc := e.webhooks(nil, services.WebhookConfig{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		actor *models.UserClaims
+		want  error
+	}{
+		{"viewer", e.member(t, "viewer@example.com", "viewer"), services.ErrForbidden},
+		{"editor", e.member(t, "editor@example.com", "editor"), services.ErrForbidden},
+		{"admin", e.member(t, "admin@example.com", "admin"), nil},
+		{"not a member", e.member(t, "outsider@example.com", ""), services.ErrForbidden},
+		{"platform admin", platformAdmin, nil},
+		{"api key of the company", &models.UserClaims{APIKeyID: 1, CompanyID: demoCompanyID}, services.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := &models.WebhookEndpoint{CompanyID: demoCompanyID, URL: "https://hooks.example.com/", EventTypes: []string{services.EventInvoicePaid}}
+			if err := svc.CreateEndpoint(ctx, ep, tt.actor); !errors.Is(err, tt.want) {
+				t.Fatalf("CreateEndpoint = %v, want %v", err, tt.want)
+			}
+			if _, err := svc.ListEndpoints(ctx, demoCompanyID, tt.actor); !errors.Is(err, tt.want) {
+				t.Fatalf("ListEndpoints = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestWebhookDispatch — подписанная доставка; неудача — повтор по расписанию,
+// ручной повтор закрывает доставку
+func TestWebhookDispatch(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	var requests atomic.Int32
+	var secret string
+	client := receiver(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		ts, err := strconv.ParseInt(r.Header.Get(services.HeaderWebhookTimestamp), 10, 64)
+		if err != nil || !services.VerifyWebhook(secret, ts, body, r.Header.Get(services.HeaderWebhookSignature), time.Minute) {
+			t.Errorf("bad signature %q at %q", r.Header.Get(services.HeaderWebhookSignature), r.Header.Get(services.HeaderWebhookTimestamp))
+		}
+		if got := r.Header.Get(services.HeaderWebhookEvent); got != services.EventWebhookTest {
+			t.Errorf("%s = %q", services.HeaderWebhookEvent, got)
+		}
+		w.WriteHeader(int(status.Load()))
+	})
+	svc := e.webhooks(client, services.WebhookConfig{MaxAttempts: 2})
+
+	ep := &models.WebhookEndpoint{CompanyID: demoCompanyID, URL: "http://hooks.example.com/", EventTypes: []string{services.EventInvoicePaid}}
+	if err := svc.CreateEndpoint(ctx, ep, platformAdmin); err != nil {
+		t.Fatal(err)
+	}
+	secret = ep.Secret
+	if _, err := svc.Test(ctx, demoCompanyID, ep.ID, platformAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	delivery := func() models.WebhookDelivery {
+		t.Helper()
+		list, err := svc.Deliveries(ctx, demoCompanyID, ep.ID, "", 0, 0, platformAdmin)
+		if err != nil || len(list) != 1 {
+			t.Fatalf("deliveries = %v, %v", list, err)
+		}
+		return list[0]
+	}
+
+	// первая неудача — повтор по расписанию
+	if err := svc.Dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	d := delivery()
+	if d.Status != repositories.DeliveryPending || d.Attempts != 1 || d.NextAttemptAt == nil {
+		t.Fatalf("after 500: %+v", d)
+	}
+
+	// ручной повтор не расходует попытки; успех закрывает доставку
+	status.Store(http.StatusNoContent)
+	a, err := svc.Redeliver(ctx, demoCompanyID, ep.ID, d.ID, platformAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Manual || a.StatusCode == nil || *a.StatusCode != http.StatusNoContent {
+		t.Errorf("redelivery attempt = %+v", a)
+	}
+	if d := delivery(); d.Status != repositories.DeliverySucceeded || d.Attempts != 1 {
+		t.Errorf("after redelivery: %+v", d)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+// TestWebhookDeliveryFails — после MaxAttempts неудач доставка failed и больше не отправляется
+func TestWebhookDeliveryFails(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+
+	var requests atomic.Int32
+	client := receiver(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	svc := e.webhooks(client, services.WebhookConfig{MaxAttempts: 1})
+
+	ep := &models.WebhookEndpoint{CompanyID: demoCompanyID, URL: "http://hooks.example.com/", EventTypes: []string{services.EventInvoicePaid}}
+	if err := svc.CreateEndpoint(ctx, ep, platformAdmin); err != nil {
+		t.Fatal(err)
+	}
+	svc.Publish(ctx, demoCompanyID, services.EventInvoicePaid, map[string]any{"invoice_id": 1})
+	svc.Publish(ctx, demoCompanyID, services.EventFacadeOffline, map[string]any{"facade_id": 1}) // не подписан
+
+	for range 2 {
+		if err := svc.Dispatch(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := svc.Deliveries(ctx, demoCompanyID, ep.ID, "", 0, 0, platformAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Status != repositories.DeliveryFailed || list[0].LastError == "" {
+		t.Fatalf("deliveries = %+v, want one failed", list)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
+
+// TestWebhookDialer — клиент по умолчанию не соединяется с внутренними адресами, даже если
+// URL в базе (старая запись, DNS rebinding) на них указывает
+func TestWebhookDialer(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	t.Cleanup(srv.Close)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	svc := e.webhooks(nil, services.WebhookConfig{Timeout: 2 * time.Second})
+	for _, host := range []string{"127.0.0.1", "localhost"} {
+		t.Run(host, func(t *testing.T) {
+			ep := &models.WebhookEndpoint{CompanyID: demoCompanyID, URL: "https://hooks.example.com/", EventTypes: []string{services.EventInvoicePaid}}
+			if err := svc.CreateEndpoint(ctx, ep, platformAdmin); err != nil {
+				t.Fatal(err)
+			}
+			ep.URL = "http://" + net.JoinHostPort(host, port) + "/"
+			if err := e.repos.Webhooks.UpdateEndpoint(ctx, ep); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := svc.Test(ctx, demoCompanyID, ep.ID, platformAdmin); err != nil {
+				t.Fatal(err)
+			}
+			if err := svc.Dispatch(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			list, err := svc.Deliveries(ctx, demoCompanyID, ep.ID, "", 0, 0, platformAdmin)
+			if err != nil || len(list) != 1 {
+				t.Fatalf("deliveries = %v, %v", list, err)
+			}
+			if !strings.Contains(list[0].LastError, services.ErrWebhookAddress.Error()) {
+				t.Errorf("last error = %q, want %q", list[0].LastError, services.ErrWebhookAddress)
+			}
+		})
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("internal receiver got %d requests", n)
+	}
+}
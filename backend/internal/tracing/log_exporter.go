@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogExporter пишет законченные спаны в лог (TRACE_EXPORTER=log) — для отладки
+// без коллектора: видно, из чего состоит медленный запрос
+type LogExporter struct{}
+
+func (LogExporter) ExportSpan(d SpanData) {
+	attrs := []slog.Attr{
+		slog.String("trace_id", d.TraceID.String()),
+		slog.String("span_id", d.SpanID.String()),
+		slog.String("kind", d.Kind.String()),
+		slog.Float64("duration_ms", float64(d.End.Sub(d.Start).Microseconds())/1000),
+	}
+	if d.Parent.IsValid() {
+		attrs = append(attrs, slog.String("parent_id", d.Parent.String()))
+	}
+	if d.Status == StatusError {
+		attrs = append(attrs, slog.String("error", d.StatusMessage))
+	}
+	for _, a := range d.Attrs {
+		attrs = append(attrs, slog.Any(a.Key, a.Value))
+	}
+	slog.LogAttrs(context.Background(), slog.LevelInfo, "span "+d.Name, attrs...)
+}
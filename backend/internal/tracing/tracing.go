@@ -0,0 +1,272 @@
+// Package tracing — трассировка запросов и фоновых задач в модели OpenTelemetry:
+// 16-байтовый trace ID, 8-байтовый span ID, родитель, вид, атрибуты и статус спана.
+// Контекст передаётся заголовком W3C traceparent (входящие HTTP-запросы, исходящие webhooks).
+//
+// Законченные спаны уходят в Exporter. По умолчанию он ничего не делает: ID всё равно
+// генерируются и попадают в логи (trace_id), чтобы связать строки одного запроса.
+// Подключить OTLP-коллектор — реализовать Exporter поверх SDK и передать в SetExporter.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type TraceID [16]byte
+type SpanID [8]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (s SpanID) String() string  { return hex.EncodeToString(s[:]) }
+
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+func (s SpanID) IsValid() bool  { return s != SpanID{} }
+
+// SpanContext — то, что передаётся между процессами
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// SpanKind — как в OpenTelemetry
+type SpanKind int
+
+const (
+	KindInternal SpanKind = iota
+	KindServer
+	KindClient
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case KindServer:
+		return "server"
+	case KindClient:
+		return "client"
+	}
+	return "internal"
+}
+
+// StatusCode — Unset / Ok / Error, как в OpenTelemetry
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+// Attr — атрибут спана; значения — строки, числа и bool
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// SpanData — законченный спан для экспортёра
+type SpanData struct {
+	SpanContext
+	Parent        SpanID
+	Name          string
+	Kind          SpanKind
+	Start, End    time.Time
+	Attrs         []Attr
+	Status        StatusCode
+	StatusMessage string
+}
+
+//
+// ---------- EXPORTER ----------
+//
+// Exporter получает каждый законченный спан с Sampled = true. Вызывается синхронно
+// из End, поэтому реализация должна буферизовать отправку сама.
+type Exporter interface {
+	ExportSpan(SpanData)
+}
+
+// Noop — экспортёр по умолчанию
+type Noop struct{}
+
+func (Noop) ExportSpan(SpanData) {}
+
+type exporterBox struct{ Exporter }
+
+var current atomic.Value // exporterBox
+
+func init() {
+	current.Store(exporterBox{Noop{}})
+}
+
+// SetExporter меняет экспортёр (nil — обратно Noop). Новые спаны сэмплируются,
+// только если экспортёр не Noop или так решил вызывающий сервис (флаг в traceparent).
+func SetExporter(e Exporter) {
+	if e == nil {
+		e = Noop{}
+	}
+	current.Store(exporterBox{e})
+}
+
+func exporter() Exporter {
+	return current.Load().(exporterBox).Exporter
+}
+
+//
+// ---------- SPANS ----------
+//
+// Span — текущая операция. Методы безопасны для nil (трассировки нет — ничего не делают).
+type Span struct {
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+type ctxKey struct{}
+
+// Start открывает дочерний спан текущего (или корневой, если спана в контексте нет)
+func Start(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	parent := SpanContextFrom(ctx)
+	return start(ctx, parent, name, kind, attrs)
+}
+
+// StartRemote — спан входящего запроса: родитель из traceparent, если он есть
+func StartRemote(ctx context.Context, h http.Header, name string, attrs ...Attr) (context.Context, *Span) {
+	parent, _ := Extract(h)
+	return start(ctx, parent, name, KindServer, attrs)
+}
+
+func start(ctx context.Context, parent SpanContext, name string, kind SpanKind, attrs []Attr) (context.Context, *Span) {
+	s := &Span{data: SpanData{Name: name, Kind: kind, Start: time.Now(), Attrs: attrs}}
+	if parent.IsValid() {
+		s.data.TraceID = parent.TraceID
+		s.data.Parent = parent.SpanID
+		s.data.Sampled = parent.Sampled
+	} else {
+		rand.Read(s.data.TraceID[:])
+	}
+	if _, noop := exporter().(Noop); !noop {
+		s.data.Sampled = true
+	}
+	rand.Read(s.data.SpanID[:])
+	return context.WithValue(ctx, ctxKey{}, s), s
+}
+
+// FromContext — текущий спан или nil
+func FromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(ctxKey{}).(*Span)
+	return s
+}
+
+// SpanContextFrom — ID текущего спана (пустой, если трассировки нет)
+func SpanContextFrom(ctx context.Context) SpanContext {
+	if s := FromContext(ctx); s != nil {
+		return s.data.SpanContext
+	}
+	return SpanContext{}
+}
+
+// SetName — имя становится известно позже старта (маршрут chi — после роутинга)
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Name = name
+	s.mu.Unlock()
+}
+
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Attrs = append(s.data.Attrs, Attr{Key: key, Value: value})
+	s.mu.Unlock()
+}
+
+// RecordError помечает спан ошибочным; nil игнорируется
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.SetStatus(StatusError, err.Error())
+}
+
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Status = code
+	s.data.StatusMessage = message
+	s.mu.Unlock()
+}
+
+// End закрывает спан и отдаёт его экспортёру; повторный вызов ничего не делает
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.Sampled {
+		exporter().ExportSpan(data)
+	}
+}
+
+//
+// ---------- PROPAGATION ----------
+//
+// Inject дописывает traceparent текущего спана в заголовки исходящего запроса
+func Inject(ctx context.Context, h http.Header) {
+	sc := SpanContextFrom(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	h.Set("traceparent", fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags))
+}
+
+// Extract разбирает traceparent (version-traceid-spanid-flags); битый заголовок — как отсутствующий
+func Extract(h http.Header) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(h.Get("traceparent")), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	var sc SpanContext
+	if !decodeHex(parts[1], sc.TraceID[:]) || !decodeHex(parts[2], sc.SpanID[:]) || !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	var flags [1]byte
+	if !decodeHex(parts[3], flags[:]) {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, true
+}
+
+func decodeHex(s string, dst []byte) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
@@ -0,0 +1,81 @@
+package ws
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// closeWait — сколько ждать отправки close frame медленному клиенту
+const closeWait = time.Second
+
+// Conns — открытые соединения одной точки входа: учитываются в Connections и закрываются
+// с close frame при остановке сервера (http.Server.Shutdown hijacked-соединения не видит)
+type Conns struct {
+	endpoint string
+
+	mu      sync.Mutex
+	conns   map[*websocket.Conn]struct{}
+	closing bool
+}
+
+func NewConns(endpoint string) *Conns {
+	return &Conns{endpoint: endpoint, conns: make(map[*websocket.Conn]struct{})}
+}
+
+// Add — false, если сервер уже останавливается: соединение сразу закрывается с 1001
+func (c *Conns) Add(conn *websocket.Conn) bool {
+	c.mu.Lock()
+	if c.closing {
+		c.mu.Unlock()
+		CloseGoingAway(conn)
+		return false
+	}
+	c.conns[conn] = struct{}{}
+	c.mu.Unlock()
+
+	Connections.With(c.endpoint).Inc()
+	return true
+}
+
+func (c *Conns) Remove(conn *websocket.Conn) {
+	c.mu.Lock()
+	_, ok := c.conns[conn]
+	delete(c.conns, conn)
+	c.mu.Unlock()
+
+	if ok {
+		Connections.With(c.endpoint).Dec()
+	}
+}
+
+// CloseAll закрывает все соединения (1001 going away); новые после этого не принимаются
+func (c *Conns) CloseAll() {
+	c.mu.Lock()
+	c.closing = true
+	list := make([]*websocket.Conn, 0, len(c.conns))
+	for conn := range c.conns {
+		list = append(list, conn)
+	}
+	c.mu.Unlock()
+
+	for _, conn := range list {
+		CloseGoingAway(conn)
+		c.Remove(conn)
+	}
+}
+
+// CloseGoingAway — close frame 1001 (сервер уходит, клиенту переподключиться) и закрытие.
+// WriteControl можно вызывать параллельно с записью кадров из обработчика.
+func CloseGoingAway(conn *websocket.Conn) {
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(closeWait))
+	conn.Close()
+}
+
+// ClearDeadlines снимает дедлайны http.Server (read_timeout / write_timeout): после Upgrade
+// они остаются на соединении и оборвали бы долгий стрим через десятки секунд
+func ClearDeadlines(conn *websocket.Conn) {
+	conn.UnderlyingConn().SetDeadline(time.Time{})
+}
@@ -1,7 +1,7 @@
 package ws
 
 import (
-    "fmt"
+    "log/slog"
     "net/http"
     "strconv"
     "sync"
@@ -35,25 +35,31 @@ func (h *Hub) HandleFacadeWS(w http.ResponseWriter, r *http.Request) {
 
     conn, err := upgrader.Upgrade(w, r, nil)
     if err != nil {
-        fmt.Println("Upgrade error:", err)
+        slog.WarnContext(r.Context(), "ws: facade upgrade failed", "err", err)
         return
     }
+    ClearDeadlines(conn)
 
     h.mu.Lock()
     h.facadeClients[conn] = id
     total := len(h.facadeClients)
     h.mu.Unlock()
 
-    fmt.Printf("🎥 Facade client %d connected (%d total)\n", id, total)
+    Connections.With("hub_facade").Inc()
+    slog.InfoContext(r.Context(), "ws: facade client connected", "facade_id", id, "clients", total)
 
     for {
         _, _, err := conn.ReadMessage()
         if err != nil {
             h.mu.Lock()
+            _, open := h.facadeClients[conn]
             delete(h.facadeClients, conn)
             h.mu.Unlock()
             conn.Close()
-            fmt.Println("❌ Facade WS disconnected")
+            if open {
+                Connections.With("hub_facade").Dec()
+            }
+            slog.InfoContext(r.Context(), "ws: facade client disconnected", "facade_id", id)
             break
         }
     }
@@ -63,7 +69,7 @@ func (h *Hub) BroadcastToFacade(facadeID int, msg []byte) {
     h.mu.Lock()
     defer h.mu.Unlock()
 
-    fmt.Printf("📤 Broadcasting update to facade %d\n", facadeID)
+    slog.Debug("ws: broadcast to facade", "facade_id", facadeID)
 
     for c, id := range h.facadeClients {
         if id != facadeID {
@@ -71,9 +77,10 @@ func (h *Hub) BroadcastToFacade(facadeID int, msg []byte) {
         }
 
         if err := c.WriteMessage(websocket.TextMessage, msg); err != nil {
-            fmt.Println("❌ WS send failed:", err)
+            slog.Warn("ws: facade send failed", "facade_id", facadeID, "err", err)
             c.Close()
             delete(h.facadeClients, c)
+            Connections.With("hub_facade").Dec()
         }
     }
 }
@@ -82,24 +89,30 @@ func (h *Hub) BroadcastToFacade(facadeID int, msg []byte) {
 func (h *Hub) HandleMonitorWS(w http.ResponseWriter, r *http.Request) {
     conn, err := upgrader.Upgrade(w, r, nil)
     if err != nil {
-        fmt.Println("monitor upgrade error:", err)
+        slog.WarnContext(r.Context(), "ws: monitor upgrade failed", "err", err)
         return
     }
+    ClearDeadlines(conn)
 
     h.mu.Lock()
     h.monitorClients[conn] = true
     h.mu.Unlock()
 
-    fmt.Println("📡 Monitor connected")
+    Connections.With("hub_monitor").Inc()
+    slog.InfoContext(r.Context(), "ws: monitor connected")
 
     for {
         _, _, err := conn.ReadMessage()
         if err != nil {
             h.mu.Lock()
+            _, open := h.monitorClients[conn]
             delete(h.monitorClients, conn)
             h.mu.Unlock()
             conn.Close()
-            fmt.Println("📴 Monitor disconnected")
+            if open {
+                Connections.With("hub_monitor").Dec()
+            }
+            slog.InfoContext(r.Context(), "ws: monitor disconnected")
             break
         }
     }
@@ -111,9 +124,10 @@ func (h *Hub) BroadcastMonitor(msg []byte) {
 
     for c := range h.monitorClients {
         if err := c.WriteMessage(websocket.TextMessage, msg); err != nil {
-            fmt.Println("❌ Monitor WS error:", err)
+            slog.Warn("ws: monitor send failed", "err", err)
             c.Close()
             delete(h.monitorClients, c)
+            Connections.With("hub_monitor").Dec()
         }
     }
 }
@@ -0,0 +1,8 @@
+package ws
+
+import "mediawork/internal/metrics"
+
+// Connections — открытые WebSocket-соединения по точке входа:
+// facade_live (кадры фасада), hub_facade и hub_monitor (Hub)
+var Connections = metrics.NewGaugeVec("mediawork_ws_connections",
+	"Open WebSocket connections by endpoint.", "endpoint")
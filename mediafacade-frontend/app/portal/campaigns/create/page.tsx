@@ -3,7 +3,7 @@
 import { useEffect, useState } from "react";
 import { useRouter } from "next/navigation";
 import PageGuard from "@/components/RoleGuard";
-import { apiFetch } from "@/lib/apiClient";
+import { apiFetch, apiList } from "@/lib/apiClient";
 
 /* -------------------- TYPES -------------------- */
 
@@ -50,7 +50,7 @@ export default function CampaignCreatePage() {
       try {
         const [me, facades] = await Promise.all([
           apiFetch<MeResponse>("/me"),
-          apiFetch<Facade[]>("/facades"),
+          apiList<Facade>("/facades"),
         ]);
 
         setCompanyId(me.company_id);
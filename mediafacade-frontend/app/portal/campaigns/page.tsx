@@ -2,7 +2,7 @@
 
 import PageGuard from "@/components/RoleGuard";
 import { useEffect, useState } from "react";
-import { apiFetch } from "@/lib/apiClient";
+import { apiList } from "@/lib/apiClient";
 import { useRouter } from "next/navigation";
 
 type Campaign = {
@@ -23,7 +23,7 @@ export default function CampaignsPage() {
   >("all");
 
   useEffect(() => {
-    apiFetch("/campaigns")
+    apiList("/campaigns")
       .then((data) => setCampaigns(data || []))
       .catch((err) => console.error("Failed to load campaigns:", err))
       .finally(() => setLoading(false));
@@ -3,7 +3,7 @@
 import { useEffect, useState } from "react";
 import Link from "next/link";
 import PageGuard from "@/components/RoleGuard";
-import { apiFetch } from "@/lib/apiClient";
+import { apiFetch, apiList } from "@/lib/apiClient";
 
 /* ============================================================================================
    TYPES
@@ -97,9 +97,9 @@ export default function DashboardPage() {
     if (loadingUser) return;
 
     Promise.all([
-      apiFetch<Campaign[]>("/campaigns").catch(() => []),
-      apiFetch<Facade[]>("/facades").catch(() => []),
-      apiFetch<Invoice[]>("/invoices").catch(() => []),
+      apiList<Campaign>("/campaigns").catch(() => []),
+      apiList<Facade>("/facades").catch(() => []),
+      apiList<Invoice>("/invoices").catch(() => []),
     ])
       .then(([camps, facs, invs]) => {
         setCampaigns(camps);
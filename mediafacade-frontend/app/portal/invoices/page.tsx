@@ -2,7 +2,7 @@
 
 import PageGuard from "@/components/RoleGuard";
 import { useEffect, useState } from "react";
-import { apiFetch } from "@/lib/apiClient";
+import { apiFetch, apiList } from "@/lib/apiClient";
 
 type Invoice = {
   id: number;
@@ -34,7 +34,7 @@ export default function InvoicesPage() {
 
   /* ---------------- LOAD LIST FROM BACKEND ---------------- */
   useEffect(() => {
-    apiFetch("/invoices")
+    apiList("/invoices")
       .then((data) => setInvoices(data || []))
       .catch((err) => console.error("Failed to load invoices:", err));
   }, []);
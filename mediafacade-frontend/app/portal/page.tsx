@@ -3,7 +3,7 @@
 import Link from "next/link";
 import { useEffect, useState } from "react";
 import PageGuard from "@/components/RoleGuard";
-import { apiFetch } from "@/lib/apiClient";
+import { apiFetch, apiList } from "@/lib/apiClient";
 
 type ScreenId = "dashboard" | "campaigns" | "invoices" | "profile";
 
@@ -127,9 +127,9 @@ export default function PortalHomePage() {
         const [meRes, facadesRes, campaignsRes, invoicesRes] =
           await Promise.all([
             apiFetch<UserProfile>("/me"),
-            apiFetch<Facade[]>("/facades"),
-            apiFetch<Campaign[]>("/campaigns"),
-            apiFetch<Invoice[]>("/invoices"),
+            apiList<Facade>("/facades"),
+            apiList<Campaign>("/campaigns"),
+            apiList<Invoice>("/invoices"),
           ]);
 
         if (cancelled) return;
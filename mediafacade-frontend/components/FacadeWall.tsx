@@ -16,7 +16,7 @@ export default function FacadeWall({ facadeId }: Props) {
       try {
         const res = await fetch(`http://localhost:8080/api/facades`);
         const data = await res.json();
-        const facade = data.find((f: any) => f.id === facadeId);
+        const facade = data.items.find((f: any) => f.id === facadeId);
         if (facade?.current_content_url) {
           console.log("🎞 Loaded current content:", facade.current_content_url);
           setSrc(facade.current_content_url);
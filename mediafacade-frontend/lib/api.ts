@@ -2,7 +2,8 @@ export const API_URL = "http://localhost:5000/api";
 
 export async function getFacades() {
   const res = await fetch("http://localhost:8080/api/facades");
-  return res.json();
+  const page = await res.json();
+  return page.items;
 }
 
 export async function getFacade(id: number) {
@@ -27,3 +27,17 @@ export async function apiFetch<T = any>(
 
   return res.json() as Promise<T>;
 }
+
+// Списки API отдаются страницами по курсору
+export type Page<T> = {
+  items: T[];
+  next_cursor?: string;
+  prev_cursor?: string;
+  limit: number;
+};
+
+// apiList — элементы первой страницы списка (query — фильтры, sort, limit, cursor)
+export async function apiList<T = any>(path: string): Promise<T[]> {
+  const page = await apiFetch<Page<T>>(path);
+  return page.items;
+}